@@ -21,10 +21,13 @@ import (
 	"goyavision/internal/adapter/schema"
 	"goyavision/internal/api"
 	"goyavision/internal/app"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
 	infraeventbus "goyavision/internal/infra/eventbus"
 	infraauth "goyavision/internal/infra/auth"
 	infraengine "goyavision/internal/infra/engine"
 	inframediamtx "goyavision/internal/infra/mediamtx"
+	infranotify "goyavision/internal/infra/notify"
 	infrapersistence "goyavision/internal/infra/persistence"
 	"goyavision/internal/port"
 
@@ -60,7 +63,12 @@ func main() {
 
 	repo := persistence.NewRepository(db)
 	uow := infrapersistence.NewUnitOfWork(db)
-	eventBus := infraeventbus.NewLocalEventBus(100)
+	eventBus, closeEventBus, err := infraeventbus.New(context.Background(), cfg.EventBus, db, true)
+	if err != nil {
+		log.Fatalf("create event bus: %v", err)
+	}
+	defer closeEventBus()
+	log.Printf("event bus: driver=%s", cfg.EventBus.Driver)
 	mediaGateway := inframediamtx.NewGateway(
 		cfg.MediaMTX.APIAddress,
 		cfg.MediaMTX.Username,
@@ -68,6 +76,8 @@ func main() {
 		cfg.MediaMTX.RecordPath,
 		cfg.MediaMTX.RecordFormat,
 		cfg.MediaMTX.SegmentDuration,
+		cfg.MediaMTX.HookURL,
+		cfg.MediaMTX.HookToken,
 	)
 	tokenService, err := infraauth.NewJWTService(&cfg.JWT)
 	if err != nil {
@@ -117,6 +127,7 @@ func main() {
 	cryptoService, _ := adaptercrypto.NewAESCryptoService(encryptKey)
 
 	var workflowScheduler *app.WorkflowScheduler
+	var workflowEngine *infraengine.DAGWorkflowEngine
 	if db != nil {
 		ctx := context.Background()
 
@@ -131,8 +142,24 @@ func main() {
 		registry.Register(aiModelExecutor.Mode(), aiModelExecutor)
 		routingExecutor := engine.NewRoutingOperatorExecutor(registry)
 
-		workflowEngine := infraengine.NewDAGWorkflowEngine(uow, routingExecutor, schemaValidator)
-		workflowScheduler, err = app.NewWorkflowScheduler(repo, workflowEngine, eventBus)
+		workflowEngine = infraengine.NewDAGWorkflowEngine(uow, routingExecutor, schemaValidator)
+		workflowEngine.SetOperatorLimits(cfg.Queue.Operators)
+		if cfg.NodeCache.Enabled {
+			workflowEngine.EnableResultCache(cfg.NodeCache.TTL)
+		}
+		workflowEngine.EnableProgressCallback(cfg.Progress.CallbackBaseURL, []byte(encryptKey))
+		workflowEngine.SetEventBus(eventBus)
+		workflowEngine.SetArtifactStorage(fileStorage)
+
+		// queue.embedded=false 时任务只入队，由 cmd/worker 认领执行
+		var taskDispatcher *app.TaskDispatcher
+		if cfg.Queue.Embedded {
+			taskDispatcher = app.NewTaskDispatcher(repo, workflowEngine, eventBus, cfg.Queue)
+			taskDispatcher.Start(ctx)
+			defer taskDispatcher.Stop()
+		}
+
+		workflowScheduler, err = app.NewWorkflowScheduler(repo, taskDispatcher, eventBus)
 		if err != nil {
 			log.Fatalf("create workflow scheduler: %v", err)
 		}
@@ -142,6 +169,21 @@ func main() {
 		}
 		log.Print("workflow scheduler started (DAG engine)")
 		defer workflowScheduler.Stop()
+
+		// 通知投递与 webhook 媒体下载共用出站目标校验
+		urlGuard := infranotify.NewURLGuard(cfg.Notification.AllowedHosts)
+		notifyClient := urlGuard.Client(cfg.Notification.Timeout)
+		notificationDispatcher := app.NewNotificationDispatcher(uow, eventBus, cryptoService, map[notification.Channel]appport.Notifier{
+			notification.ChannelWebhook: infranotify.NewWebhookNotifier(notifyClient),
+			notification.ChannelChat:    infranotify.NewChatNotifier(notifyClient),
+			notification.ChannelEmail:   infranotify.NewSMTPNotifier(cfg.Notification.SMTP),
+		}, cfg.Notification)
+		notificationDispatcher.Start(ctx)
+		defer notificationDispatcher.Stop()
+
+		webhookMediaIngester := app.NewWebhookMediaIngester(uow, eventBus, fileStorage, infranotify.NewFetcher(urlGuard, 0), taskDispatcher)
+		webhookMediaIngester.Start()
+		defer webhookMediaIngester.Stop()
 	}
 
 	e := echo.New()
@@ -168,6 +210,11 @@ func main() {
 		eventBus,
 	)
 	api.RegisterRouter(e, handlers, webDist)
+	if workflowEngine != nil {
+		// 进度回调与异步算子回调以签名令牌鉴权，不经过 JWT
+		e.POST(infraengine.ProgressCallbackPath, echo.WrapHandler(workflowEngine.ProgressCallbackHandler()))
+		e.POST(infraengine.AsyncCallbackPath, echo.WrapHandler(workflowEngine.AsyncCallbackHandler()))
+	}
 
 	srv := &http.Server{Addr: cfg.Server.Addr(), Handler: e}
 	go func() {
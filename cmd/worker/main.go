@@ -0,0 +1,148 @@
+// Command worker 独立的任务执行进程：从数据库队列认领任务并通过 DAG 引擎执行。
+//
+// 与 cmd/server 共用配置；横向扩容时将 queue.embedded 设为 false，由一个或多个 worker 执行任务。
+// 表结构由 cmd/server 或 cmd/init 迁移，worker 不执行迁移。
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"goyavision/config"
+	adaptercrypto "goyavision/internal/adapter/crypto"
+	"goyavision/internal/adapter/engine"
+	mcpadapter "goyavision/internal/adapter/mcp"
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/adapter/schema"
+	adapterstorage "goyavision/internal/adapter/storage"
+	"goyavision/internal/app"
+	appport "goyavision/internal/app/port"
+	infraengine "goyavision/internal/infra/engine"
+	infraeventbus "goyavision/internal/infra/eventbus"
+	infrapersistence "goyavision/internal/infra/persistence"
+	"goyavision/internal/port"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	if cfg.DB.DSN == "" {
+		log.Fatal("db.dsn is required for worker")
+	}
+
+	driver := cfg.DB.Driver
+	if driver == "" {
+		driver = "postgres"
+	}
+	db, err := persistence.OpenDB(driver, cfg.DB.DSN)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	log.Printf("db connected: driver=%s", driver)
+
+	repo := persistence.NewRepository(db)
+	uow := infrapersistence.NewUnitOfWork(db)
+	schemaValidator := schema.NewJSONSchemaValidator()
+
+	mcpClient := mcpadapter.NewStaticClientWithoutDefaults()
+	for i := range cfg.MCP.Servers {
+		serverCfg := cfg.MCP.Servers[i]
+		tools := make([]port.MCPTool, 0, len(serverCfg.Tools))
+		for j := range serverCfg.Tools {
+			toolCfg := serverCfg.Tools[j]
+			tools = append(tools, port.MCPTool{
+				Name:         toolCfg.Name,
+				Description:  toolCfg.Description,
+				Version:      toolCfg.Version,
+				InputSchema:  toolCfg.InputSchema,
+				OutputSchema: toolCfg.OutputSchema,
+			})
+		}
+		mcpClient.RegisterServerWithConfig(port.MCPServer{
+			ID:          serverCfg.ID,
+			Name:        serverCfg.Name,
+			Description: serverCfg.Description,
+			Status:      serverCfg.Status,
+		}, tools, serverCfg.Endpoint, serverCfg.APIToken, serverCfg.TimeoutSec)
+	}
+
+	encryptKey := cfg.EncryptKey
+	if encryptKey == "" {
+		encryptKey = cfg.JWT.Secret
+	}
+	cryptoService, _ := adaptercrypto.NewAESCryptoService(encryptKey)
+
+	httpExecutor := engine.NewHTTPOperatorExecutor()
+	cliExecutor := engine.NewCLIOperatorExecutor()
+	mcpExecutor := engine.NewMCPOperatorExecutor(mcpClient)
+	aiModelExecutor := engine.NewAIModelExecutor(repo, cryptoService)
+	registry := engine.NewExecutorRegistry()
+	registry.Register(httpExecutor.Mode(), httpExecutor)
+	registry.Register(cliExecutor.Mode(), cliExecutor)
+	registry.Register(mcpExecutor.Mode(), mcpExecutor)
+	registry.Register(aiModelExecutor.Mode(), aiModelExecutor)
+	routingExecutor := engine.NewRoutingOperatorExecutor(registry)
+
+	workflowEngine := infraengine.NewDAGWorkflowEngine(uow, routingExecutor, schemaValidator)
+	workflowEngine.SetOperatorLimits(cfg.Queue.Operators)
+	if cfg.NodeCache.Enabled {
+		workflowEngine.EnableResultCache(cfg.NodeCache.TTL)
+	}
+	workflowEngine.EnableProgressCallback(cfg.Progress.CallbackBaseURL, []byte(encryptKey))
+
+	// 任务失败或取消后删除临时产物的文件
+	fileStorage, _, err := adapterstorage.NewFileStorageFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("create file storage: %v", err)
+	}
+	workflowEngine.SetArtifactStorage(fileStorage)
+
+	// worker 发布任务与节点事件，并以临时订阅接收任务取消事件。outbox 下中继只在持有租约的进程上分配序号，
+	// 各进程只向本进程的订阅投递；local 下事件不跨进程，取消由续约时检查任务状态发现
+	var eventBus appport.EventBus
+	if cfg.EventBus.Driver != "local" {
+		bus, closeEventBus, err := infraeventbus.New(context.Background(), cfg.EventBus, db, true)
+		if err != nil {
+			log.Fatalf("create event bus: %v", err)
+		}
+		defer closeEventBus()
+		eventBus = bus
+		workflowEngine.SetEventBus(eventBus)
+	}
+
+	// HTTP 算子的进度回调与异步作业回调需发送到执行任务的进程，worker 单独监听回调地址
+	var progressSrv *http.Server
+	if cfg.Progress.ListenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(infraengine.ProgressCallbackPath, workflowEngine.ProgressCallbackHandler())
+		mux.Handle(infraengine.AsyncCallbackPath, workflowEngine.AsyncCallbackHandler())
+		progressSrv = &http.Server{Addr: cfg.Progress.ListenAddr, Handler: mux}
+		go func() {
+			if err := progressSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("progress callback server: %v", err)
+			}
+		}()
+	}
+
+	taskDispatcher := app.NewTaskDispatcher(repo, workflowEngine, eventBus, cfg.Queue)
+	taskDispatcher.Start(context.Background())
+	log.Print("worker started")
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	taskDispatcher.Stop()
+	if progressSrv != nil {
+		_ = progressSrv.Close()
+	}
+	log.Print("worker stopped")
+}
@@ -25,9 +25,107 @@ type Config struct {
 	MCP        MCP
 	OAuth      OAuth
 	Payment    Payment
+	Queue      Queue
+	NodeCache  NodeCache
+	Progress   Progress
+	EventBus   EventBus
+	Notification Notification
 	EncryptKey string
 }
 
+// Queue 任务队列与并发限制，限制值 <= 0 表示不限制。
+// 并发限制按所有执行方（server 与 worker）合计，在数据库中校验
+type Queue struct {
+	MaxConcurrent  int           `mapstructure:"max_concurrent"`
+	MaxPerTenant   int           `mapstructure:"max_per_tenant"`
+	MaxPerWorkflow int           `mapstructure:"max_per_workflow"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+	// Tenants 按租户 ID 覆盖 MaxPerTenant
+	Tenants map[string]int `mapstructure:"tenants"`
+	// Workflows 按工作流编码覆盖 MaxPerWorkflow
+	Workflows map[string]int `mapstructure:"workflows"`
+	// Operators 按算子编码限制同时执行的算子调用数，如 object_detection: 4
+	Operators map[string]int `mapstructure:"operators"`
+	// Embedded cmd/server 是否在进程内执行任务（默认 true），设为 false 时仅由 cmd/worker 执行
+	Embedded bool `mapstructure:"embedded"`
+	// LeaseTTL 任务租约时长，执行方每 LeaseTTL/3 续约，失联超过该时长的任务会被回收重新派发
+	LeaseTTL time.Duration `mapstructure:"lease_ttl"`
+}
+
+// NodeCache 节点结果缓存，默认关闭；TTL 为 0 表示缓存项不过期，直到被清除
+type NodeCache struct {
+	Enabled bool          `mapstructure:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"`
+}
+
+// Progress 算子进度回调
+type Progress struct {
+	// CallbackBaseURL 算子可访问的本进程地址（如 http://goyavision:8080），为空时不向 HTTP 算子下发进度回调地址
+	CallbackBaseURL string `mapstructure:"callback_base_url"`
+	// ListenAddr cmd/worker 接收进度回调的监听地址，cmd/server 使用 API 端口
+	ListenAddr string `mapstructure:"listen_addr"`
+}
+
+// EventBus 事件总线
+type EventBus struct {
+	// Driver outbox（默认，事件随业务事务写入数据库，由中继至少一次投递）、local（进程内内存投递，不持久化）、
+	// nats（NATS JetStream）或 redis（Redis Streams）；outbox 在未配置数据库时退化为 local，
+	// nats/redis 同样先将事件随业务事务写入 outbox，再由转发确认送达消息系统后删除
+	Driver string `mapstructure:"driver"`
+	// PollInterval outbox 中继或 nats/redis 转发的轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// MaxAttempts 订阅方处理失败的最大尝试次数，超过后事件进入死信
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Retention 已投递事件在 outbox 中的保留时长
+	Retention time.Duration `mapstructure:"retention"`
+	NATS      EventBusNATS  `mapstructure:"nats"`
+	Redis     EventBusRedis `mapstructure:"redis"`
+}
+
+// EventBusNATS driver=nats 时的 NATS JetStream 配置
+type EventBusNATS struct {
+	URL           string        `mapstructure:"url"`
+	Stream        string        `mapstructure:"stream"`
+	SubjectPrefix string        `mapstructure:"subject_prefix"`
+	MaxAge        time.Duration `mapstructure:"max_age"`
+}
+
+// EventBusRedis driver=redis 时的 Redis Streams 配置
+type EventBusRedis struct {
+	Addr      string `mapstructure:"addr"`
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db"`
+	KeyPrefix string `mapstructure:"key_prefix"`
+	MaxLen    int64  `mapstructure:"max_len"`
+}
+
+// Notification 事件通知订阅的投递
+type Notification struct {
+	// PollInterval 投递循环轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// MaxAttempts 每次投递的最大尝试次数，失败后按 30s 起指数退避重试
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// DisableAfter 订阅连续多少次投递失败（重试耗尽）后自动停用
+	DisableAfter int `mapstructure:"disable_after"`
+	// Timeout 单次 HTTP 投递超时
+	Timeout time.Duration `mapstructure:"timeout"`
+	// AllowedHosts webhook、chat 渠道与入站 webhook 的 media_url 允许访问的内网主机名、IP 或 CIDR 网段；
+	// 其余解析到回环、私有、链路本地等地址的订阅地址在创建、更新与投递时被拒绝
+	AllowedHosts []string         `mapstructure:"allowed_hosts"`
+	SMTP         NotificationSMTP `mapstructure:"smtp"`
+}
+
+// NotificationSMTP email 渠道的 SMTP 配置，Host 为空时 email 渠道的投递失败
+type NotificationSMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// TLS 使用隐式 TLS（通常为 465 端口）；为 false 时服务器支持则使用 STARTTLS
+	TLS bool `mapstructure:"tls"`
+}
+
 type Payment struct {
 	Alipay AlipayConfig `mapstructure:"alipay"`
 	Wechat WechatConfig `mapstructure:"wechat"`
@@ -106,6 +204,10 @@ type MediaMTX struct {
 	SegmentDuration string
 	Username        string
 	Password        string
+	// HookURL MediaMTX 可访问的本服务地址（如 http://goyavision:8080），非空时为新建路径注册上线、下线与录制分段回调
+	HookURL string
+	// HookToken 回调请求的共享令牌，为空时回调端点不可用
+	HookToken string
 }
 
 type JWT struct {
@@ -246,6 +348,8 @@ func Load() (*Config, error) {
 			SegmentDuration: v.GetString("mediamtx.segment_duration"),
 			Username:        v.GetString("mediamtx.username"),
 			Password:        v.GetString("mediamtx.password"),
+			HookURL:         v.GetString("mediamtx.hook_url"),
+			HookToken:       v.GetString("mediamtx.hook_token"),
 		},
 		Storage: Storage{
 			Type: v.GetString("storage.type"),
@@ -314,6 +418,59 @@ func Load() (*Config, error) {
 	_ = v.UnmarshalKey("mcp", &cfg.MCP)
 	_ = v.UnmarshalKey("oauth", &cfg.OAuth)
 	_ = v.UnmarshalKey("payment", &cfg.Payment)
+	_ = v.UnmarshalKey("queue", &cfg.Queue)
+	if cfg.Queue.MaxConcurrent == 0 {
+		cfg.Queue.MaxConcurrent = 16
+	}
+	if cfg.Queue.PollInterval == 0 {
+		cfg.Queue.PollInterval = 2 * time.Second
+	}
+	if !v.IsSet("queue.embedded") {
+		cfg.Queue.Embedded = true
+	}
+	if cfg.Queue.LeaseTTL == 0 {
+		cfg.Queue.LeaseTTL = 30 * time.Second
+	}
+	_ = v.UnmarshalKey("node_cache", &cfg.NodeCache)
+	_ = v.UnmarshalKey("progress", &cfg.Progress)
+	_ = v.UnmarshalKey("event_bus", &cfg.EventBus)
+	if cfg.EventBus.Driver == "" {
+		cfg.EventBus.Driver = "outbox"
+	}
+	switch cfg.EventBus.Driver {
+	case "outbox", "local":
+	case "nats":
+		if cfg.EventBus.NATS.URL == "" {
+			return nil, fmt.Errorf("event_bus.driver=nats requires event_bus.nats.url")
+		}
+	case "redis":
+		if cfg.EventBus.Redis.Addr == "" {
+			return nil, fmt.Errorf("event_bus.driver=redis requires event_bus.redis.addr")
+		}
+	default:
+		return nil, fmt.Errorf("event_bus.driver must be one of: outbox, local, nats, redis")
+	}
+	_ = v.UnmarshalKey("notification", &cfg.Notification)
+	if cfg.Notification.PollInterval == 0 {
+		cfg.Notification.PollInterval = 5 * time.Second
+	}
+	if cfg.Notification.MaxAttempts == 0 {
+		cfg.Notification.MaxAttempts = 8
+	}
+	if cfg.Notification.DisableAfter == 0 {
+		cfg.Notification.DisableAfter = 5
+	}
+	if cfg.Notification.Timeout == 0 {
+		cfg.Notification.Timeout = 10 * time.Second
+	}
+	if cfg.Notification.SMTP.Host != "" {
+		if cfg.Notification.SMTP.Port == 0 {
+			cfg.Notification.SMTP.Port = 587
+		}
+		if cfg.Notification.SMTP.From == "" {
+			return nil, fmt.Errorf("notification.smtp.from is required when notification.smtp.host is set")
+		}
+	}
 	return cfg, nil
 }
 
@@ -342,6 +499,9 @@ func (c *Config) Validate() error {
 	if c.MediaMTX.RecordPath == "" || c.MediaMTX.RecordFormat == "" || c.MediaMTX.SegmentDuration == "" {
 		return fmt.Errorf("mediamtx record settings are required")
 	}
+	if c.MediaMTX.HookURL != "" && c.MediaMTX.HookToken == "" {
+		return fmt.Errorf("mediamtx.hook_token is required when mediamtx.hook_url is set")
+	}
 	stype := c.Storage.Type
 	if stype == "" {
 		stype = "minio"
@@ -43,13 +43,13 @@ func NewAIModelExecutor(repo port.Repository, crypto appport.CryptoService) *AIM
 
 func (e *AIModelExecutor) Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error) {
 	if version == nil {
-		return nil, fmt.Errorf("operator version is nil")
+		return nil, operator.InvalidError("operator version is nil")
 	}
 	if version.ExecMode != operator.ExecModeAIModel {
-		return nil, fmt.Errorf("ai_model executor does not support exec mode: %s", version.ExecMode)
+		return nil, operator.InvalidError("ai_model executor does not support exec mode: %s", version.ExecMode)
 	}
 	if version.ExecConfig == nil || version.ExecConfig.AIModel == nil {
-		return nil, fmt.Errorf("ai_model exec config is required")
+		return nil, operator.InvalidError("ai_model exec config is required")
 	}
 
 	cfg := version.ExecConfig.AIModel
@@ -59,7 +59,7 @@ func (e *AIModelExecutor) Execute(ctx context.Context, version *operator.Operato
 		return nil, fmt.Errorf("failed to resolve AI model: %w", err)
 	}
 	if !model.IsActive() {
-		return nil, fmt.Errorf("AI model %s is disabled", model.Name)
+		return nil, operator.InvalidError("AI model %s is disabled", model.Name)
 	}
 
 	if model.APIKey != "" && e.crypto != nil {
@@ -70,7 +70,7 @@ func (e *AIModelExecutor) Execute(ctx context.Context, version *operator.Operato
 
 	provider, ok := e.providers[model.Provider]
 	if !ok {
-		return nil, fmt.Errorf("unsupported AI provider: %s", model.Provider)
+		return nil, operator.InvalidError("unsupported AI provider: %s", model.Provider)
 	}
 
 	vars := BuildTemplateVars(input.AssetID.String(), input.Params, nil)
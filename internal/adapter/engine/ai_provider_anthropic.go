@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"goyavision/internal/domain/ai_model"
+	"goyavision/internal/domain/operator"
 )
 
 // AnthropicProvider handles Anthropic API calls.
@@ -101,7 +102,7 @@ func (p *AnthropicProvider) Chat(ctx context.Context, model *ai_model.AIModel, r
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody)))
 	}
 
 	var result anthropicResponse
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"goyavision/internal/domain/ai_model"
+	"goyavision/internal/domain/operator"
 )
 
 // OllamaProvider handles Ollama API calls.
@@ -92,7 +93,7 @@ func (p *OllamaProvider) Chat(ctx context.Context, model *ai_model.AIModel, req
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody)))
 	}
 
 	var result ollamaResponse
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"goyavision/internal/domain/ai_model"
+	"goyavision/internal/domain/operator"
 )
 
 // OpenAICompatProvider handles OpenAI-compatible APIs.
@@ -114,7 +115,7 @@ func (p *OpenAICompatProvider) Chat(ctx context.Context, model *ai_model.AIModel
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody)))
 	}
 
 	var result openAIResponse
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,18 +27,18 @@ func NewCLIOperatorExecutor() *CLIOperatorExecutor {
 
 func (e *CLIOperatorExecutor) Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error) {
 	if version == nil {
-		return nil, fmt.Errorf("operator version is nil")
+		return nil, operator.InvalidError("operator version is nil")
 	}
 	if version.ExecMode != operator.ExecModeCLI {
-		return nil, fmt.Errorf("cli executor does not support exec mode: %s", version.ExecMode)
+		return nil, operator.InvalidError("cli executor does not support exec mode: %s", version.ExecMode)
 	}
 	if version.ExecConfig == nil || version.ExecConfig.CLI == nil {
-		return nil, fmt.Errorf("cli exec config is required")
+		return nil, operator.InvalidError("cli exec config is required")
 	}
 
 	cliCfg := version.ExecConfig.CLI
 	if strings.TrimSpace(cliCfg.Command) == "" {
-		return nil, fmt.Errorf("cli command is required")
+		return nil, operator.InvalidError("cli command is required")
 	}
 
 	execCtx := ctx
@@ -64,17 +66,27 @@ func (e *CLIOperatorExecutor) Execute(ctx context.Context, version *operator.Ope
 	}
 	inputBytes, err := json.Marshal(input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal input: %w", err)
+		return nil, operator.InvalidError("failed to marshal input: %w", err)
 	}
 
 	cmd.Stdin = bytes.NewReader(inputBytes)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	progress := &progressWriter{ctx: ctx, out: &stderr}
+	cmd.Stderr = progress
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("cli command failed: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	err = cmd.Run()
+	progress.flush()
+	if err != nil {
+		runErr := fmt.Errorf("cli command failed: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+		switch {
+		case errors.Is(execCtx.Err(), context.DeadlineExceeded):
+			return nil, operator.NewExecError(operator.ErrorClassTimeout, runErr)
+		case errors.Is(err, exec.ErrNotFound):
+			return nil, operator.NewExecError(operator.ErrorClassInvalid, runErr)
+		}
+		return nil, operator.NewExecError(operator.ErrorClassUnknown, runErr)
 	}
 
 	out := strings.TrimSpace(stdout.String())
@@ -84,12 +96,68 @@ func (e *CLIOperatorExecutor) Execute(ctx context.Context, version *operator.Ope
 
 	var output operator.Output
 	if err := json.Unmarshal([]byte(out), &output); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cli output: %w", err)
+		return nil, operator.InvalidError("failed to unmarshal cli output: %w", err)
 	}
 
 	return &output, nil
 }
 
+// cliProgressPrefix 标准错误中的进度行前缀，格式为 "PROGRESS <比例> [说明]"，
+// 比例为 0~1 的小数或百分比（如 42%），例如 "PROGRESS 0.42 transcoding"
+const cliProgressPrefix = "PROGRESS "
+
+// progressWriter 逐行解析 CLI 标准错误：进度行上报给引擎，其余内容写入 out 用于错误信息
+type progressWriter struct {
+	ctx     context.Context
+	out     *bytes.Buffer
+	pending []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.handleLine(w.pending[:i+1])
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush 处理末尾没有换行符的内容
+func (w *progressWriter) flush() {
+	if len(w.pending) > 0 {
+		w.handleLine(w.pending)
+		w.pending = nil
+	}
+}
+
+func (w *progressWriter) handleLine(line []byte) {
+	text := strings.TrimSpace(string(line))
+	if !strings.HasPrefix(text, cliProgressPrefix) {
+		w.out.Write(line)
+		return
+	}
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(text, cliProgressPrefix)), " ", 2)
+	value := fields[0]
+	percent := strings.HasSuffix(value, "%")
+	fraction, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		w.out.Write(line)
+		return
+	}
+	if percent {
+		fraction /= 100
+	}
+	message := ""
+	if len(fields) > 1 {
+		message = strings.TrimSpace(fields[1])
+	}
+	operator.ReportProgress(w.ctx, fraction, message)
+}
+
 func (e *CLIOperatorExecutor) Mode() operator.ExecMode {
 	return operator.ExecModeCLI
 }
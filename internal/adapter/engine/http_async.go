@@ -0,0 +1,283 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+)
+
+const (
+	// asyncCancelTimeout 取消远端作业请求的超时
+	asyncCancelTimeout = 30 * time.Second
+	// asyncResponseLimit 提交与轮询响应体的大小上限
+	asyncResponseLimit = 16 << 20
+)
+
+// executeAsync 提交异步作业并等待结果
+//
+// poll 模式按退避间隔轮询状态地址；callback 模式等待引擎转交的回调，配置了状态地址时同样轮询，作为回调丢失的兜底。
+// 算子以 200 响应提交请求时按同步结果处理。等待期间 ctx 结束（任务取消、任务或节点超时）或超过 async.timeout_sec 时，
+// 按 cancel_url 取消远端作业。
+func (e *HTTPOperatorExecutor) executeAsync(ctx context.Context, httpCfg *operator.HTTPExecConfig, input *operator.Input) (*operator.Output, error) {
+	async := httpCfg.Async
+
+	header := http.Header{}
+	var callbacks <-chan *operator.AsyncCallbackRequest
+	if async.Mode == operator.HTTPAsyncModeCallback {
+		registrar := operator.AsyncCallbackRegistrarFromContext(ctx)
+		if registrar == nil {
+			return nil, operator.InvalidError("async callback mode requires progress.callback_base_url")
+		}
+		callbackURL, ch, release := registrar()
+		defer release()
+		callbacks = ch
+		header.Set(operator.CallbackURLHeader, callbackURL)
+	}
+
+	resp, err := e.submit(ctx, httpCfg, input, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return decodeOutput(resp.Body)
+	case http.StatusAccepted:
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("operator returned status %d: %s", resp.StatusCode, string(body)))
+	}
+
+	jobID, err := parseJobID(io.LimitReader(resp.Body, asyncResponseLimit), async.GetJobIDField())
+	if err != nil {
+		return nil, err
+	}
+	statusURL, err := jobStatusURL(httpCfg.Endpoint, async.StatusURL, resp.Header.Get("Location"), jobID)
+	if err != nil {
+		e.cancelJob(ctx, httpCfg, jobID)
+		return nil, err
+	}
+	if async.Mode == operator.HTTPAsyncModePoll && statusURL == "" {
+		e.cancelJob(ctx, httpCfg, jobID)
+		return nil, operator.InvalidError("async poll mode requires status_url or a Location header")
+	}
+
+	waitCtx := ctx
+	if async.TimeoutSec > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, time.Duration(async.TimeoutSec)*time.Second)
+		defer cancel()
+	}
+
+	output, err := e.waitJob(waitCtx, httpCfg, jobID, statusURL, callbacks)
+	if err != nil && waitCtx.Err() != nil {
+		e.cancelJob(ctx, httpCfg, jobID)
+		if ctx.Err() == nil {
+			return nil, operator.NewExecError(operator.ErrorClassTimeout,
+				fmt.Errorf("async job %s did not finish within %ds", jobID, async.TimeoutSec))
+		}
+	}
+	return output, err
+}
+
+// waitJob 等待作业结束，返回作业输出；ctx 结束时返回 ctx 的错误
+func (e *HTTPOperatorExecutor) waitJob(
+	ctx context.Context,
+	httpCfg *operator.HTTPExecConfig,
+	jobID, statusURL string,
+	callbacks <-chan *operator.AsyncCallbackRequest,
+) (*operator.Output, error) {
+	async := httpCfg.Async
+	polls := 0
+	for {
+		var timer *time.Timer
+		var pollC <-chan time.Time
+		if statusURL != "" {
+			timer = time.NewTimer(async.PollDelay(polls + 1))
+			pollC = timer.C
+		}
+
+		var status *operator.AsyncJobStatus
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, fmt.Errorf("async job %s: %w", jobID, ctx.Err())
+
+		case req := <-callbacks:
+			stopTimer(timer)
+			var err error
+			status, err = parseCallback(async, req)
+			req.Reply(err)
+			if err != nil {
+				continue
+			}
+
+		case <-pollC:
+			polls++
+			var err error
+			status, err = e.pollJob(ctx, httpCfg, statusURL)
+			if err != nil {
+				// 状态查询的临时故障不影响远端作业，继续轮询
+				switch operator.ClassifyError(err) {
+				case operator.ErrorClassTimeout, operator.ErrorClassServer, operator.ErrorClassRateLimited, operator.ErrorClassConnection:
+					continue
+				}
+				return nil, fmt.Errorf("async job %s: poll status: %w", jobID, err)
+			}
+		}
+
+		terminal, err := status.Terminal()
+		if err != nil {
+			return nil, operator.InvalidError("async job %s: %w", jobID, err)
+		}
+		if terminal {
+			return status.Result()
+		}
+		if status.Progress != nil {
+			operator.ReportProgress(ctx, *status.Progress, status.Message)
+		}
+	}
+}
+
+// pollJob 查询作业状态
+func (e *HTTPOperatorExecutor) pollJob(ctx context.Context, httpCfg *operator.HTTPExecConfig, statusURL string) (*operator.AsyncJobStatus, error) {
+	req, err := newOperatorRequest(ctx, httpCfg, http.MethodGet, statusURL, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, operator.NewExecError(operator.ClassifyError(err), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, asyncResponseLimit))
+	if err != nil {
+		return nil, operator.NewExecError(operator.ClassifyError(err), fmt.Errorf("failed to read status body: %w", err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("status returned %d: %s", resp.StatusCode, string(body)))
+	}
+
+	var status operator.AsyncJobStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, operator.InvalidError("failed to unmarshal job status: %w", err)
+	}
+	return &status, nil
+}
+
+// cancelJob 请求取消远端作业，失败时忽略（作业可能已结束）
+func (e *HTTPOperatorExecutor) cancelJob(ctx context.Context, httpCfg *operator.HTTPExecConfig, jobID string) {
+	if httpCfg.Async.CancelURL == "" {
+		return
+	}
+	// ctx 可能已被取消，取消请求使用独立的超时
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncCancelTimeout)
+	defer cancel()
+
+	cancelURL, err := resolveURL(httpCfg.Endpoint, operator.JobURL(httpCfg.Async.CancelURL, jobID))
+	if err != nil {
+		return
+	}
+	req, err := newOperatorRequest(ctx, httpCfg, httpCfg.Async.GetCancelMethod(), cancelURL, nil, nil)
+	if err != nil {
+		return
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, asyncResponseLimit))
+	resp.Body.Close()
+}
+
+// parseCallback 校验回调签名（配置了 callback_secret 时）并解析作业状态
+func parseCallback(async *operator.HTTPAsyncConfig, req *operator.AsyncCallbackRequest) (*operator.AsyncJobStatus, error) {
+	if async.CallbackSecret != "" {
+		timestamp := req.Header.Get(workflow.WebhookHeaderTimestamp)
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, errors.New("missing or invalid timestamp")
+		}
+		if d := time.Since(time.Unix(ts, 0)); d > workflow.DefaultWebhookToleranceSeconds*time.Second || d < -workflow.DefaultWebhookToleranceSeconds*time.Second {
+			return nil, errors.New("timestamp outside tolerance")
+		}
+		if !workflow.VerifyWebhookSignature(async.CallbackSecret, timestamp, req.Header.Get(workflow.WebhookHeaderNonce),
+			req.Body, req.Header.Get(workflow.WebhookHeaderSignature)) {
+			return nil, errors.New("invalid signature")
+		}
+	}
+
+	var status operator.AsyncJobStatus
+	if err := json.Unmarshal(req.Body, &status); err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	if _, err := status.Terminal(); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// parseJobID 从提交响应中读取作业 ID，字段可为字符串或数字
+func parseJobID(r io.Reader, field string) (string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", operator.NewExecError(operator.ClassifyError(err), fmt.Errorf("failed to read response body: %w", err))
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var resp map[string]interface{}
+	if err := dec.Decode(&resp); err != nil {
+		return "", operator.InvalidError("failed to unmarshal accepted response: %w", err)
+	}
+	switch id := resp[field].(type) {
+	case string:
+		if id != "" {
+			return id, nil
+		}
+	case json.Number:
+		return id.String(), nil
+	}
+	return "", operator.InvalidError("accepted response has no %s", field)
+}
+
+// jobStatusURL 返回作业状态地址：优先使用 status_url 模板，其次为 Location 头，相对地址按 endpoint 解析
+func jobStatusURL(endpoint, template, location, jobID string) (string, error) {
+	ref := location
+	if template != "" {
+		ref = operator.JobURL(template, jobID)
+	}
+	if ref == "" {
+		return "", nil
+	}
+	return resolveURL(endpoint, ref)
+}
+
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", operator.InvalidError("invalid endpoint: %w", err)
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", operator.InvalidError("invalid job url %q: %w", ref, err)
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
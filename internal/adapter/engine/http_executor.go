@@ -32,7 +32,7 @@ func NewHTTPOperatorExecutor() *HTTPOperatorExecutor {
 // Execute 执行算子版本
 func (e *HTTPOperatorExecutor) Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error) {
 	if version == nil {
-		return nil, fmt.Errorf("operator version is nil")
+		return nil, operator.InvalidError("operator version is nil")
 	}
 
 	if input == nil {
@@ -40,18 +40,40 @@ func (e *HTTPOperatorExecutor) Execute(ctx context.Context, version *operator.Op
 	}
 
 	if version.ExecMode != operator.ExecModeHTTP {
-		return nil, fmt.Errorf("http executor does not support exec mode: %s", version.ExecMode)
+		return nil, operator.InvalidError("http executor does not support exec mode: %s", version.ExecMode)
 	}
 
 	if version.ExecConfig == nil || version.ExecConfig.HTTP == nil {
-		return nil, fmt.Errorf("http exec config is required")
+		return nil, operator.InvalidError("http exec config is required")
 	}
 
 	httpCfg := version.ExecConfig.HTTP
 	if httpCfg.Endpoint == "" {
-		return nil, fmt.Errorf("http endpoint is required")
+		return nil, operator.InvalidError("http endpoint is required")
 	}
 
+	if httpCfg.Async != nil {
+		if err := httpCfg.Async.Validate(); err != nil {
+			return nil, operator.InvalidError("invalid async config: %w", err)
+		}
+		return e.executeAsync(ctx, httpCfg, input)
+	}
+
+	resp, err := e.submit(ctx, httpCfg, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("operator returned status %d: %s", resp.StatusCode, string(body)))
+	}
+	return decodeOutput(resp.Body)
+}
+
+// submit 以算子输入为请求体调用 endpoint
+func (e *HTTPOperatorExecutor) submit(ctx context.Context, httpCfg *operator.HTTPExecConfig, input *operator.Input, header http.Header) (*http.Response, error) {
 	method := httpCfg.Method
 	if method == "" {
 		method = http.MethodPost
@@ -59,15 +81,38 @@ func (e *HTTPOperatorExecutor) Execute(ctx context.Context, version *operator.Op
 
 	requestBody, err := json.Marshal(input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal input: %w", err)
+		return nil, operator.InvalidError("failed to marshal input: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, httpCfg.Endpoint, bytes.NewReader(requestBody))
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	if progressURL := operator.ProgressURLFromContext(ctx); progressURL != "" {
+		header.Set(operator.ProgressURLHeader, progressURL)
+	}
+	req, err := newOperatorRequest(ctx, httpCfg, method, httpCfg.Endpoint, bytes.NewReader(requestBody), header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, operator.NewExecError(operator.ClassifyError(err), fmt.Errorf("failed to execute request: %w", err))
+	}
+	return resp, nil
+}
+
+// newOperatorRequest 创建请求：依次设置 header、算子自定义请求头与认证信息，提交、轮询与取消作业共用
+func newOperatorRequest(ctx context.Context, httpCfg *operator.HTTPExecConfig, method, url string, body io.Reader, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, operator.InvalidError("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
 	}
 
-	req.Header.Set("Content-Type", "application/json")
 	for k, v := range httpCfg.Headers {
 		req.Header.Set(k, v)
 	}
@@ -88,26 +133,18 @@ func (e *HTTPOperatorExecutor) Execute(ctx context.Context, version *operator.Op
 	case "basic":
 		req.SetBasicAuth(httpCfg.AuthConfig["username"], httpCfg.AuthConfig["password"])
 	}
+	return req, nil
+}
 
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("operator returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
+func decodeOutput(r io.Reader) (*operator.Output, error) {
+	body, err := io.ReadAll(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, operator.NewExecError(operator.ClassifyError(err), fmt.Errorf("failed to read response body: %w", err))
 	}
 
 	var output operator.Output
 	if err := json.Unmarshal(body, &output); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
+		return nil, operator.InvalidError("failed to unmarshal output: %w", err)
 	}
 
 	return &output, nil
@@ -129,5 +166,10 @@ func (e *HTTPOperatorExecutor) HealthCheck(ctx context.Context, version *operato
 	if version.ExecConfig.HTTP.Endpoint == "" {
 		return fmt.Errorf("http endpoint is required")
 	}
+	if async := version.ExecConfig.HTTP.Async; async != nil {
+		if err := async.Validate(); err != nil {
+			return fmt.Errorf("invalid async config: %w", err)
+		}
+	}
 	return nil
 }
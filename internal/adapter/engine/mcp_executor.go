@@ -22,21 +22,21 @@ func NewMCPOperatorExecutor(client port.MCPClient) *MCPOperatorExecutor {
 
 func (e *MCPOperatorExecutor) Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error) {
 	if version == nil {
-		return nil, fmt.Errorf("operator version is nil")
+		return nil, operator.InvalidError("operator version is nil")
 	}
 	if version.ExecMode != operator.ExecModeMCP {
-		return nil, fmt.Errorf("mcp executor does not support exec mode: %s", version.ExecMode)
+		return nil, operator.InvalidError("mcp executor does not support exec mode: %s", version.ExecMode)
 	}
 	if e.client == nil {
-		return nil, fmt.Errorf("mcp client is not configured")
+		return nil, operator.InvalidError("mcp client is not configured")
 	}
 	if version.ExecConfig == nil || version.ExecConfig.MCP == nil {
-		return nil, fmt.Errorf("mcp exec config is required")
+		return nil, operator.InvalidError("mcp exec config is required")
 	}
 
 	mcpCfg := version.ExecConfig.MCP
 	if mcpCfg.ServerID == "" || mcpCfg.ToolName == "" {
-		return nil, fmt.Errorf("mcp server_id and tool_name are required")
+		return nil, operator.InvalidError("mcp server_id and tool_name are required")
 	}
 
 	args := map[string]interface{}{}
@@ -51,7 +51,7 @@ func (e *MCPOperatorExecutor) Execute(ctx context.Context, version *operator.Ope
 
 	result, err := e.client.CallTool(ctx, mcpCfg.ServerID, mcpCfg.ToolName, args)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call mcp tool: %w", err)
+		return nil, operator.NewExecError(operator.ClassifyError(err), fmt.Errorf("failed to call mcp tool: %w", err))
 	}
 
 	if len(result) == 0 {
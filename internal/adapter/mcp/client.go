@@ -1,10 +1,13 @@
 package mcp
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -12,6 +15,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"goyavision/internal/domain/operator"
 	"goyavision/internal/port"
 )
 
@@ -158,10 +162,15 @@ func (c *StaticClient) CallTool(ctx context.Context, serverID, toolName string,
 	if args == nil {
 		args = map[string]interface{}{}
 	}
-	result, err := c.callRPC(ctx, meta, "tools/call", map[string]interface{}{
+	params := map[string]interface{}{
 		"name":      toolName,
 		"arguments": args,
-	})
+	}
+	// 执行上下文接收进度时请求服务端发送 notifications/progress
+	if operator.HasProgressReporter(ctx) {
+		params["_meta"] = map[string]interface{}{"progressToken": atomic.AddInt64(&c.seq, 1)}
+	}
+	result, err := c.callRPC(ctx, meta, "tools/call", params)
 	if err != nil {
 		return nil, err
 	}
@@ -260,7 +269,7 @@ func (c *StaticClient) callRPC(ctx context.Context, meta serverMeta, method stri
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
 	if meta.APIToken != "" {
 		req.Header.Set("Authorization", "Bearer "+meta.APIToken)
 	}
@@ -271,11 +280,15 @@ func (c *StaticClient) callRPC(ctx context.Context, meta serverMeta, method stri
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("mcp request failed: method=%s status=%d", method, resp.StatusCode)
+		return nil, operator.HTTPStatusError(resp, fmt.Errorf("mcp request failed: method=%s status=%d", method, resp.StatusCode))
 	}
 
 	var rpcResp jsonRPCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		if err := readStreamResponse(ctx, resp.Body, &rpcResp); err != nil {
+			return nil, fmt.Errorf("decode mcp response failed: %w", err)
+		}
+	} else if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
 		return nil, fmt.Errorf("decode mcp response failed: %w", err)
 	}
 	if rpcResp.Error != nil {
@@ -314,6 +327,74 @@ func (c *StaticClient) notifyRPC(ctx context.Context, meta serverMeta, method st
 	return nil
 }
 
+// streamMessage Streamable HTTP 响应流中的 JSON-RPC 消息（响应或服务端通知）
+type streamMessage struct {
+	jsonRPCResponse
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type progressNotification struct {
+	Progress float64 `json:"progress"`
+	Total    float64 `json:"total"`
+	Message  string  `json:"message"`
+}
+
+// readStreamResponse 读取 text/event-stream 响应直到收到 JSON-RPC 响应，
+// 期间的 notifications/progress 通知转交给执行上下文中的进度接收方
+func readStreamResponse(ctx context.Context, body io.Reader, out *jsonRPCResponse) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var data strings.Builder
+	dispatch := func() (bool, error) {
+		if data.Len() == 0 {
+			return false, nil
+		}
+		var msg streamMessage
+		err := json.Unmarshal([]byte(data.String()), &msg)
+		data.Reset()
+		if err != nil {
+			return false, err
+		}
+		if msg.Method == "notifications/progress" {
+			var n progressNotification
+			if json.Unmarshal(msg.Params, &n) == nil && n.Total > 0 {
+				operator.ReportProgress(ctx, n.Progress/n.Total, n.Message)
+			}
+			return false, nil
+		}
+		if msg.Method != "" {
+			return false, nil
+		}
+		*out = msg.jsonRPCResponse
+		return true, nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if done, err := dispatch(); done || err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if done, err := dispatch(); done || err != nil {
+		return err
+	}
+	return errors.New("event stream ended without a response")
+}
+
 func parseMCPTools(result json.RawMessage) ([]port.MCPTool, error) {
 	var payload map[string]interface{}
 	if err := json.Unmarshal(result, &payload); err != nil {
@@ -47,6 +47,8 @@ type repository struct {
 	userIdentities *repo.UserIdentityRepo
 	systemConfigs  *repo.SystemConfigRepo
 	userAssets     *repo.UserAssetRepo
+	leaderLeases   *repo.LeaderLeaseRepo
+	scheduledRuns  *repo.ScheduledRunRepo
 }
 
 func NewRepository(db *gorm.DB) *repository {
@@ -67,6 +69,8 @@ func NewRepository(db *gorm.DB) *repository {
 		userIdentities: repo.NewUserIdentityRepo(db),
 		systemConfigs:  repo.NewSystemConfigRepo(db),
 		userAssets:     repo.NewUserAssetRepo(db),
+		leaderLeases:   repo.NewLeaderLeaseRepo(db),
+		scheduledRuns:  repo.NewScheduledRunRepo(db),
 	}
 }
 
@@ -96,8 +100,23 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.WorkflowModel{},
 		&model.WorkflowNodeModel{},
 		&model.WorkflowEdgeModel{},
+		&model.WorkflowRevisionModel{},
+		&model.WorkflowWebhookModel{},
+		&model.WebhookNonceModel{},
+		&model.WebhookDeliveryModel{},
 		&model.TaskModel{},
 		&model.ArtifactModel{},
+		&model.TaskCheckpointModel{},
+		&model.LeaderLeaseModel{},
+		&model.ScheduledRunModel{},
+		&model.LockModel{},
+		&model.OperatorSlotModel{},
+		&model.OutboxEventModel{},
+		&model.EventConsumerOffsetModel{},
+		&model.EventDeadLetterModel{},
+		&model.NotificationSubscriptionModel{},
+		&model.NotificationDeliveryModel{},
+		&model.NodeCacheEntryModel{},
 		&model.FileModel{},
 		&model.AIModelModel{},
 		&model.UserIdentityModel{},
@@ -588,6 +607,13 @@ func (r *repository) ListEnabledWorkflows(ctx context.Context) ([]*workflow.Work
 	return r.workflows.ListEnabled(ctx)
 }
 
+func (r *repository) UpdateWorkflowRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error {
+	if err := r.checkDB(); err != nil {
+		return err
+	}
+	return r.workflows.UpdateRunTimes(ctx, id, lastRunAt, nextRunAt)
+}
+
 // WorkflowNode methods
 func (r *repository) CreateWorkflowNode(ctx context.Context, n *workflow.Node) error {
 	if err := r.checkDB(); err != nil {
@@ -689,6 +715,119 @@ func (r *repository) ListRunningTasks(ctx context.Context) ([]*workflow.Task, er
 	return r.tasks.ListRunning(ctx)
 }
 
+func (r *repository) ListWaitingTasks(ctx context.Context) ([]*workflow.Task, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.ListWaiting(ctx)
+}
+
+func (r *repository) ListPendingTasks(ctx context.Context, limit int, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.ListPending(ctx, limit, limits)
+}
+
+func (r *repository) ListUnfinishedTasks(ctx context.Context) ([]*workflow.Task, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.ListUnfinished(ctx)
+}
+
+func (r *repository) ClaimTasks(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.Claim(ctx, ids, owner, ttl, limits)
+}
+
+func (r *repository) CountRunningTasks(ctx context.Context) (*workflow.RunningTaskCounts, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.CountRunning(ctx)
+}
+
+func (r *repository) RenewTaskLeases(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration) ([]uuid.UUID, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.tasks.RenewLeases(ctx, ids, owner, ttl)
+}
+
+func (r *repository) UpdateTaskFenced(ctx context.Context, t *workflow.Task, owner string) error {
+	if err := r.checkDB(); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tasks := repo.NewTaskRepo(tx)
+		if err := tasks.Fence(ctx, t.ID, owner); err != nil {
+			return err
+		}
+		return tasks.Update(ctx, t)
+	})
+}
+
+func (r *repository) ReclaimExpiredTasks(ctx context.Context) (int64, error) {
+	if err := r.checkDB(); err != nil {
+		return 0, err
+	}
+	return r.tasks.ReclaimExpired(ctx)
+}
+
+// LeaderLease methods
+func (r *repository) TryAcquireLeaderLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if err := r.checkDB(); err != nil {
+		return false, err
+	}
+	return r.leaderLeases.TryAcquire(ctx, name, holder, ttl)
+}
+
+// ScheduledRun methods
+func (r *repository) QueueScheduledRun(ctx context.Context, run *workflow.ScheduledRun) error {
+	if err := r.checkDB(); err != nil {
+		return err
+	}
+	return r.scheduledRuns.Queue(ctx, run)
+}
+
+func (r *repository) RecordMissedRuns(ctx context.Context, wf *workflow.Workflow, now time.Time, next *time.Time, runs []time.Time) (bool, error) {
+	if err := r.checkDB(); err != nil {
+		return false, err
+	}
+	return r.scheduledRuns.RecordMissed(ctx, wf, now, next, runs)
+}
+
+func (r *repository) ListScheduledRuns(ctx context.Context) ([]*workflow.ScheduledRun, error) {
+	if err := r.checkDB(); err != nil {
+		return nil, err
+	}
+	return r.scheduledRuns.List(ctx)
+}
+
+func (r *repository) StartScheduledRun(ctx context.Context, id uuid.UUID, task *workflow.Task) (bool, error) {
+	if err := r.checkDB(); err != nil {
+		return false, err
+	}
+	return r.scheduledRuns.Start(ctx, id, task)
+}
+
+func (r *repository) DeleteScheduledRun(ctx context.Context, id uuid.UUID) error {
+	if err := r.checkDB(); err != nil {
+		return err
+	}
+	return r.scheduledRuns.Delete(ctx, id)
+}
+
+func (r *repository) DeleteScheduledRunsByWorkflow(ctx context.Context, workflowID uuid.UUID) error {
+	if err := r.checkDB(); err != nil {
+		return err
+	}
+	return r.scheduledRuns.DeleteByWorkflow(ctx, workflowID)
+}
+
 // Artifact methods
 func (r *repository) CreateArtifact(ctx context.Context, a *workflow.Artifact) error {
 	if err := r.checkDB(); err != nil {
@@ -27,6 +27,8 @@ type ArtifactCreateReq struct {
 	Type    string                 `json:"type" validate:"required"`
 	AssetID *uuid.UUID             `json:"asset_id,omitempty"`
 	Data    map[string]interface{} `json:"data,omitempty"`
+	// Temporary 临时产物，任务失败、超时或被取消后自动删除
+	Temporary bool `json:"temporary,omitempty"`
 }
 
 // ArtifactResponse 产物响应
@@ -37,6 +39,7 @@ type ArtifactResponse struct {
 	AssetID   *uuid.UUID             `json:"asset_id,omitempty"`
 	Asset     *AssetResponse         `json:"asset,omitempty"`
 	Data      map[string]interface{} `json:"data,omitempty"`
+	Temporary bool                   `json:"temporary,omitempty"`
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
 }
@@ -67,6 +70,7 @@ func ArtifactToResponse(a *workflow.Artifact, minioEndpoint, minioBucket string,
 		Type:      string(a.Type),
 		AssetID:   a.AssetID,
 		Data:      data,
+		Temporary: a.Temporary,
 		CreatedAt: a.CreatedAt,
 		UpdatedAt: a.UpdatedAt,
 	}
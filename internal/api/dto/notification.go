@@ -0,0 +1,162 @@
+package dto
+
+import (
+	"time"
+
+	"goyavision/internal/domain/notification"
+
+	"github.com/google/uuid"
+)
+
+// NotificationSubscriptionCreateReq 创建事件通知订阅请求
+type NotificationSubscriptionCreateReq struct {
+	Name    string `json:"name" validate:"required"`
+	Channel string `json:"channel" validate:"required"`
+	// EventTypes 订阅的事件类型，["*"] 表示全部
+	EventTypes []string `json:"event_types" validate:"required"`
+	// URL webhook 与 chat 渠道的投递地址
+	URL string `json:"url,omitempty"`
+	// ChatFormat chat 渠道的消息格式：generic（默认）、slack、dingtalk、feishu、wecom
+	ChatFormat string `json:"chat_format,omitempty"`
+	// Recipients email 渠道的收件人
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// NotificationSubscriptionUpdateReq 更新事件通知订阅请求，enabled=true 重新启用并清零失败计数
+type NotificationSubscriptionUpdateReq struct {
+	Name       *string  `json:"name,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	URL        *string  `json:"url,omitempty"`
+	ChatFormat *string  `json:"chat_format,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+}
+
+// NotificationSubscriptionListQuery 列出事件通知订阅查询参数
+type NotificationSubscriptionListQuery struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// NotificationSubscriptionResponse 事件通知订阅响应，Secret 仅在创建或轮换时返回
+type NotificationSubscriptionResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	Name                string     `json:"name"`
+	Channel             string     `json:"channel"`
+	EventTypes          []string   `json:"event_types"`
+	URL                 string     `json:"url,omitempty"`
+	Secret              string     `json:"secret,omitempty"`
+	ChatFormat          string     `json:"chat_format,omitempty"`
+	Recipients          []string   `json:"recipients,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedBy           *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// NotificationSubscriptionListResponse 事件通知订阅列表响应
+type NotificationSubscriptionListResponse struct {
+	Items []*NotificationSubscriptionResponse `json:"items"`
+	Total int64                               `json:"total"`
+}
+
+// NotificationSubscriptionToResponse 转换为响应
+func NotificationSubscriptionToResponse(s *notification.Subscription, secret string) *NotificationSubscriptionResponse {
+	if s == nil {
+		return nil
+	}
+	return &NotificationSubscriptionResponse{
+		ID:                  s.ID,
+		Name:                s.Name,
+		Channel:             string(s.Channel),
+		EventTypes:          s.EventTypes,
+		URL:                 s.URL,
+		Secret:              secret,
+		ChatFormat:          string(s.ChatFormat),
+		Recipients:          s.Recipients,
+		Enabled:             s.Enabled,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		DisabledReason:      s.DisabledReason,
+		CreatedBy:           s.CreatedBy,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+// NotificationSubscriptionsToResponse 转换为响应列表
+func NotificationSubscriptionsToResponse(subs []*notification.Subscription) []*NotificationSubscriptionResponse {
+	result := make([]*NotificationSubscriptionResponse, len(subs))
+	for i, s := range subs {
+		result[i] = NotificationSubscriptionToResponse(s, "")
+	}
+	return result
+}
+
+// NotificationDeliveryListQuery 列出投递记录查询参数
+type NotificationDeliveryListQuery struct {
+	Status *string `query:"status"`
+	Limit  int     `query:"limit"`
+	Offset int     `query:"offset"`
+}
+
+// NotificationDeliveryResponse 投递记录响应
+type NotificationDeliveryResponse struct {
+	ID             uuid.UUID              `json:"id"`
+	SubscriptionID uuid.UUID              `json:"subscription_id"`
+	EventID        uuid.UUID              `json:"event_id"`
+	EventType      string                 `json:"event_type"`
+	Payload        map[string]interface{} `json:"payload"`
+	OccurredAt     int64                  `json:"occurred_at"`
+	Status         string                 `json:"status"`
+	Attempts       int                    `json:"attempts"`
+	NextAttemptAt  *time.Time             `json:"next_attempt_at,omitempty"`
+	LastError      string                 `json:"last_error,omitempty"`
+	ResponseStatus int                    `json:"response_status,omitempty"`
+	ResponseBody   string                 `json:"response_body,omitempty"`
+	ReplayOf       *uuid.UUID             `json:"replay_of,omitempty"`
+	DeliveredAt    *time.Time             `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// NotificationDeliveryListResponse 投递记录列表响应
+type NotificationDeliveryListResponse struct {
+	Items []*NotificationDeliveryResponse `json:"items"`
+	Total int64                           `json:"total"`
+}
+
+// NotificationDeliveryToResponse 转换为响应
+func NotificationDeliveryToResponse(d *notification.Delivery) *NotificationDeliveryResponse {
+	if d == nil {
+		return nil
+	}
+	return &NotificationDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		OccurredAt:     d.OccurredAt,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		ReplayOf:       d.ReplayOf,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+// NotificationDeliveriesToResponse 转换为响应列表
+func NotificationDeliveriesToResponse(deliveries []*notification.Delivery) []*NotificationDeliveryResponse {
+	result := make([]*NotificationDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = NotificationDeliveryToResponse(d)
+	}
+	return result
+}
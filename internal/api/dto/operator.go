@@ -80,6 +80,11 @@ type OperatorVersionActionReq struct {
 	VersionID uuid.UUID `json:"version_id" validate:"required"`
 }
 
+// NodeCacheInvalidateResponse 清除节点结果缓存响应
+type NodeCacheInvalidateResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
 type OperatorVersionListResponse struct {
 	Items []*OperatorVersionResponse `json:"items"`
 	Total int64                      `json:"total"`
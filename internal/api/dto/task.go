@@ -11,13 +11,15 @@ import (
 
 // TaskListQuery 列出任务查询参数
 type TaskListQuery struct {
-	WorkflowID *uuid.UUID `query:"workflow_id"`
-	AssetID    *uuid.UUID `query:"asset_id"`
-	Status     *string    `query:"status"`
-	From       *int64     `query:"from"`
-	To         *int64     `query:"to"`
-	Limit      int        `query:"limit"`
-	Offset     int        `query:"offset"`
+	WorkflowID   *uuid.UUID `query:"workflow_id"`
+	AssetID      *uuid.UUID `query:"asset_id"`
+	CallerTaskID *uuid.UUID `query:"caller_task_id"`
+	ParentTaskID *uuid.UUID `query:"parent_task_id"`
+	Status       *string    `query:"status"`
+	From         *int64     `query:"from"`
+	To           *int64     `query:"to"`
+	Limit        int        `query:"limit"`
+	Offset       int        `query:"offset"`
 }
 
 // TaskCreateReq 创建任务请求
@@ -25,6 +27,22 @@ type TaskCreateReq struct {
 	WorkflowID  uuid.UUID              `json:"workflow_id" validate:"required"`
 	AssetID     *uuid.UUID             `json:"asset_id,omitempty"`
 	InputParams map[string]interface{} `json:"input_params,omitempty"`
+	// Priority 队列优先级，越大越先执行，默认 0
+	Priority int `json:"priority,omitempty"`
+	// Deadline 任务截止时间，到期仍未完成则置为 timed_out
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// TaskRerunReq 重跑任务请求
+type TaskRerunReq struct {
+	// Mode full（默认）/ failed / from_node
+	Mode string `json:"mode,omitempty"`
+	// NodeKey from_node 模式的起始节点
+	NodeKey string `json:"node_key,omitempty"`
+	// InputParams 覆盖原任务同名输入参数
+	InputParams map[string]interface{} `json:"input_params,omitempty"`
+	// Priority 为空时沿用原任务优先级
+	Priority *int `json:"priority,omitempty"`
 }
 
 // TaskUpdateReq 更新任务请求
@@ -43,14 +61,73 @@ type NodeExecutionDTO struct {
 	StartedAt   *time.Time  `json:"started_at,omitempty"`
 	CompletedAt *time.Time  `json:"completed_at,omitempty"`
 	ArtifactIDs []uuid.UUID `json:"artifact_ids,omitempty"`
+
+	Items       []NodeItemExecutionDTO `json:"items,omitempty"`
+	ChildTaskID *uuid.UUID             `json:"child_task_id,omitempty"`
+	Approval    *NodeApprovalDTO       `json:"approval,omitempty"`
+	Attempts    []NodeAttemptDTO       `json:"attempts,omitempty"`
+	Progress    float64                `json:"progress,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+
+	Compensation *NodeCompensationDTO `json:"compensation,omitempty"`
+}
+
+// NodeCompensationDTO 节点补偿执行结果 DTO
+type NodeCompensationDTO struct {
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// NodeAttemptDTO 节点单次执行尝试 DTO
+type NodeAttemptDTO struct {
+	Attempt     int       `json:"attempt"`
+	Error       string    `json:"error,omitempty"`
+	ErrorClass  string    `json:"error_class,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	BackoffMs   int64     `json:"backoff_ms,omitempty"`
+}
+
+// NodeApprovalDTO 审批节点等待状态与决策 DTO
+type NodeApprovalDTO struct {
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+	Deadline    *time.Time             `json:"deadline,omitempty"`
+	Decision    string                 `json:"decision,omitempty"`
+	ApproverID  *uuid.UUID             `json:"approver_id,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	DecidedAt   *time.Time             `json:"decided_at,omitempty"`
+	AutoDecided bool                   `json:"auto_decided,omitempty"`
+}
+
+// ApprovalDecisionReq 审批通过/驳回请求，payload 仅在通过时替换流向下游的待审内容
+type ApprovalDecisionReq struct {
+	Comment string                 `json:"comment,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// NodeItemExecutionDTO 扇出节点单个元素执行状态 DTO
+type NodeItemExecutionDTO struct {
+	Index       int              `json:"index"`
+	Status      string           `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Attempts    []NodeAttemptDTO `json:"attempts,omitempty"`
 }
 
 // TaskResponse 任务响应
 type TaskResponse struct {
 	ID             uuid.UUID              `json:"id"`
 	WorkflowID     uuid.UUID              `json:"workflow_id"`
+	RevisionID     *uuid.UUID             `json:"revision_id,omitempty"`
 	AssetID        *uuid.UUID             `json:"asset_id,omitempty"`
+	CallerTaskID   *uuid.UUID             `json:"caller_task_id,omitempty"`
+	CallerNodeKey  string                 `json:"caller_node_key,omitempty"`
+	ParentTaskID   *uuid.UUID             `json:"parent_task_id,omitempty"`
 	Status         string                 `json:"status"`
+	Priority       int                    `json:"priority"`
 	Progress       int                    `json:"progress"`
 	CurrentNode    string                 `json:"current_node,omitempty"`
 	InputParams    map[string]interface{} `json:"input_params,omitempty"`
@@ -61,16 +138,26 @@ type TaskResponse struct {
 	CompletedAt    *time.Time             `json:"completed_at,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
+	// OperatorVersions 节点 key -> 开始执行时解析的算子版本 ID
+	OperatorVersions map[string]uuid.UUID `json:"operator_versions,omitempty"`
+	// Deadline 任务截止时间，SLABreachedAt 首次超过工作流 SLA 阈值的时间
+	Deadline      *time.Time `json:"deadline,omitempty"`
+	SLABreachedAt *time.Time `json:"sla_breached_at,omitempty"`
 }
 
 // TaskWithRelationsResponse 任务及关联数据响应
 type TaskWithRelationsResponse struct {
 	ID             uuid.UUID              `json:"id"`
 	WorkflowID     uuid.UUID              `json:"workflow_id"`
+	RevisionID     *uuid.UUID             `json:"revision_id,omitempty"`
 	Workflow       *WorkflowResponse      `json:"workflow,omitempty"`
 	AssetID        *uuid.UUID             `json:"asset_id,omitempty"`
 	Asset          *AssetResponse         `json:"asset,omitempty"`
+	CallerTaskID   *uuid.UUID             `json:"caller_task_id,omitempty"`
+	CallerNodeKey  string                 `json:"caller_node_key,omitempty"`
+	ParentTaskID   *uuid.UUID             `json:"parent_task_id,omitempty"`
 	Status         string                 `json:"status"`
+	Priority       int                    `json:"priority"`
 	Progress       int                    `json:"progress"`
 	CurrentNode    string                 `json:"current_node,omitempty"`
 	InputParams    map[string]interface{} `json:"input_params,omitempty"`
@@ -81,6 +168,11 @@ type TaskWithRelationsResponse struct {
 	CompletedAt    *time.Time             `json:"completed_at,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
+	// OperatorVersions 节点 key -> 开始执行时解析的算子版本 ID
+	OperatorVersions map[string]uuid.UUID `json:"operator_versions,omitempty"`
+	// Deadline 任务截止时间，SLABreachedAt 首次超过工作流 SLA 阈值的时间
+	Deadline      *time.Time `json:"deadline,omitempty"`
+	SLABreachedAt *time.Time `json:"sla_breached_at,omitempty"`
 }
 
 // TaskListResponse 任务列表响应
@@ -94,9 +186,12 @@ type TaskStatsResponse struct {
 	Total     int64 `json:"total"`
 	Pending   int64 `json:"pending"`
 	Running   int64 `json:"running"`
+	Waiting   int64 `json:"waiting"`
+	Queued    int64 `json:"queued"`
 	Success   int64 `json:"success"`
 	Failed    int64 `json:"failed"`
 	Cancelled int64 `json:"cancelled"`
+	TimedOut  int64 `json:"timed_out"`
 }
 
 func nodeExecutionsToDTOs(execs []workflow.NodeExecution) []NodeExecutionDTO {
@@ -112,6 +207,77 @@ func nodeExecutionsToDTOs(execs []workflow.NodeExecution) []NodeExecutionDTO {
 			StartedAt:   e.StartedAt,
 			CompletedAt: e.CompletedAt,
 			ArtifactIDs: e.ArtifactIDs,
+			Items:       nodeItemExecutionsToDTOs(e.Items),
+			ChildTaskID: e.ChildTaskID,
+			Approval:    nodeApprovalToDTO(e.Approval),
+			Attempts:    nodeAttemptsToDTOs(e.Attempts),
+			Progress:    e.Progress,
+			Message:     e.Message,
+
+			Compensation: nodeCompensationToDTO(e.Compensation),
+		}
+	}
+	return dtos
+}
+
+func nodeCompensationToDTO(c *workflow.NodeCompensation) *NodeCompensationDTO {
+	if c == nil {
+		return nil
+	}
+	return &NodeCompensationDTO{
+		Status:      string(c.Status),
+		Error:       c.Error,
+		StartedAt:   c.StartedAt,
+		CompletedAt: c.CompletedAt,
+	}
+}
+
+func nodeApprovalToDTO(a *workflow.NodeApproval) *NodeApprovalDTO {
+	if a == nil {
+		return nil
+	}
+	return &NodeApprovalDTO{
+		Payload:     a.Payload,
+		Deadline:    a.Deadline,
+		Decision:    string(a.Decision),
+		ApproverID:  a.ApproverID,
+		Comment:     a.Comment,
+		DecidedAt:   a.DecidedAt,
+		AutoDecided: a.AutoDecided,
+	}
+}
+
+func nodeItemExecutionsToDTOs(items []workflow.NodeItemExecution) []NodeItemExecutionDTO {
+	if len(items) == 0 {
+		return nil
+	}
+	dtos := make([]NodeItemExecutionDTO, len(items))
+	for i, item := range items {
+		dtos[i] = NodeItemExecutionDTO{
+			Index:       item.Index,
+			Status:      string(item.Status),
+			Error:       item.Error,
+			StartedAt:   item.StartedAt,
+			CompletedAt: item.CompletedAt,
+			Attempts:    nodeAttemptsToDTOs(item.Attempts),
+		}
+	}
+	return dtos
+}
+
+func nodeAttemptsToDTOs(attempts []workflow.NodeAttempt) []NodeAttemptDTO {
+	if len(attempts) == 0 {
+		return nil
+	}
+	dtos := make([]NodeAttemptDTO, len(attempts))
+	for i, a := range attempts {
+		dtos[i] = NodeAttemptDTO{
+			Attempt:     a.Attempt,
+			Error:       a.Error,
+			ErrorClass:  string(a.ErrorClass),
+			StartedAt:   a.StartedAt,
+			CompletedAt: a.CompletedAt,
+			BackoffMs:   a.BackoffMs,
 		}
 	}
 	return dtos
@@ -131,8 +297,13 @@ func TaskToResponse(t *workflow.Task) *TaskResponse {
 	return &TaskResponse{
 		ID:             t.ID,
 		WorkflowID:     t.WorkflowID,
+		RevisionID:     t.RevisionID,
 		AssetID:        t.AssetID,
+		CallerTaskID:   t.CallerTaskID,
+		CallerNodeKey:  t.CallerNodeKey,
+		ParentTaskID:   t.ParentTaskID,
 		Status:         string(t.Status),
+		Priority:       t.Priority,
 		Progress:       t.Progress,
 		CurrentNode:    t.CurrentNode,
 		InputParams:    inputParams,
@@ -143,6 +314,10 @@ func TaskToResponse(t *workflow.Task) *TaskResponse {
 		CompletedAt:    t.CompletedAt,
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
+
+		OperatorVersions: t.OperatorVersions,
+		Deadline:         t.Deadline,
+		SLABreachedAt:    t.SLABreachedAt,
 	}
 }
 
@@ -160,8 +335,13 @@ func TaskToResponseWithRelations(t *workflow.Task, workflow *workflow.Workflow,
 	resp := &TaskWithRelationsResponse{
 		ID:             t.ID,
 		WorkflowID:     t.WorkflowID,
+		RevisionID:     t.RevisionID,
 		AssetID:        t.AssetID,
+		CallerTaskID:   t.CallerTaskID,
+		CallerNodeKey:  t.CallerNodeKey,
+		ParentTaskID:   t.ParentTaskID,
 		Status:         string(t.Status),
+		Priority:       t.Priority,
 		Progress:       t.Progress,
 		CurrentNode:    t.CurrentNode,
 		InputParams:    inputParams,
@@ -172,6 +352,10 @@ func TaskToResponseWithRelations(t *workflow.Task, workflow *workflow.Workflow,
 		CompletedAt:    t.CompletedAt,
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
+
+		OperatorVersions: t.OperatorVersions,
+		Deadline:         t.Deadline,
+		SLABreachedAt:    t.SLABreachedAt,
 	}
 
 	if workflow != nil {
@@ -203,8 +387,11 @@ func TaskStatsToResponse(stats *workflow.TaskStats) *TaskStatsResponse {
 		Total:     stats.Total,
 		Pending:   stats.Pending,
 		Running:   stats.Running,
+		Waiting:   stats.Waiting,
+		Queued:    stats.Queued,
 		Success:   stats.Success,
 		Failed:    stats.Failed,
 		Cancelled: stats.Cancelled,
+		TimedOut:  stats.TimedOut,
 	}
 }
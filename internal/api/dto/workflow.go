@@ -33,9 +33,11 @@ type WorkflowCreateReq struct {
 	Edges          []WorkflowEdgeInput    `json:"edges,omitempty"`
 	Visibility     *int                   `json:"visibility,omitempty"`
 	VisibleRoleIDs []string               `json:"visible_role_ids,omitempty"`
+	// Timeouts 任务最长执行时间与 SLA 告警阈值
+	Timeouts *workflow.TimeoutConfig `json:"timeouts,omitempty"`
 }
 
-// WorkflowUpdateReq 更新工作流请求
+// WorkflowUpdateReq 更新工作流请求，Timeouts 整体替换，传空对象清除
 type WorkflowUpdateReq struct {
 	Name           *string                `json:"name,omitempty"`
 	Description    *string                `json:"description,omitempty"`
@@ -46,6 +48,8 @@ type WorkflowUpdateReq struct {
 	Edges          []WorkflowEdgeInput    `json:"edges,omitempty"`
 	Visibility     *int                   `json:"visibility,omitempty"`
 	VisibleRoleIDs []string               `json:"visible_role_ids,omitempty"`
+	// Timeouts 任务最长执行时间与 SLA 告警阈值
+	Timeouts *workflow.TimeoutConfig `json:"timeouts,omitempty"`
 }
 
 // WorkflowNodeInput 工作流节点输入
@@ -71,6 +75,8 @@ type WorkflowResponse struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description,omitempty"`
 	Version     string                 `json:"version"`
+	Revision    int                    `json:"revision"`
+	RevisionID  *uuid.UUID             `json:"revision_id,omitempty"`
 	TriggerType string                 `json:"trigger_type"`
 	TriggerConf    map[string]interface{} `json:"trigger_conf,omitempty"`
 	Status         string                 `json:"status"`
@@ -79,6 +85,11 @@ type WorkflowResponse struct {
 	VisibleRoleIDs []string               `json:"visible_role_ids,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
+	// Timeouts 任务最长执行时间与 SLA 告警阈值
+	Timeouts *workflow.TimeoutConfig `json:"timeouts,omitempty"`
+	// LastRunAt/NextRunAt 定时触发的上一次与下一次触发时间
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
 }
 
 // WorkflowWithNodesResponse 工作流及节点响应
@@ -88,6 +99,8 @@ type WorkflowWithNodesResponse struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description,omitempty"`
 	Version     string                 `json:"version"`
+	Revision    int                    `json:"revision"`
+	RevisionID  *uuid.UUID             `json:"revision_id,omitempty"`
 	TriggerType string                 `json:"trigger_type"`
 	TriggerConf    map[string]interface{} `json:"trigger_conf,omitempty"`
 	Status         string                 `json:"status"`
@@ -98,6 +111,11 @@ type WorkflowWithNodesResponse struct {
 	Edges          []WorkflowEdgeResponse `json:"edges"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
+	// Timeouts 任务最长执行时间与 SLA 告警阈值
+	Timeouts *workflow.TimeoutConfig `json:"timeouts,omitempty"`
+	// LastRunAt/NextRunAt 定时触发的上一次与下一次触发时间
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
 }
 
 // WorkflowNodeResponse 工作流节点响应
@@ -148,6 +166,8 @@ func WorkflowToResponse(w *workflow.Workflow) *WorkflowResponse {
 		Name:        w.Name,
 		Description: w.Description,
 		Version:     w.Version,
+		Revision:    w.Revision,
+		RevisionID:  w.RevisionID,
 		TriggerType:    string(w.TriggerType),
 		TriggerConf:    triggerConf,
 		Status:         string(w.Status),
@@ -156,6 +176,10 @@ func WorkflowToResponse(w *workflow.Workflow) *WorkflowResponse {
 		VisibleRoleIDs: w.VisibleRoleIDs,
 		CreatedAt:      w.CreatedAt,
 		UpdatedAt:      w.UpdatedAt,
+
+		Timeouts:  w.Timeouts,
+		LastRunAt: w.LastRunAt,
+		NextRunAt: w.NextRunAt,
 	}
 }
 
@@ -224,6 +248,8 @@ func WorkflowToResponseWithNodes(w *workflow.Workflow) *WorkflowWithNodesRespons
 		Name:        w.Name,
 		Description: w.Description,
 		Version:     w.Version,
+		Revision:    w.Revision,
+		RevisionID:  w.RevisionID,
 		TriggerType:    string(w.TriggerType),
 		TriggerConf:    triggerConf,
 		Status:         string(w.Status),
@@ -234,6 +260,10 @@ func WorkflowToResponseWithNodes(w *workflow.Workflow) *WorkflowWithNodesRespons
 		Edges:          edges,
 		CreatedAt:      w.CreatedAt,
 		UpdatedAt:      w.UpdatedAt,
+
+		Timeouts:  w.Timeouts,
+		LastRunAt: w.LastRunAt,
+		NextRunAt: w.NextRunAt,
 	}
 }
 
@@ -245,3 +275,167 @@ func WorkflowsToResponse(workflows []*workflow.Workflow) []*WorkflowResponse {
 	}
 	return result
 }
+
+// WorkflowRevisionListQuery 列出工作流修订查询参数
+type WorkflowRevisionListQuery struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// WorkflowRevisionDiffQuery 比较工作流修订查询参数
+type WorkflowRevisionDiffQuery struct {
+	From int `query:"from"`
+	To   int `query:"to"`
+}
+
+// WorkflowRevisionRestoreReq 恢复工作流修订请求
+type WorkflowRevisionRestoreReq struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// WorkflowPlanReq 工作流试运行请求，输入与触发任务时一致
+type WorkflowPlanReq struct {
+	AssetID     *uuid.UUID             `json:"asset_id,omitempty"`
+	InputParams map[string]interface{} `json:"input_params,omitempty"`
+	// FanOutItems 按节点 key 假定扇出节点的元素数，元素来自上游输出时用于估算
+	FanOutItems map[string]int `json:"fan_out_items,omitempty"`
+}
+
+// WorkflowRevisionResponse 工作流修订响应，列表中不返回节点与连线
+type WorkflowRevisionResponse struct {
+	ID         uuid.UUID               `json:"id"`
+	WorkflowID uuid.UUID               `json:"workflow_id"`
+	Revision   int                     `json:"revision"`
+	Hash       string                  `json:"hash"`
+	Comment    string                  `json:"comment,omitempty"`
+	CreatedBy  *uuid.UUID              `json:"created_by,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	Nodes      []workflow.RevisionNode `json:"nodes,omitempty"`
+	Edges      []workflow.RevisionEdge `json:"edges,omitempty"`
+}
+
+// WorkflowRevisionListResponse 工作流修订列表响应
+type WorkflowRevisionListResponse struct {
+	Items []*WorkflowRevisionResponse `json:"items"`
+	Total int64                       `json:"total"`
+}
+
+// WorkflowRevisionToResponse 转换为响应，withGraph 为 true 时包含节点与连线
+func WorkflowRevisionToResponse(r *workflow.Revision, withGraph bool) *WorkflowRevisionResponse {
+	if r == nil {
+		return nil
+	}
+	resp := &WorkflowRevisionResponse{
+		ID:         r.ID,
+		WorkflowID: r.WorkflowID,
+		Revision:   r.Revision,
+		Hash:       r.Hash,
+		Comment:    r.Comment,
+		CreatedBy:  r.CreatedBy,
+		CreatedAt:  r.CreatedAt,
+	}
+	if withGraph {
+		resp.Nodes = r.Nodes
+		resp.Edges = r.Edges
+	}
+	return resp
+}
+
+// WorkflowRevisionsToResponse 转换为响应列表
+func WorkflowRevisionsToResponse(revisions []*workflow.Revision) []*WorkflowRevisionResponse {
+	result := make([]*WorkflowRevisionResponse, len(revisions))
+	for i, r := range revisions {
+		result[i] = WorkflowRevisionToResponse(r, false)
+	}
+	return result
+}
+
+// WorkflowWebhookRotateReq 创建 webhook 或轮换签名密钥请求
+type WorkflowWebhookRotateReq struct {
+	// GraceSeconds 旧密钥继续有效的秒数，默认 86400，0 表示立即失效
+	GraceSeconds *int `json:"grace_seconds,omitempty"`
+}
+
+// WorkflowWebhookResponse 工作流 webhook 响应，Secret 仅在创建或轮换时返回
+type WorkflowWebhookResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	WorkflowID        uuid.UUID  `json:"workflow_id"`
+	URL               string     `json:"url"`
+	Secret            string     `json:"secret,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
+	RotatedAt         time.Time  `json:"rotated_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// WorkflowWebhookToResponse 转换为响应，旧密钥已过期时不返回其过期时间
+func WorkflowWebhookToResponse(w *workflow.Webhook, secret string) *WorkflowWebhookResponse {
+	if w == nil {
+		return nil
+	}
+	resp := &WorkflowWebhookResponse{
+		ID:         w.ID,
+		WorkflowID: w.WorkflowID,
+		URL:        "/api/v1/webhooks/" + w.ID.String(),
+		Secret:     secret,
+		CreatedBy:  w.CreatedBy,
+		RotatedAt:  w.RotatedAt,
+		CreatedAt:  w.CreatedAt,
+	}
+	if w.PreviousSecretValid(time.Now()) {
+		resp.PreviousExpiresAt = w.PreviousExpiresAt
+	}
+	return resp
+}
+
+// WebhookDeliveryListQuery 列出 webhook 投递记录查询参数
+type WebhookDeliveryListQuery struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// WebhookDeliveryResponse webhook 投递记录响应
+type WebhookDeliveryResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Nonce      string     `json:"nonce,omitempty"`
+	RemoteAddr string     `json:"remote_addr,omitempty"`
+	Body       string     `json:"body,omitempty"`
+	TaskID     *uuid.UUID `json:"task_id,omitempty"`
+	AssetID    *uuid.UUID `json:"asset_id,omitempty"`
+	ReceivedAt time.Time  `json:"received_at"`
+}
+
+// WebhookDeliveryListResponse webhook 投递记录列表响应
+type WebhookDeliveryListResponse struct {
+	Items []*WebhookDeliveryResponse `json:"items"`
+	Total int64                      `json:"total"`
+}
+
+// WebhookDeliveriesToResponse 转换为响应列表
+func WebhookDeliveriesToResponse(deliveries []*workflow.WebhookDelivery) []*WebhookDeliveryResponse {
+	result := make([]*WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = &WebhookDeliveryResponse{
+			ID:         d.ID,
+			Status:     string(d.Status),
+			Error:      d.Error,
+			Nonce:      d.Nonce,
+			RemoteAddr: d.RemoteAddr,
+			Body:       d.Body,
+			TaskID:     d.TaskID,
+			AssetID:    d.AssetID,
+			ReceivedAt: d.ReceivedAt,
+		}
+	}
+	return result
+}
+
+// WebhookReceiveResponse 入站 webhook 受理响应。Status 为 pending 时媒体在后台下载，任务随后创建
+type WebhookReceiveResponse struct {
+	DeliveryID uuid.UUID  `json:"delivery_id"`
+	Status     string     `json:"status"`
+	TaskID     *uuid.UUID `json:"task_id,omitempty"`
+	AssetID    *uuid.UUID `json:"asset_id,omitempty"`
+}
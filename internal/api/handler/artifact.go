@@ -90,6 +90,8 @@ func (h *artifactHandler) Create(c echo.Context) error {
 		Type:    workflow.ArtifactType(req.Type),
 		AssetID: req.AssetID,
 		Data:    req.Data,
+
+		Temporary: req.Temporary,
 	}
 
 	artifact, err := h.svc.Create(c.Request().Context(), createReq)
@@ -5,12 +5,15 @@ import (
 	"goyavision/internal/adapter/crypto"
 	"goyavision/internal/adapter/engine"
 	"goyavision/internal/adapter/payment"
+	"goyavision/internal/api/middleware"
 	"goyavision/internal/adapter/mediamtx"
 	"goyavision/internal/app"
 	"goyavision/internal/app/command"
 	appport "goyavision/internal/app/port"
 	"goyavision/internal/app/query"
 	infraauth "goyavision/internal/infra/auth"
+	infraengine "goyavision/internal/infra/engine"
+	infranotify "goyavision/internal/infra/notify"
 	"goyavision/internal/port"
 	"gorm.io/gorm"
 )
@@ -19,6 +22,7 @@ type Handlers struct {
 	CreateSource             *command.CreateSourceHandler
 	UpdateSource             *command.UpdateSourceHandler
 	DeleteSource             *command.DeleteSourceHandler
+	ReceiveMediaHook         *command.ReceiveMediaHookHandler
 	CreateAsset              *command.CreateAssetHandler
 	UpdateAsset              *command.UpdateAssetHandler
 	DeleteAsset              *command.DeleteAssetHandler
@@ -32,6 +36,7 @@ type Handlers struct {
 	ActivateVersion          *command.ActivateVersionHandler
 	RollbackVersion          *command.RollbackVersionHandler
 	ArchiveVersion           *command.ArchiveVersionHandler
+	InvalidateNodeCache      *command.InvalidateNodeCacheHandler
 	InstallTemplate          *command.InstallTemplateHandler
 	SetOperatorDependencies  *command.SetOperatorDependenciesHandler
 	PublishOperator          *command.PublishOperatorHandler
@@ -49,6 +54,14 @@ type Handlers struct {
 	UpdateWorkflow           *command.UpdateWorkflowHandler
 	DeleteWorkflow           *command.DeleteWorkflowHandler
 	EnableWorkflow           *command.EnableWorkflowHandler
+	RestoreWorkflowRevision  *command.RestoreWorkflowRevisionHandler
+	RotateWorkflowWebhook    *command.RotateWorkflowWebhookHandler
+	ReceiveWorkflowWebhook   *command.ReceiveWorkflowWebhookHandler
+	CreateNotificationSubscription *command.CreateNotificationSubscriptionHandler
+	UpdateNotificationSubscription *command.UpdateNotificationSubscriptionHandler
+	DeleteNotificationSubscription *command.DeleteNotificationSubscriptionHandler
+	RotateNotificationSecret       *command.RotateNotificationSecretHandler
+	ReplayNotificationDelivery     *command.ReplayNotificationDeliveryHandler
 	CreateTask               *command.CreateTaskHandler
 	UpdateTask               *command.UpdateTaskHandler
 	DeleteTask               *command.DeleteTaskHandler
@@ -56,6 +69,8 @@ type Handlers struct {
 	CompleteTask             *command.CompleteTaskHandler
 	FailTask                 *command.FailTaskHandler
 	CancelTask               *command.CancelTaskHandler
+	DecideApproval           *command.DecideApprovalHandler
+	RerunTask                *command.RerunTaskHandler
 	GetSource                *query.GetSourceHandler
 	ListSources              *query.ListSourcesHandler
 	GetAsset                 *query.GetAssetHandler
@@ -81,6 +96,15 @@ type Handlers struct {
 	GetWorkflowWithNodes     *query.GetWorkflowWithNodesHandler
 	GetWorkflowByCode        *query.GetWorkflowByCodeHandler
 	ListWorkflows            *query.ListWorkflowsHandler
+	ListWorkflowRevisions    *query.ListWorkflowRevisionsHandler
+	GetWorkflowRevision      *query.GetWorkflowRevisionHandler
+	DiffWorkflowRevisions    *query.DiffWorkflowRevisionsHandler
+	GetWorkflowWebhook       *query.GetWorkflowWebhookHandler
+	ListWebhookDeliveries    *query.ListWebhookDeliveriesHandler
+	GetNotificationSubscription   *query.GetNotificationSubscriptionHandler
+	ListNotificationSubscriptions *query.ListNotificationSubscriptionsHandler
+	ListNotificationDeliveries    *query.ListNotificationDeliveriesHandler
+	PlanWorkflow             *query.PlanWorkflowHandler
 	GetTask                  *query.GetTaskHandler
 	GetTaskWithRelations     *query.GetTaskWithRelationsHandler
 	ListTasks                *query.ListTasksHandler
@@ -98,6 +122,7 @@ type Handlers struct {
 	FileStorage       appport.FileStorage
 	StorageURLConfig  appport.StorageURLConfig
 	WorkflowScheduler *app.WorkflowScheduler
+	TaskEvents        *app.TaskEventStream
 	DB                       *gorm.DB
 	Repo                     port.Repository      // For middleware and non-migrated handlers
 	TokenService             appport.TokenService // For auth handlers
@@ -146,14 +171,24 @@ func NewHandlers(
 	executorRegistry.Register(mcpExecutor.Mode(), mcpExecutor)
 	executorRegistry.Register(aiModelExecutor.Mode(), aiModelExecutor)
 
+	// 试运行只解析执行计划，不需要算子执行器
+	planner := infraengine.NewDAGWorkflowEngine(uow, nil, schemaValidator)
+
 	paymentAdapter, _ := payment.NewGoPayAdapter(cfg.Payment)
+	notifyGuard := infranotify.NewURLGuard(cfg.Notification.AllowedHosts)
+
+	var taskEvents *app.TaskEventStream
+	if eventBus != nil {
+		taskEvents = app.NewTaskEventStream(eventBus)
+	}
 
 	return &Handlers{
-		CreateSource:             command.NewCreateSourceHandler(uow, mediaGateway),
+		CreateSource:             command.NewCreateSourceHandler(uow, mediaGateway, eventBus),
 		UpdateSource:             command.NewUpdateSourceHandler(uow, mediaGateway),
-		DeleteSource:             command.NewDeleteSourceHandler(uow, mediaGateway),
+		DeleteSource:             command.NewDeleteSourceHandler(uow, mediaGateway, eventBus),
+		ReceiveMediaHook:         command.NewReceiveMediaHookHandler(uow, eventBus),
 		CreateAsset:              command.NewCreateAssetHandler(uow, eventBus),
-		UpdateAsset:              command.NewUpdateAssetHandler(uow),
+		UpdateAsset:              command.NewUpdateAssetHandler(uow, eventBus),
 		DeleteAsset:              command.NewDeleteAssetHandler(uow),
 		Login:                    command.NewLoginHandler(uow, tokenService),
 		LoginOAuth:               command.NewLoginOAuthHandler(uow, tokenService, authProviderFactory, userService),
@@ -162,13 +197,14 @@ func NewHandlers(
 		UpdateOperator:           command.NewUpdateOperatorHandler(uow),
 		DeleteOperator:           command.NewDeleteOperatorHandler(uow),
 		CreateOperatorVersion:    command.NewCreateOperatorVersionHandler(uow, schemaValidator),
-		ActivateVersion:          command.NewActivateVersionHandler(uow),
-		RollbackVersion:          command.NewRollbackVersionHandler(uow),
+		ActivateVersion:          command.NewActivateVersionHandler(uow, eventBus),
+		RollbackVersion:          command.NewRollbackVersionHandler(uow, eventBus),
 		ArchiveVersion:           command.NewArchiveVersionHandler(uow),
+		InvalidateNodeCache:      command.NewInvalidateNodeCacheHandler(uow),
 		InstallTemplate:          command.NewInstallTemplateHandler(uow),
 		SetOperatorDependencies:  command.NewSetOperatorDependenciesHandler(uow),
 		PublishOperator:          command.NewPublishOperatorHandler(uow, mcpClient, schemaValidator),
-		DeprecateOperator:        command.NewDeprecateOperatorHandler(uow),
+		DeprecateOperator:        command.NewDeprecateOperatorHandler(uow, eventBus),
 		TestOperator:             command.NewTestOperatorHandler(uow, executorRegistry),
 		InstallMCPOperator:       command.NewInstallMCPOperatorHandler(uow, mcpClient),
 		SyncMCPTemplates:         command.NewSyncMCPTemplatesHandler(uow, mcpClient),
@@ -181,14 +217,24 @@ func NewHandlers(
 		CreateWorkflow:           command.NewCreateWorkflowHandler(uow, schemaValidator),
 		UpdateWorkflow:           command.NewUpdateWorkflowHandler(uow, schemaValidator),
 		DeleteWorkflow:           command.NewDeleteWorkflowHandler(uow),
-		EnableWorkflow:           command.NewEnableWorkflowHandler(uow),
+		EnableWorkflow:           command.NewEnableWorkflowHandler(uow, eventBus),
+		RestoreWorkflowRevision:  command.NewRestoreWorkflowRevisionHandler(uow),
+		RotateWorkflowWebhook:    command.NewRotateWorkflowWebhookHandler(uow, cryptoService),
+		ReceiveWorkflowWebhook:   command.NewReceiveWorkflowWebhookHandler(uow, cryptoService, notifyGuard, eventBus, middleware.ContextWithIdentity),
+		CreateNotificationSubscription: command.NewCreateNotificationSubscriptionHandler(uow, cryptoService, notifyGuard),
+		UpdateNotificationSubscription: command.NewUpdateNotificationSubscriptionHandler(uow, notifyGuard),
+		DeleteNotificationSubscription: command.NewDeleteNotificationSubscriptionHandler(uow),
+		RotateNotificationSecret:       command.NewRotateNotificationSecretHandler(uow, cryptoService),
+		ReplayNotificationDelivery:     command.NewReplayNotificationDeliveryHandler(uow),
 		CreateTask:               command.NewCreateTaskHandler(uow),
 		UpdateTask:               command.NewUpdateTaskHandler(uow),
 		DeleteTask:               command.NewDeleteTaskHandler(uow),
-		StartTask:                command.NewStartTaskHandler(uow),
-		CompleteTask:             command.NewCompleteTaskHandler(uow),
-		FailTask:                 command.NewFailTaskHandler(uow),
-		CancelTask:               command.NewCancelTaskHandler(uow),
+		StartTask:                command.NewStartTaskHandler(uow, eventBus),
+		CompleteTask:             command.NewCompleteTaskHandler(uow, eventBus),
+		FailTask:                 command.NewFailTaskHandler(uow, eventBus),
+		CancelTask:               command.NewCancelTaskHandler(uow, eventBus),
+		DecideApproval:           command.NewDecideApprovalHandler(uow),
+		RerunTask:                command.NewRerunTaskHandler(uow),
 		GetSource:                query.NewGetSourceHandler(uow),
 		ListSources:              query.NewListSourcesHandler(uow),
 		GetAsset:                 query.NewGetAssetHandler(uow),
@@ -214,6 +260,15 @@ func NewHandlers(
 		GetWorkflowWithNodes:     query.NewGetWorkflowWithNodesHandler(uow),
 		GetWorkflowByCode:        query.NewGetWorkflowByCodeHandler(uow),
 		ListWorkflows:            query.NewListWorkflowsHandler(uow),
+		ListWorkflowRevisions:    query.NewListWorkflowRevisionsHandler(uow),
+		GetWorkflowRevision:      query.NewGetWorkflowRevisionHandler(uow),
+		DiffWorkflowRevisions:    query.NewDiffWorkflowRevisionsHandler(uow),
+		GetWorkflowWebhook:       query.NewGetWorkflowWebhookHandler(uow),
+		ListWebhookDeliveries:    query.NewListWebhookDeliveriesHandler(uow),
+		GetNotificationSubscription:   query.NewGetNotificationSubscriptionHandler(uow),
+		ListNotificationSubscriptions: query.NewListNotificationSubscriptionsHandler(uow),
+		ListNotificationDeliveries:    query.NewListNotificationDeliveriesHandler(uow),
+		PlanWorkflow:             query.NewPlanWorkflowHandler(uow, planner),
 		GetTask:                  query.NewGetTaskHandler(uow),
 		GetTaskWithRelations:     query.NewGetTaskWithRelationsHandler(uow),
 		ListTasks:                query.NewListTasksHandler(uow),
@@ -231,6 +286,7 @@ func NewHandlers(
 		FileStorage:       fileStorage,
 		StorageURLConfig:  storageURLConfig,
 		WorkflowScheduler: workflowScheduler,
+		TaskEvents:        taskEvents,
 		DB:                       db,
 		Repo:                     repo,
 		TokenService:             tokenService,
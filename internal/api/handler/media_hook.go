@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	appdto "goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterMediaHookReceiver 注册 MediaMTX 路径回调端点。该端点不经过 JWT 认证，
+// 以 mediamtx.hook_token 共享令牌鉴权；未配置令牌时端点不可用。
+func RegisterMediaHookReceiver(g *echo.Group, h *Handlers) {
+	handler := &mediaHookHandler{h: h}
+	g.POST("/hooks/mediamtx/:event", handler.Receive)
+}
+
+type mediaHookHandler struct {
+	h *Handlers
+}
+
+func (h *mediaHookHandler) Receive(c echo.Context) error {
+	token := h.h.Cfg.MediaMTX.HookToken
+	if token == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "media hooks are disabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Request().Header.Get(port.MediaHookTokenHeader)), []byte(token)) != 1 {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid hook token")
+	}
+
+	cmd := appdto.ReceiveMediaHookCommand{
+		Event:       c.Param("event"),
+		PathName:    c.FormValue("path"),
+		SegmentPath: c.FormValue("segment_path"),
+	}
+	if raw := c.FormValue("segment_duration"); raw != "" {
+		duration, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid segment_duration")
+		}
+		cmd.SegmentDuration = duration
+	}
+
+	if err := h.h.ReceiveMediaHook.Handle(c.Request().Context(), cmd); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"goyavision/internal/api/dto"
+	appdto "goyavision/internal/app/dto"
+	"goyavision/internal/domain/notification"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterNotificationRoutes(g *echo.Group, h *Handlers) {
+	handler := &notificationHandler{h: h}
+	g.GET("/notifications/subscriptions", handler.ListSubscriptions)
+	g.POST("/notifications/subscriptions", handler.CreateSubscription)
+	g.GET("/notifications/subscriptions/:id", handler.GetSubscription)
+	g.PUT("/notifications/subscriptions/:id", handler.UpdateSubscription)
+	g.DELETE("/notifications/subscriptions/:id", handler.DeleteSubscription)
+	g.POST("/notifications/subscriptions/:id/rotate-secret", handler.RotateSecret)
+	g.GET("/notifications/subscriptions/:id/deliveries", handler.ListDeliveries)
+	g.POST("/notifications/deliveries/:id/replay", handler.ReplayDelivery)
+}
+
+type notificationHandler struct {
+	h *Handlers
+}
+
+func (h *notificationHandler) ListSubscriptions(c echo.Context) error {
+	var query dto.NotificationSubscriptionListQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters")
+	}
+
+	result, err := h.h.ListNotificationSubscriptions.Handle(c.Request().Context(), appdto.ListNotificationSubscriptionsQuery{
+		Pagination: appdto.Pagination{Limit: query.Limit, Offset: query.Offset},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NotificationSubscriptionListResponse{
+		Items: dto.NotificationSubscriptionsToResponse(result.Items),
+		Total: result.Total,
+	})
+}
+
+func (h *notificationHandler) CreateSubscription(c echo.Context) error {
+	var req dto.NotificationSubscriptionCreateReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	sub, secret, err := h.h.CreateNotificationSubscription.Handle(c.Request().Context(), appdto.CreateNotificationSubscriptionCommand{
+		Name:       req.Name,
+		Channel:    notification.Channel(req.Channel),
+		EventTypes: req.EventTypes,
+		URL:        req.URL,
+		ChatFormat: notification.ChatFormat(req.ChatFormat),
+		Recipients: req.Recipients,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, dto.NotificationSubscriptionToResponse(sub, secret))
+}
+
+func (h *notificationHandler) GetSubscription(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription id")
+	}
+
+	sub, err := h.h.GetNotificationSubscription.Handle(c.Request().Context(), appdto.GetNotificationSubscriptionQuery{ID: id})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NotificationSubscriptionToResponse(sub, ""))
+}
+
+func (h *notificationHandler) UpdateSubscription(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription id")
+	}
+
+	var req dto.NotificationSubscriptionUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	cmd := appdto.UpdateNotificationSubscriptionCommand{
+		ID:         id,
+		Name:       req.Name,
+		EventTypes: req.EventTypes,
+		URL:        req.URL,
+		Recipients: req.Recipients,
+		Enabled:    req.Enabled,
+	}
+	if req.ChatFormat != nil {
+		format := notification.ChatFormat(*req.ChatFormat)
+		cmd.ChatFormat = &format
+	}
+
+	sub, err := h.h.UpdateNotificationSubscription.Handle(c.Request().Context(), cmd)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NotificationSubscriptionToResponse(sub, ""))
+}
+
+func (h *notificationHandler) DeleteSubscription(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription id")
+	}
+
+	if err := h.h.DeleteNotificationSubscription.Handle(c.Request().Context(), appdto.DeleteNotificationSubscriptionCommand{ID: id}); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *notificationHandler) RotateSecret(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription id")
+	}
+
+	sub, secret, err := h.h.RotateNotificationSecret.Handle(c.Request().Context(), appdto.RotateNotificationSecretCommand{ID: id})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NotificationSubscriptionToResponse(sub, secret))
+}
+
+func (h *notificationHandler) ListDeliveries(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription id")
+	}
+
+	var query dto.NotificationDeliveryListQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters")
+	}
+
+	q := appdto.ListNotificationDeliveriesQuery{
+		SubscriptionID: id,
+		Pagination:     appdto.Pagination{Limit: query.Limit, Offset: query.Offset},
+	}
+	if query.Status != nil {
+		status := notification.DeliveryStatus(*query.Status)
+		q.Status = &status
+	}
+
+	result, err := h.h.ListNotificationDeliveries.Handle(c.Request().Context(), q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NotificationDeliveryListResponse{
+		Items: dto.NotificationDeliveriesToResponse(result.Items),
+		Total: result.Total,
+	})
+}
+
+func (h *notificationHandler) ReplayDelivery(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid delivery id")
+	}
+
+	delivery, err := h.h.ReplayNotificationDelivery.Handle(c.Request().Context(), appdto.ReplayNotificationDeliveryCommand{ID: id})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, dto.NotificationDeliveryToResponse(delivery))
+}
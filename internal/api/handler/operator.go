@@ -38,6 +38,7 @@ func RegisterOperatorRoutes(public *echo.Group, protected *echo.Group, h *Handle
 	protected.POST("/operators/:id/versions/activate", handler.ActivateVersion)
 	protected.POST("/operators/:id/versions/rollback", handler.RollbackVersion)
 	protected.POST("/operators/:id/versions/archive", handler.ArchiveVersion)
+	protected.DELETE("/operators/:id/versions/:version_id/cache", handler.InvalidateCache)
 	protected.POST("/operators/validate-schema", handler.ValidateSchema)
 	protected.POST("/operators/validate-connection", handler.ValidateConnection)
 	protected.POST("/operators/templates/install", handler.InstallTemplate)
@@ -363,6 +364,27 @@ func (h *operatorHandler) RollbackVersion(c echo.Context) error {
 	return c.JSON(http.StatusOK, dto.OperatorToResponse(op))
 }
 
+func (h *operatorHandler) InvalidateCache(c echo.Context) error {
+	operatorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid operator id")
+	}
+	versionID, err := uuid.Parse(c.Param("version_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid version id")
+	}
+
+	deleted, err := h.h.InvalidateNodeCache.Handle(c.Request().Context(), appdto.InvalidateNodeCacheCommand{
+		OperatorID: operatorID,
+		VersionID:  versionID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.NodeCacheInvalidateResponse{Deleted: deleted})
+}
+
 func (h *operatorHandler) ArchiveVersion(c echo.Context) error {
 	operatorID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
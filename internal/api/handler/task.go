@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,7 +9,9 @@ import (
 
 	"goyavision/internal/api/dto"
 	authmiddleware "goyavision/internal/api/middleware"
+	"goyavision/internal/app"
 	appdto "goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/domain/media"
 	"goyavision/internal/domain/workflow"
 
@@ -25,6 +28,7 @@ func RegisterTaskRoutes(public *echo.Group, protected *echo.Group, h *Handlers)
 	public.GET("/tasks/:id/progress/stream", handler.ProgressStream)
 
 	// Protected
+	protected.GET("/tasks/events/stream", handler.EventsStream)
 	protected.POST("/tasks", handler.Create)
 	protected.PUT("/tasks/:id", handler.Update)
 	protected.DELETE("/tasks/:id", handler.Delete)
@@ -32,6 +36,9 @@ func RegisterTaskRoutes(public *echo.Group, protected *echo.Group, h *Handlers)
 	protected.POST("/tasks/:id/complete", handler.Complete)
 	protected.POST("/tasks/:id/fail", handler.Fail)
 	protected.POST("/tasks/:id/cancel", handler.Cancel)
+	protected.POST("/tasks/:id/rerun", handler.Rerun)
+	protected.POST("/tasks/:id/nodes/:node_key/approve", handler.Approve)
+	protected.POST("/tasks/:id/nodes/:node_key/reject", handler.Reject)
 }
 
 type taskHandler struct {
@@ -45,8 +52,10 @@ func (h *taskHandler) List(c echo.Context) error {
 	}
 
 	q := appdto.ListTasksQuery{
-		WorkflowID: query.WorkflowID,
-		AssetID:    query.AssetID,
+		WorkflowID:   query.WorkflowID,
+		AssetID:      query.AssetID,
+		CallerTaskID: query.CallerTaskID,
+		ParentTaskID: query.ParentTaskID,
 		Pagination: appdto.Pagination{
 			Limit:  query.Limit,
 			Offset: query.Offset,
@@ -106,12 +115,17 @@ func (h *taskHandler) Create(c echo.Context) error {
 		WorkflowID:  req.WorkflowID,
 		AssetID:     req.AssetID,
 		InputParams: req.InputParams,
+		Priority:    req.Priority,
+		Deadline:    req.Deadline,
 	}
 
 	task, err := h.h.CreateTask.Handle(c.Request().Context(), cmd)
 	if err != nil {
 		return err
 	}
+	if h.h.WorkflowScheduler != nil {
+		h.h.WorkflowScheduler.NotifyQueued()
+	}
 
 	var wf *workflow.Workflow
 	var asset *media.Asset
@@ -273,6 +287,91 @@ func (h *taskHandler) Cancel(c echo.Context) error {
 	return c.JSON(http.StatusOK, dto.TaskToResponse(task))
 }
 
+// Rerun 基于已结束的任务创建重跑任务，未重新执行的节点复用原任务输出
+func (h *taskHandler) Rerun(c echo.Context) error {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid task id")
+	}
+
+	var req dto.TaskRerunReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	task, err := h.h.RerunTask.Handle(c.Request().Context(), appdto.RerunTaskCommand{
+		TaskID:      id,
+		Mode:        workflow.RerunMode(req.Mode),
+		NodeKey:     req.NodeKey,
+		InputParams: req.InputParams,
+		Priority:    req.Priority,
+	})
+	if err != nil {
+		return err
+	}
+	if h.h.WorkflowScheduler != nil {
+		h.h.WorkflowScheduler.NotifyQueued()
+	}
+
+	var wf *workflow.Workflow
+	var asset *media.Asset
+	if task.WorkflowID != uuid.Nil {
+		wf, _ = h.h.GetWorkflow.Handle(c.Request().Context(), appdto.GetWorkflowQuery{ID: task.WorkflowID})
+	}
+	if task.AssetID != nil {
+		asset, _ = h.h.GetAsset.Handle(c.Request().Context(), appdto.GetAssetQuery{ID: *task.AssetID})
+	}
+
+	return c.JSON(http.StatusCreated, dto.TaskToResponseWithRelations(task, wf, asset, h.h.StorageURLConfig.Endpoint, h.h.StorageURLConfig.BucketName, h.h.StorageURLConfig.PublicBase, h.h.StorageURLConfig.UseSSL))
+}
+
+// Approve 审批通过，可选提交修改后的内容替换流向下游的数据
+func (h *taskHandler) Approve(c echo.Context) error {
+	return h.decideApproval(c, true)
+}
+
+// Reject 审批驳回
+func (h *taskHandler) Reject(c echo.Context) error {
+	return h.decideApproval(c, false)
+}
+
+func (h *taskHandler) decideApproval(c echo.Context, approved bool) error {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid task id")
+	}
+
+	var req dto.ApprovalDecisionReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if h.h.WorkflowScheduler == nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "scheduler not available")
+	}
+
+	userID, _ := authmiddleware.GetUserID(c)
+	task, err := h.h.DecideApproval.Handle(c.Request().Context(), appdto.DecideApprovalCommand{
+		TaskID:   id,
+		NodeKey:  c.Param("node_key"),
+		Approved: approved,
+		UserID:   userID,
+		Comment:  req.Comment,
+		Payload:  req.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := h.h.WorkflowScheduler.ResumeTask(c.Request().Context(), task.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.TaskToResponse(task))
+}
+
 func (h *taskHandler) Stats(c echo.Context) error {
 	var workflowID *uuid.UUID
 	if wfIDStr := c.QueryParam("workflow_id"); wfIDStr != "" {
@@ -291,7 +390,19 @@ func (h *taskHandler) Stats(c echo.Context) error {
 	return c.JSON(http.StatusOK, dto.TaskStatsToResponse(stats))
 }
 
+// taskStreamSnapshotInterval 事件流的心跳间隔。进度流在间隔内没有收到任务事件时重新查询一次任务快照，
+// 覆盖由其他进程执行、事件未发布到本进程的任务
+const taskStreamSnapshotInterval = 15 * time.Second
+
 // ProgressStream SSE 实时推送任务执行进度
+//
+// 无名消息（data）为任务快照，连接建立时发送，之后按任务事件的载荷（任务状态、进度与节点状态）增量更新并在变化时发送，
+// 任务进入终态时结束推送；任务事件以命名消息发送（event 为事件类型，id 为事件 ID）。
+// 连接只在建立时查询任务，此后仅在一个心跳间隔内没有事件时重新查询，不随事件频率增加数据库负载。
+//
+// 断线重连携带 Last-Event-ID 时补发断线期间的事件。事件 ID 与补发历史由本进程的 TaskEventStream 维护，
+// 仅保留最近 1024 条（所有任务共享）：重连到其他副本、进程重启或断线期间事件过多时无法续传，
+// 此时重新发送完整快照，客户端应以快照为准。未启用事件总线时返回 503。
 func (h *taskHandler) ProgressStream(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -299,39 +410,258 @@ func (h *taskHandler) ProgressStream(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid task id")
 	}
 
-	c.Response().Header().Set("Content-Type", "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().Header().Set("X-Accel-Buffering", "no")
-	c.Response().WriteHeader(http.StatusOK)
+	if h.h.TaskEvents == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "task events are not enabled")
+	}
+
+	// 先确认任务存在且当前租户可见
+	task, err := h.h.GetTask.Handle(c.Request().Context(), appdto.GetTaskQuery{ID: id})
+	if err != nil {
+		return err
+	}
+	snapshot := newTaskStreamSnapshot(task)
+
+	sub, replay, resumed := h.h.TaskEvents.Subscribe(app.TaskEventFilter{TaskID: &id}, lastEventID(c))
+	defer sub.Close()
+
+	setSSEHeaders(c)
+
+	var last []byte
+	sendSnapshot := func() (completed bool) {
+		data := snapshot.data()
+		if !bytes.Equal(data, last) {
+			writeSSE(c, "", "", data)
+			last = data
+		}
+		return snapshot.completed()
+	}
 
-	ticker := time.NewTicker(1 * time.Second)
+	if !resumed {
+		if sendSnapshot() {
+			return nil
+		}
+	} else {
+		last = snapshot.data()
+	}
+	for _, msg := range replay {
+		writeTaskEvent(c, msg)
+		snapshot.apply(msg.Event)
+	}
+	if len(replay) > 0 && sendSnapshot() {
+		return nil
+	}
+
+	ticker := time.NewTicker(taskStreamSnapshotInterval)
 	defer ticker.Stop()
+	received := false
 
 	for {
 		select {
 		case <-c.Request().Context().Done():
 			return nil
-		case <-ticker.C:
-			task, err := h.h.GetTask.Handle(c.Request().Context(), appdto.GetTaskQuery{ID: id})
-			if err != nil {
+		case msg, ok := <-sub.C:
+			if !ok {
 				return nil
 			}
-
-			event := map[string]interface{}{
-				"status":          string(task.Status),
-				"progress":        task.Progress,
-				"current_node":    task.CurrentNode,
-				"node_executions": dto.TaskToResponse(task).NodeExecutions,
-				"error":           task.Error,
+			writeTaskEvent(c, msg)
+			received = true
+			snapshot.apply(msg.Event)
+			if sendSnapshot() {
+				return nil
 			}
-			data, _ := json.Marshal(event)
-			fmt.Fprintf(c.Response(), "data: %s\n\n", data)
+		case <-ticker.C:
+			if !received {
+				task, err := h.h.GetTask.Handle(c.Request().Context(), appdto.GetTaskQuery{ID: id})
+				if err != nil {
+					return nil
+				}
+				snapshot = newTaskStreamSnapshot(task)
+				if sendSnapshot() {
+					return nil
+				}
+			}
+			received = false
+			fmt.Fprint(c.Response(), ": ping\n\n")
 			c.Response().Flush()
+		}
+	}
+}
+
+// taskStreamSnapshot 进度流的任务快照，连接建立时由任务构造，之后按任务事件的载荷更新
+type taskStreamSnapshot struct {
+	Status         string                 `json:"status"`
+	Progress       int                    `json:"progress"`
+	CurrentNode    string                 `json:"current_node"`
+	NodeExecutions []dto.NodeExecutionDTO `json:"node_executions"`
+	Error          string                 `json:"error"`
+
+	// seq 已应用的最大引擎事件序号，用于丢弃乱序到达的旧事件
+	seq int64
+}
+
+func newTaskStreamSnapshot(task *workflow.Task) *taskStreamSnapshot {
+	return &taskStreamSnapshot{
+		Status:         string(task.Status),
+		Progress:       task.Progress,
+		CurrentNode:    task.CurrentNode,
+		NodeExecutions: dto.TaskToResponse(task).NodeExecutions,
+		Error:          task.Error,
+	}
+}
+
+// apply 按事件更新快照。携带 NodeKey 的事件的 Status 为节点状态，其余事件为任务状态
+func (s *taskStreamSnapshot) apply(e *event.TaskEvent) {
+	if e.Seq > 0 {
+		if e.Seq < s.seq {
+			return
+		}
+		s.seq = e.Seq
+	}
+
+	switch e.Type {
+	case event.EventTypeTaskStatus:
+		s.Status = e.Status
+		s.Progress = e.Progress
+		s.Error = e.Error
+		return
+	case event.EventTypeArtifactCreated:
+		if e.ArtifactID != nil && e.NodeKey != "" {
+			node := s.node(e.NodeKey)
+			node.ArtifactIDs = append(node.ArtifactIDs, *e.ArtifactID)
+		}
+		return
+	case event.EventTypeTaskProgress, event.EventTypeNodeStarted, event.EventTypeNodeFinished:
+	default:
+		return
+	}
+
+	s.Progress = e.Progress
+	if e.NodeKey == "" {
+		if e.Status != "" {
+			s.Status = e.Status
+		}
+		return
+	}
+	node := s.node(e.NodeKey)
+	if e.Status != "" {
+		node.Status = e.Status
+	}
+	node.Progress = e.NodeProgress
+	node.Message = e.Message
+	node.Error = e.Error
+	at := time.Unix(e.At, 0)
+	switch e.Type {
+	case event.EventTypeNodeStarted:
+		s.CurrentNode = e.NodeKey
+		node.StartedAt = &at
+		node.CompletedAt = nil
+	case event.EventTypeNodeFinished:
+		node.CompletedAt = &at
+	}
+}
+
+// node 返回节点的执行记录，不存在时追加
+func (s *taskStreamSnapshot) node(key string) *dto.NodeExecutionDTO {
+	for i := range s.NodeExecutions {
+		if s.NodeExecutions[i].NodeKey == key {
+			return &s.NodeExecutions[i]
+		}
+	}
+	s.NodeExecutions = append(s.NodeExecutions, dto.NodeExecutionDTO{NodeKey: key})
+	return &s.NodeExecutions[len(s.NodeExecutions)-1]
+}
+
+func (s *taskStreamSnapshot) data() []byte {
+	data, _ := json.Marshal(s)
+	return data
+}
+
+func (s *taskStreamSnapshot) completed() bool {
+	task := workflow.Task{Status: workflow.TaskStatus(s.Status)}
+	return task.IsCompleted()
+}
+
+// EventsStream SSE 推送当前租户的任务事件，可按工作流或任务过滤，支持 Last-Event-ID 续传
+func (h *taskHandler) EventsStream(c echo.Context) error {
+	if h.h.TaskEvents == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "task events are not enabled")
+	}
+
+	var filter app.TaskEventFilter
+	if tenantID, ok := authmiddleware.GetTenantID(c); ok {
+		filter.TenantID = &tenantID
+	} else {
+		return echo.NewHTTPError(http.StatusForbidden, "tenant is required")
+	}
+	if v := c.QueryParam("workflow_id"); v != "" {
+		workflowID, err := uuid.Parse(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow_id")
+		}
+		filter.WorkflowID = &workflowID
+	}
+	if v := c.QueryParam("task_id"); v != "" {
+		taskID, err := uuid.Parse(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid task_id")
+		}
+		filter.TaskID = &taskID
+	}
 
-			if task.IsCompleted() {
+	sub, replay, _ := h.h.TaskEvents.Subscribe(filter, lastEventID(c))
+	defer sub.Close()
+
+	setSSEHeaders(c)
+	for _, msg := range replay {
+		writeTaskEvent(c, msg)
+	}
+
+	ticker := time.NewTicker(taskStreamSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case msg, ok := <-sub.C:
+			if !ok {
 				return nil
 			}
+			writeTaskEvent(c, msg)
+		case <-ticker.C:
+			fmt.Fprint(c.Response(), ": ping\n\n")
+			c.Response().Flush()
 		}
 	}
 }
+
+func lastEventID(c echo.Context) string {
+	if id := c.Request().Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.QueryParam("last_event_id")
+}
+
+func setSSEHeaders(c echo.Context) {
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("X-Accel-Buffering", "no")
+	c.Response().WriteHeader(http.StatusOK)
+}
+
+func writeTaskEvent(c echo.Context, msg app.TaskEventMessage) {
+	data, _ := json.Marshal(msg.Event)
+	writeSSE(c, msg.ID, msg.Event.Type, data)
+}
+
+func writeSSE(c echo.Context, id, name string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(c.Response(), "id: %s\n", id)
+	}
+	if name != "" {
+		fmt.Fprintf(c.Response(), "event: %s\n", name)
+	}
+	fmt.Fprintf(c.Response(), "data: %s\n\n", data)
+	c.Response().Flush()
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+func TestTaskStreamSnapshotApply(t *testing.T) {
+	task := &workflow.Task{
+		ID:     uuid.New(),
+		Status: workflow.TaskStatusRunning,
+		NodeExecutions: []workflow.NodeExecution{
+			{NodeKey: "extract", Status: workflow.NodeExecSuccess},
+		},
+	}
+	s := newTaskStreamSnapshot(task)
+
+	newEvent := func(eventType string, seq int64, fill func(*event.TaskEvent)) *event.TaskEvent {
+		e := event.NewTaskEvent(eventType, task.ID, task.TenantID, task.WorkflowID)
+		e.Seq = seq
+		fill(e)
+		return e
+	}
+	artifactID := uuid.New()
+
+	s.apply(newEvent(event.EventTypeNodeStarted, 1, func(e *event.TaskEvent) {
+		e.NodeKey, e.Status, e.Progress = "detect", "running", 50
+	}))
+	s.apply(newEvent(event.EventTypeTaskProgress, 2, func(e *event.TaskEvent) {
+		e.NodeKey, e.Status, e.Progress, e.NodeProgress, e.Message = "detect", "running", 60, 0.5, "frame 5/10"
+	}))
+	s.apply(newEvent(event.EventTypeArtifactCreated, 3, func(e *event.TaskEvent) {
+		e.NodeKey, e.ArtifactID = "detect", &artifactID
+	}))
+	s.apply(newEvent(event.EventTypeNodeFinished, 4, func(e *event.TaskEvent) {
+		e.NodeKey, e.Status, e.Progress, e.NodeProgress = "detect", "success", 100, 1
+	}))
+	// 乱序到达的旧事件被丢弃
+	s.apply(newEvent(event.EventTypeTaskProgress, 2, func(e *event.TaskEvent) {
+		e.NodeKey, e.Status, e.Progress = "detect", "running", 60
+	}))
+
+	if s.CurrentNode != "detect" || s.Progress != 100 {
+		t.Errorf("current_node = %q progress = %d, want detect 100", s.CurrentNode, s.Progress)
+	}
+	if len(s.NodeExecutions) != 2 {
+		t.Fatalf("node_executions = %+v, want extract and detect", s.NodeExecutions)
+	}
+	detect := s.NodeExecutions[1]
+	if detect.NodeKey != "detect" || detect.Status != "success" || detect.StartedAt == nil || detect.CompletedAt == nil {
+		t.Errorf("detect = %+v, want a finished node", detect)
+	}
+	if len(detect.ArtifactIDs) != 1 || detect.ArtifactIDs[0] != artifactID {
+		t.Errorf("detect artifacts = %v, want [%s]", detect.ArtifactIDs, artifactID)
+	}
+	if s.completed() {
+		t.Fatal("snapshot completed before task_status")
+	}
+
+	// API 发布的任务状态事件不带序号
+	s.apply(newEvent(event.EventTypeTaskStatus, 0, func(e *event.TaskEvent) {
+		e.Status, e.Progress, e.Error = string(workflow.TaskStatusFailed), 100, "boom"
+	}))
+	if !s.completed() || s.Error != "boom" {
+		t.Errorf("status = %s error = %q, want failed boom", s.Status, s.Error)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(s.data(), &decoded); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	for _, key := range []string{"status", "progress", "current_node", "node_executions", "error"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("snapshot missing %q: %s", key, s.data())
+		}
+	}
+}
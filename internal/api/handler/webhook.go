@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"goyavision/internal/api/dto"
+	appdto "goyavision/internal/app/dto"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// webhookBodyLimit 入站 webhook 请求体上限
+const webhookBodyLimit = 1 << 20
+
+// RegisterWebhookReceiver 注册入站 webhook 端点。该端点不经过 JWT 认证，以请求签名鉴权。
+func RegisterWebhookReceiver(g *echo.Group, h *Handlers) {
+	handler := &webhookHandler{h: h}
+	g.POST("/webhooks/:id", handler.Receive)
+}
+
+type webhookHandler struct {
+	h *Handlers
+}
+
+func (h *webhookHandler) Receive(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "webhook not found")
+	}
+
+	req := c.Request()
+	body, err := io.ReadAll(io.LimitReader(req.Body, webhookBodyLimit+1))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read request body")
+	}
+	if len(body) > webhookBodyLimit {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body too large")
+	}
+
+	delivery, err := h.h.ReceiveWorkflowWebhook.Handle(req.Context(), appdto.ReceiveWorkflowWebhookCommand{
+		WebhookID:  id,
+		Timestamp:  req.Header.Get(workflow.WebhookHeaderTimestamp),
+		Nonce:      req.Header.Get(workflow.WebhookHeaderNonce),
+		Signature:  req.Header.Get(workflow.WebhookHeaderSignature),
+		Body:       body,
+		RemoteAddr: c.RealIP(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if h.h.WorkflowScheduler != nil {
+		h.h.WorkflowScheduler.NotifyQueued()
+	}
+
+	return c.JSON(http.StatusAccepted, dto.WebhookReceiveResponse{
+		DeliveryID: delivery.ID,
+		Status:     string(delivery.Status),
+		TaskID:     delivery.TaskID,
+		AssetID:    delivery.AssetID,
+	})
+}
@@ -0,0 +1,207 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	adaptercrypto "goyavision/internal/adapter/crypto"
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/api"
+	"goyavision/internal/api/handler"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/command"
+	appdto "goyavision/internal/app/dto"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	infrapersistence "goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type webhookFixture struct {
+	t      *testing.T
+	db     *gorm.DB
+	e      *echo.Echo
+	rotate *command.RotateWorkflowWebhookHandler
+	ctx    context.Context
+	wf     *workflow.Workflow
+}
+
+type allowAllGuard struct{}
+
+func (allowAllGuard) CheckURL(ctx context.Context, rawURL string) error { return nil }
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := persistence.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	uow := infrapersistence.NewUnitOfWork(db)
+	crypto, err := adaptercrypto.NewAESCryptoService("webhook-test-key")
+	if err != nil {
+		t.Fatalf("crypto: %v", err)
+	}
+	h := &handler.Handlers{
+		ReceiveWorkflowWebhook: command.NewReceiveWorkflowWebhookHandler(uow, crypto, allowAllGuard{}, nil, middleware.ContextWithIdentity),
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = api.ErrorHandler
+	handler.RegisterWebhookReceiver(e.Group("/api/v1"), h)
+
+	ctx := middleware.ContextWithIdentity(context.Background(), uuid.New(), uuid.New())
+	wf := &workflow.Workflow{
+		ID:          uuid.New(),
+		Code:        "hook",
+		Name:        "hook",
+		TriggerType: workflow.TriggerTypeWebhook,
+		Status:      workflow.StatusEnabled,
+	}
+	if err := uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		return repos.Workflows.Create(ctx, wf)
+	}); err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	return &webhookFixture{t: t, db: db, e: e, rotate: command.NewRotateWorkflowWebhookHandler(uow, crypto), ctx: ctx, wf: wf}
+}
+
+// rotateSecret 创建端点或轮换密钥，返回端点与新密钥
+func (f *webhookFixture) rotateSecret(grace int) (*workflow.Webhook, string) {
+	f.t.Helper()
+	wh, secret, err := f.rotate.Handle(f.ctx, appdto.RotateWorkflowWebhookCommand{WorkflowID: f.wf.ID, GraceSeconds: &grace})
+	if err != nil {
+		f.t.Fatalf("rotate webhook: %v", err)
+	}
+	return wh, secret
+}
+
+func (f *webhookFixture) post(id uuid.UUID, secret string, ts time.Time, nonce string) *httptest.ResponseRecorder {
+	f.t.Helper()
+	body := []byte(`{"camera":"c1"}`)
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/"+id.String(), strings.NewReader(string(body)))
+	req.Header.Set(workflow.WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(workflow.WebhookHeaderNonce, nonce)
+	req.Header.Set(workflow.WebhookHeaderSignature, "sha256="+workflow.WebhookSignature(secret, timestamp, nonce, body))
+	rec := httptest.NewRecorder()
+	f.e.ServeHTTP(rec, req)
+	return rec
+}
+
+func (f *webhookFixture) deliveries() int64 {
+	f.t.Helper()
+	var n int64
+	if err := f.db.Model(&model.WebhookDeliveryModel{}).Count(&n).Error; err != nil {
+		f.t.Fatalf("count deliveries: %v", err)
+	}
+	return n
+}
+
+func TestReceiveWebhookAuthentication(t *testing.T) {
+	f := newWebhookFixture(t)
+	wh, secret := f.rotateSecret(0)
+	now := time.Now()
+
+	unknown := f.post(uuid.New(), secret, now, uuid.NewString())
+	tests := []struct {
+		name string
+		rec  *httptest.ResponseRecorder
+	}{
+		{"unknown webhook", unknown},
+		{"bad signature", f.post(wh.ID, "whsec_wrong", now, uuid.NewString())},
+		{"stale timestamp", f.post(wh.ID, secret, now.Add(-time.Duration(workflow.DefaultWebhookToleranceSeconds+60)*time.Second), uuid.NewString())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401: %s", tt.rec.Code, tt.rec.Body)
+			}
+			// 鉴权失败的响应一致，不泄露端点或工作流是否存在
+			if tt.rec.Body.String() != unknown.Body.String() {
+				t.Errorf("body = %s, want %s", tt.rec.Body, unknown.Body)
+			}
+		})
+	}
+	if n := f.deliveries(); n != 0 {
+		t.Errorf("deliveries = %d, want none for unauthenticated requests", n)
+	}
+
+	nonce := uuid.NewString()
+	if rec := f.post(wh.ID, secret, now, nonce); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	replay := f.post(wh.ID, secret, now, nonce)
+	if replay.Code != http.StatusUnauthorized || replay.Body.String() != unknown.Body.String() {
+		t.Errorf("replay: status = %d body = %s, want the uniform 401", replay.Code, replay.Body)
+	}
+	if n := f.deliveries(); n != 1 {
+		t.Errorf("deliveries = %d, want 1", n)
+	}
+}
+
+func TestReceiveWebhookRotationGrace(t *testing.T) {
+	f := newWebhookFixture(t)
+	wh, first := f.rotateSecret(0)
+	_, second := f.rotateSecret(3600)
+
+	for _, secret := range []string{first, second} {
+		rec := f.post(wh.ID, secret, time.Now(), uuid.NewString())
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202 within the grace period: %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			TaskID *uuid.UUID `json:"task_id"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.TaskID == nil {
+			t.Errorf("response = %s, want a task_id", rec.Body)
+		}
+	}
+
+	_, third := f.rotateSecret(0)
+	if rec := f.post(wh.ID, second, time.Now(), uuid.NewString()); rec.Code != http.StatusUnauthorized {
+		t.Errorf("previous secret without grace: status = %d, want 401", rec.Code)
+	}
+	if rec := f.post(wh.ID, third, time.Now(), uuid.NewString()); rec.Code != http.StatusAccepted {
+		t.Errorf("current secret: status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestReceiveWebhookRecordsAuthenticatedRejections(t *testing.T) {
+	f := newWebhookFixture(t)
+	wh, secret := f.rotateSecret(0)
+	if err := f.db.Model(&model.WorkflowModel{}).Where("id = ?", f.wf.ID).Update("status", workflow.StatusDisabled).Error; err != nil {
+		t.Fatalf("disable workflow: %v", err)
+	}
+
+	rec := f.post(wh.ID, secret, time.Now(), uuid.NewString())
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	var row model.WebhookDeliveryModel
+	if err := f.db.First(&row).Error; err != nil {
+		t.Fatalf("get delivery: %v", err)
+	}
+	if row.Status != string(workflow.WebhookDeliveryRejected) {
+		t.Errorf("delivery status = %s, want rejected", row.Status)
+	}
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"goyavision/internal/api/dto"
 	authmiddleware "goyavision/internal/api/middleware"
@@ -18,6 +20,9 @@ func RegisterWorkflowRoutes(public *echo.Group, protected *echo.Group, h *Handle
 	// Public
 	public.GET("/workflows", handler.List)
 	public.GET("/workflows/:id", handler.Get)
+	public.GET("/workflows/:id/revisions", handler.ListRevisions)
+	public.GET("/workflows/:id/revisions/diff", handler.DiffRevisions)
+	public.GET("/workflows/:id/revisions/:revision", handler.GetRevision)
 
 	// Protected
 	protected.POST("/workflows", handler.Create)
@@ -26,6 +31,11 @@ func RegisterWorkflowRoutes(public *echo.Group, protected *echo.Group, h *Handle
 	protected.POST("/workflows/:id/enable", handler.Enable)
 	protected.POST("/workflows/:id/disable", handler.Disable)
 	protected.POST("/workflows/:id/trigger", handler.Trigger)
+	protected.POST("/workflows/:id/plan", handler.Plan)
+	protected.POST("/workflows/:id/revisions/:revision/restore", handler.RestoreRevision)
+	protected.GET("/workflows/:id/webhook", handler.GetWebhook)
+	protected.POST("/workflows/:id/webhook/rotate", handler.RotateWebhook)
+	protected.GET("/workflows/:id/webhook/deliveries", handler.ListWebhookDeliveries)
 }
 
 type workflowHandler struct {
@@ -132,12 +142,14 @@ func (h *workflowHandler) Create(c echo.Context) error {
 		Edges:          edges,
 		Visibility:     visibility,
 		VisibleRoleIDs: visibleRoleIDs,
+		Timeouts:       req.Timeouts,
 	}
 
 	wf, err := h.h.CreateWorkflow.Handle(c.Request().Context(), cmd)
 	if err != nil {
 		return err
 	}
+	h.reschedule(c, wf)
 
 	return c.JSON(http.StatusCreated, dto.WorkflowToResponseWithNodes(wf))
 }
@@ -207,6 +219,7 @@ func (h *workflowHandler) Update(c echo.Context) error {
 		Nodes:          nodes,
 		Edges:          edges,
 		VisibleRoleIDs: req.VisibleRoleIDs,
+		Timeouts:       req.Timeouts,
 	}
 
 	if req.Visibility != nil {
@@ -223,6 +236,7 @@ func (h *workflowHandler) Update(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	h.reschedule(c, result)
 
 	return c.JSON(http.StatusOK, dto.WorkflowToResponseWithNodes(result))
 }
@@ -238,6 +252,9 @@ func (h *workflowHandler) Delete(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if h.h.WorkflowScheduler != nil {
+		h.h.WorkflowScheduler.RemoveWorkflow(c.Request().Context(), id)
+	}
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -253,6 +270,7 @@ func (h *workflowHandler) Enable(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	h.reschedule(c, result)
 
 	return c.JSON(http.StatusOK, dto.WorkflowToResponseWithNodes(result))
 }
@@ -268,10 +286,23 @@ func (h *workflowHandler) Disable(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	h.reschedule(c, result)
 
 	return c.JSON(http.StatusOK, dto.WorkflowToResponse(result))
 }
 
+// reschedule 立即按工作流的最新状态与触发配置重建定时作业，响应中的 next_run_at 随之更新
+func (h *workflowHandler) reschedule(c echo.Context, wf *workflow.Workflow) {
+	if h.h.WorkflowScheduler == nil || wf == nil {
+		return
+	}
+	h.h.WorkflowScheduler.RescheduleWorkflow(c.Request().Context(), wf)
+	if latest, err := h.h.GetWorkflow.Handle(c.Request().Context(), appdto.GetWorkflowQuery{ID: wf.ID}); err == nil {
+		wf.LastRunAt = latest.LastRunAt
+		wf.NextRunAt = latest.NextRunAt
+	}
+}
+
 func (h *workflowHandler) Trigger(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -280,7 +311,9 @@ func (h *workflowHandler) Trigger(c echo.Context) error {
 	}
 
 	var req struct {
-		AssetID *uuid.UUID `json:"asset_id,omitempty"`
+		AssetID  *uuid.UUID `json:"asset_id,omitempty"`
+		Priority int        `json:"priority,omitempty"`
+		Deadline *time.Time `json:"deadline,omitempty"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
@@ -290,10 +323,194 @@ func (h *workflowHandler) Trigger(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "scheduler not available")
 	}
 
-	task, err := h.h.WorkflowScheduler.TriggerWorkflow(c.Request().Context(), id, req.AssetID)
+	task, err := h.h.WorkflowScheduler.TriggerWorkflow(c.Request().Context(), id, req.AssetID, req.Priority, req.Deadline)
 	if err != nil {
 		return err
 	}
 
 	return c.JSON(http.StatusAccepted, dto.TaskToResponse(task))
 }
+
+func (h *workflowHandler) ListRevisions(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	var query dto.WorkflowRevisionListQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters")
+	}
+
+	result, err := h.h.ListWorkflowRevisions.Handle(c.Request().Context(), appdto.ListWorkflowRevisionsQuery{
+		WorkflowID: id,
+		Pagination: appdto.Pagination{Limit: query.Limit, Offset: query.Offset},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WorkflowRevisionListResponse{
+		Items: dto.WorkflowRevisionsToResponse(result.Items),
+		Total: result.Total,
+	})
+}
+
+func (h *workflowHandler) GetRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid revision")
+	}
+
+	rev, err := h.h.GetWorkflowRevision.Handle(c.Request().Context(), appdto.GetWorkflowRevisionQuery{
+		WorkflowID: id,
+		Revision:   revision,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WorkflowRevisionToResponse(rev, true))
+}
+
+func (h *workflowHandler) DiffRevisions(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	var query dto.WorkflowRevisionDiffQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters")
+	}
+	if query.From <= 0 || query.To <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to revisions are required")
+	}
+
+	diff, err := h.h.DiffWorkflowRevisions.Handle(c.Request().Context(), appdto.DiffWorkflowRevisionsQuery{
+		WorkflowID: id,
+		From:       query.From,
+		To:         query.To,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+func (h *workflowHandler) RestoreRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid revision")
+	}
+
+	var req dto.WorkflowRevisionRestoreReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	result, err := h.h.RestoreWorkflowRevision.Handle(c.Request().Context(), appdto.RestoreWorkflowRevisionCommand{
+		WorkflowID: id,
+		Revision:   revision,
+		Comment:    req.Comment,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WorkflowToResponseWithNodes(result))
+}
+
+func (h *workflowHandler) Plan(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	var req dto.WorkflowPlanReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	plan, err := h.h.PlanWorkflow.Handle(c.Request().Context(), appdto.PlanWorkflowQuery{
+		WorkflowID:  id,
+		AssetID:     req.AssetID,
+		InputParams: req.InputParams,
+		FanOutItems: req.FanOutItems,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, plan)
+}
+
+func (h *workflowHandler) GetWebhook(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	wh, err := h.h.GetWorkflowWebhook.Handle(c.Request().Context(), appdto.GetWorkflowWebhookQuery{WorkflowID: id})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WorkflowWebhookToResponse(wh, ""))
+}
+
+func (h *workflowHandler) RotateWebhook(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	var req dto.WorkflowWebhookRotateReq
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	wh, secret, err := h.h.RotateWorkflowWebhook.Handle(c.Request().Context(), appdto.RotateWorkflowWebhookCommand{
+		WorkflowID:   id,
+		GraceSeconds: req.GraceSeconds,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WorkflowWebhookToResponse(wh, secret))
+}
+
+func (h *workflowHandler) ListWebhookDeliveries(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid workflow id")
+	}
+
+	var query dto.WebhookDeliveryListQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters")
+	}
+
+	result, err := h.h.ListWebhookDeliveries.Handle(c.Request().Context(), appdto.ListWebhookDeliveriesQuery{
+		WorkflowID: id,
+		Pagination: appdto.Pagination{Limit: query.Limit, Offset: query.Offset},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.WebhookDeliveryListResponse{
+		Items: dto.WebhookDeliveriesToResponse(result.Items),
+		Total: result.Total,
+	})
+}
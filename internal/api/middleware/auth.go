@@ -184,6 +184,18 @@ func contextWithAuth(ctx context.Context, claims *JWTClaims) context.Context {
 	return ctx
 }
 
+// ContextWithIdentity 为后台执行（定时、事件、任务恢复、子工作流）构造带租户与用户信息的上下文，
+// 使仓储层的租户与可见性过滤与发起请求时一致
+func ContextWithIdentity(ctx context.Context, tenantID, userID uuid.UUID) context.Context {
+	if tenantID != uuid.Nil {
+		ctx = context.WithValue(ctx, ContextKeyTenantID, tenantID)
+	}
+	if userID != uuid.Nil {
+		ctx = context.WithValue(ctx, ContextKeyUserID, userID)
+	}
+	return ctx
+}
+
 // OptionalJWTAuth 可选的 JWT 认证中间件
 // 如果提供了有效的 Token，则解析并设置上下文；否则直接放行
 func OptionalJWTAuth(cfg config.JWT) echo.MiddlewareFunc {
@@ -70,6 +70,11 @@ func RegisterRouter(e *echo.Echo, h *handler.Handlers, webFS fs.FS) {
 	authGroup := e.Group("/api/v1/auth")
 	handler.RegisterAuth(authGroup, h)
 
+	// 入站 webhook 以请求签名鉴权，不经过 JWT
+	handler.RegisterWebhookReceiver(e.Group("/api/v1"), h)
+	// MediaMTX 路径回调以共享令牌鉴权，不经过 JWT
+	handler.RegisterMediaHookReceiver(e.Group("/api/v1"), h)
+
 	api := e.Group("/api/v1", authMiddleware.JWTAuth(h.Cfg.JWT))
 	optionalApi := e.Group("/api/v1", authMiddleware.OptionalJWTAuth(h.Cfg.JWT))
 
@@ -88,6 +93,7 @@ func RegisterRouter(e *echo.Echo, h *handler.Handlers, webFS fs.FS) {
 	handler.RegisterWorkflowRoutes(optionalApi, api, h)
 	handler.RegisterTaskRoutes(optionalApi, api, h)
 	handler.RegisterArtifact(api, h)
+	handler.RegisterNotificationRoutes(api, h)
 	handler.RegisterAIModelRoutes(optionalApi, api, h)
 	handler.RegisterUserAssetRoutes(api, h)
 
@@ -19,6 +19,8 @@ type CreateArtifactRequest struct {
 	Type    workflow.ArtifactType  `json:"type"`
 	AssetID *uuid.UUID             `json:"asset_id,omitempty"`
 	Data    map[string]interface{} `json:"data,omitempty"`
+	// Temporary 临时产物，任务失败、超时或被取消后自动删除
+	Temporary bool `json:"temporary,omitempty"`
 }
 
 // ListArtifactsRequest 列出产物请求
@@ -79,6 +81,8 @@ func (s *ArtifactService) Create(ctx context.Context, req *CreateArtifactRequest
 		TaskID:  req.TaskID,
 		Type:    req.Type,
 		AssetID: req.AssetID,
+
+		Temporary: req.Temporary,
 	}
 
 	if err := s.repo.CreateArtifact(ctx, artifact); err != nil {
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
 	"goyavision/pkg/apperr"
@@ -13,11 +14,12 @@ import (
 )
 
 type ActivateVersionHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewActivateVersionHandler(uow port.UnitOfWork) *ActivateVersionHandler {
-	return &ActivateVersionHandler{uow: uow}
+func NewActivateVersionHandler(uow port.UnitOfWork, eventBus port.EventBus) *ActivateVersionHandler {
+	return &ActivateVersionHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *ActivateVersionHandler) Handle(ctx context.Context, cmd dto.ActivateVersionCommand) (*operator.Operator, error) {
@@ -42,6 +44,7 @@ func (h *ActivateVersionHandler) Handle(ctx context.Context, cmd dto.ActivateVer
 			return apperr.InvalidInput("version does not belong to operator")
 		}
 
+		previousVersionID := op.ActiveVersionID
 		if op.ActiveVersionID != nil && *op.ActiveVersionID != target.ID {
 			current, err := repos.OperatorVersions.Get(ctx, *op.ActiveVersionID)
 			if err != nil {
@@ -74,6 +77,14 @@ func (h *ActivateVersionHandler) Handle(ctx context.Context, cmd dto.ActivateVer
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to update operator active version")
 		}
 
+		if previousVersionID == nil || *previousVersionID != target.ID {
+			ev := event.NewOperatorEvent(event.EventTypeOperatorVersionActivated, op)
+			ev.PreviousVersionID = previousVersionID
+			if err := publishEvent(ctx, h.eventBus, ev); err != nil {
+				return apperr.Internal("publish operator event", err)
+			}
+		}
+
 		result = op
 		return nil
 	})
@@ -14,16 +14,30 @@ import (
 )
 
 type CancelTaskHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewCancelTaskHandler(uow port.UnitOfWork) *CancelTaskHandler {
-	return &CancelTaskHandler{uow: uow}
+func NewCancelTaskHandler(uow port.UnitOfWork, eventBus port.EventBus) *CancelTaskHandler {
+	return &CancelTaskHandler{uow: uow, eventBus: eventBus}
 }
 
+// Handle 将任务置为 cancelled 并发布 task_status 事件；执行该任务的派发器收到事件后取消引擎中的执行，
+// 等待中的任务由一个派发器执行补偿与收尾节点
 func (h *CancelTaskHandler) Handle(ctx context.Context, cmd dto.CancelTaskCommand) (*workflow.Task, error) {
 	var result *workflow.Task
 	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		// 锁定任务行，执行方随后的写入会看到取消，不会以成功或失败覆盖
+		if err := repos.Tasks.Fence(ctx, cmd.ID, ""); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("task", cmd.ID.String())
+			}
+			if errors.Is(err, workflow.ErrTaskStopped) {
+				return apperr.InvalidInput("task is already completed")
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to lock task")
+		}
+
 		task, err := repos.Tasks.Get(ctx, cmd.ID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -36,6 +50,7 @@ func (h *CancelTaskHandler) Handle(ctx context.Context, cmd dto.CancelTaskComman
 			return apperr.InvalidInput("task is already completed")
 		}
 
+		previous := task.Status
 		now := time.Now()
 		task.Status = workflow.TaskStatusCancelled
 		if task.CompletedAt == nil {
@@ -45,6 +60,11 @@ func (h *CancelTaskHandler) Handle(ctx context.Context, cmd dto.CancelTaskComman
 		if err := repos.Tasks.Update(ctx, task); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to cancel task")
 		}
+		ev := newTaskStatusEvent(task)
+		ev.PreviousStatus = string(previous)
+		if err := publishEvent(ctx, h.eventBus, ev); err != nil {
+			return apperr.Internal("publish task event", err)
+		}
 
 		result = task
 		return nil
@@ -0,0 +1,325 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goyavision/config"
+	adapterengine "goyavision/internal/adapter/engine"
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app"
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+	infraengine "goyavision/internal/infra/engine"
+	infraeventbus "goyavision/internal/infra/eventbus"
+	infrapersistence "goyavision/internal/infra/persistence"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// cancelFixture 以真实的数据库、事件总线、派发器与引擎组装取消链路：
+// CancelTaskHandler -> task_status 事件 -> TaskDispatcher -> DAGWorkflowEngine.Cancel
+type cancelFixture struct {
+	db       *gorm.DB
+	uow      port.UnitOfWork
+	bus      *infraeventbus.LocalEventBus
+	dispatch *app.TaskDispatcher
+	ctx      context.Context
+	tenantID uuid.UUID
+	userID   uuid.UUID
+}
+
+func newCancelFixture(t *testing.T, executor workflow.OperatorExecutor) *cancelFixture {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := persistence.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	uow := infrapersistence.NewUnitOfWork(db)
+	bus := infraeventbus.NewLocalEventBus(0)
+	engine := infraengine.NewDAGWorkflowEngine(uow, executor)
+	engine.SetEventBus(bus)
+	dispatcher := app.NewTaskDispatcher(persistence.NewRepository(db), engine, bus,
+		config.Queue{PollInterval: 20 * time.Millisecond, LeaseTTL: time.Minute})
+	dispatcher.Start(context.Background())
+	t.Cleanup(func() {
+		waitUntil(t, func() bool { return dispatcher.Running() == 0 })
+		dispatcher.Stop()
+	})
+
+	tenantID, userID := uuid.New(), uuid.New()
+	return &cancelFixture{
+		db:       db,
+		uow:      uow,
+		bus:      bus,
+		dispatch: dispatcher,
+		ctx:      middleware.ContextWithIdentity(context.Background(), tenantID, userID),
+		tenantID: tenantID,
+		userID:   userID,
+	}
+}
+
+// operator 创建带已发布版本的算子
+func (f *cancelFixture) operator(t *testing.T, code string, mode operator.ExecMode, execConfig *operator.ExecConfig) uuid.UUID {
+	t.Helper()
+	var opID uuid.UUID
+	err := f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		op := &operator.Operator{Code: code, Name: code, Status: operator.StatusPublished}
+		if err := repos.Operators.Create(ctx, op); err != nil {
+			return err
+		}
+		version := &operator.OperatorVersion{
+			ID:         uuid.New(),
+			OperatorID: op.ID,
+			Version:    "1.0.0",
+			ExecMode:   mode,
+			ExecConfig: execConfig,
+			Status:     operator.VersionStatusActive,
+		}
+		if err := repos.OperatorVersions.Create(ctx, version); err != nil {
+			return err
+		}
+		op.ActiveVersionID = &version.ID
+		opID = op.ID
+		return repos.Operators.Update(ctx, op)
+	})
+	if err != nil {
+		t.Fatalf("create operator: %v", err)
+	}
+	return opID
+}
+
+// start 创建工作流与 pending 任务并等待 started 条件满足
+func (f *cancelFixture) start(t *testing.T, nodes []workflow.Node, edges []workflow.Edge, started func() bool) *workflow.Task {
+	t.Helper()
+	task := &workflow.Task{Status: workflow.TaskStatusPending}
+	err := f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		wf := &workflow.Workflow{
+			Code:        "wf-" + uuid.NewString()[:8],
+			Name:        "cancel",
+			TriggerType: workflow.TriggerTypeManual,
+			Status:      workflow.StatusEnabled,
+		}
+		if err := repos.Workflows.Create(ctx, wf); err != nil {
+			return err
+		}
+		for i := range nodes {
+			nodes[i].WorkflowID = wf.ID
+			if err := repos.Workflows.CreateNode(ctx, &nodes[i]); err != nil {
+				return err
+			}
+		}
+		for i := range edges {
+			edges[i].WorkflowID = wf.ID
+			if err := repos.Workflows.CreateEdge(ctx, &edges[i]); err != nil {
+				return err
+			}
+		}
+		task.WorkflowID = wf.ID
+		return repos.Tasks.Create(ctx, task)
+	})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	f.dispatch.Notify()
+	waitUntil(t, started)
+	return task
+}
+
+func (f *cancelFixture) cancel(t *testing.T, taskID uuid.UUID) {
+	t.Helper()
+	if _, err := NewCancelTaskHandler(f.uow, f.bus).Handle(f.ctx, dto.CancelTaskCommand{ID: taskID}); err != nil {
+		t.Fatalf("cancel task: %v", err)
+	}
+}
+
+func (f *cancelFixture) task(t *testing.T, id uuid.UUID) *workflow.Task {
+	t.Helper()
+	var task *workflow.Task
+	err := f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		task, err = repos.Tasks.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	return task
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// blockingExecutor 普通节点阻塞到执行被取消，收尾节点立即成功并记录调用
+type blockingExecutor struct {
+	started chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	hooks []map[string]interface{}
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error) {
+	if taskCtx, ok := input.Params["task"].(map[string]interface{}); ok {
+		e.mu.Lock()
+		e.hooks = append(e.hooks, taskCtx)
+		e.mu.Unlock()
+		return &operator.Output{}, nil
+	}
+	e.once.Do(func() { close(e.started) })
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (e *blockingExecutor) isStarted() bool {
+	select {
+	case <-e.started:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *blockingExecutor) hookCalls() []map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]map[string]interface{}(nil), e.hooks...)
+}
+
+func TestCancelTask_RunsOnCancelHooks(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{})}
+	f := newCancelFixture(t, executor)
+	opID := f.operator(t, "worker", operator.ExecModeHTTP, nil)
+
+	task := f.start(t, []workflow.Node{
+		{ID: uuid.New(), NodeKey: "work", OperatorID: &opID},
+		{ID: uuid.New(), NodeKey: "on_cancel", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+			Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnCancel}},
+		}},
+		{ID: uuid.New(), NodeKey: "on_failure", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+			Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnFailure}},
+		}},
+	}, nil, executor.isStarted)
+
+	f.cancel(t, task.ID)
+
+	waitUntil(t, func() bool { return len(executor.hookCalls()) > 0 && f.dispatch.Running() == 0 })
+	hooks := executor.hookCalls()
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, "cancelled", hooks[0]["status"])
+	}
+
+	stored := f.task(t, task.ID)
+	assert.Equal(t, workflow.TaskStatusCancelled, stored.Status)
+	var hookExec *workflow.NodeExecution
+	for i := range stored.NodeExecutions {
+		if stored.NodeExecutions[i].NodeKey == "on_cancel" {
+			hookExec = &stored.NodeExecutions[i]
+		}
+	}
+	if assert.NotNil(t, hookExec, "on_cancel hook execution is recorded") {
+		assert.Equal(t, workflow.NodeExecSuccess, hookExec.Status)
+	}
+}
+
+func TestCancelTask_WaitingTaskRunsOnCancelHooks(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{})}
+	f := newCancelFixture(t, executor)
+	opID := f.operator(t, "worker", operator.ExecModeHTTP, nil)
+
+	var task *workflow.Task
+	waiting := func() bool { return task != nil && f.task(t, task.ID).IsWaiting() && f.dispatch.Running() == 0 }
+	task = f.start(t, []workflow.Node{
+		{ID: uuid.New(), NodeKey: "review", NodeType: workflow.NodeTypeApproval, Config: &workflow.NodeConfig{
+			Approval: &workflow.ApprovalConfig{TimeoutSeconds: 3600},
+		}},
+		{ID: uuid.New(), NodeKey: "work", OperatorID: &opID},
+		{ID: uuid.New(), NodeKey: "on_cancel", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+			Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnCancel}},
+		}},
+	}, []workflow.Edge{{ID: uuid.New(), SourceKey: "review", TargetKey: "work"}}, func() bool { return true })
+	waitUntil(t, waiting)
+
+	f.cancel(t, task.ID)
+
+	waitUntil(t, func() bool { return len(executor.hookCalls()) > 0 })
+	hooks := executor.hookCalls()
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, "cancelled", hooks[0]["status"])
+	}
+	assert.False(t, executor.isStarted(), "nodes after the approval never run")
+	assert.Equal(t, workflow.TaskStatusCancelled, f.task(t, task.ID).Status)
+}
+
+func TestCancelTask_CancelsRemoteAsyncJob(t *testing.T) {
+	var submitted, cancelled atomic.Bool
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/jobs":
+			submitted.Store(true)
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"job_id":"job-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/jobs/job-1":
+			_, _ = w.Write([]byte(`{"status":"running"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/jobs/job-1/cancel":
+			cancelled.Store(true)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	httpExecutor := adapterengine.NewHTTPOperatorExecutor()
+	registry := adapterengine.NewExecutorRegistry()
+	registry.Register(httpExecutor.Mode(), httpExecutor)
+	f := newCancelFixture(t, adapterengine.NewRoutingOperatorExecutor(registry))
+	opID := f.operator(t, "remote_job", operator.ExecModeHTTP, &operator.ExecConfig{HTTP: &operator.HTTPExecConfig{
+		Endpoint: remote.URL + "/jobs",
+		Method:   http.MethodPost,
+		Async: &operator.HTTPAsyncConfig{
+			Mode:            operator.HTTPAsyncModePoll,
+			StatusURL:       "/jobs/{job_id}",
+			CancelURL:       "/jobs/{job_id}/cancel",
+			PollIntervalSec: 1,
+		},
+	}})
+
+	task := f.start(t, []workflow.Node{{ID: uuid.New(), NodeKey: "remote", OperatorID: &opID}}, nil, submitted.Load)
+
+	f.cancel(t, task.ID)
+
+	waitUntil(t, func() bool { return cancelled.Load() && f.dispatch.Running() == 0 })
+	assert.Equal(t, workflow.TaskStatusCancelled, f.task(t, task.ID).Status)
+}
@@ -14,11 +14,12 @@ import (
 )
 
 type CompleteTaskHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewCompleteTaskHandler(uow port.UnitOfWork) *CompleteTaskHandler {
-	return &CompleteTaskHandler{uow: uow}
+func NewCompleteTaskHandler(uow port.UnitOfWork, eventBus port.EventBus) *CompleteTaskHandler {
+	return &CompleteTaskHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *CompleteTaskHandler) Handle(ctx context.Context, cmd dto.CompleteTaskCommand) (*workflow.Task, error) {
@@ -44,6 +45,9 @@ func (h *CompleteTaskHandler) Handle(ctx context.Context, cmd dto.CompleteTaskCo
 		if err := repos.Tasks.Update(ctx, task); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to complete task")
 		}
+		if err := publishTaskStatus(ctx, h.eventBus, task); err != nil {
+			return apperr.Internal("publish task event", err)
+		}
 
 		result = task
 		return nil
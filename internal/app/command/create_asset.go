@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"goyavision/internal/app/dto"
-	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/media"
 	"goyavision/pkg/apperr"
@@ -71,15 +70,15 @@ func (h *CreateAssetHandler) Handle(ctx context.Context, cmd dto.CreateAssetComm
 			Tags:       cmd.Tags,
 		}
 
-		return repos.Assets.Create(ctx, asset)
+		if err := repos.Assets.Create(ctx, asset); err != nil {
+			return err
+		}
+		// 在事务内发布，事件随资产一起提交
+		return publishAssetCreated(ctx, h.eventBus, asset)
 	})
 
 	if err != nil {
 		return nil, err
 	}
-
-	if h.eventBus != nil {
-		_ = h.eventBus.Publish(ctx, event.NewAssetCreatedEvent(asset.ID))
-	}
 	return asset, nil
 }
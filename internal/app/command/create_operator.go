@@ -65,6 +65,9 @@ func (h *CreateOperatorHandler) Handle(ctx context.Context, cmd dto.CreateOperat
 	if err := validateExecMode(execMode); err != nil {
 		return nil, err
 	}
+	if err := validateHTTPAsync(cmd.ExecConfig); err != nil {
+		return nil, err
+	}
 
 	if execMode == operator.ExecModeAIModel {
 		if cmd.ExecConfig == nil || cmd.ExecConfig.AIModel == nil {
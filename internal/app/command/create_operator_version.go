@@ -38,6 +38,9 @@ func (h *CreateOperatorVersionHandler) Handle(ctx context.Context, cmd dto.Creat
 	if err := validateExecMode(cmd.ExecMode); err != nil {
 		return nil, err
 	}
+	if err := validateHTTPAsync(cmd.ExecConfig); err != nil {
+		return nil, err
+	}
 
 	status := cmd.Status
 	if status == "" {
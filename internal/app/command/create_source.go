@@ -4,18 +4,20 @@ import (
 	"context"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/media"
 	"goyavision/pkg/apperr"
 )
 
 type CreateSourceHandler struct {
-	uow     port.UnitOfWork
-	gateway port.MediaGateway
+	uow      port.UnitOfWork
+	gateway  port.MediaGateway
+	eventBus port.EventBus
 }
 
-func NewCreateSourceHandler(uow port.UnitOfWork, gw port.MediaGateway) *CreateSourceHandler {
-	return &CreateSourceHandler{uow: uow, gateway: gw}
+func NewCreateSourceHandler(uow port.UnitOfWork, gw port.MediaGateway, eventBus port.EventBus) *CreateSourceHandler {
+	return &CreateSourceHandler{uow: uow, gateway: gw, eventBus: eventBus}
 }
 
 func (h *CreateSourceHandler) Handle(ctx context.Context, cmd dto.CreateSourceCommand) (*media.Source, error) {
@@ -49,7 +51,10 @@ func (h *CreateSourceHandler) Handle(ctx context.Context, cmd dto.CreateSourceCo
 			Protocol: cmd.Protocol,
 			Enabled:  cmd.Enabled,
 		}
-		return repos.Sources.Create(ctx, src)
+		if err := repos.Sources.Create(ctx, src); err != nil {
+			return err
+		}
+		return publishEvent(ctx, h.eventBus, event.NewSourceEvent(event.EventTypeSourceCreated, src))
 	})
 
 	if err != nil {
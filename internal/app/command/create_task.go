@@ -55,6 +55,8 @@ func (h *CreateTaskHandler) Handle(ctx context.Context, cmd dto.CreateTaskComman
 			Status:      workflow.TaskStatusPending,
 			Progress:    0,
 			InputParams: cmd.InputParams,
+			Priority:    cmd.Priority,
+			Deadline:    cmd.Deadline,
 		}
 
 		if err := repos.Tasks.Create(ctx, task); err != nil {
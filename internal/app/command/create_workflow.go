@@ -35,7 +35,8 @@ func (h *CreateWorkflowHandler) Handle(ctx context.Context, cmd dto.CreateWorkfl
 		cmd.TriggerType != workflow.TriggerTypeSchedule &&
 		cmd.TriggerType != workflow.TriggerTypeEvent &&
 		cmd.TriggerType != workflow.TriggerTypeAssetNew &&
-		cmd.TriggerType != workflow.TriggerTypeAssetDone {
+		cmd.TriggerType != workflow.TriggerTypeAssetDone &&
+		cmd.TriggerType != workflow.TriggerTypeWebhook {
 		return nil, apperr.InvalidInput("invalid trigger type")
 	}
 
@@ -44,6 +45,12 @@ func (h *CreateWorkflowHandler) Handle(ctx context.Context, cmd dto.CreateWorkfl
 		version = cmd.Version
 	}
 
+	if cmd.Timeouts != nil {
+		if err := cmd.Timeouts.Validate(); err != nil {
+			return nil, apperr.InvalidInput(err.Error())
+		}
+	}
+
 	status := workflow.StatusDraft
 	if cmd.Status != "" {
 		status = cmd.Status
@@ -58,11 +65,17 @@ func (h *CreateWorkflowHandler) Handle(ctx context.Context, cmd dto.CreateWorkfl
 		if err := validateWorkflowConnections(ctx, repos, h.schemaValidator, cmd.Nodes, cmd.Edges); err != nil {
 			return err
 		}
+		if err := validateSubWorkflowReferences(ctx, repos, &workflow.Workflow{Code: cmd.Code}, cmd.Nodes); err != nil {
+			return err
+		}
 
 		triggerConf, err := buildTriggerConfig(cmd.TriggerConf)
 		if err != nil {
 			return apperr.InvalidInput(err.Error())
 		}
+		if err := validateTriggerConfig(cmd.TriggerType, triggerConf); err != nil {
+			return apperr.InvalidInput(err.Error())
+		}
 
 		wf := &workflow.Workflow{
 			Code:        cmd.Code,
@@ -73,6 +86,7 @@ func (h *CreateWorkflowHandler) Handle(ctx context.Context, cmd dto.CreateWorkfl
 			TriggerConf: triggerConf,
 			Status:      status,
 			Tags:        cmd.Tags,
+			Timeouts:    cmd.Timeouts,
 		}
 
 		if err := repos.Workflows.Create(ctx, wf); err != nil {
@@ -115,6 +129,10 @@ func (h *CreateWorkflowHandler) Handle(ctx context.Context, cmd dto.CreateWorkfl
 			}
 		}
 
+		if _, err := recordWorkflowRevision(ctx, repos, wf.ID, "created"); err != nil {
+			return err
+		}
+
 		wfWithNodes, err := repos.Workflows.GetWithNodes(ctx, wf.ID)
 		if err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow with nodes")
@@ -3,15 +3,18 @@ package command
 import (
 	"context"
 	"testing"
+	"time"
 
 	"goyavision/internal/app/dto"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // --- Mocks ---
@@ -71,6 +74,9 @@ func (m *MockWorkflowRepo) ListEnabled(ctx context.Context) ([]*workflow.Workflo
 	args := m.Called(ctx)
 	return args.Get(0).([]*workflow.Workflow), args.Error(1)
 }
+func (m *MockWorkflowRepo) UpdateRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error {
+	return m.Called(ctx, id, lastRunAt, nextRunAt).Error(0)
+}
 func (m *MockWorkflowRepo) CreateNode(ctx context.Context, n *workflow.Node) error {
 	return m.Called(ctx, n).Error(0)
 }
@@ -92,6 +98,42 @@ func (m *MockWorkflowRepo) DeleteEdges(ctx context.Context, workflowID uuid.UUID
 	return m.Called(ctx, workflowID).Error(0)
 }
 
+type MockRevisionRepo struct {
+	mock.Mock
+}
+
+func (m *MockRevisionRepo) Create(ctx context.Context, r *workflow.Revision) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return m.Called(ctx, r).Error(0)
+}
+func (m *MockRevisionRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Revision, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workflow.Revision), args.Error(1)
+}
+func (m *MockRevisionRepo) GetByNumber(ctx context.Context, workflowID uuid.UUID, revision int) (*workflow.Revision, error) {
+	args := m.Called(ctx, workflowID, revision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workflow.Revision), args.Error(1)
+}
+func (m *MockRevisionRepo) GetLatest(ctx context.Context, workflowID uuid.UUID) (*workflow.Revision, error) {
+	args := m.Called(ctx, workflowID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workflow.Revision), args.Error(1)
+}
+func (m *MockRevisionRepo) List(ctx context.Context, workflowID uuid.UUID, limit, offset int) ([]*workflow.Revision, int64, error) {
+	args := m.Called(ctx, workflowID, limit, offset)
+	return args.Get(0).([]*workflow.Revision), args.Get(1).(int64), args.Error(2)
+}
+
 type MockOperatorRepo struct {
 	mock.Mock
 }
@@ -168,10 +210,12 @@ func TestCreateWorkflow_Persistence(t *testing.T) {
 	mockWFRepo := new(MockWorkflowRepo)
 	mockOpRepo := new(MockOperatorRepo)
 	mockValidator := new(MockSchemaValidator)
+	mockRevRepo := new(MockRevisionRepo)
 
 	mockUOW.Repos = &port.Repositories{
-		Workflows: mockWFRepo,
-		Operators: mockOpRepo,
+		Workflows:         mockWFRepo,
+		Operators:         mockOpRepo,
+		WorkflowRevisions: mockRevRepo,
 	}
 
 	handler := NewCreateWorkflowHandler(mockUOW, mockValidator)
@@ -262,13 +306,252 @@ func TestCreateWorkflow_Persistence(t *testing.T) {
 		return false
 	})).Return(nil)
 
-	// GetWithNodes for return
+	// GetWithNodes for revision and return
 	mockWFRepo.On("GetWithNodes", mock.Anything, mock.Anything).Return(&workflow.Workflow{}, nil)
 
+	// First revision recorded and set as the current revision
+	mockRevRepo.On("GetLatest", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockRevRepo.On("Create", mock.Anything, mock.MatchedBy(func(r *workflow.Revision) bool {
+		return r.Revision == 1 && r.Hash != ""
+	})).Return(nil)
+	mockWFRepo.On("Update", mock.Anything, mock.MatchedBy(func(w *workflow.Workflow) bool {
+		return w.Revision == 1 && w.RevisionID != nil
+	})).Return(nil)
+
 	// Execute
 	_, err := handler.Handle(context.Background(), cmd)
 
 	// Assert
 	assert.NoError(t, err)
 	mockWFRepo.AssertExpectations(t)
+	mockRevRepo.AssertExpectations(t)
+}
+
+func TestCreateWorkflow_InvalidEdgeExpression(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockWFRepo := new(MockWorkflowRepo)
+	mockOpRepo := new(MockOperatorRepo)
+	mockValidator := new(MockSchemaValidator)
+
+	mockUOW.Repos = &port.Repositories{
+		Workflows: mockWFRepo,
+		Operators: mockOpRepo,
+	}
+
+	handler := NewCreateWorkflowHandler(mockUOW, mockValidator)
+
+	tests := []struct {
+		name      string
+		condition map[string]interface{}
+	}{
+		{"syntax error", map[string]interface{}{"expression": "results[0].confidence >"}},
+		{"unknown function", map[string]interface{}{"expression": "exec('ls')"}},
+		{"invalid type", map[string]interface{}{"type": "sometimes"}},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockWFRepo.On("GetByCode", mock.Anything, "cond-wf").Return(nil, assert.AnError)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := dto.CreateWorkflowCommand{
+				Code:        "cond-wf",
+				Name:        "Conditional Workflow",
+				TriggerType: workflow.TriggerTypeManual,
+				Nodes: []dto.WorkflowNodeInput{
+					{NodeKey: "a", NodeType: "start"},
+					{NodeKey: "b", NodeType: "end"},
+				},
+				Edges: []dto.WorkflowEdgeInput{
+					{SourceKey: "a", TargetKey: "b", Condition: tt.condition},
+				},
+			}
+
+			_, err := handler.Handle(context.Background(), cmd)
+
+			var appErr *apperr.Error
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, apperr.CodeInvalidInput, appErr.Code)
+			}
+		})
+	}
+
+	mockWFRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateWorkflow_InvalidMapNode(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockWFRepo := new(MockWorkflowRepo)
+	mockValidator := new(MockSchemaValidator)
+
+	mockUOW.Repos = &port.Repositories{
+		Workflows: mockWFRepo,
+		Operators: new(MockOperatorRepo),
+	}
+
+	handler := NewCreateWorkflowHandler(mockUOW, mockValidator)
+
+	opID := uuid.New()
+	tests := []struct {
+		name string
+		node dto.WorkflowNodeInput
+	}{
+		{"missing operator", dto.WorkflowNodeInput{
+			NodeKey: "detect", NodeType: workflow.NodeTypeMap,
+			Config: map[string]interface{}{"map": map[string]interface{}{"items": "extract_assets"}},
+		}},
+		{"missing items", dto.WorkflowNodeInput{
+			NodeKey: "detect", NodeType: workflow.NodeTypeMap, OperatorID: &opID,
+			Config: map[string]interface{}{"map": map[string]interface{}{"parallelism": float64(4)}},
+		}},
+		{"parallelism too high", dto.WorkflowNodeInput{
+			NodeKey: "detect", NodeType: workflow.NodeTypeMap, OperatorID: &opID,
+			Config: map[string]interface{}{"map": map[string]interface{}{"items": "extract_assets", "parallelism": float64(1000)}},
+		}},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockWFRepo.On("GetByCode", mock.Anything, "map-wf").Return(nil, assert.AnError)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := dto.CreateWorkflowCommand{
+				Code:        "map-wf",
+				Name:        "Map Workflow",
+				TriggerType: workflow.TriggerTypeManual,
+				Nodes:       []dto.WorkflowNodeInput{tt.node},
+			}
+
+			_, err := handler.Handle(context.Background(), cmd)
+
+			var appErr *apperr.Error
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, apperr.CodeInvalidInput, appErr.Code)
+			}
+		})
+	}
+
+	mockWFRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateWorkflow_InputMappingNonAncestor(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockWFRepo := new(MockWorkflowRepo)
+	mockValidator := new(MockSchemaValidator)
+
+	mockUOW.Repos = &port.Repositories{
+		Workflows: mockWFRepo,
+		Operators: new(MockOperatorRepo),
+	}
+
+	handler := NewCreateWorkflowHandler(mockUOW, mockValidator)
+
+	tests := []struct {
+		name string
+		from string
+	}{
+		{"sibling node", "b.results[0].data"},
+		{"sibling node by key", "nodes['b'].results"},
+		{"downstream node", "d.diagnostics"},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockWFRepo.On("GetByCode", mock.Anything, "mapping-wf").Return(nil, assert.AnError)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := dto.CreateWorkflowCommand{
+				Code:        "mapping-wf",
+				Name:        "Mapping Workflow",
+				TriggerType: workflow.TriggerTypeManual,
+				Nodes: []dto.WorkflowNodeInput{
+					{NodeKey: "a", NodeType: "start"},
+					{NodeKey: "b", NodeType: "start"},
+					{NodeKey: "c", NodeType: "end", Config: map[string]interface{}{
+						"inputs": []interface{}{
+							map[string]interface{}{"from": "a.results", "to": "params.items"},
+							map[string]interface{}{"from": tt.from, "to": "params.extra"},
+						},
+					}},
+					{NodeKey: "d", NodeType: "end"},
+				},
+				Edges: []dto.WorkflowEdgeInput{
+					{SourceKey: "a", TargetKey: "c"},
+					{SourceKey: "b", TargetKey: "d"},
+					{SourceKey: "c", TargetKey: "d"},
+				},
+			}
+
+			_, err := handler.Handle(context.Background(), cmd)
+
+			var appErr *apperr.Error
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, apperr.CodeInvalidInput, appErr.Code)
+			}
+		})
+	}
+
+	mockWFRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateWorkflow_SubWorkflowCycle(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockWFRepo := new(MockWorkflowRepo)
+	mockValidator := new(MockSchemaValidator)
+
+	mockUOW.Repos = &port.Repositories{
+		Workflows: mockWFRepo,
+		Operators: new(MockOperatorRepo),
+	}
+
+	handler := NewCreateWorkflowHandler(mockUOW, mockValidator)
+
+	// library -> shared -> cycle-wf (the workflow being created)
+	library := &workflow.Workflow{ID: uuid.New(), Code: "library"}
+	shared := &workflow.Workflow{
+		ID:   uuid.New(),
+		Code: "shared",
+		Nodes: []workflow.Node{{
+			NodeKey:  "back",
+			NodeType: workflow.NodeTypeSubWorkflow,
+			Config: &workflow.NodeConfig{
+				SubWorkflow: &workflow.SubWorkflowConfig{WorkflowCode: "cycle-wf"},
+			},
+		}},
+	}
+	library.Nodes = []workflow.Node{{
+		NodeKey:  "shared",
+		NodeType: workflow.NodeTypeSubWorkflow,
+		Config: &workflow.NodeConfig{
+			SubWorkflow: &workflow.SubWorkflowConfig{WorkflowID: &shared.ID},
+		},
+	}}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockWFRepo.On("GetByCode", mock.Anything, "cycle-wf").Return(nil, assert.AnError)
+	mockWFRepo.On("GetByCode", mock.Anything, "library").Return(library, nil)
+	mockWFRepo.On("GetWithNodes", mock.Anything, library.ID).Return(library, nil)
+	mockWFRepo.On("GetWithNodes", mock.Anything, shared.ID).Return(shared, nil)
+
+	cmd := dto.CreateWorkflowCommand{
+		Code:        "cycle-wf",
+		Name:        "Cycle Workflow",
+		TriggerType: workflow.TriggerTypeManual,
+		Nodes: []dto.WorkflowNodeInput{{
+			NodeKey:  "call",
+			NodeType: workflow.NodeTypeSubWorkflow,
+			Config: map[string]interface{}{
+				"sub_workflow": map[string]interface{}{"workflow_code": "library"},
+			},
+		}},
+	}
+
+	_, err := handler.Handle(context.Background(), cmd)
+
+	var appErr *apperr.Error
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, apperr.CodeInvalidInput, appErr.Code)
+		assert.Contains(t, appErr.Message, "cycle")
+	}
+	mockWFRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 }
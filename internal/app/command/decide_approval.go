@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+// DecideApprovalHandler 记录审批节点的通过/驳回决策，任务随后由调度器恢复执行
+type DecideApprovalHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewDecideApprovalHandler(uow port.UnitOfWork) *DecideApprovalHandler {
+	return &DecideApprovalHandler{uow: uow}
+}
+
+func (h *DecideApprovalHandler) Handle(ctx context.Context, cmd dto.DecideApprovalCommand) (*workflow.Task, error) {
+	if cmd.NodeKey == "" {
+		return nil, apperr.InvalidInput("node_key is required")
+	}
+
+	var result *workflow.Task
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		task, err := repos.Tasks.Get(ctx, cmd.TaskID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("task", cmd.TaskID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get task")
+		}
+
+		if err := task.DecideApproval(cmd.NodeKey, cmd.Approved, cmd.UserID, cmd.Comment, cmd.Payload); err != nil {
+			if errors.Is(err, workflow.ErrApprovalDecided) {
+				return apperr.Conflict(err.Error())
+			}
+			return apperr.InvalidInput(err.Error())
+		}
+
+		if err := repos.Tasks.Update(ctx, task); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to record approval decision")
+		}
+
+		result = task
+		return nil
+	})
+
+	return result, err
+}
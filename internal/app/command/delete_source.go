@@ -4,17 +4,19 @@ import (
 	"context"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/pkg/apperr"
 )
 
 type DeleteSourceHandler struct {
-	uow     port.UnitOfWork
-	gateway port.MediaGateway
+	uow      port.UnitOfWork
+	gateway  port.MediaGateway
+	eventBus port.EventBus
 }
 
-func NewDeleteSourceHandler(uow port.UnitOfWork, gw port.MediaGateway) *DeleteSourceHandler {
-	return &DeleteSourceHandler{uow: uow, gateway: gw}
+func NewDeleteSourceHandler(uow port.UnitOfWork, gw port.MediaGateway, eventBus port.EventBus) *DeleteSourceHandler {
+	return &DeleteSourceHandler{uow: uow, gateway: gw, eventBus: eventBus}
 }
 
 func (h *DeleteSourceHandler) Handle(ctx context.Context, cmd dto.DeleteSourceCommand) error {
@@ -42,7 +44,10 @@ func (h *DeleteSourceHandler) Handle(ctx context.Context, cmd dto.DeleteSourceCo
 			return apperr.Wrap(err, apperr.CodeServiceUnavailable, "mediamtx delete path failed")
 		}
 
-		return repos.Sources.Delete(ctx, cmd.ID)
+		if err := repos.Sources.Delete(ctx, cmd.ID); err != nil {
+			return err
+		}
+		return publishEvent(ctx, h.eventBus, event.NewSourceEvent(event.EventTypeSourceDeleted, src))
 	})
 
 	return err
@@ -33,6 +33,10 @@ func (h *DeleteTaskHandler) Handle(ctx context.Context, cmd dto.DeleteTaskComman
 			return apperr.InvalidInput("cannot delete running task")
 		}
 
+		if err := repos.TaskCheckpoints.DeleteByTask(ctx, cmd.ID); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to delete task checkpoints")
+		}
+
 		if err := repos.Tasks.Delete(ctx, cmd.ID); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to delete task")
 		}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
 	"goyavision/pkg/apperr"
@@ -13,17 +14,18 @@ import (
 )
 
 type DeprecateOperatorHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewDeprecateOperatorHandler(uow port.UnitOfWork) *DeprecateOperatorHandler {
-	return &DeprecateOperatorHandler{uow: uow}
+func NewDeprecateOperatorHandler(uow port.UnitOfWork, eventBus port.EventBus) *DeprecateOperatorHandler {
+	return &DeprecateOperatorHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *DeprecateOperatorHandler) Handle(ctx context.Context, cmd dto.DeprecateOperatorCommand) (*operator.Operator, error) {
 	var result *operator.Operator
 	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
-		op, err := repos.Operators.Get(ctx, cmd.ID)
+		op, err := repos.Operators.GetWithActiveVersion(ctx, cmd.ID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return apperr.NotFound("operator", cmd.ID.String())
@@ -35,6 +37,9 @@ func (h *DeprecateOperatorHandler) Handle(ctx context.Context, cmd dto.Deprecate
 		if err := repos.Operators.Update(ctx, op); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to deprecate operator")
 		}
+		if err := publishEvent(ctx, h.eventBus, event.NewOperatorEvent(event.EventTypeOperatorDeprecated, op)); err != nil {
+			return apperr.Internal("publish operator event", err)
+		}
 
 		result = op
 		return nil
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/workflow"
 	"goyavision/pkg/apperr"
@@ -13,11 +14,12 @@ import (
 )
 
 type EnableWorkflowHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewEnableWorkflowHandler(uow port.UnitOfWork) *EnableWorkflowHandler {
-	return &EnableWorkflowHandler{uow: uow}
+func NewEnableWorkflowHandler(uow port.UnitOfWork, eventBus port.EventBus) *EnableWorkflowHandler {
+	return &EnableWorkflowHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *EnableWorkflowHandler) Handle(ctx context.Context, cmd dto.EnableWorkflowCommand) (*workflow.Workflow, error) {
@@ -31,7 +33,10 @@ func (h *EnableWorkflowHandler) Handle(ctx context.Context, cmd dto.EnableWorkfl
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow")
 		}
 
+		previous := wf.Status
+		eventType := event.EventTypeWorkflowDisabled
 		if cmd.Enabled {
+			eventType = event.EventTypeWorkflowEnabled
 			if len(wf.Nodes) == 0 {
 				return apperr.InvalidInput("workflow must have at least one node to enable")
 			}
@@ -43,6 +48,11 @@ func (h *EnableWorkflowHandler) Handle(ctx context.Context, cmd dto.EnableWorkfl
 		if err := repos.Workflows.Update(ctx, wf); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to update workflow status")
 		}
+		if wf.Status != previous {
+			if err := publishEvent(ctx, h.eventBus, event.NewWorkflowEvent(eventType, wf)); err != nil {
+				return apperr.Internal("publish workflow event", err)
+			}
+		}
 
 		result = wf
 		return nil
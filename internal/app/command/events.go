@@ -0,0 +1,43 @@
+package command
+
+import (
+	"context"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/workflow"
+)
+
+// publishEvent 发布事件，bus 为 nil 时不发布。
+// 在 uow.Do 内以事务上下文调用，事件随事务一起提交，回滚时不会发布。
+func publishEvent(ctx context.Context, bus port.EventBus, ev port.Event) error {
+	if bus == nil {
+		return nil
+	}
+	return bus.Publish(ctx, ev)
+}
+
+// publishTaskStatus 发布任务当前状态，与工作流引擎发布的 task_status 事件格式一致
+func publishTaskStatus(ctx context.Context, bus port.EventBus, task *workflow.Task) error {
+	return publishEvent(ctx, bus, newTaskStatusEvent(task))
+}
+
+func newTaskStatusEvent(task *workflow.Task) *event.TaskEvent {
+	ev := event.NewTaskEvent(event.EventTypeTaskStatus, task.ID, task.TenantID, task.WorkflowID)
+	ev.Status = string(task.Status)
+	ev.Progress = task.Progress
+	ev.Error = task.Error
+	return ev
+}
+
+// publishAssetCreated 发布 asset_new，创建时已就绪的资产同时发布 asset_done
+func publishAssetCreated(ctx context.Context, bus port.EventBus, asset *media.Asset) error {
+	if err := publishEvent(ctx, bus, event.NewAssetCreatedEvent(asset)); err != nil {
+		return err
+	}
+	if asset.IsReady() {
+		return publishEvent(ctx, bus, event.NewAssetDoneEvent(asset))
+	}
+	return nil
+}
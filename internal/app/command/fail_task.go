@@ -14,11 +14,12 @@ import (
 )
 
 type FailTaskHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewFailTaskHandler(uow port.UnitOfWork) *FailTaskHandler {
-	return &FailTaskHandler{uow: uow}
+func NewFailTaskHandler(uow port.UnitOfWork, eventBus port.EventBus) *FailTaskHandler {
+	return &FailTaskHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *FailTaskHandler) Handle(ctx context.Context, cmd dto.FailTaskCommand) (*workflow.Task, error) {
@@ -44,6 +45,9 @@ func (h *FailTaskHandler) Handle(ctx context.Context, cmd dto.FailTaskCommand) (
 		if err := repos.Tasks.Update(ctx, task); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to mark task as failed")
 		}
+		if err := publishTaskStatus(ctx, h.eventBus, task); err != nil {
+			return apperr.Internal("publish task event", err)
+		}
 
 		result = task
 		return nil
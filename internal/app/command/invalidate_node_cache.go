@@ -0,0 +1,45 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+type InvalidateNodeCacheHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewInvalidateNodeCacheHandler(uow port.UnitOfWork) *InvalidateNodeCacheHandler {
+	return &InvalidateNodeCacheHandler{uow: uow}
+}
+
+// Handle 删除算子版本的节点结果缓存，返回删除的缓存项数量
+func (h *InvalidateNodeCacheHandler) Handle(ctx context.Context, cmd dto.InvalidateNodeCacheCommand) (int64, error) {
+	var deleted int64
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		version, err := repos.OperatorVersions.Get(ctx, cmd.VersionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("operator_version", cmd.VersionID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get operator version")
+		}
+		if version.OperatorID != cmd.OperatorID {
+			return apperr.InvalidInput("version does not belong to operator")
+		}
+
+		deleted, err = repos.NodeCache.DeleteByOperatorVersion(ctx, version.ID)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to invalidate node cache")
+		}
+		return nil
+	})
+
+	return deleted, err
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+// ReceiveMediaHookHandler 处理 MediaMTX 路径回调，发布媒体源上线、下线与录制分段完成事件
+type ReceiveMediaHookHandler struct {
+	uow      port.UnitOfWork
+	eventBus port.EventBus
+}
+
+func NewReceiveMediaHookHandler(uow port.UnitOfWork, eventBus port.EventBus) *ReceiveMediaHookHandler {
+	return &ReceiveMediaHookHandler{uow: uow, eventBus: eventBus}
+}
+
+func (h *ReceiveMediaHookHandler) Handle(ctx context.Context, cmd dto.ReceiveMediaHookCommand) error {
+	if cmd.PathName == "" {
+		return apperr.InvalidInput("path is required")
+	}
+	if cmd.Event == port.MediaHookSegmentComplete && cmd.SegmentPath == "" {
+		return apperr.InvalidInput("segment_path is required")
+	}
+
+	return h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		src, err := repos.Sources.GetByPathNameUnscoped(ctx, cmd.PathName)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("media source", cmd.PathName)
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get source")
+		}
+
+		var ev port.Event
+		switch cmd.Event {
+		case port.MediaHookReady:
+			ev = event.NewSourceEvent(event.EventTypeSourceOnline, src)
+		case port.MediaHookNotReady:
+			ev = event.NewSourceEvent(event.EventTypeSourceOffline, src)
+		case port.MediaHookSegmentComplete:
+			ev = event.NewRecordingSegmentEvent(src, cmd.SegmentPath, cmd.SegmentDuration)
+		default:
+			return apperr.InvalidInput("unknown media hook event: " + cmd.Event)
+		}
+		return publishEvent(ctx, h.eventBus, ev)
+	})
+}
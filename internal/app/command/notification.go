@@ -0,0 +1,257 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateNotificationSubscriptionHandler struct {
+	uow    port.UnitOfWork
+	crypto port.CryptoService
+	guard  port.URLGuard
+}
+
+func NewCreateNotificationSubscriptionHandler(uow port.UnitOfWork, crypto port.CryptoService, guard port.URLGuard) *CreateNotificationSubscriptionHandler {
+	return &CreateNotificationSubscriptionHandler{uow: uow, crypto: crypto, guard: guard}
+}
+
+// Handle 创建订阅；webhook 渠道返回签名密钥明文（仅此一次可见），其他渠道返回空字符串
+func (h *CreateNotificationSubscriptionHandler) Handle(ctx context.Context, cmd dto.CreateNotificationSubscriptionCommand) (*notification.Subscription, string, error) {
+	sub := &notification.Subscription{
+		Name:       cmd.Name,
+		Channel:    cmd.Channel,
+		EventTypes: cmd.EventTypes,
+		URL:        cmd.URL,
+		ChatFormat: cmd.ChatFormat,
+		Recipients: cmd.Recipients,
+		Enabled:    true,
+	}
+	if sub.Channel == notification.ChannelChat && sub.ChatFormat == "" {
+		sub.ChatFormat = notification.ChatFormatGeneric
+	}
+	if err := sub.Validate(event.IsTriggerEventType); err != nil {
+		return nil, "", apperr.InvalidInput(err.Error())
+	}
+	if err := checkSubscriptionURL(ctx, h.guard, sub); err != nil {
+		return nil, "", err
+	}
+
+	var secret string
+	if sub.Channel == notification.ChannelWebhook {
+		var err error
+		secret, err = newWebhookSecret()
+		if err != nil {
+			return nil, "", apperr.Internal("failed to generate webhook secret", err)
+		}
+		sub.Secret, err = h.crypto.Encrypt(secret)
+		if err != nil {
+			return nil, "", apperr.Internal("failed to encrypt webhook secret", err)
+		}
+	}
+
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := repos.NotificationSubscriptions.Create(ctx, sub); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to create notification subscription")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return sub, secret, nil
+}
+
+type UpdateNotificationSubscriptionHandler struct {
+	uow   port.UnitOfWork
+	guard port.URLGuard
+}
+
+func NewUpdateNotificationSubscriptionHandler(uow port.UnitOfWork, guard port.URLGuard) *UpdateNotificationSubscriptionHandler {
+	return &UpdateNotificationSubscriptionHandler{uow: uow, guard: guard}
+}
+
+func (h *UpdateNotificationSubscriptionHandler) Handle(ctx context.Context, cmd dto.UpdateNotificationSubscriptionCommand) (*notification.Subscription, error) {
+	var result *notification.Subscription
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		sub, err := getNotificationSubscription(ctx, repos, cmd.ID)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Name != nil {
+			sub.Name = *cmd.Name
+		}
+		if cmd.EventTypes != nil {
+			sub.EventTypes = cmd.EventTypes
+		}
+		if cmd.URL != nil {
+			sub.URL = *cmd.URL
+		}
+		if cmd.ChatFormat != nil {
+			sub.ChatFormat = *cmd.ChatFormat
+		}
+		if cmd.Recipients != nil {
+			sub.Recipients = cmd.Recipients
+		}
+		if cmd.Enabled != nil {
+			if *cmd.Enabled {
+				sub.Enable()
+			} else {
+				sub.Enabled = false
+			}
+		}
+		if err := sub.Validate(event.IsTriggerEventType); err != nil {
+			return apperr.InvalidInput(err.Error())
+		}
+		if cmd.URL != nil {
+			if err := checkSubscriptionURL(ctx, h.guard, sub); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.NotificationSubscriptions.Update(ctx, sub); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to update notification subscription")
+		}
+		result = sub
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type DeleteNotificationSubscriptionHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewDeleteNotificationSubscriptionHandler(uow port.UnitOfWork) *DeleteNotificationSubscriptionHandler {
+	return &DeleteNotificationSubscriptionHandler{uow: uow}
+}
+
+// Handle 删除订阅，其等待中的投递在下次尝试时置为 failed
+func (h *DeleteNotificationSubscriptionHandler) Handle(ctx context.Context, cmd dto.DeleteNotificationSubscriptionCommand) error {
+	return h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if _, err := getNotificationSubscription(ctx, repos, cmd.ID); err != nil {
+			return err
+		}
+		if err := repos.NotificationSubscriptions.Delete(ctx, cmd.ID); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to delete notification subscription")
+		}
+		return nil
+	})
+}
+
+type RotateNotificationSecretHandler struct {
+	uow    port.UnitOfWork
+	crypto port.CryptoService
+}
+
+func NewRotateNotificationSecretHandler(uow port.UnitOfWork, crypto port.CryptoService) *RotateNotificationSecretHandler {
+	return &RotateNotificationSecretHandler{uow: uow, crypto: crypto}
+}
+
+// Handle 轮换签名密钥并返回新密钥明文（仅此一次可见）
+func (h *RotateNotificationSecretHandler) Handle(ctx context.Context, cmd dto.RotateNotificationSecretCommand) (*notification.Subscription, string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", apperr.Internal("failed to generate webhook secret", err)
+	}
+	encrypted, err := h.crypto.Encrypt(secret)
+	if err != nil {
+		return nil, "", apperr.Internal("failed to encrypt webhook secret", err)
+	}
+
+	var result *notification.Subscription
+	err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		sub, err := getNotificationSubscription(ctx, repos, cmd.ID)
+		if err != nil {
+			return err
+		}
+		if sub.Channel != notification.ChannelWebhook {
+			return apperr.InvalidInput("only webhook subscriptions have a signing secret")
+		}
+		sub.Secret = encrypted
+		if err := repos.NotificationSubscriptions.Update(ctx, sub); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to rotate notification secret")
+		}
+		result = sub
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return result, secret, nil
+}
+
+type ReplayNotificationDeliveryHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewReplayNotificationDeliveryHandler(uow port.UnitOfWork) *ReplayNotificationDeliveryHandler {
+	return &ReplayNotificationDeliveryHandler{uow: uow}
+}
+
+// Handle 创建一条以原事件内容重新投递的记录，在分发器下一个轮询周期发送
+func (h *ReplayNotificationDeliveryHandler) Handle(ctx context.Context, cmd dto.ReplayNotificationDeliveryCommand) (*notification.Delivery, error) {
+	var result *notification.Delivery
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		original, err := repos.NotificationDeliveries.Get(ctx, cmd.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("notification delivery", cmd.ID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get notification delivery")
+		}
+		sub, err := getNotificationSubscription(ctx, repos, original.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if !sub.Enabled {
+			return apperr.InvalidInput("notification subscription is disabled")
+		}
+
+		replay := original.Replay(time.Now())
+		if err := repos.NotificationDeliveries.Create(ctx, replay); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to create notification delivery")
+		}
+		result = replay
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func getNotificationSubscription(ctx context.Context, repos *port.Repositories, id uuid.UUID) (*notification.Subscription, error) {
+	sub, err := repos.NotificationSubscriptions.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.NotFound("notification subscription", id.String())
+		}
+		return nil, apperr.Wrap(err, apperr.CodeDBError, "failed to get notification subscription")
+	}
+	return sub, nil
+}
+
+// checkSubscriptionURL 校验 webhook、chat 渠道的地址不指向内网等不允许访问的地址，guard 为 nil 时不校验
+func checkSubscriptionURL(ctx context.Context, guard port.URLGuard, sub *notification.Subscription) error {
+	if guard == nil || sub.Channel == notification.ChannelEmail {
+		return nil
+	}
+	if err := guard.CheckURL(ctx, sub.URL); err != nil {
+		return apperr.InvalidInput(err.Error())
+	}
+	return nil
+}
@@ -23,3 +23,13 @@ func validateSemver(version string) error {
 	}
 	return nil
 }
+
+func validateHTTPAsync(execConfig *operator.ExecConfig) error {
+	if execConfig == nil || execConfig.HTTP == nil || execConfig.HTTP.Async == nil {
+		return nil
+	}
+	if err := execConfig.HTTP.Async.Validate(); err != nil {
+		return apperr.InvalidInput("invalid http.async: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RerunTaskHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewRerunTaskHandler(uow port.UnitOfWork) *RerunTaskHandler {
+	return &RerunTaskHandler{uow: uow}
+}
+
+// Handle 基于已结束的任务创建重跑任务。
+//
+// 不重新执行的节点从原任务的检查点（没有检查点时由节点产物还原）复制输出与执行记录，
+// 其产物复制到新任务，新任务入队后由引擎按检查点恢复，只执行其余节点。重跑任务沿用原任务的工作流修订与算子版本。
+func (h *RerunTaskHandler) Handle(ctx context.Context, cmd dto.RerunTaskCommand) (*workflow.Task, error) {
+	if cmd.Mode == "" {
+		cmd.Mode = workflow.RerunModeFull
+	}
+	if !cmd.Mode.IsValid() {
+		return nil, apperr.InvalidInput("invalid rerun mode")
+	}
+
+	var result *workflow.Task
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		parent, err := repos.Tasks.Get(ctx, cmd.TaskID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("task", cmd.TaskID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get task")
+		}
+		if !parent.IsCompleted() {
+			return apperr.InvalidInput("only completed tasks can be re-run")
+		}
+		if parent.IsSubTask() {
+			return apperr.InvalidInput("sub workflow tasks are re-run through their caller task")
+		}
+
+		wf, err := repos.Workflows.GetWithNodes(ctx, parent.WorkflowID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("workflow", parent.WorkflowID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow")
+		}
+		if !wf.IsEnabled() {
+			return apperr.InvalidInput("workflow is not enabled")
+		}
+		// 按原任务执行的修订重跑，不受之后对工作流的编辑影响
+		if parent.RevisionID != nil && (wf.RevisionID == nil || *wf.RevisionID != *parent.RevisionID) {
+			rev, err := repos.WorkflowRevisions.Get(ctx, *parent.RevisionID)
+			if err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow revision")
+			}
+			wf = rev.Apply(wf)
+		}
+
+		outputs, executions, err := h.reusableOutputs(ctx, repos, parent)
+		if err != nil {
+			return err
+		}
+
+		// 已补偿或临时产物已删除的节点必须重新执行
+		undone := workflow.UndoneNodes(wf, parent)
+		rerun, err := workflow.PlanRerun(wf, parent, cmd.Mode, cmd.NodeKey, func(nodeKey string) bool {
+			_, ok := executions[nodeKey]
+			return ok && !undone[nodeKey]
+		})
+		if err != nil {
+			return apperr.InvalidInput(err.Error())
+		}
+
+		inputParams := make(map[string]interface{}, len(parent.InputParams)+len(cmd.InputParams))
+		for k, v := range parent.InputParams {
+			inputParams[k] = v
+		}
+		for k, v := range cmd.InputParams {
+			inputParams[k] = v
+		}
+
+		priority := parent.Priority
+		if cmd.Priority != nil {
+			priority = *cmd.Priority
+		}
+
+		task := &workflow.Task{
+			ID:           uuid.New(),
+			WorkflowID:   parent.WorkflowID,
+			AssetID:      parent.AssetID,
+			ParentTaskID: &parent.ID,
+			RevisionID:   parent.RevisionID,
+			Status:       workflow.TaskStatusPending,
+			Progress:     0,
+			InputParams:  inputParams,
+			Priority:     priority,
+		}
+		if parent.OperatorVersions != nil {
+			task.OperatorVersions = make(map[string]uuid.UUID, len(parent.OperatorVersions))
+			for k, v := range parent.OperatorVersions {
+				task.OperatorVersions[k] = v
+			}
+		}
+		copier := &artifactCopier{repos: repos, parentID: parent.ID}
+		var checkpoints []*workflow.TaskCheckpoint
+		for _, node := range wf.Nodes {
+			if rerun[node.NodeKey] {
+				continue
+			}
+			execution := executions[node.NodeKey]
+			if execution.ArtifactIDs, err = copier.copy(ctx, node.NodeKey, execution.ArtifactIDs); err != nil {
+				return err
+			}
+			task.NodeExecutions = append(task.NodeExecutions, execution)
+			checkpoints = append(checkpoints, &workflow.TaskCheckpoint{
+				NodeKey:   node.NodeKey,
+				Output:    outputs[node.NodeKey],
+				Execution: execution,
+			})
+		}
+
+		if err := repos.Tasks.Create(ctx, task); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to create task")
+		}
+		for _, a := range copier.copies {
+			a.TaskID = task.ID
+			if err := repos.Artifacts.Create(ctx, a); err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to copy artifact")
+			}
+		}
+		for _, cp := range checkpoints {
+			cp.TenantID = task.TenantID
+			cp.TaskID = task.ID
+			if err := repos.TaskCheckpoints.Save(ctx, cp); err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to save checkpoint")
+			}
+		}
+
+		taskWithRelations, err := repos.Tasks.GetWithRelations(ctx, task.ID)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get task with relations")
+		}
+		result = taskWithRelations
+		return nil
+	})
+
+	return result, err
+}
+
+// reusableOutputs 返回原任务中可复用节点的输出与执行记录：优先使用检查点，
+// 没有检查点的已成功节点由其产物还原输出
+func (h *RerunTaskHandler) reusableOutputs(
+	ctx context.Context,
+	repos *port.Repositories,
+	parent *workflow.Task,
+) (map[string]*operator.Output, map[string]workflow.NodeExecution, error) {
+	outputs := make(map[string]*operator.Output)
+	executions := make(map[string]workflow.NodeExecution)
+
+	checkpoints, err := repos.TaskCheckpoints.ListByTask(ctx, parent.ID)
+	if err != nil {
+		return nil, nil, apperr.Wrap(err, apperr.CodeDBError, "failed to list checkpoints")
+	}
+	for _, cp := range checkpoints {
+		if cp.IsCompleted() {
+			outputs[cp.NodeKey] = cp.Output
+			executions[cp.NodeKey] = cp.Execution
+		}
+	}
+
+	var artifacts map[uuid.UUID]*workflow.Artifact
+	for _, ne := range parent.NodeExecutions {
+		if _, ok := executions[ne.NodeKey]; ok {
+			continue
+		}
+		switch {
+		case ne.Status == workflow.NodeExecSkipped:
+			executions[ne.NodeKey] = ne
+		case ne.Status.IsSucceeded():
+			if artifacts == nil {
+				list, err := repos.Artifacts.ListByTask(ctx, parent.ID)
+				if err != nil {
+					return nil, nil, apperr.Wrap(err, apperr.CodeDBError, "failed to list artifacts")
+				}
+				artifacts = make(map[uuid.UUID]*workflow.Artifact, len(list))
+				for _, a := range list {
+					artifacts[a.ID] = a
+				}
+			}
+			var nodeArtifacts []*workflow.Artifact
+			for _, id := range ne.ArtifactIDs {
+				if a, ok := artifacts[id]; ok {
+					nodeArtifacts = append(nodeArtifacts, a)
+				}
+			}
+			outputs[ne.NodeKey] = workflow.OutputFromArtifacts(nodeArtifacts)
+			executions[ne.NodeKey] = ne
+		}
+	}
+	return outputs, executions, nil
+}
+
+// artifactCopier 为复用的节点复制原任务的产物，使重跑任务拥有自己的产物，
+// 删除原任务不会留下悬空引用；原任务中已不存在的产物不再引用
+type artifactCopier struct {
+	repos    *port.Repositories
+	parentID uuid.UUID
+	sources  map[uuid.UUID]*workflow.Artifact
+	// copies 待在重跑任务创建后写入的产物
+	copies []*workflow.Artifact
+}
+
+// copy 复制节点的产物并返回新产物 ID
+func (c *artifactCopier) copy(ctx context.Context, nodeKey string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if c.sources == nil {
+		list, err := c.repos.Artifacts.ListByTask(ctx, c.parentID)
+		if err != nil {
+			return nil, apperr.Wrap(err, apperr.CodeDBError, "failed to list artifacts")
+		}
+		c.sources = make(map[uuid.UUID]*workflow.Artifact, len(list))
+		for _, a := range list {
+			c.sources[a.ID] = a
+		}
+	}
+
+	copied := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		source, ok := c.sources[id]
+		if !ok {
+			continue
+		}
+		var data workflow.ArtifactData
+		if source.Data != nil {
+			data = *source.Data
+		}
+		data.Metadata = maps.Clone(data.Metadata)
+		if data.Metadata == nil {
+			data.Metadata = make(map[string]interface{})
+		}
+		data.Metadata["node_key"] = nodeKey
+		data.Metadata["rerun_from"] = source.ID.String()
+		artifact := &workflow.Artifact{
+			ID:        uuid.New(),
+			Type:      source.Type,
+			AssetID:   source.AssetID,
+			Data:      &data,
+			Temporary: source.Temporary,
+		}
+		c.copies = append(c.copies, artifact)
+		copied = append(copied, artifact.ID)
+	}
+	return copied, nil
+}
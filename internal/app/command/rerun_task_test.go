@@ -0,0 +1,237 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	infrapersistence "goyavision/internal/infra/persistence"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rerunFixture 原任务为 probe -> transcode -> thumbnail 三个节点的工作流，
+// probe 成功并保存一个产物，之后的节点按用例设置状态
+type rerunFixture struct {
+	uow      port.UnitOfWork
+	ctx      context.Context
+	parent   *workflow.Task
+	artifact *workflow.Artifact
+}
+
+func newRerunFixture(t *testing.T, status workflow.TaskStatus, transcode, thumbnail workflow.NodeExecutionStatus) *rerunFixture {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := persistence.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	f := &rerunFixture{
+		uow: infrapersistence.NewUnitOfWork(db),
+		ctx: middleware.ContextWithIdentity(context.Background(), uuid.New(), uuid.New()),
+	}
+	err = f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		wf := &workflow.Workflow{
+			Code:        "rerun-" + uuid.NewString()[:8],
+			Name:        "rerun",
+			TriggerType: workflow.TriggerTypeManual,
+			Status:      workflow.StatusEnabled,
+		}
+		if err := repos.Workflows.Create(ctx, wf); err != nil {
+			return err
+		}
+		for _, key := range []string{"probe", "transcode", "thumbnail"} {
+			if err := repos.Workflows.CreateNode(ctx, &workflow.Node{WorkflowID: wf.ID, NodeKey: key}); err != nil {
+				return err
+			}
+		}
+		for _, edge := range [][2]string{{"probe", "transcode"}, {"transcode", "thumbnail"}} {
+			if err := repos.Workflows.CreateEdge(ctx, &workflow.Edge{WorkflowID: wf.ID, SourceKey: edge[0], TargetKey: edge[1]}); err != nil {
+				return err
+			}
+		}
+
+		f.parent = &workflow.Task{
+			ID:          uuid.New(),
+			WorkflowID:  wf.ID,
+			Status:      status,
+			Priority:    3,
+			InputParams: map[string]interface{}{"format": "mp4", "width": 1280.0},
+		}
+		f.artifact = &workflow.Artifact{
+			ID:     uuid.New(),
+			Type:   workflow.ArtifactTypeResult,
+			Data:   &workflow.ArtifactData{Results: []map[string]interface{}{{"type": "probe"}}},
+			TaskID: f.parent.ID,
+		}
+		f.parent.NodeExecutions = []workflow.NodeExecution{
+			{NodeKey: "probe", Status: workflow.NodeExecSuccess, ArtifactIDs: []uuid.UUID{f.artifact.ID}},
+			{NodeKey: "transcode", Status: transcode},
+			{NodeKey: "thumbnail", Status: thumbnail},
+		}
+		if err := repos.Tasks.Create(ctx, f.parent); err != nil {
+			return err
+		}
+		return repos.Artifacts.Create(ctx, f.artifact)
+	})
+	if err != nil {
+		t.Fatalf("create parent task: %v", err)
+	}
+	return f
+}
+
+func (f *rerunFixture) rerun(t *testing.T, cmd dto.RerunTaskCommand) *workflow.Task {
+	t.Helper()
+	cmd.TaskID = f.parent.ID
+	task, err := NewRerunTaskHandler(f.uow).Handle(f.ctx, cmd)
+	if err != nil {
+		t.Fatalf("rerun task: %v", err)
+	}
+	return task
+}
+
+// reused 返回重跑任务中复用的节点及其检查点
+func (f *rerunFixture) reused(t *testing.T, task *workflow.Task) (map[string]workflow.NodeExecution, map[string]*workflow.TaskCheckpoint) {
+	t.Helper()
+	executions := make(map[string]workflow.NodeExecution)
+	for _, ne := range task.NodeExecutions {
+		executions[ne.NodeKey] = ne
+	}
+	checkpoints := make(map[string]*workflow.TaskCheckpoint)
+	err := f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		list, err := repos.TaskCheckpoints.ListByTask(ctx, task.ID)
+		for _, cp := range list {
+			checkpoints[cp.NodeKey] = cp
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("list checkpoints: %v", err)
+	}
+	return executions, checkpoints
+}
+
+func TestRerunTask_Full(t *testing.T) {
+	f := newRerunFixture(t, workflow.TaskStatusSuccess, workflow.NodeExecSuccess, workflow.NodeExecSuccess)
+	task := f.rerun(t, dto.RerunTaskCommand{})
+
+	if task.ParentTaskID == nil || *task.ParentTaskID != f.parent.ID {
+		t.Errorf("ParentTaskID = %v, want %s", task.ParentTaskID, f.parent.ID)
+	}
+	if task.Status != workflow.TaskStatusPending || task.Priority != 3 {
+		t.Errorf("task status = %s, priority = %d", task.Status, task.Priority)
+	}
+	executions, checkpoints := f.reused(t, task)
+	if len(executions) != 0 || len(checkpoints) != 0 {
+		t.Errorf("full rerun reused %d nodes with %d checkpoints, want none", len(executions), len(checkpoints))
+	}
+}
+
+func TestRerunTask_FailedOnly(t *testing.T) {
+	f := newRerunFixture(t, workflow.TaskStatusFailed, workflow.NodeExecFailed, workflow.NodeExecPending)
+	task := f.rerun(t, dto.RerunTaskCommand{Mode: workflow.RerunModeFailed})
+
+	executions, checkpoints := f.reused(t, task)
+	if len(executions) != 1 || len(checkpoints) != 1 {
+		t.Fatalf("reused nodes = %v, checkpoints = %d, want probe only", executions, len(checkpoints))
+	}
+	probe, ok := executions["probe"]
+	if !ok || checkpoints["probe"] == nil {
+		t.Fatalf("probe not reused: %v", executions)
+	}
+	if n := len(checkpoints["probe"].Output.Results); n != 1 {
+		t.Errorf("probe checkpoint results = %d, want 1", n)
+	}
+
+	// 复用节点的产物复制到重跑任务，原任务删除后不留下悬空引用
+	if len(probe.ArtifactIDs) != 1 || probe.ArtifactIDs[0] == f.artifact.ID {
+		t.Fatalf("probe artifact ids = %v, want one copy of %s", probe.ArtifactIDs, f.artifact.ID)
+	}
+	if ids := checkpoints["probe"].Execution.ArtifactIDs; len(ids) != 1 || ids[0] != probe.ArtifactIDs[0] {
+		t.Errorf("checkpoint artifact ids = %v, want %v", ids, probe.ArtifactIDs)
+	}
+	err := f.uow.Do(f.ctx, func(ctx context.Context, repos *port.Repositories) error {
+		copied, err := repos.Artifacts.Get(ctx, probe.ArtifactIDs[0])
+		if err != nil {
+			return err
+		}
+		if copied.TaskID != task.ID || copied.Type != f.artifact.Type {
+			t.Errorf("copied artifact = %+v, want task %s", copied, task.ID)
+		}
+		if copied.Data == nil || copied.Data.Metadata["rerun_from"] != f.artifact.ID.String() {
+			t.Errorf("copied artifact data = %+v", copied.Data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("get copied artifact: %v", err)
+	}
+}
+
+func TestRerunTask_FailedOnlyRejectsSucceededTask(t *testing.T) {
+	f := newRerunFixture(t, workflow.TaskStatusSuccess, workflow.NodeExecSuccess, workflow.NodeExecSuccess)
+	_, err := NewRerunTaskHandler(f.uow).Handle(f.ctx, dto.RerunTaskCommand{TaskID: f.parent.ID, Mode: workflow.RerunModeFailed})
+	if err == nil {
+		t.Fatal("rerun of failed nodes in a succeeded task should fail")
+	}
+}
+
+func TestRerunTask_FromNode(t *testing.T) {
+	f := newRerunFixture(t, workflow.TaskStatusSuccess, workflow.NodeExecSuccess, workflow.NodeExecSuccess)
+	task := f.rerun(t, dto.RerunTaskCommand{Mode: workflow.RerunModeFromNode, NodeKey: "transcode"})
+
+	executions, checkpoints := f.reused(t, task)
+	if _, ok := executions["probe"]; !ok || len(executions) != 1 || len(checkpoints) != 1 {
+		t.Errorf("reused nodes = %v, want probe only", executions)
+	}
+
+	_, err := NewRerunTaskHandler(f.uow).Handle(f.ctx, dto.RerunTaskCommand{
+		TaskID:  f.parent.ID,
+		Mode:    workflow.RerunModeFromNode,
+		NodeKey: "missing",
+	})
+	if err == nil {
+		t.Error("rerun from an unknown node should fail")
+	}
+}
+
+func TestRerunTask_InputParamsOverride(t *testing.T) {
+	f := newRerunFixture(t, workflow.TaskStatusSuccess, workflow.NodeExecSuccess, workflow.NodeExecSuccess)
+	priority := 9
+	task := f.rerun(t, dto.RerunTaskCommand{
+		InputParams: map[string]interface{}{"width": 640.0, "crf": 23.0},
+		Priority:    &priority,
+	})
+
+	want := map[string]interface{}{"format": "mp4", "width": 640.0, "crf": 23.0}
+	if len(task.InputParams) != len(want) {
+		t.Fatalf("InputParams = %v, want %v", task.InputParams, want)
+	}
+	for k, v := range want {
+		if task.InputParams[k] != v {
+			t.Errorf("InputParams[%s] = %v, want %v", k, task.InputParams[k], v)
+		}
+	}
+	if task.Priority != 9 {
+		t.Errorf("Priority = %d, want 9", task.Priority)
+	}
+	// 原任务的输入参数不受影响
+	if f.parent.InputParams["width"] != 1280.0 {
+		t.Errorf("parent InputParams = %v", f.parent.InputParams)
+	}
+}
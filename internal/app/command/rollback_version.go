@@ -9,15 +9,16 @@ import (
 )
 
 type RollbackVersionHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewRollbackVersionHandler(uow port.UnitOfWork) *RollbackVersionHandler {
-	return &RollbackVersionHandler{uow: uow}
+func NewRollbackVersionHandler(uow port.UnitOfWork, eventBus port.EventBus) *RollbackVersionHandler {
+	return &RollbackVersionHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *RollbackVersionHandler) Handle(ctx context.Context, cmd dto.RollbackVersionCommand) (*operator.Operator, error) {
-	activateHandler := NewActivateVersionHandler(h.uow)
+	activateHandler := NewActivateVersionHandler(h.uow, h.eventBus)
 	return activateHandler.Handle(ctx, dto.ActivateVersionCommand{
 		OperatorID: cmd.OperatorID,
 		VersionID:  cmd.VersionID,
@@ -14,11 +14,12 @@ import (
 )
 
 type StartTaskHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewStartTaskHandler(uow port.UnitOfWork) *StartTaskHandler {
-	return &StartTaskHandler{uow: uow}
+func NewStartTaskHandler(uow port.UnitOfWork, eventBus port.EventBus) *StartTaskHandler {
+	return &StartTaskHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *StartTaskHandler) Handle(ctx context.Context, cmd dto.StartTaskCommand) (*workflow.Task, error) {
@@ -44,6 +45,9 @@ func (h *StartTaskHandler) Handle(ctx context.Context, cmd dto.StartTaskCommand)
 		if err := repos.Tasks.Update(ctx, task); err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to start task")
 		}
+		if err := publishTaskStatus(ctx, h.eventBus, task); err != nil {
+			return apperr.Internal("publish task event", err)
+		}
 
 		result = task
 		return nil
@@ -4,17 +4,19 @@ import (
 	"context"
 
 	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/media"
 	"goyavision/pkg/apperr"
 )
 
 type UpdateAssetHandler struct {
-	uow port.UnitOfWork
+	uow      port.UnitOfWork
+	eventBus port.EventBus
 }
 
-func NewUpdateAssetHandler(uow port.UnitOfWork) *UpdateAssetHandler {
-	return &UpdateAssetHandler{uow: uow}
+func NewUpdateAssetHandler(uow port.UnitOfWork, eventBus port.EventBus) *UpdateAssetHandler {
+	return &UpdateAssetHandler{uow: uow, eventBus: eventBus}
 }
 
 func (h *UpdateAssetHandler) Handle(ctx context.Context, cmd dto.UpdateAssetCommand) (*media.Asset, error) {
@@ -29,6 +31,7 @@ func (h *UpdateAssetHandler) Handle(ctx context.Context, cmd dto.UpdateAssetComm
 			return apperr.Internal("get asset", err)
 		}
 
+		previous := asset.Status
 		if cmd.Name != nil {
 			asset.Name = *cmd.Name
 		}
@@ -45,7 +48,21 @@ func (h *UpdateAssetHandler) Handle(ctx context.Context, cmd dto.UpdateAssetComm
 			asset.Visibility = *cmd.Visibility
 		}
 
-		return repos.Assets.Update(ctx, asset)
+		if err := repos.Assets.Update(ctx, asset); err != nil {
+			return err
+		}
+
+		// 状态变更随更新一起提交；变为 ready 时同时发布 asset_done
+		if asset.Status == previous {
+			return nil
+		}
+		if err := publishEvent(ctx, h.eventBus, event.NewAssetStatusEvent(asset, previous)); err != nil {
+			return err
+		}
+		if asset.IsReady() {
+			return publishEvent(ctx, h.eventBus, event.NewAssetDoneEvent(asset))
+		}
+		return nil
 	})
 
 	if err != nil {
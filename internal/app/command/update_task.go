@@ -37,7 +37,8 @@ func (h *UpdateTaskHandler) Handle(ctx context.Context, cmd dto.UpdateTaskComman
 				now := time.Now()
 				task.StartedAt = &now
 			}
-			if (*cmd.Status == workflow.TaskStatusSuccess || *cmd.Status == workflow.TaskStatusFailed || *cmd.Status == workflow.TaskStatusCancelled) && task.CompletedAt == nil {
+			if (*cmd.Status == workflow.TaskStatusSuccess || *cmd.Status == workflow.TaskStatusFailed || *cmd.Status == workflow.TaskStatusCancelled ||
+				*cmd.Status == workflow.TaskStatusTimedOut) && task.CompletedAt == nil {
 				now := time.Now()
 				task.CompletedAt = &now
 			}
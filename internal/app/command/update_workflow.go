@@ -22,6 +22,12 @@ func NewUpdateWorkflowHandler(uow port.UnitOfWork, schemaValidator port.SchemaVa
 }
 
 func (h *UpdateWorkflowHandler) Handle(ctx context.Context, cmd dto.UpdateWorkflowCommand) (*workflow.Workflow, error) {
+	if cmd.Timeouts != nil {
+		if err := cmd.Timeouts.Validate(); err != nil {
+			return nil, apperr.InvalidInput(err.Error())
+		}
+	}
+
 	var result *workflow.Workflow
 	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		wf, err := repos.Workflows.Get(ctx, cmd.ID)
@@ -43,6 +49,9 @@ func (h *UpdateWorkflowHandler) Handle(ctx context.Context, cmd dto.UpdateWorkfl
 			if err != nil {
 				return apperr.InvalidInput(err.Error())
 			}
+			if err := validateTriggerConfig(wf.TriggerType, triggerConf); err != nil {
+				return apperr.InvalidInput(err.Error())
+			}
 			wf.TriggerConf = triggerConf
 		}
 		if cmd.Status != nil {
@@ -54,11 +63,17 @@ func (h *UpdateWorkflowHandler) Handle(ctx context.Context, cmd dto.UpdateWorkfl
 		if cmd.Visibility != nil {
 			wf.Visibility = *cmd.Visibility
 		}
+		if cmd.Timeouts != nil {
+			wf.Timeouts = cmd.Timeouts
+		}
 
 		if len(cmd.Nodes) > 0 {
 			if err := validateWorkflowConnections(ctx, repos, h.schemaValidator, cmd.Nodes, cmd.Edges); err != nil {
 				return err
 			}
+			if err := validateSubWorkflowReferences(ctx, repos, wf, cmd.Nodes); err != nil {
+				return err
+			}
 
 			if err := repos.Workflows.DeleteNodes(ctx, wf.ID); err != nil {
 				return apperr.Wrap(err, apperr.CodeDBError, "failed to delete old nodes")
@@ -106,6 +121,12 @@ func (h *UpdateWorkflowHandler) Handle(ctx context.Context, cmd dto.UpdateWorkfl
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to update workflow")
 		}
 
+		if len(cmd.Nodes) > 0 {
+			if _, err := recordWorkflowRevision(ctx, repos, wf.ID, "updated"); err != nil {
+				return err
+			}
+		}
+
 		wfWithNodes, err := repos.Workflows.GetWithNodes(ctx, wf.ID)
 		if err != nil {
 			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow with nodes")
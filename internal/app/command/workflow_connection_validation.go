@@ -7,7 +7,10 @@ import (
 	"goyavision/internal/app/dto"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
 	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
 )
 
 func validateWorkflowConnections(
@@ -17,6 +20,24 @@ func validateWorkflowConnections(
 	nodes []dto.WorkflowNodeInput,
 	edges []dto.WorkflowEdgeInput,
 ) error {
+	if err := validateWorkflowNodes(nodes); err != nil {
+		return err
+	}
+	if err := validateEdgeConditions(edges); err != nil {
+		return err
+	}
+	if err := validateHookEdges(nodes, edges); err != nil {
+		return err
+	}
+
+	configs := make(map[string]*workflow.NodeConfig, len(nodes))
+	for i := range nodes {
+		configs[nodes[i].NodeKey] = parseNodeConfig(nodes[i].Config)
+	}
+	if err := validateInputMappings(nodes, configs, edges); err != nil {
+		return err
+	}
+
 	if validator == nil {
 		return apperr.ServiceUnavailable("schema validator is not configured")
 	}
@@ -34,6 +55,15 @@ func validateWorkflowConnections(
 		}
 		nodeOperatorMap[n.NodeKey] = op
 	}
+	for i := range nodes {
+		cfg := configs[nodes[i].NodeKey]
+		if cfg == nil || cfg.Compensate == nil {
+			continue
+		}
+		if _, err := repos.Operators.Get(ctx, cfg.Compensate.OperatorID); err != nil {
+			return apperr.NotFound("operator", cfg.Compensate.OperatorID)
+		}
+	}
 
 	for i := range edges {
 		e := edges[i]
@@ -42,6 +72,10 @@ func validateWorkflowConnections(
 		if upstream == nil || downstream == nil {
 			continue
 		}
+		// 声明了输入映射的节点按映射校验，见下方
+		if hasInputMappings(configs[e.TargetKey]) {
+			continue
+		}
 
 		upstreamOutputSpec := getOperatorOutputSpec(upstream)
 		if len(upstreamOutputSpec) == 0 {
@@ -62,6 +96,271 @@ func validateWorkflowConnections(
 		}
 	}
 
+	for i := range nodes {
+		n := nodes[i]
+		cfg := configs[n.NodeKey]
+		downstream := nodeOperatorMap[n.NodeKey]
+		if downstream == nil || !hasInputMappings(cfg) {
+			continue
+		}
+
+		downstreamInputSchema := getOperatorInputSchema(downstream)
+		if len(downstreamInputSchema) == 0 {
+			return apperr.InvalidInput(fmt.Sprintf("workflow connection invalid: 下游节点 %s 的输入 Schema 缺失", n.NodeKey))
+		}
+
+		mappedSpec := buildMappedInputSpec(cfg, nodeOperatorMap)
+		if err := validator.ValidateConnection(ctx, mappedSpec, downstreamInputSchema); err != nil {
+			return apperr.Wrap(
+				err,
+				apperr.CodeInvalidInput,
+				fmt.Sprintf("workflow input mapping invalid: %s", n.NodeKey),
+			)
+		}
+	}
+
+	return nil
+}
+
+// validateInputMappings 校验输入映射只引用目标节点的祖先节点或任务输入
+func validateInputMappings(nodes []dto.WorkflowNodeInput, configs map[string]*workflow.NodeConfig, edges []dto.WorkflowEdgeInput) error {
+	wfEdges := make([]workflow.Edge, 0, len(edges))
+	for i := range edges {
+		wfEdges = append(wfEdges, workflow.Edge{SourceKey: edges[i].SourceKey, TargetKey: edges[i].TargetKey})
+	}
+	ancestors := workflow.Ancestors(wfEdges)
+
+	for i := range nodes {
+		key := nodes[i].NodeKey
+		cfg := configs[key]
+		if !hasInputMappings(cfg) {
+			continue
+		}
+		for _, m := range cfg.Inputs {
+			refs, err := mappingReferences(m)
+			if err != nil {
+				return apperr.Wrap(err, apperr.CodeInvalidInput, fmt.Sprintf("workflow input mapping invalid: %s", key))
+			}
+			for _, ref := range refs {
+				if !ancestors[key][ref] {
+					return apperr.InvalidInput(fmt.Sprintf("workflow input mapping invalid: %s: %q 引用了非祖先节点 %s", key, m.From, ref))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mappingReferences 返回映射源表达式引用的上游节点 key
+func mappingReferences(m workflow.InputMapping) ([]string, error) {
+	program, err := m.Program()
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, name := range program.Variables() {
+		switch name {
+		case workflow.MappingVarInput:
+		case workflow.MappingVarNodes:
+			if root, segments, ok := program.Path(); ok && root == name && len(segments) > 0 && !segments[0].IsIndex {
+				refs = append(refs, segments[0].Field)
+			}
+		default:
+			refs = append(refs, name)
+		}
+	}
+	return refs, nil
+}
+
+// buildMappedInputSpec 根据输入映射与静态参数构造目标节点实际收到的参数 Schema，
+// 源字段类型取自上游算子输出 Schema 中对应路径，无法推断时不限制类型
+func buildMappedInputSpec(cfg *workflow.NodeConfig, nodeOperatorMap map[string]*operator.Operator) map[string]interface{} {
+	props := make(map[string]interface{})
+	for k := range cfg.Params {
+		props[k] = map[string]interface{}{}
+	}
+	for _, m := range cfg.Inputs {
+		target := m.TargetPath()
+		if len(target) == 0 {
+			continue
+		}
+		if len(target) > 1 {
+			props[target[0]] = map[string]interface{}{"type": "object"}
+			continue
+		}
+		props[target[0]] = mappedSourceSchema(m, nodeOperatorMap)
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func mappedSourceSchema(m workflow.InputMapping, nodeOperatorMap map[string]*operator.Operator) map[string]interface{} {
+	program, err := m.Program()
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	root, segments, ok := program.Path()
+	if !ok {
+		return map[string]interface{}{}
+	}
+	if root == workflow.MappingVarNodes {
+		if len(segments) == 0 || segments[0].IsIndex {
+			return map[string]interface{}{}
+		}
+		root, segments = segments[0].Field, segments[1:]
+	}
+
+	schema := getOperatorOutputSpec(nodeOperatorMap[root])
+	if len(schema) == 0 {
+		return map[string]interface{}{}
+	}
+	for _, seg := range segments {
+		var next interface{}
+		if seg.IsIndex {
+			next = schema["items"]
+		} else if props, ok := schema["properties"].(map[string]interface{}); ok {
+			next = props[seg.Field]
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{}
+		}
+		schema = child
+	}
+	return schema
+}
+
+func hasInputMappings(cfg *workflow.NodeConfig) bool {
+	return cfg != nil && len(cfg.Inputs) > 0
+}
+
+func validateWorkflowNodes(nodes []dto.WorkflowNodeInput) error {
+	for i := range nodes {
+		n := nodes[i]
+		node := workflow.Node{
+			NodeKey:    n.NodeKey,
+			NodeType:   n.NodeType,
+			OperatorID: n.OperatorID,
+			Config:     parseNodeConfig(n.Config),
+		}
+		if err := node.Validate(); err != nil {
+			return apperr.Wrap(err, apperr.CodeInvalidInput, fmt.Sprintf("workflow node invalid: %s", n.NodeKey))
+		}
+	}
+	return nil
+}
+
+// validateHookEdges 校验收尾节点不参与连线
+func validateHookEdges(nodes []dto.WorkflowNodeInput, edges []dto.WorkflowEdgeInput) error {
+	wfNodes := make([]workflow.Node, 0, len(nodes))
+	for i := range nodes {
+		wfNodes = append(wfNodes, workflow.Node{NodeKey: nodes[i].NodeKey, NodeType: nodes[i].NodeType})
+	}
+	wfEdges := make([]workflow.Edge, 0, len(edges))
+	for i := range edges {
+		wfEdges = append(wfEdges, workflow.Edge{SourceKey: edges[i].SourceKey, TargetKey: edges[i].TargetKey})
+	}
+	if err := workflow.ValidateHookEdges(wfNodes, wfEdges); err != nil {
+		return apperr.Wrap(err, apperr.CodeInvalidInput, "workflow connection invalid")
+	}
+	return nil
+}
+
+// validateSubWorkflowReferences 校验子工作流节点引用的工作流存在，且引用链中不出现循环（含引用自身）
+func validateSubWorkflowReferences(
+	ctx context.Context,
+	repos *port.Repositories,
+	self *workflow.Workflow,
+	nodes []dto.WorkflowNodeInput,
+) error {
+	root := make([]workflow.Node, 0, len(nodes))
+	for i := range nodes {
+		root = append(root, workflow.Node{
+			NodeKey:  nodes[i].NodeKey,
+			NodeType: nodes[i].NodeType,
+			Config:   parseNodeConfig(nodes[i].Config),
+		})
+	}
+
+	checker := &subWorkflowCycleChecker{
+		ctx:       ctx,
+		repo:      repos.Workflows,
+		revisions: repos.WorkflowRevisions,
+		self:      self,
+		onPath:    make(map[uuid.UUID]bool),
+		checked:   make(map[uuid.UUID]bool),
+	}
+	return checker.visit(self.Code, root, 0)
+}
+
+type subWorkflowCycleChecker struct {
+	ctx       context.Context
+	repo      workflow.Repository
+	revisions workflow.RevisionRepository
+	self      *workflow.Workflow
+	onPath    map[uuid.UUID]bool
+	checked   map[uuid.UUID]bool
+}
+
+func (c *subWorkflowCycleChecker) visit(code string, nodes []workflow.Node, depth int) error {
+	for i := range nodes {
+		n := nodes[i]
+		if !n.IsSubWorkflow() || n.Config == nil || n.Config.SubWorkflow == nil {
+			continue
+		}
+		cfg := n.Config.SubWorkflow
+
+		if depth >= workflow.MaxSubWorkflowDepth {
+			return apperr.InvalidInput(fmt.Sprintf("sub workflow nesting exceeds %d levels", workflow.MaxSubWorkflowDepth))
+		}
+		if (c.self.ID != uuid.Nil && cfg.Matches(c.self)) || (cfg.WorkflowCode != "" && cfg.WorkflowCode == c.self.Code) {
+			return apperr.InvalidInput(fmt.Sprintf("sub workflow cycle detected: %s node %s references workflow %s", code, n.NodeKey, c.self.Code))
+		}
+
+		target, err := workflow.ResolveSubWorkflow(c.ctx, c.repo, c.revisions, cfg)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeInvalidInput, fmt.Sprintf("sub workflow node invalid: %s", n.NodeKey))
+		}
+		if target.ID == c.self.ID {
+			return apperr.InvalidInput(fmt.Sprintf("sub workflow cycle detected: %s node %s references workflow %s", code, n.NodeKey, c.self.Code))
+		}
+		if c.onPath[target.ID] {
+			return apperr.InvalidInput(fmt.Sprintf("sub workflow cycle detected: %s node %s references workflow %s", code, n.NodeKey, target.Code))
+		}
+		if c.checked[target.ID] {
+			continue
+		}
+
+		c.onPath[target.ID] = true
+		if err := c.visit(target.Code, target.Nodes, depth+1); err != nil {
+			return err
+		}
+		delete(c.onPath, target.ID)
+		c.checked[target.ID] = true
+	}
+	return nil
+}
+
+func validateEdgeConditions(edges []dto.WorkflowEdgeInput) error {
+	for i := range edges {
+		e := edges[i]
+		if len(e.Condition) == 0 {
+			continue
+		}
+		cond := parseEdgeCondition(e.Condition)
+		if cond == nil {
+			return apperr.InvalidInput(fmt.Sprintf("workflow edge condition invalid: %s -> %s: 条件格式错误", e.SourceKey, e.TargetKey))
+		}
+		if err := cond.Validate(); err != nil {
+			return apperr.Wrap(
+				err,
+				apperr.CodeInvalidInput,
+				fmt.Sprintf("workflow edge condition invalid: %s -> %s", e.SourceKey, e.TargetKey),
+			)
+		}
+	}
 	return nil
 }
 
@@ -1,9 +1,13 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"goyavision/internal/app/event"
 	"goyavision/internal/domain/workflow"
+
+	"github.com/robfig/cron/v3"
 )
 
 func buildTriggerConfig(raw map[string]interface{}) (*workflow.TriggerConfig, error) {
@@ -32,6 +36,41 @@ func buildTriggerConfig(raw map[string]interface{}) (*workflow.TriggerConfig, er
 		}
 	}
 
+	if v, ok := raw["timezone"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("trigger_conf.timezone must be string")
+		}
+		tc.Timezone = s
+	}
+
+	if v, ok := raw["overlap"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("trigger_conf.overlap must be string")
+		}
+		tc.Overlap = workflow.OverlapPolicy(s)
+	}
+
+	if v, ok := raw["misfire"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("trigger_conf.misfire must be string")
+		}
+		tc.Misfire = workflow.MisfirePolicy(s)
+	}
+
+	if v, ok := raw["catch_up_limit"]; ok {
+		switch iv := v.(type) {
+		case float64:
+			tc.CatchUpLimit = int(iv)
+		case int:
+			tc.CatchUpLimit = iv
+		default:
+			return nil, fmt.Errorf("trigger_conf.catch_up_limit must be number")
+		}
+	}
+
 	if v, ok := raw["event_type"]; ok {
 		s, ok := v.(string)
 		if !ok {
@@ -40,6 +79,18 @@ func buildTriggerConfig(raw map[string]interface{}) (*workflow.TriggerConfig, er
 		tc.EventType = s
 	}
 
+	if v, ok := raw["webhook"]; ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("trigger_conf.webhook must be object")
+		}
+		var wc workflow.WebhookTriggerConfig
+		if err := json.Unmarshal(data, &wc); err != nil {
+			return nil, fmt.Errorf("trigger_conf.webhook: %v", err)
+		}
+		tc.Webhook = &wc
+	}
+
 	if v, ok := raw["event_filter"]; ok {
 		m, ok := v.(map[string]interface{})
 		if !ok {
@@ -50,3 +101,33 @@ func buildTriggerConfig(raw map[string]interface{}) (*workflow.TriggerConfig, er
 
 	return tc, nil
 }
+
+// validateTriggerConfig 校验事件过滤条件、调度策略与 webhook 配置；event 触发器须指定已注册的 event_type
+func validateTriggerConfig(triggerType workflow.TriggerType, tc *workflow.TriggerConfig) error {
+	if err := tc.ValidateEventFilter(); err != nil {
+		return err
+	}
+	if err := tc.ValidateSchedule(); err != nil {
+		return err
+	}
+	if tc != nil && tc.Schedule != "" {
+		if _, err := cron.ParseStandard(tc.Schedule); err != nil {
+			return fmt.Errorf("invalid trigger_conf.schedule: %v", err)
+		}
+	}
+	if tc != nil {
+		if err := tc.Webhook.Validate(); err != nil {
+			return err
+		}
+	}
+	if triggerType != workflow.TriggerTypeEvent {
+		return nil
+	}
+	if tc == nil || tc.EventType == "" {
+		return fmt.Errorf("trigger_conf.event_type is required for event trigger")
+	}
+	if !event.IsTriggerEventType(tc.EventType) {
+		return fmt.Errorf("unknown event type: %s", tc.EventType)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// webhookSecretPrefix 签名密钥前缀，便于在日志与配置中识别
+const webhookSecretPrefix = "whsec_"
+
+type RotateWorkflowWebhookHandler struct {
+	uow    port.UnitOfWork
+	crypto port.CryptoService
+}
+
+func NewRotateWorkflowWebhookHandler(uow port.UnitOfWork, crypto port.CryptoService) *RotateWorkflowWebhookHandler {
+	return &RotateWorkflowWebhookHandler{uow: uow, crypto: crypto}
+}
+
+// Handle 首次调用时创建 webhook，之后轮换签名密钥；返回端点与新密钥明文（仅此一次可见）
+func (h *RotateWorkflowWebhookHandler) Handle(ctx context.Context, cmd dto.RotateWorkflowWebhookCommand) (*workflow.Webhook, string, error) {
+	grace := workflow.DefaultWebhookGraceSeconds
+	if cmd.GraceSeconds != nil {
+		if *cmd.GraceSeconds < 0 {
+			return nil, "", apperr.InvalidInput("grace_seconds must not be negative")
+		}
+		grace = *cmd.GraceSeconds
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", apperr.Internal("failed to generate webhook secret", err)
+	}
+	encrypted, err := h.crypto.Encrypt(secret)
+	if err != nil {
+		return nil, "", apperr.Internal("failed to encrypt webhook secret", err)
+	}
+
+	var result *workflow.Webhook
+	err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		wf, err := repos.Workflows.Get(ctx, cmd.WorkflowID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("workflow", cmd.WorkflowID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow")
+		}
+		if wf.TriggerType != workflow.TriggerTypeWebhook {
+			return apperr.InvalidInput("workflow trigger_type is not webhook")
+		}
+
+		now := time.Now()
+		wh, err := repos.WorkflowWebhooks.GetByWorkflow(ctx, wf.ID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow webhook")
+			}
+			wh = &workflow.Webhook{
+				TenantID:   wf.TenantID,
+				WorkflowID: wf.ID,
+				Secret:     encrypted,
+				RotatedAt:  now,
+			}
+			if err := repos.WorkflowWebhooks.Create(ctx, wh); err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to create workflow webhook")
+			}
+			result = wh
+			return nil
+		}
+
+		wh.PreviousSecret = ""
+		wh.PreviousExpiresAt = nil
+		if grace > 0 {
+			expiresAt := now.Add(time.Duration(grace) * time.Second)
+			wh.PreviousSecret = wh.Secret
+			wh.PreviousExpiresAt = &expiresAt
+		}
+		wh.Secret = encrypted
+		wh.RotatedAt = now
+		if err := repos.WorkflowWebhooks.Update(ctx, wh); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to rotate workflow webhook secret")
+		}
+		result = wh
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return result, secret, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
+
+// ReceiveWorkflowWebhookHandler 处理入站 webhook：先校验时间戳、签名并登记 nonce，
+// 再按 trigger_conf.webhook 映射请求体并创建任务。配置了 media_url 时只登记 pending 资产并发布
+// webhook_media_requested，由 app.WebhookMediaIngester 在后台下载媒体后创建任务。
+//
+// 鉴权失败（端点不存在、缺少请求头、时间戳超出窗口、签名错误、nonce 重放）统一返回 401 且不写投递记录，
+// 未持有密钥的调用方无法借此探测端点与工作流状态或写入任意数量的记录；鉴权通过后的每次请求都写入投递记录。
+type ReceiveWorkflowWebhookHandler struct {
+	uow          port.UnitOfWork
+	crypto       port.CryptoService
+	guard        port.URLGuard
+	eventBus     port.EventBus
+	withIdentity func(ctx context.Context, tenantID, userID uuid.UUID) context.Context
+}
+
+// NewReceiveWorkflowWebhookHandler guard 校验 media_url 的目标地址，
+// withIdentity 为入站请求构造 webhook 所属租户与用户的上下文
+func NewReceiveWorkflowWebhookHandler(
+	uow port.UnitOfWork,
+	crypto port.CryptoService,
+	guard port.URLGuard,
+	eventBus port.EventBus,
+	withIdentity func(ctx context.Context, tenantID, userID uuid.UUID) context.Context,
+) *ReceiveWorkflowWebhookHandler {
+	return &ReceiveWorkflowWebhookHandler{
+		uow:          uow,
+		crypto:       crypto,
+		guard:        guard,
+		eventBus:     eventBus,
+		withIdentity: withIdentity,
+	}
+}
+
+// webhookUnauthorized 鉴权失败的统一错误，不区分具体原因
+func webhookUnauthorized() error {
+	return apperr.Unauthorized("webhook authentication failed")
+}
+
+func (h *ReceiveWorkflowWebhookHandler) Handle(ctx context.Context, cmd dto.ReceiveWorkflowWebhookCommand) (*workflow.WebhookDelivery, error) {
+	now := time.Now()
+	wh, wf, ctx, err := h.authenticate(ctx, cmd, now)
+	if err != nil {
+		return nil, err
+	}
+
+	body := string(cmd.Body)
+	if len(body) > workflow.WebhookDeliveryBodyLimit {
+		body = body[:workflow.WebhookDeliveryBodyLimit]
+	}
+	delivery := &workflow.WebhookDelivery{
+		TenantID:   wh.TenantID,
+		WebhookID:  wh.ID,
+		WorkflowID: wh.WorkflowID,
+		Status:     workflow.WebhookDeliveryRejected,
+		Nonce:      cmd.Nonce,
+		RemoteAddr: cmd.RemoteAddr,
+		Body:       body,
+		ReceivedAt: now,
+	}
+
+	// 受理成功时投递记录已随任务或资产一并写入
+	handleErr := h.receive(ctx, wh, wf, cmd, delivery)
+	if handleErr == nil {
+		return delivery, nil
+	}
+	delivery.Error = handleErr.Error()
+	if err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		return repos.WorkflowWebhooks.CreateDelivery(ctx, delivery)
+	}); err != nil {
+		log.Printf("[Webhook] record delivery webhook=%s: %v", wh.ID, err)
+	}
+	return delivery, handleErr
+}
+
+// authenticate 读取端点并校验时间戳、签名，通过后登记 nonce，返回带 webhook 所属租户与用户身份的上下文。
+// 工作流仅用于读取时间戳容差，不存在时返回 nil，其可触发性在鉴权通过后由 receive 检查
+func (h *ReceiveWorkflowWebhookHandler) authenticate(ctx context.Context, cmd dto.ReceiveWorkflowWebhookCommand, now time.Time) (*workflow.Webhook, *workflow.Workflow, context.Context, error) {
+	if cmd.Timestamp == "" || cmd.Nonce == "" || cmd.Signature == "" || len(cmd.Nonce) > 128 {
+		return nil, nil, ctx, webhookUnauthorized()
+	}
+
+	var wh *workflow.Webhook
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		wh, err = repos.WorkflowWebhooks.Get(ctx, cmd.WebhookID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ctx, webhookUnauthorized()
+	}
+	if err != nil {
+		return nil, nil, ctx, apperr.Wrap(err, apperr.CodeDBError, "failed to get webhook")
+	}
+
+	ctx = h.withIdentity(ctx, wh.TenantID, webhookUser(wh))
+
+	var wf *workflow.Workflow
+	err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		wf, err = repos.Workflows.Get(ctx, wh.WorkflowID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			wf = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, ctx, apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow")
+	}
+	cfg := webhookConfig(wf)
+
+	if err := h.verify(wh, cfg, cmd, now); err != nil {
+		return nil, nil, ctx, err
+	}
+
+	err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		return repos.WorkflowWebhooks.UseNonce(ctx, wh.ID, cmd.Nonce, now.Add(2*cfg.Tolerance()))
+	})
+	if errors.Is(err, workflow.ErrWebhookReplay) {
+		return nil, nil, ctx, webhookUnauthorized()
+	}
+	if err != nil {
+		return nil, nil, ctx, apperr.Wrap(err, apperr.CodeDBError, "failed to record webhook nonce")
+	}
+	return wh, wf, ctx, nil
+}
+
+// webhookUser webhook 触发的任务以最近创建或轮换密钥的用户身份执行
+func webhookUser(wh *workflow.Webhook) uuid.UUID {
+	if wh.CreatedBy == nil {
+		return uuid.Nil
+	}
+	return *wh.CreatedBy
+}
+
+func webhookConfig(wf *workflow.Workflow) *workflow.WebhookTriggerConfig {
+	if wf == nil || wf.TriggerConf == nil {
+		return nil
+	}
+	return wf.TriggerConf.Webhook
+}
+
+// receive 请求体或工作流校验未通过时 delivery 保持 rejected，之后的失败记为 failed
+func (h *ReceiveWorkflowWebhookHandler) receive(ctx context.Context, wh *workflow.Webhook, wf *workflow.Workflow, cmd dto.ReceiveWorkflowWebhookCommand, delivery *workflow.WebhookDelivery) error {
+	if wf == nil {
+		return apperr.NotFound("workflow", wh.WorkflowID.String())
+	}
+	if wf.TriggerType != workflow.TriggerTypeWebhook {
+		return apperr.InvalidInput("workflow trigger_type is not webhook")
+	}
+	if !wf.IsEnabled() {
+		return apperr.InvalidInput("workflow is not enabled")
+	}
+	cfg := webhookConfig(wf)
+
+	var payload interface{}
+	if len(cmd.Body) > 0 {
+		if err := json.Unmarshal(cmd.Body, &payload); err != nil {
+			return apperr.InvalidInput("request body must be valid JSON")
+		}
+	}
+	params, err := cfg.BuildInput(payload)
+	if err != nil {
+		return apperr.InvalidInput(err.Error())
+	}
+	mediaURL, err := cfg.ResolveMediaURL(payload)
+	if err != nil {
+		return apperr.InvalidInput(err.Error())
+	}
+	if mediaURL != "" {
+		if h.eventBus == nil {
+			return apperr.ServiceUnavailable("media ingestion is not available")
+		}
+		if err := h.guard.CheckURL(ctx, mediaURL); err != nil {
+			return apperr.InvalidInput(fmt.Sprintf("media_url is not allowed: %v", err))
+		}
+	}
+
+	delivery.Status = workflow.WebhookDeliveryFailed
+
+	if mediaURL != "" {
+		err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+			asset := newWebhookAsset(wh, cfg, mediaURL)
+			if err := repos.Assets.Create(ctx, asset); err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to create asset")
+			}
+			delivery.AssetID = &asset.ID
+			delivery.Status = workflow.WebhookDeliveryPending
+			if err := repos.WorkflowWebhooks.CreateDelivery(ctx, delivery); err != nil {
+				return apperr.Wrap(err, apperr.CodeDBError, "failed to record webhook delivery")
+			}
+			if err := publishAssetCreated(ctx, h.eventBus, asset); err != nil {
+				return err
+			}
+			return publishEvent(ctx, h.eventBus, event.NewWebhookMediaEvent(delivery, webhookUser(wh), mediaURL, cfg.AssetType, params))
+		})
+		if err != nil {
+			delivery.Status = workflow.WebhookDeliveryFailed
+			delivery.AssetID = nil
+		}
+		return err
+	}
+
+	task := &workflow.Task{
+		TenantID:    wf.TenantID,
+		WorkflowID:  wf.ID,
+		Status:      workflow.TaskStatusPending,
+		Progress:    0,
+		InputParams: params,
+	}
+	err = h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := repos.Tasks.Create(ctx, task); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to create task")
+		}
+		delivery.Status = workflow.WebhookDeliveryAccepted
+		delivery.TaskID = &task.ID
+		if err := repos.WorkflowWebhooks.CreateDelivery(ctx, delivery); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to record webhook delivery")
+		}
+		return nil
+	})
+	if err != nil {
+		delivery.Status = workflow.WebhookDeliveryFailed
+		delivery.TaskID = nil
+	}
+	return err
+}
+
+// newWebhookAsset 构造待下载的 pending 资产；未配置 asset_type 时先记为 video，下载后按内容类型修正
+func newWebhookAsset(wh *workflow.Webhook, cfg *workflow.WebhookTriggerConfig, rawURL string) *media.Asset {
+	assetType := media.AssetTypeVideo
+	if cfg.AssetType != "" {
+		assetType = media.AssetType(cfg.AssetType)
+	}
+	name := "webhook-media"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "" && base != "/" && base != "." {
+			name = strings.TrimSuffix(base, path.Ext(base))
+		}
+	}
+	return &media.Asset{
+		ID:         uuid.New(),
+		TenantID:   wh.TenantID,
+		Visibility: media.VisibilityPrivate,
+		Type:       assetType,
+		SourceType: media.AssetSourceUpload,
+		Name:       name,
+		Metadata:   map[string]interface{}{"webhook_id": wh.ID.String(), "source_url": rawURL},
+		Status:     media.AssetStatusPending,
+	}
+}
+
+// verify 校验时间戳与签名；轮换后的旧密钥在宽限期内仍可验签
+func (h *ReceiveWorkflowWebhookHandler) verify(wh *workflow.Webhook, cfg *workflow.WebhookTriggerConfig, cmd dto.ReceiveWorkflowWebhookCommand, now time.Time) error {
+	ts, err := strconv.ParseInt(cmd.Timestamp, 10, 64)
+	if err != nil {
+		return webhookUnauthorized()
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > cfg.Tolerance() {
+		return webhookUnauthorized()
+	}
+
+	secret, err := h.crypto.Decrypt(wh.Secret)
+	if err != nil {
+		return apperr.Internal("failed to decrypt webhook secret", err)
+	}
+	if workflow.VerifyWebhookSignature(secret, cmd.Timestamp, cmd.Nonce, cmd.Body, cmd.Signature) {
+		return nil
+	}
+	if wh.PreviousSecretValid(now) {
+		previous, err := h.crypto.Decrypt(wh.PreviousSecret)
+		if err != nil {
+			return apperr.Internal("failed to decrypt webhook secret", err)
+		}
+		if workflow.VerifyWebhookSignature(previous, cmd.Timestamp, cmd.Nonce, cmd.Body, cmd.Signature) {
+			return nil
+		}
+	}
+	return webhookUnauthorized()
+}
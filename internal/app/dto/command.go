@@ -1,9 +1,12 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"goyavision/internal/domain/identity"
 	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/notification"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
 )
@@ -34,6 +37,15 @@ type DeleteSourceCommand struct {
 	ID uuid.UUID
 }
 
+// ReceiveMediaHookCommand MediaMTX 路径回调，Event 为 port.MediaHook* 之一
+type ReceiveMediaHookCommand struct {
+	Event       string
+	PathName    string
+	SegmentPath string
+	// SegmentDuration 录制分段时长（秒）
+	SegmentDuration float64
+}
+
 // Media Asset Commands
 
 type CreateAssetCommand struct {
@@ -178,6 +190,11 @@ type ArchiveVersionCommand struct {
 	VersionID  uuid.UUID
 }
 
+type InvalidateNodeCacheCommand struct {
+	OperatorID uuid.UUID
+	VersionID  uuid.UUID
+}
+
 type TestOperatorResult struct {
 	Success     bool                   `json:"success"`
 	Message     string                 `json:"message"`
@@ -253,6 +270,7 @@ type CreateWorkflowCommand struct {
 	Edges          []WorkflowEdgeInput
 	Visibility     workflow.Visibility
 	VisibleRoleIDs []string
+	Timeouts       *workflow.TimeoutConfig
 }
 
 type UpdateWorkflowCommand struct {
@@ -266,6 +284,8 @@ type UpdateWorkflowCommand struct {
 	Edges          []WorkflowEdgeInput
 	Visibility     *workflow.Visibility
 	VisibleRoleIDs []string
+	// Timeouts 非空时整体替换任务时限配置
+	Timeouts *workflow.TimeoutConfig
 }
 
 type DeleteWorkflowCommand struct {
@@ -277,12 +297,89 @@ type EnableWorkflowCommand struct {
 	Enabled bool
 }
 
+// RestoreWorkflowRevisionCommand 将工作流的节点与连线恢复为指定修订
+type RestoreWorkflowRevisionCommand struct {
+	WorkflowID uuid.UUID
+	Revision   int
+	// Comment 新修订的说明，为空时自动生成
+	Comment string
+}
+
+// RotateWorkflowWebhookCommand 创建工作流 webhook 或轮换其签名密钥
+type RotateWorkflowWebhookCommand struct {
+	WorkflowID uuid.UUID
+	// GraceSeconds 旧密钥继续有效的秒数，nil 时为 24 小时，0 表示立即失效
+	GraceSeconds *int
+}
+
+// ReceiveWorkflowWebhookCommand 入站 webhook 请求
+type ReceiveWorkflowWebhookCommand struct {
+	WebhookID  uuid.UUID
+	Timestamp  string
+	Nonce      string
+	Signature  string
+	Body       []byte
+	RemoteAddr string
+}
+
+// Notification Commands
+
+// CreateNotificationSubscriptionCommand 创建事件通知订阅，webhook 渠道自动生成签名密钥
+type CreateNotificationSubscriptionCommand struct {
+	Name       string
+	Channel    notification.Channel
+	EventTypes []string
+	URL        string
+	ChatFormat notification.ChatFormat
+	Recipients []string
+}
+
+// UpdateNotificationSubscriptionCommand 更新订阅，nil 字段保持不变；渠道不可修改。
+// Enabled 为 true 时重新启用订阅并清零失败计数
+type UpdateNotificationSubscriptionCommand struct {
+	ID         uuid.UUID
+	Name       *string
+	EventTypes []string
+	URL        *string
+	ChatFormat *notification.ChatFormat
+	Recipients []string
+	Enabled    *bool
+}
+
+type DeleteNotificationSubscriptionCommand struct {
+	ID uuid.UUID
+}
+
+// RotateNotificationSecretCommand 轮换 webhook 订阅的签名密钥，旧密钥立即失效
+type RotateNotificationSecretCommand struct {
+	ID uuid.UUID
+}
+
+// ReplayNotificationDeliveryCommand 以原事件内容重新投递
+type ReplayNotificationDeliveryCommand struct {
+	ID uuid.UUID
+}
+
 // Task Commands
 
 type CreateTaskCommand struct {
 	WorkflowID  uuid.UUID
 	AssetID     *uuid.UUID
 	InputParams map[string]interface{}
+	Priority    int
+	Deadline    *time.Time
+}
+
+// RerunTaskCommand 重跑任务：按 Mode 决定重新执行的节点，其余节点复用原任务输出
+type RerunTaskCommand struct {
+	TaskID uuid.UUID
+	Mode   workflow.RerunMode
+	// NodeKey from_node 模式的起始节点
+	NodeKey string
+	// InputParams 覆盖原任务同名输入参数
+	InputParams map[string]interface{}
+	// Priority 为空时沿用原任务优先级
+	Priority *int
 }
 
 type UpdateTaskCommand struct {
@@ -314,6 +411,16 @@ type CancelTaskCommand struct {
 	ID uuid.UUID
 }
 
+// DecideApprovalCommand 审批节点决策；Payload 仅在通过时生效，为空时保留原待审内容
+type DecideApprovalCommand struct {
+	TaskID   uuid.UUID
+	NodeKey  string
+	Approved bool
+	UserID   uuid.UUID
+	Comment  string
+	Payload  map[string]interface{}
+}
+
 // User Management Commands
 
 type CreateUserCommand struct {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/notification"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
 )
@@ -166,6 +167,54 @@ type ListWorkflowsQuery struct {
 	Pagination  Pagination
 }
 
+type ListWorkflowRevisionsQuery struct {
+	WorkflowID uuid.UUID
+	Pagination Pagination
+}
+
+type GetWorkflowRevisionQuery struct {
+	WorkflowID uuid.UUID
+	Revision   int
+}
+
+type GetWorkflowWebhookQuery struct {
+	WorkflowID uuid.UUID
+}
+
+type ListWebhookDeliveriesQuery struct {
+	WorkflowID uuid.UUID
+	Pagination Pagination
+}
+
+type GetNotificationSubscriptionQuery struct {
+	ID uuid.UUID
+}
+
+type ListNotificationSubscriptionsQuery struct {
+	Pagination Pagination
+}
+
+type ListNotificationDeliveriesQuery struct {
+	SubscriptionID uuid.UUID
+	Status         *notification.DeliveryStatus
+	Pagination     Pagination
+}
+
+// DiffWorkflowRevisionsQuery 比较修订 From 到 To 的变化
+type DiffWorkflowRevisionsQuery struct {
+	WorkflowID uuid.UUID
+	From       int
+	To         int
+}
+
+// PlanWorkflowQuery 以给定的任务输入试运行工作流；FanOutItems 按节点 key 假定扇出元素数
+type PlanWorkflowQuery struct {
+	WorkflowID  uuid.UUID
+	AssetID     *uuid.UUID
+	InputParams map[string]interface{}
+	FanOutItems map[string]int
+}
+
 // Task Queries
 type GetTaskQuery struct {
 	ID uuid.UUID
@@ -178,6 +227,8 @@ type GetTaskWithRelationsQuery struct {
 type ListTasksQuery struct {
 	WorkflowID        *uuid.UUID
 	AssetID           *uuid.UUID
+	CallerTaskID      *uuid.UUID
+	ParentTaskID      *uuid.UUID
 	Status            *workflow.TaskStatus
 	TriggeredByUserID *uuid.UUID
 	From              *time.Time
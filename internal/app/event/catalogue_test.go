@@ -0,0 +1,89 @@
+package event
+
+import (
+	"reflect"
+	"testing"
+
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+func TestEventCatalogueRoundTrip(t *testing.T) {
+	tenantID := uuid.New()
+	versionID := uuid.New()
+	previousID := uuid.New()
+	src := &media.Source{ID: uuid.New(), TenantID: tenantID, Name: "cam-1", PathName: "cam_1", Type: media.SourceTypePush}
+	asset := &media.Asset{ID: uuid.New(), TenantID: tenantID, Type: media.AssetTypeVideo, Status: media.AssetStatusReady}
+	op := &operator.Operator{
+		ID: uuid.New(), TenantID: tenantID, Code: "detect", Name: "Detect", Status: operator.StatusDraft,
+		ActiveVersionID: &versionID, ActiveVersion: &operator.OperatorVersion{ID: versionID, Version: "1.2.0"},
+	}
+	wf := &workflow.Workflow{ID: uuid.New(), TenantID: tenantID, Code: "wf", Name: "WF", Revision: 3, TriggerType: workflow.TriggerTypeEvent}
+
+	activated := NewOperatorEvent(EventTypeOperatorVersionActivated, op)
+	activated.PreviousVersionID = &previousID
+
+	tests := []struct {
+		ev   TriggerEvent
+		keys []string
+	}{
+		{NewSourceEvent(EventTypeSourceOnline, src), []string{"source_id", "tenant_id", "name", "path_name", "kind"}},
+		{NewSourceEvent(EventTypeSourceDeleted, src), []string{"source_id", "tenant_id", "name", "path_name", "kind"}},
+		{NewRecordingSegmentEvent(src, "/rec/cam_1/1.mp4", 60), []string{"source_id", "tenant_id", "path_name", "segment_path", "duration"}},
+		{NewAssetStatusEvent(asset, media.AssetStatusPending), []string{"asset_id", "status", "previous_status"}},
+		{NewAssetDoneEvent(asset), []string{"asset_id", "status"}},
+		{activated, []string{"operator_id", "tenant_id", "code", "name", "status", "version_id", "version", "previous_version_id"}},
+		{NewOperatorEvent(EventTypeOperatorDeprecated, op), []string{"operator_id", "version_id", "version"}},
+		{NewWorkflowEvent(EventTypeWorkflowDisabled, wf), []string{"workflow_id", "tenant_id", "code", "name", "revision", "trigger_type"}},
+	}
+	for _, tt := range tests {
+		eventType := tt.ev.EventType()
+		t.Run(eventType, func(t *testing.T) {
+			if !IsTriggerEventType(eventType) {
+				t.Errorf("%s is not a registered trigger event type", eventType)
+			}
+			if tt.ev.Tenant() != tenantID {
+				t.Errorf("Tenant() = %s, want %s", tt.ev.Tenant(), tenantID)
+			}
+
+			payload := tt.ev.Payload()
+			for _, key := range tt.keys {
+				if _, ok := payload[key]; !ok {
+					t.Errorf("payload missing %q: %v", key, payload)
+				}
+			}
+
+			data, err := Encode(tt.ev)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := Decode(eventType, data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if reflect.TypeOf(decoded) != reflect.TypeOf(tt.ev) {
+				t.Fatalf("decoded type = %T, want %T", decoded, tt.ev)
+			}
+			if decoded.EventType() != eventType {
+				t.Errorf("decoded EventType() = %s, want %s", decoded.EventType(), eventType)
+			}
+			if got := decoded.(TriggerEvent).Payload(); !reflect.DeepEqual(got, payload) {
+				t.Errorf("decoded payload = %v, want %v", got, payload)
+			}
+		})
+	}
+}
+
+func TestTaskProgressIsNotTriggerEvent(t *testing.T) {
+	if IsTriggerEventType(EventTypeTaskProgress) {
+		t.Error("task_progress should not trigger workflows")
+	}
+	for _, eventType := range []string{EventTypeTaskStatus, EventTypeNodeStarted, EventTypeNodeFinished, EventTypeArtifactCreated} {
+		if !IsTriggerEventType(eventType) {
+			t.Errorf("%s is not a registered trigger event type", eventType)
+		}
+	}
+}
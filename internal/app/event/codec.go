@@ -0,0 +1,73 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"goyavision/internal/app/port"
+)
+
+var eventFactories = struct {
+	mu        sync.RWMutex
+	factories map[string]func() port.Event
+}{factories: make(map[string]func() port.Event)}
+
+func init() {
+	RegisterEventFactory(EventTypeAssetNew, func() port.Event { return &AssetCreatedEvent{} })
+	RegisterEventFactory(EventTypeAssetDone, func() port.Event { return &AssetDoneEvent{} })
+	RegisterEventFactory(EventTypeAssetStatus, func() port.Event { return &AssetStatusEvent{} })
+	for _, eventType := range TaskEventTypes {
+		RegisterEventFactory(eventType, func() port.Event { return &TaskEvent{} })
+	}
+	for _, eventType := range SourceEventTypes {
+		RegisterEventFactory(eventType, func() port.Event { return &SourceEvent{} })
+	}
+	RegisterEventFactory(EventTypeRecordingSegmentCompleted, func() port.Event { return &RecordingSegmentEvent{} })
+	for _, eventType := range OperatorEventTypes {
+		RegisterEventFactory(eventType, func() port.Event { return &OperatorEvent{} })
+	}
+	for _, eventType := range WorkflowEventTypes {
+		RegisterEventFactory(eventType, func() port.Event { return &WorkflowEvent{} })
+	}
+	RegisterEventFactory(EventTypeWorkflowUpdated, func() port.Event { return &WorkflowEvent{} })
+	RegisterEventFactory(EventTypeWebhookMediaRequested, func() port.Event { return &WebhookMediaEvent{} })
+}
+
+// RegisterEventFactory 注册事件类型对应的结构，持久化事件总线据此从 JSON 载荷还原事件。
+// factory 须返回指针，未注册的类型还原为 GenericEvent。
+func RegisterEventFactory(eventType string, factory func() port.Event) {
+	eventFactories.mu.Lock()
+	defer eventFactories.mu.Unlock()
+	eventFactories.factories[eventType] = factory
+}
+
+// Encode 将事件序列化为 JSON 载荷
+func Encode(ev port.Event) ([]byte, error) {
+	return json.Marshal(ev)
+}
+
+// Decode 从 Encode 得到的载荷还原事件
+func Decode(eventType string, data []byte) (port.Event, error) {
+	eventFactories.mu.RLock()
+	factory, ok := eventFactories.factories[eventType]
+	eventFactories.mu.RUnlock()
+
+	if !ok {
+		ev := &GenericEvent{}
+		if err := json.Unmarshal(data, ev); err != nil {
+			return nil, fmt.Errorf("decode event %s: %w", eventType, err)
+		}
+		ev.Type = eventType
+		if ev.Data == nil {
+			ev.Data = make(map[string]interface{})
+		}
+		return ev, nil
+	}
+
+	ev := factory()
+	if err := json.Unmarshal(data, ev); err != nil {
+		return nil, fmt.Errorf("decode event %s: %w", eventType, err)
+	}
+	return ev, nil
+}
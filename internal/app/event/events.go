@@ -3,7 +3,7 @@ package event
 import (
 	"time"
 
-	"goyavision/internal/app/port"
+	"goyavision/internal/domain/media"
 
 	"github.com/google/uuid"
 )
@@ -11,36 +11,118 @@ import (
 const (
 	EventTypeAssetNew  = "asset_new"
 	EventTypeAssetDone = "asset_done"
+	// EventTypeAssetStatus 资产状态变更（pending/ready/failed）
+	EventTypeAssetStatus = "asset_status"
 )
 
+// AssetEvent 资产事件的公共字段，作为 trigger_conf.event_filter 的匹配载荷
+type AssetEvent struct {
+	AssetID    uuid.UUID
+	TenantID   uuid.UUID
+	Type       media.AssetType
+	SourceType media.AssetSourceType
+	SourceID   *uuid.UUID
+	Status     media.AssetStatus
+	Name       string
+	Format     string
+	Size       int64
+	Duration   *float64
+	Tags       []string
+	At         int64
+}
+
+func newAssetEvent(asset *media.Asset) AssetEvent {
+	return AssetEvent{
+		AssetID:    asset.ID,
+		TenantID:   asset.TenantID,
+		Type:       asset.Type,
+		SourceType: asset.SourceType,
+		SourceID:   asset.SourceID,
+		Status:     asset.Status,
+		Name:       asset.Name,
+		Format:     asset.Format,
+		Size:       asset.Size,
+		Duration:   asset.Duration,
+		Tags:       asset.Tags,
+		At:         time.Now().Unix(),
+	}
+}
+
+func (e *AssetEvent) OccurredAt() int64 { return e.At }
+func (e *AssetEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 资产事件载荷：asset_id、tenant_id、type、source_type、source_id、status、name、format、size、duration、tags
+func (e *AssetEvent) Payload() map[string]interface{} {
+	tags := make([]interface{}, len(e.Tags))
+	for i, t := range e.Tags {
+		tags[i] = t
+	}
+	payload := map[string]interface{}{
+		"asset_id":    e.AssetID.String(),
+		"tenant_id":   e.TenantID.String(),
+		"type":        string(e.Type),
+		"source_type": string(e.SourceType),
+		"status":      string(e.Status),
+		"name":        e.Name,
+		"format":      e.Format,
+		"size":        float64(e.Size),
+		"tags":        tags,
+	}
+	if e.SourceID != nil {
+		payload["source_id"] = e.SourceID.String()
+	}
+	if e.Duration != nil {
+		payload["duration"] = *e.Duration
+	}
+	return payload
+}
+
 // AssetCreatedEvent 资产创建事件（新资产上传/生成后发布）
 type AssetCreatedEvent struct {
-	AssetID uuid.UUID
-	At      int64
+	AssetEvent
 }
 
 func (e *AssetCreatedEvent) EventType() string { return EventTypeAssetNew }
-func (e *AssetCreatedEvent) OccurredAt() int64  { return e.At }
 
-var _ port.Event = (*AssetCreatedEvent)(nil)
+var _ TriggerEvent = (*AssetCreatedEvent)(nil)
 
 // AssetDoneEvent 资产就绪事件（如录制完成、任务产出资产就绪后发布）
 type AssetDoneEvent struct {
-	AssetID uuid.UUID
-	At      int64
+	AssetEvent
 }
 
 func (e *AssetDoneEvent) EventType() string { return EventTypeAssetDone }
-func (e *AssetDoneEvent) OccurredAt() int64  { return e.At }
 
-var _ port.Event = (*AssetDoneEvent)(nil)
+var _ TriggerEvent = (*AssetDoneEvent)(nil)
+
+// AssetStatusEvent 资产状态变更事件，状态变为 ready 时还会发布 AssetDoneEvent
+type AssetStatusEvent struct {
+	AssetEvent
+	PreviousStatus media.AssetStatus
+}
+
+func (e *AssetStatusEvent) EventType() string { return EventTypeAssetStatus }
+
+// Payload 在资产事件载荷基础上增加 previous_status
+func (e *AssetStatusEvent) Payload() map[string]interface{} {
+	payload := e.AssetEvent.Payload()
+	payload["previous_status"] = string(e.PreviousStatus)
+	return payload
+}
+
+var _ TriggerEvent = (*AssetStatusEvent)(nil)
 
 // NewAssetCreatedEvent 构造资产创建事件
-func NewAssetCreatedEvent(assetID uuid.UUID) *AssetCreatedEvent {
-	return &AssetCreatedEvent{AssetID: assetID, At: time.Now().Unix()}
+func NewAssetCreatedEvent(asset *media.Asset) *AssetCreatedEvent {
+	return &AssetCreatedEvent{AssetEvent: newAssetEvent(asset)}
 }
 
 // NewAssetDoneEvent 构造资产就绪事件
-func NewAssetDoneEvent(assetID uuid.UUID) *AssetDoneEvent {
-	return &AssetDoneEvent{AssetID: assetID, At: time.Now().Unix()}
+func NewAssetDoneEvent(asset *media.Asset) *AssetDoneEvent {
+	return &AssetDoneEvent{AssetEvent: newAssetEvent(asset)}
+}
+
+// NewAssetStatusEvent 构造资产状态变更事件，asset 为变更后的资产
+func NewAssetStatusEvent(asset *media.Asset, previous media.AssetStatus) *AssetStatusEvent {
+	return &AssetStatusEvent{AssetEvent: newAssetEvent(asset), PreviousStatus: previous}
 }
@@ -0,0 +1,83 @@
+package event
+
+import (
+	"time"
+
+	"goyavision/internal/domain/operator"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// EventTypeOperatorVersionActivated 算子切换了激活版本（激活或回滚）
+	EventTypeOperatorVersionActivated = "operator_version_activated"
+	// EventTypeOperatorDeprecated 算子被弃用，其当前激活版本随之弃用
+	EventTypeOperatorDeprecated = "operator_deprecated"
+)
+
+// OperatorEventTypes 算子生命周期事件类型
+var OperatorEventTypes = []string{
+	EventTypeOperatorVersionActivated,
+	EventTypeOperatorDeprecated,
+}
+
+// OperatorEvent 算子事件，Type 为 OperatorEventTypes 之一
+type OperatorEvent struct {
+	Type       string          `json:"type"`
+	OperatorID uuid.UUID       `json:"operator_id"`
+	TenantID   uuid.UUID       `json:"tenant_id"`
+	Code       string          `json:"code"`
+	Name       string          `json:"name"`
+	Status     operator.Status `json:"status"`
+	// VersionID/Version 事件发生后的激活版本
+	VersionID *uuid.UUID `json:"version_id,omitempty"`
+	Version   string     `json:"version,omitempty"`
+	// PreviousVersionID 切换前的激活版本，仅 operator_version_activated 携带
+	PreviousVersionID *uuid.UUID `json:"previous_version_id,omitempty"`
+	At                int64      `json:"at"`
+}
+
+func (e *OperatorEvent) EventType() string { return e.Type }
+func (e *OperatorEvent) OccurredAt() int64 { return e.At }
+func (e *OperatorEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 算子事件载荷：operator_id、tenant_id、code、name、status，以及非空的 version_id、version、previous_version_id
+func (e *OperatorEvent) Payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"operator_id": e.OperatorID.String(),
+		"tenant_id":   e.TenantID.String(),
+		"code":        e.Code,
+		"name":        e.Name,
+		"status":      string(e.Status),
+	}
+	if e.VersionID != nil {
+		payload["version_id"] = e.VersionID.String()
+	}
+	if e.Version != "" {
+		payload["version"] = e.Version
+	}
+	if e.PreviousVersionID != nil {
+		payload["previous_version_id"] = e.PreviousVersionID.String()
+	}
+	return payload
+}
+
+var _ TriggerEvent = (*OperatorEvent)(nil)
+
+// NewOperatorEvent 构造算子事件，版本信息取自 op 的激活版本
+func NewOperatorEvent(eventType string, op *operator.Operator) *OperatorEvent {
+	e := &OperatorEvent{
+		Type:       eventType,
+		OperatorID: op.ID,
+		TenantID:   op.TenantID,
+		Code:       op.Code,
+		Name:       op.Name,
+		Status:     op.Status,
+		VersionID:  op.ActiveVersionID,
+		At:         time.Now().Unix(),
+	}
+	if op.ActiveVersion != nil {
+		e.Version = op.ActiveVersion.Version
+	}
+	return e
+}
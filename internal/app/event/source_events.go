@@ -0,0 +1,111 @@
+package event
+
+import (
+	"time"
+
+	"goyavision/internal/domain/media"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// EventTypeSourceCreated 媒体源已创建
+	EventTypeSourceCreated = "source_created"
+	// EventTypeSourceDeleted 媒体源已删除
+	EventTypeSourceDeleted = "source_deleted"
+	// EventTypeSourceOnline 媒体源开始出流（MediaMTX 路径就绪）
+	EventTypeSourceOnline = "source_online"
+	// EventTypeSourceOffline 媒体源停止出流（MediaMTX 路径不再就绪）
+	EventTypeSourceOffline = "source_offline"
+	// EventTypeRecordingSegmentCompleted 媒体源的一个录制分段已写完
+	EventTypeRecordingSegmentCompleted = "recording_segment_completed"
+)
+
+// SourceEventTypes 媒体源生命周期事件类型
+var SourceEventTypes = []string{
+	EventTypeSourceCreated,
+	EventTypeSourceDeleted,
+	EventTypeSourceOnline,
+	EventTypeSourceOffline,
+}
+
+// SourceEvent 媒体源事件，Type 为 SourceEventTypes 之一
+type SourceEvent struct {
+	Type     string           `json:"type"`
+	SourceID uuid.UUID        `json:"source_id"`
+	TenantID uuid.UUID        `json:"tenant_id"`
+	Name     string           `json:"name"`
+	PathName string           `json:"path_name"`
+	Kind     media.SourceType `json:"kind"`
+	At       int64            `json:"at"`
+}
+
+func (e *SourceEvent) EventType() string { return e.Type }
+func (e *SourceEvent) OccurredAt() int64 { return e.At }
+func (e *SourceEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 媒体源事件载荷：source_id、tenant_id、name、path_name、kind（pull/push）
+func (e *SourceEvent) Payload() map[string]interface{} {
+	return map[string]interface{}{
+		"source_id": e.SourceID.String(),
+		"tenant_id": e.TenantID.String(),
+		"name":      e.Name,
+		"path_name": e.PathName,
+		"kind":      string(e.Kind),
+	}
+}
+
+var _ TriggerEvent = (*SourceEvent)(nil)
+
+// NewSourceEvent 构造媒体源事件
+func NewSourceEvent(eventType string, src *media.Source) *SourceEvent {
+	return &SourceEvent{
+		Type:     eventType,
+		SourceID: src.ID,
+		TenantID: src.TenantID,
+		Name:     src.Name,
+		PathName: src.PathName,
+		Kind:     src.Type,
+		At:       time.Now().Unix(),
+	}
+}
+
+// RecordingSegmentEvent 录制分段完成事件
+type RecordingSegmentEvent struct {
+	SourceID    uuid.UUID `json:"source_id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	PathName    string    `json:"path_name"`
+	SegmentPath string    `json:"segment_path"`
+	// Duration 分段时长（秒），MediaMTX 未提供时为 0
+	Duration float64 `json:"duration"`
+	At       int64   `json:"at"`
+}
+
+func (e *RecordingSegmentEvent) EventType() string { return EventTypeRecordingSegmentCompleted }
+func (e *RecordingSegmentEvent) OccurredAt() int64 { return e.At }
+func (e *RecordingSegmentEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 录制分段事件载荷：source_id、tenant_id、path_name、segment_path、duration
+func (e *RecordingSegmentEvent) Payload() map[string]interface{} {
+	return map[string]interface{}{
+		"source_id":    e.SourceID.String(),
+		"tenant_id":    e.TenantID.String(),
+		"path_name":    e.PathName,
+		"segment_path": e.SegmentPath,
+		"duration":     e.Duration,
+	}
+}
+
+var _ TriggerEvent = (*RecordingSegmentEvent)(nil)
+
+// NewRecordingSegmentEvent 构造录制分段完成事件
+func NewRecordingSegmentEvent(src *media.Source, segmentPath string, duration float64) *RecordingSegmentEvent {
+	return &RecordingSegmentEvent{
+		SourceID:    src.ID,
+		TenantID:    src.TenantID,
+		PathName:    src.PathName,
+		SegmentPath: segmentPath,
+		Duration:    duration,
+		At:          time.Now().Unix(),
+	}
+}
@@ -0,0 +1,102 @@
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// EventTypeTaskStatus 任务状态变更（running/waiting/success/failed/cancelled/timed_out）
+	EventTypeTaskStatus = "task_status"
+	// EventTypeTaskProgress 任务进度变更（层完成或算子上报进度）
+	EventTypeTaskProgress = "task_progress"
+	// EventTypeNodeStarted 节点开始执行
+	EventTypeNodeStarted = "node_started"
+	// EventTypeNodeFinished 节点结束（success/cached/failed/skipped/waiting）
+	EventTypeNodeFinished = "node_finished"
+	// EventTypeArtifactCreated 节点产物已保存
+	EventTypeArtifactCreated = "artifact_created"
+	// EventTypeTaskSLABreached 任务排队或运行时长超过工作流 SLA 阈值，每个任务只发布一次
+	EventTypeTaskSLABreached = "task_sla_breached"
+)
+
+// TaskEventTypes 工作流引擎与调度器发布的全部任务事件类型
+var TaskEventTypes = []string{
+	EventTypeTaskStatus,
+	EventTypeTaskProgress,
+	EventTypeNodeStarted,
+	EventTypeNodeFinished,
+	EventTypeArtifactCreated,
+	EventTypeTaskSLABreached,
+}
+
+// TaskEvent 任务执行事件，由工作流引擎在任务与节点状态变化时发布
+//
+// 事件总线异步投递，同一任务的事件可能乱序到达，消费方可按 Seq 排序。
+type TaskEvent struct {
+	Type       string    `json:"type"`
+	TaskID     uuid.UUID `json:"task_id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	WorkflowID uuid.UUID `json:"workflow_id"`
+	// Seq 引擎内单调递增的发布序号
+	Seq int64 `json:"seq"`
+	// PreviousStatus 任务状态在执行方之外改变（如 API 取消）时的原状态
+	PreviousStatus string `json:"previous_status,omitempty"`
+	// Status 任务事件为任务状态，携带 NodeKey 的事件为节点状态
+	Status   string `json:"status,omitempty"`
+	Progress int    `json:"progress"`
+	NodeKey  string `json:"node_key,omitempty"`
+	// NodeProgress 节点上报的完成比例（0~1）
+	NodeProgress float64    `json:"node_progress,omitempty"`
+	Message      string     `json:"message,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	ArtifactID   *uuid.UUID `json:"artifact_id,omitempty"`
+	ArtifactType string     `json:"artifact_type,omitempty"`
+	At           int64      `json:"at"`
+}
+
+func (e *TaskEvent) EventType() string { return e.Type }
+func (e *TaskEvent) OccurredAt() int64 { return e.At }
+func (e *TaskEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 任务事件载荷：task_id、tenant_id、workflow_id、status、progress，以及非空的 previous_status、node_key、error、message、artifact_id、artifact_type
+func (e *TaskEvent) Payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"task_id":     e.TaskID.String(),
+		"tenant_id":   e.TenantID.String(),
+		"workflow_id": e.WorkflowID.String(),
+		"status":      e.Status,
+		"progress":    float64(e.Progress),
+	}
+	if e.PreviousStatus != "" {
+		payload["previous_status"] = e.PreviousStatus
+	}
+	if e.NodeKey != "" {
+		payload["node_key"] = e.NodeKey
+	}
+	if e.Error != "" {
+		payload["error"] = e.Error
+	}
+	if e.Message != "" {
+		payload["message"] = e.Message
+	}
+	if e.ArtifactID != nil {
+		payload["artifact_id"] = e.ArtifactID.String()
+		payload["artifact_type"] = e.ArtifactType
+	}
+	return payload
+}
+
+var _ TriggerEvent = (*TaskEvent)(nil)
+
+// NewTaskEvent 构造任务事件
+func NewTaskEvent(eventType string, taskID, tenantID, workflowID uuid.UUID) *TaskEvent {
+	return &TaskEvent{
+		Type:       eventType,
+		TaskID:     taskID,
+		TenantID:   tenantID,
+		WorkflowID: workflowID,
+		At:         time.Now().Unix(),
+	}
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"goyavision/internal/app/port"
+
+	"github.com/google/uuid"
+)
+
+// TriggerEvent 可触发工作流的事件
+//
+// Tenant 为事件所属租户，只有同租户的工作流会被触发；
+// Payload 为匹配 trigger_conf.event_filter 的字段，同时作为任务输入参数 event 传入工作流。
+// 数值字段统一为 float64，列表字段为 []interface{}，与 JSON 解码后的过滤条件保持一致。
+type TriggerEvent interface {
+	port.Event
+	Tenant() uuid.UUID
+	Payload() map[string]interface{}
+}
+
+var triggerTypes = struct {
+	mu    sync.RWMutex
+	types map[string]struct{}
+}{types: make(map[string]struct{})}
+
+func init() {
+	RegisterTriggerEventType(EventTypeAssetNew, EventTypeAssetDone, EventTypeAssetStatus)
+	// task_progress 发布频繁，不作为触发事件
+	RegisterTriggerEventType(EventTypeTaskStatus, EventTypeNodeStarted, EventTypeNodeFinished, EventTypeArtifactCreated, EventTypeTaskSLABreached)
+	RegisterTriggerEventType(SourceEventTypes...)
+	RegisterTriggerEventType(EventTypeRecordingSegmentCompleted)
+	RegisterTriggerEventType(OperatorEventTypes...)
+	RegisterTriggerEventType(WorkflowEventTypes...)
+}
+
+// RegisterTriggerEventType 注册可被 trigger_type=event 的工作流订阅的事件类型。
+// 调度器在启动时订阅全部已注册类型，因此须在调度器启动前注册（通常在 init 中）。
+func RegisterTriggerEventType(eventTypes ...string) {
+	triggerTypes.mu.Lock()
+	defer triggerTypes.mu.Unlock()
+	for _, t := range eventTypes {
+		if t != "" {
+			triggerTypes.types[t] = struct{}{}
+		}
+	}
+}
+
+// IsTriggerEventType 事件类型是否已注册
+func IsTriggerEventType(eventType string) bool {
+	triggerTypes.mu.RLock()
+	defer triggerTypes.mu.RUnlock()
+	_, ok := triggerTypes.types[eventType]
+	return ok
+}
+
+// TriggerEventTypes 已注册的事件类型（按名称排序）
+func TriggerEventTypes() []string {
+	triggerTypes.mu.RLock()
+	defer triggerTypes.mu.RUnlock()
+	types := make([]string, 0, len(triggerTypes.types))
+	for t := range triggerTypes.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// GenericEvent 通用事件，供没有专用事件结构的模块发布可触发工作流的事件
+type GenericEvent struct {
+	Type     string
+	TenantID uuid.UUID
+	Data     map[string]interface{}
+	At       int64
+}
+
+func (e *GenericEvent) EventType() string               { return e.Type }
+func (e *GenericEvent) OccurredAt() int64               { return e.At }
+func (e *GenericEvent) Tenant() uuid.UUID               { return e.TenantID }
+func (e *GenericEvent) Payload() map[string]interface{} { return e.Data }
+
+var _ TriggerEvent = (*GenericEvent)(nil)
+
+// NewGenericEvent 构造通用事件，eventType 须已注册才能触发工作流
+func NewGenericEvent(eventType string, tenantID uuid.UUID, data map[string]interface{}) *GenericEvent {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return &GenericEvent{Type: eventType, TenantID: tenantID, Data: data, At: time.Now().Unix()}
+}
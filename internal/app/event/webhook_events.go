@@ -0,0 +1,46 @@
+package event
+
+import (
+	"time"
+
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeWebhookMediaRequested 入站 webhook 已受理，media_url 待下载。
+// 仅供内部使用，由 app.WebhookMediaIngester 以持久消费者处理，不能作为触发事件
+const EventTypeWebhookMediaRequested = "webhook_media_requested"
+
+// WebhookMediaEvent webhook 媒体下载请求，与 pending 资产和投递记录在同一事务中发布
+type WebhookMediaEvent struct {
+	DeliveryID  uuid.UUID              `json:"delivery_id"`
+	WebhookID   uuid.UUID              `json:"webhook_id"`
+	WorkflowID  uuid.UUID              `json:"workflow_id"`
+	TenantID    uuid.UUID              `json:"tenant_id"`
+	UserID      uuid.UUID              `json:"user_id"`
+	AssetID     uuid.UUID              `json:"asset_id"`
+	MediaURL    string                 `json:"media_url"`
+	AssetType   string                 `json:"asset_type,omitempty"`
+	InputParams map[string]interface{} `json:"input_params"`
+	At          int64                  `json:"at"`
+}
+
+func (e *WebhookMediaEvent) EventType() string { return EventTypeWebhookMediaRequested }
+func (e *WebhookMediaEvent) OccurredAt() int64 { return e.At }
+
+// NewWebhookMediaEvent 构造 webhook 媒体下载请求，delivery 须已关联 pending 资产
+func NewWebhookMediaEvent(delivery *workflow.WebhookDelivery, userID uuid.UUID, mediaURL, assetType string, params map[string]interface{}) *WebhookMediaEvent {
+	return &WebhookMediaEvent{
+		DeliveryID:  delivery.ID,
+		WebhookID:   delivery.WebhookID,
+		WorkflowID:  delivery.WorkflowID,
+		TenantID:    delivery.TenantID,
+		UserID:      userID,
+		AssetID:     *delivery.AssetID,
+		MediaURL:    mediaURL,
+		AssetType:   assetType,
+		InputParams: params,
+		At:          time.Now().Unix(),
+	}
+}
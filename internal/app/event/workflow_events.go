@@ -0,0 +1,71 @@
+package event
+
+import (
+	"time"
+
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// EventTypeWorkflowEnabled 工作流已启用
+	EventTypeWorkflowEnabled = "workflow_enabled"
+	// EventTypeWorkflowDisabled 工作流已停用
+	EventTypeWorkflowDisabled = "workflow_disabled"
+	// EventTypeWorkflowUpdated 工作流已创建、更新、启停或删除，各副本的调度器据此按最新配置重建定时作业。
+	// 仅供内部同步，不能作为触发事件
+	EventTypeWorkflowUpdated = "workflow_updated"
+)
+
+// WorkflowEventTypes 工作流生命周期事件类型
+var WorkflowEventTypes = []string{
+	EventTypeWorkflowEnabled,
+	EventTypeWorkflowDisabled,
+}
+
+// WorkflowEvent 工作流事件，Type 为 WorkflowEventTypes 之一或 EventTypeWorkflowUpdated
+type WorkflowEvent struct {
+	Type        string               `json:"type"`
+	WorkflowID  uuid.UUID            `json:"workflow_id"`
+	TenantID    uuid.UUID            `json:"tenant_id"`
+	OwnerID     uuid.UUID            `json:"owner_id"`
+	Code        string               `json:"code"`
+	Name        string               `json:"name"`
+	Revision    int                  `json:"revision"`
+	TriggerType workflow.TriggerType `json:"trigger_type"`
+	At          int64                `json:"at"`
+}
+
+func (e *WorkflowEvent) EventType() string { return e.Type }
+func (e *WorkflowEvent) OccurredAt() int64 { return e.At }
+func (e *WorkflowEvent) Tenant() uuid.UUID { return e.TenantID }
+
+// Payload 工作流事件载荷：workflow_id、tenant_id、code、name、revision、trigger_type
+func (e *WorkflowEvent) Payload() map[string]interface{} {
+	return map[string]interface{}{
+		"workflow_id":  e.WorkflowID.String(),
+		"tenant_id":    e.TenantID.String(),
+		"code":         e.Code,
+		"name":         e.Name,
+		"revision":     float64(e.Revision),
+		"trigger_type": string(e.TriggerType),
+	}
+}
+
+var _ TriggerEvent = (*WorkflowEvent)(nil)
+
+// NewWorkflowEvent 构造工作流事件
+func NewWorkflowEvent(eventType string, wf *workflow.Workflow) *WorkflowEvent {
+	return &WorkflowEvent{
+		Type:        eventType,
+		WorkflowID:  wf.ID,
+		TenantID:    wf.TenantID,
+		OwnerID:     wf.OwnerID,
+		Code:        wf.Code,
+		Name:        wf.Name,
+		Revision:    wf.Revision,
+		TriggerType: wf.TriggerType,
+		At:          time.Now().Unix(),
+	}
+}
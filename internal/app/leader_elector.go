@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"goyavision/internal/port"
+)
+
+var errNotLeader = errors.New("not the leader")
+
+// leaseElector 基于数据库租约的领导者选举，实现 gocron.Elector。
+// 持有者在每次调度作业触发时续约，租约过期后由其他副本接管。
+type leaseElector struct {
+	repo   port.Repository
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+func newLeaseElector(repo port.Repository, name string, ttl time.Duration) *leaseElector {
+	return &leaseElector{
+		repo:   repo,
+		name:   name,
+		holder: newInstanceID(),
+		ttl:    ttl,
+	}
+}
+
+func (e *leaseElector) IsLeader(ctx context.Context) error {
+	ok, err := e.repo.TryAcquireLeaderLease(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		return fmt.Errorf("acquire leader lease %s: %w", e.name, err)
+	}
+	if !ok {
+		return errNotLeader
+	}
+	return nil
+}
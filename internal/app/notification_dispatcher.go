@@ -0,0 +1,314 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"goyavision/config"
+	"goyavision/internal/app/event"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationEventConsumer 通知分发的持久消费者名，多副本时每个事件只由一个副本写入投递记录
+	notificationEventConsumer = "notification_dispatcher"
+	// notificationBatchSize 每轮认领的最大投递数
+	notificationBatchSize = 50
+	// notificationDeliveryLease 认领投递的租约，须大于单次发送超时；进程异常退出时租约到期后投递被重新认领
+	notificationDeliveryLease = 2 * time.Minute
+)
+
+// NotificationDispatcher 事件通知分发器
+//
+// 订阅全部可触发工作流的事件，为租户内匹配的已启用订阅各写入一条 pending 投递记录，
+// 再由投递循环认领到期的投递并交给对应渠道发送。发送失败按指数退避重试，重试耗尽记为一次订阅失败，
+// 连续失败达到阈值的订阅自动停用，其等待中的投递一并置为 failed。
+//
+// 投递通过带租约的认领分配，多个进程可同时运行投递循环。
+type NotificationDispatcher struct {
+	uow       appport.UnitOfWork
+	eventBus  appport.EventBus
+	crypto    appport.CryptoService
+	notifiers map[notification.Channel]appport.Notifier
+	cfg       config.Notification
+
+	subs []appport.Subscription
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewNotificationDispatcher 创建通知分发器，notifiers 按渠道提供发送实现，未提供的渠道投递失败
+func NewNotificationDispatcher(
+	uow appport.UnitOfWork,
+	eventBus appport.EventBus,
+	crypto appport.CryptoService,
+	notifiers map[notification.Channel]appport.Notifier,
+	cfg config.Notification,
+) *NotificationDispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &NotificationDispatcher{
+		uow:       uow,
+		eventBus:  eventBus,
+		crypto:    crypto,
+		notifiers: notifiers,
+		cfg:       cfg,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start 订阅事件并启动投递循环
+func (d *NotificationDispatcher) Start(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	if d.eventBus != nil {
+		for _, eventType := range event.TriggerEventTypes() {
+			d.subs = append(d.subs, d.eventBus.Subscribe(eventType, d.handleEvent, appport.WithConsumer(notificationEventConsumer)))
+		}
+	}
+	d.wg.Add(1)
+	go d.loop(ctx)
+	log.Print("[NotificationDispatcher] started")
+}
+
+// Stop 取消事件订阅并等待投递循环退出
+func (d *NotificationDispatcher) Stop() {
+	for _, sub := range d.subs {
+		d.eventBus.Unsubscribe(sub)
+	}
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// Notify 通知投递循环有新的待投递记录（如重放）。nil 分发器忽略通知
+func (d *NotificationDispatcher) Notify() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// handleEvent 为租户内订阅了该事件类型的已启用订阅写入投递记录，同一事件的投递共享 EventID
+func (d *NotificationDispatcher) handleEvent(ctx context.Context, ev appport.Event) error {
+	te, ok := ev.(event.TriggerEvent)
+	if !ok {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	eventID := uuid.New()
+	created := 0
+	err := d.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		subs, err := repos.NotificationSubscriptions.ListEnabledByTenant(ctx, te.Tenant())
+		if err != nil {
+			return fmt.Errorf("list subscriptions: %w", err)
+		}
+		for _, sub := range subs {
+			if !sub.Matches(ev.EventType()) {
+				continue
+			}
+			delivery := &notification.Delivery{
+				TenantID:       sub.TenantID,
+				SubscriptionID: sub.ID,
+				EventID:        eventID,
+				EventType:      ev.EventType(),
+				Payload:        te.Payload(),
+				OccurredAt:     ev.OccurredAt(),
+				Status:         notification.DeliveryPending,
+				NextAttemptAt:  &now,
+			}
+			if err := repos.NotificationDeliveries.Create(ctx, delivery); err != nil {
+				return fmt.Errorf("create delivery: %w", err)
+			}
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[NotificationDispatcher] handleEvent: type=%s tenant=%s: %v", ev.EventType(), te.Tenant(), err)
+		return err
+	}
+	if created > 0 {
+		d.Notify()
+	}
+	return nil
+}
+
+func (d *NotificationDispatcher) loop(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue 认领到期的投递并逐条发送，一批认领满时继续下一批
+func (d *NotificationDispatcher) deliverDue(ctx context.Context) {
+	for {
+		var deliveries []*notification.Delivery
+		err := d.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+			var err error
+			deliveries, err = repos.NotificationDeliveries.ClaimDue(ctx, time.Now(), notificationBatchSize, notificationDeliveryLease)
+			return err
+		})
+		if err != nil {
+			log.Printf("[NotificationDispatcher] claim deliveries: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *notification.Delivery) {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < notificationBatchSize {
+			return
+		}
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+	}
+}
+
+// deliver 发送一条投递并保存结果；订阅已删除或已停用时直接置为 failed
+func (d *NotificationDispatcher) deliver(ctx context.Context, delivery *notification.Delivery) {
+	var sub *notification.Subscription
+	err := d.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		var err error
+		sub, err = repos.NotificationSubscriptions.GetUnscoped(ctx, delivery.SubscriptionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			sub = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		// 租约到期后重新认领
+		log.Printf("[NotificationDispatcher] delivery=%s: get subscription: %v", delivery.ID, err)
+		return
+	}
+	if sub == nil || !sub.Enabled {
+		reason := "subscription deleted"
+		if sub != nil {
+			reason = "subscription disabled"
+		}
+		delivery.Status = notification.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = reason
+		d.save(ctx, delivery)
+		return
+	}
+
+	result, sendErr := d.send(ctx, sub, delivery)
+	now := time.Now()
+	exhausted := delivery.RecordAttempt(result, sendErr, d.cfg.MaxAttempts, now)
+	if sendErr != nil {
+		log.Printf("[NotificationDispatcher] delivery=%s subscription=%s attempt=%d: %v", delivery.ID, sub.ID, delivery.Attempts, sendErr)
+	}
+
+	err = d.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		if err := repos.NotificationDeliveries.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		if sendErr == nil && sub.ConsecutiveFailures == 0 {
+			return nil
+		}
+		if sendErr != nil && !exhausted {
+			return nil
+		}
+
+		// 重新读取订阅，避免覆盖并发投递或用户的修改
+		current, err := repos.NotificationSubscriptions.GetUnscoped(ctx, sub.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("get subscription: %w", err)
+		}
+		if sendErr == nil {
+			current.RecordSuccess()
+			return repos.NotificationSubscriptions.Update(ctx, current)
+		}
+		if !current.RecordFailure(d.cfg.DisableAfter, delivery.LastError, now) {
+			return repos.NotificationSubscriptions.Update(ctx, current)
+		}
+		if err := repos.NotificationSubscriptions.Update(ctx, current); err != nil {
+			return err
+		}
+		n, err := repos.NotificationDeliveries.FailPending(ctx, current.ID, "subscription disabled")
+		if err != nil {
+			return fmt.Errorf("fail pending deliveries: %w", err)
+		}
+		log.Printf("[NotificationDispatcher] subscription=%s disabled after %d consecutive failures, %d pending deliveries failed",
+			current.ID, current.ConsecutiveFailures, n)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[NotificationDispatcher] delivery=%s: save result: %v", delivery.ID, err)
+	}
+}
+
+func (d *NotificationDispatcher) send(ctx context.Context, sub *notification.Subscription, delivery *notification.Delivery) (*notification.SendResult, error) {
+	notifier, ok := d.notifiers[sub.Channel]
+	if !ok {
+		return nil, fmt.Errorf("channel %s is not configured", sub.Channel)
+	}
+	if sub.Secret != "" {
+		if d.crypto == nil {
+			return nil, errors.New("crypto service is not configured")
+		}
+		secret, err := d.crypto.Decrypt(sub.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt secret: %w", err)
+		}
+		plain := *sub
+		plain.Secret = secret
+		sub = &plain
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+	return notifier.Send(sendCtx, sub, delivery.Message())
+}
+
+func (d *NotificationDispatcher) save(ctx context.Context, delivery *notification.Delivery) {
+	err := d.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		return repos.NotificationDeliveries.Update(ctx, delivery)
+	})
+	if err != nil {
+		log.Printf("[NotificationDispatcher] delivery=%s: save result: %v", delivery.ID, err)
+	}
+}
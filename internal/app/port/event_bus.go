@@ -11,22 +11,64 @@ import "context"
 //
 // 实现：
 //   - infra/eventbus/local.go (本地内存实现)
+//   - infra/eventbus/outbox.go (事务性 outbox + 中继，至少一次投递)
 //   - 未来可扩展：Redis Pub/Sub, Kafka, RabbitMQ
 //
 // 使用场景：
 //   - 媒体源创建后，触发资产索引
 //   - 任务完成后，发送通知
 //   - 工作流状态变更，记录审计日志
+//
+// 在 UnitOfWork.Do 的回调内以回调的 ctx 发布时，事件随事务提交，事务回滚则丢弃。
 type EventBus interface {
 	// Publish 发布事件
 	Publish(ctx context.Context, event Event) error
 
-	// Subscribe 订阅事件（按事件类型）
-	// handler 在新 goroutine 中异步执行
-	Subscribe(eventType string, handler EventHandler)
+	// Subscribe 订阅事件（按事件类型），返回用于取消订阅的句柄
+	// handler 异步执行，返回 error 时由实现决定是否重试
+	Subscribe(eventType string, handler EventHandler, opts ...SubscribeOption) Subscription
+
+	// Unsubscribe 取消订阅，重复取消无副作用
+	Unsubscribe(sub Subscription)
+}
+
+// Subscription 订阅句柄
+type Subscription interface {
+	// EventType 订阅的事件类型
+	EventType() string
+}
+
+// SubscribeOptions 订阅选项
+type SubscribeOptions struct {
+	// Consumer 持久消费者名称：非空时消费位置持久化，重启后从上次位置继续，
+	// 多副本部署时同名消费者同一时刻只在一个副本上消费；为空时为本进程内的临时订阅，从订阅时的最新事件开始
+	Consumer string
+	// MaxAttempts handler 失败的最大尝试次数，超过后事件进入死信，<= 0 时使用实现的默认值
+	MaxAttempts int
+}
+
+// SubscribeOption 订阅选项函数
+type SubscribeOption func(*SubscribeOptions)
+
+// WithConsumer 以持久消费者订阅
+func WithConsumer(name string) SubscribeOption {
+	return func(o *SubscribeOptions) { o.Consumer = name }
+}
+
+// WithMaxAttempts 设置 handler 失败的最大尝试次数
+func WithMaxAttempts(n int) SubscribeOption {
+	return func(o *SubscribeOptions) { o.MaxAttempts = n }
+}
 
-	// Unsubscribe 取消订阅
-	Unsubscribe(eventType string, handler EventHandler)
+// ApplySubscribeOptions 合并订阅选项
+func ApplySubscribeOptions(opts []SubscribeOption) SubscribeOptions {
+	var o SubscribeOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
 }
 
 // Event 领域事件接口
@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"net/http"
+)
+
+// MediaFetcher 下载调用方提供的外部媒体地址（如 webhook 的 media_url）
+//
+// 实现须拒绝解析到内网、回环、链路本地等地址的目标，连接时与每次跳转时都要校验。
+//
+// 实现：
+//   - infra/notify/fetch.go
+type MediaFetcher interface {
+	// CheckURL 解析地址的主机，目标不被允许时返回错误
+	CheckURL(ctx context.Context, rawURL string) error
+	// Fetch 发起 GET 请求，调用方负责关闭响应体
+	Fetch(ctx context.Context, rawURL string) (*http.Response, error)
+}
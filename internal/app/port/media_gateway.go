@@ -40,6 +40,19 @@ type MediaGateway interface {
 	Ping(ctx context.Context) error
 }
 
+// MediaMTX 路径回调事件，网关配置回调地址后为新建路径注册对应的 runOn* 命令
+const (
+	// MediaHookReady 路径开始出流（runOnReady）
+	MediaHookReady = "ready"
+	// MediaHookNotReady 路径停止出流（runOnNotReady）
+	MediaHookNotReady = "not_ready"
+	// MediaHookSegmentComplete 录制分段写完（runOnRecordSegmentComplete）
+	MediaHookSegmentComplete = "segment_complete"
+
+	// MediaHookTokenHeader 回调请求携带共享令牌的请求头
+	MediaHookTokenHeader = "X-GoyaVision-Hook-Token"
+)
+
 // PathStatus 路径状态
 type PathStatus struct {
 	Name          string
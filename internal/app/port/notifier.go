@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+
+	"goyavision/internal/domain/notification"
+)
+
+// Notifier 通知渠道
+//
+// 实现：
+//   - infra/notify/webhook.go (签名的 JSON webhook)
+//   - infra/notify/chat.go (聊天机器人 webhook)
+//   - infra/notify/smtp.go (SMTP 邮件)
+type Notifier interface {
+	// Send 发送一条通知，sub.Secret 已解密。
+	// 对端返回的响应（若有）即使发送失败也应返回，用于记录投递日志
+	Send(ctx context.Context, sub *notification.Subscription, msg *notification.Message) (*notification.SendResult, error)
+}
+
+// URLGuard 校验通知订阅的出站地址
+//
+// 实现：
+//   - infra/notify/guard.go
+type URLGuard interface {
+	// CheckURL 解析地址的主机，主机解析到内网、回环、链路本地等地址且未被配置允许时返回错误
+	CheckURL(ctx context.Context, rawURL string) error
+}
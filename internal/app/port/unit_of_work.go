@@ -6,6 +6,7 @@ import (
 	"goyavision/internal/domain/ai_model"
 	"goyavision/internal/domain/identity"
 	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/notification"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/storage"
 	"goyavision/internal/domain/workflow"
@@ -44,9 +45,14 @@ type Repositories struct {
 	OperatorVersions     operator.VersionRepository
 	OperatorTemplates    operator.TemplateRepository
 	OperatorDependencies operator.DependencyRepository
+	OperatorSlots        operator.SlotRepository
 	Workflows   workflow.Repository
+	WorkflowRevisions workflow.RevisionRepository
+	WorkflowWebhooks  workflow.WebhookRepository
 	Tasks       workflow.TaskRepository
 	Artifacts   workflow.ArtifactRepository
+	TaskCheckpoints workflow.CheckpointRepository
+	NodeCache       workflow.NodeCacheRepository
 	Users       identity.UserRepository
 	Roles       identity.RoleRepository
 	Permissions identity.PermissionRepository
@@ -55,4 +61,6 @@ type Repositories struct {
 	AIModels       ai_model.Repository
 	UserIdentities identity.UserIdentityRepository
 	UserAssets     portrepo.UserAssetRepository
+	NotificationSubscriptions notification.SubscriptionRepository
+	NotificationDeliveries    notification.DeliveryRepository
 }
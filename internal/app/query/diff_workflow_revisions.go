@@ -0,0 +1,38 @@
+package query
+
+import (
+	"context"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+)
+
+type DiffWorkflowRevisionsHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewDiffWorkflowRevisionsHandler(uow port.UnitOfWork) *DiffWorkflowRevisionsHandler {
+	return &DiffWorkflowRevisionsHandler{uow: uow}
+}
+
+func (h *DiffWorkflowRevisionsHandler) Handle(ctx context.Context, q dto.DiffWorkflowRevisionsQuery) (*workflow.RevisionDiff, error) {
+	var result *workflow.RevisionDiff
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := checkWorkflowAccess(ctx, repos, q.WorkflowID); err != nil {
+			return err
+		}
+
+		from, err := getRevisionByNumber(ctx, repos, q.WorkflowID, q.From)
+		if err != nil {
+			return err
+		}
+		to, err := getRevisionByNumber(ctx, repos, q.WorkflowID, q.To)
+		if err != nil {
+			return err
+		}
+		result = workflow.DiffRevisions(from, to)
+		return nil
+	})
+	return result, err
+}
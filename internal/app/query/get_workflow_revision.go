@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GetWorkflowRevisionHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewGetWorkflowRevisionHandler(uow port.UnitOfWork) *GetWorkflowRevisionHandler {
+	return &GetWorkflowRevisionHandler{uow: uow}
+}
+
+func (h *GetWorkflowRevisionHandler) Handle(ctx context.Context, q dto.GetWorkflowRevisionQuery) (*workflow.Revision, error) {
+	var result *workflow.Revision
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := checkWorkflowAccess(ctx, repos, q.WorkflowID); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = getRevisionByNumber(ctx, repos, q.WorkflowID, q.Revision)
+		return err
+	})
+	return result, err
+}
+
+func getRevisionByNumber(ctx context.Context, repos *port.Repositories, workflowID uuid.UUID, revision int) (*workflow.Revision, error) {
+	rev, err := repos.WorkflowRevisions.GetByNumber(ctx, workflowID, revision)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.NotFound("workflow revision", strconv.Itoa(revision))
+		}
+		return nil, apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow revision")
+	}
+	return rev, nil
+}
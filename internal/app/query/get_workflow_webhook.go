@@ -0,0 +1,41 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+type GetWorkflowWebhookHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewGetWorkflowWebhookHandler(uow port.UnitOfWork) *GetWorkflowWebhookHandler {
+	return &GetWorkflowWebhookHandler{uow: uow}
+}
+
+func (h *GetWorkflowWebhookHandler) Handle(ctx context.Context, q dto.GetWorkflowWebhookQuery) (*workflow.Webhook, error) {
+	var result *workflow.Webhook
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := checkWorkflowAccess(ctx, repos, q.WorkflowID); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = repos.WorkflowWebhooks.GetByWorkflow(ctx, q.WorkflowID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("workflow webhook", q.WorkflowID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow webhook")
+		}
+		return nil
+	})
+	return result, err
+}
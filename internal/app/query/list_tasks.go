@@ -23,6 +23,8 @@ func (h *ListTasksHandler) Handle(ctx context.Context, query dto.ListTasksQuery)
 	filter := workflow.TaskFilter{
 		WorkflowID:        query.WorkflowID,
 		AssetID:           query.AssetID,
+		CallerTaskID:      query.CallerTaskID,
+		ParentTaskID:      query.ParentTaskID,
 		Status:            query.Status,
 		TriggeredByUserID: query.TriggeredByUserID,
 		From:              query.From,
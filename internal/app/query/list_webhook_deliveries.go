@@ -0,0 +1,57 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+type ListWebhookDeliveriesHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewListWebhookDeliveriesHandler(uow port.UnitOfWork) *ListWebhookDeliveriesHandler {
+	return &ListWebhookDeliveriesHandler{uow: uow}
+}
+
+func (h *ListWebhookDeliveriesHandler) Handle(ctx context.Context, q dto.ListWebhookDeliveriesQuery) (*dto.PagedResult[*workflow.WebhookDelivery], error) {
+	q.Pagination.Normalize()
+
+	var items []*workflow.WebhookDelivery
+	var total int64
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := checkWorkflowAccess(ctx, repos, q.WorkflowID); err != nil {
+			return err
+		}
+
+		wh, err := repos.WorkflowWebhooks.GetByWorkflow(ctx, q.WorkflowID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("workflow webhook", q.WorkflowID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow webhook")
+		}
+
+		items, total, err = repos.WorkflowWebhooks.ListDeliveries(ctx, wh.ID, q.Pagination.Limit, q.Pagination.Offset)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to list webhook deliveries")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PagedResult[*workflow.WebhookDelivery]{
+		Items:  items,
+		Total:  total,
+		Limit:  q.Pagination.Limit,
+		Offset: q.Pagination.Offset,
+	}, nil
+}
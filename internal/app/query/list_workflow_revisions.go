@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ListWorkflowRevisionsHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewListWorkflowRevisionsHandler(uow port.UnitOfWork) *ListWorkflowRevisionsHandler {
+	return &ListWorkflowRevisionsHandler{uow: uow}
+}
+
+func (h *ListWorkflowRevisionsHandler) Handle(ctx context.Context, q dto.ListWorkflowRevisionsQuery) (*dto.PagedResult[*workflow.Revision], error) {
+	q.Pagination.Normalize()
+
+	var items []*workflow.Revision
+	var total int64
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := checkWorkflowAccess(ctx, repos, q.WorkflowID); err != nil {
+			return err
+		}
+
+		var err error
+		items, total, err = repos.WorkflowRevisions.List(ctx, q.WorkflowID, q.Pagination.Limit, q.Pagination.Offset)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to list workflow revisions")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PagedResult[*workflow.Revision]{
+		Items:  items,
+		Total:  total,
+		Limit:  q.Pagination.Limit,
+		Offset: q.Pagination.Offset,
+	}, nil
+}
+
+// checkWorkflowAccess 修订仓储不按租户过滤，先通过工作流仓储确认当前用户可访问该工作流
+func checkWorkflowAccess(ctx context.Context, repos *port.Repositories, workflowID uuid.UUID) error {
+	if _, err := repos.Workflows.Get(ctx, workflowID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperr.NotFound("workflow", workflowID.String())
+		}
+		return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow")
+	}
+	return nil
+}
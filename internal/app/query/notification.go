@@ -0,0 +1,121 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+	"goyavision/pkg/apperr"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GetNotificationSubscriptionHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewGetNotificationSubscriptionHandler(uow port.UnitOfWork) *GetNotificationSubscriptionHandler {
+	return &GetNotificationSubscriptionHandler{uow: uow}
+}
+
+func (h *GetNotificationSubscriptionHandler) Handle(ctx context.Context, q dto.GetNotificationSubscriptionQuery) (*notification.Subscription, error) {
+	var result *notification.Subscription
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		result, err = getNotificationSubscription(ctx, repos, q.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type ListNotificationSubscriptionsHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewListNotificationSubscriptionsHandler(uow port.UnitOfWork) *ListNotificationSubscriptionsHandler {
+	return &ListNotificationSubscriptionsHandler{uow: uow}
+}
+
+func (h *ListNotificationSubscriptionsHandler) Handle(ctx context.Context, q dto.ListNotificationSubscriptionsQuery) (*dto.PagedResult[*notification.Subscription], error) {
+	q.Pagination.Normalize()
+
+	var items []*notification.Subscription
+	var total int64
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		items, total, err = repos.NotificationSubscriptions.List(ctx, q.Pagination.Limit, q.Pagination.Offset)
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to list notification subscriptions")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PagedResult[*notification.Subscription]{
+		Items:  items,
+		Total:  total,
+		Limit:  q.Pagination.Limit,
+		Offset: q.Pagination.Offset,
+	}, nil
+}
+
+type ListNotificationDeliveriesHandler struct {
+	uow port.UnitOfWork
+}
+
+func NewListNotificationDeliveriesHandler(uow port.UnitOfWork) *ListNotificationDeliveriesHandler {
+	return &ListNotificationDeliveriesHandler{uow: uow}
+}
+
+func (h *ListNotificationDeliveriesHandler) Handle(ctx context.Context, q dto.ListNotificationDeliveriesQuery) (*dto.PagedResult[*notification.Delivery], error) {
+	q.Pagination.Normalize()
+
+	var items []*notification.Delivery
+	var total int64
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if _, err := getNotificationSubscription(ctx, repos, q.SubscriptionID); err != nil {
+			return err
+		}
+
+		var err error
+		items, total, err = repos.NotificationDeliveries.List(ctx, notification.DeliveryFilter{
+			SubscriptionID: q.SubscriptionID,
+			Status:         q.Status,
+			Limit:          q.Pagination.Limit,
+			Offset:         q.Pagination.Offset,
+		})
+		if err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to list notification deliveries")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PagedResult[*notification.Delivery]{
+		Items:  items,
+		Total:  total,
+		Limit:  q.Pagination.Limit,
+		Offset: q.Pagination.Offset,
+	}, nil
+}
+
+func getNotificationSubscription(ctx context.Context, repos *port.Repositories, id uuid.UUID) (*notification.Subscription, error) {
+	sub, err := repos.NotificationSubscriptions.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.NotFound("notification subscription", id.String())
+		}
+		return nil, apperr.Wrap(err, apperr.CodeDBError, "failed to get notification subscription")
+	}
+	return sub, nil
+}
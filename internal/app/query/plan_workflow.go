@@ -0,0 +1,51 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"goyavision/internal/app/dto"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/pkg/apperr"
+
+	"gorm.io/gorm"
+)
+
+type PlanWorkflowHandler struct {
+	uow     port.UnitOfWork
+	planner workflow.Planner
+}
+
+func NewPlanWorkflowHandler(uow port.UnitOfWork, planner workflow.Planner) *PlanWorkflowHandler {
+	return &PlanWorkflowHandler{uow: uow, planner: planner}
+}
+
+// Handle 试运行工作流：按当前的节点与连线解析执行计划，不创建任务、不调用算子
+func (h *PlanWorkflowHandler) Handle(ctx context.Context, q dto.PlanWorkflowQuery) (*workflow.Plan, error) {
+	var wf *workflow.Workflow
+	err := h.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		wf, err = repos.Workflows.GetWithNodes(ctx, q.WorkflowID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.NotFound("workflow", q.WorkflowID.String())
+			}
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to get workflow with nodes")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := h.planner.Plan(ctx, wf, workflow.PlanRequest{
+		AssetID:     q.AssetID,
+		InputParams: q.InputParams,
+		FanOutItems: q.FanOutItems,
+	})
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeDBError, "failed to plan workflow")
+	}
+	return plan, nil
+}
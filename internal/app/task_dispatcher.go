@@ -0,0 +1,424 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"goyavision/config"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/event"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/port"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// dispatchBatchSize 每轮从队列读取的最大任务数
+	dispatchBatchSize = 100
+	// taskCleanupConsumer 清理等待中被取消任务的持久消费者名，多副本时每个任务只由一个副本执行收尾节点
+	taskCleanupConsumer = "task_cleanup"
+)
+
+// TaskDispatcher 任务派发器
+//
+// 触发方只负责创建 pending 任务并调用 Notify，派发器按优先级从数据库队列中取出任务，
+// 在全局、租户、工作流并发限制内交给引擎执行。进入 waiting 的任务会释放执行槽位。
+//
+// 多个进程（cmd/server 内嵌或 cmd/worker）可同时运行派发器：任务通过带租约的认领分配给唯一执行方，
+// 执行期间定期续约；执行方失联导致租约过期的任务由任一派发器回收，重新派发后从检查点继续。
+// 原执行方在续约失败时放弃本地执行，引擎对任务状态与检查点的写入也按租约持有者校验。
+// 全局、租户与工作流并发限制在认领事务中按所有执行方运行中的任务合计校验，
+// MaxConcurrent 同时也是单个进程的执行槽位数。
+//
+// 任务在 API 中被取消时，各进程的派发器通过 task_status 事件取消本进程引擎中的执行；
+// 未收到事件时（如 worker 未配置事件总线），续约时发现任务已被取消同样取消执行。
+// 等待中被取消的任务不在任何引擎中执行，由一个派发器通过持久订阅执行其补偿与收尾节点。
+type TaskDispatcher struct {
+	repo     port.Repository
+	engine   port.WorkflowEngine
+	eventBus appport.EventBus
+	cfg      config.Queue
+	ownerID  string
+	subs     []appport.Subscription
+	limits   workflow.ClaimLimits
+
+	mu      sync.Mutex
+	running map[uuid.UUID]bool
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTaskDispatcher 创建任务派发器，eventBus 为 nil 时不响应任务取消事件
+func NewTaskDispatcher(repo port.Repository, engine port.WorkflowEngine, eventBus appport.EventBus, cfg config.Queue) *TaskDispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = 30 * time.Second
+	}
+	return &TaskDispatcher{
+		repo:     repo,
+		engine:   engine,
+		eventBus: eventBus,
+		cfg:      cfg,
+		ownerID:  newInstanceID(),
+		limits: workflow.ClaimLimits{
+			MaxConcurrent:  cfg.MaxConcurrent,
+			MaxPerTenant:   cfg.MaxPerTenant,
+			MaxPerWorkflow: cfg.MaxPerWorkflow,
+			Tenants:        cfg.Tenants,
+			Workflows:      cfg.Workflows,
+		},
+		running: make(map[uuid.UUID]bool),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start 订阅任务状态事件，启动派发与租约续约循环
+func (d *TaskDispatcher) Start(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	if d.eventBus != nil {
+		// 临时订阅：每个进程都收到事件，由执行该任务的进程取消
+		d.subs = append(d.subs,
+			d.eventBus.Subscribe(event.EventTypeTaskStatus, d.handleTaskStatus),
+			d.eventBus.Subscribe(event.EventTypeTaskStatus, d.cleanupStopped, appport.WithConsumer(taskCleanupConsumer)))
+	}
+	d.wg.Add(2)
+	go d.loop(ctx)
+	go d.heartbeat(ctx)
+	log.Printf("[TaskDispatcher] started owner=%s", d.ownerID)
+}
+
+// Stop 停止派发新任务，已在执行的任务不受影响（租约不再续约，过期后由其他执行方回收）
+func (d *TaskDispatcher) Stop() {
+	for _, sub := range d.subs {
+		d.eventBus.Unsubscribe(sub)
+	}
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// Notify 通知派发器队列有变化（新任务入队或执行槽位释放）。nil 派发器（任务由独立 worker 执行）忽略通知
+func (d *TaskDispatcher) Notify() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Running 返回当前进程中正在执行的任务数
+func (d *TaskDispatcher) Running() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.running)
+}
+
+func (d *TaskDispatcher) loop(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	d.dispatch(ctx)
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.dispatch(ctx)
+	}
+}
+
+// heartbeat 定期为本进程执行中的任务续约，并回收其他执行方失联后过期的任务
+func (d *TaskDispatcher) heartbeat(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.cfg.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+
+		d.renewLeases(ctx)
+
+		n, err := d.repo.ReclaimExpiredTasks(ctx)
+		if err != nil {
+			log.Printf("[TaskDispatcher] heartbeat: reclaim expired tasks: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("[TaskDispatcher] reclaimed %d tasks with expired lease", n)
+			d.Notify()
+		}
+	}
+}
+
+// renewLeases 为执行中的任务续约。未能续约的任务按数据库中的状态处理：
+// 已被取消或判定超时的任务取消本地执行（未收到取消事件时的兜底），
+// 租约已被回收或被其他执行方认领的任务放弃本地执行，由新的执行方从检查点继续
+func (d *TaskDispatcher) renewLeases(ctx context.Context) {
+	ids := d.runningIDs()
+	if len(ids) == 0 {
+		return
+	}
+	renewed, err := d.repo.RenewTaskLeases(ctx, ids, d.ownerID, d.cfg.LeaseTTL)
+	if err != nil {
+		log.Printf("[TaskDispatcher] heartbeat: renew leases: %v", err)
+		return
+	}
+	if len(renewed) == len(ids) {
+		return
+	}
+
+	held := make(map[uuid.UUID]bool, len(renewed))
+	for _, id := range renewed {
+		held[id] = true
+	}
+	for _, id := range ids {
+		if held[id] {
+			continue
+		}
+		task, err := d.repo.GetTask(ctx, id)
+		if err != nil {
+			log.Printf("[TaskDispatcher] heartbeat: get task %s: %v", id, err)
+			continue
+		}
+		switch {
+		case task.IsCancelled() || task.IsTimedOut():
+			_ = d.engine.Cancel(ctx, id)
+		case task.IsPending() || (task.IsRunning() && task.LeaseOwner != d.ownerID):
+			log.Printf("[TaskDispatcher] lease lost task=%s, abandoning local execution", id)
+			_ = d.engine.Abandon(ctx, id)
+		}
+		// 其余状态（成功、失败、等待）由本进程的执行写入，执行即将结束
+	}
+}
+
+// handleTaskStatus 取消已在执行方之外被取消或判定超时、仍在本进程引擎中执行的任务。
+// 子工作流的子任务不经派发器认领，但与父任务在同一引擎中执行，同样可以取消
+func (d *TaskDispatcher) handleTaskStatus(ctx context.Context, ev appport.Event) error {
+	te, ok := ev.(*event.TaskEvent)
+	if !ok {
+		return nil
+	}
+	switch workflow.TaskStatus(te.Status) {
+	case workflow.TaskStatusCancelled, workflow.TaskStatusTimedOut:
+		// 任务不在本进程执行时 Cancel 返回错误，忽略
+		_ = d.engine.Cancel(ctx, te.TaskID)
+	}
+	return nil
+}
+
+// cleanupStopped 为等待中被取消或判定超时的任务执行补偿与收尾节点。
+// 等待中的任务不在任何引擎中执行，执行中的任务由引擎在停止时自行清理
+func (d *TaskDispatcher) cleanupStopped(ctx context.Context, ev appport.Event) error {
+	te, ok := ev.(*event.TaskEvent)
+	if !ok || te.PreviousStatus != string(workflow.TaskStatusWaiting) {
+		return nil
+	}
+	switch workflow.TaskStatus(te.Status) {
+	case workflow.TaskStatusCancelled, workflow.TaskStatusTimedOut:
+	default:
+		return nil
+	}
+
+	task, err := d.repo.GetTask(ctx, te.TaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get task %s: %w", te.TaskID, err)
+	}
+	wf, err := d.loadWorkflow(ctx, task)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get workflow %s: %w", task.WorkflowID, err)
+	}
+	return d.engine.Cleanup(ctx, wf, task)
+}
+
+// dispatch 从队列中选出可执行的任务，认领成功后启动执行。
+// 队列按批读取并排除已达到并发上限的租户与工作流；一批中有任务因上限未被认领而本进程仍有空闲槽位时，
+// 重新读取（此时刚占满上限的租户与工作流已被排除），使某个租户积压的任务不会阻塞其他租户
+func (d *TaskDispatcher) dispatch(ctx context.Context) {
+	for {
+		free := d.freeSlots()
+		if free == 0 {
+			return
+		}
+
+		tasks, err := d.repo.ListPendingTasks(ctx, dispatchBatchSize, &d.limits)
+		if err != nil {
+			log.Printf("[TaskDispatcher] dispatch: list pending tasks: %v", err)
+			return
+		}
+		// 读到的任务已是全部候选，或本批没有认领到任务（被其他执行方取走或工作流不可用）时结束本轮
+		if n := d.dispatchBatch(ctx, tasks, free); n == 0 || len(tasks) < dispatchBatchSize {
+			return
+		}
+	}
+}
+
+// dispatchBatch 在本批任务中认领至多 free 个并启动执行，返回认领到的任务数
+func (d *TaskDispatcher) dispatchBatch(ctx context.Context, tasks []*workflow.Task, free int) int {
+	var err error
+	workflows := make(map[uuid.UUID]*workflow.Workflow)
+	unavailable := make(map[uuid.UUID]bool)
+	ids := make([]uuid.UUID, 0, len(tasks))
+	for _, task := range tasks {
+		if unavailable[task.WorkflowID] {
+			continue
+		}
+		wf, ok := workflows[task.WorkflowID]
+		if !ok {
+			wf, err = d.loadWorkflow(ctx, task)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				d.failUnclaimed(ctx, task, fmt.Errorf("workflow %s not found", task.WorkflowID))
+				continue
+			}
+			if err != nil {
+				// 任务尚未认领，不能改写其状态；跳过该工作流的任务，下一轮重试
+				log.Printf("[TaskDispatcher] dispatch: get workflow %s: %v", task.WorkflowID, err)
+				unavailable[task.WorkflowID] = true
+				continue
+			}
+			workflows[task.WorkflowID] = wf
+		}
+
+		ids = append(ids, task.ID)
+	}
+	if len(ids) == 0 {
+		return 0
+	}
+
+	// 认领时按所有执行方合计的并发上限筛选，最多占满本进程的空闲槽位；
+	// 超出上限或已被其他执行方取走的任务留待下一轮
+	limits := d.limits
+	limits.Max = free
+	claimed, err := d.repo.ClaimTasks(ctx, ids, d.ownerID, d.cfg.LeaseTTL, &limits)
+	if err != nil {
+		log.Printf("[TaskDispatcher] dispatch: claim tasks: %v", err)
+	}
+	for _, task := range claimed {
+		d.acquire(task)
+		go d.run(ctx, workflows[task.WorkflowID], task)
+	}
+	return len(claimed)
+}
+
+// loadWorkflow 以任务所属租户与触发用户的身份读取工作流，与引擎执行任务时的身份一致
+func (d *TaskDispatcher) loadWorkflow(ctx context.Context, task *workflow.Task) (*workflow.Workflow, error) {
+	var userID uuid.UUID
+	if task.TriggeredByUserID != nil {
+		userID = *task.TriggeredByUserID
+	}
+	return d.repo.GetWorkflowWithNodes(middleware.ContextWithIdentity(ctx, task.TenantID, userID), task.WorkflowID)
+}
+
+// failUnclaimed 认领成功后将任务置为失败，已被其他执行方认领或取消的任务不受影响
+func (d *TaskDispatcher) failUnclaimed(ctx context.Context, task *workflow.Task, err error) {
+	claimed, claimErr := d.repo.ClaimTasks(ctx, []uuid.UUID{task.ID}, d.ownerID, d.cfg.LeaseTTL, nil)
+	if claimErr != nil {
+		log.Printf("[TaskDispatcher] dispatch: claim task %s: %v", task.ID, claimErr)
+		return
+	}
+	for _, t := range claimed {
+		log.Printf("[TaskDispatcher] dispatch: task=%s: %v", t.ID, err)
+		d.failTask(ctx, t, err)
+	}
+}
+
+func (d *TaskDispatcher) run(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) {
+	defer func() {
+		d.release(task)
+		d.Notify()
+	}()
+
+	// 新任务没有检查点，Resume 与 Execute 等价；被恢复的任务从检查点继续
+	if err := d.engine.Resume(ctx, wf, task); err != nil {
+		log.Printf("[TaskDispatcher] execute failed workflow=%s task=%s: %v", wf.ID, task.ID, err)
+		// 引擎已将被取消或超时的任务置为终态，不再改为失败；租约丢失的任务由新的执行方处理
+		if task.IsCancelled() || task.IsTimedOut() ||
+			errors.Is(err, workflow.ErrTaskStopped) || errors.Is(err, workflow.ErrLeaseLost) {
+			return
+		}
+		d.failTask(ctx, task, err)
+	}
+}
+
+// failTask 将本进程认领的任务置为失败，任务已被取消或租约已丢失时不写入
+func (d *TaskDispatcher) failTask(ctx context.Context, task *workflow.Task, err error) {
+	now := time.Now()
+	task.Status = workflow.TaskStatusFailed
+	task.Error = err.Error()
+	task.CompletedAt = &now
+	if updateErr := d.repo.UpdateTaskFenced(ctx, task, d.ownerID); updateErr != nil {
+		log.Printf("[TaskDispatcher] update task status failed task=%s: %v", task.ID, updateErr)
+	}
+}
+
+func (d *TaskDispatcher) runningIDs() []uuid.UUID {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]uuid.UUID, 0, len(d.running))
+	for id := range d.running {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (d *TaskDispatcher) freeSlots() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cfg.MaxConcurrent <= 0 {
+		return dispatchBatchSize
+	}
+	if free := d.cfg.MaxConcurrent - len(d.running); free > 0 {
+		return free
+	}
+	return 0
+}
+
+// acquire 占用本进程的执行槽位
+func (d *TaskDispatcher) acquire(task *workflow.Task) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running[task.ID] = true
+}
+
+func (d *TaskDispatcher) release(task *workflow.Task) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.running, task.ID)
+}
+
+// newInstanceID 生成进程实例标识，用作任务租约与领导者租约的持有者
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
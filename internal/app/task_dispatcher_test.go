@@ -0,0 +1,570 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"goyavision/config"
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/port"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(
+		&model.WorkflowModel{},
+		&model.WorkflowNodeModel{},
+		&model.WorkflowEdgeModel{},
+		&model.TaskModel{},
+		&model.LeaderLeaseModel{},
+		&model.ScheduledRunModel{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// fakeEngine 阻塞执行直到测试放行，或任务被 Cancel/Abandon
+type fakeEngine struct {
+	mu        sync.Mutex
+	release   chan struct{}
+	stops     map[uuid.UUID]chan error
+	cancelled []uuid.UUID
+	abandoned []uuid.UUID
+}
+
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{release: make(chan struct{}), stops: make(map[uuid.UUID]chan error)}
+}
+
+func (e *fakeEngine) Execute(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	return e.Resume(ctx, wf, task)
+}
+
+func (e *fakeEngine) Resume(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	e.mu.Lock()
+	stop := make(chan error, 1)
+	e.stops[task.ID] = stop
+	e.mu.Unlock()
+
+	select {
+	case <-e.release:
+		return nil
+	case err := <-stop:
+		return err
+	}
+}
+
+func (e *fakeEngine) Cancel(ctx context.Context, taskID uuid.UUID) error {
+	return e.stop(taskID, &e.cancelled, workflow.ErrTaskStopped)
+}
+
+func (e *fakeEngine) Abandon(ctx context.Context, taskID uuid.UUID) error {
+	return e.stop(taskID, &e.abandoned, workflow.ErrLeaseLost)
+}
+
+func (e *fakeEngine) stop(taskID uuid.UUID, calls *[]uuid.UUID, cause error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	*calls = append(*calls, taskID)
+	if stop, ok := e.stops[taskID]; ok {
+		stop <- cause
+		delete(e.stops, taskID)
+		return nil
+	}
+	return errors.New("task is not running")
+}
+
+func (e *fakeEngine) Cleanup(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	return nil
+}
+
+func (e *fakeEngine) GetProgress(ctx context.Context, taskID uuid.UUID) (int, error) { return 0, nil }
+
+func (e *fakeEngine) calls() (cancelled, abandoned []uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]uuid.UUID(nil), e.cancelled...), append([]uuid.UUID(nil), e.abandoned...)
+}
+
+type dispatcherFixture struct {
+	owner  uuid.UUID
+	db     *gorm.DB
+	repo   port.Repository
+	engine *fakeEngine
+	d      *TaskDispatcher
+}
+
+func newDispatcherFixture(t *testing.T, cfg config.Queue) *dispatcherFixture {
+	t.Helper()
+	db := newTestDB(t)
+	repo := persistence.NewRepository(db)
+	engine := newFakeEngine()
+	d := NewTaskDispatcher(repo, engine, nil, cfg)
+	t.Cleanup(func() {
+		close(engine.release)
+		waitFor(t, func() bool { return d.Running() == 0 })
+	})
+	return &dispatcherFixture{owner: uuid.New(), db: db, repo: repo, engine: engine, d: d}
+}
+
+func (f *dispatcherFixture) workflow(t *testing.T, tenantID uuid.UUID, code string) *workflow.Workflow {
+	t.Helper()
+	wf := &workflow.Workflow{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		OwnerID:     f.owner,
+		Code:        code,
+		Name:        code,
+		TriggerType: workflow.TriggerTypeManual,
+		Status:      workflow.StatusEnabled,
+	}
+	ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+	if err := f.repo.CreateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	return wf
+}
+
+func (f *dispatcherFixture) task(t *testing.T, wf *workflow.Workflow) *workflow.Task {
+	t.Helper()
+	task := &workflow.Task{
+		ID:                uuid.New(),
+		TenantID:          wf.TenantID,
+		TriggeredByUserID: &f.owner,
+		WorkflowID:        wf.ID,
+		Status:            workflow.TaskStatusPending,
+	}
+	if err := f.repo.CreateTask(context.Background(), task); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	return task
+}
+
+func (f *dispatcherFixture) get(t *testing.T, id uuid.UUID) *workflow.Task {
+	t.Helper()
+	task, err := f.repo.GetTask(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	return task
+}
+
+func (f *dispatcherFixture) setStatus(t *testing.T, id uuid.UUID, updates map[string]interface{}) {
+	t.Helper()
+	if err := f.db.Model(&model.TaskModel{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClaimTasks_Exclusive(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	wf := f.workflow(t, uuid.New(), "wf")
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		ids = append(ids, f.task(t, wf).ID)
+	}
+
+	var mu sync.Mutex
+	owners := make(map[uuid.UUID]string)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		owner := fmt.Sprintf("worker-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := f.repo.ClaimTasks(context.Background(), ids, owner, time.Minute, nil)
+			if err != nil {
+				t.Errorf("claim: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, task := range claimed {
+				if prev, ok := owners[task.ID]; ok {
+					t.Errorf("task %s claimed by %s and %s", task.ID, prev, owner)
+				}
+				owners[task.ID] = owner
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(owners) != len(ids) {
+		t.Fatalf("claimed %d tasks, want %d", len(owners), len(ids))
+	}
+	for id, owner := range owners {
+		task := f.get(t, id)
+		if !task.IsRunning() || task.LeaseOwner != owner {
+			t.Errorf("task %s: status=%s owner=%q, want running by %s", id, task.Status, task.LeaseOwner, owner)
+		}
+	}
+}
+
+func TestReclaimExpiredTasks(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	wf := f.workflow(t, uuid.New(), "wf")
+	expired := f.task(t, wf)
+	held := f.task(t, wf)
+	ctx := context.Background()
+
+	if _, err := f.repo.ClaimTasks(ctx, []uuid.UUID{expired.ID}, "lost", -time.Second, nil); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if _, err := f.repo.ClaimTasks(ctx, []uuid.UUID{held.ID}, "alive", time.Minute, nil); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	n, err := f.repo.ReclaimExpiredTasks(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("ReclaimExpiredTasks() = %d, %v, want 1", n, err)
+	}
+	if task := f.get(t, expired.ID); !task.IsPending() || task.LeaseOwner != "" {
+		t.Errorf("expired task: status=%s owner=%q, want pending without owner", task.Status, task.LeaseOwner)
+	}
+	if task := f.get(t, held.ID); !task.IsRunning() || task.LeaseOwner != "alive" {
+		t.Errorf("held task: status=%s owner=%q, want running by alive", task.Status, task.LeaseOwner)
+	}
+
+	// 原执行方不能再续约或写入，回收的任务可被其他执行方认领
+	renewed, err := f.repo.RenewTaskLeases(ctx, []uuid.UUID{expired.ID, held.ID}, "lost", time.Minute)
+	if err != nil || len(renewed) != 0 {
+		t.Errorf("RenewTaskLeases(lost) = %v, %v, want none", renewed, err)
+	}
+	stale := f.get(t, expired.ID)
+	stale.Status = workflow.TaskStatusFailed
+	if err := f.repo.UpdateTaskFenced(ctx, stale, "lost"); !errors.Is(err, workflow.ErrLeaseLost) {
+		t.Errorf("UpdateTaskFenced(lost) = %v, want ErrLeaseLost", err)
+	}
+	claimed, err := f.repo.ClaimTasks(ctx, []uuid.UUID{expired.ID}, "other", time.Minute, nil)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("reclaimed task not claimable: %v, %v", claimed, err)
+	}
+
+	renewed, err = f.repo.RenewTaskLeases(ctx, []uuid.UUID{held.ID}, "alive", time.Minute)
+	if err != nil || len(renewed) != 1 || renewed[0] != held.ID {
+		t.Errorf("RenewTaskLeases(alive) = %v, %v, want [%s]", renewed, err, held.ID)
+	}
+}
+
+func TestTaskDispatcher_ConcurrencyLimits(t *testing.T) {
+	tenantA, tenantB := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		cfg  config.Queue
+		// 每个租户 a、b 各有工作流 wf-a、wf-b，每个工作流两个任务
+		wantRunning int
+		check       func(t *testing.T, byTenant, byWorkflow map[uuid.UUID]int, wfs map[string]*workflow.Workflow)
+	}{
+		{
+			name:        "global",
+			cfg:         config.Queue{MaxConcurrent: 3},
+			wantRunning: 3,
+		},
+		{
+			name:        "per tenant",
+			cfg:         config.Queue{MaxPerTenant: 1},
+			wantRunning: 2,
+			check: func(t *testing.T, byTenant, _ map[uuid.UUID]int, _ map[string]*workflow.Workflow) {
+				if byTenant[tenantA] != 1 || byTenant[tenantB] != 1 {
+					t.Errorf("running by tenant = %v, want one each", byTenant)
+				}
+			},
+		},
+		{
+			name:        "tenant override",
+			cfg:         config.Queue{MaxPerTenant: 1, Tenants: map[string]int{tenantA.String(): 3}},
+			wantRunning: 4,
+			check: func(t *testing.T, byTenant, _ map[uuid.UUID]int, _ map[string]*workflow.Workflow) {
+				if byTenant[tenantA] != 3 || byTenant[tenantB] != 1 {
+					t.Errorf("running by tenant = %v, want a=3 b=1", byTenant)
+				}
+			},
+		},
+		{
+			name:        "per workflow",
+			cfg:         config.Queue{MaxPerWorkflow: 1, Workflows: map[string]int{"a-wf-b": 2}},
+			wantRunning: 5,
+			check: func(t *testing.T, _, byWorkflow map[uuid.UUID]int, wfs map[string]*workflow.Workflow) {
+				if n := byWorkflow[wfs["a-wf-b"].ID]; n != 2 {
+					t.Errorf("a-wf-b running = %d, want 2", n)
+				}
+				if n := byWorkflow[wfs["a-wf-a"].ID]; n != 1 {
+					t.Errorf("a-wf-a running = %d, want 1", n)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newDispatcherFixture(t, tt.cfg)
+			wfs := make(map[string]*workflow.Workflow)
+			for prefix, tenantID := range map[string]uuid.UUID{"a": tenantA, "b": tenantB} {
+				for _, code := range []string{"wf-a", "wf-b"} {
+					wf := f.workflow(t, tenantID, prefix+"-"+code)
+					wfs[wf.Code] = wf
+					f.task(t, wf)
+					f.task(t, wf)
+				}
+			}
+
+			f.d.dispatch(context.Background())
+
+			if got := f.d.Running(); got != tt.wantRunning {
+				t.Fatalf("Running() = %d, want %d", got, tt.wantRunning)
+			}
+			running, err := f.repo.ListRunningTasks(context.Background())
+			if err != nil {
+				t.Fatalf("list running: %v", err)
+			}
+			if len(running) != tt.wantRunning {
+				t.Fatalf("claimed %d tasks, want %d", len(running), tt.wantRunning)
+			}
+			byTenant := make(map[uuid.UUID]int)
+			byWorkflow := make(map[uuid.UUID]int)
+			for _, task := range running {
+				byTenant[task.TenantID]++
+				byWorkflow[task.WorkflowID]++
+			}
+			if tt.check != nil {
+				tt.check(t, byTenant, byWorkflow, wfs)
+			}
+
+			// 槽位占满时不再认领
+			f.d.dispatch(context.Background())
+			if got := f.d.Running(); got != tt.wantRunning {
+				t.Errorf("Running() after second dispatch = %d, want %d", got, tt.wantRunning)
+			}
+		})
+	}
+}
+
+func TestTaskDispatcher_LimitsAcrossDispatchers(t *testing.T) {
+	cfg := config.Queue{MaxConcurrent: 3, MaxPerTenant: 2}
+	f := newDispatcherFixture(t, cfg)
+	other := NewTaskDispatcher(f.repo, f.engine, nil, cfg)
+	tenantA, tenantB := uuid.New(), uuid.New()
+	for code, tenantID := range map[string]uuid.UUID{"a-wf": tenantA, "b-wf": tenantB} {
+		wf := f.workflow(t, tenantID, code)
+		for i := 0; i < 3; i++ {
+			f.task(t, wf)
+		}
+	}
+
+	// 每个派发器本地都有空闲槽位，上限按两者合计
+	f.d.dispatch(context.Background())
+	other.dispatch(context.Background())
+
+	if got := f.d.Running() + other.Running(); got != 3 {
+		t.Fatalf("running across dispatchers = %d, want the global limit 3", got)
+	}
+	counts, err := f.repo.CountRunningTasks(context.Background())
+	if err != nil {
+		t.Fatalf("count running: %v", err)
+	}
+	if counts.Total != 3 || counts.ByTenant[tenantA] > 2 || counts.ByTenant[tenantB] > 2 {
+		t.Errorf("running = %+v, want 3 with at most 2 per tenant", counts)
+	}
+}
+
+func TestTaskDispatcher_SaturatedTenantDoesNotBlockOthers(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{MaxPerTenant: 1})
+	busy := f.workflow(t, uuid.New(), "busy")
+	idle := f.workflow(t, uuid.New(), "idle")
+
+	// 积压租户的任务优先级更高且超过一批，占满按优先级读取的第一批
+	for i := 0; i < dispatchBatchSize+1; i++ {
+		task := f.task(t, busy)
+		f.setStatus(t, task.ID, map[string]interface{}{"priority": 10})
+	}
+	waiting := f.task(t, idle)
+
+	f.d.dispatch(context.Background())
+
+	if got := f.get(t, waiting.ID); !got.IsRunning() {
+		t.Fatalf("idle tenant task status = %s, want running", got.Status)
+	}
+	counts, err := f.repo.CountRunningTasks(context.Background())
+	if err != nil {
+		t.Fatalf("count running: %v", err)
+	}
+	if counts.ByTenant[busy.TenantID] != 1 || counts.Total != 2 {
+		t.Errorf("running = %+v, want one task per tenant", counts)
+	}
+
+	// 积压租户已达上限，再次派发不读取其任务
+	pending, err := f.repo.ListPendingTasks(context.Background(), dispatchBatchSize, &f.d.limits)
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending candidates = %d, want none while the busy tenant is saturated", len(pending))
+	}
+}
+
+// unavailableWorkflows 模拟读取工作流时的临时故障
+type unavailableWorkflows struct {
+	port.Repository
+}
+
+func (r unavailableWorkflows) GetWorkflowWithNodes(ctx context.Context, id uuid.UUID) (*workflow.Workflow, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestTaskDispatcher_SkipsTaskWhenWorkflowUnavailable(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	task := f.task(t, f.workflow(t, uuid.New(), "wf"))
+
+	broken := NewTaskDispatcher(unavailableWorkflows{f.repo}, f.engine, nil, config.Queue{})
+	broken.dispatch(context.Background())
+	if got := f.get(t, task.ID); !got.IsPending() || got.Error != "" {
+		t.Fatalf("task status=%s error=%q, want untouched pending task", got.Status, got.Error)
+	}
+
+	f.d.dispatch(context.Background())
+	if got := f.get(t, task.ID); !got.IsRunning() {
+		t.Fatalf("task status=%s, want running on retry", got.Status)
+	}
+}
+
+func TestTaskDispatcher_FailsTaskOfDeletedWorkflow(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	task := f.task(t, &workflow.Workflow{ID: uuid.New(), TenantID: uuid.New()})
+
+	f.d.dispatch(context.Background())
+	got := f.get(t, task.ID)
+	if !got.IsFailed() || got.LeaseOwner != f.d.ownerID {
+		t.Fatalf("task status=%s owner=%q, want failed after claim", got.Status, got.LeaseOwner)
+	}
+	if f.d.Running() != 0 {
+		t.Errorf("Running() = %d, want 0", f.d.Running())
+	}
+}
+
+func TestTaskDispatcher_RenewLeases(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	wf := f.workflow(t, uuid.New(), "wf")
+	held := f.task(t, wf)
+	reclaimed := f.task(t, wf)
+	taken := f.task(t, wf)
+	cancelled := f.task(t, wf)
+
+	ctx := context.Background()
+	f.d.dispatch(ctx)
+	if got := f.d.Running(); got != 4 {
+		t.Fatalf("Running() = %d, want 4", got)
+	}
+	waitFor(t, func() bool {
+		f.engine.mu.Lock()
+		defer f.engine.mu.Unlock()
+		return len(f.engine.stops) == 4
+	})
+
+	f.setStatus(t, reclaimed.ID, map[string]interface{}{"status": "pending", "lease_owner": "", "lease_expires_at": nil})
+	f.setStatus(t, taken.ID, map[string]interface{}{"lease_owner": "other"})
+	f.setStatus(t, cancelled.ID, map[string]interface{}{"status": "cancelled"})
+
+	f.d.renewLeases(ctx)
+
+	gotCancelled, gotAbandoned := f.engine.calls()
+	if len(gotCancelled) != 1 || gotCancelled[0] != cancelled.ID {
+		t.Errorf("cancelled = %v, want [%s]", gotCancelled, cancelled.ID)
+	}
+	abandoned := map[uuid.UUID]bool{}
+	for _, id := range gotAbandoned {
+		abandoned[id] = true
+	}
+	if len(abandoned) != 2 || !abandoned[reclaimed.ID] || !abandoned[taken.ID] {
+		t.Errorf("abandoned = %v, want %s and %s", gotAbandoned, reclaimed.ID, taken.ID)
+	}
+
+	// 放弃或取消的执行结束后不改写任务状态，仍持有租约的任务继续执行
+	waitFor(t, func() bool { return f.d.Running() == 1 })
+	if got := f.get(t, reclaimed.ID); !got.IsPending() {
+		t.Errorf("reclaimed task status = %s, want pending", got.Status)
+	}
+	if got := f.get(t, taken.ID); !got.IsRunning() || got.LeaseOwner != "other" {
+		t.Errorf("taken task status=%s owner=%q, want running by other", got.Status, got.LeaseOwner)
+	}
+	if got := f.get(t, cancelled.ID); !got.IsCancelled() {
+		t.Errorf("cancelled task status = %s, want cancelled", got.Status)
+	}
+	if got := f.get(t, held.ID); !got.IsRunning() || got.LeaseOwner != f.d.ownerID {
+		t.Errorf("held task status=%s owner=%q, want running by dispatcher", got.Status, got.LeaseOwner)
+	}
+}
+
+func TestTaskDispatcher_FailTaskFenced(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	task := f.task(t, f.workflow(t, uuid.New(), "wf"))
+	ctx := context.Background()
+
+	claimed, err := f.repo.ClaimTasks(ctx, []uuid.UUID{task.ID}, "other", time.Minute, nil)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v, %v", claimed, err)
+	}
+	f.d.failTask(ctx, claimed[0], errors.New("boom"))
+	if got := f.get(t, task.ID); !got.IsRunning() {
+		t.Errorf("task of another owner status = %s, want running", got.Status)
+	}
+}
+
+func TestLeaseElector(t *testing.T) {
+	repo := persistence.NewRepository(newTestDB(t))
+	ctx := context.Background()
+	ttl := 100 * time.Millisecond
+	a := newLeaseElector(repo, "scheduler", ttl)
+	b := newLeaseElector(repo, "scheduler", ttl)
+
+	if err := a.IsLeader(ctx); err != nil {
+		t.Fatalf("a.IsLeader() = %v, want leader", err)
+	}
+	if err := b.IsLeader(ctx); !errors.Is(err, errNotLeader) {
+		t.Fatalf("b.IsLeader() = %v, want errNotLeader", err)
+	}
+	if err := a.IsLeader(ctx); err != nil {
+		t.Fatalf("a renew = %v, want leader", err)
+	}
+
+	// 持有者停止续约后由其他副本接管
+	time.Sleep(2 * ttl)
+	if err := b.IsLeader(ctx); err != nil {
+		t.Fatalf("b.IsLeader() after expiry = %v, want leader", err)
+	}
+	if err := a.IsLeader(ctx); !errors.Is(err, errNotLeader) {
+		t.Fatalf("a.IsLeader() after takeover = %v, want errNotLeader", err)
+	}
+}
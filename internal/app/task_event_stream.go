@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goyavision/internal/app/event"
+	appport "goyavision/internal/app/port"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// taskEventHistorySize 保留用于断线续传的最近事件数
+	taskEventHistorySize = 1024
+	// taskEventSubscriberBuffer 每个订阅者的待发送事件上限，超出后断开该订阅者
+	taskEventSubscriberBuffer = 256
+)
+
+// TaskEventFilter 任务事件过滤条件，字段为空表示不限
+type TaskEventFilter struct {
+	TenantID   *uuid.UUID
+	TaskID     *uuid.UUID
+	WorkflowID *uuid.UUID
+}
+
+// Match 事件是否满足过滤条件
+func (f TaskEventFilter) Match(e *event.TaskEvent) bool {
+	if f.TenantID != nil && e.TenantID != *f.TenantID {
+		return false
+	}
+	if f.TaskID != nil && e.TaskID != *f.TaskID {
+		return false
+	}
+	if f.WorkflowID != nil && e.WorkflowID != *f.WorkflowID {
+		return false
+	}
+	return true
+}
+
+// TaskEventMessage 带流内 ID 的任务事件，ID 用作 SSE 的 id 字段
+type TaskEventMessage struct {
+	ID    string
+	Event *event.TaskEvent
+}
+
+// TaskEventStream 任务事件流
+//
+// 订阅事件总线上的任务事件，按到达顺序编号并保留最近 taskEventHistorySize 条，
+// 供 SSE 连接按过滤条件订阅；客户端携带 Last-Event-ID 重连时补发断线期间的事件。
+// 事件 ID 形如 "<epoch>-<n>"，epoch 随进程变化，进程重启后旧 ID 无法续传。
+type TaskEventStream struct {
+	epoch string
+
+	mu      sync.Mutex
+	next    int64
+	history []TaskEventMessage
+	subs    map[*TaskEventSubscription]struct{}
+}
+
+// TaskEventSubscription 事件流订阅，C 关闭表示订阅已结束（调用 Close 或消费过慢被断开）
+type TaskEventSubscription struct {
+	C <-chan TaskEventMessage
+
+	ch     chan TaskEventMessage
+	filter TaskEventFilter
+	stream *TaskEventStream
+}
+
+// NewTaskEventStream 创建任务事件流并订阅事件总线
+func NewTaskEventStream(bus appport.EventBus) *TaskEventStream {
+	s := &TaskEventStream{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:  make(map[*TaskEventSubscription]struct{}),
+	}
+	for _, eventType := range event.TaskEventTypes {
+		bus.Subscribe(eventType, s.handle)
+	}
+	return s
+}
+
+func (s *TaskEventStream) handle(_ context.Context, e appport.Event) error {
+	evt, ok := e.(*event.TaskEvent)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next++
+	msg := TaskEventMessage{ID: s.epoch + "-" + strconv.FormatInt(s.next, 10), Event: evt}
+	if len(s.history) >= taskEventHistorySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, msg)
+
+	for sub := range s.subs {
+		if !sub.filter.Match(evt) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// 消费过慢的订阅者直接断开，客户端可携带 Last-Event-ID 重连补发
+			delete(s.subs, sub)
+			close(sub.ch)
+			log.Printf("[TaskEventStream] dropped slow subscriber")
+		}
+	}
+	return nil
+}
+
+// Subscribe 按过滤条件订阅事件。lastEventID 非空时返回其后仍在保留范围内的事件，
+// resumed 表示续传成功；ID 无效、来自其他进程或已超出保留范围时 resumed 为 false，
+// 调用方应重新发送完整状态
+func (s *TaskEventStream) Subscribe(filter TaskEventFilter, lastEventID string) (sub *TaskEventSubscription, replay []TaskEventMessage, resumed bool) {
+	ch := make(chan TaskEventMessage, taskEventSubscriberBuffer)
+	sub = &TaskEventSubscription{C: ch, ch: ch, filter: filter, stream: s}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n, ok := s.parseID(lastEventID); ok {
+		oldest := s.next - int64(len(s.history)) + 1
+		if n >= oldest-1 && n <= s.next {
+			resumed = true
+			for _, msg := range s.history[n-oldest+1:] {
+				if filter.Match(msg.Event) {
+					replay = append(replay, msg)
+				}
+			}
+		}
+	}
+	s.subs[sub] = struct{}{}
+	return sub, replay, resumed
+}
+
+func (s *TaskEventStream) parseID(id string) (int64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != s.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Close 取消订阅
+func (sub *TaskEventSubscription) Close() {
+	s := sub.stream
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
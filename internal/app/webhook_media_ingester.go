@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/event"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookMediaConsumer webhook 媒体下载的持久消费者名，多副本时每个请求只由一个副本下载
+	webhookMediaConsumer = "webhook_media_ingester"
+	// webhookMediaMaxBytes webhook 媒体下载的大小上限
+	webhookMediaMaxBytes = 2 << 30
+	// webhookMediaTimeout 单次下载（含上传到文件存储）的超时
+	webhookMediaTimeout = 10 * time.Minute
+)
+
+// WebhookMediaIngester 在后台下载入站 webhook 的 media_url
+//
+// 持久消费 webhook_media_requested：下载媒体并上传到文件存储，将 pending 资产置为 ready，
+// 再以该资产创建任务并把投递记录置为 accepted；下载失败时资产置为 failed、投递记为 failed。
+// 资产已不是 pending 时视为重复投递的事件，直接跳过。
+type WebhookMediaIngester struct {
+	uow         appport.UnitOfWork
+	eventBus    appport.EventBus
+	fileStorage appport.FileStorage
+	fetcher     appport.MediaFetcher
+	dispatcher  *TaskDispatcher
+
+	sub appport.Subscription
+}
+
+// NewWebhookMediaIngester 创建 webhook 媒体下载器，dispatcher 为 nil 时任务只入队
+func NewWebhookMediaIngester(
+	uow appport.UnitOfWork,
+	eventBus appport.EventBus,
+	fileStorage appport.FileStorage,
+	fetcher appport.MediaFetcher,
+	dispatcher *TaskDispatcher,
+) *WebhookMediaIngester {
+	return &WebhookMediaIngester{
+		uow:         uow,
+		eventBus:    eventBus,
+		fileStorage: fileStorage,
+		fetcher:     fetcher,
+		dispatcher:  dispatcher,
+	}
+}
+
+// Start 订阅媒体下载请求
+func (i *WebhookMediaIngester) Start() {
+	i.sub = i.eventBus.Subscribe(event.EventTypeWebhookMediaRequested, i.handleEvent, appport.WithConsumer(webhookMediaConsumer))
+	log.Print("[WebhookMediaIngester] started")
+}
+
+// Stop 取消订阅
+func (i *WebhookMediaIngester) Stop() {
+	if i.sub != nil {
+		i.eventBus.Unsubscribe(i.sub)
+	}
+}
+
+func (i *WebhookMediaIngester) handleEvent(ctx context.Context, ev appport.Event) error {
+	req, ok := ev.(*event.WebhookMediaEvent)
+	if !ok {
+		return nil
+	}
+	ctx = middleware.ContextWithIdentity(context.WithoutCancel(ctx), req.TenantID, req.UserID)
+	return i.ingest(ctx, req)
+}
+
+// ingest 返回错误时事件按持久消费者的策略重试，仅用于数据库等可恢复的错误
+func (i *WebhookMediaIngester) ingest(ctx context.Context, req *event.WebhookMediaEvent) error {
+	var asset *media.Asset
+	err := i.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		var err error
+		asset, err = repos.Assets.Get(ctx, req.AssetID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && asset.Status != media.AssetStatusPending) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get asset: %w", err)
+	}
+
+	downloadCtx, cancel := context.WithTimeout(ctx, webhookMediaTimeout)
+	defer cancel()
+	if err := i.download(downloadCtx, req, asset); err != nil {
+		log.Printf("[WebhookMediaIngester] delivery=%s asset=%s: %v", req.DeliveryID, asset.ID, err)
+		return i.fail(ctx, req, asset, err)
+	}
+
+	task := &workflow.Task{
+		TenantID:    req.TenantID,
+		WorkflowID:  req.WorkflowID,
+		AssetID:     &asset.ID,
+		Status:      workflow.TaskStatusPending,
+		InputParams: req.InputParams,
+	}
+	if task.InputParams == nil {
+		task.InputParams = make(map[string]interface{})
+	}
+	task.InputParams["asset_id"] = asset.ID.String()
+
+	err = i.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		if err := i.updateAsset(ctx, repos, asset, media.AssetStatusReady); err != nil {
+			return err
+		}
+		delivery := &workflow.WebhookDelivery{ID: req.DeliveryID, Status: workflow.WebhookDeliveryAccepted}
+		wf, err := repos.Workflows.Get(ctx, req.WorkflowID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			delivery.Status = workflow.WebhookDeliveryFailed
+			delivery.Error = "workflow not found"
+		case err != nil:
+			return fmt.Errorf("get workflow: %w", err)
+		case !wf.IsEnabled():
+			delivery.Status = workflow.WebhookDeliveryFailed
+			delivery.Error = "workflow is not enabled"
+		default:
+			if err := repos.Tasks.Create(ctx, task); err != nil {
+				return fmt.Errorf("create task: %w", err)
+			}
+			delivery.TaskID = &task.ID
+		}
+		return repos.WorkflowWebhooks.UpdateDelivery(ctx, delivery)
+	})
+	if err != nil {
+		_ = i.fileStorage.Delete(ctx, asset.Path)
+		return err
+	}
+	i.dispatcher.Notify()
+	return nil
+}
+
+// fail 资产置为 failed、投递记为 failed
+func (i *WebhookMediaIngester) fail(ctx context.Context, req *event.WebhookMediaEvent, asset *media.Asset, cause error) error {
+	return i.uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+		if err := i.updateAsset(ctx, repos, asset, media.AssetStatusFailed); err != nil {
+			return err
+		}
+		return repos.WorkflowWebhooks.UpdateDelivery(ctx, &workflow.WebhookDelivery{
+			ID:     req.DeliveryID,
+			Status: workflow.WebhookDeliveryFailed,
+			Error:  cause.Error(),
+		})
+	})
+}
+
+// updateAsset 保存资产并随事务发布状态变更，变为 ready 时同时发布 asset_done
+func (i *WebhookMediaIngester) updateAsset(ctx context.Context, repos *appport.Repositories, asset *media.Asset, status media.AssetStatus) error {
+	previous := asset.Status
+	asset.Status = status
+	if err := repos.Assets.Update(ctx, asset); err != nil {
+		return fmt.Errorf("update asset: %w", err)
+	}
+	if err := i.eventBus.Publish(ctx, event.NewAssetStatusEvent(asset, previous)); err != nil {
+		return err
+	}
+	if asset.IsReady() {
+		return i.eventBus.Publish(ctx, event.NewAssetDoneEvent(asset))
+	}
+	return nil
+}
+
+// download 下载媒体并上传到文件存储，填充资产的路径、大小、格式与类型
+func (i *WebhookMediaIngester) download(ctx context.Context, req *event.WebhookMediaEvent, asset *media.Asset) error {
+	resp, err := i.fetcher.Fetch(ctx, req.MediaURL)
+	if err != nil {
+		return fmt.Errorf("download media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("download media: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > webhookMediaMaxBytes {
+		return errors.New("media is too large")
+	}
+
+	tmp, err := os.CreateTemp("", "goyavision-webhook-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(resp.Body, webhookMediaMaxBytes+1))
+	if err != nil {
+		return fmt.Errorf("download media: %w", err)
+	}
+	if size > webhookMediaMaxBytes {
+		return errors.New("media is too large")
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("read downloaded media: %w", err)
+	}
+
+	// 跳转后以最终地址推断扩展名
+	ext := strings.ToLower(path.Ext(resp.Request.URL.Path))
+	contentType := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mt
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(ext); byExt != "" {
+			contentType = byExt
+		}
+	}
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+
+	if req.AssetType == "" {
+		switch {
+		case strings.HasPrefix(contentType, "image/"):
+			asset.Type = media.AssetTypeImage
+		case strings.HasPrefix(contentType, "audio/"):
+			asset.Type = media.AssetTypeAudio
+		default:
+			asset.Type = media.AssetTypeVideo
+		}
+	}
+
+	objectName := fmt.Sprintf("files/%s/%s%s", asset.Type, uuid.New().String(), ext)
+	if _, err := i.fileStorage.Upload(ctx, objectName, tmp, size, contentType); err != nil {
+		return fmt.Errorf("upload media to storage: %w", err)
+	}
+	asset.Path = objectName
+	asset.Size = size
+	asset.Format = strings.TrimPrefix(ext, ".")
+	return nil
+}
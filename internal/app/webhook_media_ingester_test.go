@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"goyavision/config"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/event"
+	appport "goyavision/internal/app/port"
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/eventbus"
+	infrapersistence "goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+)
+
+type fakeFetcher struct {
+	body string
+	err  error
+}
+
+func (f *fakeFetcher) CheckURL(ctx context.Context, rawURL string) error { return f.err }
+
+func (f *fakeFetcher) Fetch(ctx context.Context, rawURL string) (*http.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	u, _ := url.Parse(rawURL)
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"image/png"}},
+		Body:          io.NopCloser(strings.NewReader(f.body)),
+		ContentLength: int64(len(f.body)),
+		Request:       &http.Request{URL: u},
+	}, nil
+}
+
+type memoryFileStorage struct {
+	objects map[string]string
+}
+
+func (s *memoryFileStorage) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	s.objects[objectName] = string(data)
+	return objectName, nil
+}
+
+func (s *memoryFileStorage) Delete(ctx context.Context, objectName string) error {
+	delete(s.objects, objectName)
+	return nil
+}
+
+func (s *memoryFileStorage) GetPublicURL(objectName string) string { return objectName }
+
+func TestWebhookMediaIngester(t *testing.T) {
+	tests := []struct {
+		name         string
+		fetcher      *fakeFetcher
+		wantAsset    media.AssetStatus
+		wantDelivery workflow.WebhookDeliveryStatus
+		wantTask     bool
+	}{
+		{"downloaded", &fakeFetcher{body: "png"}, media.AssetStatusReady, workflow.WebhookDeliveryAccepted, true},
+		{"download failed", &fakeFetcher{err: errors.New("forbidden target")}, media.AssetStatusFailed, workflow.WebhookDeliveryFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newDispatcherFixture(t, config.Queue{})
+			if err := f.db.AutoMigrate(&model.MediaAssetModel{}, &model.WebhookDeliveryModel{}); err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			uow := infrapersistence.NewUnitOfWork(f.db)
+			storage := &memoryFileStorage{objects: make(map[string]string)}
+			ingester := NewWebhookMediaIngester(uow, eventbus.NewLocalEventBus(0), storage, tt.fetcher, nil)
+
+			tenantID := uuid.New()
+			ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+			wf := f.workflow(t, tenantID, "webhook")
+			asset := &media.Asset{
+				ID: uuid.New(), TenantID: tenantID, Visibility: media.VisibilityPrivate, Type: media.AssetTypeVideo,
+				SourceType: media.AssetSourceUpload, Name: "frame", Status: media.AssetStatusPending,
+			}
+			delivery := &workflow.WebhookDelivery{
+				ID: uuid.New(), TenantID: tenantID, WebhookID: uuid.New(), WorkflowID: wf.ID,
+				Status: workflow.WebhookDeliveryPending, AssetID: &asset.ID,
+			}
+			err := uow.Do(ctx, func(ctx context.Context, repos *appport.Repositories) error {
+				if err := repos.Assets.Create(ctx, asset); err != nil {
+					return err
+				}
+				return repos.WorkflowWebhooks.CreateDelivery(ctx, delivery)
+			})
+			if err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+
+			ev := event.NewWebhookMediaEvent(delivery, f.owner, "https://media.example.com/frame.png", "", map[string]interface{}{"camera": "c1"})
+			for i := 0; i < 2; i++ {
+				// 重复投递的事件不会重复下载或创建任务
+				if err := ingester.handleEvent(context.Background(), ev); err != nil {
+					t.Fatalf("handleEvent: %v", err)
+				}
+			}
+
+			var assetRow model.MediaAssetModel
+			if err := f.db.First(&assetRow, "id = ?", asset.ID).Error; err != nil {
+				t.Fatalf("get asset: %v", err)
+			}
+			if media.AssetStatus(assetRow.Status) != tt.wantAsset {
+				t.Errorf("asset status = %s, want %s", assetRow.Status, tt.wantAsset)
+			}
+			var deliveryRow model.WebhookDeliveryModel
+			if err := f.db.First(&deliveryRow, "id = ?", delivery.ID).Error; err != nil {
+				t.Fatalf("get delivery: %v", err)
+			}
+			if workflow.WebhookDeliveryStatus(deliveryRow.Status) != tt.wantDelivery {
+				t.Errorf("delivery status = %s (%s), want %s", deliveryRow.Status, deliveryRow.Error, tt.wantDelivery)
+			}
+
+			var tasks []model.TaskModel
+			if err := f.db.Find(&tasks, "workflow_id = ?", wf.ID).Error; err != nil {
+				t.Fatalf("list tasks: %v", err)
+			}
+			if !tt.wantTask {
+				if len(tasks) != 0 || deliveryRow.TaskID != nil {
+					t.Errorf("tasks = %d, delivery task = %v, want none", len(tasks), deliveryRow.TaskID)
+				}
+				return
+			}
+			if len(tasks) != 1 {
+				t.Fatalf("tasks = %d, want 1", len(tasks))
+			}
+			if deliveryRow.TaskID == nil || *deliveryRow.TaskID != tasks[0].ID {
+				t.Errorf("delivery task = %v, want %s", deliveryRow.TaskID, tasks[0].ID)
+			}
+			if assetRow.Type != string(media.AssetTypeImage) || storage.objects[assetRow.Path] != "png" {
+				t.Errorf("asset type = %s path = %s, want an uploaded image", assetRow.Type, assetRow.Path)
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"goyavision/internal/api/middleware"
 	"goyavision/internal/app/event"
 	appport "goyavision/internal/app/port"
 	"goyavision/internal/domain/workflow"
@@ -14,31 +19,58 @@ import (
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// approvalTimeoutCheckInterval 审批超时检查间隔
+const approvalTimeoutCheckInterval = 30 * time.Second
+
+// taskDeadlineCheckInterval 任务截止时间与 SLA 检查间隔
+const taskDeadlineCheckInterval = 30 * time.Second
+
+// queuedRunCheckInterval 检查排队与补跑的触发能否执行的间隔
+const queuedRunCheckInterval = 10 * time.Second
+
+// maxMissedRunScan 计算错过的触发时每次最多逐个枚举的触发时间数，超过后按已枚举的平均间隔跳到接近当前时间处继续
+const maxMissedRunScan = 10000
+
+const (
+	// schedulerLeaderLease 定时调度的领导者租约名，多副本部署时只有领导者执行调度作业
+	schedulerLeaderLease = "workflow_scheduler"
+	schedulerLeaderTTL   = 30 * time.Second
+	// leaderRenewInterval 续约作业间隔：每次作业触发都会经过选举，领导者借此续约、其他副本借此接管过期租约
+	leaderRenewInterval = schedulerLeaderTTL / 3
+	// schedulerEventConsumer 事件触发的持久消费者名，持久化事件总线下重启后从上次位置继续，多副本时只由一个副本触发
+	schedulerEventConsumer = "workflow_scheduler"
 )
 
 // WorkflowScheduler 工作流调度器
 type WorkflowScheduler struct {
-	scheduler gocron.Scheduler
-	repo      port.Repository
-	engine    port.WorkflowEngine
-	eventBus  appport.EventBus
-	jobs      map[uuid.UUID]gocron.Job
-	jobsMu    sync.RWMutex
+	scheduler  gocron.Scheduler
+	repo       port.Repository
+	dispatcher *TaskDispatcher
+	eventBus   appport.EventBus
+	jobs       map[uuid.UUID]gocron.Job
+	jobsMu     sync.RWMutex
 }
 
-// NewWorkflowScheduler 创建工作流调度器。eventBus 可选，为 nil 时不启用事件触发。
-func NewWorkflowScheduler(repo port.Repository, engine port.WorkflowEngine, eventBus appport.EventBus) (*WorkflowScheduler, error) {
-	s, err := gocron.NewScheduler()
+// NewWorkflowScheduler 创建工作流调度器，触发的任务入队后由 dispatcher（可为 nil，表示由独立 worker 执行）派发。
+// eventBus 可选，为 nil 时不启用事件触发。定时作业经数据库租约选主，多副本部署时每次调度只触发一次。
+func NewWorkflowScheduler(repo port.Repository, dispatcher *TaskDispatcher, eventBus appport.EventBus) (*WorkflowScheduler, error) {
+	s, err := gocron.NewScheduler(
+		gocron.WithDistributedElector(newLeaseElector(repo, schedulerLeaderLease, schedulerLeaderTTL)),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("create scheduler: %w", err)
 	}
 
 	ws := &WorkflowScheduler{
-		scheduler: s,
-		repo:      repo,
-		engine:    engine,
-		eventBus:  eventBus,
-		jobs:      make(map[uuid.UUID]gocron.Job),
+		scheduler:  s,
+		repo:       repo,
+		dispatcher: dispatcher,
+		eventBus:   eventBus,
+		jobs:       make(map[uuid.UUID]gocron.Job),
 	}
 	return ws, nil
 }
@@ -49,9 +81,39 @@ func (s *WorkflowScheduler) Start(ctx context.Context) error {
 	if err := s.loadAndScheduleWorkflows(ctx); err != nil {
 		return err
 	}
+	if err := s.resumeInterruptedTasks(ctx); err != nil {
+		return err
+	}
+	if _, err := s.scheduler.NewJob(
+		gocron.DurationJob(approvalTimeoutCheckInterval),
+		gocron.NewTask(s.resumeExpiredApprovals),
+	); err != nil {
+		return fmt.Errorf("create approval timeout job: %w", err)
+	}
+	if _, err := s.scheduler.NewJob(
+		gocron.DurationJob(taskDeadlineCheckInterval),
+		gocron.NewTask(s.checkTaskDeadlines),
+	); err != nil {
+		return fmt.Errorf("create task deadline job: %w", err)
+	}
+	if _, err := s.scheduler.NewJob(
+		gocron.DurationJob(queuedRunCheckInterval),
+		gocron.NewTask(s.runQueued),
+	); err != nil {
+		return fmt.Errorf("create queued run job: %w", err)
+	}
+	if _, err := s.scheduler.NewJob(
+		gocron.DurationJob(leaderRenewInterval),
+		gocron.NewTask(func() {}),
+	); err != nil {
+		return fmt.Errorf("create leader renew job: %w", err)
+	}
 	if s.eventBus != nil {
-		s.eventBus.Subscribe(event.EventTypeAssetNew, s.handleAssetNew)
-		s.eventBus.Subscribe(event.EventTypeAssetDone, s.handleAssetDone)
+		for _, eventType := range event.TriggerEventTypes() {
+			s.eventBus.Subscribe(eventType, s.handleEvent, appport.WithConsumer(schedulerEventConsumer))
+		}
+		// 临时订阅：每个副本都需要按最新配置重建自己的定时作业
+		s.eventBus.Subscribe(event.EventTypeWorkflowUpdated, s.handleWorkflowUpdated)
 	}
 	return nil
 }
@@ -61,52 +123,385 @@ func (s *WorkflowScheduler) Stop() error {
 	return s.scheduler.Shutdown()
 }
 
-// loadAndScheduleWorkflows 加载并调度所有启用的工作流
+// loadAndScheduleWorkflows 加载并调度所有启用的工作流，按 misfire 策略补跑停机期间错过的触发
 func (s *WorkflowScheduler) loadAndScheduleWorkflows(ctx context.Context) error {
 	workflows, err := s.repo.ListEnabledWorkflows(ctx)
 	if err != nil {
 		return fmt.Errorf("list enabled workflows: %w", err)
 	}
 
+	now := time.Now()
+	caughtUp := false
 	for _, wf := range workflows {
 		if wf.TriggerType == workflow.TriggerTypeSchedule {
+			// 错过的触发须在重新调度（写入新的下一次触发时间）之前计算并记录
+			if missed := missedRuns(wf, now); len(missed) > 0 && s.recordMissed(ctx, wf, now, missed) {
+				caughtUp = true
+			}
 			if err := s.ScheduleWorkflow(ctx, wf); err != nil {
-				continue
+				log.Printf("[WorkflowScheduler] schedule workflow=%s: %v", wf.ID, err)
 			}
 		}
 	}
 
+	if caughtUp {
+		// 立即补跑；本副本不是领导者时作业不执行，由领导者的排队检查作业补跑
+		if _, err := s.scheduler.NewJob(
+			gocron.OneTimeJob(gocron.OneTimeJobStartImmediately()),
+			gocron.NewTask(s.runQueued),
+		); err != nil {
+			log.Printf("[WorkflowScheduler] create catch up job: %v", err)
+		}
+	}
 	return nil
 }
 
-// ScheduleWorkflow 调度工作流
-func (s *WorkflowScheduler) ScheduleWorkflow(ctx context.Context, wf *workflow.Workflow) error {
-	s.jobsMu.Lock()
-	defer s.jobsMu.Unlock()
+// recordMissed 将错过的触发记录为待执行的触发，由执行调度作业的领导者补跑。
+// 多个副本同时启动时只有推进了下一次触发时间的副本记录，返回是否记录
+func (s *WorkflowScheduler) recordMissed(ctx context.Context, wf *workflow.Workflow, now time.Time, times []time.Time) bool {
+	var nextRunAt *time.Time
+	if next, err := scheduleNext(wf.TriggerConf); err == nil {
+		if n := next(now); !n.IsZero() {
+			nextRunAt = &n
+		}
+	}
+	recorded, err := s.repo.RecordMissedRuns(ctx, wf, now, nextRunAt, times)
+	if err != nil {
+		log.Printf("[WorkflowScheduler] record missed runs workflow=%s: %v", wf.ID, err)
+		return false
+	}
+	if recorded {
+		log.Printf("[WorkflowScheduler] catching up %d missed runs workflow=%s", len(times), wf.ID)
+	}
+	return recorded
+}
+
+// missedRuns 按 trigger_conf.misfire 返回需要补跑的触发时间：从记录的下一次触发时间到 now 之间错过的触发，
+// catch_up 时取最近的若干次。从未调度过（没有下一次触发时间）的工作流没有错过的触发
+func missedRuns(wf *workflow.Workflow, now time.Time) []time.Time {
+	tc := wf.TriggerConf
+	if tc == nil || wf.NextRunAt == nil || !wf.NextRunAt.Before(now) {
+		return nil
+	}
+	next, err := scheduleNext(tc)
+	if err != nil {
+		return nil
+	}
 
-	if _, exists := s.jobs[wf.ID]; exists {
+	keep := tc.MisfireRuns(math.MaxInt)
+	if keep == 0 {
 		return nil
 	}
+	recent := make([]time.Time, 0, keep+1)
+	missed, scanned := 0, 0
+	from := *wf.NextRunAt
+	for t := from; t.Before(now); {
+		missed++
+		scanned++
+		recent = append(recent, t)
+		if len(recent) > keep {
+			recent = recent[1:]
+		}
+		// 没有后续触发时间的 cron 表达式返回零值
+		n := next(t)
+		if !n.After(t) {
+			break
+		}
+		// 停机时间过长时不再逐个枚举：只需保留最近 keep 次触发，跳过的触发不计入 missed
+		if scanned == maxMissedRunScan {
+			period := t.Sub(from) / time.Duration(scanned-1)
+			if skip := now.Add(-period * time.Duration(keep+1)); skip.After(n) {
+				n = next(skip)
+				if !n.After(t) {
+					break
+				}
+			}
+			from, scanned = n, 0
+		}
+		t = n
+	}
+	n := tc.MisfireRuns(missed)
+	return recent[len(recent)-n:]
+}
+
+// scheduleNext 返回计算下一次触发时间的函数，与作业的 cron 表达式（含时区）或间隔一致
+func scheduleNext(tc *workflow.TriggerConfig) (func(time.Time) time.Time, error) {
+	if tc.Schedule != "" {
+		sched, err := cron.ParseStandard(cronSpec(tc))
+		if err != nil {
+			return nil, err
+		}
+		return sched.Next, nil
+	}
+	if tc.IntervalSec > 0 {
+		interval := time.Duration(tc.IntervalSec) * time.Second
+		return func(t time.Time) time.Time { return t.Add(interval) }, nil
+	}
+	return nil, fmt.Errorf("invalid trigger config: no schedule or interval specified")
+}
+
+// cronSpec 为 cron 表达式加上 trigger_conf.timezone 指定的时区
+func cronSpec(tc *workflow.TriggerConfig) string {
+	if tc.Timezone == "" || strings.HasPrefix(tc.Schedule, "TZ=") || strings.HasPrefix(tc.Schedule, "CRON_TZ=") {
+		return tc.Schedule
+	}
+	return "CRON_TZ=" + tc.Timezone + " " + tc.Schedule
+}
+
+// resumeInterruptedTasks 将进程重启前处于 running 状态且没有租约的任务重新入队，派发后从检查点继续执行。
+// 持有租约的任务可能仍在其他执行方运行，由派发器在租约过期后回收。
+func (s *WorkflowScheduler) resumeInterruptedTasks(ctx context.Context) error {
+	tasks, err := s.repo.ListRunningTasks(ctx)
+	if err != nil {
+		return fmt.Errorf("list running tasks: %w", err)
+	}
+
+	for _, task := range tasks {
+		// 子任务由父任务的子工作流节点负责恢复
+		if task.IsSubTask() || task.LeaseExpiresAt != nil {
+			continue
+		}
+
+		log.Printf("[WorkflowScheduler] requeueing interrupted task=%s workflow=%s", task.ID, task.WorkflowID)
+		task.Status = workflow.TaskStatusPending
+		if err := s.repo.UpdateTask(ctx, task); err != nil {
+			log.Printf("[WorkflowScheduler] resumeInterruptedTasks: requeue task=%s: %v", task.ID, err)
+		}
+	}
+	s.dispatcher.Notify()
+
+	return nil
+}
+
+// ResumeTask 将处于 waiting 状态的任务重新入队（审批决策后调用）。
+// 子任务由父任务的子工作流节点负责恢复，因此实际恢复的是最外层的调用方任务。
+func (s *WorkflowScheduler) ResumeTask(ctx context.Context, taskID uuid.UUID) error {
+	task, err := s.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("get task: %w", err)
+	}
+	for task.IsSubTask() {
+		task, err = s.repo.GetTask(ctx, *task.CallerTaskID)
+		if err != nil {
+			return fmt.Errorf("get caller task: %w", err)
+		}
+	}
+	if !task.IsWaiting() {
+		return fmt.Errorf("task %s is not waiting", task.ID)
+	}
 
-	var triggerConf *workflow.TriggerConfig
-	if wf.TriggerConf != nil {
-		triggerConf = wf.TriggerConf
+	task.Status = workflow.TaskStatusPending
+	if err := s.repo.UpdateTask(ctx, task); err != nil {
+		return fmt.Errorf("requeue task: %w", err)
 	}
+	s.dispatcher.Notify()
+	return nil
+}
 
-	job, err := s.createJob(wf, triggerConf)
+// resumeExpiredApprovals 恢复审批已超时的等待任务，由引擎按节点的超时策略自动决策
+func (s *WorkflowScheduler) resumeExpiredApprovals() {
+	ctx := context.Background()
+	tasks, err := s.repo.ListWaitingTasks(ctx)
 	if err != nil {
+		log.Printf("[WorkflowScheduler] resumeExpiredApprovals: list waiting tasks: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		deadline := task.ApprovalDeadline()
+		if deadline == nil || now.Before(*deadline) {
+			continue
+		}
+		log.Printf("[WorkflowScheduler] approval timed out task=%s", task.ID)
+		if err := s.ResumeTask(ctx, task.ID); err != nil {
+			log.Printf("[WorkflowScheduler] resumeExpiredApprovals: resume task=%s: %v", task.ID, err)
+		}
+	}
+}
+
+// checkTaskDeadlines 终止已过截止时间的排队与等待任务（执行中的任务由引擎按截止时间终止），
+// 并为排队或运行时长超过工作流 SLA 阈值的任务发布一次告警事件
+func (s *WorkflowScheduler) checkTaskDeadlines() {
+	ctx := context.Background()
+	tasks, err := s.repo.ListUnfinishedTasks(ctx)
+	if err != nil {
+		log.Printf("[WorkflowScheduler] checkTaskDeadlines: list unfinished tasks: %v", err)
+		return
+	}
+
+	workflows := make(map[uuid.UUID]*workflow.Workflow)
+	now := time.Now()
+	for _, task := range tasks {
+		wf, ok := workflows[task.WorkflowID]
+		if !ok {
+			wf, err = s.repo.GetWorkflow(ctx, task.WorkflowID)
+			if err != nil {
+				log.Printf("[WorkflowScheduler] checkTaskDeadlines: get workflow %s: %v", task.WorkflowID, err)
+				continue
+			}
+			workflows[task.WorkflowID] = wf
+		}
+
+		if !task.IsRunning() {
+			if deadline := task.EffectiveDeadline(wf.MaxDuration()); deadline != nil && now.After(*deadline) {
+				s.timeOutTask(ctx, task, *deadline)
+				continue
+			}
+		}
+		if task.SLABreachedAt == nil {
+			if reason := wf.SLA().Breach(task, now); reason != "" {
+				s.reportSLABreach(ctx, task, reason, now)
+			}
+		}
+	}
+}
+
+// timeOutTask 将未在执行的任务置为 timed_out
+func (s *WorkflowScheduler) timeOutTask(ctx context.Context, task *workflow.Task, deadline time.Time) {
+	task.TimeOut(fmt.Sprintf("task exceeded its deadline %s", deadline.Format(time.RFC3339)))
+	update := &workflow.Task{
+		ID:          task.ID,
+		Status:      task.Status,
+		Error:       task.Error,
+		CompletedAt: task.CompletedAt,
+	}
+	if err := s.repo.UpdateTask(ctx, update); err != nil {
+		log.Printf("[WorkflowScheduler] timeOutTask: update task=%s: %v", task.ID, err)
+		return
+	}
+	log.Printf("[WorkflowScheduler] task timed out task=%s deadline=%s", task.ID, deadline.Format(time.RFC3339))
+
+	ev := event.NewTaskEvent(event.EventTypeTaskStatus, task.ID, task.TenantID, task.WorkflowID)
+	ev.Status = string(task.Status)
+	ev.Progress = task.Progress
+	ev.Error = task.Error
+	s.publish(ctx, ev)
+}
+
+// reportSLABreach 记录任务首次超过 SLA 阈值的时间并发布告警事件
+func (s *WorkflowScheduler) reportSLABreach(ctx context.Context, task *workflow.Task, reason string, now time.Time) {
+	if err := s.repo.UpdateTask(ctx, &workflow.Task{ID: task.ID, SLABreachedAt: &now}); err != nil {
+		log.Printf("[WorkflowScheduler] reportSLABreach: update task=%s: %v", task.ID, err)
+		return
+	}
+	log.Printf("[WorkflowScheduler] sla breached task=%s: %s", task.ID, reason)
+
+	ev := event.NewTaskEvent(event.EventTypeTaskSLABreached, task.ID, task.TenantID, task.WorkflowID)
+	ev.Status = string(task.Status)
+	ev.Progress = task.Progress
+	ev.Message = reason
+	s.publish(ctx, ev)
+}
+
+func (s *WorkflowScheduler) publish(ctx context.Context, ev appport.Event) {
+	if s.eventBus == nil {
+		return
+	}
+	if err := s.eventBus.Publish(ctx, ev); err != nil {
+		log.Printf("[WorkflowScheduler] publish %s: %v", ev.EventType(), err)
+	}
+}
+
+// ScheduleWorkflow 按工作流当前的触发配置调度，已有作业时先移除再重建，并记录下一次触发时间
+func (s *WorkflowScheduler) ScheduleWorkflow(ctx context.Context, wf *workflow.Workflow) error {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	if err := s.removeJobLocked(wf.ID); err != nil {
+		return err
+	}
+
+	job, err := s.createJob(wf, wf.TriggerConf)
+	if err != nil {
+		s.saveRunTimes(ctx, wf.ID, nil, nil)
 		return fmt.Errorf("create job: %w", err)
 	}
 
 	s.jobs[wf.ID] = job
+	s.saveRunTimes(ctx, wf.ID, nil, job)
+	return nil
+}
+
+// RescheduleWorkflow 工作流创建、更新、启用或停用后立即按最新配置重建本副本的定时作业，
+// 并广播 workflow_updated 事件，其他副本（包括执行调度作业的领导者）据此重建作业
+func (s *WorkflowScheduler) RescheduleWorkflow(ctx context.Context, wf *workflow.Workflow) {
+	s.applySchedule(ctx, wf)
+	s.publish(ctx, event.NewWorkflowEvent(event.EventTypeWorkflowUpdated, wf))
+}
+
+// RemoveWorkflow 工作流删除后移除本副本的定时作业，并广播 workflow_updated 事件通知其他副本
+func (s *WorkflowScheduler) RemoveWorkflow(ctx context.Context, workflowID uuid.UUID) {
+	if err := s.UnscheduleWorkflow(workflowID); err != nil {
+		log.Printf("[WorkflowScheduler] unschedule workflow=%s: %v", workflowID, err)
+	}
+	tenantID, _ := middleware.GetTenantID(ctx)
+	s.publish(ctx, &event.WorkflowEvent{
+		Type:       event.EventTypeWorkflowUpdated,
+		WorkflowID: workflowID,
+		TenantID:   tenantID,
+		At:         time.Now().Unix(),
+	})
+}
+
+// applySchedule 按工作流当前状态重建本副本的定时作业，未启用或不是定时触发的工作流移除作业
+func (s *WorkflowScheduler) applySchedule(ctx context.Context, wf *workflow.Workflow) {
+	if wf.IsEnabled() && wf.TriggerType == workflow.TriggerTypeSchedule {
+		if err := s.ScheduleWorkflow(ctx, wf); err != nil {
+			log.Printf("[WorkflowScheduler] reschedule workflow=%s: %v", wf.ID, err)
+		}
+		return
+	}
+	if err := s.UnscheduleWorkflow(wf.ID); err != nil {
+		log.Printf("[WorkflowScheduler] unschedule workflow=%s: %v", wf.ID, err)
+	}
+}
+
+// handleWorkflowUpdated 收到 workflow_updated 事件后按数据库中的最新状态重建本副本的定时作业，
+// 工作流已删除时移除作业
+func (s *WorkflowScheduler) handleWorkflowUpdated(ctx context.Context, ev appport.Event) error {
+	we, ok := ev.(*event.WorkflowEvent)
+	if !ok {
+		return nil
+	}
+	// 以工作流所属租户与所有者的身份读取，后台上下文只能看到公开工作流
+	ctx = middleware.ContextWithIdentity(context.WithoutCancel(ctx), we.TenantID, we.OwnerID)
+	wf, err := s.repo.GetWorkflowWithNodes(ctx, we.WorkflowID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.UnscheduleWorkflow(we.WorkflowID); err != nil {
+			log.Printf("[WorkflowScheduler] unschedule workflow=%s: %v", we.WorkflowID, err)
+		}
+		return nil
+	}
+	if err != nil {
+		log.Printf("[WorkflowScheduler] handleWorkflowUpdated: get workflow %s: %v", we.WorkflowID, err)
+		return err
+	}
+	s.applySchedule(ctx, wf)
 	return nil
 }
 
-// UnscheduleWorkflow 取消调度工作流
+// UnscheduleWorkflow 取消调度工作流，丢弃排队与待补跑的触发并清空下一次触发时间
 func (s *WorkflowScheduler) UnscheduleWorkflow(workflowID uuid.UUID) error {
 	s.jobsMu.Lock()
 	defer s.jobsMu.Unlock()
 
+	// 删除失败时残留的触发由领导者在工作流未启用定时触发时丢弃
+	if err := s.repo.DeleteScheduledRunsByWorkflow(context.Background(), workflowID); err != nil {
+		log.Printf("[WorkflowScheduler] drop scheduled runs workflow=%s: %v", workflowID, err)
+	}
+
+	if _, exists := s.jobs[workflowID]; !exists {
+		return nil
+	}
+	if err := s.removeJobLocked(workflowID); err != nil {
+		return err
+	}
+	s.saveRunTimes(context.Background(), workflowID, nil, nil)
+	return nil
+}
+
+func (s *WorkflowScheduler) removeJobLocked(workflowID uuid.UUID) error {
 	job, exists := s.jobs[workflowID]
 	if !exists {
 		return nil
@@ -120,8 +515,24 @@ func (s *WorkflowScheduler) UnscheduleWorkflow(workflowID uuid.UUID) error {
 	return nil
 }
 
+// saveRunTimes 记录上一次触发时间（nil 时保留原值）与作业的下一次触发时间（job 为 nil 时清空）
+func (s *WorkflowScheduler) saveRunTimes(ctx context.Context, workflowID uuid.UUID, lastRunAt *time.Time, job gocron.Job) {
+	var nextRunAt *time.Time
+	if job != nil {
+		if next, err := job.NextRun(); err == nil && !next.IsZero() {
+			nextRunAt = &next
+		}
+	}
+	if err := s.repo.UpdateWorkflowRunTimes(ctx, workflowID, lastRunAt, nextRunAt); err != nil {
+		log.Printf("[WorkflowScheduler] save run times workflow=%s: %v", workflowID, err)
+	}
+}
+
 // createJob 创建调度任务
 func (s *WorkflowScheduler) createJob(workflow *workflow.Workflow, triggerConf *workflow.TriggerConfig) (gocron.Job, error) {
+	if triggerConf == nil {
+		return nil, fmt.Errorf("invalid trigger config: no schedule or interval specified")
+	}
 	if triggerConf.Schedule != "" {
 		return s.createCronJob(workflow, triggerConf)
 	}
@@ -148,10 +559,10 @@ func (s *WorkflowScheduler) createIntervalJob(wf *workflow.Workflow, triggerConf
 	return job, nil
 }
 
-// createCronJob 创建 Cron 任务
+// createCronJob 创建 Cron 任务，按 trigger_conf.timezone 解释 cron 表达式
 func (s *WorkflowScheduler) createCronJob(wf *workflow.Workflow, triggerConf *workflow.TriggerConfig) (gocron.Job, error) {
 	job, err := s.scheduler.NewJob(
-		gocron.CronJob(triggerConf.Schedule, false),
+		gocron.CronJob(cronSpec(triggerConf), false),
 		gocron.NewTask(s.runWorkflow, wf.ID),
 	)
 	if err != nil {
@@ -164,6 +575,7 @@ func (s *WorkflowScheduler) createCronJob(wf *workflow.Workflow, triggerConf *wo
 // runWorkflow 执行工作流（由定时任务调用，无请求 context）
 func (s *WorkflowScheduler) runWorkflow(workflowID uuid.UUID) {
 	ctx := context.Background()
+	now := time.Now()
 
 	wf, err := s.repo.GetWorkflowWithNodes(ctx, workflowID)
 	if err != nil {
@@ -176,34 +588,181 @@ func (s *WorkflowScheduler) runWorkflow(workflowID uuid.UUID) {
 		return
 	}
 
-	task := &workflow.Task{
-		WorkflowID: wf.ID,
-		Status:     workflow.TaskStatusPending,
-		Progress:   0,
+	s.jobsMu.RLock()
+	job := s.jobs[workflowID]
+	s.jobsMu.RUnlock()
+	if job != nil {
+		s.saveRunTimes(ctx, workflowID, &now, job)
 	}
 
-	if err := s.repo.CreateTask(ctx, task); err != nil {
-		log.Printf("[WorkflowScheduler] runWorkflow: create task for workflow %s: %v", workflowID, err)
+	s.fire(ctx, wf, now)
+}
+
+// fire 处理一次定时触发：按 trigger_conf.overlap，上一次运行未结束时照常创建任务、跳过或排队。
+// 排队的触发写入数据库，按触发时间先后执行，重启或领导者切换后由新的领导者继续。
+// 任务输入参数 scheduled_at 为本次触发时间
+func (s *WorkflowScheduler) fire(ctx context.Context, wf *workflow.Workflow, scheduledAt time.Time) {
+	switch wf.TriggerConf.OverlapPolicy() {
+	case workflow.OverlapQueue:
+		// 总是先排队，使本次触发排在更早的排队触发之后
+		run := &workflow.ScheduledRun{TenantID: wf.TenantID, WorkflowID: wf.ID, ScheduledAt: scheduledAt}
+		if err := s.repo.QueueScheduledRun(ctx, run); err != nil {
+			log.Printf("[WorkflowScheduler] fire: queue run workflow=%s: %v", wf.ID, err)
+			return
+		}
+		runs, err := s.repo.ListScheduledRuns(ctx)
+		if err != nil {
+			log.Printf("[WorkflowScheduler] fire: list queued runs workflow=%s: %v", wf.ID, err)
+			return
+		}
+		s.drain(ctx, wf, runsOf(runs, wf.ID))
+	case workflow.OverlapSkip:
+		busy, err := s.hasUnfinishedTask(ctx, wf.ID)
+		if err != nil {
+			log.Printf("[WorkflowScheduler] fire: check running tasks workflow=%s: %v", wf.ID, err)
+			return
+		}
+		if busy {
+			log.Printf("[WorkflowScheduler] previous run still going, skipping workflow=%s scheduled_at=%s", wf.ID, scheduledAt.Format(time.RFC3339))
+			return
+		}
+		s.enqueueScheduled(ctx, wf, scheduledAt)
+	default:
+		s.enqueueScheduled(ctx, wf, scheduledAt)
+	}
+}
+
+// runQueued 执行数据库中排队与待补跑的触发，只由执行调度作业的领导者执行
+func (s *WorkflowScheduler) runQueued() {
+	ctx := context.Background()
+
+	runs, err := s.repo.ListScheduledRuns(ctx)
+	if err != nil {
+		log.Printf("[WorkflowScheduler] runQueued: list scheduled runs: %v", err)
+		return
+	}
+	if len(runs) == 0 {
+		return
+	}
+	workflows, err := s.repo.ListEnabledWorkflows(ctx)
+	if err != nil {
+		log.Printf("[WorkflowScheduler] runQueued: list workflows: %v", err)
 		return
 	}
+	enabled := make(map[uuid.UUID]*workflow.Workflow, len(workflows))
+	for _, wf := range workflows {
+		if wf.TriggerType == workflow.TriggerTypeSchedule {
+			enabled[wf.ID] = wf
+		}
+	}
+
+	for _, id := range workflowIDsOf(runs) {
+		wf, ok := enabled[id]
+		if !ok {
+			// 工作流已停用、删除或不再定时触发
+			if err := s.repo.DeleteScheduledRunsByWorkflow(ctx, id); err != nil {
+				log.Printf("[WorkflowScheduler] runQueued: drop runs workflow=%s: %v", id, err)
+			}
+			continue
+		}
+		s.drain(ctx, wf, runsOf(runs, id))
+	}
+}
 
-	go func() {
-		runCtx := context.Background()
-		if err := s.engine.Execute(runCtx, wf, task); err != nil {
-			log.Printf("[WorkflowScheduler] runWorkflow: execute failed workflow=%s task=%s: %v", workflowID, task.ID, err)
-			now := time.Now()
-			task.Status = workflow.TaskStatusFailed
-			task.Error = err.Error()
-			task.CompletedAt = &now
-			if updateErr := s.repo.UpdateTask(runCtx, task); updateErr != nil {
-				log.Printf("[WorkflowScheduler] runWorkflow: update task status failed task=%s: %v", task.ID, updateErr)
+// drain 按触发时间先后执行工作流待执行的触发：overlap 为 allow 时全部创建任务；
+// 上一次运行未结束时，skip 丢弃触发（补跑的触发），queue 保留触发等待下一次检查
+func (s *WorkflowScheduler) drain(ctx context.Context, wf *workflow.Workflow, runs []*workflow.ScheduledRun) {
+	policy := wf.TriggerConf.OverlapPolicy()
+	for _, run := range runs {
+		if policy != workflow.OverlapAllow {
+			busy, err := s.hasUnfinishedTask(ctx, wf.ID)
+			if err != nil {
+				log.Printf("[WorkflowScheduler] drain: check running tasks workflow=%s: %v", wf.ID, err)
+				return
+			}
+			if busy && policy == workflow.OverlapQueue {
+				log.Printf("[WorkflowScheduler] previous run still going, queued workflow=%s scheduled_at=%s", wf.ID, run.ScheduledAt.Format(time.RFC3339))
+				return
+			}
+			if busy {
+				log.Printf("[WorkflowScheduler] previous run still going, skipping workflow=%s scheduled_at=%s", wf.ID, run.ScheduledAt.Format(time.RFC3339))
+				if err := s.repo.DeleteScheduledRun(ctx, run.ID); err != nil {
+					log.Printf("[WorkflowScheduler] drain: drop run workflow=%s: %v", wf.ID, err)
+				}
+				continue
 			}
 		}
-	}()
+
+		started, err := s.repo.StartScheduledRun(ctx, run.ID, newScheduledTask(wf, run.ScheduledAt))
+		if err != nil {
+			log.Printf("[WorkflowScheduler] drain: create task for workflow %s: %v", wf.ID, err)
+			return
+		}
+		if started {
+			s.dispatcher.Notify()
+		}
+	}
+}
+
+// runsOf 返回工作流的触发，保持触发时间先后顺序
+func runsOf(runs []*workflow.ScheduledRun, workflowID uuid.UUID) []*workflow.ScheduledRun {
+	var result []*workflow.ScheduledRun
+	for _, run := range runs {
+		if run.WorkflowID == workflowID {
+			result = append(result, run)
+		}
+	}
+	return result
+}
+
+// workflowIDsOf 返回触发所属的工作流，按首次出现的顺序去重
+func workflowIDsOf(runs []*workflow.ScheduledRun) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, run := range runs {
+		if !seen[run.WorkflowID] {
+			seen[run.WorkflowID] = true
+			ids = append(ids, run.WorkflowID)
+		}
+	}
+	return ids
+}
+
+// hasUnfinishedTask 工作流是否仍有排队、执行中或等待中的任务
+func (s *WorkflowScheduler) hasUnfinishedTask(ctx context.Context, workflowID uuid.UUID) (bool, error) {
+	for _, status := range []workflow.TaskStatus{workflow.TaskStatusPending, workflow.TaskStatusRunning, workflow.TaskStatusWaiting} {
+		_, total, err := s.repo.ListTasks(ctx, workflow.TaskFilter{WorkflowID: &workflowID, Status: &status, Limit: 1})
+		if err != nil {
+			return false, err
+		}
+		if total > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-// TriggerWorkflow 手动触发工作流
-func (s *WorkflowScheduler) TriggerWorkflow(ctx context.Context, workflowID uuid.UUID, assetID *uuid.UUID) (*workflow.Task, error) {
+func (s *WorkflowScheduler) enqueueScheduled(ctx context.Context, wf *workflow.Workflow, scheduledAt time.Time) {
+	if err := s.enqueue(ctx, newScheduledTask(wf, scheduledAt)); err != nil {
+		log.Printf("[WorkflowScheduler] runWorkflow: create task for workflow %s: %v", wf.ID, err)
+	}
+}
+
+// newScheduledTask 创建定时触发的任务，输入参数 scheduled_at 为触发时间（补跑时为错过的触发时间）
+func newScheduledTask(wf *workflow.Workflow, scheduledAt time.Time) *workflow.Task {
+	return &workflow.Task{
+		TenantID:   wf.TenantID,
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+		Progress:   0,
+		InputParams: map[string]interface{}{
+			"scheduled_at": scheduledAt.Format(time.RFC3339),
+		},
+	}
+}
+
+// TriggerWorkflow 手动触发工作流，任务入队后按 priority（越大越先执行）派发，deadline 可选
+func (s *WorkflowScheduler) TriggerWorkflow(ctx context.Context, workflowID uuid.UUID, assetID *uuid.UUID, priority int, deadline *time.Time) (*workflow.Task, error) {
 	wf, err := s.repo.GetWorkflowWithNodes(ctx, workflowID)
 	if err != nil {
 		return nil, fmt.Errorf("get workflow: %w", err)
@@ -226,85 +785,140 @@ func (s *WorkflowScheduler) TriggerWorkflow(ctx context.Context, workflowID uuid
 		Status:      workflow.TaskStatusPending,
 		Progress:    0,
 		InputParams: inputParams,
+		Priority:    priority,
+		Deadline:    deadline,
 	}
 
-	if err := s.repo.CreateTask(ctx, task); err != nil {
+	if err := s.enqueue(ctx, task); err != nil {
 		return nil, fmt.Errorf("create task: %w", err)
 	}
 
-	goCtx := context.WithoutCancel(ctx)
-	go func() {
-		if err := s.engine.Execute(goCtx, wf, task); err != nil {
-			log.Printf("[WorkflowScheduler] TriggerWorkflow: execute failed workflow=%s task=%s: %v", workflowID, task.ID, err)
-			now := time.Now()
-			task.Status = workflow.TaskStatusFailed
-			task.Error = err.Error()
-			task.CompletedAt = &now
-			if updateErr := s.repo.UpdateTask(goCtx, task); updateErr != nil {
-				log.Printf("[WorkflowScheduler] TriggerWorkflow: update task status failed task=%s: %v", task.ID, updateErr)
-			}
-		}
-	}()
-
 	return task, nil
 }
 
-func (s *WorkflowScheduler) handleAssetNew(ctx context.Context, ev appport.Event) error {
-	e, ok := ev.(*event.AssetCreatedEvent)
-	if !ok {
-		return nil
+// NotifyQueued 通知派发器有任务入队，供调度器以外的入口（如 POST /tasks）创建任务后调用
+func (s *WorkflowScheduler) NotifyQueued() {
+	s.dispatcher.Notify()
+}
+
+// enqueue 创建 pending 任务并通知派发器
+func (s *WorkflowScheduler) enqueue(ctx context.Context, task *workflow.Task) error {
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return err
 	}
-	return s.triggerWorkflowsByEvent(ctx, e.AssetID, workflow.TriggerTypeAssetNew)
+	s.dispatcher.Notify()
+	return nil
 }
 
-func (s *WorkflowScheduler) handleAssetDone(ctx context.Context, ev appport.Event) error {
-	e, ok := ev.(*event.AssetDoneEvent)
+// handleEvent 按事件类型、租户与 trigger_conf.event_filter 触发工作流。
+// 任务输入参数包含 event_type 与事件载荷 event，载荷含 asset_id 时同时作为任务资产。
+// 由任务事件触发的任务记录触发链 trigger_chain，已在链中的工作流不再触发，链长度达到上限后停止触发。
+func (s *WorkflowScheduler) handleEvent(ctx context.Context, ev appport.Event) error {
+	te, ok := ev.(event.TriggerEvent)
 	if !ok {
 		return nil
 	}
-	return s.triggerWorkflowsByEvent(ctx, e.AssetID, workflow.TriggerTypeAssetDone)
-}
+	// 事件异步投递，发布方的请求上下文可能已结束
+	ctx = context.WithoutCancel(ctx)
 
-func (s *WorkflowScheduler) triggerWorkflowsByEvent(ctx context.Context, assetID uuid.UUID, triggerType workflow.TriggerType) error {
 	workflows, err := s.repo.ListEnabledWorkflows(ctx)
 	if err != nil {
-		log.Printf("[WorkflowScheduler] triggerWorkflowsByEvent: list workflows: %v", err)
+		log.Printf("[WorkflowScheduler] handleEvent: list workflows: %v", err)
 		return err
 	}
-	assetIDPtr := &assetID
+
+	eventType := ev.EventType()
+	tenantID := te.Tenant()
+	payload := te.Payload()
+	var (
+		chain         []string
+		chainResolved bool
+	)
 	for _, wf := range workflows {
-		if wf.TriggerType != triggerType {
+		if wf.EventTriggerType() != eventType || wf.TenantID != tenantID {
 			continue
 		}
-		wfWithNodes, err := s.repo.GetWorkflowWithNodes(ctx, wf.ID)
+		matched, err := wf.TriggerConf.MatchEvent(payload)
 		if err != nil {
-			log.Printf("[WorkflowScheduler] triggerWorkflowsByEvent: get workflow %s: %v", wf.ID, err)
+			log.Printf("[WorkflowScheduler] handleEvent: workflow=%s filter: %v", wf.ID, err)
 			continue
 		}
-		task := &workflow.Task{
-			WorkflowID:  wfWithNodes.ID,
-			AssetID:     assetIDPtr,
-			Status:      workflow.TaskStatusPending,
-			Progress:    0,
-			InputParams: map[string]interface{}{"asset_id": assetID.String()},
-		}
-		if err := s.repo.CreateTask(ctx, task); err != nil {
-			log.Printf("[WorkflowScheduler] triggerWorkflowsByEvent: create task workflow=%s: %v", wf.ID, err)
+		if !matched {
 			continue
 		}
-		goCtx := context.WithoutCancel(ctx)
-		go func(w *workflow.Workflow, t *workflow.Task) {
-			if err := s.engine.Execute(goCtx, w, t); err != nil {
-				log.Printf("[WorkflowScheduler] triggerWorkflowsByEvent: execute failed workflow=%s task=%s: %v", w.ID, t.ID, err)
-				now := time.Now()
-				t.Status = workflow.TaskStatusFailed
-				t.Error = err.Error()
-				t.CompletedAt = &now
-				if updateErr := s.repo.UpdateTask(goCtx, t); updateErr != nil {
-					log.Printf("[WorkflowScheduler] triggerWorkflowsByEvent: update task failed task=%s: %v", t.ID, updateErr)
-				}
+
+		if !chainResolved {
+			chain = s.triggerChain(ctx, payload)
+			chainResolved = true
+		}
+		// 工作流自身或触发链上游任务的事件不触发该工作流，避免循环触发
+		if slices.Contains(chain, wf.ID.String()) {
+			continue
+		}
+		if len(chain) >= workflow.MaxTriggerChainDepth {
+			log.Printf("[WorkflowScheduler] handleEvent: workflow=%s trigger chain exceeds %d workflows, not triggered", wf.ID, workflow.MaxTriggerChainDepth)
+			continue
+		}
+
+		task := &workflow.Task{
+			TenantID:   wf.TenantID,
+			WorkflowID: wf.ID,
+			Status:     workflow.TaskStatusPending,
+			Progress:   0,
+			InputParams: map[string]interface{}{
+				"event_type": eventType,
+				"event":      payload,
+			},
+		}
+		if len(chain) > 0 {
+			task.InputParams[workflow.TriggerChainParam] = slices.Clone(chain)
+		}
+		if raw, ok := payload["asset_id"].(string); ok {
+			if assetID, err := uuid.Parse(raw); err == nil {
+				task.AssetID = &assetID
+				task.InputParams["asset_id"] = raw
 			}
-		}(wfWithNodes, task)
+		}
+		if err := s.enqueue(ctx, task); err != nil {
+			log.Printf("[WorkflowScheduler] handleEvent: create task workflow=%s: %v", wf.ID, err)
+		}
 	}
 	return nil
 }
+
+// triggerChain 返回事件的触发链：事件所属的工作流，以及发布事件的任务、其调用方任务的工作流与调用方任务记录的触发链
+func (s *WorkflowScheduler) triggerChain(ctx context.Context, payload map[string]interface{}) []string {
+	var chain []string
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if id != "" && !slices.Contains(chain, id) {
+				chain = append(chain, id)
+			}
+		}
+	}
+	workflowID, _ := payload["workflow_id"].(string)
+	add(workflowID)
+
+	raw, _ := payload["task_id"].(string)
+	taskID, err := uuid.Parse(raw)
+	if err != nil {
+		return chain
+	}
+	// 子工作流任务沿调用方上溯到顶层任务，顶层任务记录了触发它的触发链
+	for depth := 0; depth <= workflow.MaxSubWorkflowDepth; depth++ {
+		task, err := s.repo.GetTask(ctx, taskID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("[WorkflowScheduler] handleEvent: get task %s: %v", taskID, err)
+			}
+			break
+		}
+		add(task.WorkflowID.String())
+		if task.CallerTaskID == nil {
+			add(task.TriggerChain()...)
+			break
+		}
+		taskID = *task.CallerTaskID
+	}
+	return chain
+}
@@ -0,0 +1,445 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goyavision/config"
+	"goyavision/internal/adapter/persistence"
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/event"
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/eventbus"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+)
+
+func TestMissedRuns_LongOutage(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	since := now.AddDate(-10, 0, 0)
+
+	tests := []struct {
+		name string
+		tc   *workflow.TriggerConfig
+		want int
+		// gap 返回的最后一次触发距 now 的最大间隔
+		gap time.Duration
+	}{
+		{"interval catch up", &workflow.TriggerConfig{IntervalSec: 1, Misfire: workflow.MisfireCatchUp, CatchUpLimit: 5}, 5, 2 * time.Second},
+		{"cron catch up", &workflow.TriggerConfig{Schedule: "* * * * *", Misfire: workflow.MisfireCatchUp}, workflow.DefaultCatchUpLimit, 2 * time.Minute},
+		{"run once", &workflow.TriggerConfig{IntervalSec: 1, Misfire: workflow.MisfireRunOnce}, 1, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &workflow.Workflow{ID: uuid.New(), TriggerConf: tt.tc, NextRunAt: &since}
+
+			start := time.Now()
+			runs := missedRuns(wf, now)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("missedRuns took %s", elapsed)
+			}
+
+			if len(runs) != tt.want {
+				t.Fatalf("missedRuns() returned %d runs, want %d", len(runs), tt.want)
+			}
+			for i, run := range runs {
+				if !run.Before(now) {
+					t.Errorf("run %s is not before now", run)
+				}
+				if i > 0 && !run.After(runs[i-1]) {
+					t.Errorf("runs are not in ascending order: %v", runs)
+				}
+			}
+			if last := runs[len(runs)-1]; now.Sub(last) > tt.gap {
+				t.Errorf("last run %s is %s before now, want within %s", last, now.Sub(last), tt.gap)
+			}
+		})
+	}
+}
+
+func TestWorkflowScheduler_RescheduleBroadcast(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	bus := eventbus.NewLocalEventBus(0)
+
+	newScheduler := func() *WorkflowScheduler {
+		s, err := NewWorkflowScheduler(persistence.NewRepository(f.db), nil, bus)
+		if err != nil {
+			t.Fatalf("new scheduler: %v", err)
+		}
+		if err := s.Start(context.Background()); err != nil {
+			t.Fatalf("start scheduler: %v", err)
+		}
+		t.Cleanup(func() { s.Stop() })
+		return s
+	}
+	local, other := newScheduler(), newScheduler()
+	scheduled := func(s *WorkflowScheduler, id uuid.UUID) bool {
+		s.jobsMu.RLock()
+		defer s.jobsMu.RUnlock()
+		_, ok := s.jobs[id]
+		return ok
+	}
+
+	tenantID := uuid.New()
+	ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+	wf := f.workflow(t, tenantID, "scheduled")
+	wf.TriggerType = workflow.TriggerTypeSchedule
+	wf.TriggerConf = &workflow.TriggerConfig{IntervalSec: 3600}
+	if err := f.repo.UpdateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+
+	// 私有工作流：其他副本须以所有者身份读取
+	local.RescheduleWorkflow(ctx, wf)
+	if !scheduled(local, wf.ID) {
+		t.Fatal("workflow is not scheduled on the local replica")
+	}
+	waitFor(t, func() bool { return scheduled(other, wf.ID) })
+
+	wf.Status = workflow.StatusDisabled
+	if err := f.repo.UpdateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+	local.RescheduleWorkflow(ctx, wf)
+	waitFor(t, func() bool { return !scheduled(other, wf.ID) })
+
+	wf.Status = workflow.StatusEnabled
+	if err := f.repo.UpdateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+	local.RescheduleWorkflow(ctx, wf)
+	waitFor(t, func() bool { return scheduled(other, wf.ID) })
+
+	if err := f.repo.DeleteWorkflow(ctx, wf.ID); err != nil {
+		t.Fatalf("delete workflow: %v", err)
+	}
+	local.RemoveWorkflow(ctx, wf.ID)
+	if scheduled(local, wf.ID) {
+		t.Error("deleted workflow is still scheduled on the local replica")
+	}
+	waitFor(t, func() bool { return !scheduled(other, wf.ID) })
+}
+
+// scheduledWorkflow 将测试工作流改为定时触发
+func scheduledWorkflow(t *testing.T, f *dispatcherFixture, code string, tc *workflow.TriggerConfig) *workflow.Workflow {
+	t.Helper()
+	tenantID := uuid.New()
+	wf := f.workflow(t, tenantID, code)
+	wf.TriggerType = workflow.TriggerTypeSchedule
+	wf.TriggerConf = tc
+	ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+	if err := f.repo.UpdateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+	return wf
+}
+
+// scheduledTimes 返回工作流定时任务的 scheduled_at，按创建先后排列
+func scheduledTimes(t *testing.T, f *dispatcherFixture, workflowID uuid.UUID) []string {
+	t.Helper()
+	var tasks []model.TaskModel
+	if err := f.db.Order("created_at ASC").Find(&tasks, "workflow_id = ?", workflowID).Error; err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	times := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		at, _ := f.get(t, task.ID).InputParams["scheduled_at"].(string)
+		times = append(times, at)
+	}
+	return times
+}
+
+func countScheduledRuns(t *testing.T, f *dispatcherFixture) int64 {
+	t.Helper()
+	var n int64
+	if err := f.db.Model(&model.ScheduledRunModel{}).Count(&n).Error; err != nil {
+		t.Fatalf("count scheduled runs: %v", err)
+	}
+	return n
+}
+
+func TestWorkflowScheduler_QueuedRunSurvivesRestart(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	wf := scheduledWorkflow(t, f, "queued", &workflow.TriggerConfig{IntervalSec: 3600, Overlap: workflow.OverlapQueue})
+	previous := f.task(t, wf)
+	f.setStatus(t, previous.ID, map[string]interface{}{"status": "running"})
+
+	newScheduler := func() *WorkflowScheduler {
+		s, err := NewWorkflowScheduler(persistence.NewRepository(f.db), nil, nil)
+		if err != nil {
+			t.Fatalf("new scheduler: %v", err)
+		}
+		return s
+	}
+	scheduledAt := time.Now().Truncate(time.Second)
+	newScheduler().fire(context.Background(), wf, scheduledAt)
+	if n := countScheduledRuns(t, f); n != 1 {
+		t.Fatalf("scheduled runs = %d, want the queued run persisted", n)
+	}
+
+	// 重启或领导者切换后，新的调度器继续执行排队的触发
+	leader := newScheduler()
+	leader.runQueued()
+	if got := scheduledTimes(t, f, wf.ID); len(got) != 1 {
+		t.Fatalf("tasks = %v, want the queued run to wait for the previous run", got)
+	}
+
+	f.setStatus(t, previous.ID, map[string]interface{}{"status": "success"})
+	leader.runQueued()
+	got := scheduledTimes(t, f, wf.ID)
+	if len(got) != 2 || got[1] != scheduledAt.Format(time.RFC3339) {
+		t.Errorf("tasks scheduled_at = %v, want the queued run at %s", got, scheduledAt.Format(time.RFC3339))
+	}
+	if n := countScheduledRuns(t, f); n != 0 {
+		t.Errorf("scheduled runs = %d, want none after the run started", n)
+	}
+}
+
+func TestWorkflowScheduler_CatchUpOnNonLeader(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	wf := scheduledWorkflow(t, f, "catch-up", &workflow.TriggerConfig{IntervalSec: 60, Misfire: workflow.MisfireCatchUp, CatchUpLimit: 3})
+	nextRunAt := time.Now().Add(-10 * time.Minute)
+	if err := f.repo.UpdateWorkflowRunTimes(context.Background(), wf.ID, nil, &nextRunAt); err != nil {
+		t.Fatalf("update run times: %v", err)
+	}
+	// 其他副本持有领导者租约，本副本的调度作业不执行
+	if err := f.db.Create(&model.LeaderLeaseModel{Name: schedulerLeaderLease, Holder: "leader", ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("create lease: %v", err)
+	}
+
+	start := func() *WorkflowScheduler {
+		s, err := NewWorkflowScheduler(persistence.NewRepository(f.db), nil, nil)
+		if err != nil {
+			t.Fatalf("new scheduler: %v", err)
+		}
+		if err := s.Start(context.Background()); err != nil {
+			t.Fatalf("start scheduler: %v", err)
+		}
+		t.Cleanup(func() { s.Stop() })
+		return s
+	}
+	start()
+	if n := countScheduledRuns(t, f); n != 3 {
+		t.Fatalf("scheduled runs = %d, want 3 missed runs recorded for the leader", n)
+	}
+	// 稍后启动的副本读到已推进的下一次触发时间，不重复记录
+	start()
+	if n := countScheduledRuns(t, f); n != 3 {
+		t.Fatalf("scheduled runs = %d after another replica started, want 3", n)
+	}
+
+	leader, err := NewWorkflowScheduler(persistence.NewRepository(f.db), nil, nil)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	leader.runQueued()
+	if got := scheduledTimes(t, f, wf.ID); len(got) != 3 {
+		t.Errorf("tasks = %v, want 3 caught up runs", got)
+	}
+	if n := countScheduledRuns(t, f); n != 0 {
+		t.Errorf("scheduled runs = %d, want none after catch up", n)
+	}
+}
+
+// eventWorkflow 将测试工作流改为订阅 eventType 的事件触发
+func eventWorkflow(t *testing.T, f *dispatcherFixture, tenantID uuid.UUID, code, eventType string, filter map[string]interface{}) *workflow.Workflow {
+	t.Helper()
+	wf := f.workflow(t, tenantID, code)
+	wf.TriggerType = workflow.TriggerTypeEvent
+	wf.TriggerConf = &workflow.TriggerConfig{EventType: eventType, EventFilter: filter}
+	ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+	if err := f.repo.UpdateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+	return wf
+}
+
+// eventTasks 返回工作流的任务，按创建先后排列
+func eventTasks(t *testing.T, f *dispatcherFixture, workflowID uuid.UUID) []*workflow.Task {
+	t.Helper()
+	var models []model.TaskModel
+	if err := f.db.Order("created_at ASC").Find(&models, "workflow_id = ?", workflowID).Error; err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	tasks := make([]*workflow.Task, 0, len(models))
+	for _, m := range models {
+		tasks = append(tasks, f.get(t, m.ID))
+	}
+	return tasks
+}
+
+func newEventScheduler(t *testing.T, f *dispatcherFixture) *WorkflowScheduler {
+	t.Helper()
+	s, err := NewWorkflowScheduler(persistence.NewRepository(f.db), nil, nil)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	return s
+}
+
+func TestWorkflowScheduler_EventTenantIsolation(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	s := newEventScheduler(t, f)
+	tenantA, tenantB := uuid.New(), uuid.New()
+	wfA := eventWorkflow(t, f, tenantA, "sample-a", "sample_uploaded", nil)
+	wfB := eventWorkflow(t, f, tenantB, "sample-b", "sample_uploaded", nil)
+	other := eventWorkflow(t, f, tenantA, "other-a", "sample_deleted", nil)
+
+	ev := event.NewGenericEvent("sample_uploaded", tenantA, map[string]interface{}{"name": "clip.mp4"})
+	if err := s.handleEvent(context.Background(), ev); err != nil {
+		t.Fatalf("handleEvent() error = %v", err)
+	}
+
+	tasks := eventTasks(t, f, wfA.ID)
+	if len(tasks) != 1 {
+		t.Fatalf("tenant A tasks = %d, want 1", len(tasks))
+	}
+	if tasks[0].TenantID != tenantA || tasks[0].InputParams["event_type"] != "sample_uploaded" {
+		t.Errorf("task = %+v", tasks[0])
+	}
+	if n := len(eventTasks(t, f, wfB.ID)); n != 0 {
+		t.Errorf("tenant B tasks = %d, want none for another tenant's event", n)
+	}
+	if n := len(eventTasks(t, f, other.ID)); n != 0 {
+		t.Errorf("tasks of workflow subscribed to another event type = %d, want none", n)
+	}
+}
+
+func TestWorkflowScheduler_EventFilter(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	s := newEventScheduler(t, f)
+	tenantID := uuid.New()
+	video := eventWorkflow(t, f, tenantID, "video-only", event.EventTypeAssetNew, map[string]interface{}{"type": "video"})
+	large := eventWorkflow(t, f, tenantID, "large", event.EventTypeAssetNew, map[string]interface{}{
+		"size": map[string]interface{}{"gte": 1024.0},
+	})
+	vip := eventWorkflow(t, f, tenantID, "vip", event.EventTypeAssetNew, map[string]interface{}{
+		"expression": `contains(event.tags, "vip")`,
+	})
+	invalid := eventWorkflow(t, f, tenantID, "invalid", event.EventTypeAssetNew, map[string]interface{}{
+		"size": map[string]interface{}{"between": 1.0},
+	})
+
+	publish := func(assetType media.AssetType, size int64, tags []string) {
+		ev := event.NewAssetCreatedEvent(&media.Asset{
+			ID:       uuid.New(),
+			TenantID: tenantID,
+			Type:     assetType,
+			Size:     size,
+			Tags:     tags,
+		})
+		if err := s.handleEvent(context.Background(), ev); err != nil {
+			t.Fatalf("handleEvent() error = %v", err)
+		}
+	}
+	publish(media.AssetTypeImage, 10, nil)
+	publish(media.AssetTypeVideo, 2048, []string{"vip"})
+
+	counts := map[string]int{}
+	for _, wf := range []*workflow.Workflow{video, large, vip, invalid} {
+		counts[wf.Code] = len(eventTasks(t, f, wf.ID))
+	}
+	want := map[string]int{"video-only": 1, "large": 1, "vip": 1, "invalid": 0}
+	for code, n := range want {
+		if counts[code] != n {
+			t.Errorf("%s tasks = %d, want %d", code, counts[code], n)
+		}
+	}
+	tasks := eventTasks(t, f, video.ID)
+	if len(tasks) == 1 && tasks[0].AssetID == nil {
+		t.Error("task created from an asset event has no asset")
+	}
+}
+
+func TestWorkflowScheduler_EventTriggerChain(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	s := newEventScheduler(t, f)
+	tenantID := uuid.New()
+	// A 的任务成功后触发 B，B 的任务成功后触发 A
+	wfA := f.workflow(t, tenantID, "chain-a")
+	wfB := eventWorkflow(t, f, tenantID, "chain-b", event.EventTypeTaskStatus, map[string]interface{}{
+		"status": "success", "workflow_id": wfA.ID.String(),
+	})
+	ctx := middleware.ContextWithIdentity(context.Background(), tenantID, f.owner)
+	wfA.TriggerType = workflow.TriggerTypeEvent
+	wfA.TriggerConf = &workflow.TriggerConfig{EventType: event.EventTypeTaskStatus, EventFilter: map[string]interface{}{
+		"status": "success", "workflow_id": wfB.ID.String(),
+	}}
+	if err := f.repo.UpdateWorkflow(ctx, wfA); err != nil {
+		t.Fatalf("update workflow: %v", err)
+	}
+
+	succeeded := func(task *workflow.Task) {
+		t.Helper()
+		ev := event.NewTaskEvent(event.EventTypeTaskStatus, task.ID, task.TenantID, task.WorkflowID)
+		ev.Status = string(workflow.TaskStatusSuccess)
+		if err := s.handleEvent(context.Background(), ev); err != nil {
+			t.Fatalf("handleEvent() error = %v", err)
+		}
+	}
+
+	succeeded(f.task(t, wfA))
+	tasksB := eventTasks(t, f, wfB.ID)
+	if len(tasksB) != 1 {
+		t.Fatalf("B tasks = %d, want 1", len(tasksB))
+	}
+	if chain := tasksB[0].TriggerChain(); len(chain) != 1 || chain[0] != wfA.ID.String() {
+		t.Errorf("B trigger chain = %v, want [A]", chain)
+	}
+
+	// B 的任务由 A 触发，不再反过来触发 A
+	succeeded(tasksB[0])
+	if n := len(eventTasks(t, f, wfA.ID)); n != 1 {
+		t.Errorf("A tasks = %d, want only the original task", n)
+	}
+
+	// 手动运行的 B 不在任何触发链中，仍然触发 A
+	succeeded(f.task(t, wfB))
+	if n := len(eventTasks(t, f, wfA.ID)); n != 2 {
+		t.Errorf("A tasks = %d, want a task triggered by the manual B run", n)
+	}
+}
+
+func TestWorkflowScheduler_EventTriggerChainDepth(t *testing.T) {
+	f := newDispatcherFixture(t, config.Queue{})
+	s := newEventScheduler(t, f)
+	tenantID := uuid.New()
+	source := f.workflow(t, tenantID, "source")
+	target := eventWorkflow(t, f, tenantID, "target", event.EventTypeTaskStatus, map[string]interface{}{
+		"workflow_id": source.ID.String(),
+	})
+
+	start := func(chainLen int) {
+		t.Helper()
+		chain := make([]interface{}, chainLen)
+		for i := range chain {
+			chain[i] = uuid.NewString()
+		}
+		task := &workflow.Task{
+			ID:          uuid.New(),
+			TenantID:    tenantID,
+			WorkflowID:  source.ID,
+			Status:      workflow.TaskStatusSuccess,
+			InputParams: map[string]interface{}{workflow.TriggerChainParam: chain},
+		}
+		if err := f.repo.CreateTask(context.Background(), task); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		if err := s.handleEvent(context.Background(), event.NewTaskEvent(event.EventTypeTaskStatus, task.ID, tenantID, source.ID)); err != nil {
+			t.Fatalf("handleEvent() error = %v", err)
+		}
+	}
+
+	start(workflow.MaxTriggerChainDepth - 2)
+	tasks := eventTasks(t, f, target.ID)
+	if len(tasks) != 1 || len(tasks[0].TriggerChain()) != workflow.MaxTriggerChainDepth-1 {
+		t.Fatalf("target tasks = %d, want 1 with a chain of %d", len(tasks), workflow.MaxTriggerChainDepth-1)
+	}
+	start(workflow.MaxTriggerChainDepth - 1)
+	if n := len(eventTasks(t, f, target.ID)); n != 1 {
+		t.Errorf("target tasks = %d, want no task once the chain reaches %d", n, workflow.MaxTriggerChainDepth)
+	}
+}
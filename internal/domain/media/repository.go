@@ -10,6 +10,8 @@ type SourceRepository interface {
 	Create(ctx context.Context, s *Source) error
 	Get(ctx context.Context, id uuid.UUID) (*Source, error)
 	GetByPathName(ctx context.Context, pathName string) (*Source, error)
+	// GetByPathNameUnscoped 不按租户与可见性过滤，供 MediaMTX 回调等没有用户身份的场景使用
+	GetByPathNameUnscoped(ctx context.Context, pathName string) (*Source, error)
 	List(ctx context.Context, filter SourceFilter) ([]*Source, int64, error)
 	Update(ctx context.Context, s *Source) error
 	Delete(ctx context.Context, id uuid.UUID) error
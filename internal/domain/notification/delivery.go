@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook 渠道的请求头。签名与入站 webhook 相同：
+// hex(HMAC-SHA256(secret, timestamp + "." + delivery_id + "." + body))，以 "sha256=" 为前缀。
+const (
+	HeaderEvent     = "X-GoyaVision-Event"
+	HeaderDelivery  = "X-GoyaVision-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderNonce     = "X-Webhook-Nonce"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// 投递重试参数
+const (
+	// DefaultMaxAttempts 每次投递的最大尝试次数
+	DefaultMaxAttempts = 8
+	retryBaseDelay     = 30 * time.Second
+	retryMaxDelay      = time.Hour
+)
+
+// ResponseBodyLimit 投递记录保存的响应体上限（字节）
+const ResponseBodyLimit = 4096
+
+// DeliveryStatus 投递状态
+type DeliveryStatus string
+
+const (
+	// DeliveryPending 等待投递或等待重试
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded 投递成功
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed 重试耗尽或订阅已停用
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery 一个事件对一个订阅的投递记录
+//
+// 同一事件投递到多个订阅时共享 EventID，接收方可据此去重；重放会创建新的投递记录（ReplayOf 指向原记录）。
+type Delivery struct {
+	ID             uuid.UUID
+	TenantID       uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        map[string]interface{}
+	OccurredAt     int64
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastError      string
+	// ResponseStatus/ResponseBody 最近一次尝试的响应（webhook、chat 渠道），响应体超过 ResponseBodyLimit 时截断
+	ResponseStatus int
+	ResponseBody   string
+	ReplayOf       *uuid.UUID
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Message 投递内容
+func (d *Delivery) Message() *Message {
+	return &Message{
+		DeliveryID: d.ID,
+		EventID:    d.EventID,
+		EventType:  d.EventType,
+		TenantID:   d.TenantID,
+		OccurredAt: d.OccurredAt,
+		Payload:    d.Payload,
+	}
+}
+
+// Replay 基于本记录创建待投递的重放记录
+func (d *Delivery) Replay(now time.Time) *Delivery {
+	id := d.ID
+	return &Delivery{
+		TenantID:       d.TenantID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		OccurredAt:     d.OccurredAt,
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       &id,
+	}
+}
+
+// RecordAttempt 记录一次尝试的结果：成功时置为 succeeded；失败时按指数退避安排重试，
+// 达到 maxAttempts 后置为 failed 并返回 true
+func (d *Delivery) RecordAttempt(result *SendResult, err error, maxAttempts int, now time.Time) (exhausted bool) {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	d.Attempts++
+	d.ResponseStatus = 0
+	d.ResponseBody = ""
+	if result != nil {
+		d.ResponseStatus = result.StatusCode
+		d.ResponseBody = result.Body
+		if len(d.ResponseBody) > ResponseBodyLimit {
+			d.ResponseBody = d.ResponseBody[:ResponseBodyLimit]
+		}
+	}
+
+	if err == nil {
+		d.Status = DeliverySucceeded
+		d.LastError = ""
+		d.NextAttemptAt = nil
+		d.DeliveredAt = &now
+		return false
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryFailed
+		d.NextAttemptAt = nil
+		return true
+	}
+	next := now.Add(RetryDelay(d.Attempts))
+	d.Status = DeliveryPending
+	d.NextAttemptAt = &next
+	return false
+}
+
+// RetryDelay 第 attempts 次尝试失败后的重试间隔：30s 起按 2 倍递增，最长 1 小时
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// Message 通知内容，由各渠道按自身格式渲染
+type Message struct {
+	DeliveryID uuid.UUID
+	EventID    uuid.UUID
+	EventType  string
+	TenantID   uuid.UUID
+	OccurredAt int64
+	Payload    map[string]interface{}
+}
+
+// Subject 邮件标题与聊天消息首行
+func (m *Message) Subject() string {
+	if status, ok := m.Payload["status"].(string); ok && status != "" {
+		return fmt.Sprintf("[GoyaVision] %s: %s", m.EventType, status)
+	}
+	return "[GoyaVision] " + m.EventType
+}
+
+// Text 纯文本正文：标题、发生时间与按键名排序的载荷字段
+func (m *Message) Text() string {
+	var b strings.Builder
+	b.WriteString(m.Subject())
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "time: %s\n", time.Unix(m.OccurredAt, 0).Format(time.RFC3339))
+
+	keys := make([]string, 0, len(m.Payload))
+	for k := range m.Payload {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: %v\n", k, m.Payload[k])
+	}
+	return b.String()
+}
+
+// SendResult 一次发送的响应
+type SendResult struct {
+	StatusCode int
+	Body       string
+}
+
+// DeliveryFilter 投递记录查询条件
+type DeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         *DeliveryStatus
+	Limit          int
+	Offset         int
+}
@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SubscriptionRepository interface {
+	Create(ctx context.Context, s *Subscription) error
+	Get(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	List(ctx context.Context, limit, offset int) ([]*Subscription, int64, error)
+	Update(ctx context.Context, s *Subscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListEnabledByTenant 列出租户已启用的订阅，不按上下文身份过滤，供事件分发使用
+	ListEnabledByTenant(ctx context.Context, tenantID uuid.UUID) ([]*Subscription, error)
+	// GetUnscoped 不按上下文身份过滤，供投递使用
+	GetUnscoped(ctx context.Context, id uuid.UUID) (*Subscription, error)
+}
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, d *Delivery) error
+	Get(ctx context.Context, id uuid.UUID) (*Delivery, error)
+	List(ctx context.Context, filter DeliveryFilter) ([]*Delivery, int64, error)
+	// Update 保存投递结果
+	Update(ctx context.Context, d *Delivery) error
+	// ClaimDue 认领到期的 pending 投递：将 next_attempt_at 推迟 lease 作为处理租约，
+	// 处理方异常退出时租约到期后由其他进程重新认领
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error)
+	// FailPending 将订阅仍在等待的投递置为 failed，返回数量
+	FailPending(ctx context.Context, subscriptionID uuid.UUID, reason string) (int64, error)
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel 通知渠道
+type Channel string
+
+const (
+	// ChannelWebhook 以签名的 JSON 请求投递到订阅方地址
+	ChannelWebhook Channel = "webhook"
+	// ChannelEmail 通过 SMTP 发送邮件
+	ChannelEmail Channel = "email"
+	// ChannelChat 投递到聊天机器人 webhook（钉钉、飞书、企业微信、Slack 等）
+	ChannelChat Channel = "chat"
+)
+
+// ChatFormat 聊天 webhook 的消息格式
+type ChatFormat string
+
+const (
+	// ChatFormatGeneric {"text": ..., "event": ...}
+	ChatFormatGeneric  ChatFormat = "generic"
+	ChatFormatSlack    ChatFormat = "slack"
+	ChatFormatDingTalk ChatFormat = "dingtalk"
+	ChatFormatFeishu   ChatFormat = "feishu"
+	ChatFormatWeCom    ChatFormat = "wecom"
+)
+
+// EventTypeAll 订阅全部事件类型
+const EventTypeAll = "*"
+
+// DefaultDisableAfter 连续多少次投递失败（重试耗尽）后自动停用订阅
+const DefaultDisableAfter = 5
+
+// Subscription 租户的事件通知订阅
+//
+// EventTypes 为订阅的事件类型，包含 "*" 时订阅全部可触发工作流的事件。
+// webhook 渠道使用 URL 与 Secret（加密保存），chat 渠道使用 URL 与 ChatFormat，email 渠道使用 Recipients。
+// 连续投递失败达到阈值后订阅自动停用（DisabledAt/DisabledReason），重新启用时清零失败计数。
+type Subscription struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	Name       string
+	Channel    Channel
+	EventTypes []string
+	URL        string
+	Secret     string
+	ChatFormat ChatFormat
+	Recipients []string
+	Enabled    bool
+	// ConsecutiveFailures 连续失败的投递数，投递成功后清零
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	DisabledReason      string
+	CreatedBy           *uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// Validate 校验渠道配置，isEventType 判断事件类型是否可订阅
+func (s *Subscription) Validate(isEventType func(string) bool) error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(s.EventTypes) == 0 {
+		return errors.New("event_types is required")
+	}
+	for _, t := range s.EventTypes {
+		if t != EventTypeAll && !isEventType(t) {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+
+	switch s.Channel {
+	case ChannelWebhook:
+		return validateURL(s.URL)
+	case ChannelChat:
+		switch s.ChatFormat {
+		case ChatFormatGeneric, ChatFormatSlack, ChatFormatDingTalk, ChatFormatFeishu, ChatFormatWeCom:
+		default:
+			return fmt.Errorf("chat_format must be one of: generic, slack, dingtalk, feishu, wecom")
+		}
+		return validateURL(s.URL)
+	case ChannelEmail:
+		if len(s.Recipients) == 0 {
+			return errors.New("recipients is required for email channel")
+		}
+		for _, r := range s.Recipients {
+			if _, err := mail.ParseAddress(r); err != nil {
+				return fmt.Errorf("invalid recipient %q", r)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("channel must be one of: webhook, email, chat")
+	}
+}
+
+// validateURL 只校验格式；地址是否指向内网由应用层在解析主机后校验
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an http(s) URL")
+	}
+	return nil
+}
+
+// Matches 订阅是否接收该类型的事件
+func (s *Subscription) Matches(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == EventTypeAll || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Enable 启用订阅并清零失败计数
+func (s *Subscription) Enable() {
+	s.Enabled = true
+	s.ConsecutiveFailures = 0
+	s.DisabledAt = nil
+	s.DisabledReason = ""
+}
+
+// RecordSuccess 记录一次投递成功
+func (s *Subscription) RecordSuccess() {
+	s.ConsecutiveFailures = 0
+}
+
+// RecordFailure 记录一次投递失败（重试耗尽），连续失败达到 threshold 时停用订阅并返回 true
+func (s *Subscription) RecordFailure(threshold int, reason string, now time.Time) bool {
+	if threshold <= 0 {
+		threshold = DefaultDisableAfter
+	}
+	s.ConsecutiveFailures++
+	if !s.Enabled || s.ConsecutiveFailures < threshold {
+		return false
+	}
+	s.Enabled = false
+	s.DisabledAt = &now
+	s.DisabledReason = fmt.Sprintf("disabled after %d consecutive failed deliveries: %s", s.ConsecutiveFailures, reason)
+	return true
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"goyavision/internal/domain/notification"
+)
+
+func TestSubscriptionValidate(t *testing.T) {
+	known := func(t string) bool { return t == "task_status" }
+	tests := []struct {
+		name    string
+		sub     notification.Subscription
+		wantErr bool
+	}{
+		{"webhook", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, EventTypes: []string{"task_status"}, URL: "https://example.com/hook"}, false},
+		{"all events", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, EventTypes: []string{"*"}, URL: "http://example.com"}, false},
+		{"unknown event", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, EventTypes: []string{"task_progress"}, URL: "https://example.com"}, true},
+		{"no events", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, URL: "https://example.com"}, true},
+		{"bad url", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, EventTypes: []string{"*"}, URL: "ftp://example.com"}, true},
+		{"url without host", notification.Subscription{Name: "n", Channel: notification.ChannelWebhook, EventTypes: []string{"*"}, URL: "http:///hook"}, true},
+		{"chat", notification.Subscription{Name: "n", Channel: notification.ChannelChat, EventTypes: []string{"*"}, URL: "https://oapi.dingtalk.com/robot/send", ChatFormat: notification.ChatFormatDingTalk}, false},
+		{"chat unknown format", notification.Subscription{Name: "n", Channel: notification.ChannelChat, EventTypes: []string{"*"}, URL: "https://example.com", ChatFormat: "teams"}, true},
+		{"email", notification.Subscription{Name: "n", Channel: notification.ChannelEmail, EventTypes: []string{"*"}, Recipients: []string{"Ops <ops@example.com>"}}, false},
+		{"email no recipients", notification.Subscription{Name: "n", Channel: notification.ChannelEmail, EventTypes: []string{"*"}}, true},
+		{"email bad recipient", notification.Subscription{Name: "n", Channel: notification.ChannelEmail, EventTypes: []string{"*"}, Recipients: []string{"ops"}}, true},
+		{"unknown channel", notification.Subscription{Name: "n", Channel: "sms", EventTypes: []string{"*"}}, true},
+		{"no name", notification.Subscription{Channel: notification.ChannelWebhook, EventTypes: []string{"*"}, URL: "https://example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sub.Validate(known); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := notification.RetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryRecordAttempt(t *testing.T) {
+	now := time.Now()
+	d := &notification.Delivery{Status: notification.DeliveryPending}
+
+	if d.RecordAttempt(&notification.SendResult{StatusCode: 500}, errors.New("boom"), 2, now) {
+		t.Fatal("first failure should not exhaust")
+	}
+	if d.Status != notification.DeliveryPending || d.NextAttemptAt == nil || !d.NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected retry state: status=%s next=%v", d.Status, d.NextAttemptAt)
+	}
+	if !d.RecordAttempt(nil, errors.New("boom"), 2, now) {
+		t.Fatal("second failure should exhaust")
+	}
+	if d.Status != notification.DeliveryFailed || d.NextAttemptAt != nil || d.LastError != "boom" {
+		t.Fatalf("unexpected failed state: %+v", d)
+	}
+
+	replay := d.Replay(now)
+	if replay.ReplayOf == nil || *replay.ReplayOf != d.ID || replay.Status != notification.DeliveryPending || replay.Attempts != 0 {
+		t.Fatalf("unexpected replay: %+v", replay)
+	}
+	if replay.RecordAttempt(&notification.SendResult{StatusCode: 200}, nil, 2, now) {
+		t.Fatal("success should not exhaust")
+	}
+	if replay.Status != notification.DeliverySucceeded || replay.DeliveredAt == nil || replay.LastError != "" {
+		t.Fatalf("unexpected success state: %+v", replay)
+	}
+}
+
+func TestSubscriptionAutoDisable(t *testing.T) {
+	now := time.Now()
+	sub := &notification.Subscription{Enabled: true}
+	for i := 0; i < 2; i++ {
+		if sub.RecordFailure(3, "timeout", now) {
+			t.Fatalf("disabled after %d failures", i+1)
+		}
+	}
+	if !sub.RecordFailure(3, "timeout", now) {
+		t.Fatal("expected disable at threshold")
+	}
+	if sub.Enabled || sub.DisabledAt == nil || sub.DisabledReason == "" {
+		t.Fatalf("unexpected disabled state: %+v", sub)
+	}
+
+	sub.Enable()
+	if !sub.Enabled || sub.ConsecutiveFailures != 0 || sub.DisabledAt != nil {
+		t.Fatalf("unexpected enabled state: %+v", sub)
+	}
+	sub.RecordFailure(3, "timeout", now)
+	sub.RecordSuccess()
+	if sub.ConsecutiveFailures != 0 {
+		t.Fatalf("success should reset failures, got %d", sub.ConsecutiveFailures)
+	}
+}
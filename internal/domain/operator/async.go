@@ -0,0 +1,216 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTP 算子异步模式
+const (
+	// HTTPAsyncModePoll 提交后轮询作业状态
+	HTTPAsyncModePoll = "poll"
+	// HTTPAsyncModeCallback 提交时下发回调地址，等待算子回调作业结果
+	HTTPAsyncModeCallback = "callback"
+)
+
+// CallbackURLHeader 异步 HTTP 算子请求头，值为算子回调作业结果的地址（callback 模式）
+const CallbackURLHeader = "X-Callback-URL"
+
+// JobIDPlaceholder status_url 与 cancel_url 中的作业 ID 占位符
+const JobIDPlaceholder = "{job_id}"
+
+// 异步作业轮询间隔默认值
+const (
+	defaultAsyncPollInterval    = 5 * time.Second
+	defaultAsyncMaxPollInterval = time.Minute
+)
+
+// HTTPAsyncConfig HTTP 算子异步作业协议
+//
+// 提交请求与同步模式相同；算子返回 202 Accepted 表示已受理，响应体 JSON 的 JobIDField 字段为作业 ID
+// （返回 200 时按同步结果处理）。之后按 Mode 轮询 StatusURL 或等待回调，作业状态格式见 AsyncJobStatus。
+// 任务取消、超时或节点超时时，若配置了 CancelURL，以 CancelMethod 请求取消远端作业。
+type HTTPAsyncConfig struct {
+	// Mode poll 或 callback
+	Mode string `json:"mode"`
+	// JobIDField 提交响应中作业 ID 的字段名，默认 job_id
+	JobIDField string `json:"job_id_field,omitempty"`
+	// StatusURL 作业状态地址，可含 {job_id}；为空时使用提交响应的 Location 头。poll 模式必需其一
+	StatusURL string `json:"status_url,omitempty"`
+	// CancelURL 取消作业的地址，可含 {job_id}
+	CancelURL string `json:"cancel_url,omitempty"`
+	// CancelMethod 取消请求方法，默认 POST
+	CancelMethod string `json:"cancel_method,omitempty"`
+	// PollIntervalSec 首次轮询间隔（秒，默认 5），之后按 2 倍递增到 MaxPollIntervalSec（默认 60）
+	PollIntervalSec    int `json:"poll_interval_sec,omitempty"`
+	MaxPollIntervalSec int `json:"max_poll_interval_sec,omitempty"`
+	// TimeoutSec 等待作业完成的最长时间（秒），0 表示不限制（仍受节点超时与任务最长执行时间约束）
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+	// CallbackSecret 非空时回调请求须带与入站 webhook 相同格式的签名头
+	// （X-Webhook-Timestamp、X-Webhook-Nonce、X-Webhook-Signature）
+	CallbackSecret string `json:"callback_secret,omitempty"`
+}
+
+// Validate 校验异步配置
+func (c *HTTPAsyncConfig) Validate() error {
+	switch c.Mode {
+	case HTTPAsyncModePoll, HTTPAsyncModeCallback:
+	default:
+		return fmt.Errorf("async mode must be one of: poll, callback")
+	}
+	if c.PollIntervalSec < 0 || c.MaxPollIntervalSec < 0 || c.TimeoutSec < 0 {
+		return errors.New("async intervals and timeout must not be negative")
+	}
+	for _, u := range []string{c.StatusURL, c.CancelURL} {
+		if u == "" {
+			continue
+		}
+		if _, err := url.Parse(strings.ReplaceAll(u, JobIDPlaceholder, "x")); err != nil {
+			return fmt.Errorf("invalid async url %q: %w", u, err)
+		}
+	}
+	return nil
+}
+
+// GetJobIDField 返回作业 ID 字段名
+func (c *HTTPAsyncConfig) GetJobIDField() string {
+	if c.JobIDField == "" {
+		return "job_id"
+	}
+	return c.JobIDField
+}
+
+// GetCancelMethod 返回取消请求方法
+func (c *HTTPAsyncConfig) GetCancelMethod() string {
+	if c.CancelMethod == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(c.CancelMethod)
+}
+
+// PollDelay 第 polls 次（从 1 开始）轮询前的等待时间：从 PollIntervalSec 起按 2 倍递增，不超过 MaxPollIntervalSec
+func (c *HTTPAsyncConfig) PollDelay(polls int) time.Duration {
+	delay := defaultAsyncPollInterval
+	if c.PollIntervalSec > 0 {
+		delay = time.Duration(c.PollIntervalSec) * time.Second
+	}
+	max := defaultAsyncMaxPollInterval
+	if c.MaxPollIntervalSec > 0 {
+		max = time.Duration(c.MaxPollIntervalSec) * time.Second
+	}
+	for i := 1; i < polls && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// JobURL 将地址模板中的 {job_id} 替换为作业 ID
+func JobURL(template, jobID string) string {
+	return strings.ReplaceAll(template, JobIDPlaceholder, url.PathEscape(jobID))
+}
+
+// 异步作业状态
+const (
+	AsyncJobPending   = "pending"
+	AsyncJobRunning   = "running"
+	AsyncJobSucceeded = "succeeded"
+	AsyncJobFailed    = "failed"
+	AsyncJobCancelled = "cancelled"
+)
+
+// AsyncJobStatus 异步作业状态，轮询响应与回调请求体使用相同格式：
+//
+//	{"status": "running", "progress": 0.4, "message": "..."}
+//	{"status": "succeeded", "output": {...}}
+//	{"status": "failed", "error": "..."}
+//
+// progress、message 作为节点进度上报；output 格式与同步模式的响应相同。
+type AsyncJobStatus struct {
+	Status   string   `json:"status"`
+	Progress *float64 `json:"progress,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Output   *Output  `json:"output,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Terminal 作业是否已结束；未知状态返回错误
+func (s *AsyncJobStatus) Terminal() (bool, error) {
+	switch s.Status {
+	case AsyncJobPending, AsyncJobRunning:
+		return false, nil
+	case AsyncJobSucceeded, AsyncJobFailed, AsyncJobCancelled:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown async job status %q", s.Status)
+	}
+}
+
+// Result 已结束作业的输出；失败或取消时返回错误
+func (s *AsyncJobStatus) Result() (*Output, error) {
+	switch s.Status {
+	case AsyncJobSucceeded:
+		if s.Output == nil {
+			return &Output{}, nil
+		}
+		return s.Output, nil
+	case AsyncJobCancelled:
+		return nil, fmt.Errorf("async job cancelled: %s", s.Error)
+	default:
+		return nil, fmt.Errorf("async job failed: %s", s.Error)
+	}
+}
+
+// AsyncCallbackRequest 算子发送到回调地址的请求，由等待该回调的执行器校验并答复
+type AsyncCallbackRequest struct {
+	Header http.Header
+	Body   []byte
+	reply  chan error
+}
+
+// NewAsyncCallbackRequest 创建回调请求
+func NewAsyncCallbackRequest(header http.Header, body []byte) *AsyncCallbackRequest {
+	return &AsyncCallbackRequest{Header: header, Body: body, reply: make(chan error, 1)}
+}
+
+// Reply 答复回调请求，err 非 nil 表示回调被拒绝（签名或格式错误）
+func (r *AsyncCallbackRequest) Reply(err error) {
+	select {
+	case r.reply <- err:
+	default:
+	}
+}
+
+// Wait 等待执行器答复
+func (r *AsyncCallbackRequest) Wait(ctx context.Context) error {
+	select {
+	case err := <-r.reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AsyncCallbackRegistrar 为一次算子调用登记回调地址，返回地址、接收回调请求的通道与注销函数。
+// 由工作流引擎注入执行上下文，地址带签名令牌，只对本次调用有效
+type AsyncCallbackRegistrar func() (url string, requests <-chan *AsyncCallbackRequest, release func())
+
+type asyncCallbackRegistrarKey struct{}
+
+// WithAsyncCallbackRegistrar 在上下文中注入回调登记函数
+func WithAsyncCallbackRegistrar(ctx context.Context, registrar AsyncCallbackRegistrar) context.Context {
+	return context.WithValue(ctx, asyncCallbackRegistrarKey{}, registrar)
+}
+
+// AsyncCallbackRegistrarFromContext 返回回调登记函数，引擎未配置回调地址时为 nil
+func AsyncCallbackRegistrarFromContext(ctx context.Context) AsyncCallbackRegistrar {
+	registrar, _ := ctx.Value(asyncCallbackRegistrarKey{}).(AsyncCallbackRegistrar)
+	return registrar
+}
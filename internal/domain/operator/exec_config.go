@@ -16,6 +16,8 @@ type HTTPExecConfig struct {
 	TimeoutSec int               `json:"timeout_sec,omitempty"`
 	AuthType   string            `json:"auth_type,omitempty"`
 	AuthConfig map[string]string `json:"auth_config,omitempty"`
+	// Async 异步作业协议，为 nil 时同步等待响应
+	Async *HTTPAsyncConfig `json:"async,omitempty"`
 }
 
 type CLIExecConfig struct {
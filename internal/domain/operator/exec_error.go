@@ -0,0 +1,154 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorClass 算子执行错误分类，工作流引擎据此决定是否重试
+type ErrorClass string
+
+const (
+	// ErrorClassTimeout 执行超时
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassServer 服务端错误（HTTP 5xx）
+	ErrorClassServer ErrorClass = "server_error"
+	// ErrorClassRateLimited 被限流（HTTP 429），可携带 Retry-After
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassConnection 连接失败（拒绝连接、连接重置、DNS 解析失败等）
+	ErrorClassConnection ErrorClass = "connection"
+	// ErrorClassClient 请求错误（HTTP 4xx，429 除外），重试不会改变结果
+	ErrorClassClient ErrorClass = "client_error"
+	// ErrorClassInvalid 配置错误、输入输出不合法等确定性失败
+	ErrorClassInvalid ErrorClass = "invalid"
+	// ErrorClassUnknown 执行器未能分类的错误（如 CLI 非零退出）
+	ErrorClassUnknown ErrorClass = "unknown"
+)
+
+// IsValid 是否为已知的错误分类
+func (c ErrorClass) IsValid() bool {
+	switch c {
+	case ErrorClassTimeout, ErrorClassServer, ErrorClassRateLimited, ErrorClassConnection,
+		ErrorClassClient, ErrorClassInvalid, ErrorClassUnknown:
+		return true
+	}
+	return false
+}
+
+// ExecError 带分类的算子执行错误，由执行器返回
+type ExecError struct {
+	Class ErrorClass
+	// StatusCode HTTP 类执行器的响应状态码，其余为 0
+	StatusCode int
+	// RetryAfter 服务端要求的最短重试间隔（Retry-After），0 表示未指定
+	RetryAfter time.Duration
+	Err        error
+}
+
+// NewExecError 创建带分类的执行错误
+func NewExecError(class ErrorClass, err error) *ExecError {
+	return &ExecError{Class: class, Err: err}
+}
+
+// InvalidError 创建确定性失败错误（配置缺失、输出无法解析等）
+func InvalidError(format string, args ...interface{}) *ExecError {
+	return &ExecError{Class: ErrorClassInvalid, Err: fmt.Errorf(format, args...)}
+}
+
+// HTTPStatusError 按响应状态码为 err 分类，429/503 解析 Retry-After 头
+func HTTPStatusError(resp *http.Response, err error) *ExecError {
+	e := &ExecError{
+		Class:      ClassForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Err:        err,
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		e.RetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return e
+}
+
+func (e *ExecError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// ClassForStatus 按 HTTP 状态码分类
+func ClassForStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrorClassTimeout
+	case status >= 500:
+		return ErrorClassServer
+	case status >= 400:
+		return ErrorClassClient
+	}
+	return ErrorClassUnknown
+}
+
+// ClassifyError 返回错误分类。ExecError 使用其自身分类，
+// 其余错误按超时、连接错误识别，无法识别时为 ErrorClassUnknown
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	var execErr *ExecError
+	if errors.As(err, &execErr) && execErr.Class != "" {
+		return execErr.Class
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return ErrorClassConnection
+	}
+	return ErrorClassUnknown
+}
+
+// RetryAfterOf 返回错误携带的 Retry-After 间隔
+func RetryAfterOf(err error) time.Duration {
+	var execErr *ExecError
+	if errors.As(err, &execErr) {
+		return execErr.RetryAfter
+	}
+	return 0
+}
+
+// ParseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），无效或已过期时返回 0
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package operator
+
+import "context"
+
+// ProgressURLHeader HTTP 算子请求头，值为算子上报进度的回调地址
+const ProgressURLHeader = "X-Progress-URL"
+
+// Progress 算子执行过程中上报的进度
+type Progress struct {
+	// Fraction 完成比例，取值 0~1
+	Fraction float64 `json:"progress"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// ProgressReporter 接收算子进度上报，由工作流引擎注入执行上下文
+type ProgressReporter func(Progress)
+
+type progressReporterKey struct{}
+
+type progressURLKey struct{}
+
+// WithProgressReporter 在上下文中注入进度接收方
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// ReportProgress 上报执行进度，上下文中没有接收方时忽略；比例超出 0~1 时截断
+func ReportProgress(ctx context.Context, fraction float64, message string) {
+	reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter)
+	if !ok || reporter == nil {
+		return
+	}
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	reporter(Progress{Fraction: fraction, Message: message})
+}
+
+// HasProgressReporter 上下文中是否有进度接收方
+func HasProgressReporter(ctx context.Context) bool {
+	reporter, ok := ctx.Value(progressReporterKey{}).(ProgressReporter)
+	return ok && reporter != nil
+}
+
+// WithProgressURL 在上下文中注入 HTTP 算子的进度回调地址
+func WithProgressURL(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, progressURLKey{}, url)
+}
+
+// ProgressURLFromContext 返回进度回调地址，未配置时为空
+func ProgressURLFromContext(ctx context.Context) string {
+	url, _ := ctx.Value(progressURLKey{}).(string)
+	return url
+}
@@ -2,6 +2,7 @@ package operator
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -53,3 +54,14 @@ type DependencyRepository interface {
 	DeleteByOperator(ctx context.Context, operatorID uuid.UUID) error
 	CheckDependenciesSatisfied(ctx context.Context, operatorID uuid.UUID) (bool, []string, error)
 }
+
+// SlotRepository 算子并发槽位，按所有执行方合计限制同一算子进行中的调用数。
+// 每次调用占用一个槽位，持有方需在 ttl 内续约，失联后槽位过期自动释放
+type SlotRepository interface {
+	// Acquire 在算子进行中的调用数小于 limit 时占用一个槽位并返回槽位 ID，槽位已满时返回 false
+	Acquire(ctx context.Context, operatorCode string, limit int, holder string, ttl time.Duration) (uuid.UUID, bool, error)
+	// Renew 延长槽位的过期时间
+	Renew(ctx context.Context, id uuid.UUID, ttl time.Duration) error
+	// Release 释放槽位
+	Release(ctx context.Context, id uuid.UUID) error
+}
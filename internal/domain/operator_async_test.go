@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"goyavision/internal/domain/operator"
+)
+
+func TestHTTPAsyncConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     operator.HTTPAsyncConfig
+		wantErr bool
+	}{
+		{"poll", operator.HTTPAsyncConfig{Mode: operator.HTTPAsyncModePoll, StatusURL: "/jobs/{job_id}"}, false},
+		{"callback", operator.HTTPAsyncConfig{Mode: operator.HTTPAsyncModeCallback, CancelURL: "https://example.com/jobs/{job_id}/cancel"}, false},
+		{"unknown mode", operator.HTTPAsyncConfig{Mode: "stream"}, true},
+		{"negative timeout", operator.HTTPAsyncConfig{Mode: operator.HTTPAsyncModePoll, TimeoutSec: -1}, true},
+		{"bad url", operator.HTTPAsyncConfig{Mode: operator.HTTPAsyncModePoll, StatusURL: "http://[::1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPAsyncConfigPollDelay(t *testing.T) {
+	cfg := operator.HTTPAsyncConfig{PollIntervalSec: 2, MaxPollIntervalSec: 10}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := cfg.PollDelay(i + 1); got != w {
+			t.Errorf("PollDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	var def operator.HTTPAsyncConfig
+	if got := def.PollDelay(1); got != 5*time.Second {
+		t.Errorf("default PollDelay(1) = %v, want 5s", got)
+	}
+	if got := def.PollDelay(100); got != time.Minute {
+		t.Errorf("default PollDelay(100) = %v, want 1m", got)
+	}
+}
+
+func TestJobURL(t *testing.T) {
+	if got := operator.JobURL("/jobs/{job_id}/cancel", "a/b"); got != "/jobs/a%2Fb/cancel" {
+		t.Fatalf("JobURL = %q", got)
+	}
+}
+
+func TestAsyncJobStatus(t *testing.T) {
+	for _, s := range []string{operator.AsyncJobPending, operator.AsyncJobRunning} {
+		st := operator.AsyncJobStatus{Status: s}
+		if terminal, err := st.Terminal(); terminal || err != nil {
+			t.Errorf("%s: Terminal() = %v, %v", s, terminal, err)
+		}
+	}
+	if _, err := (&operator.AsyncJobStatus{Status: "done"}).Terminal(); err == nil {
+		t.Error("unknown status should fail")
+	}
+
+	ok := operator.AsyncJobStatus{Status: operator.AsyncJobSucceeded}
+	if terminal, _ := ok.Terminal(); !terminal {
+		t.Error("succeeded should be terminal")
+	}
+	if out, err := ok.Result(); err != nil || out == nil {
+		t.Errorf("succeeded Result() = %v, %v", out, err)
+	}
+
+	failed := operator.AsyncJobStatus{Status: operator.AsyncJobFailed, Error: "oom"}
+	if _, err := failed.Result(); err == nil {
+		t.Error("failed Result() should return error")
+	}
+	cancelled := operator.AsyncJobStatus{Status: operator.AsyncJobCancelled}
+	if _, err := cancelled.Result(); err == nil {
+		t.Error("cancelled Result() should return error")
+	}
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalTimeoutAction 审批超时后的处理策略
+type ApprovalTimeoutAction string
+
+const (
+	// ApprovalTimeoutReject 超时自动驳回（默认）
+	ApprovalTimeoutReject ApprovalTimeoutAction = "reject"
+	// ApprovalTimeoutApprove 超时自动通过
+	ApprovalTimeoutApprove ApprovalTimeoutAction = "approve"
+	// ApprovalTimeoutFail 超时视为节点执行失败
+	ApprovalTimeoutFail ApprovalTimeoutAction = "fail"
+)
+
+// ApprovalConfig 审批节点配置，TimeoutSeconds 为 0 表示不限时
+type ApprovalConfig struct {
+	TimeoutSeconds int                   `json:"timeout_seconds,omitempty"`
+	OnTimeout      ApprovalTimeoutAction `json:"on_timeout,omitempty"`
+}
+
+func (c *ApprovalConfig) Validate() error {
+	if c.TimeoutSeconds < 0 {
+		return errors.New("timeout_seconds must not be negative")
+	}
+	switch c.OnTimeout {
+	case "", ApprovalTimeoutReject, ApprovalTimeoutApprove, ApprovalTimeoutFail:
+		return nil
+	}
+	return fmt.Errorf("unsupported on_timeout %q", c.OnTimeout)
+}
+
+func (c *ApprovalConfig) GetOnTimeout() ApprovalTimeoutAction {
+	if c == nil || c.OnTimeout == "" {
+		return ApprovalTimeoutReject
+	}
+	return c.OnTimeout
+}
+
+// ApprovalDecision 审批结果
+type ApprovalDecision string
+
+const (
+	ApprovalApproved ApprovalDecision = "approved"
+	ApprovalRejected ApprovalDecision = "rejected"
+	// ApprovalTimedOut 超时且策略为 fail
+	ApprovalTimedOut ApprovalDecision = "timed_out"
+)
+
+// NodeApproval 审批节点的等待状态与决策记录
+//
+// 节点进入等待时记录 Payload（节点输入参数，即待审内容）与截止时间；
+// 审批人通过时可提交新的 Payload 替换原内容，流向下游节点。
+type NodeApproval struct {
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+	Deadline    *time.Time             `json:"deadline,omitempty"`
+	Decision    ApprovalDecision       `json:"decision,omitempty"`
+	ApproverID  *uuid.UUID             `json:"approver_id,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	DecidedAt   *time.Time             `json:"decided_at,omitempty"`
+	AutoDecided bool                   `json:"auto_decided,omitempty"`
+}
+
+// IsDecided 是否已有审批结果
+func (a *NodeApproval) IsDecided() bool {
+	return a != nil && a.Decision != ""
+}
+
+// IsExpired 尚未决策且已超过截止时间
+func (a *NodeApproval) IsExpired(now time.Time) bool {
+	return a != nil && !a.IsDecided() && a.Deadline != nil && !now.Before(*a.Deadline)
+}
+
+// ApplyTimeout 按超时策略自动决策
+func (a *NodeApproval) ApplyTimeout(action ApprovalTimeoutAction, now time.Time) {
+	switch action {
+	case ApprovalTimeoutApprove:
+		a.Decision = ApprovalApproved
+	case ApprovalTimeoutFail:
+		a.Decision = ApprovalTimedOut
+	default:
+		a.Decision = ApprovalRejected
+	}
+	a.AutoDecided = true
+	a.DecidedAt = &now
+}
+
+var (
+	ErrApprovalNotFound = errors.New("node is not waiting for approval")
+	ErrApprovalDecided  = errors.New("approval has already been decided")
+)
+
+// DecideApproval 记录审批人对等待中审批节点的决策；payload 为 nil 时保留原待审内容
+func (t *Task) DecideApproval(nodeKey string, approved bool, approverID uuid.UUID, comment string, payload map[string]interface{}) error {
+	if !t.IsWaiting() {
+		return ErrApprovalNotFound
+	}
+	for i := range t.NodeExecutions {
+		ne := &t.NodeExecutions[i]
+		if ne.NodeKey != nodeKey {
+			continue
+		}
+		if ne.Status != NodeExecWaiting || ne.Approval == nil {
+			return ErrApprovalNotFound
+		}
+		if ne.Approval.IsDecided() {
+			return ErrApprovalDecided
+		}
+		now := time.Now()
+		if approved {
+			ne.Approval.Decision = ApprovalApproved
+			if payload != nil {
+				ne.Approval.Payload = payload
+			}
+		} else {
+			ne.Approval.Decision = ApprovalRejected
+		}
+		ne.Approval.ApproverID = &approverID
+		ne.Approval.Comment = comment
+		ne.Approval.DecidedAt = &now
+		return nil
+	}
+	return ErrApprovalNotFound
+}
+
+// ApprovalDeadline 返回等待中审批节点最早的截止时间，没有限时审批时返回 nil
+func (t *Task) ApprovalDeadline() *time.Time {
+	var earliest *time.Time
+	for i := range t.NodeExecutions {
+		a := t.NodeExecutions[i].Approval
+		if t.NodeExecutions[i].Status != NodeExecWaiting || a == nil || a.IsDecided() || a.Deadline == nil {
+			continue
+		}
+		if earliest == nil || a.Deadline.Before(*earliest) {
+			earliest = a.Deadline
+		}
+	}
+	return earliest
+}
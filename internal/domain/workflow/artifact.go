@@ -24,6 +24,8 @@ type Artifact struct {
 	Data      *ArtifactData
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Temporary 临时产物，任务失败、超时或被取消后自动删除
+	Temporary bool
 }
 
 func (a *Artifact) IsAsset() bool {
@@ -0,0 +1,26 @@
+package workflow
+
+import (
+	"time"
+
+	"goyavision/internal/domain/operator"
+
+	"github.com/google/uuid"
+)
+
+// TaskCheckpoint 节点执行检查点，记录已完成节点的输出与执行状态，用于任务中断后恢复执行
+type TaskCheckpoint struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	TaskID    uuid.UUID
+	NodeKey   string
+	Output    *operator.Output
+	Execution NodeExecution
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsCompleted 检查点对应的节点是否已到达可跳过的终态
+func (c *TaskCheckpoint) IsCompleted() bool {
+	return c.Execution.Status.IsSucceeded() || c.Execution.Status == NodeExecSkipped
+}
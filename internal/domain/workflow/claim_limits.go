@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ClaimLimits 认领任务时校验的并发上限，<= 0 表示不限制。
+//
+// 上限按所有执行方合计：运行中（running）的顶层任务占用槽位，
+// 等待中的任务与子工作流的子任务（与父任务在同一执行中运行）不占用。
+type ClaimLimits struct {
+	// Max 本次最多认领的任务数（执行方本地的空闲槽位）
+	Max int
+	// MaxConcurrent 全局同时运行的任务数
+	MaxConcurrent int
+	// MaxPerTenant 每个租户同时运行的任务数
+	MaxPerTenant int
+	// MaxPerWorkflow 每个工作流同时运行的任务数
+	MaxPerWorkflow int
+	// Tenants 按租户 ID 覆盖 MaxPerTenant
+	Tenants map[string]int
+	// Workflows 按工作流编码（小写）覆盖 MaxPerWorkflow
+	Workflows map[string]int
+}
+
+// RunningTaskCounts 所有执行方中运行中的顶层任务数
+type RunningTaskCounts struct {
+	Total      int
+	ByTenant   map[uuid.UUID]int
+	ByWorkflow map[uuid.UUID]int
+}
+
+// NewRunningTaskCounts 创建空的计数
+func NewRunningTaskCounts() *RunningTaskCounts {
+	return &RunningTaskCounts{
+		ByTenant:   make(map[uuid.UUID]int),
+		ByWorkflow: make(map[uuid.UUID]int),
+	}
+}
+
+// TenantLimit 返回租户的并发上限
+func (l *ClaimLimits) TenantLimit(tenantID uuid.UUID) int {
+	if limit, ok := l.Tenants[tenantID.String()]; ok {
+		return limit
+	}
+	return l.MaxPerTenant
+}
+
+// WorkflowLimit 按工作流编码返回并发上限
+func (l *ClaimLimits) WorkflowLimit(code string) int {
+	if limit, ok := l.Workflows[strings.ToLower(code)]; ok {
+		return limit
+	}
+	return l.MaxPerWorkflow
+}
+
+// Admit 任务在全局、租户与工作流上限内时计入 counts 并返回 true。nil 表示不限制
+func (l *ClaimLimits) Admit(counts *RunningTaskCounts, task *Task, workflowCode string) bool {
+	if l == nil {
+		return true
+	}
+	if l.MaxConcurrent > 0 && counts.Total >= l.MaxConcurrent {
+		return false
+	}
+	if limit := l.TenantLimit(task.TenantID); limit > 0 && counts.ByTenant[task.TenantID] >= limit {
+		return false
+	}
+	if limit := l.WorkflowLimit(workflowCode); limit > 0 && counts.ByWorkflow[task.WorkflowID] >= limit {
+		return false
+	}
+	counts.Total++
+	counts.ByTenant[task.TenantID]++
+	counts.ByWorkflow[task.WorkflowID]++
+	return true
+}
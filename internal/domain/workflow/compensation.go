@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HookEvent 触发收尾节点的任务结局
+type HookEvent string
+
+const (
+	// HookOnFailure 任务失败或超时
+	HookOnFailure HookEvent = "on_failure"
+	// HookOnCancel 任务被取消
+	HookOnCancel HookEvent = "on_cancel"
+)
+
+// HookEventOf 任务终态对应的收尾事件，成功或未结束的任务返回 false
+func HookEventOf(status TaskStatus) (HookEvent, bool) {
+	switch status {
+	case TaskStatusFailed, TaskStatusTimedOut:
+		return HookOnFailure, true
+	case TaskStatusCancelled:
+		return HookOnCancel, true
+	}
+	return "", false
+}
+
+// HookConfig 收尾节点配置
+type HookConfig struct {
+	On []HookEvent `json:"on"`
+}
+
+func (c *HookConfig) Validate() error {
+	if len(c.On) == 0 {
+		return errors.New("hook.on is required")
+	}
+	for _, ev := range c.On {
+		if ev != HookOnFailure && ev != HookOnCancel {
+			return fmt.Errorf("invalid hook event: %s", ev)
+		}
+	}
+	return nil
+}
+
+// Handles 收尾节点是否响应该事件
+func (c *HookConfig) Handles(ev HookEvent) bool {
+	if c == nil {
+		return false
+	}
+	for _, on := range c.On {
+		if on == ev {
+			return true
+		}
+	}
+	return false
+}
+
+// CompensationConfig 节点补偿配置
+//
+// 任务失败、超时或被取消时，对已成功的节点按完成时间倒序调用补偿算子，
+// 算子输入包含节点输出 node_output 与任务上下文 task，用于撤销节点的副作用（如停止录制、删除上传的文件）。
+type CompensationConfig struct {
+	OperatorID     uuid.UUID              `json:"operator_id"`
+	Params         map[string]interface{} `json:"params,omitempty"`
+	TimeoutSeconds int                    `json:"timeout_seconds,omitempty"`
+}
+
+func (c *CompensationConfig) Validate() error {
+	if c.OperatorID == uuid.Nil {
+		return errors.New("compensate.operator_id is required")
+	}
+	if c.TimeoutSeconds < 0 {
+		return errors.New("compensate.timeout_seconds must not be negative")
+	}
+	return nil
+}
+
+// NodeCompensation 节点补偿的执行结果
+type NodeCompensation struct {
+	Status      NodeExecutionStatus `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+}
+
+// UndoneNodes 任务结束后已执行过补偿、或产物作为临时产物被删除的节点，重跑时不能复用其输出
+func UndoneNodes(wf *Workflow, t *Task) map[string]bool {
+	undone := make(map[string]bool)
+	for _, ne := range t.NodeExecutions {
+		if ne.Compensation != nil {
+			undone[ne.NodeKey] = true
+		}
+	}
+	if _, cleaned := HookEventOf(t.Status); cleaned {
+		for _, n := range wf.Nodes {
+			if n.HasTempArtifacts() {
+				undone[n.NodeKey] = true
+			}
+		}
+	}
+	return undone
+}
+
+// FlowNodes 参与主流程的节点（不含收尾节点）
+func (w *Workflow) FlowNodes() []Node {
+	nodes := make([]Node, 0, len(w.Nodes))
+	for _, n := range w.Nodes {
+		if !n.IsHook() {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// HookNodes 响应 ev 的收尾节点，按节点顺序
+func (w *Workflow) HookNodes(ev HookEvent) []Node {
+	var nodes []Node
+	for _, n := range w.Nodes {
+		if n.IsHook() && n.Config != nil && n.Config.Hook.Handles(ev) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// ValidateHookEdges 校验收尾节点不与其他节点连线
+func ValidateHookEdges(nodes []Node, edges []Edge) error {
+	hooks := make(map[string]bool)
+	for _, n := range nodes {
+		if n.IsHook() {
+			hooks[n.NodeKey] = true
+		}
+	}
+	for _, e := range edges {
+		if hooks[e.SourceKey] || hooks[e.TargetKey] {
+			return fmt.Errorf("hook node cannot be connected: %s -> %s", e.SourceKey, e.TargetKey)
+		}
+	}
+	return nil
+}
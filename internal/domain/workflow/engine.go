@@ -10,10 +10,18 @@ import (
 
 type Engine interface {
 	Execute(ctx context.Context, workflow *Workflow, task *Task) error
+	Resume(ctx context.Context, workflow *Workflow, task *Task) error
 	Cancel(ctx context.Context, taskID uuid.UUID) error
+	Abandon(ctx context.Context, taskID uuid.UUID) error
+	Cleanup(ctx context.Context, workflow *Workflow, task *Task) error
 	GetProgress(ctx context.Context, taskID uuid.UUID) (int, error)
 }
 
+// Planner 解析工作流执行时会使用的层级、算子版本与参数，不调用任何算子
+type Planner interface {
+	Plan(ctx context.Context, workflow *Workflow, req PlanRequest) (*Plan, error)
+}
+
 type OperatorExecutor interface {
 	Execute(ctx context.Context, version *operator.OperatorVersion, input *operator.Input) (*operator.Output, error)
 }
@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"goyavision/pkg/expr"
+)
+
+// 输入映射表达式中的保留变量
+const (
+	// MappingVarInput 任务输入参数
+	MappingVarInput = "input"
+	// MappingVarNodes 按节点 key 访问祖先节点输出，用于 key 不是合法标识符的场景，如 nodes["frame-extract"]
+	MappingVarNodes = "nodes"
+)
+
+// InputMapping 节点输入映射
+//
+// From 为表达式（见 pkg/expr），可引用祖先节点的输出（以节点 key 为变量，
+// 字段为 output_assets、results、timeline、diagnostics）与任务输入 input，
+// 例如 detect.results[0].data.bbox；To 为写入的参数路径，例如 params.region。
+// 节点声明映射后，上游数据只按映射流入，不再自动注入 <key>_output 等参数。
+type InputMapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Validate 编译源表达式并校验目标路径
+func (m *InputMapping) Validate() error {
+	if _, err := m.Program(); err != nil {
+		return err
+	}
+	path := m.TargetPath()
+	if len(path) == 0 {
+		return fmt.Errorf("input mapping target is required for %q", m.From)
+	}
+	for _, seg := range path {
+		if seg == "" {
+			return fmt.Errorf("invalid input mapping target %q", m.To)
+		}
+	}
+	return nil
+}
+
+// Program 编译源表达式
+func (m *InputMapping) Program() (*expr.Program, error) {
+	if strings.TrimSpace(m.From) == "" {
+		return nil, errors.New("input mapping source is required")
+	}
+	return expr.Compile(m.From)
+}
+
+// TargetPath 返回目标参数路径（去掉可选的 params. 前缀）
+func (m *InputMapping) TargetPath() []string {
+	to := strings.TrimPrefix(strings.TrimSpace(m.To), "params.")
+	if to == "" {
+		return nil
+	}
+	return strings.Split(to, ".")
+}
+
+// Ancestors 根据边计算每个节点的全部祖先节点
+func Ancestors(edges []Edge) map[string]map[string]bool {
+	parents := make(map[string][]string)
+	for _, e := range edges {
+		parents[e.TargetKey] = append(parents[e.TargetKey], e.SourceKey)
+	}
+
+	result := make(map[string]map[string]bool)
+	var collect func(key string) map[string]bool
+	collect = func(key string) map[string]bool {
+		if set, ok := result[key]; ok {
+			return set
+		}
+		set := make(map[string]bool)
+		// 先占位，防止环导致无限递归（环在构建执行层时另行报错）
+		result[key] = set
+		for _, p := range parents[key] {
+			set[p] = true
+			for a := range collect(p) {
+				set[a] = true
+			}
+		}
+		return set
+	}
+	for key := range parents {
+		collect(key)
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"goyavision/internal/domain/operator"
+
+	"github.com/google/uuid"
+)
+
+// NodeCacheConfig 节点结果缓存配置，仅在引擎启用结果缓存时生效
+type NodeCacheConfig struct {
+	// TTLSeconds 覆盖全局缓存有效期，0 表示使用全局配置
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// Bypass 不读取也不写入缓存，适用于结果随时间变化的算子（如实时流抓帧）
+	Bypass bool `json:"bypass,omitempty"`
+}
+
+func (c *NodeCacheConfig) Validate() error {
+	if c.TTLSeconds < 0 {
+		return errors.New("cache ttl_seconds must not be negative")
+	}
+	return nil
+}
+
+// NodeCacheEntry 节点结果缓存项，以 (算子版本 ID, 输入哈希) 为键，租户隔离
+type NodeCacheEntry struct {
+	ID                uuid.UUID
+	TenantID          uuid.UUID
+	OperatorVersionID uuid.UUID
+	InputHash         string
+	Output            *operator.Output
+	// ArtifactIDs 产生缓存的任务保存的产物，命中时复制给命中的任务；产物已被删除时视为未命中
+	ArtifactIDs  []uuid.UUID
+	SourceTaskID uuid.UUID
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// IsExpired 是否已过期，ExpiresAt 为空表示永不过期
+func (e *NodeCacheEntry) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// HashNodeInput 计算算子输入的规范化哈希。
+// JSON 序列化时 map 按键排序，相同内容的输入得到相同哈希。
+func HashNodeInput(input *operator.Input) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,171 @@
+package workflow
+
+import (
+	"goyavision/internal/domain/operator"
+
+	"github.com/google/uuid"
+)
+
+// PlanRequest 试运行参数，与触发任务时的输入一致
+type PlanRequest struct {
+	AssetID     *uuid.UUID
+	InputParams map[string]interface{}
+	// FanOutItems 按节点 key 假定扇出节点的元素数，用于上游输出决定元素数时估算成本
+	FanOutItems map[string]int
+}
+
+// PlanSeverity 试运行问题级别
+type PlanSeverity string
+
+const (
+	// PlanSeverityError 执行时必然失败或无法执行
+	PlanSeverityError PlanSeverity = "error"
+	// PlanSeverityWarning 可以执行，但结果或成本可能不符合预期
+	PlanSeverityWarning PlanSeverity = "warning"
+)
+
+// 试运行问题代码
+const (
+	PlanIssueCycle            = "cycle"
+	PlanIssueInvalidConfig    = "invalid_config"
+	PlanIssueOperatorNotFound = "operator_not_found"
+	PlanIssueNoActiveVersion  = "no_active_version"
+	PlanIssueDeprecated       = "operator_deprecated"
+	PlanIssueNotPublished     = "operator_not_published"
+	PlanIssueDependencyUnmet  = "dependency_unmet"
+	PlanIssueInputInvalid     = "input_invalid"
+	PlanIssueInputMapping     = "input_mapping"
+	PlanIssueSubWorkflow      = "sub_workflow_not_found"
+	PlanIssueFanOutUnknown    = "fan_out_unknown"
+	PlanIssueTokenBudgetUnset = "token_budget_unset"
+)
+
+// PlanIssue 试运行发现的问题
+type PlanIssue struct {
+	Severity PlanSeverity `json:"severity"`
+	Code     string       `json:"code"`
+	Message  string       `json:"message"`
+}
+
+// 节点输入校验结果
+const (
+	// InputCheckValid 按已知输入通过算子输入 Schema 校验
+	InputCheckValid = "valid"
+	// InputCheckInvalid 已知输入未通过校验，且节点不接收上游数据
+	InputCheckInvalid = "invalid"
+	// InputCheckIncomplete 已知输入未通过校验，缺少的字段可能在运行时由上游节点提供
+	InputCheckIncomplete = "incomplete"
+	// InputCheckSkipped 节点没有算子、算子版本没有输入 Schema 或未配置校验器
+	InputCheckSkipped = "skipped"
+)
+
+// NodeInputCheck 节点输入的 Schema 校验结果
+type NodeInputCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// UpstreamParams 运行时才能确定的参数（上游节点输出或引用上游的映射目标）
+	UpstreamParams []string `json:"upstream_params,omitempty"`
+}
+
+// NodeEstimate 节点成本估算，条件连线跳过的节点同样计入，因此为上限
+type NodeEstimate struct {
+	// Calls 算子调用次数（扇出节点为元素数），为空表示无法确定
+	Calls *int `json:"calls"`
+	// MaxAttempts 每次调用的最大尝试次数（含重试）
+	MaxAttempts int `json:"max_attempts"`
+	// MaxTokensPerCall AI 模型节点每次调用的 max_tokens
+	MaxTokensPerCall *int `json:"max_tokens_per_call,omitempty"`
+	// TokenBudget AI 模型节点不计重试的 token 上限：Calls × MaxTokensPerCall
+	TokenBudget *int `json:"token_budget,omitempty"`
+}
+
+// NodePlan 单个节点的解析结果
+type NodePlan struct {
+	NodeKey  string `json:"node_key"`
+	NodeType string `json:"node_type"`
+	// Layer 所在执行层（从 0 开始），因环无法执行或为收尾节点时为 -1
+	Layer          int        `json:"layer"`
+	OperatorID     *uuid.UUID `json:"operator_id,omitempty"`
+	OperatorCode   string     `json:"operator_code,omitempty"`
+	OperatorStatus string     `json:"operator_status,omitempty"`
+	VersionID      *uuid.UUID `json:"version_id,omitempty"`
+	Version        string     `json:"version,omitempty"`
+	ExecMode       string     `json:"exec_mode,omitempty"`
+	// Params 合并后的输入参数：任务输入、节点配置 params 及可静态求值的输入映射
+	Params     map[string]interface{} `json:"params"`
+	InputCheck NodeInputCheck         `json:"input_check"`
+	Estimate   NodeEstimate           `json:"estimate"`
+	Issues     []PlanIssue            `json:"issues"`
+}
+
+// PlanEstimate 工作流成本估算汇总
+type PlanEstimate struct {
+	OperatorCalls int `json:"operator_calls"`
+	// MaxOperatorCalls 所有尝试都失败重试时的调用次数
+	MaxOperatorCalls int `json:"max_operator_calls"`
+	TokenBudget      int `json:"token_budget"`
+	// Complete 为 false 时部分节点无法估算，UnknownNodes 列出这些节点
+	Complete     bool     `json:"complete"`
+	UnknownNodes []string `json:"unknown_nodes,omitempty"`
+}
+
+// Plan 工作流试运行结果：引擎执行时会使用的解析结果，不调用任何算子
+type Plan struct {
+	WorkflowID uuid.UUID `json:"workflow_id"`
+	Revision   int       `json:"revision"`
+	// Valid 没有任何 error 级问题
+	Valid    bool         `json:"valid"`
+	Layers   [][]string   `json:"layers"`
+	Nodes    []*NodePlan  `json:"nodes"`
+	Issues   []PlanIssue  `json:"issues"`
+	Estimate PlanEstimate `json:"estimate"`
+}
+
+// AddIssue 记录节点问题
+func (n *NodePlan) AddIssue(severity PlanSeverity, code, message string) {
+	n.Issues = append(n.Issues, PlanIssue{Severity: severity, Code: code, Message: message})
+}
+
+// HasErrors 节点是否存在 error 级问题
+func (n *NodePlan) HasErrors() bool {
+	for _, issue := range n.Issues {
+		if issue.Severity == PlanSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Finish 汇总成本估算与有效性
+func (p *Plan) Finish() {
+	p.Valid = true
+	p.Estimate = PlanEstimate{Complete: true}
+	for _, issue := range p.Issues {
+		if issue.Severity == PlanSeverityError {
+			p.Valid = false
+		}
+	}
+	for _, n := range p.Nodes {
+		if n.HasErrors() {
+			p.Valid = false
+		}
+		// 收尾节点只在任务失败或取消后执行，不计入估算
+		if n.OperatorID == nil || n.NodeType == NodeTypeHook {
+			continue
+		}
+		if n.Estimate.Calls == nil {
+			p.Estimate.Complete = false
+			p.Estimate.UnknownNodes = append(p.Estimate.UnknownNodes, n.NodeKey)
+			continue
+		}
+		calls := *n.Estimate.Calls
+		p.Estimate.OperatorCalls += calls
+		p.Estimate.MaxOperatorCalls += calls * n.Estimate.MaxAttempts
+		if n.Estimate.TokenBudget != nil {
+			p.Estimate.TokenBudget += *n.Estimate.TokenBudget
+		} else if n.ExecMode == string(operator.ExecModeAIModel) {
+			p.Estimate.Complete = false
+			p.Estimate.UnknownNodes = append(p.Estimate.UnknownNodes, n.NodeKey)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,6 +16,8 @@ type Repository interface {
 	Update(ctx context.Context, w *Workflow) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	ListEnabled(ctx context.Context) ([]*Workflow, error)
+	// UpdateRunTimes 写入定时触发的上一次与下一次触发时间；lastRunAt 为 nil 时保留原值，nextRunAt 为 nil 时清空
+	UpdateRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error
 
 	CreateNode(ctx context.Context, n *Node) error
 	ListNodes(ctx context.Context, workflowID uuid.UUID) ([]*Node, error)
@@ -25,6 +28,16 @@ type Repository interface {
 	DeleteEdges(ctx context.Context, workflowID uuid.UUID) error
 }
 
+// RevisionRepository 工作流修订仓储，修订只增不改
+type RevisionRepository interface {
+	Create(ctx context.Context, r *Revision) error
+	Get(ctx context.Context, id uuid.UUID) (*Revision, error)
+	GetByNumber(ctx context.Context, workflowID uuid.UUID, revision int) (*Revision, error)
+	// GetLatest 没有修订时返回 gorm.ErrRecordNotFound
+	GetLatest(ctx context.Context, workflowID uuid.UUID) (*Revision, error)
+	List(ctx context.Context, workflowID uuid.UUID, limit, offset int) ([]*Revision, int64, error)
+}
+
 type TaskRepository interface {
 	Create(ctx context.Context, t *Task) error
 	Get(ctx context.Context, id uuid.UUID) (*Task, error)
@@ -34,6 +47,40 @@ type TaskRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetStats(ctx context.Context, workflowID *uuid.UUID) (*TaskStats, error)
 	ListRunning(ctx context.Context) ([]*Task, error)
+	ListWaiting(ctx context.Context) ([]*Task, error)
+	// ListPending 列出等待调度的顶层任务，limits 非 nil 时排除已达到并发上限的租户与工作流
+	ListPending(ctx context.Context, limit int, limits *ClaimLimits) ([]*Task, error)
+	// ListUnfinished 列出 pending/running/waiting 状态的顶层任务
+	ListUnfinished(ctx context.Context) ([]*Task, error)
+	// Fence 在当前事务中锁定任务行，确认任务未被取消或判定超时，否则返回 ErrTaskStopped；
+	// owner 非空时还确认任务仍由 owner 持有租约，否则返回 ErrLeaseLost。
+	// 执行方在同一事务内先 Fence 再写入，避免覆盖执行方之外的取消或其他执行方的状态
+	Fence(ctx context.Context, id uuid.UUID, owner string) error
+	// Claim 认领仍处于 pending 的任务，置为 running 并写入租约，返回实际认领到的任务；
+	// limits 非 nil 时按所有执行方合计的并发上限认领，超出上限的任务仍为 pending
+	Claim(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration, limits *ClaimLimits) ([]*Task, error)
+	// CountRunning 统计所有执行方中运行中的顶层任务
+	CountRunning(ctx context.Context) (*RunningTaskCounts, error)
+	// RenewLeases 为 owner 持有的运行中任务续约，返回续约成功的任务 ID；
+	// 未续约的任务已结束、被取消或租约已被回收
+	RenewLeases(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration) ([]uuid.UUID, error)
+	// ReclaimExpired 将租约过期的运行中任务重新置为 pending，返回回收数量
+	ReclaimExpired(ctx context.Context) (int64, error)
+}
+
+// ScheduledRunRepository 待执行的定时触发
+type ScheduledRunRepository interface {
+	// Queue 记录排队的触发，同一工作流同一触发时间只记录一次
+	Queue(ctx context.Context, run *ScheduledRun) error
+	// RecordMissed 在工作流记录的下一次触发时间早于 now 时将其推进到 next，并在同一事务中记录需要补跑的触发，
+	// 返回是否推进成功。多个副本同时启动、计算出相同的错过触发时只有一个副本记录
+	RecordMissed(ctx context.Context, wf *Workflow, now time.Time, next *time.Time, runs []time.Time) (bool, error)
+	// List 按工作流与触发时间先后列出全部待执行的触发
+	List(ctx context.Context) ([]*ScheduledRun, error)
+	// Start 删除触发记录并在同一事务中创建任务；记录已不存在（已由其他调度方处理）时不创建任务并返回 false
+	Start(ctx context.Context, id uuid.UUID, task *Task) (bool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByWorkflow(ctx context.Context, workflowID uuid.UUID) error
 }
 
 type ArtifactRepository interface {
@@ -44,3 +91,34 @@ type ArtifactRepository interface {
 	ListByTask(ctx context.Context, taskID uuid.UUID) ([]*Artifact, error)
 	ListByType(ctx context.Context, taskID uuid.UUID, artifactType ArtifactType) ([]*Artifact, error)
 }
+
+type NodeCacheRepository interface {
+	// Get 返回未过期的缓存项，未命中时返回 gorm.ErrRecordNotFound
+	Get(ctx context.Context, operatorVersionID uuid.UUID, inputHash string) (*NodeCacheEntry, error)
+	// Save 按 (算子版本, 输入哈希) 写入或覆盖缓存项
+	Save(ctx context.Context, e *NodeCacheEntry) error
+	// DeleteByOperatorVersion 删除算子版本的全部缓存项，返回删除数量
+	DeleteByOperatorVersion(ctx context.Context, operatorVersionID uuid.UUID) (int64, error)
+}
+
+type CheckpointRepository interface {
+	Save(ctx context.Context, c *TaskCheckpoint) error
+	ListByTask(ctx context.Context, taskID uuid.UUID) ([]*TaskCheckpoint, error)
+	DeleteByTask(ctx context.Context, taskID uuid.UUID) error
+}
+
+// WebhookRepository webhook 端点、nonce 与投递记录仓储。
+// 端点按 ID 查询时不按租户过滤（入站请求没有用户身份），调用方以签名鉴权。
+type WebhookRepository interface {
+	Create(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	// GetByWorkflow 没有端点时返回 gorm.ErrRecordNotFound
+	GetByWorkflow(ctx context.Context, workflowID uuid.UUID) (*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	// UseNonce 记录 nonce，在 expiresAt 之前重复使用时返回 ErrWebhookReplay
+	UseNonce(ctx context.Context, webhookID uuid.UUID, nonce string, expiresAt time.Time) error
+	CreateDelivery(ctx context.Context, d *WebhookDelivery) error
+	// UpdateDelivery 更新投递的状态、错误与创建的任务
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]*WebhookDelivery, int64, error)
+}
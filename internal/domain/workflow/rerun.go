@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"goyavision/internal/domain/media"
+	"goyavision/internal/domain/operator"
+)
+
+// RerunMode 任务重跑方式
+type RerunMode string
+
+const (
+	// RerunModeFull 全部节点重新执行
+	RerunModeFull RerunMode = "full"
+	// RerunModeFailed 只重新执行未成功的节点及其下游节点
+	RerunModeFailed RerunMode = "failed"
+	// RerunModeFromNode 重新执行指定节点及其下游节点
+	RerunModeFromNode RerunMode = "from_node"
+)
+
+func (m RerunMode) IsValid() bool {
+	switch m {
+	case RerunModeFull, RerunModeFailed, RerunModeFromNode:
+		return true
+	}
+	return false
+}
+
+// PlanRerun 计算重跑任务中需要重新执行的节点。
+//
+// 不重新执行的节点复用原任务的输出，因此必须在原任务中已成功或被跳过（reusable 为 true）；
+// 不可复用的节点同样重新执行。重新执行的节点的全部下游节点都会重新执行。
+func PlanRerun(wf *Workflow, parent *Task, mode RerunMode, fromNode string, reusable func(nodeKey string) bool) (map[string]bool, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("invalid rerun mode: %s", mode)
+	}
+
+	statuses := make(map[string]NodeExecutionStatus, len(parent.NodeExecutions))
+	for _, ne := range parent.NodeExecutions {
+		statuses[ne.NodeKey] = ne.Status
+	}
+
+	seeds := make(map[string]bool)
+	switch mode {
+	case RerunModeFromNode:
+		if fromNode == "" {
+			return nil, errors.New("node_key is required for from_node mode")
+		}
+		found := false
+		for _, node := range wf.Nodes {
+			found = found || node.NodeKey == fromNode
+		}
+		if !found {
+			return nil, fmt.Errorf("node %s not found in workflow", fromNode)
+		}
+		seeds[fromNode] = true
+	case RerunModeFailed:
+		if parent.IsSuccess() {
+			return nil, errors.New("task has no failed nodes")
+		}
+	}
+
+	for _, node := range wf.Nodes {
+		status := statuses[node.NodeKey]
+		completed := status.IsSucceeded() || status == NodeExecSkipped
+		if mode == RerunModeFull || !completed || !reusable(node.NodeKey) {
+			seeds[node.NodeKey] = true
+		}
+	}
+
+	ancestors := Ancestors(wf.Edges)
+	rerun := make(map[string]bool, len(seeds))
+	for _, node := range wf.Nodes {
+		if seeds[node.NodeKey] {
+			rerun[node.NodeKey] = true
+			continue
+		}
+		for upstream := range ancestors[node.NodeKey] {
+			if seeds[upstream] {
+				rerun[node.NodeKey] = true
+				break
+			}
+		}
+	}
+	return rerun, nil
+}
+
+// OutputFromArtifacts 由节点保存的产物还原节点输出，用于没有检查点的历史任务
+func OutputFromArtifacts(artifacts []*Artifact) *operator.Output {
+	output := &operator.Output{}
+	for _, a := range artifacts {
+		if a.Data == nil {
+			continue
+		}
+		switch a.Type {
+		case ArtifactTypeAsset:
+			if info := a.Data.AssetInfo; info != nil {
+				output.OutputAssets = append(output.OutputAssets, operator.OutputAsset{
+					Type:     media.AssetType(info.Type),
+					Path:     info.Path,
+					Format:   info.Format,
+					Metadata: info.Metadata,
+				})
+			}
+		case ArtifactTypeResult:
+			for _, r := range a.Data.Results {
+				result := operator.Result{}
+				result.Type, _ = r["type"].(string)
+				result.Data, _ = r["data"].(map[string]interface{})
+				result.Confidence, _ = r["confidence"].(float64)
+				output.Results = append(output.Results, result)
+			}
+		case ArtifactTypeTimeline:
+			for _, seg := range a.Data.Timeline {
+				output.Timeline = append(output.Timeline, operator.TimelineEvent{
+					Start:      seg.Start,
+					End:        seg.End,
+					EventType:  seg.EventType,
+					Confidence: seg.Confidence,
+					Data:       seg.Data,
+				})
+			}
+		case ArtifactTypeReport:
+			output.Diagnostics = a.Data.Diagnostics
+		}
+	}
+	return output
+}
@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+// rerunWorkflow probe -> transcode -> thumbnail，probe -> report
+func rerunWorkflow() *Workflow {
+	return &Workflow{
+		Nodes: []Node{{NodeKey: "probe"}, {NodeKey: "transcode"}, {NodeKey: "thumbnail"}, {NodeKey: "report"}},
+		Edges: []Edge{
+			{SourceKey: "probe", TargetKey: "transcode"},
+			{SourceKey: "transcode", TargetKey: "thumbnail"},
+			{SourceKey: "probe", TargetKey: "report"},
+		},
+	}
+}
+
+func rerunParent(status TaskStatus, nodes map[string]NodeExecutionStatus) *Task {
+	t := &Task{Status: status}
+	for _, key := range []string{"probe", "transcode", "thumbnail", "report"} {
+		if s, ok := nodes[key]; ok {
+			t.NodeExecutions = append(t.NodeExecutions, NodeExecution{NodeKey: key, Status: s})
+		}
+	}
+	return t
+}
+
+func allReusable(string) bool { return true }
+
+func TestPlanRerun(t *testing.T) {
+	succeeded := rerunParent(TaskStatusSuccess, map[string]NodeExecutionStatus{
+		"probe": NodeExecSuccess, "transcode": NodeExecSuccess, "thumbnail": NodeExecSuccess, "report": NodeExecSkipped,
+	})
+	failed := rerunParent(TaskStatusFailed, map[string]NodeExecutionStatus{
+		"probe": NodeExecSuccess, "transcode": NodeExecFailed, "thumbnail": NodeExecPending, "report": NodeExecCached,
+	})
+
+	tests := []struct {
+		name     string
+		parent   *Task
+		mode     RerunMode
+		fromNode string
+		reusable func(string) bool
+		want     map[string]bool
+	}{
+		{
+			name:     "full reruns every node",
+			parent:   succeeded,
+			mode:     RerunModeFull,
+			reusable: allReusable,
+			want:     map[string]bool{"probe": true, "transcode": true, "thumbnail": true, "report": true},
+		},
+		{
+			name:     "failed reruns unfinished nodes and their downstream",
+			parent:   failed,
+			mode:     RerunModeFailed,
+			reusable: allReusable,
+			want:     map[string]bool{"transcode": true, "thumbnail": true},
+		},
+		{
+			name:     "from node reruns the node and its downstream",
+			parent:   succeeded,
+			mode:     RerunModeFromNode,
+			fromNode: "transcode",
+			reusable: allReusable,
+			want:     map[string]bool{"transcode": true, "thumbnail": true},
+		},
+		{
+			name:     "nodes that cannot be reused are rerun",
+			parent:   succeeded,
+			mode:     RerunModeFromNode,
+			fromNode: "thumbnail",
+			reusable: func(key string) bool { return key != "probe" },
+			want:     map[string]bool{"probe": true, "transcode": true, "thumbnail": true, "report": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PlanRerun(rerunWorkflow(), tt.parent, tt.mode, tt.fromNode, tt.reusable)
+			if err != nil {
+				t.Fatalf("PlanRerun() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanRerun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRerun_Errors(t *testing.T) {
+	succeeded := rerunParent(TaskStatusSuccess, map[string]NodeExecutionStatus{"probe": NodeExecSuccess})
+
+	tests := []struct {
+		name     string
+		mode     RerunMode
+		fromNode string
+	}{
+		{name: "invalid mode", mode: "partial"},
+		{name: "from node without node key", mode: RerunModeFromNode},
+		{name: "from unknown node", mode: RerunModeFromNode, fromNode: "missing"},
+		{name: "failed nodes of a succeeded task", mode: RerunModeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PlanRerun(rerunWorkflow(), succeeded, tt.mode, tt.fromNode, allReusable); err == nil {
+				t.Error("PlanRerun() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"goyavision/internal/domain/operator"
+)
+
+const (
+	// MaxRetryAttempts 单个节点最大尝试次数（含首次执行）
+	MaxRetryAttempts = 20
+
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
+	defaultRetryMultiplier     = 2.0
+)
+
+// DefaultRetryableClasses 未配置 retry_on 时可重试的错误分类。
+// 未分类错误默认重试，以兼容尚未返回分类错误的执行器
+var DefaultRetryableClasses = []operator.ErrorClass{
+	operator.ErrorClassTimeout,
+	operator.ErrorClassServer,
+	operator.ErrorClassRateLimited,
+	operator.ErrorClassConnection,
+	operator.ErrorClassUnknown,
+}
+
+// RetryPolicy 节点重试策略
+//
+// 第 n 次重试前等待 initial_backoff_ms * multiplier^(n-1)，不超过 max_backoff_ms；
+// jitter 为随机扰动比例（0~1），实际等待在 [d*(1-jitter), d] 内。
+// 限流错误携带 Retry-After 时等待时间不少于该值，但不超过 max_backoff_ms；
+// 任务设有截止时间时等待时间不超过距截止的剩余时间。
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（含首次执行），1 表示不重试
+	MaxAttempts      int                   `json:"max_attempts"`
+	InitialBackoffMs int                   `json:"initial_backoff_ms,omitempty"`
+	MaxBackoffMs     int                   `json:"max_backoff_ms,omitempty"`
+	Multiplier       float64               `json:"multiplier,omitempty"`
+	Jitter           float64               `json:"jitter,omitempty"`
+	RetryOn          []operator.ErrorClass `json:"retry_on,omitempty"`
+}
+
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("retry max_attempts must be between 0 and %d", MaxRetryAttempts)
+	}
+	if p.InitialBackoffMs < 0 || p.MaxBackoffMs < 0 {
+		return errors.New("retry backoff must not be negative")
+	}
+	if p.MaxBackoffMs > 0 && p.InitialBackoffMs > p.MaxBackoffMs {
+		return errors.New("retry initial_backoff_ms must not exceed max_backoff_ms")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return errors.New("retry multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("retry jitter must be between 0 and 1")
+	}
+	for _, class := range p.RetryOn {
+		if !class.IsValid() {
+			return fmt.Errorf("unknown retry error class: %s", class)
+		}
+	}
+	return nil
+}
+
+// Attempts 最大尝试次数，未配置时为 1
+func (p *RetryPolicy) Attempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Retryable 该分类的错误是否允许重试
+func (p *RetryPolicy) Retryable(class operator.ErrorClass) bool {
+	classes := DefaultRetryableClasses
+	if p != nil && len(p.RetryOn) > 0 {
+		classes = p.RetryOn
+	}
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff 第 retry 次重试（从 1 开始）前的等待时间，retryAfter 为服务端要求的最短间隔，
+// deadline 为任务截止时间（nil 表示无截止）
+func (p *RetryPolicy) Backoff(retry int, retryAfter time.Duration, deadline *time.Time) time.Duration {
+	initial, maxBackoff, multiplier, jitter := defaultRetryInitialBackoff, defaultRetryMaxBackoff, defaultRetryMultiplier, 0.0
+	if p != nil {
+		if p.InitialBackoffMs > 0 {
+			initial = time.Duration(p.InitialBackoffMs) * time.Millisecond
+		}
+		if p.MaxBackoffMs > 0 {
+			maxBackoff = time.Duration(p.MaxBackoffMs) * time.Millisecond
+		}
+		if p.Multiplier > 0 {
+			multiplier = p.Multiplier
+		}
+		jitter = p.Jitter
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if d > float64(maxBackoff) {
+		d = float64(maxBackoff)
+	}
+	if jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+
+	backoff := time.Duration(d)
+	if retryAfter > backoff {
+		backoff = min(retryAfter, maxBackoff)
+	}
+	if deadline != nil {
+		backoff = max(min(backoff, time.Until(*deadline)), 0)
+	}
+	return backoff
+}
+
+// EffectiveRetryPolicy 返回节点生效的重试策略。
+// 未配置 retry 时由旧字段 retry_count 推导（默认退避 1s 起、翻倍、上限 30s）
+func (c *NodeConfig) EffectiveRetryPolicy() *RetryPolicy {
+	if c == nil {
+		return nil
+	}
+	if c.Retry != nil {
+		return c.Retry
+	}
+	if c.RetryCount > 0 {
+		return &RetryPolicy{MaxAttempts: c.RetryCount + 1}
+	}
+	return nil
+}
+
+// NodeAttempt 节点（或扇出元素）单次执行尝试的记录
+type NodeAttempt struct {
+	Attempt     int                 `json:"attempt"`
+	Error       string              `json:"error,omitempty"`
+	ErrorClass  operator.ErrorClass `json:"error_class,omitempty"`
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt time.Time           `json:"completed_at"`
+	// BackoffMs 失败后距下次尝试的等待时间，最后一次尝试为 0
+	BackoffMs int64 `json:"backoff_ms,omitempty"`
+}
@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision 工作流修订：节点与连线的不可变快照
+//
+// 工作流的节点或连线每次变化都会保存一个新修订，修订号从 1 递增。
+// 任务记录执行时的修订，恢复与重跑按该修订的图执行，不受之后的编辑影响。
+type Revision struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	WorkflowID uuid.UUID
+	Revision   int
+	Nodes      []RevisionNode
+	Edges      []RevisionEdge
+	// Hash 节点与连线（不含画布位置）的摘要，用于判断图是否变化
+	Hash      string
+	Comment   string
+	CreatedBy *uuid.UUID
+	CreatedAt time.Time
+}
+
+// RevisionNode 修订中的节点
+type RevisionNode struct {
+	NodeKey    string        `json:"node_key"`
+	NodeType   string        `json:"node_type"`
+	OperatorID *uuid.UUID    `json:"operator_id,omitempty"`
+	Config     *NodeConfig   `json:"config,omitempty"`
+	Position   *NodePosition `json:"position,omitempty"`
+}
+
+// RevisionEdge 修订中的连线
+type RevisionEdge struct {
+	SourceKey string         `json:"source_key"`
+	TargetKey string         `json:"target_key"`
+	Condition *EdgeCondition `json:"condition,omitempty"`
+}
+
+// NewRevision 由工作流当前的节点与连线生成修订（未分配修订号）
+func NewRevision(wf *Workflow) *Revision {
+	r := &Revision{
+		TenantID:   wf.TenantID,
+		WorkflowID: wf.ID,
+		Nodes:      make([]RevisionNode, 0, len(wf.Nodes)),
+		Edges:      make([]RevisionEdge, 0, len(wf.Edges)),
+	}
+	for _, n := range wf.Nodes {
+		r.Nodes = append(r.Nodes, RevisionNode{
+			NodeKey:    n.NodeKey,
+			NodeType:   n.NodeType,
+			OperatorID: n.OperatorID,
+			Config:     n.Config,
+			Position:   n.Position,
+		})
+	}
+	for _, e := range wf.Edges {
+		r.Edges = append(r.Edges, RevisionEdge{
+			SourceKey: e.SourceKey,
+			TargetKey: e.TargetKey,
+			Condition: e.Condition,
+		})
+	}
+	sort.Slice(r.Nodes, func(i, j int) bool { return r.Nodes[i].NodeKey < r.Nodes[j].NodeKey })
+	sort.Slice(r.Edges, func(i, j int) bool { return r.Edges[i].key() < r.Edges[j].key() })
+	r.Hash = r.computeHash()
+	return r
+}
+
+func (r *Revision) computeHash() string {
+	type graph struct {
+		Nodes []RevisionNode `json:"nodes"`
+		Edges []RevisionEdge `json:"edges"`
+	}
+	g := graph{Nodes: make([]RevisionNode, len(r.Nodes)), Edges: r.Edges}
+	for i, n := range r.Nodes {
+		n.Position = nil
+		g.Nodes[i] = n
+	}
+	data, _ := json.Marshal(g)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// WorkflowNodes 修订中的节点，归属于 workflowID
+func (r *Revision) WorkflowNodes() []Node {
+	nodes := make([]Node, 0, len(r.Nodes))
+	for _, n := range r.Nodes {
+		nodes = append(nodes, Node{
+			WorkflowID: r.WorkflowID,
+			NodeKey:    n.NodeKey,
+			NodeType:   n.NodeType,
+			OperatorID: n.OperatorID,
+			Config:     n.Config,
+			Position:   n.Position,
+		})
+	}
+	return nodes
+}
+
+// WorkflowEdges 修订中的连线，归属于 workflowID
+func (r *Revision) WorkflowEdges() []Edge {
+	edges := make([]Edge, 0, len(r.Edges))
+	for _, e := range r.Edges {
+		edges = append(edges, Edge{
+			WorkflowID: r.WorkflowID,
+			SourceKey:  e.SourceKey,
+			TargetKey:  e.TargetKey,
+			Condition:  e.Condition,
+		})
+	}
+	return edges
+}
+
+// Apply 返回以修订中的节点与连线替换后的工作流副本
+func (r *Revision) Apply(wf *Workflow) *Workflow {
+	copied := *wf
+	copied.Nodes = r.WorkflowNodes()
+	copied.Edges = r.WorkflowEdges()
+	copied.Revision = r.Revision
+	copied.RevisionID = &r.ID
+	return &copied
+}
+
+func (e RevisionEdge) key() string {
+	return e.SourceKey + "->" + e.TargetKey
+}
+
+// RevisionDiff 两个修订之间的差异
+type RevisionDiff struct {
+	From         int            `json:"from"`
+	To           int            `json:"to"`
+	AddedNodes   []RevisionNode `json:"added_nodes"`
+	RemovedNodes []RevisionNode `json:"removed_nodes"`
+	ChangedNodes []NodeChange   `json:"changed_nodes"`
+	AddedEdges   []RevisionEdge `json:"added_edges"`
+	RemovedEdges []RevisionEdge `json:"removed_edges"`
+	ChangedEdges []EdgeChange   `json:"changed_edges"`
+}
+
+// NodeChange 同一 node_key 的节点在两个修订中的变化，Fields 为变化的字段
+type NodeChange struct {
+	NodeKey string       `json:"node_key"`
+	Fields  []string     `json:"fields"`
+	Before  RevisionNode `json:"before"`
+	After   RevisionNode `json:"after"`
+}
+
+// EdgeChange 同一对节点之间的连线条件变化
+type EdgeChange struct {
+	SourceKey string       `json:"source_key"`
+	TargetKey string       `json:"target_key"`
+	Before    RevisionEdge `json:"before"`
+	After     RevisionEdge `json:"after"`
+}
+
+// DiffRevisions 比较两个修订，节点按 node_key、连线按起止节点对应
+func DiffRevisions(from, to *Revision) *RevisionDiff {
+	diff := &RevisionDiff{
+		From:         from.Revision,
+		To:           to.Revision,
+		AddedNodes:   []RevisionNode{},
+		RemovedNodes: []RevisionNode{},
+		ChangedNodes: []NodeChange{},
+		AddedEdges:   []RevisionEdge{},
+		RemovedEdges: []RevisionEdge{},
+		ChangedEdges: []EdgeChange{},
+	}
+
+	before := make(map[string]RevisionNode, len(from.Nodes))
+	for _, n := range from.Nodes {
+		before[n.NodeKey] = n
+	}
+	after := make(map[string]bool, len(to.Nodes))
+	for _, n := range to.Nodes {
+		after[n.NodeKey] = true
+		old, ok := before[n.NodeKey]
+		if !ok {
+			diff.AddedNodes = append(diff.AddedNodes, n)
+			continue
+		}
+		if fields := changedNodeFields(old, n); len(fields) > 0 {
+			diff.ChangedNodes = append(diff.ChangedNodes, NodeChange{NodeKey: n.NodeKey, Fields: fields, Before: old, After: n})
+		}
+	}
+	for _, n := range from.Nodes {
+		if !after[n.NodeKey] {
+			diff.RemovedNodes = append(diff.RemovedNodes, n)
+		}
+	}
+
+	beforeEdges := make(map[string]RevisionEdge, len(from.Edges))
+	for _, e := range from.Edges {
+		beforeEdges[e.key()] = e
+	}
+	afterEdges := make(map[string]bool, len(to.Edges))
+	for _, e := range to.Edges {
+		afterEdges[e.key()] = true
+		old, ok := beforeEdges[e.key()]
+		if !ok {
+			diff.AddedEdges = append(diff.AddedEdges, e)
+			continue
+		}
+		if !jsonEqual(old.Condition, e.Condition) {
+			diff.ChangedEdges = append(diff.ChangedEdges, EdgeChange{SourceKey: e.SourceKey, TargetKey: e.TargetKey, Before: old, After: e})
+		}
+	}
+	for _, e := range from.Edges {
+		if !afterEdges[e.key()] {
+			diff.RemovedEdges = append(diff.RemovedEdges, e)
+		}
+	}
+	return diff
+}
+
+// changedNodeFields 返回发生变化的字段；画布位置不参与比较
+func changedNodeFields(a, b RevisionNode) []string {
+	var fields []string
+	if a.NodeType != b.NodeType {
+		fields = append(fields, "node_type")
+	}
+	if !reflect.DeepEqual(a.OperatorID, b.OperatorID) {
+		fields = append(fields, "operator_id")
+	}
+	if !jsonEqual(a.Config, b.Config) {
+		fields = append(fields, "config")
+	}
+	return fields
+}
+
+func jsonEqual(a, b interface{}) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return string(da) == string(db)
+}
@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OverlapPolicy 定时触发时上一次运行（工作流仍有未结束的任务）尚未结束的处理方式
+type OverlapPolicy string
+
+const (
+	// OverlapAllow 照常触发，多次运行可并行
+	OverlapAllow OverlapPolicy = "allow"
+	// OverlapSkip 跳过本次触发
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue 本次触发排队，上一次运行结束后再创建任务
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// ScheduledRun 持久化的待执行定时触发：overlap 为 queue 时因上一次运行未结束而排队的触发，
+// 以及调度器启动时计算出的停机期间错过、需要补跑的触发。
+// 由执行调度作业的领导者按触发时间先后创建任务，重启或领导者切换后不会丢失
+type ScheduledRun struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	WorkflowID  uuid.UUID
+	ScheduledAt time.Time
+	CreatedAt   time.Time
+}
+
+// MisfirePolicy 调度器停机期间错过的定时触发的处理方式
+type MisfirePolicy string
+
+const (
+	// MisfireSkip 丢弃错过的触发
+	MisfireSkip MisfirePolicy = "skip"
+	// MisfireRunOnce 无论错过多少次，启动后补跑一次
+	MisfireRunOnce MisfirePolicy = "run_once"
+	// MisfireCatchUp 按错过的次数逐次补跑，最多 CatchUpLimit 次
+	MisfireCatchUp MisfirePolicy = "catch_up"
+)
+
+const (
+	// DefaultCatchUpLimit catch_up 默认最多补跑次数
+	DefaultCatchUpLimit = 10
+	// MaxCatchUpLimit catch_up_limit 上限
+	MaxCatchUpLimit = 100
+)
+
+// ValidateSchedule 校验定时触发的时区与调度策略
+func (c *TriggerConfig) ValidateSchedule() error {
+	if c == nil {
+		return nil
+	}
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+	switch c.Overlap {
+	case "", OverlapAllow, OverlapSkip, OverlapQueue:
+	default:
+		return fmt.Errorf("trigger_conf.overlap must be allow, skip or queue")
+	}
+	switch c.Misfire {
+	case "", MisfireSkip, MisfireRunOnce, MisfireCatchUp:
+	default:
+		return fmt.Errorf("trigger_conf.misfire must be skip, run_once or catch_up")
+	}
+	if c.CatchUpLimit < 0 || c.CatchUpLimit > MaxCatchUpLimit {
+		return fmt.Errorf("trigger_conf.catch_up_limit must be between 0 and %d", MaxCatchUpLimit)
+	}
+	return nil
+}
+
+// Location cron 表达式使用的时区，未配置时为服务器本地时区
+func (c *TriggerConfig) Location() (*time.Location, error) {
+	if c == nil || c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("trigger_conf.timezone: unknown time zone %s", c.Timezone)
+	}
+	return loc, nil
+}
+
+// OverlapPolicy 未配置时为 allow
+func (c *TriggerConfig) OverlapPolicy() OverlapPolicy {
+	if c == nil || c.Overlap == "" {
+		return OverlapAllow
+	}
+	return c.Overlap
+}
+
+// MisfireRuns 错过 missed 次触发时需要补跑的次数，不超过 MaxCatchUpLimit
+func (c *TriggerConfig) MisfireRuns(missed int) int {
+	if c == nil || missed <= 0 {
+		return 0
+	}
+	switch c.Misfire {
+	case MisfireRunOnce:
+		return 1
+	case MisfireCatchUp:
+		limit := c.CatchUpLimit
+		if limit == 0 {
+			limit = DefaultCatchUpLimit
+		}
+		return min(missed, limit, MaxCatchUpLimit)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// MaxSubWorkflowDepth 子工作流最大嵌套深度
+const MaxSubWorkflowDepth = 8
+
+// SubWorkflowConfig 子工作流节点配置，WorkflowID 与 WorkflowCode 二选一；
+// Version 非空时与被引用工作流的当前版本（Workflow.Version）一致则执行当前的图，
+// 否则按修订号（如 "3"）执行该修订的快照，不受之后对被引用工作流的编辑影响
+type SubWorkflowConfig struct {
+	WorkflowID   *uuid.UUID `json:"workflow_id,omitempty"`
+	WorkflowCode string     `json:"workflow_code,omitempty"`
+	Version      string     `json:"version,omitempty"`
+}
+
+func (c *SubWorkflowConfig) Validate() error {
+	if (c.WorkflowID == nil || *c.WorkflowID == uuid.Nil) && c.WorkflowCode == "" {
+		return errors.New("workflow_id or workflow_code is required")
+	}
+	return nil
+}
+
+// Matches 判断工作流是否为该配置引用的工作流
+func (c *SubWorkflowConfig) Matches(w *Workflow) bool {
+	if c.WorkflowID != nil && *c.WorkflowID != uuid.Nil {
+		return *c.WorkflowID == w.ID
+	}
+	return c.WorkflowCode == w.Code
+}
+
+// ResolveSubWorkflow 加载子工作流节点引用的工作流（含节点与边）；
+// 按修订号引用时返回该修订的图，RevisionID 为该修订，子任务应固定执行它
+func ResolveSubWorkflow(ctx context.Context, repo Repository, revisions RevisionRepository, cfg *SubWorkflowConfig) (*Workflow, error) {
+	var id uuid.UUID
+	if cfg.WorkflowID != nil && *cfg.WorkflowID != uuid.Nil {
+		id = *cfg.WorkflowID
+	} else {
+		wf, err := repo.GetByCode(ctx, cfg.WorkflowCode)
+		if err != nil {
+			return nil, fmt.Errorf("workflow %s not found: %w", cfg.WorkflowCode, err)
+		}
+		id = wf.ID
+	}
+
+	wf, err := repo.GetWithNodes(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("workflow %s not found: %w", id, err)
+	}
+	if cfg.Version == "" || cfg.Version == wf.Version {
+		return wf, nil
+	}
+	number, err := strconv.Atoi(cfg.Version)
+	if err != nil || number <= 0 {
+		return nil, fmt.Errorf("workflow %s version %s not found, current version is %s", wf.Code, cfg.Version, wf.Version)
+	}
+	rev, err := revisions.GetByNumber(ctx, wf.ID, number)
+	if err != nil {
+		return nil, fmt.Errorf("workflow %s revision %d not found: %w", wf.Code, number, err)
+	}
+	return rev.Apply(wf), nil
+}
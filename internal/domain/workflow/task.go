@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,11 +12,20 @@ type TaskStatus string
 const (
 	TaskStatusPending   TaskStatus = "pending"
 	TaskStatusRunning   TaskStatus = "running"
+	TaskStatusWaiting   TaskStatus = "waiting"
 	TaskStatusSuccess   TaskStatus = "success"
 	TaskStatusFailed    TaskStatus = "failed"
 	TaskStatusCancelled TaskStatus = "cancelled"
+	// TaskStatusTimedOut 超过截止时间（任务 deadline 或工作流 max_duration）被终止
+	TaskStatusTimedOut TaskStatus = "timed_out"
 )
 
+// ErrTaskStopped 任务已在执行方之外被取消或判定超时，执行方不得再写回任务状态
+var ErrTaskStopped = errors.New("task was cancelled or timed out")
+
+// ErrLeaseLost 执行方的任务租约已过期并被回收（任务可能已由其他执行方接管），执行方不得再写回任务状态
+var ErrLeaseLost = errors.New("task lease lost")
+
 type NodeExecutionStatus string
 
 const (
@@ -24,8 +34,16 @@ const (
 	NodeExecSuccess NodeExecutionStatus = "success"
 	NodeExecFailed  NodeExecutionStatus = "failed"
 	NodeExecSkipped NodeExecutionStatus = "skipped"
+	NodeExecWaiting NodeExecutionStatus = "waiting"
+	// NodeExecCached 复用了结果缓存，下游按成功处理
+	NodeExecCached NodeExecutionStatus = "cached"
 )
 
+// IsSucceeded 节点是否成功产出结果（执行成功或命中缓存）
+func (s NodeExecutionStatus) IsSucceeded() bool {
+	return s == NodeExecSuccess || s == NodeExecCached
+}
+
 type NodeExecution struct {
 	NodeKey     string              `json:"node_key"`
 	Status      NodeExecutionStatus `json:"status"`
@@ -33,6 +51,26 @@ type NodeExecution struct {
 	StartedAt   *time.Time          `json:"started_at,omitempty"`
 	CompletedAt *time.Time          `json:"completed_at,omitempty"`
 	ArtifactIDs []uuid.UUID         `json:"artifact_ids,omitempty"`
+	Items       []NodeItemExecution `json:"items,omitempty"`
+	ChildTaskID *uuid.UUID          `json:"child_task_id,omitempty"`
+	Approval    *NodeApproval       `json:"approval,omitempty"`
+	Attempts    []NodeAttempt       `json:"attempts,omitempty"`
+	// Progress 运行中节点的完成比例（0~1），由算子上报
+	Progress float64 `json:"progress,omitempty"`
+	// Message 算子上报的最近一条进度说明
+	Message string `json:"message,omitempty"`
+	// Compensation 任务失败或被取消后补偿算子的执行结果
+	Compensation *NodeCompensation `json:"compensation,omitempty"`
+}
+
+// NodeItemExecution 扇出节点中单个元素的执行记录
+type NodeItemExecution struct {
+	Index       int                 `json:"index"`
+	Status      NodeExecutionStatus `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	Attempts    []NodeAttempt       `json:"attempts,omitempty"`
 }
 
 type Task struct {
@@ -40,17 +78,35 @@ type Task struct {
 	TenantID          uuid.UUID
 	TriggeredByUserID *uuid.UUID
 	WorkflowID        uuid.UUID
+	RevisionID        *uuid.UUID           // 执行的工作流修订，开始执行时确定
+	OperatorVersions  map[string]uuid.UUID // 节点 key -> 开始执行时解析的算子版本 ID
 	AssetID           *uuid.UUID
+	CallerTaskID      *uuid.UUID
+	CallerNodeKey     string
+	ParentTaskID      *uuid.UUID // 重跑任务的原任务
+	Priority          int
 	Status            TaskStatus
 	Progress          int
 	CurrentNode       string
 	InputParams       map[string]interface{}
 	Error             string
 	NodeExecutions    []NodeExecution
-	StartedAt         *time.Time
-	CompletedAt       *time.Time
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
+	// Deadline 任务截止时间，到期仍未完成的任务被终止并置为 timed_out
+	Deadline *time.Time
+	// SLABreachedAt 排队或运行时长首次超过工作流 SLA 阈值的时间，每个任务只告警一次
+	SLABreachedAt *time.Time
+	// LeaseOwner/LeaseExpiresAt 由认领任务的 worker 持有并定期续约，只读，Update 不会写回
+	LeaseOwner     string
+	LeaseExpiresAt *time.Time
+	StartedAt      *time.Time
+	CompletedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// IsSubTask 是否为子工作流节点创建的子任务
+func (t *Task) IsSubTask() bool {
+	return t.CallerTaskID != nil
 }
 
 func (t *Task) IsPending() bool {
@@ -61,6 +117,10 @@ func (t *Task) IsRunning() bool {
 	return t.Status == TaskStatusRunning
 }
 
+func (t *Task) IsWaiting() bool {
+	return t.Status == TaskStatusWaiting
+}
+
 func (t *Task) IsSuccess() bool {
 	return t.Status == TaskStatusSuccess
 }
@@ -73,8 +133,13 @@ func (t *Task) IsCancelled() bool {
 	return t.Status == TaskStatusCancelled
 }
 
+func (t *Task) IsTimedOut() bool {
+	return t.Status == TaskStatusTimedOut
+}
+
 func (t *Task) IsCompleted() bool {
-	return t.Status == TaskStatusSuccess || t.Status == TaskStatusFailed || t.Status == TaskStatusCancelled
+	return t.Status == TaskStatusSuccess || t.Status == TaskStatusFailed || t.Status == TaskStatusCancelled ||
+		t.Status == TaskStatusTimedOut
 }
 
 func (t *Task) Start() {
@@ -103,6 +168,26 @@ func (t *Task) Cancel() {
 	t.CompletedAt = &now
 }
 
+func (t *Task) TimeOut(errMsg string) {
+	now := time.Now()
+	t.Status = TaskStatusTimedOut
+	t.Error = errMsg
+	t.CompletedAt = &now
+}
+
+// EffectiveDeadline 任务实际的截止时间：Deadline 与开始执行时间加 maxDuration 中较早者，
+// 均未设置时返回 nil
+func (t *Task) EffectiveDeadline(maxDuration time.Duration) *time.Time {
+	deadline := t.Deadline
+	if maxDuration > 0 && t.StartedAt != nil {
+		d := t.StartedAt.Add(maxDuration)
+		if deadline == nil || d.Before(*deadline) {
+			deadline = &d
+		}
+	}
+	return deadline
+}
+
 func (t *Task) Duration() float64 {
 	if t.StartedAt == nil {
 		return 0
@@ -117,6 +202,8 @@ func (t *Task) Duration() float64 {
 type TaskFilter struct {
 	WorkflowID        *uuid.UUID
 	AssetID           *uuid.UUID
+	CallerTaskID      *uuid.UUID
+	ParentTaskID      *uuid.UUID
 	TriggeredByUserID *uuid.UUID
 	Status            *TaskStatus
 	From              *time.Time
@@ -129,7 +216,10 @@ type TaskStats struct {
 	Total     int64
 	Pending   int64
 	Running   int64
+	Waiting   int64
+	Queued    int64
 	Success   int64
 	Failed    int64
 	Cancelled int64
+	TimedOut  int64
 }
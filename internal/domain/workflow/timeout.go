@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutConfig 工作流任务的时限
+type TimeoutConfig struct {
+	// MaxDurationSec 任务从开始执行起的最长时间（秒，含等待审批），超时的任务被终止并置为 timed_out；0 表示不限
+	MaxDurationSec int `json:"max_duration_sec,omitempty"`
+	// SLA 排队与运行时长的告警阈值
+	SLA *SLAConfig `json:"sla,omitempty"`
+}
+
+func (c *TimeoutConfig) Validate() error {
+	if c.MaxDurationSec < 0 {
+		return errors.New("max_duration_sec must not be negative")
+	}
+	if c.SLA != nil {
+		return c.SLA.Validate()
+	}
+	return nil
+}
+
+// SLAConfig 任务排队与运行时长的告警阈值，超过阈值只告警，不影响任务执行
+type SLAConfig struct {
+	// PendingSeconds 任务创建后等待派发的最长时间
+	PendingSeconds int `json:"pending_seconds,omitempty"`
+	// RunningSeconds 任务开始执行后的最长时间（含等待审批）
+	RunningSeconds int `json:"running_seconds,omitempty"`
+}
+
+func (c *SLAConfig) Validate() error {
+	if c.PendingSeconds < 0 || c.RunningSeconds < 0 {
+		return errors.New("sla thresholds must not be negative")
+	}
+	return nil
+}
+
+// Breach 返回任务超出阈值的说明，未超出时返回空字符串
+func (c *SLAConfig) Breach(t *Task, now time.Time) string {
+	if c == nil {
+		return ""
+	}
+	switch t.Status {
+	case TaskStatusPending:
+		if c.PendingSeconds > 0 && t.StartedAt == nil {
+			return breachMessage("pending", now.Sub(t.CreatedAt), c.PendingSeconds)
+		}
+	case TaskStatusRunning, TaskStatusWaiting:
+		if c.RunningSeconds > 0 && t.StartedAt != nil {
+			return breachMessage(string(t.Status), now.Sub(*t.StartedAt), c.RunningSeconds)
+		}
+	}
+	return ""
+}
+
+func breachMessage(state string, elapsed time.Duration, thresholdSec int) string {
+	threshold := time.Duration(thresholdSec) * time.Second
+	if elapsed <= threshold {
+		return ""
+	}
+	return fmt.Sprintf("task %s for %s, exceeds sla of %s", state, elapsed.Truncate(time.Second), threshold)
+}
@@ -0,0 +1,283 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"goyavision/pkg/expr"
+)
+
+// EventFilterExpression event_filter 中的表达式键，值为 pkg/expr 布尔表达式，变量 event 为事件载荷
+const EventFilterExpression = "expression"
+
+// 事件过滤运算符，用于 event_filter 中值为对象的字段，例如 {"size": {"gte": 1024}}
+const (
+	FilterOpEq       = "eq"
+	FilterOpNe       = "ne"
+	FilterOpIn       = "in"
+	FilterOpNotIn    = "not_in"
+	FilterOpGt       = "gt"
+	FilterOpGte      = "gte"
+	FilterOpLt       = "lt"
+	FilterOpLte      = "lte"
+	FilterOpContains = "contains"
+)
+
+// TriggerChainParam 事件触发任务的输入参数 trigger_chain：经由任务事件依次触发到该任务的上游工作流 ID
+const TriggerChainParam = "trigger_chain"
+
+// MaxTriggerChainDepth 触发链的最大长度，达到后不再触发，避免工作流之间经由任务事件无限循环触发
+const MaxTriggerChainDepth = 8
+
+// TriggerChain 返回任务输入参数中的触发链，不是由任务事件触发的任务返回空
+func (t *Task) TriggerChain() []string {
+	switch chain := t.InputParams[TriggerChainParam].(type) {
+	case []string:
+		return chain
+	case []interface{}:
+		ids := make([]string, 0, len(chain))
+		for _, v := range chain {
+			if id, ok := v.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	return nil
+}
+
+// EventTriggerType 工作流订阅的事件类型：asset_new/asset_done 触发器对应同名事件，
+// event 触发器对应 trigger_conf.event_type，其他触发方式返回空串
+func (w *Workflow) EventTriggerType() string {
+	switch w.TriggerType {
+	case TriggerTypeAssetNew, TriggerTypeAssetDone:
+		return string(w.TriggerType)
+	case TriggerTypeEvent:
+		if w.TriggerConf != nil {
+			return w.TriggerConf.EventType
+		}
+	}
+	return ""
+}
+
+// ValidateEventFilter 校验事件过滤条件的写法
+func (c *TriggerConfig) ValidateEventFilter() error {
+	if c == nil {
+		return nil
+	}
+	for field, cond := range c.EventFilter {
+		if field == EventFilterExpression {
+			s, ok := cond.(string)
+			if !ok {
+				return errors.New("event_filter.expression must be string")
+			}
+			if _, err := expr.Compile(s); err != nil {
+				return fmt.Errorf("event_filter.expression: %w", err)
+			}
+			continue
+		}
+		ops, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(ops) == 0 {
+			return fmt.Errorf("event_filter.%s: operator object is empty", field)
+		}
+		for op, v := range ops {
+			switch op {
+			case FilterOpEq, FilterOpNe, FilterOpContains:
+			case FilterOpIn, FilterOpNotIn:
+				if _, ok := filterList(v); !ok {
+					return fmt.Errorf("event_filter.%s.%s must be array", field, op)
+				}
+			case FilterOpGt, FilterOpGte, FilterOpLt, FilterOpLte:
+				if _, ok := filterNumber(v); !ok {
+					return fmt.Errorf("event_filter.%s.%s must be number", field, op)
+				}
+			default:
+				return fmt.Errorf("event_filter.%s: unknown operator %s", field, op)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchEvent 判断事件载荷是否满足 event_filter，未配置过滤条件时总是匹配。
+//
+// 每个字段的条件须同时满足：
+//   - 标量：载荷字段相等；载荷字段为列表（如 tags）时包含该值
+//   - 数组：载荷字段等于其中任一值；载荷字段为列表时与之有交集
+//   - 对象：运算符 eq/ne/in/not_in/gt/gte/lt/lte/contains 全部成立
+//   - expression：pkg/expr 布尔表达式，例如 "event.size > 1024 && contains(event.tags, \"vip\")"
+//
+// 载荷中不存在的字段只满足 ne 与 not_in。
+func (c *TriggerConfig) MatchEvent(payload map[string]interface{}) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	for field, cond := range c.EventFilter {
+		if field == EventFilterExpression {
+			s, _ := cond.(string)
+			program, err := expr.Compile(s)
+			if err != nil {
+				return false, fmt.Errorf("event_filter.expression: %w", err)
+			}
+			ok, err := program.EvalBool(map[string]interface{}{"event": payload})
+			if err != nil {
+				return false, fmt.Errorf("event_filter.expression: %w", err)
+			}
+			if !ok {
+				return false, nil
+			}
+			continue
+		}
+
+		value, present := payload[field]
+		ok, err := matchFilterCond(value, present, cond)
+		if err != nil {
+			return false, fmt.Errorf("event_filter.%s: %w", field, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchFilterCond(value interface{}, present bool, cond interface{}) (bool, error) {
+	if ops, ok := cond.(map[string]interface{}); ok {
+		for op, want := range ops {
+			ok, err := matchFilterOp(value, present, op, want)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if !present {
+		return false, nil
+	}
+	if list, ok := filterList(cond); ok {
+		return filterAnyOf(value, list), nil
+	}
+	return filterHas(value, cond), nil
+}
+
+func matchFilterOp(value interface{}, present bool, op string, want interface{}) (bool, error) {
+	switch op {
+	case FilterOpNe:
+		return !present || !filterHas(value, want), nil
+	case FilterOpNotIn:
+		list, ok := filterList(want)
+		if !ok {
+			return false, fmt.Errorf("%s must be array", op)
+		}
+		return !present || !filterAnyOf(value, list), nil
+	}
+	if !present {
+		return false, nil
+	}
+
+	switch op {
+	case FilterOpEq:
+		return filterEqual(value, want), nil
+	case FilterOpContains:
+		return filterHas(value, want), nil
+	case FilterOpIn:
+		list, ok := filterList(want)
+		if !ok {
+			return false, fmt.Errorf("%s must be array", op)
+		}
+		return filterAnyOf(value, list), nil
+	case FilterOpGt, FilterOpGte, FilterOpLt, FilterOpLte:
+		bound, ok := filterNumber(want)
+		if !ok {
+			return false, fmt.Errorf("%s must be number", op)
+		}
+		n, ok := filterNumber(value)
+		if !ok {
+			return false, nil
+		}
+		switch op {
+		case FilterOpGt:
+			return n > bound, nil
+		case FilterOpGte:
+			return n >= bound, nil
+		case FilterOpLt:
+			return n < bound, nil
+		default:
+			return n <= bound, nil
+		}
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
+
+// filterHas 载荷值等于 want，或载荷值为列表且包含 want
+func filterHas(value, want interface{}) bool {
+	if list, ok := filterList(value); ok {
+		for _, item := range list {
+			if filterEqual(item, want) {
+				return true
+			}
+		}
+		return false
+	}
+	return filterEqual(value, want)
+}
+
+func filterAnyOf(value interface{}, candidates []interface{}) bool {
+	for _, c := range candidates {
+		if filterHas(value, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func filterEqual(a, b interface{}) bool {
+	if x, ok := filterNumber(a); ok {
+		y, ok := filterNumber(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case nil:
+		return b == nil
+	}
+	return false
+}
+
+func filterList(v interface{}) ([]interface{}, bool) {
+	switch l := v.(type) {
+	case []interface{}:
+		return l, true
+	case []string:
+		out := make([]interface{}, len(l))
+		for i := range l {
+			out[i] = l[i]
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func filterNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
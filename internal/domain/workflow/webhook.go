@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook 请求头
+const (
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderNonce     = "X-Webhook-Nonce"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// 默认时间窗口与旧密钥宽限期
+const (
+	DefaultWebhookToleranceSeconds = 300
+	DefaultWebhookGraceSeconds     = 86400
+)
+
+// MappingVarBody webhook 映射与 media_url 表达式中的请求体变量
+const MappingVarBody = "body"
+
+// ErrWebhookReplay 同一 nonce 在时间窗口内重复出现
+var ErrWebhookReplay = errors.New("webhook nonce already used")
+
+// WebhookTriggerConfig webhook 触发配置（trigger_conf.webhook）
+//
+// 外部系统以 POST /api/v1/webhooks/:id 投递 JSON 请求体，请求须携带时间戳、nonce 与签名（见 WebhookSignature）。
+// Mapping 将请求体映射为任务输入参数，From 为表达式（变量 body），To 为输入参数路径；
+// 未配置映射时请求体（须为 JSON 对象）整体作为输入参数。
+// MediaURL 为可选表达式，求值为 http(s) 地址时先登记 pending 资产并受理请求，
+// 媒体在后台下载完成后资产置为 ready，再以该资产创建任务。
+type WebhookTriggerConfig struct {
+	Mapping   []InputMapping `json:"mapping,omitempty"`
+	MediaURL  string         `json:"media_url,omitempty"`
+	AssetType string         `json:"asset_type,omitempty"`
+	// ToleranceSeconds 请求时间戳与服务端时间的最大偏差，默认 300 秒
+	ToleranceSeconds int `json:"tolerance_seconds,omitempty"`
+}
+
+func (c *WebhookTriggerConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Mapping {
+		if err := c.Mapping[i].Validate(); err != nil {
+			return fmt.Errorf("webhook.mapping: %w", err)
+		}
+	}
+	if c.MediaURL != "" {
+		m := InputMapping{From: c.MediaURL}
+		if _, err := m.Program(); err != nil {
+			return fmt.Errorf("webhook.media_url: %w", err)
+		}
+	}
+	switch c.AssetType {
+	case "", "video", "image", "audio":
+	default:
+		return fmt.Errorf("webhook.asset_type must be video, image or audio")
+	}
+	if c.ToleranceSeconds < 0 {
+		return errors.New("webhook.tolerance_seconds must not be negative")
+	}
+	return nil
+}
+
+// Tolerance 时间戳允许的偏差
+func (c *WebhookTriggerConfig) Tolerance() time.Duration {
+	if c == nil || c.ToleranceSeconds == 0 {
+		return DefaultWebhookToleranceSeconds * time.Second
+	}
+	return time.Duration(c.ToleranceSeconds) * time.Second
+}
+
+// BuildInput 按映射将请求体转换为任务输入参数
+func (c *WebhookTriggerConfig) BuildInput(body interface{}) (map[string]interface{}, error) {
+	if c == nil || len(c.Mapping) == 0 {
+		obj, ok := body.(map[string]interface{})
+		if !ok {
+			if body == nil {
+				return map[string]interface{}{}, nil
+			}
+			return nil, errors.New("request body must be a JSON object when no mapping is configured")
+		}
+		return obj, nil
+	}
+
+	env := map[string]interface{}{MappingVarBody: body}
+	params := make(map[string]interface{})
+	for i := range c.Mapping {
+		mapping := &c.Mapping[i]
+		if err := mapping.Validate(); err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", mapping.To, err)
+		}
+		program, _ := mapping.Program()
+		value, err := program.Eval(env)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", mapping.To, err)
+		}
+		setInputPath(params, mapping.TargetPath(), value)
+	}
+	return params, nil
+}
+
+// ResolveMediaURL 求值 media_url 表达式，未配置或结果为空时返回空串
+func (c *WebhookTriggerConfig) ResolveMediaURL(body interface{}) (string, error) {
+	if c == nil || c.MediaURL == "" {
+		return "", nil
+	}
+	m := InputMapping{From: c.MediaURL}
+	program, err := m.Program()
+	if err != nil {
+		return "", fmt.Errorf("media_url: %w", err)
+	}
+	value, err := program.Eval(map[string]interface{}{MappingVarBody: body})
+	if err != nil {
+		return "", fmt.Errorf("media_url: %w", err)
+	}
+	if value == nil {
+		return "", nil
+	}
+	url, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("media_url must evaluate to a string")
+	}
+	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", fmt.Errorf("media_url must be an http(s) URL")
+	}
+	return url, nil
+}
+
+func setInputPath(params map[string]interface{}, path []string, value interface{}) {
+	current := params
+	for _, key := range path[:len(path)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	current[path[len(path)-1]] = value
+}
+
+// Webhook 工作流的入站 webhook 端点
+//
+// Secret 与 PreviousSecret 为加密后的签名密钥；轮换密钥后旧密钥在 PreviousExpiresAt 之前仍可验签，
+// 便于外部系统平滑切换。
+type Webhook struct {
+	ID                uuid.UUID
+	TenantID          uuid.UUID
+	WorkflowID        uuid.UUID
+	Secret            string
+	PreviousSecret    string
+	PreviousExpiresAt *time.Time
+	// CreatedBy 最近一次创建或轮换密钥的用户，webhook 触发的任务以其身份执行
+	CreatedBy *uuid.UUID
+	RotatedAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PreviousSecretValid 旧密钥是否仍在宽限期内
+func (w *Webhook) PreviousSecretValid(now time.Time) bool {
+	return w.PreviousSecret != "" && w.PreviousExpiresAt != nil && now.Before(*w.PreviousExpiresAt)
+}
+
+// WebhookSignature 计算签名：hex(HMAC-SHA256(secret, timestamp + "." + nonce + "." + body))，
+// 请求头 X-Webhook-Signature 取值为 "sha256=" 加签名
+func WebhookSignature(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 以常量时间比较请求签名（可带 "sha256=" 前缀）
+func VerifyWebhookSignature(secret, timestamp, nonce string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(signature, "sha256=")
+	expected := WebhookSignature(secret, timestamp, nonce, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// WebhookDeliveryStatus 投递结果
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending 已受理，媒体在后台下载，完成后创建任务
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryAccepted 已创建任务
+	WebhookDeliveryAccepted WebhookDeliveryStatus = "accepted"
+	// WebhookDeliveryRejected 鉴权通过但请求体或工作流校验未通过（鉴权失败的请求不写投递记录）
+	WebhookDeliveryRejected WebhookDeliveryStatus = "rejected"
+	// WebhookDeliveryFailed 校验通过但下载媒体或创建任务失败
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery webhook 投递记录
+type WebhookDelivery struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	WebhookID  uuid.UUID
+	WorkflowID uuid.UUID
+	Status     WebhookDeliveryStatus
+	Error      string
+	Nonce      string
+	RemoteAddr string
+	// Body 请求体，超过 WebhookDeliveryBodyLimit 时截断
+	Body       string
+	TaskID     *uuid.UUID
+	AssetID    *uuid.UUID
+	ReceivedAt time.Time
+}
+
+// WebhookDeliveryBodyLimit 投递记录保存的请求体上限（字节）
+const WebhookDeliveryBodyLimit = 4096
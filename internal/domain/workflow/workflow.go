@@ -2,8 +2,11 @@ package workflow
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"goyavision/pkg/expr"
+
 	"github.com/google/uuid"
 )
 
@@ -23,6 +26,22 @@ const (
 	TriggerTypeEvent     TriggerType = "event"
 	TriggerTypeAssetNew  TriggerType = "asset_new"
 	TriggerTypeAssetDone TriggerType = "asset_done"
+	// TriggerTypeWebhook 外部系统以签名请求调用工作流的 webhook 地址触发
+	TriggerTypeWebhook TriggerType = "webhook"
+)
+
+// 节点类型
+const (
+	NodeTypeTrigger  = "trigger"
+	NodeTypeOperator = "operator"
+	// NodeTypeMap 扇出节点：对上游列表的每个元素执行一次节点算子，并聚合输出
+	NodeTypeMap = "map"
+	// NodeTypeSubWorkflow 子工作流节点：以子任务方式执行另一个工作流，子工作流末端节点的输出作为节点输出
+	NodeTypeSubWorkflow = "sub_workflow"
+	// NodeTypeApproval 人工审批节点：任务在此暂停，直到审批人通过或驳回
+	NodeTypeApproval = "approval"
+	// NodeTypeHook 收尾节点：不参与主流程，任务失败、超时或被取消后按 hook.on 执行
+	NodeTypeHook = "hook"
 )
 
 type Visibility int
@@ -43,14 +62,20 @@ type Workflow struct {
 	Name           string
 	Description string
 	Version     string
+	Revision    int        // 当前修订号，0 表示尚无修订
+	RevisionID  *uuid.UUID // 当前修订
 	TriggerType TriggerType
 	TriggerConf *TriggerConfig
 	Status      Status
 	Tags        []string
+	Timeouts    *TimeoutConfig // 任务最长执行时间与 SLA 告警阈值
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Nodes       []Node
 	Edges       []Edge
+	// LastRunAt/NextRunAt 定时触发的上一次与下一次触发时间，由调度器维护
+	LastRunAt *time.Time
+	NextRunAt *time.Time
 }
 
 func (w *Workflow) IsEnabled() bool {
@@ -104,9 +129,30 @@ func (w *Workflow) Validate() error {
 	if w.Name == "" {
 		return errors.New("workflow name is required")
 	}
+	if w.Timeouts != nil {
+		if err := w.Timeouts.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// MaxDuration 任务的最长执行时间，0 表示不限
+func (w *Workflow) MaxDuration() time.Duration {
+	if w.Timeouts == nil {
+		return 0
+	}
+	return time.Duration(w.Timeouts.MaxDurationSec) * time.Second
+}
+
+// SLA 任务时长告警阈值，未配置时为 nil
+func (w *Workflow) SLA() *SLAConfig {
+	if w.Timeouts == nil {
+		return nil
+	}
+	return w.Timeouts.SLA
+}
+
 type Node struct {
 	ID         uuid.UUID
 	WorkflowID uuid.UUID
@@ -119,6 +165,118 @@ type Node struct {
 	UpdatedAt  time.Time
 }
 
+func (n *Node) IsMap() bool {
+	return n.NodeType == NodeTypeMap
+}
+
+// HasInputMappings 节点是否声明了显式输入映射
+func (n *Node) HasInputMappings() bool {
+	return n.Config != nil && len(n.Config.Inputs) > 0
+}
+
+func (n *Node) IsSubWorkflow() bool {
+	return n.NodeType == NodeTypeSubWorkflow
+}
+
+func (n *Node) IsApproval() bool {
+	return n.NodeType == NodeTypeApproval
+}
+
+func (n *Node) IsHook() bool {
+	return n.NodeType == NodeTypeHook
+}
+
+// HasTempArtifacts 节点产物是否为临时产物
+func (n *Node) HasTempArtifacts() bool {
+	return n.Config != nil && n.Config.TempArtifacts
+}
+
+// Validate 校验节点配置
+func (n *Node) Validate() error {
+	if n.NodeKey == "" {
+		return errors.New("node key is required")
+	}
+	if n.Config != nil {
+		for i := range n.Config.Inputs {
+			if err := n.Config.Inputs[i].Validate(); err != nil {
+				return fmt.Errorf("node %s: %w", n.NodeKey, err)
+			}
+		}
+		if n.Config.Cache != nil {
+			if err := n.Config.Cache.Validate(); err != nil {
+				return fmt.Errorf("node %s: %w", n.NodeKey, err)
+			}
+		}
+		if n.Config.ProgressWeight < 0 {
+			return fmt.Errorf("node %s: progress_weight must not be negative", n.NodeKey)
+		}
+		if n.Config.Retry != nil {
+			if err := n.Config.Retry.Validate(); err != nil {
+				return fmt.Errorf("node %s: %w", n.NodeKey, err)
+			}
+		}
+		if n.Config.Compensate != nil {
+			if n.IsHook() {
+				return fmt.Errorf("hook node %s cannot have compensate config", n.NodeKey)
+			}
+			if err := n.Config.Compensate.Validate(); err != nil {
+				return fmt.Errorf("node %s: %w", n.NodeKey, err)
+			}
+		}
+	}
+	if n.IsHook() {
+		if n.OperatorID == nil {
+			return fmt.Errorf("hook node %s requires an operator", n.NodeKey)
+		}
+		if n.Config == nil || n.Config.Hook == nil {
+			return fmt.Errorf("hook node %s requires hook config", n.NodeKey)
+		}
+		if err := n.Config.Hook.Validate(); err != nil {
+			return fmt.Errorf("hook node %s: %w", n.NodeKey, err)
+		}
+	}
+	if n.IsSubWorkflow() {
+		if n.Config == nil || n.Config.SubWorkflow == nil {
+			return fmt.Errorf("sub workflow node %s requires sub_workflow config", n.NodeKey)
+		}
+		if err := n.Config.SubWorkflow.Validate(); err != nil {
+			return fmt.Errorf("sub workflow node %s: %w", n.NodeKey, err)
+		}
+	}
+	if n.IsApproval() {
+		if n.OperatorID != nil {
+			return fmt.Errorf("approval node %s cannot have an operator", n.NodeKey)
+		}
+		if n.Config != nil && n.Config.Approval != nil {
+			if err := n.Config.Approval.Validate(); err != nil {
+				return fmt.Errorf("approval node %s: %w", n.NodeKey, err)
+			}
+		}
+	}
+	if n.IsMap() {
+		if n.OperatorID == nil {
+			return fmt.Errorf("map node %s requires an operator", n.NodeKey)
+		}
+		if n.Config == nil || n.Config.Map == nil {
+			return fmt.Errorf("map node %s requires map config", n.NodeKey)
+		}
+		if err := n.Config.Map.Validate(); err != nil {
+			return fmt.Errorf("map node %s: %w", n.NodeKey, err)
+		}
+		for _, param := range n.Config.Map.ReservedParams() {
+			if _, ok := n.Config.Params[param]; ok {
+				return fmt.Errorf("map node %s: param %s is reserved for the current item", n.NodeKey, param)
+			}
+			for i := range n.Config.Inputs {
+				if path := n.Config.Inputs[i].TargetPath(); len(path) > 0 && path[0] == param {
+					return fmt.Errorf("map node %s: input mapping target %s is reserved for the current item", n.NodeKey, n.Config.Inputs[i].To)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type Edge struct {
 	ID         uuid.UUID
 	WorkflowID uuid.UUID
@@ -143,20 +301,139 @@ type TriggerConfig struct {
 	IntervalSec int                    `json:"interval_sec,omitempty"`
 	EventType   string                 `json:"event_type,omitempty"`
 	EventFilter map[string]interface{} `json:"event_filter,omitempty"`
+	// Webhook trigger_type 为 webhook 时的请求映射与媒体下载配置
+	Webhook *WebhookTriggerConfig `json:"webhook,omitempty"`
+	// Timezone cron 表达式使用的时区（IANA 名称，如 Asia/Shanghai），默认为服务器本地时区
+	Timezone string `json:"timezone,omitempty"`
+	// Overlap 上一次定时运行尚未结束时的处理方式，默认 allow
+	Overlap OverlapPolicy `json:"overlap,omitempty"`
+	// Misfire 调度器停机期间错过的定时触发的处理方式，默认 skip
+	Misfire MisfirePolicy `json:"misfire,omitempty"`
+	// CatchUpLimit misfire 为 catch_up 时最多补跑的次数，默认 DefaultCatchUpLimit
+	CatchUpLimit int `json:"catch_up_limit,omitempty"`
 }
 
 type NodeConfig struct {
 	Params         map[string]interface{} `json:"params,omitempty"`
-	RetryCount     int                    `json:"retry_count,omitempty"`
-	TimeoutSeconds int                    `json:"timeout_seconds,omitempty"`
+	RetryCount     int                    `json:"retry_count,omitempty"` // Deprecated: 兼容字段，建议改用 retry
+	Retry          *RetryPolicy           `json:"retry,omitempty"`
+	TimeoutSeconds int                    `json:"timeout_seconds,omitempty"` // 单次尝试的超时时间
+	Map            *MapConfig             `json:"map,omitempty"`
+	SubWorkflow    *SubWorkflowConfig     `json:"sub_workflow,omitempty"`
+	Inputs         []InputMapping         `json:"inputs,omitempty"`
+	Approval       *ApprovalConfig        `json:"approval,omitempty"`
+	Cache          *NodeCacheConfig       `json:"cache,omitempty"`
+	ProgressWeight float64                `json:"progress_weight,omitempty"` // 节点在任务进度中的权重，默认 1
+	// Hook 收尾节点的触发条件
+	Hook *HookConfig `json:"hook,omitempty"`
+	// Compensate 节点成功后任务失败或被取消时调用的补偿算子
+	Compensate *CompensationConfig `json:"compensate,omitempty"`
+	// TempArtifacts 节点产物为临时产物，任务失败、超时或被取消后自动删除（含存储中的文件），且不写入结果缓存
+	TempArtifacts bool `json:"temp_artifacts,omitempty"`
 }
 
+// GetProgressWeight 节点在任务进度中的权重，未配置时为 1
+func (c *NodeConfig) GetProgressWeight() float64 {
+	if c == nil || c.ProgressWeight <= 0 {
+		return 1
+	}
+	return c.ProgressWeight
+}
+
+const (
+	// DefaultMapItemParam 扇出节点中当前元素的默认参数名
+	DefaultMapItemParam = "item"
+	// MapItemIndexParam 扇出节点中当前元素下标的参数名
+	MapItemIndexParam = "item_index"
+	// MaxMapParallelism 扇出节点最大并行度
+	MaxMapParallelism = 64
+)
+
+// MapConfig 扇出节点配置
+//
+// Items 为节点输入参数中的列表键，通常引用上游输出，如 extract_assets、detect_results；
+// 每个元素以 ItemParam（默认 item）、item_index 注入算子输入，这两个参数名不能再由节点参数或输入映射写入。
+type MapConfig struct {
+	Items           string `json:"items"`
+	ItemParam       string `json:"item_param,omitempty"`
+	Parallelism     int    `json:"parallelism,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty"`
+}
+
+func (m *MapConfig) Validate() error {
+	if m.Items == "" {
+		return errors.New("map items is required")
+	}
+	if m.Parallelism < 0 || m.Parallelism > MaxMapParallelism {
+		return fmt.Errorf("map parallelism must be between 1 and %d", MaxMapParallelism)
+	}
+	if m.ItemParam == MapItemIndexParam {
+		return fmt.Errorf("map item_param must not be %s", MapItemIndexParam)
+	}
+	return nil
+}
+
+// ReservedParams 返回扇出时注入的参数名：当前元素与元素下标
+func (m *MapConfig) ReservedParams() []string {
+	return []string{m.GetItemParam(), MapItemIndexParam}
+}
+
+// GetItemParam 返回当前元素的参数名
+func (m *MapConfig) GetItemParam() string {
+	if m.ItemParam == "" {
+		return DefaultMapItemParam
+	}
+	return m.ItemParam
+}
+
+// GetParallelism 返回并行度，未配置时顺序执行
+func (m *MapConfig) GetParallelism() int {
+	if m.Parallelism <= 0 {
+		return 1
+	}
+	if m.Parallelism > MaxMapParallelism {
+		return MaxMapParallelism
+	}
+	return m.Parallelism
+}
+
+// 边条件类型
+const (
+	EdgeConditionAlways    = "always"
+	EdgeConditionOnSuccess = "on_success"
+	EdgeConditionOnFailure = "on_failure"
+)
+
+// EdgeCondition 边条件
+//
+// Type 基于上游节点状态判断；Expression 为可选的表达式（见 pkg/expr），
+// 以上游节点输出（output_assets、results、timeline、diagnostics）及 Value（变量 value）为环境求值，
+// 两者同时满足时下游节点才会执行。
 type EdgeCondition struct {
 	Type       string                 `json:"type,omitempty"`
 	Expression string                 `json:"expression,omitempty"`
 	Value      map[string]interface{} `json:"value,omitempty"`
 }
 
+// Validate 校验条件类型并编译表达式
+func (c *EdgeCondition) Validate() error {
+	switch c.Type {
+	case "", EdgeConditionAlways, EdgeConditionOnSuccess, EdgeConditionOnFailure:
+	default:
+		return fmt.Errorf("invalid edge condition type: %s", c.Type)
+	}
+	_, err := c.Program()
+	return err
+}
+
+// Program 编译条件表达式，未设置表达式时返回 nil
+func (c *EdgeCondition) Program() (*expr.Program, error) {
+	if c.Expression == "" {
+		return nil, nil
+	}
+	return expr.Compile(c.Expression)
+}
+
 type NodePosition struct {
 	X float64 `json:"x"`
 	Y float64 `json:"y"`
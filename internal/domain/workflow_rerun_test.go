@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"goyavision/internal/domain/workflow"
+)
+
+func TestPlanRerun(t *testing.T) {
+	// extract -> detect -> notify, extract -> archive
+	wf := &workflow.Workflow{
+		Nodes: []workflow.Node{{NodeKey: "extract"}, {NodeKey: "detect"}, {NodeKey: "notify"}, {NodeKey: "archive"}},
+		Edges: []workflow.Edge{
+			{SourceKey: "extract", TargetKey: "detect"},
+			{SourceKey: "detect", TargetKey: "notify"},
+			{SourceKey: "extract", TargetKey: "archive"},
+		},
+	}
+	parent := &workflow.Task{
+		Status: workflow.TaskStatusFailed,
+		NodeExecutions: []workflow.NodeExecution{
+			{NodeKey: "extract", Status: workflow.NodeExecSuccess},
+			{NodeKey: "detect", Status: workflow.NodeExecFailed},
+			{NodeKey: "notify", Status: workflow.NodeExecPending},
+			{NodeKey: "archive", Status: workflow.NodeExecCached},
+		},
+	}
+	all := func(string) bool { return true }
+
+	tests := []struct {
+		name     string
+		mode     workflow.RerunMode
+		fromNode string
+		reusable func(string) bool
+		want     []string
+		wantErr  bool
+	}{
+		{"full", workflow.RerunModeFull, "", all, []string{"archive", "detect", "extract", "notify"}, false},
+		{"failed", workflow.RerunModeFailed, "", all, []string{"detect", "notify"}, false},
+		{"from node", workflow.RerunModeFromNode, "archive", all, []string{"archive", "detect", "notify"}, false},
+		{"from node without checkpoint upstream", workflow.RerunModeFromNode, "archive",
+			func(key string) bool { return key != "extract" }, []string{"archive", "detect", "extract", "notify"}, false},
+		{"from unknown node", workflow.RerunModeFromNode, "missing", all, nil, true},
+		{"from node without key", workflow.RerunModeFromNode, "", all, nil, true},
+		{"invalid mode", workflow.RerunMode("partial"), "", all, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rerun, err := workflow.PlanRerun(wf, parent, tt.mode, tt.fromNode, tt.reusable)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("PlanRerun() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanRerun() error = %v", err)
+			}
+			var got []string
+			for key := range rerun {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanRerun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRerun_FailedModeOnSucceededTask(t *testing.T) {
+	wf := &workflow.Workflow{Nodes: []workflow.Node{{NodeKey: "a"}}}
+	parent := &workflow.Task{
+		Status:         workflow.TaskStatusSuccess,
+		NodeExecutions: []workflow.NodeExecution{{NodeKey: "a", Status: workflow.NodeExecSuccess}},
+	}
+	if _, err := workflow.PlanRerun(wf, parent, workflow.RerunModeFailed, "", func(string) bool { return true }); err == nil {
+		t.Fatal("PlanRerun() error = nil, want error for succeeded task")
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"testing"
+
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+func TestNewRevision_HashIgnoresOrderAndPosition(t *testing.T) {
+	opID := uuid.New()
+	a := &workflow.Workflow{
+		Nodes: []workflow.Node{
+			{NodeKey: "extract", NodeType: "operator", OperatorID: &opID, Position: &workflow.NodePosition{X: 10, Y: 20}},
+			{NodeKey: "notify", NodeType: "end"},
+		},
+		Edges: []workflow.Edge{{SourceKey: "extract", TargetKey: "notify"}},
+	}
+	b := &workflow.Workflow{
+		Nodes: []workflow.Node{
+			{NodeKey: "notify", NodeType: "end"},
+			{NodeKey: "extract", NodeType: "operator", OperatorID: &opID, Position: &workflow.NodePosition{X: 300, Y: 40}},
+		},
+		Edges: []workflow.Edge{{SourceKey: "extract", TargetKey: "notify"}},
+	}
+	if workflow.NewRevision(a).Hash != workflow.NewRevision(b).Hash {
+		t.Error("hash changed with node order or position")
+	}
+
+	b.Nodes[1].Config = &workflow.NodeConfig{TimeoutSeconds: 30}
+	if workflow.NewRevision(a).Hash == workflow.NewRevision(b).Hash {
+		t.Error("hash unchanged after config change")
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	opID := uuid.New()
+	from := workflow.NewRevision(&workflow.Workflow{
+		Nodes: []workflow.Node{
+			{NodeKey: "extract", NodeType: "operator", OperatorID: &opID},
+			{NodeKey: "detect", NodeType: "operator", OperatorID: &opID},
+			{NodeKey: "archive", NodeType: "end"},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "extract", TargetKey: "detect"},
+			{SourceKey: "extract", TargetKey: "archive"},
+		},
+	})
+	from.Revision = 1
+	to := workflow.NewRevision(&workflow.Workflow{
+		Nodes: []workflow.Node{
+			{NodeKey: "extract", NodeType: "operator", OperatorID: &opID, Position: &workflow.NodePosition{X: 1}},
+			{NodeKey: "detect", NodeType: "operator", OperatorID: &opID, Config: &workflow.NodeConfig{RetryCount: 2}},
+			{NodeKey: "notify", NodeType: "end"},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "extract", TargetKey: "detect", Condition: &workflow.EdgeCondition{Type: "on_success"}},
+			{SourceKey: "detect", TargetKey: "notify"},
+		},
+	})
+	to.Revision = 2
+
+	diff := workflow.DiffRevisions(from, to)
+	if diff.From != 1 || diff.To != 2 {
+		t.Errorf("diff revisions = %d..%d, want 1..2", diff.From, diff.To)
+	}
+	if len(diff.AddedNodes) != 1 || diff.AddedNodes[0].NodeKey != "notify" {
+		t.Errorf("added nodes = %+v, want notify", diff.AddedNodes)
+	}
+	if len(diff.RemovedNodes) != 1 || diff.RemovedNodes[0].NodeKey != "archive" {
+		t.Errorf("removed nodes = %+v, want archive", diff.RemovedNodes)
+	}
+	// Moving extract on the canvas is not a change
+	if len(diff.ChangedNodes) != 1 || diff.ChangedNodes[0].NodeKey != "detect" ||
+		len(diff.ChangedNodes[0].Fields) != 1 || diff.ChangedNodes[0].Fields[0] != "config" {
+		t.Errorf("changed nodes = %+v, want detect config", diff.ChangedNodes)
+	}
+	if len(diff.AddedEdges) != 1 || diff.AddedEdges[0].TargetKey != "notify" {
+		t.Errorf("added edges = %+v, want detect->notify", diff.AddedEdges)
+	}
+	if len(diff.RemovedEdges) != 1 || diff.RemovedEdges[0].TargetKey != "archive" {
+		t.Errorf("removed edges = %+v, want extract->archive", diff.RemovedEdges)
+	}
+	if len(diff.ChangedEdges) != 1 || diff.ChangedEdges[0].TargetKey != "detect" {
+		t.Errorf("changed edges = %+v, want extract->detect", diff.ChangedEdges)
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+
+	"goyavision/internal/domain/workflow"
+)
+
+func TestTriggerConfigValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		tc      *workflow.TriggerConfig
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"defaults", &workflow.TriggerConfig{Schedule: "0 * * * *"}, false},
+		{"full", &workflow.TriggerConfig{Timezone: "Asia/Shanghai", Overlap: workflow.OverlapQueue, Misfire: workflow.MisfireCatchUp, CatchUpLimit: 3}, false},
+		{"unknown timezone", &workflow.TriggerConfig{Timezone: "Mars/Olympus"}, true},
+		{"unknown overlap", &workflow.TriggerConfig{Overlap: "wait"}, true},
+		{"unknown misfire", &workflow.TriggerConfig{Misfire: "all"}, true},
+		{"negative catch up", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp, CatchUpLimit: -1}, true},
+		{"catch up above max", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp, CatchUpLimit: workflow.MaxCatchUpLimit + 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tc.ValidateSchedule(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTriggerConfigMisfireRuns(t *testing.T) {
+	tests := []struct {
+		name   string
+		tc     *workflow.TriggerConfig
+		missed int
+		want   int
+	}{
+		{"default skips", &workflow.TriggerConfig{}, 5, 0},
+		{"skip", &workflow.TriggerConfig{Misfire: workflow.MisfireSkip}, 5, 0},
+		{"run once", &workflow.TriggerConfig{Misfire: workflow.MisfireRunOnce}, 5, 1},
+		{"nothing missed", &workflow.TriggerConfig{Misfire: workflow.MisfireRunOnce}, 0, 0},
+		{"catch up all", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp, CatchUpLimit: 10}, 4, 4},
+		{"catch up limited", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp, CatchUpLimit: 3}, 8, 3},
+		{"catch up default limit", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp}, 100, workflow.DefaultCatchUpLimit},
+		{"catch up stored limit above max", &workflow.TriggerConfig{Misfire: workflow.MisfireCatchUp, CatchUpLimit: 1 << 30}, 1 << 20, workflow.MaxCatchUpLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tc.MisfireRuns(tt.missed); got != tt.want {
+				t.Errorf("MisfireRuns(%d) = %d, want %d", tt.missed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggerConfigOverlapPolicy(t *testing.T) {
+	var nilConf *workflow.TriggerConfig
+	if got := nilConf.OverlapPolicy(); got != workflow.OverlapAllow {
+		t.Errorf("nil OverlapPolicy() = %q, want allow", got)
+	}
+	tc := &workflow.TriggerConfig{Overlap: workflow.OverlapSkip}
+	if got := tc.OverlapPolicy(); got != workflow.OverlapSkip {
+		t.Errorf("OverlapPolicy() = %q, want skip", got)
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"goyavision/internal/domain/workflow"
+)
+
+func TestTaskEffectiveDeadline(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	early := start.Add(5 * time.Minute)
+	late := start.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		task        *workflow.Task
+		maxDuration time.Duration
+		want        *time.Time
+	}{
+		{"none", &workflow.Task{StartedAt: &start}, 0, nil},
+		{"deadline only", &workflow.Task{Deadline: &late}, 0, &late},
+		{"max duration not started", &workflow.Task{}, 10 * time.Minute, nil},
+		{"max duration", &workflow.Task{StartedAt: &start}, 5 * time.Minute, &early},
+		{"deadline earlier", &workflow.Task{StartedAt: &start, Deadline: &early}, time.Hour, &early},
+		{"max duration earlier", &workflow.Task{StartedAt: &start, Deadline: &late}, 5 * time.Minute, &early},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.task.EffectiveDeadline(tt.maxDuration)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("EffectiveDeadline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSLABreach(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	twoMinAgo := now.Add(-2 * time.Minute)
+	sla := &workflow.SLAConfig{PendingSeconds: 60, RunningSeconds: 300}
+
+	tests := []struct {
+		name   string
+		sla    *workflow.SLAConfig
+		task   *workflow.Task
+		breach bool
+	}{
+		{"no sla", nil, &workflow.Task{Status: workflow.TaskStatusPending, CreatedAt: twoMinAgo}, false},
+		{"pending too long", sla, &workflow.Task{Status: workflow.TaskStatusPending, CreatedAt: twoMinAgo}, true},
+		{"pending within", sla, &workflow.Task{Status: workflow.TaskStatusPending, CreatedAt: now.Add(-30 * time.Second)}, false},
+		{"running within", sla, &workflow.Task{Status: workflow.TaskStatusRunning, CreatedAt: twoMinAgo, StartedAt: &twoMinAgo}, false},
+		{"waiting too long", &workflow.SLAConfig{RunningSeconds: 60}, &workflow.Task{Status: workflow.TaskStatusWaiting, StartedAt: &twoMinAgo}, true},
+		{"finished", sla, &workflow.Task{Status: workflow.TaskStatusSuccess, CreatedAt: twoMinAgo}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sla.Breach(tt.task, now)
+			if (got != "") != tt.breach {
+				t.Errorf("Breach() = %q, want breach %v", got, tt.breach)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+
+	"goyavision/internal/domain/workflow"
+)
+
+func TestTriggerConfigMatchEvent(t *testing.T) {
+	payload := map[string]interface{}{
+		"type":      "video",
+		"format":    "mp4",
+		"size":      float64(4096),
+		"source_id": "cam-1",
+		"tags":      []interface{}{"vip", "outdoor"},
+	}
+
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+		want   bool
+	}{
+		{"no filter", nil, true},
+		{"scalar equal", map[string]interface{}{"type": "video"}, true},
+		{"scalar mismatch", map[string]interface{}{"type": "image"}, false},
+		{"missing field", map[string]interface{}{"duration": 10.0}, false},
+		{"any of", map[string]interface{}{"format": []interface{}{"mkv", "mp4"}}, true},
+		{"tag contained", map[string]interface{}{"tags": "vip"}, true},
+		{"tags intersect", map[string]interface{}{"tags": []interface{}{"indoor", "outdoor"}}, true},
+		{"tags disjoint", map[string]interface{}{"tags": []interface{}{"indoor"}}, false},
+		{"size range", map[string]interface{}{"size": map[string]interface{}{"gte": 1024.0, "lt": 8192.0}}, true},
+		{"size too small", map[string]interface{}{"size": map[string]interface{}{"gt": 4096.0}}, false},
+		{"ne missing field", map[string]interface{}{"duration": map[string]interface{}{"ne": 0.0}}, true},
+		{"not in", map[string]interface{}{"source_id": map[string]interface{}{"not_in": []interface{}{"cam-1"}}}, false},
+		{"all fields", map[string]interface{}{"type": "video", "source_id": "cam-1"}, true},
+		{"expression", map[string]interface{}{"expression": `event.size > 1024 && contains(event.tags, "vip")`}, true},
+		{"expression false", map[string]interface{}{"expression": `event.format == "mkv"`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &workflow.TriggerConfig{EventFilter: tt.filter}
+			got, err := tc.MatchEvent(payload)
+			if err != nil {
+				t.Fatalf("MatchEvent() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggerConfigValidateEventFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  map[string]interface{}
+		wantErr bool
+	}{
+		{"valid", map[string]interface{}{"type": "video", "size": map[string]interface{}{"gte": 1.0}}, false},
+		{"unknown operator", map[string]interface{}{"size": map[string]interface{}{"between": 1.0}}, true},
+		{"range not number", map[string]interface{}{"size": map[string]interface{}{"gt": "big"}}, true},
+		{"in not array", map[string]interface{}{"type": map[string]interface{}{"in": "video"}}, true},
+		{"bad expression", map[string]interface{}{"expression": "event.size >"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &workflow.TriggerConfig{EventFilter: tt.filter}
+			if err := tc.ValidateEventFilter(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEventFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowEventTriggerType(t *testing.T) {
+	tests := []struct {
+		wf   *workflow.Workflow
+		want string
+	}{
+		{&workflow.Workflow{TriggerType: workflow.TriggerTypeAssetNew}, "asset_new"},
+		{&workflow.Workflow{TriggerType: workflow.TriggerTypeEvent, TriggerConf: &workflow.TriggerConfig{EventType: "task_status"}}, "task_status"},
+		{&workflow.Workflow{TriggerType: workflow.TriggerTypeEvent}, ""},
+		{&workflow.Workflow{TriggerType: workflow.TriggerTypeManual}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.wf.EventTriggerType(); got != tt.want {
+			t.Errorf("EventTriggerType() = %q, want %q", got, tt.want)
+		}
+	}
+}
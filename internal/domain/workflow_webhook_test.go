@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"goyavision/internal/domain/workflow"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"camera":"cam-1"}`)
+	sig := workflow.WebhookSignature("secret", "1700000000", "n-1", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		nonce     string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", "1700000000", "n-1", body, sig, true},
+		{"valid with prefix", "secret", "1700000000", "n-1", body, "sha256=" + sig, true},
+		{"wrong secret", "other", "1700000000", "n-1", body, sig, false},
+		{"timestamp changed", "secret", "1700000001", "n-1", body, sig, false},
+		{"nonce changed", "secret", "1700000000", "n-2", body, sig, false},
+		{"body changed", "secret", "1700000000", "n-1", []byte(`{"camera":"cam-2"}`), sig, false},
+		{"empty signature", "secret", "1700000000", "n-1", body, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := workflow.VerifyWebhookSignature(tt.secret, tt.timestamp, tt.nonce, tt.body, tt.signature)
+			if got != tt.want {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookTriggerConfigBuildInput(t *testing.T) {
+	body := map[string]interface{}{
+		"camera": "cam-1",
+		"event":  map[string]interface{}{"score": 0.9},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *workflow.WebhookTriggerConfig
+		body    interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"no mapping", nil, body, body, false},
+		{"empty body", &workflow.WebhookTriggerConfig{}, nil, map[string]interface{}{}, false},
+		{"non-object body", &workflow.WebhookTriggerConfig{}, []interface{}{1.0}, nil, true},
+		{
+			"mapping",
+			&workflow.WebhookTriggerConfig{Mapping: []workflow.InputMapping{
+				{From: "body.camera", To: "source"},
+				{From: "body.event.score", To: "options.threshold"},
+			}},
+			body,
+			map[string]interface{}{
+				"source":  "cam-1",
+				"options": map[string]interface{}{"threshold": 0.9},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.BuildInput(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookTriggerConfigResolveMediaURL(t *testing.T) {
+	cfg := &workflow.WebhookTriggerConfig{MediaURL: "body.url"}
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		want    string
+		wantErr bool
+	}{
+		{"http url", map[string]interface{}{"url": "https://cdn.example.com/a.mp4"}, "https://cdn.example.com/a.mp4", false},
+		{"missing", map[string]interface{}{}, "", false},
+		{"not http", map[string]interface{}{"url": "file:///etc/passwd"}, "", true},
+		{"not string", map[string]interface{}{"url": 1.0}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ResolveMediaURL(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveMediaURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveMediaURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookPreviousSecretValid(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		wh   workflow.Webhook
+		want bool
+	}{
+		{"within grace", workflow.Webhook{PreviousSecret: "old", PreviousExpiresAt: &future}, true},
+		{"expired", workflow.Webhook{PreviousSecret: "old", PreviousExpiresAt: &past}, false},
+		{"no previous", workflow.Webhook{PreviousExpiresAt: &future}, false},
+		{"no expiry", workflow.Webhook{PreviousSecret: "old"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.wh.PreviousSecretValid(now); got != tt.want {
+				t.Errorf("PreviousSecretValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"goyavision/internal/domain/operator"
+
+	"github.com/google/uuid"
+)
+
+// AsyncCallbackPath is the route that serves job result callbacks from asynchronous
+// HTTP operators
+const AsyncCallbackPath = "/api/v1/callbacks/operator"
+
+// asyncCallbackBodyLimit caps the size of a callback body, which carries the operator output
+const asyncCallbackBodyLimit = 16 << 20
+
+// ErrCallbackNotAwaited is returned when a callback targets an operator call that is not
+// waiting in this process
+var ErrCallbackNotAwaited = errors.New("callback is not awaited")
+
+// asyncCallbacks holds the operator calls of this process waiting for a callback, keyed
+// by call ID
+type asyncCallbacks struct {
+	mu      sync.Mutex
+	waiters map[uuid.UUID]*asyncWaiter
+}
+
+// asyncWaiter receives the callbacks of one operator call; done is closed once the call
+// stops waiting
+type asyncWaiter struct {
+	ch   chan *operator.AsyncCallbackRequest
+	done chan struct{}
+}
+
+func (c *asyncCallbacks) register(callID uuid.UUID) *asyncWaiter {
+	w := &asyncWaiter{ch: make(chan *operator.AsyncCallbackRequest), done: make(chan struct{})}
+	c.mu.Lock()
+	if c.waiters == nil {
+		c.waiters = make(map[uuid.UUID]*asyncWaiter)
+	}
+	c.waiters[callID] = w
+	c.mu.Unlock()
+	return w
+}
+
+func (c *asyncCallbacks) release(callID uuid.UUID) {
+	c.mu.Lock()
+	if w, ok := c.waiters[callID]; ok {
+		close(w.done)
+		delete(c.waiters, callID)
+	}
+	c.mu.Unlock()
+}
+
+func (c *asyncCallbacks) get(callID uuid.UUID) (*asyncWaiter, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w, ok := c.waiters[callID]
+	return w, ok
+}
+
+// withAsyncCallback lets asynchronous HTTP operators of a node register a signed callback
+// URL. The URL is only handed out when progress callbacks are enabled with a base URL.
+func (e *DAGWorkflowEngine) withAsyncCallback(ctx context.Context, taskID uuid.UUID, nodeKey string) context.Context {
+	if e.progressCallback == nil || e.progressCallback.baseURL == "" {
+		return ctx
+	}
+	return operator.WithAsyncCallbackRegistrar(ctx, func() (string, <-chan *operator.AsyncCallbackRequest, func()) {
+		callID := uuid.New()
+		waiter := e.asyncCallbacks.register(callID)
+
+		q := url.Values{}
+		q.Set("task_id", taskID.String())
+		q.Set("node_key", nodeKey)
+		q.Set("call_id", callID.String())
+		q.Set("token", e.asyncCallbackToken(taskID, nodeKey, callID))
+		u := e.progressCallback.baseURL + AsyncCallbackPath + "?" + q.Encode()
+		return u, waiter.ch, func() { e.asyncCallbacks.release(callID) }
+	})
+}
+
+// AsyncCallbackHandler serves POST AsyncCallbackPath?task_id=&node_key=&call_id=&token=
+// with an operator.AsyncJobStatus body. The waiting executor validates the body; a
+// rejected callback is answered with 400.
+func (e *DAGWorkflowEngine) AsyncCallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		taskID, err := uuid.Parse(q.Get("task_id"))
+		nodeKey := q.Get("node_key")
+		callID, callErr := uuid.Parse(q.Get("call_id"))
+		if err != nil || callErr != nil || nodeKey == "" {
+			http.Error(w, "invalid task_id, node_key or call_id", http.StatusBadRequest)
+			return
+		}
+		if !e.verifyAsyncCallbackToken(taskID, nodeKey, callID, q.Get("token")) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, asyncCallbackBodyLimit))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		waiter, ok := e.asyncCallbacks.get(callID)
+		if !ok {
+			http.Error(w, ErrCallbackNotAwaited.Error(), http.StatusNotFound)
+			return
+		}
+		req := operator.NewAsyncCallbackRequest(r.Header, body)
+		select {
+		case waiter.ch <- req:
+		case <-waiter.done:
+			http.Error(w, ErrCallbackNotAwaited.Error(), http.StatusNotFound)
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if err := req.Wait(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (e *DAGWorkflowEngine) asyncCallbackToken(taskID uuid.UUID, nodeKey string, callID uuid.UUID) string {
+	mac := hmac.New(sha256.New, e.progressCallback.secret)
+	mac.Write([]byte("async/" + taskID.String() + "/" + nodeKey + "/" + callID.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *DAGWorkflowEngine) verifyAsyncCallbackToken(taskID uuid.UUID, nodeKey string, callID uuid.UUID, token string) bool {
+	if e.progressCallback == nil || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(e.asyncCallbackToken(taskID, nodeKey, callID)), []byte(token))
+}
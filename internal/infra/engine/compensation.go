@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+// compensationTimeout bounds the whole cleanup of a failed or cancelled task
+const compensationTimeout = 10 * time.Minute
+
+// SetArtifactStorage lets the engine delete the files of temporary asset artifacts.
+// Without a storage only the artifact records are deleted.
+func (e *DAGWorkflowEngine) SetArtifactStorage(storage port.FileStorage) {
+	e.artifactStorage = storage
+}
+
+// compensate cleans up after a task that ended failed, timed out or cancelled:
+// it runs the compensation operators of succeeded nodes in reverse completion
+// order, then the hook nodes handling the outcome, and finally deletes the
+// temporary artifacts of the task. Cleanup is best effort and never changes
+// the task status; results are recorded in the node executions.
+func (e *DAGWorkflowEngine) compensate(ctx context.Context, wf *workflow.Workflow, task *workflow.Task, exec *taskExecution) {
+	outcome, ok := workflow.HookEventOf(task.Status)
+	if !ok || exec == nil {
+		return
+	}
+
+	// The task context is usually cancelled by now; cleanup must still run
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+
+	taskCtx := compensationTaskContext(task, exec)
+	compensated := e.compensateNodes(ctx, wf, task, exec, taskCtx)
+	hooked := e.runHooks(ctx, wf, task, exec, outcome, taskCtx)
+	if compensated || hooked {
+		e.saveNodeExecutions(ctx, task, exec)
+	}
+	e.deleteTempArtifacts(ctx, task)
+}
+
+// compensationTaskContext describes the ended task to compensation operators and hooks
+func compensationTaskContext(task *workflow.Task, exec *taskExecution) map[string]interface{} {
+	exec.mu.RLock()
+	failed := make([]string, 0)
+	for key, ne := range exec.nodeExecutions {
+		if ne.Status == workflow.NodeExecFailed {
+			failed = append(failed, key)
+		}
+	}
+	exec.mu.RUnlock()
+	sort.Strings(failed)
+
+	taskCtx := map[string]interface{}{
+		"id":           task.ID.String(),
+		"workflow_id":  task.WorkflowID.String(),
+		"status":       string(task.Status),
+		"error":        task.Error,
+		"failed_nodes": failed,
+	}
+	if task.AssetID != nil {
+		taskCtx["asset_id"] = task.AssetID.String()
+	}
+	return taskCtx
+}
+
+// compensateNodes calls the compensation operator of every node that succeeded
+// in this execution, most recently completed first. It reports whether any ran.
+func (e *DAGWorkflowEngine) compensateNodes(
+	ctx context.Context,
+	wf *workflow.Workflow,
+	task *workflow.Task,
+	exec *taskExecution,
+	taskCtx map[string]interface{},
+) bool {
+	type succeeded struct {
+		node      *workflow.Node
+		execution *workflow.NodeExecution
+		output    *operator.Output
+	}
+
+	exec.mu.RLock()
+	var nodes []succeeded
+	for i := range wf.Nodes {
+		node := &wf.Nodes[i]
+		if node.Config == nil || node.Config.Compensate == nil {
+			continue
+		}
+		// Cached nodes reused an earlier result and have nothing to undo
+		ne, ok := exec.nodeExecutions[node.NodeKey]
+		if !ok || ne.Status != workflow.NodeExecSuccess || ne.Compensation != nil {
+			continue
+		}
+		nodes = append(nodes, succeeded{node: node, execution: ne, output: exec.nodeResults[node.NodeKey]})
+	}
+	exec.mu.RUnlock()
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].execution.CompletedAt, nodes[j].execution.CompletedAt
+		return a != nil && b != nil && a.After(*b)
+	})
+
+	for _, n := range nodes {
+		startedAt := time.Now()
+		err := e.runCompensation(ctx, task, n.node, n.execution, n.output, taskCtx)
+		completedAt := time.Now()
+
+		comp := &workflow.NodeCompensation{
+			Status:      workflow.NodeExecSuccess,
+			StartedAt:   &startedAt,
+			CompletedAt: &completedAt,
+		}
+		if err != nil {
+			comp.Status = workflow.NodeExecFailed
+			comp.Error = err.Error()
+		}
+		exec.mu.Lock()
+		n.execution.Compensation = comp
+		exec.mu.Unlock()
+	}
+	return len(nodes) > 0
+}
+
+// runCompensation executes the compensation operator of a node with the node's
+// output and the task context
+func (e *DAGWorkflowEngine) runCompensation(
+	ctx context.Context,
+	task *workflow.Task,
+	node *workflow.Node,
+	execution *workflow.NodeExecution,
+	output *operator.Output,
+	taskCtx map[string]interface{},
+) error {
+	cfg := node.Config.Compensate
+
+	var op *operator.Operator
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		op, err = repos.Operators.GetWithActiveVersion(ctx, cfg.OperatorID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get compensation operator: %w", err)
+	}
+	if op.ActiveVersion == nil {
+		return fmt.Errorf("compensation operator %s has no active version", op.Code)
+	}
+
+	input := &operator.Input{Params: make(map[string]interface{}, len(cfg.Params)+4)}
+	if task.AssetID != nil {
+		input.AssetID = *task.AssetID
+	}
+	for k, v := range cfg.Params {
+		input.Params[k] = v
+	}
+	input.Params["task"] = taskCtx
+	input.Params["node_key"] = node.NodeKey
+	input.Params["node_output"] = output
+	input.Params["node_artifact_ids"] = execution.ArtifactIDs
+
+	compNode := &workflow.Node{
+		NodeKey:    node.NodeKey + ".compensate",
+		OperatorID: &cfg.OperatorID,
+		Config:     &workflow.NodeConfig{TimeoutSeconds: cfg.TimeoutSeconds},
+	}
+	_, err = e.executeOperator(ctx, compNode, op, input, func(workflow.NodeAttempt) {})
+	return err
+}
+
+// runHooks executes the hook nodes handling the task outcome in node order.
+// It reports whether any ran.
+func (e *DAGWorkflowEngine) runHooks(
+	ctx context.Context,
+	wf *workflow.Workflow,
+	task *workflow.Task,
+	exec *taskExecution,
+	outcome workflow.HookEvent,
+	taskCtx map[string]interface{},
+) bool {
+	hooks := wf.HookNodes(outcome)
+	for i := range hooks {
+		node := &hooks[i]
+
+		startedAt := time.Now()
+		exec.mu.Lock()
+		exec.nodeExecutions[node.NodeKey] = &workflow.NodeExecution{
+			NodeKey:   node.NodeKey,
+			Status:    workflow.NodeExecRunning,
+			StartedAt: &startedAt,
+		}
+		exec.mu.Unlock()
+		e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeStarted, node.NodeKey)
+
+		artifactIDs, err := e.runHook(ctx, node, task, exec, taskCtx)
+
+		exec.mu.Lock()
+		ne := exec.nodeExecutions[node.NodeKey]
+		completedAt := time.Now()
+		ne.CompletedAt = &completedAt
+		ne.ArtifactIDs = artifactIDs
+		ne.Status = workflow.NodeExecSuccess
+		if err != nil {
+			ne.Status = workflow.NodeExecFailed
+			ne.Error = err.Error()
+		}
+		exec.mu.Unlock()
+		e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, node.NodeKey)
+	}
+	return len(hooks) > 0
+}
+
+// runHook executes a hook node. Besides the usual task input and node params, the
+// hook receives the task context as "task" and the partial outputs of every node
+// that produced one as "<key>_output", "<key>_assets", "<key>_results" and "<key>_timeline".
+func (e *DAGWorkflowEngine) runHook(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	exec *taskExecution,
+	taskCtx map[string]interface{},
+) ([]uuid.UUID, error) {
+	var op *operator.Operator
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		op, err = repos.Operators.GetWithActiveVersion(ctx, *node.OperatorID)
+		if err != nil {
+			return err
+		}
+		op, err = e.resolveOperatorVersion(ctx, repos, task, node.NodeKey, op)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operator: %w", err)
+	}
+	if op.ActiveVersion == nil {
+		return nil, fmt.Errorf("operator %s has no active version", op.Code)
+	}
+
+	input, err := e.prepareNodeInput(task, node, exec)
+	if err != nil {
+		return nil, err
+	}
+	exec.mu.RLock()
+	for nodeKey, output := range exec.nodeResults {
+		if output == nil {
+			continue
+		}
+		input.Params[nodeKey+"_output"] = output
+		if len(output.OutputAssets) > 0 {
+			input.Params[nodeKey+"_assets"] = output.OutputAssets
+		}
+		if len(output.Results) > 0 {
+			input.Params[nodeKey+"_results"] = output.Results
+		}
+		if len(output.Timeline) > 0 {
+			input.Params[nodeKey+"_timeline"] = output.Timeline
+		}
+	}
+	exec.mu.RUnlock()
+	input.Params["task"] = taskCtx
+
+	output, err := e.executeOperator(ctx, node, op, input, func(a workflow.NodeAttempt) {
+		exec.mu.Lock()
+		if ne, ok := exec.nodeExecutions[node.NodeKey]; ok {
+			ne.Attempts = append(ne.Attempts, a)
+		}
+		exec.mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.saveArtifactsWithIDs(ctx, task, node, output)
+}
+
+// saveNodeExecutions persists the node executions of an ended task without
+// touching its status or progress
+func (e *DAGWorkflowEngine) saveNodeExecutions(ctx context.Context, task *workflow.Task, exec *taskExecution) {
+	exec.mu.RLock()
+	executions := make([]workflow.NodeExecution, 0, len(exec.nodeExecutions))
+	for _, ne := range exec.nodeExecutions {
+		executions = append(executions, *ne)
+	}
+	exec.mu.RUnlock()
+
+	task.NodeExecutions = executions
+	_ = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		return repos.Tasks.Update(ctx, &workflow.Task{ID: task.ID, NodeExecutions: executions})
+	})
+}
+
+// deleteTempArtifacts deletes the temporary artifacts of the task together with
+// their files. Artifacts whose file cannot be deleted are kept so that they
+// remain visible.
+func (e *DAGWorkflowEngine) deleteTempArtifacts(ctx context.Context, task *workflow.Task) {
+	var artifacts []*workflow.Artifact
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		artifacts, err = repos.Artifacts.ListByTask(ctx, task.ID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	for _, a := range artifacts {
+		if !a.Temporary {
+			continue
+		}
+		if e.artifactStorage != nil && a.Data != nil && a.Data.AssetInfo != nil && a.Data.AssetInfo.Path != "" {
+			if err := e.artifactStorage.Delete(ctx, a.Data.AssetInfo.Path); err != nil {
+				continue
+			}
+		}
+		_ = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+			return repos.Artifacts.Delete(ctx, a.ID)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// recordingArtifactRepo keeps created artifacts so that cleanup can be observed
+type recordingArtifactRepo struct {
+	stubArtifactRepo
+	mu        sync.Mutex
+	artifacts map[uuid.UUID]*workflow.Artifact
+}
+
+func (s *recordingArtifactRepo) Create(ctx context.Context, a *workflow.Artifact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artifacts[a.ID] = a
+	return nil
+}
+func (s *recordingArtifactRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.artifacts[id]; ok {
+		return a, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (s *recordingArtifactRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.artifacts, id)
+	return nil
+}
+func (s *recordingArtifactRepo) ListByTask(ctx context.Context, taskID uuid.UUID) ([]*workflow.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*workflow.Artifact
+	for _, a := range s.artifacts {
+		if a.TaskID == taskID {
+			list = append(list, a)
+		}
+	}
+	return list, nil
+}
+
+type stubFileStorage struct {
+	deleted []string
+}
+
+func (s *stubFileStorage) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+	return objectName, nil
+}
+func (s *stubFileStorage) Delete(ctx context.Context, objectName string) error {
+	s.deleted = append(s.deleted, objectName)
+	return nil
+}
+func (s *stubFileStorage) GetPublicURL(objectName string) string { return objectName }
+
+func findNodeExecution(task *workflow.Task, nodeKey string) *workflow.NodeExecution {
+	for i := range task.NodeExecutions {
+		if task.NodeExecutions[i].NodeKey == nodeKey {
+			return &task.NodeExecutions[i]
+		}
+	}
+	return nil
+}
+
+func TestExecute_CompensationOnFailure(t *testing.T) {
+	repos := newTestRepos()
+	artifacts := &recordingArtifactRepo{artifacts: make(map[uuid.UUID]*workflow.Artifact)}
+	repos.Artifacts = artifacts
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = repos
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor := new(MockOperatorExecutor)
+	storage := &stubFileStorage{}
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.SetArtifactStorage(storage)
+
+	opID := uuid.New()
+	compensateID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{NodeKey: "upload", OperatorID: &opID, Config: &workflow.NodeConfig{
+				TempArtifacts: true,
+				Compensate:    &workflow.CompensationConfig{OperatorID: compensateID, Params: map[string]interface{}{"action": "stop"}},
+			}},
+			{NodeKey: "detect", OperatorID: &opID, Config: &workflow.NodeConfig{
+				Params: map[string]interface{}{"fail": true},
+			}},
+			{NodeKey: "notify", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+				Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnFailure}},
+			}},
+			{NodeKey: "on_cancel", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+				Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnCancel}},
+			}},
+		},
+		Edges: []workflow.Edge{{SourceKey: "upload", TargetKey: "detect"}},
+	}
+
+	isCompensation := func(in *operator.Input) bool { return in.Params["node_key"] != nil }
+	isHook := func(in *operator.Input) bool { return in.Params["task"] != nil && in.Params["node_key"] == nil }
+	var compInput, hookInput *operator.Input
+
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(isCompensation)).Run(func(args mock.Arguments) {
+		compInput = args.Get(2).(*operator.Input)
+	}).Return(&operator.Output{}, nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(isHook)).Run(func(args mock.Arguments) {
+		hookInput = args.Get(2).(*operator.Input)
+	}).Return(&operator.Output{}, nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(in *operator.Input) bool {
+		return in.Params["fail"] == true && !isHook(in)
+	})).Return(nil, errors.New("detector crashed"))
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(in *operator.Input) bool {
+		return in.Params["fail"] == nil && !isHook(in) && !isCompensation(in)
+	})).Return(&operator.Output{
+		OutputAssets: []operator.OutputAsset{{Type: "video", Path: "tmp/clip.mp4"}},
+	}, nil)
+
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.Error(t, err)
+	assert.Equal(t, workflow.TaskStatusFailed, task.Status)
+	assert.Len(t, mockExecutor.Calls, 4)
+
+	if assert.NotNil(t, compInput) {
+		assert.Equal(t, "upload", compInput.Params["node_key"])
+		assert.Equal(t, "stop", compInput.Params["action"])
+		assert.NotNil(t, compInput.Params["node_output"])
+	}
+	if assert.NotNil(t, hookInput) {
+		taskCtx := hookInput.Params["task"].(map[string]interface{})
+		assert.Equal(t, "failed", taskCtx["status"])
+		assert.Equal(t, []string{"detect"}, taskCtx["failed_nodes"])
+		assert.NotNil(t, hookInput.Params["upload_output"])
+	}
+
+	upload := findNodeExecution(task, "upload")
+	if assert.NotNil(t, upload) && assert.NotNil(t, upload.Compensation) {
+		assert.Equal(t, workflow.NodeExecSuccess, upload.Compensation.Status)
+	}
+	notify := findNodeExecution(task, "notify")
+	if assert.NotNil(t, notify) {
+		assert.Equal(t, workflow.NodeExecSuccess, notify.Status)
+	}
+	assert.Nil(t, findNodeExecution(task, "on_cancel"))
+
+	// the temporary clip is deleted with its file
+	assert.Empty(t, artifacts.artifacts)
+	assert.Equal(t, []string{"tmp/clip.mp4"}, storage.deleted)
+}
+
+func TestExecute_HooksDoNotRunOnSuccess(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor := new(MockOperatorExecutor)
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{NodeKey: "a", OperatorID: &opID},
+			{NodeKey: "cleanup", NodeType: workflow.NodeTypeHook, OperatorID: &opID, Config: &workflow.NodeConfig{
+				Hook: &workflow.HookConfig{On: []workflow.HookEvent{workflow.HookOnFailure, workflow.HookOnCancel}},
+			}},
+		},
+	}
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{}, nil)
+
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, task))
+	assert.Equal(t, workflow.TaskStatusSuccess, task.Status)
+	assert.Len(t, mockExecutor.Calls, 1)
+	assert.Nil(t, findNodeExecution(task, "cleanup"))
+}
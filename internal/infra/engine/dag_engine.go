@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
+	"goyavision/internal/api/middleware"
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
@@ -17,23 +20,44 @@ import (
 
 var _ workflow.Engine = (*DAGWorkflowEngine)(nil)
 
+// errTaskWaiting stops execution when a node waits for an external decision.
+// The task is persisted as waiting and continued later through Resume.
+var errTaskWaiting = errors.New("task is waiting for approval")
+
+// errTaskTimedOut is the cancellation cause of tasks that exceed their deadline
+var errTaskTimedOut = errors.New("task exceeded its deadline")
+
 // DAGWorkflowEngine implements parallel DAG execution with topological sorting
 type DAGWorkflowEngine struct {
-	uow             port.UnitOfWork
-	executor        workflow.OperatorExecutor
-	schemaValidator port.SchemaValidator
-	tasks           map[uuid.UUID]*taskExecution
-	mu              sync.RWMutex
+	uow              port.UnitOfWork
+	executor         workflow.OperatorExecutor
+	schemaValidator  port.SchemaValidator
+	operatorLimiter  *operatorLimiter
+	resultCache      *resultCacheSettings
+	progressCallback *progressCallbackSettings
+	asyncCallbacks   asyncCallbacks
+	eventBus         port.EventBus
+	artifactStorage  port.FileStorage
+	tasks            map[uuid.UUID]*taskExecution
+	mu               sync.RWMutex
 }
 
 type taskExecution struct {
-	ctx            context.Context
-	cancel         context.CancelFunc
-	progress       int
-	currentNode    string
-	nodeResults    map[string]*operator.Output
-	nodeExecutions map[string]*workflow.NodeExecution
-	mu             sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	task     *workflow.Task
+	progress int
+	// weights maps each node key to its weight in the task progress
+	weights          map[string]float64
+	lastProgressSync time.Time
+	currentNode      string
+	nodeResults      map[string]*operator.Output
+	nodeExecutions   map[string]*workflow.NodeExecution
+	// ancestors maps each node key to the keys of all its upstream nodes
+	ancestors map[string]map[string]bool
+	mu        sync.RWMutex
+	// syncMu serializes task updates from concurrently running nodes and map items
+	syncMu sync.Mutex
 }
 
 // NewDAGWorkflowEngine creates a new DAG workflow engine
@@ -51,17 +75,73 @@ func NewDAGWorkflowEngine(uow port.UnitOfWork, executor workflow.OperatorExecuto
 	}
 }
 
-// Execute executes a workflow using DAG topology with parallel execution
+// SetOperatorLimits caps the number of concurrent calls per operator code,
+// e.g. {"object_detection": 4}. Map items and parallel nodes share the same slots,
+// and the caps apply across all engines sharing the database.
+func (e *DAGWorkflowEngine) SetOperatorLimits(limits map[string]int) {
+	e.operatorLimiter = newOperatorLimiter(e.uow, limits)
+}
+
+// Execute executes a workflow using DAG topology with parallel execution.
+// It returns nil when the task pauses at an approval node.
 func (e *DAGWorkflowEngine) Execute(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	_, err := e.run(ctx, wf, task, nil)
+	if errors.Is(err, errTaskWaiting) {
+		return nil
+	}
+	return err
+}
+
+// Resume continues an interrupted task from its persisted checkpoints.
+// Completed nodes are restored instead of re-executed, so execution picks up
+// at the first unfinished layer. Waiting tasks are resumed the same way once
+// their approval nodes have been decided or have timed out.
+func (e *DAGWorkflowEngine) Resume(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	var checkpoints []*workflow.TaskCheckpoint
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		checkpoints, err = repos.TaskCheckpoints.ListByTask(ctx, task.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoints: %w", err)
+	}
+
+	_, err = e.run(ctx, wf, task, checkpoints)
+	if errors.Is(err, errTaskWaiting) {
+		return nil
+	}
+	return err
+}
+
+// run executes the workflow layer by layer and returns the execution state,
+// which holds the outputs of all completed nodes
+func (e *DAGWorkflowEngine) run(ctx context.Context, wf *workflow.Workflow, task *workflow.Task, checkpoints []*workflow.TaskCheckpoint) (*taskExecution, error) {
 	if len(wf.Nodes) == 0 {
-		return errors.New("workflow has no nodes")
+		return nil, errors.New("workflow has no nodes")
+	}
+
+	ctx = withTaskIdentity(ctx, task)
+
+	// Build execution layers from DAG; hook nodes only run once the task has failed
+	layers, err := e.buildExecutionLayers(wf.FlowNodes(), wf.Edges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build execution layers: %w", err)
 	}
 
-	// Build execution layers from DAG
-	layers, err := e.buildExecutionLayers(wf.Nodes, wf.Edges)
+	// Execute the revision the task is pinned to, with the operator versions
+	// resolved when it first started; both are persisted with the running status
+	pinned, err := e.pinRevision(ctx, wf, task)
 	if err != nil {
-		return fmt.Errorf("failed to build execution layers: %w", err)
+		return nil, err
+	}
+	if pinned != wf {
+		wf = pinned
+		if layers, err = e.buildExecutionLayers(wf.FlowNodes(), wf.Edges); err != nil {
+			return nil, fmt.Errorf("failed to build execution layers: %w", err)
+		}
 	}
+	e.pinOperatorVersions(ctx, wf, task)
 
 	// Create node map for quick lookup
 	nodeMap := make(map[string]*workflow.Node)
@@ -70,26 +150,31 @@ func (e *DAGWorkflowEngine) Execute(ctx context.Context, wf *workflow.Workflow,
 	}
 
 	// Setup execution context
-	execCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	execCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
-	exec := &taskExecution{
-		ctx:            execCtx,
-		cancel:         cancel,
-		progress:       0,
-		nodeResults:    make(map[string]*operator.Output),
-		nodeExecutions: make(map[string]*workflow.NodeExecution),
+	// Bound the task by its deadline and the workflow's max duration, counted
+	// from when the task first started so that resuming does not extend it
+	if task.StartedAt == nil {
+		now := time.Now()
+		task.StartedAt = &now
 	}
-
-	// Initialize node executions
-	for _, node := range wf.Nodes {
-		exec.nodeExecutions[node.NodeKey] = &workflow.NodeExecution{
-			NodeKey: node.NodeKey,
-			Status:  workflow.NodeExecPending,
-		}
+	if deadline := task.EffectiveDeadline(wf.MaxDuration()); deadline != nil {
+		var cancelDeadline context.CancelFunc
+		cause := fmt.Errorf("%w %s", errTaskTimedOut, deadline.Format(time.RFC3339))
+		execCtx, cancelDeadline = context.WithDeadlineCause(execCtx, *deadline, cause)
+		defer cancelDeadline()
 	}
 
+	exec := newTaskExecution(wf, task, checkpoints)
+	exec.ctx = execCtx
+	exec.cancel = cancel
+
 	e.mu.Lock()
+	if _, running := e.tasks[task.ID]; running {
+		e.mu.Unlock()
+		return nil, errors.New("task is already running")
+	}
 	e.tasks[task.ID] = exec
 	e.mu.Unlock()
 
@@ -101,58 +186,271 @@ func (e *DAGWorkflowEngine) Execute(ctx context.Context, wf *workflow.Workflow,
 
 	// Update task status to running
 	err = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
-		now := time.Now()
+		if task.StartedAt == nil {
+			now := time.Now()
+			task.StartedAt = &now
+		}
 		task.Status = workflow.TaskStatusRunning
-		task.StartedAt = &now
-		task.Progress = 0
-		return repos.Tasks.Update(ctx, task)
+		task.Progress = exec.progress
+		return e.saveTask(ctx, repos, task)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+		if execCtx.Err() != nil {
+			return exec, e.interrupted(ctx, execCtx, wf, task, exec)
+		}
+		return exec, fmt.Errorf("failed to update task status: %w", err)
 	}
+	e.publishTaskStatus(ctx, task)
 
 	// Execute layers sequentially, nodes within layer in parallel
-	totalLayers := len(layers)
 	for i, layer := range layers {
 		select {
 		case <-execCtx.Done():
-			e.updateTaskStatus(ctx, task, workflow.TaskStatusCancelled, "execution cancelled")
-			return execCtx.Err()
+			return exec, e.interrupted(ctx, execCtx, wf, task, exec)
 		default:
 		}
 
+		if e.isLayerCompleted(layer, exec) {
+			continue
+		}
+
 		if err := e.executeLayer(execCtx, layer, nodeMap, wf.Edges, task, exec); err != nil {
-			e.updateTaskStatus(ctx, task, workflow.TaskStatusFailed, err.Error())
-			return fmt.Errorf("layer %d execution failed: %w", i+1, err)
+			// Nodes interrupted by cancellation report errors too; keep the task cancelled
+			if execCtx.Err() != nil {
+				return exec, e.interrupted(ctx, execCtx, wf, task, exec)
+			}
+			if errors.Is(err, errTaskWaiting) {
+				if updateErr := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+					task.Status = workflow.TaskStatusWaiting
+					return e.saveTask(ctx, repos, task)
+				}); updateErr != nil {
+					if execCtx.Err() != nil {
+						return exec, e.interrupted(ctx, execCtx, wf, task, exec)
+					}
+					return exec, fmt.Errorf("failed to update task status: %w", updateErr)
+				}
+				e.publishTaskStatus(ctx, task)
+				return exec, errTaskWaiting
+			}
+			if e.updateTaskStatus(ctx, task, workflow.TaskStatusFailed, err.Error()) != nil && execCtx.Err() != nil {
+				return exec, e.interrupted(ctx, execCtx, wf, task, exec)
+			}
+			e.compensate(ctx, wf, task, exec)
+			return exec, fmt.Errorf("layer %d execution failed: %w", i+1, err)
 		}
 
 		// Update progress
-		progress := int((float64(i+1) / float64(totalLayers)) * 100)
 		exec.mu.Lock()
-		exec.progress = progress
+		exec.progress = taskProgress(exec)
+		progress := exec.progress
 		exec.mu.Unlock()
 
 		e.updateTaskProgress(ctx, task, progress)
 	}
 
 	// Update task to success
-	return e.updateTaskStatus(ctx, task, workflow.TaskStatusSuccess, "")
+	if err := e.updateTaskStatus(ctx, task, workflow.TaskStatusSuccess, ""); err != nil {
+		if execCtx.Err() != nil {
+			return exec, e.interrupted(ctx, execCtx, wf, task, exec)
+		}
+		return exec, err
+	}
+	return exec, nil
+}
+
+// withTaskIdentity lets background runs (schedules, events, resume), which
+// carry no request identity, act on behalf of the task's tenant and triggering user
+func withTaskIdentity(ctx context.Context, task *workflow.Task) context.Context {
+	if _, ok := middleware.GetTenantID(ctx); !ok && task.TenantID != uuid.Nil {
+		var userID uuid.UUID
+		if task.TriggeredByUserID != nil {
+			userID = *task.TriggeredByUserID
+		}
+		ctx = middleware.ContextWithIdentity(ctx, task.TenantID, userID)
+	}
+	return ctx
+}
+
+// newTaskExecution builds the execution state of a task, restoring completed
+// nodes from its checkpoints and the links of unfinished nodes from the task
+func newTaskExecution(wf *workflow.Workflow, task *workflow.Task, checkpoints []*workflow.TaskCheckpoint) *taskExecution {
+	exec := &taskExecution{
+		task:           task,
+		progress:       0,
+		weights:        make(map[string]float64, len(wf.Nodes)),
+		nodeResults:    make(map[string]*operator.Output),
+		nodeExecutions: make(map[string]*workflow.NodeExecution),
+		ancestors:      workflow.Ancestors(wf.Edges),
+	}
+
+	// Initialize node executions
+	for _, node := range wf.FlowNodes() {
+		exec.nodeExecutions[node.NodeKey] = &workflow.NodeExecution{
+			NodeKey: node.NodeKey,
+			Status:  workflow.NodeExecPending,
+		}
+		exec.weights[node.NodeKey] = node.Config.GetProgressWeight()
+	}
+
+	// Restore completed nodes from checkpoints
+	known := make(map[string]bool, len(wf.Nodes))
+	for _, node := range wf.Nodes {
+		known[node.NodeKey] = true
+	}
+	for _, cp := range checkpoints {
+		if !known[cp.NodeKey] || !cp.IsCompleted() {
+			continue
+		}
+		execution := cp.Execution
+		exec.nodeExecutions[cp.NodeKey] = &execution
+		if cp.Output != nil {
+			exec.nodeResults[cp.NodeKey] = cp.Output
+		}
+	}
+
+	// Keep links to child tasks of unfinished sub workflow nodes so that
+	// resuming the task resumes those children instead of starting new ones,
+	// and keep the wait state and decisions of unfinished approval nodes
+	for _, ne := range task.NodeExecutions {
+		if ne.ChildTaskID == nil && ne.Approval == nil {
+			continue
+		}
+		if execNode, ok := exec.nodeExecutions[ne.NodeKey]; ok && execNode.Status == workflow.NodeExecPending {
+			execNode.ChildTaskID = ne.ChildTaskID
+			execNode.Approval = ne.Approval
+			execNode.StartedAt = ne.StartedAt
+		}
+	}
+
+	exec.progress = taskProgress(exec)
+	return exec
+}
+
+// interrupted records why the execution context of a task ended: timed out
+// when the task's deadline passed, cancelled otherwise. A task stopped outside
+// the engine keeps the status it was given there. It then runs the cleanup of
+// the task and returns the cause. An execution abandoned after losing its lease
+// neither writes the task nor runs the cleanup.
+func (e *DAGWorkflowEngine) interrupted(ctx context.Context, execCtx context.Context, wf *workflow.Workflow, task *workflow.Task, exec *taskExecution) error {
+	err := execCtx.Err()
+	cause := context.Cause(execCtx)
+	if errors.Is(cause, workflow.ErrLeaseLost) {
+		// The task may already run elsewhere; leave its status and cleanup to the new owner
+		return cause
+	}
+	var updateErr error
+	if errors.Is(cause, errTaskTimedOut) {
+		updateErr = e.updateTaskStatus(ctx, task, workflow.TaskStatusTimedOut, cause.Error())
+		err = cause
+	} else {
+		updateErr = e.updateTaskStatus(ctx, task, workflow.TaskStatusCancelled, "execution cancelled")
+	}
+	if errors.Is(updateErr, workflow.ErrTaskStopped) {
+		e.reloadTaskStatus(ctx, task)
+	}
+	e.compensate(ctx, wf, task, exec)
+	return err
 }
 
-// Cancel cancels a running workflow execution
+// Cancel cancels a running workflow execution.
+// Child tasks of sub workflow nodes run under the parent's context and are cancelled with it.
 func (e *DAGWorkflowEngine) Cancel(ctx context.Context, taskID uuid.UUID) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	if !e.stop(taskID, context.Canceled) {
+		return errors.New("task is not running")
+	}
+	return nil
+}
 
-	exec, ok := e.tasks[taskID]
-	if !ok {
+// Abandon stops a running workflow execution whose task lease has been lost.
+// The execution ends without writing the task or running its cleanup hooks.
+func (e *DAGWorkflowEngine) Abandon(ctx context.Context, taskID uuid.UUID) error {
+	if !e.stop(taskID, workflow.ErrLeaseLost) {
 		return errors.New("task is not running")
 	}
+	return nil
+}
+
+// Cleanup runs the compensation and hook nodes of a task that was cancelled
+// or timed out while no engine was executing it, e.g. while waiting for an
+// approval. Completed nodes are restored from the task's checkpoints.
+func (e *DAGWorkflowEngine) Cleanup(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) error {
+	if _, ok := workflow.HookEventOf(task.Status); !ok {
+		return fmt.Errorf("task %s is %s, nothing to clean up", task.ID, task.Status)
+	}
+	e.mu.RLock()
+	_, running := e.tasks[task.ID]
+	e.mu.RUnlock()
+	if running {
+		return errors.New("task is running")
+	}
+
+	ctx = withTaskIdentity(ctx, task)
+	pinned, err := e.pinRevision(ctx, wf, task)
+	if err != nil {
+		return err
+	}
+	var checkpoints []*workflow.TaskCheckpoint
+	err = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		checkpoints, err = repos.TaskCheckpoints.ListByTask(ctx, task.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoints: %w", err)
+	}
 
-	exec.cancel()
+	e.compensate(ctx, pinned, task, newTaskExecution(pinned, task, checkpoints))
 	return nil
 }
 
+// stop cancels the execution context of a running task with the given cause
+// and reports whether the task is running in this engine
+func (e *DAGWorkflowEngine) stop(taskID uuid.UUID, cause error) bool {
+	e.mu.RLock()
+	exec, ok := e.tasks[taskID]
+	e.mu.RUnlock()
+	if ok {
+		exec.cancel(cause)
+	}
+	return ok
+}
+
+// fence checks within the current transaction that the task has not been
+// stopped outside the engine, e.g. cancelled through the API, and that a
+// claimed task is still leased to the claiming dispatcher. A stopped task has
+// its execution cancelled, so that it ends with the status set there; a task
+// whose lease was lost is abandoned.
+func (e *DAGWorkflowEngine) fence(ctx context.Context, repos *port.Repositories, task *workflow.Task) error {
+	err := repos.Tasks.Fence(ctx, task.ID, task.LeaseOwner)
+	if errors.Is(err, workflow.ErrTaskStopped) || errors.Is(err, workflow.ErrLeaseLost) {
+		e.stop(task.ID, err)
+	}
+	return err
+}
+
+// saveTask writes the task unless it has been stopped outside the engine
+func (e *DAGWorkflowEngine) saveTask(ctx context.Context, repos *port.Repositories, task *workflow.Task) error {
+	if err := e.fence(ctx, repos, task); err != nil {
+		return err
+	}
+	return repos.Tasks.Update(ctx, task)
+}
+
+// reloadTaskStatus replaces the status of a task stopped outside the engine
+// with the persisted one
+func (e *DAGWorkflowEngine) reloadTaskStatus(ctx context.Context, task *workflow.Task) {
+	_ = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		stored, err := repos.Tasks.Get(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		task.Status = stored.Status
+		task.Error = stored.Error
+		task.CompletedAt = stored.CompletedAt
+		return nil
+	})
+}
+
 // GetProgress returns the current execution progress
 func (e *DAGWorkflowEngine) GetProgress(ctx context.Context, taskID uuid.UUID) (int, error) {
 	e.mu.RLock()
@@ -295,9 +593,18 @@ func (e *DAGWorkflowEngine) executeLayer(
 
 	// Check conditions for all nodes in layer first
 	nodesToExecute := []string{}
+	var skipped []string
 	for _, nodeKey := range layer {
+		// Nodes restored from checkpoints are not executed again
+		if e.isNodeCompleted(nodeKey, exec) {
+			continue
+		}
 		node := nodeMap[nodeKey]
-		if e.shouldExecuteNode(node, edges, exec) {
+		shouldExecute, err := e.shouldExecuteNode(node, edges, exec)
+		if err != nil {
+			return e.failNode(ctx, task, exec, nodeKey, err)
+		}
+		if shouldExecute {
 			nodesToExecute = append(nodesToExecute, nodeKey)
 		} else {
 			// Mark as skipped
@@ -306,14 +613,21 @@ func (e *DAGWorkflowEngine) executeLayer(
 				execNode.Status = workflow.NodeExecSkipped
 			}
 			exec.mu.Unlock()
+			if err := e.saveCheckpoint(ctx, task, exec, nodeKey, nil); err != nil {
+				return err
+			}
+			skipped = append(skipped, nodeKey)
 		}
 	}
-	
+
 	// Sync skipped status
-	if len(nodesToExecute) < len(layer) {
+	if len(skipped) > 0 {
 		if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
 			return err
 		}
+		for _, nodeKey := range skipped {
+			e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, nodeKey)
+		}
 	}
 
 	if len(nodesToExecute) == 0 {
@@ -342,8 +656,14 @@ func (e *DAGWorkflowEngine) executeLayer(
 	wg.Wait()
 	close(errChan)
 
+	// Failures take precedence over nodes that are waiting for approval
 	var errs []error
+	waiting := false
 	for err := range errChan {
+		if errors.Is(err, errTaskWaiting) {
+			waiting = true
+			continue
+		}
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -351,6 +671,9 @@ func (e *DAGWorkflowEngine) executeLayer(
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
+	if waiting {
+		return errTaskWaiting
+	}
 	return nil
 }
 
@@ -368,6 +691,9 @@ func (e *DAGWorkflowEngine) executeNode(
 		now := time.Now()
 		execNode.Status = workflow.NodeExecRunning
 		execNode.StartedAt = &now
+		execNode.Attempts = nil
+		execNode.Progress = 0
+		execNode.Message = ""
 	}
 	exec.mu.Unlock()
 
@@ -375,6 +701,24 @@ func (e *DAGWorkflowEngine) executeNode(
 	if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
 		return err
 	}
+	e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeStarted, node.NodeKey)
+
+	if node.IsSubWorkflow() || node.IsApproval() {
+		var output *operator.Output
+		var err error
+		if node.IsSubWorkflow() {
+			output, err = e.executeSubWorkflow(ctx, node, task, exec)
+		} else {
+			output, err = e.executeApproval(ctx, node, task, exec)
+		}
+		if errors.Is(err, errTaskWaiting) {
+			return e.waitNode(ctx, task, exec, node.NodeKey)
+		}
+		if err != nil {
+			return e.failNode(ctx, task, exec, node.NodeKey, err)
+		}
+		return e.completeNode(ctx, node, task, exec, output)
+	}
 
 	// Skip if no operator
 	if node.OperatorID == nil {
@@ -386,7 +730,14 @@ func (e *DAGWorkflowEngine) executeNode(
 			execNode.CompletedAt = &now
 		}
 		exec.mu.Unlock()
-		return e.syncTaskNodeExecutions(ctx, task, exec)
+		if err := e.saveCheckpoint(ctx, task, exec, node.NodeKey, nil); err != nil {
+			return err
+		}
+		if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
+			return err
+		}
+		e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, node.NodeKey)
+		return nil
 	}
 
 	// Get operator using UnitOfWork
@@ -394,6 +745,10 @@ func (e *DAGWorkflowEngine) executeNode(
 	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		var err error
 		op, err = repos.Operators.GetWithActiveVersion(ctx, *node.OperatorID)
+		if err != nil {
+			return err
+		}
+		op, err = e.resolveOperatorVersion(ctx, repos, task, node.NodeKey, op)
 		return err
 	})
 	if err != nil {
@@ -404,153 +759,878 @@ func (e *DAGWorkflowEngine) executeNode(
 		return e.failNode(ctx, task, exec, node.NodeKey, fmt.Errorf("operator %s has no active version", op.Code))
 	}
 
-	// Prepare input (merge task input + node config + previous outputs)
-	input := e.prepareNodeInput(task, node, exec)
-	if err := e.validateNodeInput(ctx, op.ActiveVersion, input); err != nil {
+	// Prepare input (merge task input + node config + upstream outputs)
+	input, err := e.prepareNodeInput(task, node, exec)
+	if err != nil {
 		return e.failNode(ctx, task, exec, node.NodeKey, err)
 	}
 
-	// Apply timeout if configured
-	nodeCtx := ctx
-	if node.Config != nil && node.Config.TimeoutSeconds > 0 {
-		var cancel context.CancelFunc
-		nodeCtx, cancel = context.WithTimeout(ctx, time.Duration(node.Config.TimeoutSeconds)*time.Second)
-		defer cancel()
+	cacheKey := e.nodeCacheKey(node, input)
+	if cacheKey != "" {
+		if entry := e.lookupNodeCache(ctx, op.ActiveVersion.ID, cacheKey); entry != nil {
+			if artifactIDs, ok := e.copyCachedArtifacts(ctx, node, task, entry); ok {
+				return e.finishNode(ctx, task, exec, node.NodeKey, entry.Output, workflow.NodeExecCached, artifactIDs)
+			}
+		}
 	}
 
-	// Execute operator with retry logic
 	var output *operator.Output
-	retryCount := 1
-	if node.Config != nil && node.Config.RetryCount > 0 {
-		retryCount = node.Config.RetryCount + 1
+	if node.IsMap() {
+		output, err = e.executeMapNode(ctx, node, op, input, task, exec)
+	} else {
+		opCtx := e.withNodeProgress(ctx, task, exec, node.NodeKey)
+		output, err = e.executeOperator(opCtx, node, op, input, func(a workflow.NodeAttempt) {
+			e.recordNodeAttempt(ctx, task, exec, node.NodeKey, a)
+		})
 	}
-
-	var lastErr error
-	for attempt := 0; attempt < retryCount; attempt++ {
-		output, lastErr = e.executor.Execute(nodeCtx, op.ActiveVersion, input)
-		if lastErr == nil {
-			break
-		}
-		if attempt < retryCount-1 {
-			// Wait before retry (exponential backoff)
-			time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
-		}
+	if err != nil {
+		return e.failNode(ctx, task, exec, node.NodeKey, err)
 	}
 
-	if lastErr != nil {
-		return e.failNode(ctx, task, exec, node.NodeKey, fmt.Errorf("node %s failed after %d attempts: %w", node.NodeKey, retryCount, lastErr))
+	if err := e.completeNode(ctx, node, task, exec, output); err != nil {
+		return err
 	}
-
-	if err := e.validateNodeOutput(nodeCtx, op.ActiveVersion, output); err != nil {
-		return e.failNode(ctx, task, exec, node.NodeKey, err)
+	if cacheKey != "" {
+		e.storeNodeCache(ctx, node, task, exec, op.ActiveVersion.ID, cacheKey, output)
 	}
+	return nil
+}
 
-	// Store output for downstream nodes
-	exec.mu.Lock()
-	exec.nodeResults[node.NodeKey] = output
-	exec.mu.Unlock()
-
+// completeNode saves the node artifacts and marks the node as succeeded
+func (e *DAGWorkflowEngine) completeNode(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	exec *taskExecution,
+	output *operator.Output,
+) error {
 	// Save artifacts
 	var artifactIDs []uuid.UUID
 	if output != nil {
-		artifactIDs, err = e.saveArtifactsWithIDs(ctx, task.ID, node.NodeKey, output)
+		var err error
+		artifactIDs, err = e.saveArtifactsWithIDs(ctx, task, node, output)
 		if err != nil {
 			return e.failNode(ctx, task, exec, node.NodeKey, fmt.Errorf("failed to save artifacts: %w", err))
 		}
 	}
 
-	// Mark as success
+	return e.finishNode(ctx, task, exec, node.NodeKey, output, workflow.NodeExecSuccess, artifactIDs)
+}
+
+// finishNode stores the node output for downstream nodes, marks the node with the
+// given succeeded status and checkpoints it
+func (e *DAGWorkflowEngine) finishNode(
+	ctx context.Context,
+	task *workflow.Task,
+	exec *taskExecution,
+	nodeKey string,
+	output *operator.Output,
+	status workflow.NodeExecutionStatus,
+	artifactIDs []uuid.UUID,
+) error {
 	exec.mu.Lock()
-	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+	exec.nodeResults[nodeKey] = output
+	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
 		now := time.Now()
-		execNode.Status = workflow.NodeExecSuccess
+		execNode.Status = status
 		execNode.CompletedAt = &now
 		execNode.ArtifactIDs = artifactIDs
 	}
 	exec.mu.Unlock()
 
-	return e.syncTaskNodeExecutions(ctx, task, exec)
-}
+	if err := e.saveCheckpoint(ctx, task, exec, nodeKey, output); err != nil {
+		return err
+	}
 
-func (e *DAGWorkflowEngine) failNode(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string, err error) error {
-	exec.mu.Lock()
-	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
-		now := time.Now()
-		execNode.Status = workflow.NodeExecFailed
-		execNode.Error = err.Error()
-		execNode.CompletedAt = &now
+	if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
+		return err
 	}
-	exec.mu.Unlock()
-	_ = e.syncTaskNodeExecutions(ctx, task, exec)
-	return err
+	e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, nodeKey)
+	return nil
 }
 
-func (e *DAGWorkflowEngine) shouldExecuteNode(
+// executeOperator validates the input, runs the operator under the node's retry policy
+// and validates the output. Every attempt is reported to record.
+func (e *DAGWorkflowEngine) executeOperator(
+	ctx context.Context,
 	node *workflow.Node,
-	edges []workflow.Edge,
-	exec *taskExecution,
-) bool {
-	// Find all incoming edges
-	incoming := []workflow.Edge{}
-	for _, edge := range edges {
-		if edge.TargetKey == node.NodeKey {
-			incoming = append(incoming, edge)
-		}
+	op *operator.Operator,
+	input *operator.Input,
+	record func(workflow.NodeAttempt),
+) (*operator.Output, error) {
+	version := op.ActiveVersion
+	if err := e.validateNodeInput(ctx, version, input); err != nil {
+		return nil, err
 	}
 
-	if len(incoming) == 0 {
-		return true // No dependencies
-	}
+	policy := node.Config.EffectiveRetryPolicy()
+	attempts := policy.Attempts()
 
-	// Check conditions
-	for _, edge := range incoming {
-		upstreamNodeKey := edge.SourceKey
-		exec.mu.RLock()
-		upstreamExec, ok := exec.nodeExecutions[upstreamNodeKey]
-		exec.mu.RUnlock()
+	var output *operator.Output
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		startedAt := time.Now()
+		output, lastErr = e.executeAttempt(ctx, node, op, input)
+		if lastErr == nil {
+			record(workflow.NodeAttempt{Attempt: attempt, StartedAt: startedAt, CompletedAt: time.Now()})
+			break
+		}
 
-		if !ok || upstreamExec == nil {
-			return false // Upstream not executed (should not happen in DAG if sorted correctly)
+		// A cancelled task is not retried
+		if ctx.Err() != nil {
+			record(workflow.NodeAttempt{Attempt: attempt, Error: lastErr.Error(), StartedAt: startedAt, CompletedAt: time.Now()})
+			return nil, fmt.Errorf("node %s: %w", node.NodeKey, lastErr)
 		}
 
-		// Default condition: always execute if upstream success
-		conditionType := "always"
-		if edge.Condition != nil && edge.Condition.Type != "" {
-			conditionType = edge.Condition.Type
+		class := operator.ClassifyError(lastErr)
+		rec := workflow.NodeAttempt{
+			Attempt:     attempt,
+			Error:       lastErr.Error(),
+			ErrorClass:  class,
+			StartedAt:   startedAt,
+			CompletedAt: time.Now(),
+		}
+		if attempt == attempts || !policy.Retryable(class) {
+			record(rec)
+			return nil, fmt.Errorf("node %s failed after %d attempts: %w", node.NodeKey, attempt, lastErr)
 		}
 
-		switch conditionType {
-		case "always":
-			if upstreamExec.Status != workflow.NodeExecSuccess && upstreamExec.Status != workflow.NodeExecFailed {
-				return false // Upstream skipped or not finished
-			}
-		case "on_success":
-			if upstreamExec.Status != workflow.NodeExecSuccess {
-				return false
-			}
-		case "on_failure":
-			if upstreamExec.Status != workflow.NodeExecFailed {
-				return false
-			}
+		var deadline *time.Time
+		if d, ok := ctx.Deadline(); ok {
+			deadline = &d
+		}
+		backoff := policy.Backoff(attempt, operator.RetryAfterOf(lastErr), deadline)
+		rec.BackoffMs = backoff.Milliseconds()
+		record(rec)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return nil, err
 		}
 	}
 
-	return true
+	if err := e.validateNodeOutput(ctx, version, output); err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
-func (e *DAGWorkflowEngine) syncTaskNodeExecutions(ctx context.Context, task *workflow.Task, exec *taskExecution) error {
-	exec.mu.RLock()
-	executions := make([]workflow.NodeExecution, 0, len(exec.nodeExecutions))
+// executeAttempt runs the operator once, bounded by the node timeout and the operator
+// concurrency limit
+func (e *DAGWorkflowEngine) executeAttempt(
+	ctx context.Context,
+	node *workflow.Node,
+	op *operator.Operator,
+	input *operator.Input,
+) (*operator.Output, error) {
+	attemptCtx := ctx
+	if node.Config != nil && node.Config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, time.Duration(node.Config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	release, err := e.operatorLimiter.acquire(attemptCtx, op.Code)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: wait for operator %s slot: %w", node.NodeKey, op.Code, err)
+	}
+	defer release()
+
+	output, err := e.executor.Execute(attemptCtx, op.ActiveVersion, input)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		// The node timeout fired: report it as a timeout whatever the executor returned
+		return nil, operator.NewExecError(operator.ErrorClassTimeout, err)
+	}
+	return output, err
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// executeMapNode runs the node's operator once per item of the configured upstream list,
+// bounded by the configured parallelism, and aggregates the item outputs in item order
+func (e *DAGWorkflowEngine) executeMapNode(
+	ctx context.Context,
+	node *workflow.Node,
+	op *operator.Operator,
+	input *operator.Input,
+	task *workflow.Task,
+	exec *taskExecution,
+) (*operator.Output, error) {
+	if err := node.Validate(); err != nil {
+		return nil, err
+	}
+	cfg := node.Config.Map
+
+	items, err := mapItems(input.Params[cfg.Items])
+	if err != nil {
+		return nil, fmt.Errorf("map node %s: %w", node.NodeKey, err)
+	}
+	// Node params and input mappings are checked on save; task input params are only known here
+	for _, param := range cfg.ReservedParams() {
+		if _, ok := input.Params[param]; ok {
+			return nil, fmt.Errorf("map node %s: input param %s collides with the injected item param", node.NodeKey, param)
+		}
+	}
+
+	itemExecs := make([]workflow.NodeItemExecution, len(items))
+	for i := range itemExecs {
+		itemExecs[i] = workflow.NodeItemExecution{Index: i, Status: workflow.NodeExecPending}
+	}
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		execNode.Items = itemExecs
+	}
+	exec.mu.Unlock()
+	if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
+		return nil, err
+	}
+
+	mapCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]*operator.Output, len(items))
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, cfg.GetParallelism())
+	var wg sync.WaitGroup
+
+dispatch:
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-mapCtx.Done():
+			break dispatch
+		}
+
+		wg.Add(1)
+		go func(i int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			e.updateNodeItem(exec, node.NodeKey, i, workflow.NodeExecRunning, nil)
+			_ = e.syncTaskNodeExecutions(ctx, task, exec)
+
+			itemInput := &operator.Input{
+				AssetID: input.AssetID,
+				Params:  make(map[string]interface{}, len(input.Params)+2),
+			}
+			for k, v := range input.Params {
+				itemInput.Params[k] = v
+			}
+			itemInput.Params[cfg.GetItemParam()] = item
+			itemInput.Params[workflow.MapItemIndexParam] = i
+
+			itemCtx := e.withAsyncCallback(mapCtx, task.ID, node.NodeKey)
+			outputs[i], errs[i] = e.executeOperator(itemCtx, node, op, itemInput, func(a workflow.NodeAttempt) {
+				e.recordItemAttempt(exec, node.NodeKey, i, a)
+			})
+			if errs[i] != nil {
+				e.updateNodeItem(exec, node.NodeKey, i, workflow.NodeExecFailed, errs[i])
+				if !cfg.ContinueOnError {
+					cancel()
+				}
+			} else {
+				e.updateNodeItem(exec, node.NodeKey, i, workflow.NodeExecSuccess, nil)
+			}
+			_ = e.syncTaskNodeExecutions(ctx, task, exec)
+		}(i, item)
+	}
+	wg.Wait()
+
+	// Items that were never dispatched after a failure are marked as skipped
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		for i := range execNode.Items {
+			if execNode.Items[i].Status == workflow.NodeExecPending {
+				execNode.Items[i].Status = workflow.NodeExecSkipped
+			}
+		}
+	}
+	exec.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	aggregated := &operator.Output{}
+	succeeded, failed := 0, 0
+	for i := range items {
+		if errs[i] != nil {
+			failed++
+			if !cfg.ContinueOnError {
+				return nil, fmt.Errorf("map node %s item %d: %w", node.NodeKey, i, errs[i])
+			}
+			continue
+		}
+		succeeded++
+		if outputs[i] == nil {
+			continue
+		}
+		aggregated.OutputAssets = append(aggregated.OutputAssets, outputs[i].OutputAssets...)
+		aggregated.Results = append(aggregated.Results, outputs[i].Results...)
+		aggregated.Timeline = append(aggregated.Timeline, outputs[i].Timeline...)
+	}
+	aggregated.Diagnostics = map[string]interface{}{
+		"map_total":     len(items),
+		"map_succeeded": succeeded,
+		"map_failed":    failed,
+	}
+	return aggregated, nil
+}
+
+func (e *DAGWorkflowEngine) updateNodeItem(exec *taskExecution, nodeKey string, index int, status workflow.NodeExecutionStatus, err error) {
+	exec.mu.Lock()
+	defer exec.mu.Unlock()
+	execNode, ok := exec.nodeExecutions[nodeKey]
+	if !ok || index >= len(execNode.Items) {
+		return
+	}
+	now := time.Now()
+	item := &execNode.Items[index]
+	item.Status = status
+	if status == workflow.NodeExecRunning {
+		item.StartedAt = &now
+		return
+	}
+	item.CompletedAt = &now
+	if err != nil {
+		item.Error = err.Error()
+	}
+
+	// A map node progresses by its finished items
+	finished := 0
+	for i := range execNode.Items {
+		switch execNode.Items[i].Status {
+		case workflow.NodeExecSuccess, workflow.NodeExecFailed, workflow.NodeExecSkipped:
+			finished++
+		}
+	}
+	execNode.Progress = float64(finished) / float64(len(execNode.Items))
+}
+
+// recordNodeAttempt appends an attempt to the node execution; failed attempts are
+// synced right away so retries are visible while the node is still running
+func (e *DAGWorkflowEngine) recordNodeAttempt(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string, attempt workflow.NodeAttempt) {
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
+		execNode.Attempts = append(execNode.Attempts, attempt)
+	}
+	exec.mu.Unlock()
+
+	if attempt.Error != "" {
+		_ = e.syncTaskNodeExecutions(ctx, task, exec)
+	}
+}
+
+func (e *DAGWorkflowEngine) recordItemAttempt(exec *taskExecution, nodeKey string, index int, attempt workflow.NodeAttempt) {
+	exec.mu.Lock()
+	defer exec.mu.Unlock()
+	execNode, ok := exec.nodeExecutions[nodeKey]
+	if !ok || index >= len(execNode.Items) {
+		return
+	}
+	execNode.Items[index].Attempts = append(execNode.Items[index].Attempts, attempt)
+}
+
+// mapItems converts the value referenced by a map node into a list of items
+func mapItems(v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("map items must be a list, got %T", v)
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// executeApproval resolves an approval node. On first execution it records the
+// node input as the payload under review together with the deadline and reports
+// errTaskWaiting; once a decision exists (or the deadline has passed and the
+// timeout policy applies) approval passes the payload downstream, while
+// rejection and timeouts with the fail policy fail the node.
+func (e *DAGWorkflowEngine) executeApproval(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	exec *taskExecution,
+) (*operator.Output, error) {
+	var cfg *workflow.ApprovalConfig
+	if node.Config != nil {
+		cfg = node.Config.Approval
+	}
+
+	exec.mu.RLock()
+	var approval *workflow.NodeApproval
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok && execNode.Approval != nil {
+		copied := *execNode.Approval
+		approval = &copied
+	}
+	exec.mu.RUnlock()
+
+	now := time.Now()
+	if approval == nil {
+		input, err := e.prepareNodeInput(task, node, exec)
+		if err != nil {
+			return nil, err
+		}
+		approval = &workflow.NodeApproval{Payload: input.Params}
+		if cfg != nil && cfg.TimeoutSeconds > 0 {
+			deadline := now.Add(time.Duration(cfg.TimeoutSeconds) * time.Second)
+			approval.Deadline = &deadline
+		}
+	}
+	if approval.IsExpired(now) {
+		approval.ApplyTimeout(cfg.GetOnTimeout(), now)
+	}
+
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		execNode.Approval = approval
+	}
+	exec.mu.Unlock()
+
+	switch approval.Decision {
+	case workflow.ApprovalApproved:
+		diagnostics := map[string]interface{}{
+			"decision":     string(approval.Decision),
+			"auto_decided": approval.AutoDecided,
+		}
+		if approval.ApproverID != nil {
+			diagnostics["approver_id"] = approval.ApproverID.String()
+		}
+		return &operator.Output{
+			Results:     []operator.Result{{Type: "approval", Data: approval.Payload}},
+			Diagnostics: diagnostics,
+		}, nil
+	case workflow.ApprovalRejected:
+		if approval.AutoDecided {
+			return nil, errors.New("approval rejected: timed out")
+		}
+		if approval.Comment != "" {
+			return nil, fmt.Errorf("approval rejected: %s", approval.Comment)
+		}
+		return nil, errors.New("approval rejected")
+	case workflow.ApprovalTimedOut:
+		return nil, errors.New("approval timed out")
+	}
+	return nil, errTaskWaiting
+}
+
+// waitNode marks a node as waiting and stops the layer without failing the task
+func (e *DAGWorkflowEngine) waitNode(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string) error {
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
+		execNode.Status = workflow.NodeExecWaiting
+	}
+	exec.mu.Unlock()
+	if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
+		return err
+	}
+	e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, nodeKey)
+	return errTaskWaiting
+}
+
+type subWorkflowDepthKey struct{}
+
+// executeSubWorkflow runs the referenced workflow as a child task of the current task.
+// The child runs under the parent's execution context, so cancelling the parent task
+// through Cancel cancels the child as well. The outputs of the child's sink nodes
+// become the node output.
+func (e *DAGWorkflowEngine) executeSubWorkflow(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	exec *taskExecution,
+) (*operator.Output, error) {
+	if err := node.Validate(); err != nil {
+		return nil, err
+	}
+	cfg := node.Config.SubWorkflow
+
+	depth, _ := ctx.Value(subWorkflowDepthKey{}).(int)
+	if depth >= workflow.MaxSubWorkflowDepth {
+		return nil, fmt.Errorf("sub workflow nesting exceeds %d levels", workflow.MaxSubWorkflowDepth)
+	}
+
+	exec.mu.RLock()
+	var previousChildID *uuid.UUID
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		previousChildID = execNode.ChildTaskID
+	}
+	exec.mu.RUnlock()
+	if previousChildID != nil {
+		output, resumed, err := e.resumeSubWorkflow(ctx, cfg, *previousChildID, depth)
+		if resumed {
+			return output, err
+		}
+	}
+
+	input, err := e.prepareNodeInput(task, node, exec)
+	if err != nil {
+		return nil, err
+	}
+	childTask := &workflow.Task{
+		TenantID:          task.TenantID,
+		TriggeredByUserID: task.TriggeredByUserID,
+		AssetID:           task.AssetID,
+		CallerTaskID:      &task.ID,
+		CallerNodeKey:     node.NodeKey,
+		Status:            workflow.TaskStatusPending,
+		InputParams:       input.Params,
+	}
+
+	var child *workflow.Workflow
+	err = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		child, err = workflow.ResolveSubWorkflow(ctx, repos.Workflows, repos.WorkflowRevisions, cfg)
+		if err != nil {
+			return err
+		}
+		childTask.WorkflowID = child.ID
+		childTask.RevisionID = child.RevisionID
+		return repos.Tasks.Create(ctx, childTask)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sub workflow: %w", err)
+	}
+
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		execNode.ChildTaskID = &childTask.ID
+	}
+	exec.mu.Unlock()
+	if err := e.syncTaskNodeExecutions(ctx, task, exec); err != nil {
+		return nil, err
+	}
+
+	childCtx := context.WithValue(ctx, subWorkflowDepthKey{}, depth+1)
+	childExec, err := e.run(childCtx, child, childTask, nil)
+	if errors.Is(err, errTaskWaiting) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sub workflow %s (task %s) failed: %w", child.Code, childTask.ID, err)
+	}
+
+	return subWorkflowOutput(child, childTask, childExec), nil
+}
+
+// resumeSubWorkflow continues a child task left unfinished by an interrupted parent.
+// It reports resumed=false when the child cannot be resumed and a new one should be started.
+func (e *DAGWorkflowEngine) resumeSubWorkflow(
+	ctx context.Context,
+	cfg *workflow.SubWorkflowConfig,
+	childTaskID uuid.UUID,
+	depth int,
+) (output *operator.Output, resumed bool, err error) {
+	var (
+		child       *workflow.Workflow
+		childTask   *workflow.Task
+		checkpoints []*workflow.TaskCheckpoint
+	)
+	err = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		var err error
+		childTask, err = repos.Tasks.Get(ctx, childTaskID)
+		if err != nil {
+			return err
+		}
+		child, err = repos.Workflows.GetWithNodes(ctx, childTask.WorkflowID)
+		if err != nil {
+			return err
+		}
+		checkpoints, err = repos.TaskCheckpoints.ListByTask(ctx, childTaskID)
+		return err
+	})
+	if err != nil || childTask.IsFailed() || childTask.IsCancelled() || !cfg.Matches(child) {
+		return nil, false, nil
+	}
+
+	childCtx := context.WithValue(ctx, subWorkflowDepthKey{}, depth+1)
+	childExec, err := e.run(childCtx, child, childTask, checkpoints)
+	if errors.Is(err, errTaskWaiting) {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, true, fmt.Errorf("sub workflow %s (task %s) failed: %w", child.Code, childTask.ID, err)
+	}
+	return subWorkflowOutput(child, childTask, childExec), true, nil
+}
+
+// subWorkflowOutput aggregates the outputs of the child workflow's sink nodes
+// (nodes without outgoing edges) in node order
+func subWorkflowOutput(wf *workflow.Workflow, childTask *workflow.Task, childExec *taskExecution) *operator.Output {
+	hasOutgoing := make(map[string]bool, len(wf.Edges))
+	for _, edge := range wf.Edges {
+		hasOutgoing[edge.SourceKey] = true
+	}
+
+	output := &operator.Output{
+		Diagnostics: map[string]interface{}{
+			"sub_task_id":       childTask.ID.String(),
+			"sub_workflow_id":   wf.ID.String(),
+			"sub_workflow_code": wf.Code,
+		},
+	}
+
+	childExec.mu.RLock()
+	defer childExec.mu.RUnlock()
+	for _, n := range wf.Nodes {
+		if hasOutgoing[n.NodeKey] || n.IsHook() {
+			continue
+		}
+		result := childExec.nodeResults[n.NodeKey]
+		if result == nil {
+			continue
+		}
+		output.OutputAssets = append(output.OutputAssets, result.OutputAssets...)
+		output.Results = append(output.Results, result.Results...)
+		output.Timeline = append(output.Timeline, result.Timeline...)
+		if len(result.Diagnostics) > 0 {
+			output.Diagnostics[n.NodeKey] = result.Diagnostics
+		}
+	}
+	return output
+}
+
+// saveCheckpoint persists the output and execution state of a finished node
+// so that an interrupted task can be resumed without recomputing it
+func (e *DAGWorkflowEngine) saveCheckpoint(
+	ctx context.Context,
+	task *workflow.Task,
+	exec *taskExecution,
+	nodeKey string,
+	output *operator.Output,
+) error {
+	exec.mu.RLock()
+	execNode, ok := exec.nodeExecutions[nodeKey]
+	var execution workflow.NodeExecution
+	if ok {
+		execution = *execNode
+	}
+	exec.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if err := e.fence(ctx, repos, task); err != nil {
+			return err
+		}
+		return repos.TaskCheckpoints.Save(ctx, &workflow.TaskCheckpoint{
+			TenantID:  task.TenantID,
+			TaskID:    task.ID,
+			NodeKey:   nodeKey,
+			Output:    output,
+			Execution: execution,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint for node %s: %w", nodeKey, err)
+	}
+	return nil
+}
+
+// isNodeCompleted reports whether a node has reached a terminal state that
+// does not need to be executed again
+func (e *DAGWorkflowEngine) isNodeCompleted(nodeKey string, exec *taskExecution) bool {
+	exec.mu.RLock()
+	defer exec.mu.RUnlock()
+	execNode, ok := exec.nodeExecutions[nodeKey]
+	if !ok {
+		return false
+	}
+	return execNode.Status.IsSucceeded() || execNode.Status == workflow.NodeExecSkipped
+}
+
+func (e *DAGWorkflowEngine) isLayerCompleted(layer []string, exec *taskExecution) bool {
+	for _, nodeKey := range layer {
+		if !e.isNodeCompleted(nodeKey, exec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *DAGWorkflowEngine) failNode(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string, err error) error {
+	exec.mu.Lock()
+	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
+		now := time.Now()
+		execNode.Status = workflow.NodeExecFailed
+		execNode.Error = err.Error()
+		execNode.CompletedAt = &now
+	}
+	exec.mu.Unlock()
+	_ = e.syncTaskNodeExecutions(ctx, task, exec)
+	e.publishNodeEvent(ctx, task, exec, event.EventTypeNodeFinished, nodeKey)
+	return err
+}
+
+func (e *DAGWorkflowEngine) shouldExecuteNode(
+	node *workflow.Node,
+	edges []workflow.Edge,
+	exec *taskExecution,
+) (bool, error) {
+	// Find all incoming edges
+	incoming := []workflow.Edge{}
+	for _, edge := range edges {
+		if edge.TargetKey == node.NodeKey {
+			incoming = append(incoming, edge)
+		}
+	}
+
+	if len(incoming) == 0 {
+		return true, nil // No dependencies
+	}
+
+	// Check conditions
+	for _, edge := range incoming {
+		upstreamNodeKey := edge.SourceKey
+		exec.mu.RLock()
+		upstreamExec, ok := exec.nodeExecutions[upstreamNodeKey]
+		exec.mu.RUnlock()
+
+		if !ok || upstreamExec == nil {
+			return false, nil // Upstream not executed (should not happen in DAG if sorted correctly)
+		}
+
+		// Default condition: always execute if upstream success
+		conditionType := workflow.EdgeConditionAlways
+		if edge.Condition != nil && edge.Condition.Type != "" {
+			conditionType = edge.Condition.Type
+		}
+
+		switch conditionType {
+		case workflow.EdgeConditionAlways:
+			if !upstreamExec.Status.IsSucceeded() && upstreamExec.Status != workflow.NodeExecFailed {
+				return false, nil // Upstream skipped or not finished
+			}
+		case workflow.EdgeConditionOnSuccess:
+			if !upstreamExec.Status.IsSucceeded() {
+				return false, nil
+			}
+		case workflow.EdgeConditionOnFailure:
+			if upstreamExec.Status != workflow.NodeExecFailed {
+				return false, nil
+			}
+		}
+
+		if edge.Condition == nil || edge.Condition.Expression == "" {
+			continue
+		}
+		matched, err := e.evalEdgeExpression(edge, upstreamExec.Status, exec)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// evalEdgeExpression evaluates an edge condition expression against the upstream node output.
+// The environment exposes output_assets, results, timeline and diagnostics of the upstream
+// output, the upstream node status as status, and the condition's Value map as value.
+func (e *DAGWorkflowEngine) evalEdgeExpression(edge workflow.Edge, upstreamStatus workflow.NodeExecutionStatus, exec *taskExecution) (bool, error) {
+	program, err := edge.Condition.Program()
+	if err != nil {
+		return false, fmt.Errorf("edge %s -> %s: %w", edge.SourceKey, edge.TargetKey, err)
+	}
+
+	exec.mu.RLock()
+	output := exec.nodeResults[edge.SourceKey]
+	exec.mu.RUnlock()
+
+	env, err := outputEnv(output)
+	if err != nil {
+		return false, fmt.Errorf("edge %s -> %s: %w", edge.SourceKey, edge.TargetKey, err)
+	}
+	// A cached upstream counts as success so conditions do not depend on cache state
+	if upstreamStatus.IsSucceeded() {
+		upstreamStatus = workflow.NodeExecSuccess
+	}
+	env["status"] = string(upstreamStatus)
+	value := map[string]interface{}{}
+	for k, v := range edge.Condition.Value {
+		value[k] = v
+	}
+	env["value"] = value
+
+	matched, err := program.EvalBool(env)
+	if err != nil {
+		return false, fmt.Errorf("edge %s -> %s: %w", edge.SourceKey, edge.TargetKey, err)
+	}
+	return matched, nil
+}
+
+// outputEnv converts a node output into an expression environment.
+// It round-trips through JSON so expressions see the same field names as the API.
+func outputEnv(output *operator.Output) (map[string]interface{}, error) {
+	env := map[string]interface{}{
+		"output_assets": []interface{}{},
+		"results":       []interface{}{},
+		"timeline":      []interface{}{},
+		"diagnostics":   map[string]interface{}{},
+	}
+	if output == nil {
+		return env, nil
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("marshal upstream output: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("unmarshal upstream output: %w", err)
+	}
+	for k, v := range fields {
+		env[k] = v
+	}
+	return env, nil
+}
+
+func (e *DAGWorkflowEngine) syncTaskNodeExecutions(ctx context.Context, task *workflow.Task, exec *taskExecution) error {
+	exec.syncMu.Lock()
+	defer exec.syncMu.Unlock()
+
+	exec.mu.RLock()
+	executions := make([]workflow.NodeExecution, 0, len(exec.nodeExecutions))
 	for _, v := range exec.nodeExecutions {
-		executions = append(executions, *v)
+		ne := *v
+		if len(v.Items) > 0 {
+			ne.Items = append([]workflow.NodeItemExecution(nil), v.Items...)
+		}
+		executions = append(executions, ne)
 	}
 	currentNode := exec.currentNode
 	exec.mu.RUnlock()
 
+	exec.mu.Lock()
+	exec.progress = taskProgress(exec)
+	progress := exec.progress
+	exec.mu.Unlock()
+
 	return e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		task.NodeExecutions = executions
 		task.CurrentNode = currentNode
-		return repos.Tasks.Update(ctx, task)
+		task.Progress = progress
+		return e.saveTask(ctx, repos, task)
 	})
 }
 
@@ -607,12 +1687,15 @@ func (e *DAGWorkflowEngine) validateNodeOutput(ctx context.Context, version *ope
 	return nil
 }
 
-// prepareNodeInput prepares input for node execution
+// prepareNodeInput prepares input for node execution.
+// Only outputs of the node's ancestors flow into its input: either through the
+// node's declared input mappings, or, when none are declared, flattened as
+// <key>_output, <key>_assets, <key>_results and <key>_timeline params.
 func (e *DAGWorkflowEngine) prepareNodeInput(
 	task *workflow.Task,
 	node *workflow.Node,
 	exec *taskExecution,
-) *operator.Input {
+) (*operator.Input, error) {
 	input := &operator.Input{
 		Params: make(map[string]interface{}),
 	}
@@ -636,9 +1719,21 @@ func (e *DAGWorkflowEngine) prepareNodeInput(
 		}
 	}
 
+	if node.HasInputMappings() {
+		if err := e.applyInputMappings(task, node, exec, input); err != nil {
+			return nil, err
+		}
+		return input, nil
+	}
+
 	// Add outputs from upstream nodes (for data flow)
 	exec.mu.RLock()
+	ancestors := exec.ancestors[node.NodeKey]
 	for nodeKey, output := range exec.nodeResults {
+		if !ancestors[nodeKey] || output == nil {
+			continue
+		}
+
 		// Store full output under node key
 		input.Params[nodeKey+"_output"] = output
 
@@ -655,21 +1750,95 @@ func (e *DAGWorkflowEngine) prepareNodeInput(
 	}
 	exec.mu.RUnlock()
 
-	return input
+	return input, nil
 }
 
-// saveArtifactsWithIDs saves operator output as artifacts and returns their IDs
+// applyInputMappings evaluates the node's input mappings against the outputs of its
+// ancestors and the task input, writing each value to its target param path
+func (e *DAGWorkflowEngine) applyInputMappings(
+	task *workflow.Task,
+	node *workflow.Node,
+	exec *taskExecution,
+	input *operator.Input,
+) error {
+	taskInput := make(map[string]interface{}, len(task.InputParams))
+	for k, v := range task.InputParams {
+		taskInput[k] = v
+	}
+	nodes := make(map[string]interface{})
+	env := map[string]interface{}{
+		workflow.MappingVarInput: taskInput,
+		workflow.MappingVarNodes: nodes,
+	}
+
+	exec.mu.RLock()
+	ancestors := exec.ancestors[node.NodeKey]
+	outputs := make(map[string]*operator.Output, len(ancestors))
+	for nodeKey := range ancestors {
+		if output, ok := exec.nodeResults[nodeKey]; ok {
+			outputs[nodeKey] = output
+		}
+	}
+	exec.mu.RUnlock()
+
+	for nodeKey, output := range outputs {
+		nodeEnv, err := outputEnv(output)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", nodeKey, err)
+		}
+		nodes[nodeKey] = nodeEnv
+		if _, reserved := env[nodeKey]; !reserved {
+			env[nodeKey] = nodeEnv
+		}
+	}
+
+	for _, mapping := range node.Config.Inputs {
+		program, err := mapping.Program()
+		if err != nil {
+			return fmt.Errorf("input mapping %s: %w", mapping.To, err)
+		}
+		value, err := program.Eval(env)
+		if err != nil {
+			return fmt.Errorf("input mapping %s: %w", mapping.To, err)
+		}
+		setParam(input.Params, mapping.TargetPath(), value)
+	}
+	return nil
+}
+
+// setParam writes value at a dotted param path. Intermediate maps are copied
+// so that task input params and node config are never modified.
+func setParam(params map[string]interface{}, path []string, value interface{}) {
+	current := params
+	for _, key := range path[:len(path)-1] {
+		existing, _ := current[key].(map[string]interface{})
+		next := make(map[string]interface{}, len(existing)+1)
+		for k, v := range existing {
+			next[k] = v
+		}
+		current[key] = next
+		current = next
+	}
+	current[path[len(path)-1]] = value
+}
+
+// saveArtifactsWithIDs saves operator output as artifacts and returns their IDs.
+// Artifacts of nodes with temp_artifacts, and output assets whose metadata sets
+// "temporary": true, are saved as temporary.
 func (e *DAGWorkflowEngine) saveArtifactsWithIDs(
 	ctx context.Context,
-	taskID uuid.UUID,
-	nodeKey string,
+	task *workflow.Task,
+	node *workflow.Node,
 	output *operator.Output,
 ) ([]uuid.UUID, error) {
 	if output == nil {
 		return nil, nil
 	}
 
-	var artifactIDs []uuid.UUID
+	taskID := task.ID
+	nodeKey := node.NodeKey
+	temporary := node.HasTempArtifacts()
+	var artifacts []*workflow.Artifact
 
 	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		// Save output assets
@@ -686,17 +1855,19 @@ func (e *DAGWorkflowEngine) saveArtifactsWithIDs(
 				},
 			}
 
+			tempAsset, _ := asset.Metadata["temporary"].(bool)
 			artifact := &workflow.Artifact{
-				ID:     uuid.New(),
-				TaskID: taskID,
-				Type:   workflow.ArtifactTypeAsset,
-				Data:   data,
+				ID:        uuid.New(),
+				TaskID:    taskID,
+				Type:      workflow.ArtifactTypeAsset,
+				Data:      data,
+				Temporary: temporary || tempAsset,
 			}
 
 			if err := repos.Artifacts.Create(ctx, artifact); err != nil {
 				return fmt.Errorf("failed to create asset artifact: %w", err)
 			}
-			artifactIDs = append(artifactIDs, artifact.ID)
+			artifacts = append(artifacts, artifact)
 		}
 
 		// Save analysis results
@@ -718,16 +1889,17 @@ func (e *DAGWorkflowEngine) saveArtifactsWithIDs(
 			}
 
 			artifact := &workflow.Artifact{
-				ID:     uuid.New(),
-				TaskID: taskID,
-				Type:   workflow.ArtifactTypeResult,
-				Data:   data,
+				ID:        uuid.New(),
+				TaskID:    taskID,
+				Type:      workflow.ArtifactTypeResult,
+				Data:      data,
+				Temporary: temporary,
 			}
 
 			if err := repos.Artifacts.Create(ctx, artifact); err != nil {
 				return fmt.Errorf("failed to create result artifact: %w", err)
 			}
-			artifactIDs = append(artifactIDs, artifact.ID)
+			artifacts = append(artifacts, artifact)
 		}
 
 		// Save timeline events
@@ -751,16 +1923,17 @@ func (e *DAGWorkflowEngine) saveArtifactsWithIDs(
 			}
 
 			artifact := &workflow.Artifact{
-				ID:     uuid.New(),
-				TaskID: taskID,
-				Type:   workflow.ArtifactTypeTimeline,
-				Data:   data,
+				ID:        uuid.New(),
+				TaskID:    taskID,
+				Type:      workflow.ArtifactTypeTimeline,
+				Data:      data,
+				Temporary: temporary,
 			}
 
 			if err := repos.Artifacts.Create(ctx, artifact); err != nil {
 				return fmt.Errorf("failed to create timeline artifact: %w", err)
 			}
-			artifactIDs = append(artifactIDs, artifact.ID)
+			artifacts = append(artifacts, artifact)
 		}
 
 		// Save diagnostics if present
@@ -773,30 +1946,44 @@ func (e *DAGWorkflowEngine) saveArtifactsWithIDs(
 			}
 
 			artifact := &workflow.Artifact{
-				ID:     uuid.New(),
-				TaskID: taskID,
-				Type:   workflow.ArtifactTypeReport,
-				Data:   data,
+				ID:        uuid.New(),
+				TaskID:    taskID,
+				Type:      workflow.ArtifactTypeReport,
+				Data:      data,
+				Temporary: temporary,
 			}
 
 			if err := repos.Artifacts.Create(ctx, artifact); err != nil {
 				return fmt.Errorf("failed to create diagnostics artifact: %w", err)
 			}
-			artifactIDs = append(artifactIDs, artifact.ID)
+			artifacts = append(artifacts, artifact)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return artifactIDs, err
+	e.publishArtifacts(ctx, task, nodeKey, artifacts)
+	artifactIDs := make([]uuid.UUID, len(artifacts))
+	for i, artifact := range artifacts {
+		artifactIDs[i] = artifact.ID
+	}
+	return artifactIDs, nil
 }
 
 // updateTaskProgress updates task progress
 func (e *DAGWorkflowEngine) updateTaskProgress(ctx context.Context, task *workflow.Task, progress int) error {
-	return e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		task.Progress = progress
-		return repos.Tasks.Update(ctx, task)
+		return e.saveTask(ctx, repos, task)
 	})
+	if err != nil {
+		return err
+	}
+	e.publishTaskEvent(ctx, task, event.EventTypeTaskProgress, nil)
+	return nil
 }
 
 // updateTaskStatus updates task status and completion
@@ -806,7 +1993,7 @@ func (e *DAGWorkflowEngine) updateTaskStatus(
 	status workflow.TaskStatus,
 	errorMsg string,
 ) error {
-	return e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
 		task.Status = status
 		task.Progress = 100
 		now := time.Now()
@@ -814,6 +2001,11 @@ func (e *DAGWorkflowEngine) updateTaskStatus(
 		if errorMsg != "" {
 			task.Error = errorMsg
 		}
-		return repos.Tasks.Update(ctx, task)
+		return e.saveTask(ctx, repos, task)
 	})
+	if err != nil {
+		return err
+	}
+	e.publishTaskStatus(ctx, task)
+	return nil
 }
@@ -3,9 +3,14 @@ package engine
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"goyavision/internal/app/event"
 	"goyavision/internal/app/port"
 	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
@@ -13,6 +18,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // Mock implementations
@@ -82,10 +88,32 @@ func (s *stubOperatorRepo) ListByCategory(ctx context.Context, category operator
 	return []*operator.Operator{}, nil
 }
 
-type stubTaskRepo struct{}
+type stubTaskRepo struct {
+	mu      sync.Mutex
+	created []*workflow.Task
+	// fenceErr is returned by Fence, e.g. workflow.ErrTaskStopped once the task is cancelled
+	fenceErr error
+}
 
-func (s *stubTaskRepo) Create(ctx context.Context, t *workflow.Task) error                     { return nil }
-func (s *stubTaskRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Task, error)          { return &workflow.Task{ID: id, Progress: 0}, nil }
+func (s *stubTaskRepo) Create(ctx context.Context, t *workflow.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	s.created = append(s.created, t)
+	return nil
+}
+func (s *stubTaskRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.created {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return &workflow.Task{ID: id, Progress: 0}, nil
+}
 func (s *stubTaskRepo) GetWithRelations(ctx context.Context, id uuid.UUID) (*workflow.Task, error) {
 	return &workflow.Task{ID: id}, nil
 }
@@ -100,6 +128,62 @@ func (s *stubTaskRepo) GetStats(ctx context.Context, workflowID *uuid.UUID) (*wo
 func (s *stubTaskRepo) ListRunning(ctx context.Context) ([]*workflow.Task, error) {
 	return []*workflow.Task{}, nil
 }
+func (s *stubTaskRepo) ListWaiting(ctx context.Context) ([]*workflow.Task, error) {
+	return []*workflow.Task{}, nil
+}
+func (s *stubTaskRepo) ListPending(ctx context.Context, limit int, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	return []*workflow.Task{}, nil
+}
+func (s *stubTaskRepo) ListUnfinished(ctx context.Context) ([]*workflow.Task, error) {
+	return nil, nil
+}
+func (s *stubTaskRepo) Claim(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	return []*workflow.Task{}, nil
+}
+func (s *stubTaskRepo) CountRunning(ctx context.Context) (*workflow.RunningTaskCounts, error) {
+	return workflow.NewRunningTaskCounts(), nil
+}
+func (s *stubTaskRepo) RenewLeases(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration) ([]uuid.UUID, error) {
+	return ids, nil
+}
+func (s *stubTaskRepo) ReclaimExpired(ctx context.Context) (int64, error) { return 0, nil }
+func (s *stubTaskRepo) Fence(ctx context.Context, id uuid.UUID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fenceErr
+}
+
+// stubOperatorSlotRepo holds shared operator slots in memory
+type stubOperatorSlotRepo struct {
+	mu    sync.Mutex
+	slots map[uuid.UUID]string
+}
+
+func (s *stubOperatorSlotRepo) Acquire(ctx context.Context, operatorCode string, limit int, holder string, ttl time.Duration) (uuid.UUID, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, code := range s.slots {
+		if code == operatorCode {
+			n++
+		}
+	}
+	if n >= limit {
+		return uuid.Nil, false, nil
+	}
+	id := uuid.New()
+	s.slots[id] = operatorCode
+	return id, true, nil
+}
+func (s *stubOperatorSlotRepo) Renew(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	return nil
+}
+func (s *stubOperatorSlotRepo) Release(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.slots, id)
+	return nil
+}
 
 type stubArtifactRepo struct{}
 
@@ -118,6 +202,205 @@ func (s *stubArtifactRepo) ListByType(ctx context.Context, taskID uuid.UUID, art
 	return []*workflow.Artifact{}, nil
 }
 
+type stubCheckpointRepo struct {
+	mu          sync.Mutex
+	checkpoints map[string]*workflow.TaskCheckpoint
+}
+
+func (s *stubCheckpointRepo) Save(ctx context.Context, c *workflow.TaskCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkpoints == nil {
+		s.checkpoints = make(map[string]*workflow.TaskCheckpoint)
+	}
+	s.checkpoints[c.TaskID.String()+"/"+c.NodeKey] = c
+	return nil
+}
+func (s *stubCheckpointRepo) ListByTask(ctx context.Context, taskID uuid.UUID) ([]*workflow.TaskCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*workflow.TaskCheckpoint, 0, len(s.checkpoints))
+	for _, c := range s.checkpoints {
+		if c.TaskID == taskID {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+func (s *stubCheckpointRepo) DeleteByTask(ctx context.Context, taskID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, c := range s.checkpoints {
+		if c.TaskID == taskID {
+			delete(s.checkpoints, k)
+		}
+	}
+	return nil
+}
+
+type stubWorkflowRepo struct {
+	workflows []*workflow.Workflow
+}
+
+func (s *stubWorkflowRepo) find(match func(w *workflow.Workflow) bool) (*workflow.Workflow, error) {
+	for _, w := range s.workflows {
+		if match(w) {
+			return w, nil
+		}
+	}
+	return nil, errors.New("workflow not found")
+}
+func (s *stubWorkflowRepo) Create(ctx context.Context, w *workflow.Workflow) error { return nil }
+func (s *stubWorkflowRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Workflow, error) {
+	return s.find(func(w *workflow.Workflow) bool { return w.ID == id })
+}
+func (s *stubWorkflowRepo) GetByCode(ctx context.Context, code string) (*workflow.Workflow, error) {
+	return s.find(func(w *workflow.Workflow) bool { return w.Code == code })
+}
+func (s *stubWorkflowRepo) GetWithNodes(ctx context.Context, id uuid.UUID) (*workflow.Workflow, error) {
+	return s.find(func(w *workflow.Workflow) bool { return w.ID == id })
+}
+func (s *stubWorkflowRepo) List(ctx context.Context, filter workflow.Filter) ([]*workflow.Workflow, int64, error) {
+	return s.workflows, int64(len(s.workflows)), nil
+}
+func (s *stubWorkflowRepo) Update(ctx context.Context, w *workflow.Workflow) error { return nil }
+func (s *stubWorkflowRepo) Delete(ctx context.Context, id uuid.UUID) error         { return nil }
+func (s *stubWorkflowRepo) ListEnabled(ctx context.Context) ([]*workflow.Workflow, error) {
+	return s.workflows, nil
+}
+func (s *stubWorkflowRepo) UpdateRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error {
+	return nil
+}
+func (s *stubWorkflowRepo) CreateNode(ctx context.Context, n *workflow.Node) error { return nil }
+func (s *stubWorkflowRepo) ListNodes(ctx context.Context, workflowID uuid.UUID) ([]*workflow.Node, error) {
+	return nil, nil
+}
+func (s *stubWorkflowRepo) DeleteNodes(ctx context.Context, workflowID uuid.UUID) error { return nil }
+func (s *stubWorkflowRepo) CreateEdge(ctx context.Context, e *workflow.Edge) error      { return nil }
+func (s *stubWorkflowRepo) ListEdges(ctx context.Context, workflowID uuid.UUID) ([]*workflow.Edge, error) {
+	return nil, nil
+}
+func (s *stubWorkflowRepo) DeleteEdges(ctx context.Context, workflowID uuid.UUID) error { return nil }
+
+type stubOperatorVersionRepo struct {
+	versions []*operator.OperatorVersion
+}
+
+func (s *stubOperatorVersionRepo) Create(ctx context.Context, v *operator.OperatorVersion) error {
+	s.versions = append(s.versions, v)
+	return nil
+}
+func (s *stubOperatorVersionRepo) Get(ctx context.Context, id uuid.UUID) (*operator.OperatorVersion, error) {
+	for _, v := range s.versions {
+		if v.ID == id {
+			return v, nil
+		}
+	}
+	return nil, errors.New("operator version not found")
+}
+func (s *stubOperatorVersionRepo) ListByOperator(ctx context.Context, operatorID uuid.UUID) ([]*operator.OperatorVersion, error) {
+	return s.versions, nil
+}
+func (s *stubOperatorVersionRepo) GetByOperatorAndVersion(ctx context.Context, operatorID uuid.UUID, version string) (*operator.OperatorVersion, error) {
+	for _, v := range s.versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, errors.New("operator version not found")
+}
+func (s *stubOperatorVersionRepo) Update(ctx context.Context, v *operator.OperatorVersion) error {
+	return nil
+}
+func (s *stubOperatorVersionRepo) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type stubRevisionRepo struct {
+	mu        sync.Mutex
+	revisions []*workflow.Revision
+}
+
+func (s *stubRevisionRepo) Create(ctx context.Context, r *workflow.Revision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	s.revisions = append(s.revisions, r)
+	return nil
+}
+func (s *stubRevisionRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.revisions {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (s *stubRevisionRepo) GetByNumber(ctx context.Context, workflowID uuid.UUID, revision int) (*workflow.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.revisions {
+		if r.WorkflowID == workflowID && r.Revision == revision {
+			return r, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (s *stubRevisionRepo) GetLatest(ctx context.Context, workflowID uuid.UUID) (*workflow.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest *workflow.Revision
+	for _, r := range s.revisions {
+		if r.WorkflowID == workflowID && (latest == nil || r.Revision > latest.Revision) {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return latest, nil
+}
+func (s *stubRevisionRepo) List(ctx context.Context, workflowID uuid.UUID, limit, offset int) ([]*workflow.Revision, int64, error) {
+	return s.revisions, int64(len(s.revisions)), nil
+}
+
+type stubNodeCacheRepo struct {
+	mu      sync.Mutex
+	entries map[string]*workflow.NodeCacheEntry
+}
+
+func (s *stubNodeCacheRepo) Get(ctx context.Context, versionID uuid.UUID, inputHash string) (*workflow.NodeCacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[versionID.String()+"/"+inputHash]; ok {
+		return e, nil
+	}
+	return nil, nil
+}
+func (s *stubNodeCacheRepo) Save(ctx context.Context, e *workflow.NodeCacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*workflow.NodeCacheEntry)
+	}
+	s.entries[e.OperatorVersionID.String()+"/"+e.InputHash] = e
+	return nil
+}
+func (s *stubNodeCacheRepo) DeleteByOperatorVersion(ctx context.Context, versionID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for k, e := range s.entries {
+		if e.OperatorVersionID == versionID {
+			delete(s.entries, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
 func newTestRepos() *port.Repositories {
 	ov := &operator.OperatorVersion{
 		ID:       uuid.New(),
@@ -131,9 +414,16 @@ func newTestRepos() *port.Repositories {
 	op := &operator.Operator{ID: uuid.New(), Code: "test-op", ActiveVersion: ov, ActiveVersionID: &ov.ID}
 
 	return &port.Repositories{
-		Operators: &stubOperatorRepo{op: op},
-		Tasks:     &stubTaskRepo{},
-		Artifacts: &stubArtifactRepo{},
+		Workflows:       &stubWorkflowRepo{},
+		Operators:       &stubOperatorRepo{op: op},
+		Tasks:           &stubTaskRepo{},
+		Artifacts:       &stubArtifactRepo{},
+		TaskCheckpoints: &stubCheckpointRepo{},
+		NodeCache:       &stubNodeCacheRepo{},
+		OperatorSlots:   &stubOperatorSlotRepo{slots: make(map[uuid.UUID]string)},
+
+		OperatorVersions:  &stubOperatorVersionRepo{versions: []*operator.OperatorVersion{ov}},
+		WorkflowRevisions: &stubRevisionRepo{},
 	}
 }
 
@@ -433,10 +723,17 @@ func TestPrepareNodeInput(t *testing.T) {
 					{Type: "detection", Data: map[string]interface{}{"objects": 5}},
 				},
 			},
+			"sibling_node": {
+				Results: []operator.Result{{Type: "other"}},
+			},
+		},
+		ancestors: map[string]map[string]bool{
+			"test_node": {"upstream_node": true},
 		},
 	}
 
-	input := engine.prepareNodeInput(task, node, exec)
+	input, err := engine.prepareNodeInput(task, node, exec)
+	assert.NoError(t, err)
 
 	// Check asset ID
 	assert.Equal(t, assetID, input.AssetID)
@@ -453,6 +750,70 @@ func TestPrepareNodeInput(t *testing.T) {
 	assert.NotNil(t, input.Params["upstream_node_output"])
 	assert.NotNil(t, input.Params["upstream_node_assets"])
 	assert.NotNil(t, input.Params["upstream_node_results"])
+
+	// Outputs of nodes that are not ancestors do not flow in
+	assert.Nil(t, input.Params["sibling_node_output"])
+	assert.Nil(t, input.Params["sibling_node_results"])
+}
+
+// Test prepareNodeInput with explicit input mappings
+func TestPrepareNodeInput_Mappings(t *testing.T) {
+	engine := &DAGWorkflowEngine{}
+
+	task := &workflow.Task{
+		InputParams: map[string]interface{}{
+			"threshold": 0.5,
+			"options":   map[string]interface{}{"mode": "fast"},
+		},
+	}
+
+	node := &workflow.Node{
+		NodeKey: "crop",
+		Config: &workflow.NodeConfig{
+			Params: map[string]interface{}{"format": "jpg"},
+			Inputs: []workflow.InputMapping{
+				{From: "detect.results[0].data.bbox", To: "params.region"},
+				{From: `nodes["frame-extract"].output_assets[1].path`, To: "options.frame"},
+				{From: "input.threshold", To: "min_confidence"},
+			},
+		},
+	}
+
+	exec := &taskExecution{
+		nodeResults: map[string]*operator.Output{
+			"detect": {
+				Results: []operator.Result{
+					{Type: "detection", Data: map[string]interface{}{"bbox": []interface{}{1, 2, 3, 4}}},
+				},
+			},
+			"frame-extract": {
+				OutputAssets: []operator.OutputAsset{
+					{Type: "image", Path: "/frames/0.jpg"},
+					{Type: "image", Path: "/frames/1.jpg"},
+				},
+			},
+			"unrelated": {
+				Results: []operator.Result{{Type: "other"}},
+			},
+		},
+		ancestors: map[string]map[string]bool{
+			"crop": {"detect": true, "frame-extract": true},
+		},
+	}
+
+	input, err := engine.prepareNodeInput(task, node, exec)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{float64(1), float64(2), float64(3), float64(4)}, input.Params["region"])
+	assert.Equal(t, map[string]interface{}{"mode": "fast", "frame": "/frames/1.jpg"}, input.Params["options"])
+	assert.Equal(t, 0.5, input.Params["min_confidence"])
+	assert.Equal(t, "jpg", input.Params["format"])
+
+	// Declared mappings replace the automatic <key>_output params
+	assert.Nil(t, input.Params["detect_output"])
+	assert.Nil(t, input.Params["unrelated_results"])
+	// Task input is not modified
+	assert.Equal(t, map[string]interface{}{"mode": "fast"}, task.InputParams["options"])
 }
 
 // Test execute with mock dependencies
@@ -545,25 +906,22 @@ func TestExecute_ParallelNodes(t *testing.T) {
 	assert.Equal(t, 3, len(mockExecutor.Calls))
 }
 
-// Test execute with cycle detection
-func TestExecute_CycleDetection(t *testing.T) {
+// Test operator concurrency limit serializes parallel calls to the same operator
+func TestExecute_OperatorLimit(t *testing.T) {
 	mockUOW := new(MockUnitOfWork)
 	mockUOW.repos = newTestRepos()
 	mockExecutor := new(MockOperatorExecutor)
 
 	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.SetOperatorLimits(map[string]int{"TEST-OP": 1})
 
+	opID := uuid.New()
 	wf := &workflow.Workflow{
 		ID: uuid.New(),
 		Nodes: []workflow.Node{
-			{ID: uuid.New(), NodeKey: "A"},
-			{ID: uuid.New(), NodeKey: "B"},
-			{ID: uuid.New(), NodeKey: "C"},
-		},
-		Edges: []workflow.Edge{
-			{SourceKey: "A", TargetKey: "B"},
-			{SourceKey: "B", TargetKey: "C"},
-			{SourceKey: "C", TargetKey: "A"},
+			{ID: uuid.New(), NodeKey: "a", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "b", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "c", OperatorID: &opID},
 		},
 	}
 
@@ -573,144 +931,687 @@ func TestExecute_CycleDetection(t *testing.T) {
 		Status:     workflow.TaskStatusPending,
 	}
 
-	ctx := context.Background()
-	err := engine.Execute(ctx, wf, task)
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "cycle")
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}).Return(&operator.Output{}, nil)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(mockExecutor.Calls))
+	assert.Equal(t, 1, maxActive)
 }
 
-// Test cancel
-func TestCancel(t *testing.T) {
+// Test node result cache reuses output across tasks with the same input
+func TestExecute_NodeCache(t *testing.T) {
 	mockUOW := new(MockUnitOfWork)
 	mockUOW.repos = newTestRepos()
 	mockExecutor := new(MockOperatorExecutor)
 
 	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.EnableResultCache(time.Hour)
 
-	taskID := uuid.New()
-
-	// Setup a running task
-	ctx, cancel := context.WithCancel(context.Background())
-	engine.tasks[taskID] = &taskExecution{
-		ctx:         ctx,
-		cancel:      cancel,
-		nodeResults: make(map[string]*operator.Output),
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "a", OperatorID: &opID},
+		},
 	}
 
-	// Cancel the task
-	err := engine.Cancel(context.Background(), taskID)
-	assert.NoError(t, err)
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{
+		Results: []operator.Result{{Type: "detection", Data: map[string]interface{}{"count": 1}}},
+	}, nil)
 
-	// Verify context is cancelled
-	select {
-	case <-ctx.Done():
-		// Expected
-	case <-time.After(100 * time.Millisecond):
-		t.Fatal("context not cancelled")
+	first := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, first))
+
+	second := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, second))
+
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+	assert.Equal(t, workflow.TaskStatusSuccess, second.Status)
+	if assert.Len(t, second.NodeExecutions, 1) {
+		assert.Equal(t, workflow.NodeExecCached, second.NodeExecutions[0].Status)
 	}
 
-	// Try to cancel non-existent task
-	err = engine.Cancel(context.Background(), uuid.New())
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "not running")
+	// bypass always executes
+	wf.Nodes[0].Config = &workflow.NodeConfig{Cache: &workflow.NodeCacheConfig{Bypass: true}}
+	third := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, third))
+	assert.Equal(t, 2, len(mockExecutor.Calls))
 }
 
-// Test get progress
-func TestGetProgress(t *testing.T) {
+// Test a cache hit copies the cached artifacts to the hitting task and falls back to
+// executing the node once the producing task's artifacts are gone
+func TestExecute_NodeCacheCopiesArtifacts(t *testing.T) {
 	mockUOW := new(MockUnitOfWork)
 	mockUOW.repos = newTestRepos()
+	artifacts := &recordingArtifactRepo{artifacts: make(map[uuid.UUID]*workflow.Artifact)}
+	mockUOW.repos.Artifacts = artifacts
 	mockExecutor := new(MockOperatorExecutor)
 
 	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.EnableResultCache(time.Hour)
 
-	taskID := uuid.New()
-
-	// Test running task
-	engine.tasks[taskID] = &taskExecution{
-		progress:    50,
-		nodeResults: make(map[string]*operator.Output),
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID:    uuid.New(),
+		Nodes: []workflow.Node{{ID: uuid.New(), NodeKey: "a", OperatorID: &opID}},
 	}
 
-	progress, err := engine.GetProgress(context.Background(), taskID)
-	assert.NoError(t, err)
-	assert.Equal(t, 50, progress)
-
-	// Test non-running task (fetch from database)
-	nonRunningTaskID := uuid.New()
-	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil).Once()
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{
+		Results: []operator.Result{{Type: "detection", Data: map[string]interface{}{"count": 1}}},
+	}, nil)
+
+	first := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, first))
+	second := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, second))
+
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+	owned, _ := artifacts.ListByTask(context.Background(), second.ID)
+	if assert.Len(t, owned, 1) && assert.Len(t, second.NodeExecutions, 1) {
+		assert.Equal(t, workflow.NodeExecCached, second.NodeExecutions[0].Status)
+		assert.Equal(t, []uuid.UUID{owned[0].ID}, second.NodeExecutions[0].ArtifactIDs)
+		assert.NotEqual(t, first.NodeExecutions[0].ArtifactIDs, second.NodeExecutions[0].ArtifactIDs)
+		assert.Equal(t, "a", owned[0].Data.Metadata["node_key"])
+	}
 
-	progress, err = engine.GetProgress(context.Background(), nonRunningTaskID)
-	assert.NoError(t, err)
+	// deleting the producing task removes its artifacts; the copies stay and the next task executes
+	produced, _ := artifacts.ListByTask(context.Background(), first.ID)
+	for _, a := range produced {
+		_ = artifacts.Delete(context.Background(), a.ID)
+	}
+	owned, _ = artifacts.ListByTask(context.Background(), second.ID)
+	assert.Len(t, owned, 1)
+
+	third := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	assert.NoError(t, engine.Execute(context.Background(), wf, third))
+	assert.Equal(t, 2, len(mockExecutor.Calls))
+	if assert.Len(t, third.NodeExecutions, 1) {
+		assert.Equal(t, workflow.NodeExecSuccess, third.NodeExecutions[0].Status)
+	}
 }
 
-// Test node execution with retry
-func TestExecuteNode_WithRetry(t *testing.T) {
+// Test operator progress is weighted into task progress and accepted through the signed callback
+func TestExecute_NodeProgress(t *testing.T) {
 	mockUOW := new(MockUnitOfWork)
 	mockUOW.repos = newTestRepos()
 	mockExecutor := new(MockOperatorExecutor)
 
 	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.EnableProgressCallback("http://goyavision:8080/", []byte("secret"))
 
-	operatorID := uuid.New()
-	node := &workflow.Node{
-		NodeKey:    "test_node",
-		OperatorID: &operatorID,
-		Config: &workflow.NodeConfig{
-			RetryCount: 2,
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "transcode", OperatorID: &opID, Config: &workflow.NodeConfig{ProgressWeight: 3}},
+			{ID: uuid.New(), NodeKey: "detect", OperatorID: &opID},
 		},
+		Edges: []workflow.Edge{{SourceKey: "transcode", TargetKey: "detect"}},
 	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
 
-	task := &workflow.Task{
-		ID: uuid.New(),
+	var observed []int
+	var callbackCodes []int
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+
+		callbackURL := operator.ProgressURLFromContext(ctx)
+		assert.True(t, strings.HasPrefix(callbackURL, "http://goyavision:8080"+ProgressCallbackPath+"?"))
+		target := strings.TrimPrefix(callbackURL, "http://goyavision:8080")
+
+		rec := httptest.NewRecorder()
+		engine.ProgressCallbackHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"progress": 0.5, "message": "half way"}`)))
+		callbackCodes = append(callbackCodes, rec.Code)
+
+		forged := strings.Replace(target, "token=", "token=0", 1)
+		rec = httptest.NewRecorder()
+		engine.ProgressCallbackHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, forged, strings.NewReader(`{"progress": 0.9}`)))
+		callbackCodes = append(callbackCodes, rec.Code)
+
+		progress, _ := engine.GetProgress(ctx, task.ID)
+		observed = append(observed, progress)
+		engine.mu.RLock()
+		exec := engine.tasks[task.ID]
+		engine.mu.RUnlock()
+		exec.mu.Lock()
+		exec.lastProgressSync = time.Time{}
+		exec.mu.Unlock()
+	}).Return(&operator.Output{}, nil)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{http.StatusNoContent, http.StatusUnauthorized, http.StatusNoContent, http.StatusUnauthorized}, callbackCodes)
+	// transcode (weight 3) half done, then transcode done and detect (weight 1) half done
+	assert.Equal(t, []int{37, 87}, observed)
+	for _, ne := range task.NodeExecutions {
+		assert.Equal(t, "half way", ne.Message)
 	}
+}
 
-	exec := &taskExecution{
-		nodeResults: make(map[string]*operator.Output),
+// Test asynchronous operators receive a per-call callback URL and callbacks reach the waiting call
+func TestExecute_AsyncCallback(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.EnableProgressCallback("http://goyavision:8080", []byte("secret"))
+
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID:    uuid.New(),
+		Nodes: []workflow.Node{{ID: uuid.New(), NodeKey: "detect", OperatorID: &opID}},
 	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
 
+	var codes []int
+	var target string
 	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+
+		registrar := operator.AsyncCallbackRegistrarFromContext(ctx)
+		if !assert.NotNil(t, registrar) {
+			return
+		}
+		callbackURL, callbacks, release := registrar()
+		assert.True(t, strings.HasPrefix(callbackURL, "http://goyavision:8080"+AsyncCallbackPath+"?"))
+		target = strings.TrimPrefix(callbackURL, "http://goyavision:8080")
+
+		send := func(url, body string) <-chan int {
+			done := make(chan int, 1)
+			go func() {
+				rec := httptest.NewRecorder()
+				engine.AsyncCallbackHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, url, strings.NewReader(body)))
+				done <- rec.Code
+			}()
+			return done
+		}
+
+		// rejected by the executor
+		result := send(target, `{"status": "unknown"}`)
+		req := <-callbacks
+		req.Reply(errors.New("unknown status"))
+		codes = append(codes, <-result)
+
+		// accepted
+		result = send(target, `{"status": "succeeded"}`)
+		req = <-callbacks
+		assert.JSONEq(t, `{"status": "succeeded"}`, string(req.Body))
+		req.Reply(nil)
+		codes = append(codes, <-result)
+
+		// forged token
+		codes = append(codes, <-send(strings.Replace(target, "token=", "token=0", 1), `{}`))
+
+		release()
+	}).Return(&operator.Output{}, nil)
+
+	assert.NoError(t, engine.Execute(context.Background(), wf, task))
+
+	// after the call returns the callback is no longer awaited
+	rec := httptest.NewRecorder()
+	engine.AsyncCallbackHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"status": "succeeded"}`)))
+	codes = append(codes, rec.Code)
+
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusNoContent, http.StatusUnauthorized, http.StatusNotFound}, codes)
+}
 
-	// First two calls fail, third succeeds
-	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
-		Return(nil, errors.New("temporary error")).Twice()
-	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
-		Return(&operator.Output{}, nil).Once()
+// captureEventBus records published events synchronously
+type captureEventBus struct {
+	mu     sync.Mutex
+	events []*event.TaskEvent
+}
 
-	ctx := context.Background()
-	err := engine.executeNode(ctx, node, task, exec)
+func (b *captureEventBus) Publish(ctx context.Context, e port.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, e.(*event.TaskEvent))
+	return nil
+}
 
-	assert.NoError(t, err)
-	// Should have called execute 3 times (1 original + 2 retries)
-	assert.Equal(t, 3, len(mockExecutor.Calls))
+func (b *captureEventBus) Subscribe(string, port.EventHandler, ...port.SubscribeOption) port.Subscription {
+	return nil
 }
+func (b *captureEventBus) Unsubscribe(port.Subscription) {}
 
-// Test node execution with timeout
-func TestExecuteNode_WithTimeout(t *testing.T) {
+// Test task, node and artifact events are published in execution order
+func TestExecute_PublishesTaskEvents(t *testing.T) {
 	mockUOW := new(MockUnitOfWork)
 	mockUOW.repos = newTestRepos()
 	mockExecutor := new(MockOperatorExecutor)
 
+	bus := &captureEventBus{}
 	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	engine.SetEventBus(bus)
 
-	operatorID := uuid.New()
-	node := &workflow.Node{
-		NodeKey:    "test_node",
-		OperatorID: &operatorID,
-		Config: &workflow.NodeConfig{
-			TimeoutSeconds: 1,
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "detect", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "notify", OperatorID: &opID},
 		},
+		Edges: []workflow.Edge{{SourceKey: "detect", TargetKey: "notify", Condition: &workflow.EdgeCondition{Type: "on_failure"}}},
 	}
+	task := &workflow.Task{ID: uuid.New(), TenantID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
 
-	task := &workflow.Task{
-		ID: uuid.New(),
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{
+		Results: []operator.Result{{Type: "detection", Data: map[string]interface{}{"count": 1}}},
+	}, nil)
+
+	assert.NoError(t, engine.Execute(context.Background(), wf, task))
+
+	var types []string
+	for i, e := range bus.events {
+		types = append(types, e.Type+":"+e.NodeKey+":"+e.Status)
+		assert.Equal(t, task.ID, e.TaskID)
+		assert.Equal(t, task.TenantID, e.TenantID)
+		assert.Equal(t, wf.ID, e.WorkflowID)
+		if i > 0 {
+			assert.Greater(t, e.Seq, bus.events[i-1].Seq)
+		}
 	}
-
-	exec := &taskExecution{
-		nodeResults: make(map[string]*operator.Output),
+	assert.Equal(t, []string{
+		"task_status::running",
+		"node_started:detect:running",
+		"artifact_created:detect:running",
+		"node_finished:detect:success",
+		"task_progress::running",
+		"node_finished:notify:skipped",
+		"task_progress::running",
+		"task_status::success",
+	}, types)
+
+	artifact := bus.events[2]
+	if assert.NotNil(t, artifact.ArtifactID) {
+		assert.Equal(t, string(workflow.ArtifactTypeResult), artifact.ArtifactType)
 	}
+	assert.Equal(t, 100, bus.events[len(bus.events)-1].Progress)
+}
 
-	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+// Test that a task running past its deadline is stopped and marked timed out
+func TestExecute_Deadline(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "slow", OperatorID: &operatorID},
+		},
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+		Deadline:   &deadline,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Return(nil, context.DeadlineExceeded)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.ErrorIs(t, err, errTaskTimedOut)
+	assert.Equal(t, workflow.TaskStatusTimedOut, task.Status)
+	assert.Contains(t, task.Error, "deadline")
+	assert.NotNil(t, task.CompletedAt)
+}
+
+// Test execute with cycle detection
+func TestExecute_CycleDetection(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "A"},
+			{ID: uuid.New(), NodeKey: "B"},
+			{ID: uuid.New(), NodeKey: "C"},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "A", TargetKey: "B"},
+			{SourceKey: "B", TargetKey: "C"},
+			{SourceKey: "C", TargetKey: "A"},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	ctx := context.Background()
+	err := engine.Execute(ctx, wf, task)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+}
+
+// Test cancel
+func TestCancel(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	taskID := uuid.New()
+
+	// Setup a running task
+	ctx, cancel := context.WithCancelCause(context.Background())
+	engine.tasks[taskID] = &taskExecution{
+		ctx:         ctx,
+		cancel:      cancel,
+		nodeResults: make(map[string]*operator.Output),
+	}
+
+	// Cancel the task
+	err := engine.Cancel(context.Background(), taskID)
+	assert.NoError(t, err)
+
+	// Verify context is cancelled
+	select {
+	case <-ctx.Done():
+		// Expected
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("context not cancelled")
+	}
+
+	// Try to cancel non-existent task
+	err = engine.Cancel(context.Background(), uuid.New())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not running")
+}
+
+// Test get progress
+func TestGetProgress(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	taskID := uuid.New()
+
+	// Test running task
+	engine.tasks[taskID] = &taskExecution{
+		progress:    50,
+		nodeResults: make(map[string]*operator.Output),
+	}
+
+	progress, err := engine.GetProgress(context.Background(), taskID)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, progress)
+
+	// Test non-running task (fetch from database)
+	nonRunningTaskID := uuid.New()
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil).Once()
+
+	progress, err = engine.GetProgress(context.Background(), nonRunningTaskID)
+	assert.NoError(t, err)
+}
+
+// Test node execution with retry
+func TestExecuteNode_WithRetry(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	node := &workflow.Node{
+		NodeKey:    "test_node",
+		OperatorID: &operatorID,
+		Config: &workflow.NodeConfig{
+			RetryCount: 2,
+		},
+	}
+
+	task := &workflow.Task{
+		ID: uuid.New(),
+	}
+
+	exec := &taskExecution{
+		nodeResults: make(map[string]*operator.Output),
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+
+	// First two calls fail, third succeeds
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("temporary error")).Twice()
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(&operator.Output{}, nil).Once()
+
+	ctx := context.Background()
+	err := engine.executeNode(ctx, node, task, exec)
+
+	assert.NoError(t, err)
+	// Should have called execute 3 times (1 original + 2 retries)
+	assert.Equal(t, 3, len(mockExecutor.Calls))
+}
+
+// Test retry policy classifies errors, honours Retry-After and records attempts
+func TestExecuteNode_RetryPolicy(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	node := &workflow.Node{
+		NodeKey:    "test_node",
+		OperatorID: &operatorID,
+		Config: &workflow.NodeConfig{
+			Retry: &workflow.RetryPolicy{MaxAttempts: 3, InitialBackoffMs: 1},
+		},
+	}
+
+	task := &workflow.Task{ID: uuid.New()}
+	exec := &taskExecution{
+		nodeResults:    make(map[string]*operator.Output),
+		nodeExecutions: map[string]*workflow.NodeExecution{"test_node": {NodeKey: "test_node"}},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &operator.ExecError{Class: operator.ErrorClassServer, StatusCode: 502, Err: errors.New("bad gateway")}).Once()
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &operator.ExecError{Class: operator.ErrorClassRateLimited, StatusCode: 429, RetryAfter: 20 * time.Millisecond, Err: errors.New("slow down")}).Once()
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(&operator.Output{}, nil).Once()
+
+	err := engine.executeNode(context.Background(), node, task, exec)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(mockExecutor.Calls))
+
+	attempts := exec.nodeExecutions["test_node"].Attempts
+	if assert.Len(t, attempts, 3) {
+		assert.Equal(t, operator.ErrorClassServer, attempts[0].ErrorClass)
+		assert.Equal(t, operator.ErrorClassRateLimited, attempts[1].ErrorClass)
+		assert.Equal(t, int64(20), attempts[1].BackoffMs)
+		assert.Empty(t, attempts[2].Error)
+	}
+}
+
+// Test Retry-After is capped by max_backoff_ms and by the task deadline
+func TestExecuteNode_RetryAfterClamped(t *testing.T) {
+	run := func(ctx context.Context, policy *workflow.RetryPolicy) []workflow.NodeAttempt {
+		mockUOW := new(MockUnitOfWork)
+		mockUOW.repos = newTestRepos()
+		mockExecutor := new(MockOperatorExecutor)
+
+		engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+		operatorID := uuid.New()
+		node := &workflow.Node{
+			NodeKey:    "test_node",
+			OperatorID: &operatorID,
+			Config:     &workflow.NodeConfig{Retry: policy},
+		}
+		task := &workflow.Task{ID: uuid.New()}
+		exec := &taskExecution{
+			nodeResults:    make(map[string]*operator.Output),
+			nodeExecutions: map[string]*workflow.NodeExecution{"test_node": {NodeKey: "test_node"}},
+		}
+
+		mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+		mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, &operator.ExecError{Class: operator.ErrorClassRateLimited, StatusCode: 429, RetryAfter: time.Hour, Err: errors.New("slow down")}).Once()
+		mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+			Return(&operator.Output{}, nil).Once()
+
+		_ = engine.executeNode(ctx, node, task, exec)
+		return exec.nodeExecutions["test_node"].Attempts
+	}
+
+	attempts := run(context.Background(), &workflow.RetryPolicy{MaxAttempts: 2, InitialBackoffMs: 1, MaxBackoffMs: 20})
+	if assert.Len(t, attempts, 2) {
+		assert.Equal(t, int64(20), attempts[0].BackoffMs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	attempts = run(ctx, &workflow.RetryPolicy{MaxAttempts: 2, InitialBackoffMs: 1})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	if assert.NotEmpty(t, attempts) {
+		assert.LessOrEqual(t, attempts[0].BackoffMs, int64(50))
+	}
+}
+
+// Test non-retryable errors fail the node without retrying
+func TestExecuteNode_NonRetryableError(t *testing.T) {
+	for _, execErr := range []error{
+		operator.InvalidError("http endpoint is required"),
+		&operator.ExecError{Class: operator.ErrorClassClient, StatusCode: 400, Err: errors.New("bad request")},
+	} {
+		mockUOW := new(MockUnitOfWork)
+		mockUOW.repos = newTestRepos()
+		mockExecutor := new(MockOperatorExecutor)
+
+		engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+		operatorID := uuid.New()
+		node := &workflow.Node{
+			NodeKey:    "test_node",
+			OperatorID: &operatorID,
+			Config:     &workflow.NodeConfig{RetryCount: 3},
+		}
+		task := &workflow.Task{ID: uuid.New()}
+		exec := &taskExecution{nodeResults: make(map[string]*operator.Output)}
+
+		mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+		mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(nil, execErr)
+
+		err := engine.executeNode(context.Background(), node, task, exec)
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, len(mockExecutor.Calls))
+	}
+}
+
+// Test backoff between attempts stops as soon as the task is cancelled
+func TestExecuteNode_RetryBackoffCancelled(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	node := &workflow.Node{
+		NodeKey:    "test_node",
+		OperatorID: &operatorID,
+		Config: &workflow.NodeConfig{
+			Retry: &workflow.RetryPolicy{MaxAttempts: 3, InitialBackoffMs: 60000},
+		},
+	}
+	task := &workflow.Task{ID: uuid.New()}
+	exec := &taskExecution{nodeResults: make(map[string]*operator.Output)}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &operator.ExecError{Class: operator.ErrorClassConnection, Err: errors.New("connection refused")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := engine.executeNode(ctx, node, task, exec)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+}
+
+// Test node execution with timeout
+func TestExecuteNode_WithTimeout(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	node := &workflow.Node{
+		NodeKey:    "test_node",
+		OperatorID: &operatorID,
+		Config: &workflow.NodeConfig{
+			TimeoutSeconds: 1,
+		},
+	}
+
+	task := &workflow.Task{
+		ID: uuid.New(),
+	}
+
+	exec := &taskExecution{
+		nodeResults: make(map[string]*operator.Output),
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
 
 	// Simulate slow execution
 	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).
@@ -729,3 +1630,866 @@ func TestExecuteNode_WithTimeout(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+// Test resume skips nodes restored from checkpoints
+func TestResume_FromCheckpoints(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	op1ID := uuid.New()
+	op2ID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "extract", OperatorID: &op1ID},
+			{ID: uuid.New(), NodeKey: "detect", OperatorID: &op2ID},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "extract", TargetKey: "detect"},
+		},
+	}
+
+	startedAt := time.Now().Add(-time.Hour)
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusRunning,
+		StartedAt:  &startedAt,
+	}
+
+	checkpoints := mockUOW.repos.TaskCheckpoints.(*stubCheckpointRepo)
+	_ = checkpoints.Save(context.Background(), &workflow.TaskCheckpoint{
+		TaskID:  task.ID,
+		NodeKey: "extract",
+		Output: &operator.Output{
+			OutputAssets: []operator.OutputAsset{{Type: "image", Path: "/frames/1.jpg"}},
+		},
+		Execution: workflow.NodeExecution{NodeKey: "extract", Status: workflow.NodeExecSuccess},
+	})
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		return input.Params["extract_assets"] != nil
+	})).Return(&operator.Output{}, nil).Once()
+
+	err := engine.Resume(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	// Only the unfinished node is executed
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+	assert.Equal(t, workflow.TaskStatusSuccess, task.Status)
+	assert.Equal(t, startedAt, *task.StartedAt)
+
+	cps, _ := checkpoints.ListByTask(context.Background(), task.ID)
+	assert.Equal(t, 2, len(cps))
+}
+
+// Test that a new task is pinned to the workflow revision and active operator versions
+func TestExecute_PinsRevisionAndOperatorVersions(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID:    uuid.New(),
+		Nodes: []workflow.Node{{ID: uuid.New(), NodeKey: "detect", OperatorID: &opID}},
+	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{}, nil)
+
+	assert.NoError(t, engine.Execute(context.Background(), wf, task))
+
+	// Workflows without revisions get their current graph recorded
+	revisions := mockUOW.repos.WorkflowRevisions.(*stubRevisionRepo)
+	if assert.Len(t, revisions.revisions, 1) && assert.NotNil(t, task.RevisionID) {
+		assert.Equal(t, revisions.revisions[0].ID, *task.RevisionID)
+		assert.Equal(t, 1, revisions.revisions[0].Revision)
+	}
+	active, _ := mockUOW.repos.Operators.GetWithActiveVersion(context.Background(), opID)
+	assert.Equal(t, map[string]uuid.UUID{"detect": active.ActiveVersion.ID}, task.OperatorVersions)
+}
+
+// Test that resuming a task runs its pinned revision and operator versions
+// after the workflow has been edited and another operator version activated
+func TestResume_PinnedRevision(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	pinnedVersion := &operator.OperatorVersion{ID: uuid.New(), Version: "0.9.0", ExecMode: operator.ExecModeHTTP}
+	versions := mockUOW.repos.OperatorVersions.(*stubOperatorVersionRepo)
+	_ = versions.Create(context.Background(), pinnedVersion)
+
+	// The task started on a single-node revision
+	original := &workflow.Workflow{
+		ID:    uuid.New(),
+		Nodes: []workflow.Node{{NodeKey: "detect", OperatorID: &opID}},
+	}
+	rev := workflow.NewRevision(original)
+	rev.Revision = 1
+	_ = mockUOW.repos.WorkflowRevisions.Create(context.Background(), rev)
+
+	// The workflow has since been edited
+	currentRevisionID := uuid.New()
+	wf := &workflow.Workflow{
+		ID:         original.ID,
+		Revision:   2,
+		RevisionID: &currentRevisionID,
+		Nodes: []workflow.Node{
+			{NodeKey: "detect", OperatorID: &opID},
+			{NodeKey: "notify", OperatorID: &opID},
+		},
+		Edges: []workflow.Edge{{SourceKey: "detect", TargetKey: "notify"}},
+	}
+	task := &workflow.Task{
+		ID:               uuid.New(),
+		WorkflowID:       wf.ID,
+		RevisionID:       &rev.ID,
+		OperatorVersions: map[string]uuid.UUID{"detect": pinnedVersion.ID},
+		Status:           workflow.TaskStatusRunning,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.MatchedBy(func(v *operator.OperatorVersion) bool {
+		return v.ID == pinnedVersion.ID
+	}), mock.Anything).Return(&operator.Output{}, nil).Once()
+
+	assert.NoError(t, engine.Resume(context.Background(), wf, task))
+
+	// Only the pinned graph runs, with the pinned operator version
+	mockExecutor.AssertExpectations(t)
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+	assert.Equal(t, workflow.TaskStatusSuccess, task.Status)
+	assert.Equal(t, rev.ID, *task.RevisionID)
+}
+
+// Test edge condition expressions
+func TestExecute_EdgeExpressions(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	operatorID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "detect", OperatorID: &operatorID},
+			{ID: uuid.New(), NodeKey: "alert"},
+			{ID: uuid.New(), NodeKey: "archive"},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "detect", TargetKey: "alert", Condition: &workflow.EdgeCondition{
+				Type:       workflow.EdgeConditionOnSuccess,
+				Expression: "results[0].confidence > value.threshold && len(timeline) == 0",
+				Value:      map[string]interface{}{"threshold": 0.8},
+			}},
+			{SourceKey: "detect", TargetKey: "archive", Condition: &workflow.EdgeCondition{
+				Expression: "results[0].confidence > 0.95",
+			}},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(
+		&operator.Output{
+			Results: []operator.Result{{Type: "detection", Confidence: 0.9}},
+		},
+		nil,
+	)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	statuses := make(map[string]workflow.NodeExecutionStatus)
+	for _, ne := range task.NodeExecutions {
+		statuses[ne.NodeKey] = ne.Status
+	}
+	assert.Equal(t, workflow.NodeExecSuccess, statuses["alert"])
+	assert.Equal(t, workflow.NodeExecSkipped, statuses["archive"])
+}
+
+// Test edge expression evaluation errors fail the downstream node
+func TestExecute_EdgeExpressionError(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "start"},
+			{ID: uuid.New(), NodeKey: "next"},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "start", TargetKey: "next", Condition: &workflow.EdgeCondition{
+				Expression: "diagnostics > 1",
+			}},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.Error(t, err)
+	assert.Equal(t, workflow.TaskStatusFailed, task.Status)
+}
+
+// Test map node fan-out over upstream assets
+func TestExecute_MapNode(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	extractID := uuid.New()
+	detectID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "extract", NodeType: workflow.NodeTypeOperator, OperatorID: &extractID},
+			{
+				ID:         uuid.New(),
+				NodeKey:    "detect",
+				NodeType:   workflow.NodeTypeMap,
+				OperatorID: &detectID,
+				Config: &workflow.NodeConfig{
+					Map: &workflow.MapConfig{Items: "extract_assets", ItemParam: "frame", Parallelism: 2},
+				},
+			},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "extract", TargetKey: "detect"},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		_, ok := input.Params["item_index"]
+		return !ok
+	})).Return(&operator.Output{
+		OutputAssets: []operator.OutputAsset{
+			{Type: "image", Path: "/frames/0.jpg"},
+			{Type: "image", Path: "/frames/1.jpg"},
+			{Type: "image", Path: "/frames/2.jpg"},
+		},
+	}, nil).Once()
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		frame, ok := input.Params["frame"].(operator.OutputAsset)
+		return ok && frame.Path != ""
+	})).Return(&operator.Output{
+		Results: []operator.Result{{Type: "detection", Confidence: 0.9}},
+	}, nil).Times(3)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	mockExecutor.AssertExpectations(t)
+
+	var detectExec *workflow.NodeExecution
+	for i := range task.NodeExecutions {
+		if task.NodeExecutions[i].NodeKey == "detect" {
+			detectExec = &task.NodeExecutions[i]
+		}
+	}
+	if assert.NotNil(t, detectExec) {
+		assert.Equal(t, workflow.NodeExecSuccess, detectExec.Status)
+		assert.Len(t, detectExec.Items, 3)
+		for _, item := range detectExec.Items {
+			assert.Equal(t, workflow.NodeExecSuccess, item.Status)
+		}
+	}
+
+	checkpoints, _ := mockUOW.repos.TaskCheckpoints.ListByTask(context.Background(), task.ID)
+	for _, cp := range checkpoints {
+		if cp.NodeKey == "detect" {
+			assert.Len(t, cp.Output.Results, 3)
+			assert.Equal(t, 3, cp.Output.Diagnostics["map_succeeded"])
+		}
+	}
+}
+
+// Test map node keeps going on item failures when configured
+func TestExecute_MapNodeContinueOnError(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	detectID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{
+				ID:         uuid.New(),
+				NodeKey:    "detect",
+				NodeType:   workflow.NodeTypeMap,
+				OperatorID: &detectID,
+				Config: &workflow.NodeConfig{
+					Map: &workflow.MapConfig{Items: "urls", ContinueOnError: true},
+				},
+			},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:          uuid.New(),
+		WorkflowID:  wf.ID,
+		Status:      workflow.TaskStatusPending,
+		InputParams: map[string]interface{}{"urls": []interface{}{"a", "b"}},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		return input.Params["item"] == "a"
+	})).Return(&operator.Output{Results: []operator.Result{{Type: "ok"}}}, nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		return input.Params["item"] == "b"
+	})).Return(nil, errors.New("bad item"))
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, workflow.NodeExecSuccess, task.NodeExecutions[0].Status)
+	items := task.NodeExecutions[0].Items
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, workflow.NodeExecSuccess, items[0].Status)
+		assert.Equal(t, workflow.NodeExecFailed, items[1].Status)
+		assert.Contains(t, items[1].Error, "bad item")
+	}
+}
+
+// Test map nodes reject params that would be overwritten by the injected item and item_index
+func TestExecute_MapNodeReservedParams(t *testing.T) {
+	detectID := uuid.New()
+	mapNode := func(cfg *workflow.NodeConfig) *workflow.Node {
+		cfg.Map = &workflow.MapConfig{Items: "urls"}
+		return &workflow.Node{ID: uuid.New(), NodeKey: "detect", NodeType: workflow.NodeTypeMap, OperatorID: &detectID, Config: cfg}
+	}
+
+	assert.Error(t, mapNode(&workflow.NodeConfig{Params: map[string]interface{}{"item_index": 1}}).Validate())
+	assert.Error(t, mapNode(&workflow.NodeConfig{Params: map[string]interface{}{"item": "x"}}).Validate())
+	assert.Error(t, mapNode(&workflow.NodeConfig{Inputs: []workflow.InputMapping{{From: "input.urls", To: "params.item_index"}}}).Validate())
+	assert.Error(t, (&workflow.MapConfig{Items: "urls", ItemParam: "item_index"}).Validate())
+	assert.NoError(t, mapNode(&workflow.NodeConfig{Params: map[string]interface{}{"threshold": 0.5}}).Validate())
+
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	wf := &workflow.Workflow{ID: uuid.New(), Nodes: []workflow.Node{*mapNode(&workflow.NodeConfig{})}}
+	task := &workflow.Task{
+		ID:          uuid.New(),
+		WorkflowID:  wf.ID,
+		Status:      workflow.TaskStatusPending,
+		InputParams: map[string]interface{}{"urls": []interface{}{"a", "b"}, "item_index": 7},
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "item_index")
+	}
+	mockExecutor.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test sub workflow node runs the child workflow as a linked child task
+func TestExecute_SubWorkflowNode(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	child := &workflow.Workflow{
+		ID:      uuid.New(),
+		Code:    "probe-chain",
+		Version: "1.0.0",
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "probe", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "thumbnail", OperatorID: &opID},
+		},
+		Edges: []workflow.Edge{{SourceKey: "probe", TargetKey: "thumbnail"}},
+	}
+	mockUOW.repos.Workflows.(*stubWorkflowRepo).workflows = []*workflow.Workflow{child}
+
+	parent := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "ingest"},
+			{
+				ID:       uuid.New(),
+				NodeKey:  "chain",
+				NodeType: workflow.NodeTypeSubWorkflow,
+				Config: &workflow.NodeConfig{
+					SubWorkflow: &workflow.SubWorkflowConfig{WorkflowCode: "probe-chain", Version: "1.0.0"},
+				},
+			},
+		},
+		Edges: []workflow.Edge{{SourceKey: "ingest", TargetKey: "chain"}},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: parent.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(
+		&operator.Output{Results: []operator.Result{{Type: "thumbnail"}}},
+		nil,
+	).Times(2)
+
+	err := engine.Execute(context.Background(), parent, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, workflow.TaskStatusSuccess, task.Status)
+	mockExecutor.AssertExpectations(t)
+
+	created := mockUOW.repos.Tasks.(*stubTaskRepo).created
+	if assert.Len(t, created, 1) {
+		childTask := created[0]
+		assert.Equal(t, child.ID, childTask.WorkflowID)
+		assert.Equal(t, task.ID, *childTask.CallerTaskID)
+		assert.Equal(t, "chain", childTask.CallerNodeKey)
+		assert.Equal(t, workflow.TaskStatusSuccess, childTask.Status)
+
+		for _, ne := range task.NodeExecutions {
+			if ne.NodeKey == "chain" {
+				assert.Equal(t, childTask.ID, *ne.ChildTaskID)
+			}
+		}
+	}
+
+	checkpoints, _ := mockUOW.repos.TaskCheckpoints.ListByTask(context.Background(), task.ID)
+	for _, cp := range checkpoints {
+		if cp.NodeKey == "chain" {
+			// Only the child's sink node output is exposed
+			assert.Len(t, cp.Output.Results, 1)
+			assert.Equal(t, "probe-chain", cp.Output.Diagnostics["sub_workflow_code"])
+		}
+	}
+}
+
+// Test a sub workflow node referencing a revision number runs that revision's
+// graph and pins the child task to it, even after the child has been edited
+func TestExecute_SubWorkflowNodePinnedRevision(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	original := &workflow.Workflow{
+		ID:    uuid.New(),
+		Code:  "probe-chain",
+		Nodes: []workflow.Node{{NodeKey: "probe", OperatorID: &opID}},
+	}
+	rev := workflow.NewRevision(original)
+	rev.Revision = 1
+	_ = mockUOW.repos.WorkflowRevisions.Create(context.Background(), rev)
+
+	currentRevisionID := uuid.New()
+	child := &workflow.Workflow{
+		ID:         original.ID,
+		Code:       "probe-chain",
+		Version:    "2.0.0",
+		Revision:   2,
+		RevisionID: &currentRevisionID,
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "probe", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "thumbnail", OperatorID: &opID},
+		},
+		Edges: []workflow.Edge{{SourceKey: "probe", TargetKey: "thumbnail"}},
+	}
+	mockUOW.repos.Workflows.(*stubWorkflowRepo).workflows = []*workflow.Workflow{child}
+
+	parent := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{{
+			ID:       uuid.New(),
+			NodeKey:  "chain",
+			NodeType: workflow.NodeTypeSubWorkflow,
+			Config: &workflow.NodeConfig{
+				SubWorkflow: &workflow.SubWorkflowConfig{WorkflowCode: "probe-chain", Version: "1"},
+			},
+		}},
+	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: parent.ID, Status: workflow.TaskStatusPending}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Return(&operator.Output{}, nil).Once()
+
+	assert.NoError(t, engine.Execute(context.Background(), parent, task))
+	mockExecutor.AssertExpectations(t)
+
+	created := mockUOW.repos.Tasks.(*stubTaskRepo).created
+	if assert.Len(t, created, 1) && assert.NotNil(t, created[0].RevisionID) {
+		assert.Equal(t, rev.ID, *created[0].RevisionID)
+	}
+}
+
+// Test a sub workflow node referencing an unknown version fails
+func TestExecute_SubWorkflowNodeUnknownRevision(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	child := &workflow.Workflow{
+		ID:      uuid.New(),
+		Code:    "probe-chain",
+		Version: "1.0.0",
+		Nodes:   []workflow.Node{{ID: uuid.New(), NodeKey: "probe", OperatorID: &opID}},
+	}
+	mockUOW.repos.Workflows.(*stubWorkflowRepo).workflows = []*workflow.Workflow{child}
+
+	parent := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{{
+			ID:       uuid.New(),
+			NodeKey:  "chain",
+			NodeType: workflow.NodeTypeSubWorkflow,
+			Config: &workflow.NodeConfig{
+				SubWorkflow: &workflow.SubWorkflowConfig{WorkflowCode: "probe-chain", Version: "3"},
+			},
+		}},
+	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: parent.ID, Status: workflow.TaskStatusPending}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+
+	err := engine.Execute(context.Background(), parent, task)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "revision 3 not found")
+	}
+	mockExecutor.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test cancelling the parent task cancels the running child task
+func TestCancel_SubWorkflowCascade(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	opID := uuid.New()
+	child := &workflow.Workflow{
+		ID:    uuid.New(),
+		Code:  "slow",
+		Nodes: []workflow.Node{{ID: uuid.New(), NodeKey: "wait", OperatorID: &opID}},
+	}
+	mockUOW.repos.Workflows.(*stubWorkflowRepo).workflows = []*workflow.Workflow{child}
+
+	parent := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{{
+			ID:       uuid.New(),
+			NodeKey:  "call",
+			NodeType: workflow.NodeTypeSubWorkflow,
+			Config: &workflow.NodeConfig{
+				SubWorkflow: &workflow.SubWorkflowConfig{WorkflowID: &child.ID},
+			},
+		}},
+	}
+
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: parent.ID, Status: workflow.TaskStatusPending}
+
+	started := make(chan struct{})
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		close(started)
+		<-args.Get(0).(context.Context).Done()
+	}).Return(nil, context.Canceled)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.Execute(context.Background(), parent, task)
+	}()
+
+	<-started
+	assert.NoError(t, engine.Cancel(context.Background(), task.ID))
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("parent task did not stop after cancel")
+	}
+
+	assert.Equal(t, workflow.TaskStatusCancelled, task.Status)
+	created := mockUOW.repos.Tasks.(*stubTaskRepo).created
+	if assert.Len(t, created, 1) {
+		assert.Equal(t, workflow.TaskStatusCancelled, created[0].Status)
+	}
+}
+
+// Test a task cancelled outside the engine stops at its next write and keeps the cancelled status
+func TestExecute_CancelledOutsideEngine(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	bus := &captureEventBus{}
+	engine.SetEventBus(bus)
+
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "first", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "second", OperatorID: &opID},
+		},
+		Edges: []workflow.Edge{{SourceKey: "first", TargetKey: "second"}},
+	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusPending}
+	tasks := mockUOW.repos.Tasks.(*stubTaskRepo)
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		// the API cancels the task while the first node runs
+		tasks.mu.Lock()
+		tasks.created = append(tasks.created, &workflow.Task{ID: task.ID, Status: workflow.TaskStatusCancelled, Error: "cancelled by user"})
+		tasks.fenceErr = workflow.ErrTaskStopped
+		tasks.mu.Unlock()
+	}).Return(&operator.Output{}, nil)
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.Error(t, err)
+	mockExecutor.AssertNumberOfCalls(t, "Execute", 1)
+	assert.Equal(t, workflow.TaskStatusCancelled, task.Status)
+	assert.Equal(t, "cancelled by user", task.Error)
+	for _, ev := range bus.events {
+		if ev.EventType() == event.EventTypeTaskStatus {
+			assert.NotEqual(t, string(workflow.TaskStatusSuccess), ev.Status)
+		}
+	}
+}
+
+func TestExecute_LeaseLost(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+	bus := &captureEventBus{}
+	engine.SetEventBus(bus)
+
+	opID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "first", OperatorID: &opID},
+			{ID: uuid.New(), NodeKey: "second", OperatorID: &opID},
+		},
+		Edges: []workflow.Edge{{SourceKey: "first", TargetKey: "second"}},
+	}
+	task := &workflow.Task{ID: uuid.New(), WorkflowID: wf.ID, Status: workflow.TaskStatusRunning, LeaseOwner: "worker-a"}
+	tasks := mockUOW.repos.Tasks.(*stubTaskRepo)
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		// the lease expires and is reclaimed while the first node runs
+		tasks.mu.Lock()
+		tasks.fenceErr = workflow.ErrLeaseLost
+		tasks.mu.Unlock()
+	}).Return(&operator.Output{}, nil)
+
+	err := engine.Resume(context.Background(), wf, task)
+
+	assert.ErrorIs(t, err, workflow.ErrLeaseLost)
+	mockExecutor.AssertNumberOfCalls(t, "Execute", 1)
+	for _, ev := range bus.events {
+		if ev.EventType() == event.EventTypeTaskStatus {
+			assert.Equal(t, string(workflow.TaskStatusRunning), ev.Status)
+		}
+	}
+}
+
+// Test approval node pauses the task and resumes after a decision
+func TestExecute_ApprovalNode(t *testing.T) {
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = newTestRepos()
+	mockExecutor := new(MockOperatorExecutor)
+
+	engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+	detectID := uuid.New()
+	publishID := uuid.New()
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{ID: uuid.New(), NodeKey: "detect", OperatorID: &detectID},
+			{ID: uuid.New(), NodeKey: "review", NodeType: workflow.NodeTypeApproval, Config: &workflow.NodeConfig{
+				Approval: &workflow.ApprovalConfig{TimeoutSeconds: 3600},
+			}},
+			{ID: uuid.New(), NodeKey: "publish", OperatorID: &publishID},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "detect", TargetKey: "review"},
+			{SourceKey: "review", TargetKey: "publish"},
+		},
+	}
+
+	task := &workflow.Task{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     workflow.TaskStatusPending,
+	}
+
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		return input.Params["review_results"] == nil
+	})).Return(&operator.Output{
+		Results: []operator.Result{{Type: "label", Data: map[string]interface{}{"label": "unsafe"}}},
+	}, nil).Once()
+
+	err := engine.Execute(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, workflow.TaskStatusWaiting, task.Status)
+	assert.Equal(t, 1, len(mockExecutor.Calls))
+
+	var review *workflow.NodeExecution
+	for i := range task.NodeExecutions {
+		if task.NodeExecutions[i].NodeKey == "review" {
+			review = &task.NodeExecutions[i]
+		}
+	}
+	if assert.NotNil(t, review) && assert.NotNil(t, review.Approval) {
+		assert.Equal(t, workflow.NodeExecWaiting, review.Status)
+		assert.NotNil(t, review.Approval.Deadline)
+		assert.NotNil(t, review.Approval.Payload["detect_results"])
+	}
+
+	// The reviewer approves with an edited payload
+	approverID := uuid.New()
+	err = task.DecideApproval("review", true, approverID, "looks fine", map[string]interface{}{"label": "safe"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, task.DecideApproval("review", false, approverID, "", nil), workflow.ErrApprovalDecided)
+
+	mockExecutor.On("Execute", mock.Anything, mock.Anything, mock.MatchedBy(func(input *operator.Input) bool {
+		results, ok := input.Params["review_results"].([]operator.Result)
+		return ok && len(results) == 1 && results[0].Data["label"] == "safe"
+	})).Return(&operator.Output{}, nil).Once()
+
+	err = engine.Resume(context.Background(), wf, task)
+
+	assert.NoError(t, err)
+	assert.Equal(t, workflow.TaskStatusSuccess, task.Status)
+	// detect is restored from its checkpoint, only publish runs
+	assert.Equal(t, 2, len(mockExecutor.Calls))
+	for _, ne := range task.NodeExecutions {
+		if ne.NodeKey == "review" {
+			assert.Equal(t, workflow.NodeExecSuccess, ne.Status)
+			assert.Equal(t, workflow.ApprovalApproved, ne.Approval.Decision)
+			assert.Equal(t, approverID, *ne.Approval.ApproverID)
+		}
+	}
+}
+
+// Test approval timeout policies
+func TestExecute_ApprovalTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		onTimeout  workflow.ApprovalTimeoutAction
+		wantStatus workflow.TaskStatus
+		wantResult workflow.ApprovalDecision
+	}{
+		{"auto reject", "", workflow.TaskStatusFailed, workflow.ApprovalRejected},
+		{"auto approve", workflow.ApprovalTimeoutApprove, workflow.TaskStatusSuccess, workflow.ApprovalApproved},
+		{"fail", workflow.ApprovalTimeoutFail, workflow.TaskStatusFailed, workflow.ApprovalTimedOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUOW := new(MockUnitOfWork)
+			mockUOW.repos = newTestRepos()
+			mockExecutor := new(MockOperatorExecutor)
+
+			engine := NewDAGWorkflowEngine(mockUOW, mockExecutor)
+
+			wf := &workflow.Workflow{
+				ID: uuid.New(),
+				Nodes: []workflow.Node{
+					{ID: uuid.New(), NodeKey: "review", NodeType: workflow.NodeTypeApproval, Config: &workflow.NodeConfig{
+						Approval: &workflow.ApprovalConfig{TimeoutSeconds: 60, OnTimeout: tt.onTimeout},
+					}},
+				},
+			}
+
+			deadline := time.Now().Add(-time.Minute)
+			task := &workflow.Task{
+				ID:         uuid.New(),
+				WorkflowID: wf.ID,
+				Status:     workflow.TaskStatusWaiting,
+				NodeExecutions: []workflow.NodeExecution{{
+					NodeKey:  "review",
+					Status:   workflow.NodeExecWaiting,
+					Approval: &workflow.NodeApproval{Deadline: &deadline},
+				}},
+			}
+			assert.Equal(t, deadline, *task.ApprovalDeadline())
+
+			mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+
+			_ = engine.Resume(context.Background(), wf, task)
+
+			assert.Equal(t, tt.wantStatus, task.Status)
+			if assert.Len(t, task.NodeExecutions, 1) {
+				approval := task.NodeExecutions[0].Approval
+				assert.Equal(t, tt.wantResult, approval.Decision)
+				assert.True(t, approval.AutoDecided)
+				assert.Nil(t, approval.ApproverID)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/workflow"
+)
+
+// eventSeq numbers published task events across all engines of this process
+var eventSeq atomic.Int64
+
+// SetEventBus publishes task and node state changes on bus. Without a bus no events
+// are published.
+func (e *DAGWorkflowEngine) SetEventBus(bus port.EventBus) {
+	e.eventBus = bus
+}
+
+// publishTaskEvent publishes an event of the task. fill sets the event specific fields.
+// Publishing never fails the task; events are delivered even when ctx is cancelled.
+func (e *DAGWorkflowEngine) publishTaskEvent(ctx context.Context, task *workflow.Task, eventType string, fill func(*event.TaskEvent)) {
+	if e.eventBus == nil || task == nil {
+		return
+	}
+	evt := event.NewTaskEvent(eventType, task.ID, task.TenantID, task.WorkflowID)
+	evt.Status = string(task.Status)
+	evt.Progress = task.Progress
+	if fill != nil {
+		fill(evt)
+	}
+	evt.Seq = eventSeq.Add(1)
+	_ = e.eventBus.Publish(context.WithoutCancel(ctx), evt)
+}
+
+// publishTaskStatus publishes the current status of the task
+func (e *DAGWorkflowEngine) publishTaskStatus(ctx context.Context, task *workflow.Task) {
+	e.publishTaskEvent(ctx, task, event.EventTypeTaskStatus, func(evt *event.TaskEvent) {
+		evt.Error = task.Error
+	})
+}
+
+// publishNodeEvent publishes the current state of a node
+func (e *DAGWorkflowEngine) publishNodeEvent(ctx context.Context, task *workflow.Task, exec *taskExecution, eventType, nodeKey string) {
+	if e.eventBus == nil {
+		return
+	}
+	exec.mu.RLock()
+	var ne workflow.NodeExecution
+	if execNode, ok := exec.nodeExecutions[nodeKey]; ok {
+		ne = *execNode
+	}
+	progress := exec.progress
+	exec.mu.RUnlock()
+
+	e.publishTaskEvent(ctx, task, eventType, func(evt *event.TaskEvent) {
+		evt.Status = string(ne.Status)
+		evt.Progress = progress
+		evt.NodeKey = nodeKey
+		evt.NodeProgress = ne.Progress
+		evt.Message = ne.Message
+		evt.Error = ne.Error
+	})
+}
+
+// publishArtifacts publishes an artifact_created event per saved artifact
+func (e *DAGWorkflowEngine) publishArtifacts(ctx context.Context, task *workflow.Task, nodeKey string, artifacts []*workflow.Artifact) {
+	for _, artifact := range artifacts {
+		id := artifact.ID
+		artifactType := string(artifact.Type)
+		e.publishTaskEvent(ctx, task, event.EventTypeArtifactCreated, func(evt *event.TaskEvent) {
+			evt.NodeKey = nodeKey
+			evt.ArtifactID = &id
+			evt.ArtifactType = artifactType
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+// resultCacheSettings holds the engine-wide node result cache settings.
+// A nil value means caching is disabled.
+type resultCacheSettings struct {
+	ttl time.Duration
+}
+
+// EnableResultCache turns on node result caching keyed on the operator version and
+// the canonicalized node input. Entries expire after ttl unless the node overrides it;
+// ttl <= 0 keeps entries until they are invalidated.
+func (e *DAGWorkflowEngine) EnableResultCache(ttl time.Duration) {
+	e.resultCache = &resultCacheSettings{ttl: ttl}
+}
+
+// nodeCacheKey returns the input hash used as cache key, or "" when the node
+// does not use the cache
+func (e *DAGWorkflowEngine) nodeCacheKey(node *workflow.Node, input *operator.Input) string {
+	if e.resultCache == nil {
+		return ""
+	}
+	if node.Config != nil && node.Config.Cache != nil && node.Config.Cache.Bypass {
+		return ""
+	}
+	// Temporary artifacts are deleted when the task fails and cannot be shared
+	if node.HasTempArtifacts() {
+		return ""
+	}
+	hash, err := workflow.HashNodeInput(input)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// lookupNodeCache returns the cached result, or nil on a miss. Lookup errors are
+// treated as misses so the node simply executes.
+func (e *DAGWorkflowEngine) lookupNodeCache(ctx context.Context, versionID uuid.UUID, key string) *workflow.NodeCacheEntry {
+	var entry *workflow.NodeCacheEntry
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if repos.NodeCache == nil {
+			return nil
+		}
+		var err error
+		entry, err = repos.NodeCache.Get(ctx, versionID, key)
+		return err
+	})
+	if err != nil || entry == nil || entry.IsExpired(time.Now()) {
+		return nil
+	}
+	return entry
+}
+
+// storeNodeCache records a node result together with the artifacts saved for it.
+// Hits copy the artifacts to the hitting task (see copyCachedArtifacts).
+// Caching is best effort: a failed write does not fail the node.
+func (e *DAGWorkflowEngine) storeNodeCache(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	exec *taskExecution,
+	versionID uuid.UUID,
+	key string,
+	output *operator.Output,
+) {
+	// Temporary assets are deleted when the producing task fails
+	for _, asset := range output.OutputAssets {
+		if temporary, _ := asset.Metadata["temporary"].(bool); temporary {
+			return
+		}
+	}
+
+	ttl := e.resultCache.ttl
+	if node.Config != nil && node.Config.Cache != nil && node.Config.Cache.TTLSeconds > 0 {
+		ttl = time.Duration(node.Config.Cache.TTLSeconds) * time.Second
+	}
+
+	entry := &workflow.NodeCacheEntry{
+		TenantID:          task.TenantID,
+		OperatorVersionID: versionID,
+		InputHash:         key,
+		Output:            output,
+		SourceTaskID:      task.ID,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+	exec.mu.RLock()
+	if execNode, ok := exec.nodeExecutions[node.NodeKey]; ok {
+		entry.ArtifactIDs = append([]uuid.UUID(nil), execNode.ArtifactIDs...)
+	}
+	exec.mu.RUnlock()
+
+	_ = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if repos.NodeCache == nil {
+			return nil
+		}
+		return repos.NodeCache.Save(ctx, entry)
+	})
+}
+
+// copyCachedArtifacts copies the artifacts recorded with a cache entry to the task,
+// so that the task owns its artifacts and deleting the task that produced the entry
+// does not leave it with dangling references. It returns false when a recorded
+// artifact no longer exists, in which case the node executes as on a miss.
+func (e *DAGWorkflowEngine) copyCachedArtifacts(
+	ctx context.Context,
+	node *workflow.Node,
+	task *workflow.Task,
+	entry *workflow.NodeCacheEntry,
+) ([]uuid.UUID, bool) {
+	var artifacts []*workflow.Artifact
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		artifacts = artifacts[:0]
+		for _, id := range entry.ArtifactIDs {
+			source, err := repos.Artifacts.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			var data workflow.ArtifactData
+			if source.Data != nil {
+				data = *source.Data
+			}
+			data.Metadata = maps.Clone(data.Metadata)
+			if data.Metadata == nil {
+				data.Metadata = make(map[string]interface{})
+			}
+			data.Metadata["node_key"] = node.NodeKey
+			data.Metadata["cached_from"] = source.ID.String()
+			artifact := &workflow.Artifact{
+				ID:      uuid.New(),
+				TaskID:  task.ID,
+				Type:    source.Type,
+				AssetID: source.AssetID,
+				Data:    &data,
+			}
+			if err := repos.Artifacts.Create(ctx, artifact); err != nil {
+				return err
+			}
+			artifacts = append(artifacts, artifact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	e.publishArtifacts(ctx, task, node.NodeKey, artifacts)
+	ids := make([]uuid.UUID, len(artifacts))
+	for i, artifact := range artifacts {
+		ids[i] = artifact.ID
+	}
+	return ids, true
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"goyavision/internal/app/port"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// operatorSlotTTL is how long a shared slot outlives an engine that stopped renewing it
+	operatorSlotTTL = 30 * time.Second
+	// operatorSlotPollInterval is how often a call waiting for a full operator retries
+	operatorSlotPollInterval = 500 * time.Millisecond
+)
+
+// operatorLimiter caps concurrent operator calls per operator code.
+// A nil limiter or an operator without a configured limit is not restricted.
+// Codes are matched case-insensitively since config keys are lower-cased on load.
+//
+// Calls first take a slot within the process, then a slot shared by all engines
+// on the database, so the limit holds across the server and any number of workers.
+type operatorLimiter struct {
+	limits map[string]int
+	uow    port.UnitOfWork
+	holder string
+	mu     sync.Mutex
+	slots  map[string]chan struct{}
+}
+
+func newOperatorLimiter(uow port.UnitOfWork, limits map[string]int) *operatorLimiter {
+	filtered := make(map[string]int, len(limits))
+	for code, limit := range limits {
+		if limit > 0 {
+			filtered[strings.ToLower(code)] = limit
+		}
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &operatorLimiter{
+		limits: filtered,
+		uow:    uow,
+		holder: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		slots:  make(map[string]chan struct{}),
+	}
+}
+
+// acquire blocks until a slot for the operator is free or ctx is done.
+// The returned release function must be called once the call has finished.
+func (l *operatorLimiter) acquire(ctx context.Context, code string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	code = strings.ToLower(code)
+	limit, ok := l.limits[code]
+	if !ok {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[code]
+	if !ok {
+		slots = make(chan struct{}, limit)
+		l.slots[code] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	releaseShared, err := l.acquireShared(ctx, code, limit)
+	if err != nil {
+		<-slots
+		return nil, err
+	}
+	return func() {
+		releaseShared()
+		<-slots
+	}, nil
+}
+
+// acquireShared takes a database slot for the operator, polling while all slots
+// are held, and keeps it alive until released
+func (l *operatorLimiter) acquireShared(ctx context.Context, code string, limit int) (func(), error) {
+	var id uuid.UUID
+	for {
+		acquired := false
+		err := l.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+			var err error
+			id, acquired, err = repos.OperatorSlots.Acquire(ctx, code, limit, l.holder, operatorSlotTTL)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("acquire operator slot: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if err := sleepContext(ctx, operatorSlotPollInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	bg := context.WithoutCancel(ctx)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(operatorSlotTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			// A missed renewal is retried on the next tick, well before the slot expires
+			_ = l.uow.Do(bg, func(ctx context.Context, repos *port.Repositories) error {
+				return repos.OperatorSlots.Renew(ctx, id, operatorSlotTTL)
+			})
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		// A slot that fails to be released expires once renewal has stopped
+		_ = l.uow.Do(bg, func(ctx context.Context, repos *port.Repositories) error {
+			return repos.OperatorSlots.Release(ctx, id)
+		})
+	}, nil
+}
@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"gorm.io/gorm"
+)
+
+var _ workflow.Planner = (*DAGWorkflowEngine)(nil)
+
+// Plan resolves everything an execution of wf would use without calling any
+// operator: execution layers, active operator versions, merged params, input
+// schema checks and a cost estimate. Problems are reported on the plan per
+// node; the error result is reserved for storage failures.
+func (e *DAGWorkflowEngine) Plan(ctx context.Context, wf *workflow.Workflow, req workflow.PlanRequest) (*workflow.Plan, error) {
+	plan := &workflow.Plan{
+		WorkflowID: wf.ID,
+		Revision:   wf.Revision,
+		Layers:     [][]string{},
+		Nodes:      make([]*workflow.NodePlan, 0, len(wf.Nodes)),
+		Issues:     []workflow.PlanIssue{},
+	}
+	if len(wf.Nodes) == 0 {
+		plan.Issues = append(plan.Issues, workflow.PlanIssue{
+			Severity: workflow.PlanSeverityError,
+			Code:     workflow.PlanIssueInvalidConfig,
+			Message:  "workflow has no nodes",
+		})
+	}
+
+	layerOf := make(map[string]int, len(wf.Nodes))
+	layers, err := e.buildExecutionLayers(wf.FlowNodes(), wf.Edges)
+	if err != nil {
+		plan.Issues = append(plan.Issues, workflow.PlanIssue{
+			Severity: workflow.PlanSeverityError,
+			Code:     workflow.PlanIssueCycle,
+			Message:  err.Error(),
+		})
+	} else {
+		for i, layer := range layers {
+			sort.Strings(layer)
+			for _, nodeKey := range layer {
+				layerOf[nodeKey] = i
+			}
+		}
+		plan.Layers = layers
+	}
+
+	ancestors := workflow.Ancestors(wf.Edges)
+	err = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		for i := range wf.Nodes {
+			np, err := e.planNode(ctx, repos, &wf.Nodes[i], req, ancestors[wf.Nodes[i].NodeKey])
+			if err != nil {
+				return err
+			}
+			if layer, ok := layerOf[np.NodeKey]; ok {
+				np.Layer = layer
+			} else if len(layers) == 0 && !wf.Nodes[i].IsHook() {
+				np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueCycle, "node is part of or downstream of a cycle")
+			}
+			plan.Nodes = append(plan.Nodes, np)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan workflow: %w", err)
+	}
+
+	// Nodes in execution order; nodes that cannot be placed come last
+	sort.SliceStable(plan.Nodes, func(i, j int) bool {
+		li, lj := plan.Nodes[i].Layer, plan.Nodes[j].Layer
+		if li != lj {
+			return lj < 0 || (li >= 0 && li < lj)
+		}
+		return plan.Nodes[i].NodeKey < plan.Nodes[j].NodeKey
+	})
+	plan.Finish()
+	return plan, nil
+}
+
+// planNode resolves a single node the way executeNode would
+func (e *DAGWorkflowEngine) planNode(
+	ctx context.Context,
+	repos *port.Repositories,
+	node *workflow.Node,
+	req workflow.PlanRequest,
+	ancestors map[string]bool,
+) (*workflow.NodePlan, error) {
+	np := &workflow.NodePlan{
+		NodeKey:    node.NodeKey,
+		NodeType:   node.NodeType,
+		Layer:      -1,
+		OperatorID: node.OperatorID,
+		InputCheck: workflow.NodeInputCheck{Status: workflow.InputCheckSkipped},
+		Estimate:   workflow.NodeEstimate{MaxAttempts: node.Config.EffectiveRetryPolicy().Attempts()},
+		Issues:     []workflow.PlanIssue{},
+	}
+	if err := node.Validate(); err != nil {
+		np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueInvalidConfig, err.Error())
+	}
+
+	input, upstream := planNodeInput(np, node, req, ancestors)
+	np.Params = input.Params
+	np.InputCheck.UpstreamParams = upstream
+
+	if node.IsSubWorkflow() {
+		if node.Config != nil && node.Config.SubWorkflow != nil {
+			if _, err := workflow.ResolveSubWorkflow(ctx, repos.Workflows, repos.WorkflowRevisions, node.Config.SubWorkflow); err != nil {
+				np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueSubWorkflow, err.Error())
+			}
+		}
+		return np, nil
+	}
+	if node.OperatorID == nil {
+		return np, nil
+	}
+
+	op, err := repos.Operators.GetWithActiveVersion(ctx, *node.OperatorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueOperatorNotFound,
+				fmt.Sprintf("operator %s not found", node.OperatorID))
+			return np, nil
+		}
+		return nil, err
+	}
+	np.OperatorCode = op.Code
+	np.OperatorStatus = string(op.Status)
+	switch op.Status {
+	case operator.StatusDeprecated:
+		np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueDeprecated,
+			fmt.Sprintf("operator %s is deprecated", op.Code))
+	case operator.StatusDraft, operator.StatusTesting:
+		np.AddIssue(workflow.PlanSeverityWarning, workflow.PlanIssueNotPublished,
+			fmt.Sprintf("operator %s is not published (status %s)", op.Code, op.Status))
+	}
+
+	satisfied, unmet, err := repos.OperatorDependencies.CheckDependenciesSatisfied(ctx, op.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !satisfied {
+		for _, msg := range unmet {
+			np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueDependencyUnmet, msg)
+		}
+	}
+
+	version := op.ActiveVersion
+	if version == nil {
+		np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueNoActiveVersion,
+			fmt.Sprintf("operator %s has no active version", op.Code))
+		return np, nil
+	}
+	np.VersionID = &version.ID
+	np.Version = version.Version
+	np.ExecMode = string(version.ExecMode)
+
+	e.planInputCheck(ctx, np, version, input)
+	planEstimate(np, node, version, input, req)
+	return np, nil
+}
+
+// planNodeInput merges the params known before execution as prepareNodeInput
+// does, and returns the params that are only known once upstream nodes ran
+func planNodeInput(np *workflow.NodePlan, node *workflow.Node, req workflow.PlanRequest, ancestors map[string]bool) (*operator.Input, []string) {
+	input := &operator.Input{Params: make(map[string]interface{})}
+	if req.AssetID != nil {
+		input.AssetID = *req.AssetID
+	}
+	for k, v := range req.InputParams {
+		input.Params[k] = v
+	}
+	if node.Config != nil {
+		for k, v := range node.Config.Params {
+			input.Params[k] = v
+		}
+	}
+
+	var upstream []string
+	if node.HasInputMappings() {
+		env := map[string]interface{}{
+			workflow.MappingVarInput: copyParams(req.InputParams),
+			workflow.MappingVarNodes: map[string]interface{}{},
+		}
+		for _, mapping := range node.Config.Inputs {
+			program, err := mapping.Program()
+			if err != nil {
+				np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueInputMapping,
+					fmt.Sprintf("input mapping %s: %v", mapping.To, err))
+				continue
+			}
+			if !onlyTaskInput(program.Variables()) {
+				upstream = append(upstream, mapping.To)
+				continue
+			}
+			value, err := program.Eval(env)
+			if err != nil {
+				np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueInputMapping,
+					fmt.Sprintf("input mapping %s: %v", mapping.To, err))
+				continue
+			}
+			setParam(input.Params, mapping.TargetPath(), value)
+		}
+		return input, upstream
+	}
+
+	for nodeKey := range ancestors {
+		upstream = append(upstream, nodeKey+"_output")
+	}
+	// Hooks receive the context of the ended task at run time
+	if node.IsHook() {
+		upstream = append(upstream, "task")
+	}
+	sort.Strings(upstream)
+	return input, upstream
+}
+
+func onlyTaskInput(vars []string) bool {
+	for _, v := range vars {
+		if v != workflow.MappingVarInput {
+			return false
+		}
+	}
+	return true
+}
+
+func copyParams(params map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+	return copied
+}
+
+// planInputCheck validates the known input against the version's input schema.
+// A failure is only certain when no params come from upstream nodes.
+func (e *DAGWorkflowEngine) planInputCheck(ctx context.Context, np *workflow.NodePlan, version *operator.OperatorVersion, input *operator.Input) {
+	if e.schemaValidator == nil || len(version.InputSchema) == 0 {
+		return
+	}
+	if err := e.validateNodeInput(ctx, version, input); err != nil {
+		np.InputCheck.Error = err.Error()
+		if len(np.InputCheck.UpstreamParams) > 0 {
+			np.InputCheck.Status = workflow.InputCheckIncomplete
+			np.AddIssue(workflow.PlanSeverityWarning, workflow.PlanIssueInputInvalid,
+				"input does not match the operator schema yet; upstream outputs may supply the missing fields: "+err.Error())
+			return
+		}
+		np.InputCheck.Status = workflow.InputCheckInvalid
+		np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueInputInvalid, err.Error())
+		return
+	}
+	np.InputCheck.Status = workflow.InputCheckValid
+}
+
+// planEstimate estimates operator calls and, for AI model operators, the token budget
+func planEstimate(np *workflow.NodePlan, node *workflow.Node, version *operator.OperatorVersion, input *operator.Input, req workflow.PlanRequest) {
+	calls := 1
+	if node.IsMap() && node.Config != nil && node.Config.Map != nil {
+		items := node.Config.Map.Items
+		if n, ok := req.FanOutItems[node.NodeKey]; ok {
+			calls = n
+		} else if v, ok := input.Params[items]; ok {
+			list, err := mapItems(v)
+			if err != nil {
+				np.AddIssue(workflow.PlanSeverityError, workflow.PlanIssueInvalidConfig, err.Error())
+				return
+			}
+			calls = len(list)
+		} else {
+			np.AddIssue(workflow.PlanSeverityWarning, workflow.PlanIssueFanOutUnknown,
+				fmt.Sprintf("fan-out items %q come from upstream output; pass fan_out_items to estimate", items))
+			return
+		}
+	}
+	np.Estimate.Calls = &calls
+
+	if version.ExecMode != operator.ExecModeAIModel || version.ExecConfig == nil || version.ExecConfig.AIModel == nil {
+		return
+	}
+	maxTokens := version.ExecConfig.AIModel.MaxTokens
+	if maxTokens == nil || *maxTokens <= 0 {
+		np.AddIssue(workflow.PlanSeverityWarning, workflow.PlanIssueTokenBudgetUnset,
+			"max_tokens is not set; the token budget depends on the model provider default")
+		return
+	}
+	budget := calls * *maxTokens
+	np.Estimate.MaxTokensPerCall = maxTokens
+	np.Estimate.TokenBudget = &budget
+}
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// planOperatorRepo resolves operators by ID
+type planOperatorRepo struct {
+	stubOperatorRepo
+	ops map[uuid.UUID]*operator.Operator
+}
+
+func (s *planOperatorRepo) GetWithActiveVersion(ctx context.Context, id uuid.UUID) (*operator.Operator, error) {
+	op, ok := s.ops[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return op, nil
+}
+
+type stubDependencyRepo struct {
+	unmet map[uuid.UUID][]string
+}
+
+func (s *stubDependencyRepo) Create(ctx context.Context, dep *operator.OperatorDependency) error {
+	return nil
+}
+func (s *stubDependencyRepo) ListByOperator(ctx context.Context, operatorID uuid.UUID) ([]*operator.OperatorDependency, error) {
+	return nil, nil
+}
+func (s *stubDependencyRepo) DeleteByOperator(ctx context.Context, operatorID uuid.UUID) error {
+	return nil
+}
+func (s *stubDependencyRepo) CheckDependenciesSatisfied(ctx context.Context, operatorID uuid.UUID) (bool, []string, error) {
+	msgs := s.unmet[operatorID]
+	return len(msgs) == 0, msgs, nil
+}
+
+func newPlanEngine(ops []*operator.Operator, unmet map[uuid.UUID][]string) *DAGWorkflowEngine {
+	repos := newTestRepos()
+	byID := make(map[uuid.UUID]*operator.Operator, len(ops))
+	for _, op := range ops {
+		byID[op.ID] = op
+	}
+	repos.Operators = &planOperatorRepo{ops: byID}
+	repos.OperatorDependencies = &stubDependencyRepo{unmet: unmet}
+
+	mockUOW := new(MockUnitOfWork)
+	mockUOW.repos = repos
+	mockUOW.On("Do", mock.Anything, mock.Anything).Return(nil)
+	return NewDAGWorkflowEngine(mockUOW, nil)
+}
+
+func newPlanOperator(code string, status operator.Status, maxTokens *int) *operator.Operator {
+	ov := &operator.OperatorVersion{ID: uuid.New(), Version: "1.0.0", ExecMode: operator.ExecModeHTTP}
+	if maxTokens != nil {
+		ov.ExecMode = operator.ExecModeAIModel
+		ov.ExecConfig = &operator.ExecConfig{AIModel: &operator.AIModelExecConfig{MaxTokens: maxTokens}}
+	}
+	return &operator.Operator{ID: uuid.New(), Code: code, Status: status, ActiveVersion: ov, ActiveVersionID: &ov.ID}
+}
+
+func findNodePlan(plan *workflow.Plan, nodeKey string) *workflow.NodePlan {
+	for _, n := range plan.Nodes {
+		if n.NodeKey == nodeKey {
+			return n
+		}
+	}
+	return nil
+}
+
+func issueCodes(issues []workflow.PlanIssue) []string {
+	codes := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestPlan_LayersParamsAndEstimate(t *testing.T) {
+	maxTokens := 500
+	detect := newPlanOperator("detect", operator.StatusPublished, nil)
+	caption := newPlanOperator("caption", operator.StatusPublished, &maxTokens)
+	engine := newPlanEngine([]*operator.Operator{detect, caption}, nil)
+
+	wf := &workflow.Workflow{
+		ID:       uuid.New(),
+		Revision: 3,
+		Nodes: []workflow.Node{
+			{NodeKey: "detect", OperatorID: &detect.ID, Config: &workflow.NodeConfig{
+				Params: map[string]interface{}{"threshold": 0.5},
+				Retry:  &workflow.RetryPolicy{MaxAttempts: 3},
+			}},
+			{NodeKey: "caption", NodeType: workflow.NodeTypeMap, OperatorID: &caption.ID, Config: &workflow.NodeConfig{
+				Map: &workflow.MapConfig{Items: "frames"},
+			}},
+		},
+		Edges: []workflow.Edge{{SourceKey: "detect", TargetKey: "caption"}},
+	}
+
+	plan, err := engine.Plan(context.Background(), wf, workflow.PlanRequest{
+		InputParams: map[string]interface{}{"frames": []interface{}{1, 2, 3}},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, plan.Valid)
+	assert.Equal(t, 3, plan.Revision)
+	assert.Equal(t, [][]string{{"detect"}, {"caption"}}, plan.Layers)
+	assert.Len(t, plan.Nodes, 2)
+	assert.Equal(t, "detect", plan.Nodes[0].NodeKey)
+
+	d := findNodePlan(plan, "detect")
+	assert.Equal(t, 0, d.Layer)
+	assert.Equal(t, detect.ActiveVersion.ID, *d.VersionID)
+	assert.Equal(t, 0.5, d.Params["threshold"])
+	assert.Equal(t, 1, *d.Estimate.Calls)
+	assert.Equal(t, 3, d.Estimate.MaxAttempts)
+	assert.Nil(t, d.Estimate.TokenBudget)
+
+	c := findNodePlan(plan, "caption")
+	assert.Equal(t, 1, c.Layer)
+	assert.Equal(t, []string{"detect_output"}, c.InputCheck.UpstreamParams)
+	assert.Equal(t, 3, *c.Estimate.Calls)
+	assert.Equal(t, 1500, *c.Estimate.TokenBudget)
+
+	assert.True(t, plan.Estimate.Complete)
+	assert.Equal(t, 4, plan.Estimate.OperatorCalls)
+	assert.Equal(t, 6, plan.Estimate.MaxOperatorCalls)
+	assert.Equal(t, 1500, plan.Estimate.TokenBudget)
+}
+
+func TestPlan_FanOutItems(t *testing.T) {
+	maxTokens := 100
+	caption := newPlanOperator("caption", operator.StatusPublished, &maxTokens)
+	engine := newPlanEngine([]*operator.Operator{caption}, nil)
+
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{NodeKey: "caption", NodeType: workflow.NodeTypeMap, OperatorID: &caption.ID, Config: &workflow.NodeConfig{
+				Map: &workflow.MapConfig{Items: "frames"},
+			}},
+		},
+	}
+
+	plan, err := engine.Plan(context.Background(), wf, workflow.PlanRequest{})
+	assert.NoError(t, err)
+	assert.True(t, plan.Valid)
+	assert.False(t, plan.Estimate.Complete)
+	assert.Equal(t, []string{"caption"}, plan.Estimate.UnknownNodes)
+	assert.Equal(t, []string{workflow.PlanIssueFanOutUnknown}, issueCodes(plan.Nodes[0].Issues))
+
+	plan, err = engine.Plan(context.Background(), wf, workflow.PlanRequest{FanOutItems: map[string]int{"caption": 10}})
+	assert.NoError(t, err)
+	assert.True(t, plan.Estimate.Complete)
+	assert.Equal(t, 10, plan.Estimate.OperatorCalls)
+	assert.Equal(t, 1000, plan.Estimate.TokenBudget)
+}
+
+func TestPlan_OperatorIssues(t *testing.T) {
+	deprecated := newPlanOperator("old", operator.StatusDeprecated, nil)
+	noVersion := newPlanOperator("empty", operator.StatusPublished, nil)
+	noVersion.ActiveVersion = nil
+	noVersion.ActiveVersionID = nil
+	draft := newPlanOperator("draft", operator.StatusDraft, nil)
+	missing := uuid.New()
+	engine := newPlanEngine(
+		[]*operator.Operator{deprecated, noVersion, draft},
+		map[uuid.UUID][]string{draft.ID: {"依赖算子 detect 版本不满足"}},
+	)
+
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{NodeKey: "a", OperatorID: &deprecated.ID},
+			{NodeKey: "b", OperatorID: &noVersion.ID},
+			{NodeKey: "c", OperatorID: &draft.ID},
+			{NodeKey: "d", OperatorID: &missing},
+		},
+	}
+
+	plan, err := engine.Plan(context.Background(), wf, workflow.PlanRequest{})
+	assert.NoError(t, err)
+	assert.False(t, plan.Valid)
+
+	assert.Equal(t, []string{workflow.PlanIssueDeprecated}, issueCodes(findNodePlan(plan, "a").Issues))
+	assert.Equal(t, []string{workflow.PlanIssueNoActiveVersion}, issueCodes(findNodePlan(plan, "b").Issues))
+	assert.Equal(t, []string{workflow.PlanIssueNotPublished, workflow.PlanIssueDependencyUnmet}, issueCodes(findNodePlan(plan, "c").Issues))
+	assert.Equal(t, []string{workflow.PlanIssueOperatorNotFound}, issueCodes(findNodePlan(plan, "d").Issues))
+	assert.Nil(t, findNodePlan(plan, "b").VersionID)
+}
+
+func TestPlan_Cycle(t *testing.T) {
+	op := newPlanOperator("op", operator.StatusPublished, nil)
+	engine := newPlanEngine([]*operator.Operator{op}, nil)
+
+	wf := &workflow.Workflow{
+		ID: uuid.New(),
+		Nodes: []workflow.Node{
+			{NodeKey: "a", OperatorID: &op.ID},
+			{NodeKey: "b", OperatorID: &op.ID},
+		},
+		Edges: []workflow.Edge{
+			{SourceKey: "a", TargetKey: "b"},
+			{SourceKey: "b", TargetKey: "a"},
+		},
+	}
+
+	plan, err := engine.Plan(context.Background(), wf, workflow.PlanRequest{})
+	assert.NoError(t, err)
+	assert.False(t, plan.Valid)
+	assert.Empty(t, plan.Layers)
+	assert.Equal(t, []string{workflow.PlanIssueCycle}, issueCodes(plan.Issues))
+	for _, n := range plan.Nodes {
+		assert.Equal(t, -1, n.Layer)
+		assert.Contains(t, issueCodes(n.Issues), workflow.PlanIssueCycle)
+	}
+}
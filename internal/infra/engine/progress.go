@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+// ProgressCallbackPath is the route that serves progress callbacks from HTTP operators
+const ProgressCallbackPath = "/api/v1/callbacks/progress"
+
+// progressSyncInterval throttles how often reported progress is written to the task
+const progressSyncInterval = time.Second
+
+// ErrTaskNotRunning is returned when a progress report targets a task that is not
+// executing in this process
+var ErrTaskNotRunning = errors.New("task is not running")
+
+// progressCallbackSettings holds the base URL and signing secret of progress callbacks.
+// A nil value means HTTP operators receive no callback URL.
+type progressCallbackSettings struct {
+	baseURL string
+	secret  []byte
+}
+
+// EnableProgressCallback hands HTTP operators a signed URL under baseURL (the address
+// operators use to reach this process) to which they can post progress while running.
+func (e *DAGWorkflowEngine) EnableProgressCallback(baseURL string, secret []byte) {
+	e.progressCallback = &progressCallbackSettings{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+	}
+}
+
+// ReportProgress records progress reported for a running node of a task executing in
+// this process
+func (e *DAGWorkflowEngine) ReportProgress(ctx context.Context, taskID uuid.UUID, nodeKey string, p operator.Progress) error {
+	e.mu.RLock()
+	exec, ok := e.tasks[taskID]
+	e.mu.RUnlock()
+	if !ok || exec.task == nil {
+		return ErrTaskNotRunning
+	}
+	if !e.recordNodeProgress(exec.ctx, exec.task, exec, nodeKey, p) {
+		return ErrTaskNotRunning
+	}
+	return nil
+}
+
+// ProgressCallbackHandler serves POST ProgressCallbackPath?task_id=&node_key=&token= with
+// a JSON body {"progress": 0.42, "message": "..."}, progress being a fraction between 0 and 1
+func (e *DAGWorkflowEngine) ProgressCallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		taskID, err := uuid.Parse(q.Get("task_id"))
+		nodeKey := q.Get("node_key")
+		if err != nil || nodeKey == "" {
+			http.Error(w, "invalid task_id or node_key", http.StatusBadRequest)
+			return
+		}
+		if !e.verifyProgressToken(taskID, nodeKey, q.Get("token")) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		var p operator.Progress
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&p); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		p.Fraction = clampFraction(p.Fraction)
+
+		if err := e.ReportProgress(r.Context(), taskID, nodeKey, p); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (e *DAGWorkflowEngine) progressToken(taskID uuid.UUID, nodeKey string) string {
+	mac := hmac.New(sha256.New, e.progressCallback.secret)
+	mac.Write([]byte(taskID.String() + "/" + nodeKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *DAGWorkflowEngine) verifyProgressToken(taskID uuid.UUID, nodeKey, token string) bool {
+	if e.progressCallback == nil || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(e.progressToken(taskID, nodeKey)), []byte(token))
+}
+
+func (e *DAGWorkflowEngine) progressCallbackURL(taskID uuid.UUID, nodeKey string) string {
+	if e.progressCallback == nil || e.progressCallback.baseURL == "" {
+		return ""
+	}
+	q := url.Values{}
+	q.Set("task_id", taskID.String())
+	q.Set("node_key", nodeKey)
+	q.Set("token", e.progressToken(taskID, nodeKey))
+	return e.progressCallback.baseURL + ProgressCallbackPath + "?" + q.Encode()
+}
+
+// withNodeProgress routes progress reported by the operator of a node into the task and
+// lets asynchronous HTTP operators of the node register result callbacks
+func (e *DAGWorkflowEngine) withNodeProgress(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string) context.Context {
+	syncCtx := ctx
+	ctx = operator.WithProgressReporter(ctx, func(p operator.Progress) {
+		e.recordNodeProgress(syncCtx, task, exec, nodeKey, p)
+	})
+	if u := e.progressCallbackURL(task.ID, nodeKey); u != "" {
+		ctx = operator.WithProgressURL(ctx, u)
+	}
+	return e.withAsyncCallback(ctx, task.ID, nodeKey)
+}
+
+// recordNodeProgress stores the progress of a running node and recomputes the task
+// progress. Writes to the task are throttled to one per progressSyncInterval.
+// It returns false when the node is not running.
+func (e *DAGWorkflowEngine) recordNodeProgress(ctx context.Context, task *workflow.Task, exec *taskExecution, nodeKey string, p operator.Progress) bool {
+	exec.mu.Lock()
+	execNode, ok := exec.nodeExecutions[nodeKey]
+	if !ok || execNode.Status != workflow.NodeExecRunning {
+		exec.mu.Unlock()
+		return false
+	}
+	execNode.Progress = clampFraction(p.Fraction)
+	if p.Message != "" {
+		execNode.Message = p.Message
+	}
+	now := time.Now()
+	sync := now.Sub(exec.lastProgressSync) >= progressSyncInterval
+	if sync {
+		exec.lastProgressSync = now
+	}
+	exec.mu.Unlock()
+
+	if sync {
+		if err := e.syncTaskNodeExecutions(ctx, task, exec); err == nil {
+			e.publishNodeEvent(ctx, task, exec, event.EventTypeTaskProgress, nodeKey)
+		}
+	}
+	return true
+}
+
+// taskProgress computes the task progress in percent as the weighted average of node
+// progress: finished nodes count fully, running nodes by their reported fraction.
+// The caller must hold exec.mu.
+func taskProgress(exec *taskExecution) int {
+	var total, done float64
+	for key, ne := range exec.nodeExecutions {
+		weight := 1.0
+		if w, ok := exec.weights[key]; ok {
+			weight = w
+		}
+		total += weight
+		switch ne.Status {
+		case workflow.NodeExecSuccess, workflow.NodeExecCached, workflow.NodeExecSkipped, workflow.NodeExecFailed:
+			done += weight
+		case workflow.NodeExecRunning, workflow.NodeExecWaiting:
+			done += weight * ne.Progress
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return int(done / total * 100)
+}
+
+func clampFraction(f float64) float64 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/operator"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// pinRevision returns the graph the task executes. A task is pinned to the
+// workflow's current revision when it first starts; resuming it later runs
+// the pinned revision even if the workflow has been edited since. Workflows
+// created before revisions existed get their current graph recorded first.
+func (e *DAGWorkflowEngine) pinRevision(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) (*workflow.Workflow, error) {
+	if task.RevisionID != nil && wf.RevisionID != nil && *task.RevisionID == *wf.RevisionID {
+		return wf, nil
+	}
+
+	var pinned *workflow.Workflow
+	err := e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		if task.RevisionID != nil {
+			rev, err := repos.WorkflowRevisions.Get(ctx, *task.RevisionID)
+			if err != nil {
+				return err
+			}
+			pinned = rev.Apply(wf)
+			return nil
+		}
+
+		if wf.RevisionID != nil {
+			task.RevisionID = wf.RevisionID
+			pinned = wf
+			return nil
+		}
+
+		rev := workflow.NewRevision(wf)
+		latest, err := repos.WorkflowRevisions.GetLatest(ctx, wf.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if latest != nil && latest.Hash == rev.Hash {
+			rev = latest
+		} else {
+			rev.Revision = 1
+			if latest != nil {
+				rev.Revision = latest.Revision + 1
+			}
+			rev.Comment = "recorded at task start"
+			if err := repos.WorkflowRevisions.Create(ctx, rev); err != nil {
+				return err
+			}
+		}
+		if err := repos.Workflows.Update(ctx, &workflow.Workflow{ID: wf.ID, Revision: rev.Revision, RevisionID: &rev.ID}); err != nil {
+			return err
+		}
+		task.RevisionID = &rev.ID
+		pinned = rev.Apply(wf)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin workflow revision: %w", err)
+	}
+	return pinned, nil
+}
+
+// pinOperatorVersions records the active version of every operator node when
+// the task first starts. Nodes whose operator cannot be resolved are left
+// unpinned and fail with the usual error when executed.
+func (e *DAGWorkflowEngine) pinOperatorVersions(ctx context.Context, wf *workflow.Workflow, task *workflow.Task) {
+	if task.OperatorVersions != nil {
+		return
+	}
+	versions := make(map[string]uuid.UUID)
+	_ = e.uow.Do(ctx, func(ctx context.Context, repos *port.Repositories) error {
+		for _, node := range wf.Nodes {
+			if node.OperatorID == nil {
+				continue
+			}
+			op, err := repos.Operators.GetWithActiveVersion(ctx, *node.OperatorID)
+			if err != nil || op.ActiveVersion == nil {
+				continue
+			}
+			versions[node.NodeKey] = op.ActiveVersion.ID
+		}
+		return nil
+	})
+	task.OperatorVersions = versions
+}
+
+// resolveOperatorVersion returns the operator with the version pinned for the
+// node when the operator has been re-activated since the task started
+func (e *DAGWorkflowEngine) resolveOperatorVersion(ctx context.Context, repos *port.Repositories, task *workflow.Task, nodeKey string, op *operator.Operator) (*operator.Operator, error) {
+	versionID, ok := task.OperatorVersions[nodeKey]
+	if !ok || (op.ActiveVersion != nil && op.ActiveVersion.ID == versionID) {
+		return op, nil
+	}
+	version, err := repos.OperatorVersions.Get(ctx, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned operator version %s: %w", versionID, err)
+	}
+	pinned := *op
+	pinned.ActiveVersion = version
+	pinned.ActiveVersionID = &version.ID
+	return &pinned, nil
+}
@@ -0,0 +1,91 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+)
+
+// EnvelopeVersion 当前事件信封版本，消费方拒绝无法识别的版本
+const EnvelopeVersion = 1
+
+// Envelope 外部消息系统中传输的事件信封
+//
+// Data 为 event.Encode 得到的事件 JSON，按 Type 由 event.Decode 还原；
+// 增加字段保持版本不变，字段语义变化时递增 Version。
+type Envelope struct {
+	Version    int             `json:"v"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	OccurredAt int64           `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// EncodeEnvelope 将事件封装为信封 JSON
+func EncodeEnvelope(ev port.Event) (*Envelope, []byte, error) {
+	data, err := event.Encode(ev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode event %s: %w", ev.EventType(), err)
+	}
+	env := &Envelope{
+		Version:    EnvelopeVersion,
+		ID:         uuid.NewString(),
+		Type:       ev.EventType(),
+		OccurredAt: ev.OccurredAt(),
+		Data:       data,
+	}
+	if te, ok := ev.(interface{ Tenant() uuid.UUID }); ok {
+		if tenantID := te.Tenant(); tenantID != uuid.Nil {
+			env.TenantID = tenantID.String()
+		}
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode envelope %s: %w", ev.EventType(), err)
+	}
+	return env, raw, nil
+}
+
+// DecodeEnvelope 解析信封并还原事件
+func DecodeEnvelope(raw []byte) (*Envelope, port.Event, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, nil, fmt.Errorf("decode envelope: %w", err)
+	}
+	if env.Version != EnvelopeVersion {
+		return &env, nil, fmt.Errorf("unsupported envelope version %d", env.Version)
+	}
+	if env.Type == "" {
+		return &env, nil, fmt.Errorf("envelope type is required")
+	}
+	ev, err := event.Decode(env.Type, env.Data)
+	if err != nil {
+		return &env, nil, err
+	}
+	return &env, ev, nil
+}
+
+// outboxEnvelope 将 outbox 事件封装为信封 JSON；信封 ID 即事件 ID，重复转发的同一事件 ID 相同
+func outboxEnvelope(row *model.OutboxEventModel) ([]byte, error) {
+	env := &Envelope{
+		Version:    EnvelopeVersion,
+		ID:         row.ID.String(),
+		Type:       row.EventType,
+		OccurredAt: row.OccurredAt,
+		Data:       json.RawMessage(row.Payload),
+	}
+	if row.TenantID != nil {
+		env.TenantID = row.TenantID.String()
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("encode envelope %s: %w", row.EventType, err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+
+	"goyavision/config"
+	"goyavision/internal/app/port"
+
+	"gorm.io/gorm"
+)
+
+// New 按 event_bus 配置创建事件总线，返回的 closer 停止中继或关闭外部连接
+//
+// relay 为 true 时启动 outbox 中继，订阅事件的进程须传 true：cmd/server 如此，cmd/worker 也以临时订阅接收任务取消事件；
+// 只发布不订阅的进程可传 false，由其他进程的中继投递。多个进程同时运行中继是安全的：分配序号与清理只由持有租约的进程执行，
+// 同名持久消费者同一时刻只在持有其租约的进程上消费，取得租约时从数据库重新加载消费位置。
+// driver=nats/redis 时事件经 outbox 转发到消息系统，relay 为 true 时同时启动转发，同样只由持有租约的进程执行。
+// driver=outbox 且 db 为 nil 时使用本地实现。
+func New(ctx context.Context, cfg config.EventBus, db *gorm.DB, relay bool) (port.EventBus, func(), error) {
+	switch cfg.Driver {
+	case "", "outbox":
+		if db == nil {
+			return NewLocalEventBus(100), func() {}, nil
+		}
+		bus := NewOutboxEventBus(db, OutboxConfig{
+			PollInterval: cfg.PollInterval,
+			MaxAttempts:  cfg.MaxAttempts,
+			Retention:    cfg.Retention,
+		})
+		if !relay {
+			return bus, func() {}, nil
+		}
+		bus.Start(ctx)
+		return bus, bus.Stop, nil
+	case "local":
+		return NewLocalEventBus(100), func() {}, nil
+	case "nats":
+		transport, err := NewNATSTransport(ctx, NATSConfig{
+			URL:           cfg.NATS.URL,
+			Stream:        cfg.NATS.Stream,
+			SubjectPrefix: cfg.NATS.SubjectPrefix,
+			MaxAge:        cfg.NATS.MaxAge,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		bus, closer := newStreamBus(ctx, transport, cfg, db, relay)
+		return bus, closer, nil
+	case "redis":
+		transport, err := NewRedisTransport(ctx, RedisConfig{
+			Addr:      cfg.Redis.Addr,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			KeyPrefix: cfg.Redis.KeyPrefix,
+			MaxLen:    cfg.Redis.MaxLen,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		bus, closer := newStreamBus(ctx, transport, cfg, db, relay)
+		return bus, closer, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown event bus driver %q", cfg.Driver)
+	}
+}
+
+// newStreamBus 创建经 outbox 转发的外部事件总线，relay 为 true 时启动转发
+func newStreamBus(ctx context.Context, transport StreamTransport, cfg config.EventBus, db *gorm.DB, relay bool) (*StreamEventBus, func()) {
+	bus := NewStreamEventBus(transport, db, StreamConfig{
+		MaxAttempts:  cfg.MaxAttempts,
+		PollInterval: cfg.PollInterval,
+	})
+	if relay {
+		bus.Start(ctx)
+	}
+	return bus, func() { _ = bus.Close() }
+}
@@ -5,11 +5,15 @@ import (
 	"sync"
 
 	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
 	"goyavision/pkg/apperr"
 	"goyavision/pkg/logger"
 )
 
 // LocalEventBus 本地内存事件总线实现
+//
+// 事件只在本进程内投递，handler 失败仅记录日志；需要持久化与重试时使用 OutboxEventBus。
+// 在事务内发布的事件在事务提交后才投递，回滚时丢弃。
 type LocalEventBus struct {
 	mu         sync.RWMutex
 	handlers   map[string][]handlerWrapper
@@ -22,6 +26,14 @@ type handlerWrapper struct {
 	handler port.EventHandler
 }
 
+// localSubscription 订阅句柄，按订阅 ID 取消订阅
+type localSubscription struct {
+	eventType string
+	id        int
+}
+
+func (s *localSubscription) EventType() string { return s.eventType }
+
 var handlerIDCounter = 0
 var handlerIDMutex sync.Mutex
 
@@ -48,12 +60,23 @@ func (bus *LocalEventBus) Publish(ctx context.Context, event port.Event) error {
 		return apperr.InvalidInput("event type is required")
 	}
 
+	if persistence.AfterCommit(ctx, func() { bus.dispatch(context.WithoutCancel(ctx), event) }) {
+		return nil
+	}
+	bus.dispatch(ctx, event)
+	return nil
+}
+
+// dispatch 为每个 handler 启动一个 goroutine 投递事件
+func (bus *LocalEventBus) dispatch(ctx context.Context, event port.Event) {
+	eventType := event.EventType()
+
 	bus.mu.RLock()
 	handlers := bus.handlers[eventType]
 	if len(handlers) == 0 {
 		bus.mu.RUnlock()
 		logger.Debug("no handlers registered for event type", "event_type", eventType)
-		return nil
+		return
 	}
 
 	handlersCopy := make([]handlerWrapper, len(handlers))
@@ -73,19 +96,17 @@ func (bus *LocalEventBus) Publish(ctx context.Context, event port.Event) error {
 			}
 		}(hw.handler)
 	}
-
-	return nil
 }
 
-// Subscribe 订阅事件
-func (bus *LocalEventBus) Subscribe(eventType string, handler port.EventHandler) {
+// Subscribe 订阅事件，本地实现忽略订阅选项；参数无效时返回 nil
+func (bus *LocalEventBus) Subscribe(eventType string, handler port.EventHandler, _ ...port.SubscribeOption) port.Subscription {
 	if eventType == "" {
 		logger.Warn("attempted to subscribe with empty event type")
-		return
+		return nil
 	}
 	if handler == nil {
 		logger.Warn("attempted to subscribe with nil handler")
-		return
+		return nil
 	}
 
 	handlerIDMutex.Lock()
@@ -103,41 +124,39 @@ func (bus *LocalEventBus) Subscribe(eventType string, handler port.EventHandler)
 
 	bus.handlers[eventType] = append(bus.handlers[eventType], hw)
 	logger.Debug("subscribed handler to event type", "handler_id", id, "event_type", eventType)
+	return &localSubscription{eventType: eventType, id: id}
 }
 
 // Unsubscribe 取消订阅
-func (bus *LocalEventBus) Unsubscribe(eventType string, handler port.EventHandler) {
-	if eventType == "" {
-		logger.Warn("attempted to unsubscribe with empty event type")
-		return
-	}
-	if handler == nil {
-		logger.Warn("attempted to unsubscribe with nil handler")
+func (bus *LocalEventBus) Unsubscribe(sub port.Subscription) {
+	ls, ok := sub.(*localSubscription)
+	if !ok || ls == nil {
+		logger.Warn("attempted to unsubscribe with unknown subscription")
 		return
 	}
 
 	bus.mu.Lock()
 	defer bus.mu.Unlock()
 
-	handlers, exists := bus.handlers[eventType]
+	handlers, exists := bus.handlers[ls.eventType]
 	if !exists {
-		logger.Debug("no handlers found for event type", "event_type", eventType)
+		logger.Debug("no handlers found for event type", "event_type", ls.eventType)
 		return
 	}
 
 	for i, hw := range handlers {
-		if isSameHandler(hw.handler, handler) {
-			bus.handlers[eventType] = append(handlers[:i], handlers[i+1:]...)
-			logger.Debug("unsubscribed handler from event type", "handler_id", hw.id, "event_type", eventType)
+		if hw.id == ls.id {
+			bus.handlers[ls.eventType] = append(handlers[:i:i], handlers[i+1:]...)
+			logger.Debug("unsubscribed handler from event type", "handler_id", hw.id, "event_type", ls.eventType)
 
-			if len(bus.handlers[eventType]) == 0 {
-				delete(bus.handlers, eventType)
+			if len(bus.handlers[ls.eventType]) == 0 {
+				delete(bus.handlers, ls.eventType)
 			}
 			return
 		}
 	}
 
-	logger.Debug("handler not found for event type", "event_type", eventType)
+	logger.Debug("handler not found for event type", "event_type", ls.eventType)
 }
 
 // GetSubscriberCount 获取指定事件类型的订阅者数量（用于测试和监控）
@@ -154,10 +173,3 @@ func (bus *LocalEventBus) Clear() {
 	bus.handlers = make(map[string][]handlerWrapper)
 	logger.Debug("event bus cleared")
 }
-
-// isSameHandler 比较两个 handler 是否相同
-// 注意：Go 中函数不能直接比较，这里使用简单的实现
-// 实际使用中，建议使用具名函数或结构体方法作为 handler，便于管理
-func isSameHandler(h1, h2 port.EventHandler) bool {
-	return &h1 == &h2
-}
@@ -0,0 +1,74 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
+
+	"github.com/google/uuid"
+)
+
+func TestLocalEventBusUnsubscribe(t *testing.T) {
+	bus := NewLocalEventBus(0)
+	handler := func(context.Context, port.Event) error { return nil }
+	first := bus.Subscribe(testEventType, handler)
+	second := bus.Subscribe(testEventType, handler)
+	if got := bus.GetSubscriberCount(testEventType); got != 2 {
+		t.Fatalf("subscriber count = %d, want 2", got)
+	}
+
+	bus.Unsubscribe(first)
+	bus.Unsubscribe(first)
+	if got := bus.GetSubscriberCount(testEventType); got != 1 {
+		t.Fatalf("subscriber count after unsubscribe = %d, want 1", got)
+	}
+	bus.Unsubscribe(second)
+	if got := bus.GetSubscriberCount(testEventType); got != 0 {
+		t.Fatalf("subscriber count after unsubscribe all = %d, want 0", got)
+	}
+}
+
+func TestLocalEventBusPublishAfterCommit(t *testing.T) {
+	db := newTestDB(t)
+	uow := persistence.NewUnitOfWork(db)
+	bus := NewLocalEventBus(0)
+	received := make(chan float64, 2)
+	bus.Subscribe(testEventType, func(_ context.Context, ev port.Event) error {
+		n, _ := ev.(*event.GenericEvent).Data["n"].(float64)
+		received <- n
+		return nil
+	})
+
+	_ = uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		_ = bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 1.0}))
+		return errors.New("rollback")
+	})
+	_ = uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		_ = bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 2.0}))
+		select {
+		case <-received:
+			t.Error("event delivered before commit")
+		case <-time.After(20 * time.Millisecond):
+		}
+		return nil
+	})
+
+	select {
+	case n := <-received:
+		if n != 2 {
+			t.Errorf("received n = %v, want 2", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered after commit")
+	}
+	select {
+	case n := <-received:
+		t.Errorf("rolled back event delivered: n = %v", n)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,161 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport 进程内的 StreamTransport 实现，语义与 NATS/Redis 实现一致（消费组、确认与重新投递），供测试与单机调试使用
+type MemoryTransport struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	topics map[string]*memoryTopic
+	closed bool
+}
+
+type memoryTopic struct {
+	messages [][]byte
+	groups   map[string]*memoryGroup
+}
+
+// memoryGroup 消费组：next 为下一条未分发的消息，redeliver 为处理失败待重新投递的消息
+type memoryGroup struct {
+	next      int
+	redeliver [][]byte
+}
+
+type memoryConsumer struct {
+	t         *MemoryTransport
+	topic     string
+	group     string
+	ephemeral bool
+	stopped   bool
+	done      chan struct{}
+	cancel    context.CancelFunc
+}
+
+// NewMemoryTransport 创建进程内消息传输
+func NewMemoryTransport() *MemoryTransport {
+	t := &MemoryTransport{topics: make(map[string]*memoryTopic)}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// NewMemoryEventBus 创建基于 MemoryTransport 的 StreamEventBus
+func NewMemoryEventBus(cfg StreamConfig) *StreamEventBus {
+	return NewStreamEventBus(NewMemoryTransport(), nil, cfg)
+}
+
+func (t *MemoryTransport) topicLocked(name string) *memoryTopic {
+	tp, ok := t.topics[name]
+	if !ok {
+		tp = &memoryTopic{groups: make(map[string]*memoryGroup)}
+		t.topics[name] = tp
+	}
+	return tp
+}
+
+func (t *MemoryTransport) Publish(_ context.Context, topic, _ string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tp := t.topicLocked(topic)
+	tp.messages = append(tp.messages, append([]byte(nil), data...))
+	t.cond.Broadcast()
+	return nil
+}
+
+// Messages 返回主题中的全部消息（用于测试）
+func (t *MemoryTransport) Messages(topic string) [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tp, ok := t.topics[topic]
+	if !ok {
+		return nil
+	}
+	return append([][]byte(nil), tp.messages...)
+}
+
+func (t *MemoryTransport) Consume(ctx context.Context, topic, group string, ephemeral bool, handle func(ctx context.Context, data []byte) error) (StreamConsumer, error) {
+	t.mu.Lock()
+	tp := t.topicLocked(topic)
+	if _, ok := tp.groups[group]; !ok {
+		// 新消费组从此后发布的消息开始
+		tp.groups[group] = &memoryGroup{next: len(tp.messages)}
+	}
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &memoryConsumer{t: t, topic: topic, group: group, ephemeral: ephemeral, done: make(chan struct{}), cancel: cancel}
+	go c.run(ctx, handle)
+	return c, nil
+}
+
+// nextMessage 阻塞直到有待处理的消息或消费者停止
+func (c *memoryConsumer) nextMessage() ([]byte, bool) {
+	t := c.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for {
+		if c.stopped || t.closed {
+			return nil, false
+		}
+		tp := t.topics[c.topic]
+		g := tp.groups[c.group]
+		if len(g.redeliver) > 0 {
+			msg := g.redeliver[0]
+			g.redeliver = g.redeliver[1:]
+			return msg, true
+		}
+		if g.next < len(tp.messages) {
+			msg := tp.messages[g.next]
+			g.next++
+			return msg, true
+		}
+		t.cond.Wait()
+	}
+}
+
+func (c *memoryConsumer) run(ctx context.Context, handle func(ctx context.Context, data []byte) error) {
+	defer close(c.done)
+	for {
+		msg, ok := c.nextMessage()
+		if !ok {
+			return
+		}
+		if err := handle(ctx, msg); err != nil {
+			c.t.mu.Lock()
+			if g, ok := c.t.topics[c.topic].groups[c.group]; ok {
+				g.redeliver = append(g.redeliver, msg)
+			}
+			c.t.mu.Unlock()
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *memoryConsumer) Stop() {
+	c.cancel()
+	c.t.mu.Lock()
+	c.stopped = true
+	c.t.cond.Broadcast()
+	c.t.mu.Unlock()
+	<-c.done
+
+	if c.ephemeral {
+		c.t.mu.Lock()
+		delete(c.t.topics[c.topic].groups, c.group)
+		c.t.mu.Unlock()
+	}
+}
+
+func (t *MemoryTransport) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	t.cond.Broadcast()
+	t.mu.Unlock()
+	return nil
+}
+
+var _ StreamTransport = (*MemoryTransport)(nil)
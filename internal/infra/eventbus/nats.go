@@ -0,0 +1,154 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"goyavision/pkg/logger"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultNATSStream        = "GOYAVISION_EVENTS"
+	defaultNATSSubjectPrefix = "goyavision.events"
+	defaultNATSMaxAge        = 7 * 24 * time.Hour
+
+	// natsAckWait 消息确认超时，须大于 handler 在本进程内重试的总时长，超时未确认的消息会重新投递
+	natsAckWait = 2 * time.Minute
+	// natsEphemeralInactive 临时消费组在进程异常退出后由服务端清理的闲置时长
+	natsEphemeralInactive = 5 * time.Minute
+	natsNakDelay          = 5 * time.Second
+)
+
+var natsNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// NATSConfig NATS JetStream 传输配置，零值字段使用默认值
+type NATSConfig struct {
+	URL string
+	// Stream JetStream 流名称，覆盖 SubjectPrefix.> 下的全部主题
+	Stream        string
+	SubjectPrefix string
+	// MaxAge 消息保留时长
+	MaxAge time.Duration
+}
+
+// NATSTransport 基于 NATS JetStream 的 StreamTransport：事件类型映射为 SubjectPrefix.<type> 主题，
+// 消费组映射为 durable pull 消费者，同名消费者的多个订阅共享消息
+type NATSTransport struct {
+	cfg NATSConfig
+	nc  *nats.Conn
+	js  jetstream.JetStream
+}
+
+// NewNATSTransport 连接 NATS 并创建或更新 JetStream 流
+func NewNATSTransport(ctx context.Context, cfg NATSConfig) (*NATSTransport, error) {
+	if cfg.URL == "" {
+		cfg.URL = nats.DefaultURL
+	}
+	if cfg.Stream == "" {
+		cfg.Stream = defaultNATSStream
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = defaultNATSSubjectPrefix
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultNATSMaxAge
+	}
+
+	nc, err := nats.Connect(cfg.URL, nats.Name("goyavision"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect nats: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create jetstream: %w", err)
+	}
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       cfg.Stream,
+		Subjects:   []string{cfg.SubjectPrefix + ".>"},
+		MaxAge:     cfg.MaxAge,
+		Duplicates: 2 * time.Minute,
+	}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create stream %s: %w", cfg.Stream, err)
+	}
+	return &NATSTransport{cfg: cfg, nc: nc, js: js}, nil
+}
+
+func (t *NATSTransport) subject(topic string) string {
+	return t.cfg.SubjectPrefix + "." + topic
+}
+
+func (t *NATSTransport) Publish(ctx context.Context, topic, id string, data []byte) error {
+	_, err := t.js.Publish(ctx, t.subject(topic), data, jetstream.WithMsgID(id))
+	return err
+}
+
+func (t *NATSTransport) Consume(ctx context.Context, topic, group string, ephemeral bool, handle func(ctx context.Context, data []byte) error) (StreamConsumer, error) {
+	name := natsNameSanitizer.ReplaceAllString(group+"_"+topic, "_")
+	cfg := jetstream.ConsumerConfig{
+		FilterSubject: t.subject(topic),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		AckWait:       natsAckWait,
+	}
+	if ephemeral {
+		cfg.Name = name
+		cfg.InactiveThreshold = natsEphemeralInactive
+	} else {
+		cfg.Durable = name
+	}
+	cons, err := t.js.CreateOrUpdateConsumer(ctx, t.cfg.Stream, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create consumer %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		if err := handle(ctx, msg.Data()); err != nil {
+			logger.Warn("nats message will be redelivered", "error", err, "consumer", name)
+			_ = msg.NakWithDelay(natsNakDelay)
+			return
+		}
+		if err := msg.Ack(); err != nil {
+			logger.Warn("nats ack failed", "error", err, "consumer", name)
+		}
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("consume %s: %w", name, err)
+	}
+	return &natsConsumer{t: t, name: name, ephemeral: ephemeral, cc: cc, cancel: cancel}, nil
+}
+
+func (t *NATSTransport) Close() error {
+	return t.nc.Drain()
+}
+
+type natsConsumer struct {
+	t         *NATSTransport
+	name      string
+	ephemeral bool
+	cc        jetstream.ConsumeContext
+	cancel    context.CancelFunc
+}
+
+func (c *natsConsumer) Stop() {
+	c.cancel()
+	c.cc.Drain()
+	<-c.cc.Closed()
+	if c.ephemeral {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.t.js.DeleteConsumer(ctx, c.t.cfg.Stream, c.name); err != nil {
+			logger.Debug("delete nats consumer failed", "error", err, "consumer", c.name)
+		}
+	}
+}
+
+var _ StreamTransport = (*NATSTransport)(nil)
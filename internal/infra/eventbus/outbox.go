@@ -0,0 +1,523 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/infra/persistence/repo"
+	"goyavision/pkg/apperr"
+	"goyavision/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxMaxAttempts  = 5
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+
+	outboxBatchSize       = 100
+	outboxCleanupInterval = time.Hour
+	outboxRetryBaseDelay  = time.Second
+	outboxRetryMaxDelay   = 5 * time.Minute
+
+	// outboxRelayLease 分配序号与清理过期事件的租约，多副本部署时只有持有者执行
+	outboxRelayLease = "event_outbox_relay"
+	// outboxConsumerLeasePrefix 持久消费者租约名前缀，同名消费者同一时刻只在一个副本上消费
+	outboxConsumerLeasePrefix = "event_consumer:"
+	outboxLeaseTTL            = 30 * time.Second
+)
+
+// OutboxConfig outbox 事件总线配置，零值字段使用默认值
+type OutboxConfig struct {
+	// PollInterval 中继轮询间隔，本进程发布事件时会立即唤醒中继
+	PollInterval time.Duration
+	// MaxAttempts handler 失败的默认最大尝试次数，超过后事件进入死信
+	MaxAttempts int
+	// Retention 已分配序号的事件保留时长，落后超过该时长的消费者会跳过被清理的事件
+	Retention time.Duration
+}
+
+// OutboxEventBus 基于事务性 outbox 的事件总线，至少一次投递
+//
+// Publish 将事件写入 outbox_events：在 UnitOfWork 事务内发布时随事务提交，回滚则丢弃；
+// 中继按提交顺序为事件分配序号，再按序号把事件依次交给各订阅的 handler。
+// 各订阅的投递相互独立，一个订阅的 handler 阻塞或退避不影响其他订阅推进。
+// handler 返回 error 或 panic 时按指数退避重试，超过最大尝试次数后写入 event_dead_letters 并跳过。
+//
+// 订阅分两类：
+//   - 持久消费者（port.WithConsumer）：消费位置保存在 event_consumer_offsets，重启后继续，
+//     多副本部署时同名消费者只在持有租约的副本上消费；
+//   - 临时订阅：消费位置只在内存，从订阅时的最新事件开始，每个副本各自投递（如 SSE 事件流）。
+//
+// 进程在 handler 成功后、提交位置前退出时事件会被重复投递，handler 应保证幂等。
+type OutboxEventBus struct {
+	cfg    OutboxConfig
+	holder string
+	outbox *repo.OutboxRepo
+	leases *repo.LeaderLeaseRepo
+
+	mu     sync.Mutex
+	subs   map[int]*outboxSubscription
+	nextID int
+
+	notify    chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	started   bool
+	startOnce sync.Once
+	stopOnce  sync.Once
+	// deliveries 正在进行的投递，每个订阅同一时刻至多一个
+	deliveries sync.WaitGroup
+
+	// 以下字段只由中继访问
+	lastCleanup time.Time
+	// owned 上一轮持有租约的持久消费者，新取得租约时从数据库重新加载消费位置
+	owned map[string]bool
+}
+
+// outboxSubscription 订阅句柄及其投递状态
+type outboxSubscription struct {
+	id          int
+	eventType   string
+	handler     port.EventHandler
+	consumer    string
+	maxAttempts int
+
+	// 以下字段由 mu 保护：delivering 投递进行中，missed 投递期间有被跳过的中继轮次，reload 需从数据库重新加载消费位置
+	mu         sync.Mutex
+	delivering bool
+	missed     bool
+	reload     bool
+
+	// 以下字段只由该订阅的投递访问
+	position    int64
+	initialized bool
+	attempts    int
+	retryAt     time.Time
+}
+
+func (s *outboxSubscription) EventType() string { return s.eventType }
+
+// NewOutboxEventBus 创建 outbox 事件总线，需调用 Start 启动中继；只发布不订阅的进程可不启动，多个进程的中继由租约协调
+func NewOutboxEventBus(db *gorm.DB, cfg OutboxConfig) *OutboxEventBus {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultOutboxPollInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultOutboxRetention
+	}
+	return &OutboxEventBus{
+		cfg:    cfg,
+		holder: newHolderID(),
+		outbox: repo.NewOutboxRepo(db),
+		leases: repo.NewLeaderLeaseRepo(db),
+		subs:   make(map[int]*outboxSubscription),
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		owned:  make(map[string]bool),
+	}
+}
+
+// Publish 将事件写入 outbox；ctx 在 UnitOfWork 事务内时使用该事务
+func (bus *OutboxEventBus) Publish(ctx context.Context, ev port.Event) error {
+	return writeOutbox(ctx, bus.outbox, ev, bus.wake)
+}
+
+// writeOutbox 将事件写入 outbox，写入生效后调用 wake；ctx 在 UnitOfWork 事务内时使用该事务，提交后才唤醒
+func writeOutbox(ctx context.Context, outbox *repo.OutboxRepo, ev port.Event, wake func()) error {
+	if ev == nil {
+		return apperr.InvalidInput("event is required")
+	}
+	eventType := ev.EventType()
+	if eventType == "" {
+		return apperr.InvalidInput("event type is required")
+	}
+
+	data, err := event.Encode(ev)
+	if err != nil {
+		return apperr.Wrap(err, apperr.CodeInternal, "failed to encode event")
+	}
+	row := &model.OutboxEventModel{
+		ID:         uuid.New(),
+		EventType:  eventType,
+		Payload:    string(data),
+		OccurredAt: ev.OccurredAt(),
+	}
+	if te, ok := ev.(interface{ Tenant() uuid.UUID }); ok {
+		if tenantID := te.Tenant(); tenantID != uuid.Nil {
+			row.TenantID = &tenantID
+		}
+	}
+
+	if tx := persistence.TxFromContext(ctx); tx != nil {
+		if err := repo.NewOutboxRepo(tx).Create(ctx, row); err != nil {
+			return apperr.Wrap(err, apperr.CodeDBError, "failed to write outbox event")
+		}
+		persistence.AfterCommit(ctx, wake)
+		return nil
+	}
+	if err := outbox.Create(ctx, row); err != nil {
+		return apperr.Wrap(err, apperr.CodeDBError, "failed to write outbox event")
+	}
+	wake()
+	return nil
+}
+
+// Subscribe 订阅事件；参数无效时返回 nil
+func (bus *OutboxEventBus) Subscribe(eventType string, handler port.EventHandler, opts ...port.SubscribeOption) port.Subscription {
+	if eventType == "" {
+		logger.Warn("attempted to subscribe with empty event type")
+		return nil
+	}
+	if handler == nil {
+		logger.Warn("attempted to subscribe with nil handler")
+		return nil
+	}
+
+	o := port.ApplySubscribeOptions(opts)
+	sub := &outboxSubscription{
+		eventType:   eventType,
+		handler:     handler,
+		consumer:    o.Consumer,
+		maxAttempts: o.MaxAttempts,
+	}
+	if sub.maxAttempts <= 0 {
+		sub.maxAttempts = bus.cfg.MaxAttempts
+	}
+	// 临时订阅从当前最新事件开始；此后提交的事件序号一定更大，不会遗漏
+	if sub.consumer == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		head, err := bus.outbox.MaxSeq(ctx)
+		cancel()
+		if err != nil {
+			logger.Warn("outbox subscribe: read head failed, start from next relay round", "error", err, "event_type", eventType)
+		} else {
+			sub.position = head
+			sub.initialized = true
+		}
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.nextID++
+	sub.id = bus.nextID
+	bus.subs[sub.id] = sub
+	logger.Debug("subscribed handler to event type", "handler_id", sub.id, "event_type", eventType, "consumer", sub.consumer)
+	return sub
+}
+
+// Unsubscribe 取消订阅，正在执行的 handler 不受影响
+func (bus *OutboxEventBus) Unsubscribe(sub port.Subscription) {
+	s, ok := sub.(*outboxSubscription)
+	if !ok || s == nil {
+		logger.Warn("attempted to unsubscribe with unknown subscription")
+		return
+	}
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	delete(bus.subs, s.id)
+	logger.Debug("unsubscribed handler from event type", "handler_id", s.id, "event_type", s.eventType)
+}
+
+// Start 启动中继
+func (bus *OutboxEventBus) Start(ctx context.Context) {
+	bus.startOnce.Do(func() {
+		bus.mu.Lock()
+		bus.started = true
+		bus.mu.Unlock()
+		go bus.run(ctx)
+	})
+}
+
+// Stop 停止中继并等待正在进行的投递结束
+func (bus *OutboxEventBus) Stop() {
+	bus.stopOnce.Do(func() { close(bus.stop) })
+	bus.mu.Lock()
+	started := bus.started
+	bus.mu.Unlock()
+	if started {
+		<-bus.done
+		bus.deliveries.Wait()
+	}
+}
+
+func (bus *OutboxEventBus) wake() {
+	select {
+	case bus.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (bus *OutboxEventBus) run(ctx context.Context) {
+	defer close(bus.done)
+	ticker := time.NewTicker(bus.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		bus.relayOnce(ctx)
+		select {
+		case <-bus.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-bus.notify:
+		}
+	}
+}
+
+// relayOnce 执行一轮中继：分配序号、清理过期事件，并为各订阅启动投递。
+// 各订阅的投递相互独立，不等待投递结束；上一轮投递仍在进行的订阅本轮跳过，投递结束后再唤醒中继
+func (bus *OutboxEventBus) relayOnce(ctx context.Context) {
+	// 在分配序号前读取最新序号：新订阅从这里开始，本轮分配到序号的事件都会投递给它
+	head, err := bus.outbox.MaxSeq(ctx)
+	if err != nil {
+		logger.Error("outbox relay: read head failed", "error", err)
+		return
+	}
+
+	leader, err := bus.leases.TryAcquire(ctx, outboxRelayLease, bus.holder, outboxLeaseTTL)
+	if err != nil {
+		logger.Error("outbox relay: acquire lease failed", "error", err)
+	}
+	if leader {
+		bus.sequence(ctx)
+		bus.cleanup(ctx)
+	}
+
+	bus.mu.Lock()
+	subs := make([]*outboxSubscription, 0, len(bus.subs))
+	for _, sub := range bus.subs {
+		subs = append(subs, sub)
+	}
+	bus.mu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	// 每个持久消费者每轮只竞争一次租约
+	owned := make(map[string]bool)
+	for _, sub := range subs {
+		if sub.consumer == "" {
+			continue
+		}
+		if _, ok := owned[sub.consumer]; ok {
+			continue
+		}
+		ok, err := bus.leases.TryAcquire(ctx, outboxConsumerLeasePrefix+sub.consumer, bus.holder, outboxLeaseTTL)
+		if err != nil {
+			logger.Error("outbox relay: acquire consumer lease failed", "error", err, "consumer", sub.consumer)
+		}
+		owned[sub.consumer] = ok
+	}
+	for _, sub := range subs {
+		if sub.consumer != "" && owned[sub.consumer] && !bus.owned[sub.consumer] {
+			sub.mu.Lock()
+			sub.reload = true
+			sub.mu.Unlock()
+		}
+	}
+	bus.owned = owned
+
+	for _, sub := range subs {
+		if sub.consumer != "" && !owned[sub.consumer] {
+			continue
+		}
+		sub.mu.Lock()
+		if sub.delivering {
+			sub.missed = true
+			sub.mu.Unlock()
+			continue
+		}
+		sub.delivering = true
+		reload := sub.reload
+		sub.reload = false
+		sub.mu.Unlock()
+
+		bus.deliveries.Add(1)
+		go func(sub *outboxSubscription) {
+			defer bus.deliveries.Done()
+			if reload {
+				sub.initialized = false
+			}
+			bus.deliver(ctx, sub, head)
+
+			sub.mu.Lock()
+			missed := sub.missed
+			sub.delivering, sub.missed = false, false
+			sub.mu.Unlock()
+			if missed {
+				bus.wake()
+			}
+		}(sub)
+	}
+}
+
+func (bus *OutboxEventBus) sequence(ctx context.Context) {
+	for {
+		n, err := bus.outbox.Sequence(ctx, outboxBatchSize)
+		if err != nil {
+			if errors.Is(err, repo.ErrOutboxSequenceConflict) {
+				logger.Debug("outbox relay: sequence conflict, retry next round")
+			} else {
+				logger.Error("outbox relay: sequence failed", "error", err)
+			}
+			return
+		}
+		if n < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (bus *OutboxEventBus) cleanup(ctx context.Context) {
+	if time.Since(bus.lastCleanup) < outboxCleanupInterval {
+		return
+	}
+	bus.lastCleanup = time.Now()
+	n, err := bus.outbox.DeleteSequencedBefore(ctx, time.Now().Add(-bus.cfg.Retention))
+	if err != nil {
+		logger.Error("outbox relay: cleanup failed", "error", err)
+		return
+	}
+	if n > 0 {
+		logger.Info("outbox relay: expired events deleted", "count", n)
+	}
+}
+
+// deliver 按序号把订阅位置之后的事件交给 handler，失败时在退避期内停止推进
+func (bus *OutboxEventBus) deliver(ctx context.Context, sub *outboxSubscription, head int64) {
+	if time.Now().Before(sub.retryAt) {
+		return
+	}
+	if !sub.initialized {
+		position := head
+		if sub.consumer != "" {
+			var err error
+			position, err = bus.outbox.EnsurePosition(ctx, sub.consumer, sub.eventType, head)
+			if err != nil {
+				logger.Error("outbox relay: load consumer position failed", "error", err, "consumer", sub.consumer)
+				return
+			}
+		}
+		sub.position = position
+		sub.initialized = true
+	}
+
+	for {
+		rows, err := bus.outbox.ListAfter(ctx, sub.eventType, sub.position, outboxBatchSize)
+		if err != nil {
+			logger.Error("outbox relay: list events failed", "error", err, "event_type", sub.eventType)
+			return
+		}
+		for _, row := range rows {
+			if !bus.handle(ctx, sub, row) {
+				return
+			}
+			if !bus.advance(ctx, sub, *row.Seq) {
+				return
+			}
+		}
+		if len(rows) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// handle 投递单个事件，返回是否可以推进位置（成功或已进入死信）
+func (bus *OutboxEventBus) handle(ctx context.Context, sub *outboxSubscription, row *model.OutboxEventModel) bool {
+	ev, err := event.Decode(row.EventType, []byte(row.Payload))
+	if err == nil {
+		err = callHandler(ctx, sub.handler, ev)
+		if err == nil {
+			sub.attempts = 0
+			sub.retryAt = time.Time{}
+			return true
+		}
+		sub.attempts++
+		if sub.attempts < sub.maxAttempts {
+			sub.retryAt = time.Now().Add(retryDelay(sub.attempts))
+			logger.Warn("event handler error, will retry",
+				"error", err, "event_type", row.EventType, "seq", *row.Seq, "consumer", sub.consumer, "attempt", sub.attempts)
+			return false
+		}
+	} else {
+		// 无法解码的事件重试也不会成功
+		sub.attempts = 1
+	}
+
+	logger.Error("event dead-lettered",
+		"error", err, "event_type", row.EventType, "seq", *row.Seq, "consumer", sub.consumer, "attempts", sub.attempts)
+	if sub.consumer != "" {
+		dl := &model.EventDeadLetterModel{
+			ID:        uuid.New(),
+			Consumer:  sub.consumer,
+			EventID:   row.ID,
+			EventType: row.EventType,
+			Seq:       *row.Seq,
+			Payload:   row.Payload,
+			Error:     err.Error(),
+			Attempts:  sub.attempts,
+		}
+		if err := bus.outbox.CreateDeadLetter(ctx, dl); err != nil {
+			logger.Error("outbox relay: write dead letter failed", "error", err, "consumer", sub.consumer)
+			return false
+		}
+	}
+	sub.attempts = 0
+	sub.retryAt = time.Time{}
+	return true
+}
+
+func (bus *OutboxEventBus) advance(ctx context.Context, sub *outboxSubscription, seq int64) bool {
+	if sub.consumer != "" {
+		if err := bus.outbox.CommitPosition(ctx, sub.consumer, sub.eventType, seq); err != nil {
+			logger.Error("outbox relay: commit position failed", "error", err, "consumer", sub.consumer)
+			return false
+		}
+	}
+	sub.position = seq
+	return true
+}
+
+func callHandler(ctx context.Context, handler port.EventHandler, ev port.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, ev)
+}
+
+// retryDelay 第 attempt 次失败后的退避时长：1s、2s、4s……最长 5 分钟
+func retryDelay(attempt int) time.Duration {
+	d := outboxRetryBaseDelay
+	for i := 1; i < attempt && d < outboxRetryMaxDelay; i++ {
+		d *= 2
+	}
+	if d > outboxRetryMaxDelay {
+		d = outboxRetryMaxDelay
+	}
+	return d
+}
+
+func newHolderID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
@@ -0,0 +1,270 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testEventType = "outbox_test"
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(
+		&model.OutboxEventModel{},
+		&model.EventConsumerOffsetModel{},
+		&model.EventDeadLetterModel{},
+		&model.LeaderLeaseModel{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// recorder 记录收到的事件，failures 指定前几次调用返回错误
+type recorder struct {
+	mu       sync.Mutex
+	got      []int
+	calls    int
+	failures int
+}
+
+func (r *recorder) handle(_ context.Context, ev port.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("boom")
+	}
+	n, _ := ev.(*event.GenericEvent).Data["n"].(float64)
+	r.got = append(r.got, int(n))
+	return nil
+}
+
+func (r *recorder) received() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.got...)
+}
+
+func publishN(t *testing.T, bus port.EventBus, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		ev := event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": i})
+		if err := bus.Publish(context.Background(), ev); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+}
+
+func assertInts(t *testing.T, got, want []int) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("received %v, want %v", got, want)
+	}
+}
+
+// relay 执行一轮中继并等待投递结束
+func relay(bus *OutboxEventBus) {
+	bus.relayOnce(context.Background())
+	bus.deliveries.Wait()
+}
+
+func TestOutboxEventBusDeliversInOrder(t *testing.T) {
+	db := newTestDB(t)
+	bus := NewOutboxEventBus(db, OutboxConfig{})
+	rec := &recorder{}
+	bus.Subscribe(testEventType, rec.handle)
+
+	publishN(t, bus, 1, 3)
+	relay(bus)
+	assertInts(t, rec.received(), []int{1, 2, 3})
+
+	relay(bus)
+	assertInts(t, rec.received(), []int{1, 2, 3})
+}
+
+func TestOutboxEventBusTransaction(t *testing.T) {
+	db := newTestDB(t)
+	bus := NewOutboxEventBus(db, OutboxConfig{})
+	uow := persistence.NewUnitOfWork(db)
+	rec := &recorder{}
+	bus.Subscribe(testEventType, rec.handle)
+
+	errRollback := errors.New("rollback")
+	err := uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		if err := bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 1})); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Do() error = %v", err)
+	}
+	err = uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		return bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 2}))
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	relay(bus)
+	assertInts(t, rec.received(), []int{2})
+}
+
+func TestOutboxEventBusRetryAndDeadLetter(t *testing.T) {
+	db := newTestDB(t)
+	bus := NewOutboxEventBus(db, OutboxConfig{})
+	retried := &recorder{failures: 2}
+	dead := &recorder{failures: 100}
+	retriedSub := bus.Subscribe(testEventType, retried.handle, port.WithConsumer("retried"), port.WithMaxAttempts(3)).(*outboxSubscription)
+	deadSub := bus.Subscribe(testEventType, dead.handle, port.WithConsumer("dead"), port.WithMaxAttempts(2)).(*outboxSubscription)
+
+	publishN(t, bus, 1, 2)
+	for i := 0; i < 3; i++ {
+		relay(bus)
+		// 跳过退避等待
+		retriedSub.retryAt = time.Time{}
+		deadSub.retryAt = time.Time{}
+	}
+
+	assertInts(t, retried.received(), []int{1, 2})
+	if dead.calls != 4 {
+		t.Errorf("dead handler calls = %d, want 4", dead.calls)
+	}
+	var letters []model.EventDeadLetterModel
+	db.Order("seq").Find(&letters)
+	if len(letters) != 2 || letters[0].Consumer != "dead" || letters[0].Attempts != 2 || letters[0].Error != "boom" {
+		t.Errorf("dead letters = %+v", letters)
+	}
+}
+
+func TestOutboxEventBusDurableConsumerResumes(t *testing.T) {
+	db := newTestDB(t)
+	first := NewOutboxEventBus(db, OutboxConfig{})
+	rec := &recorder{}
+	sub := first.Subscribe(testEventType, rec.handle, port.WithConsumer("durable"))
+	relay(first)
+	publishN(t, first, 1, 2)
+	relay(first)
+	first.Unsubscribe(sub)
+	assertInts(t, rec.received(), []int{1, 2})
+
+	// 消费者下线期间发布的事件在重新订阅后投递
+	publishN(t, first, 3, 4)
+	relay(first)
+	assertInts(t, rec.received(), []int{1, 2})
+
+	// 同名消费者的租约仍由 first 持有，交给新实例前先释放
+	db.Where("name = ?", outboxConsumerLeasePrefix+"durable").Delete(&model.LeaderLeaseModel{})
+	second := NewOutboxEventBus(db, OutboxConfig{})
+	second.Subscribe(testEventType, rec.handle, port.WithConsumer("durable"))
+	relay(second)
+	assertInts(t, rec.received(), []int{1, 2, 3, 4})
+}
+
+// 同名持久消费者在多个运行中继的进程（如 cmd/server 与 cmd/worker）上订阅时，每个事件只投递一次
+func TestOutboxEventBusDurableConsumerAcrossRelays(t *testing.T) {
+	db := newTestDB(t)
+	server, worker := NewOutboxEventBus(db, OutboxConfig{}), NewOutboxEventBus(db, OutboxConfig{})
+	onServer, onWorker := &recorder{}, &recorder{}
+	server.Subscribe(testEventType, onServer.handle, port.WithConsumer("shared"))
+	worker.Subscribe(testEventType, onWorker.handle, port.WithConsumer("shared"))
+
+	relay(server)
+	relay(worker)
+	publishN(t, worker, 1, 3)
+	relay(server)
+	relay(worker)
+	assertInts(t, onServer.received(), []int{1, 2, 3})
+	assertInts(t, onWorker.received(), nil)
+
+	// 租约转移后从已提交的位置继续，不重复投递
+	db.Where("name = ?", outboxConsumerLeasePrefix+"shared").Delete(&model.LeaderLeaseModel{})
+	publishN(t, server, 4, 5)
+	relay(worker)
+	// 序号仍由持有中继租约的 server 分配
+	relay(server)
+	relay(worker)
+	assertInts(t, onServer.received(), []int{1, 2, 3})
+	assertInts(t, onWorker.received(), []int{4, 5})
+}
+
+func TestOutboxEventBusSlowConsumerDoesNotBlockOthers(t *testing.T) {
+	db := newTestDB(t)
+	bus := NewOutboxEventBus(db, OutboxConfig{})
+	release := make(chan struct{})
+	slow, fast := &recorder{}, &recorder{}
+	bus.Subscribe(testEventType, func(ctx context.Context, ev port.Event) error {
+		<-release
+		return slow.handle(ctx, ev)
+	}, port.WithConsumer("slow"))
+	bus.Subscribe(testEventType, fast.handle, port.WithConsumer("fast"))
+
+	publishN(t, bus, 1, 1)
+	bus.relayOnce(context.Background())
+	waitFor(t, "first delivery", func() bool { return len(fast.received()) == 1 })
+	publishN(t, bus, 2, 2)
+	bus.relayOnce(context.Background())
+	waitFor(t, "second delivery", func() bool { return len(fast.received()) == 2 })
+	assertInts(t, slow.received(), nil)
+
+	close(release)
+	bus.deliveries.Wait()
+	relay(bus)
+	assertInts(t, slow.received(), []int{1, 2})
+	assertInts(t, fast.received(), []int{1, 2})
+}
+
+func TestOutboxEventBusUnsubscribe(t *testing.T) {
+	db := newTestDB(t)
+	bus := NewOutboxEventBus(db, OutboxConfig{})
+	kept, removed := &recorder{}, &recorder{}
+	bus.Subscribe(testEventType, kept.handle)
+	sub := bus.Subscribe(testEventType, removed.handle)
+	bus.Unsubscribe(sub)
+	bus.Unsubscribe(sub)
+
+	publishN(t, bus, 1, 1)
+	relay(bus)
+	assertInts(t, kept.received(), []int{1})
+	assertInts(t, removed.received(), nil)
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, outboxRetryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
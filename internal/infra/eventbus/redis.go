@@ -0,0 +1,181 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"goyavision/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisKeyPrefix = "goyavision:events"
+	defaultRedisMaxLen    = 100000
+
+	redisReadCount = 10
+	redisReadBlock = 2 * time.Second
+	// redisClaimIdle 消费者处理中未确认超过该时长的消息会被同组其他消费者接管，须大于 handler 在本进程内重试的总时长
+	redisClaimIdle     = 2 * time.Minute
+	redisClaimInterval = 30 * time.Second
+	redisErrorBackoff  = time.Second
+)
+
+// RedisConfig Redis Streams 传输配置，零值字段使用默认值
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// KeyPrefix 事件类型 t 对应的 Stream 键为 KeyPrefix:t
+	KeyPrefix string
+	// MaxLen 每个 Stream 保留的大致消息数
+	MaxLen int64
+}
+
+// RedisTransport 基于 Redis Streams 的 StreamTransport：消费组映射为 Stream 消费组，
+// 进程以实例标识作为组内消费者名，异常退出后未确认的消息由同组其他消费者通过 XAUTOCLAIM 接管
+type RedisTransport struct {
+	cfg      RedisConfig
+	rdb      *redis.Client
+	consumer string
+}
+
+// NewRedisTransport 连接 Redis
+func NewRedisTransport(ctx context.Context, cfg RedisConfig) (*RedisTransport, error) {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = defaultRedisKeyPrefix
+	}
+	if cfg.MaxLen <= 0 {
+		cfg.MaxLen = defaultRedisMaxLen
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
+		return nil, fmt.Errorf("connect redis: %w", err)
+	}
+	return &RedisTransport{cfg: cfg, rdb: rdb, consumer: newHolderID()}, nil
+}
+
+func (t *RedisTransport) key(topic string) string {
+	return t.cfg.KeyPrefix + ":" + topic
+}
+
+func (t *RedisTransport) Publish(ctx context.Context, topic, id string, data []byte) error {
+	return t.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: t.key(topic),
+		MaxLen: t.cfg.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"id": id, "data": data},
+	}).Err()
+}
+
+func (t *RedisTransport) Consume(ctx context.Context, topic, group string, ephemeral bool, handle func(ctx context.Context, data []byte) error) (StreamConsumer, error) {
+	key := t.key(topic)
+	if err := t.rdb.XGroupCreateMkStream(ctx, key, group, "$").Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("create consumer group %s: %w", group, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c := &redisConsumer{t: t, key: key, group: group, ephemeral: ephemeral, cancel: cancel}
+	c.wg.Add(1)
+	go c.run(ctx, handle)
+	return c, nil
+}
+
+func (t *RedisTransport) Close() error {
+	return t.rdb.Close()
+}
+
+type redisConsumer struct {
+	t         *RedisTransport
+	key       string
+	group     string
+	ephemeral bool
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func (c *redisConsumer) run(ctx context.Context, handle func(ctx context.Context, data []byte) error) {
+	defer c.wg.Done()
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= redisClaimInterval {
+			lastClaim = time.Now()
+			c.claim(ctx, handle)
+		}
+
+		streams, err := c.t.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.t.consumer,
+			Streams:  []string{c.key, ">"},
+			Count:    redisReadCount,
+			Block:    redisReadBlock,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			logger.Warn("redis read group failed", "error", err, "stream", c.key, "group", c.group)
+			select {
+			case <-ctx.Done():
+			case <-time.After(redisErrorBackoff):
+			}
+			continue
+		}
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				c.process(ctx, msg, handle)
+			}
+		}
+	}
+}
+
+// claim 接管同组中闲置过久的未确认消息（其消费者已退出或卡住）
+func (c *redisConsumer) claim(ctx context.Context, handle func(ctx context.Context, data []byte) error) {
+	msgs, _, err := c.t.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   c.key,
+		Group:    c.group,
+		Consumer: c.t.consumer,
+		MinIdle:  redisClaimIdle,
+		Start:    "0-0",
+		Count:    redisReadCount,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("redis auto claim failed", "error", err, "stream", c.key, "group", c.group)
+		}
+		return
+	}
+	for _, msg := range msgs {
+		c.process(ctx, msg, handle)
+	}
+}
+
+func (c *redisConsumer) process(ctx context.Context, msg redis.XMessage, handle func(ctx context.Context, data []byte) error) {
+	data, _ := msg.Values["data"].(string)
+	if err := handle(ctx, []byte(data)); err != nil {
+		// 不确认，闲置超过 redisClaimIdle 后重新投递
+		logger.Warn("redis message will be redelivered", "error", err, "stream", c.key, "group", c.group, "id", msg.ID)
+		return
+	}
+	if err := c.t.rdb.XAck(context.WithoutCancel(ctx), c.key, c.group, msg.ID).Err(); err != nil {
+		logger.Warn("redis ack failed", "error", err, "stream", c.key, "group", c.group, "id", msg.ID)
+	}
+}
+
+func (c *redisConsumer) Stop() {
+	c.cancel()
+	c.wg.Wait()
+	if c.ephemeral {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.t.rdb.XGroupDestroy(ctx, c.key, c.group).Err(); err != nil {
+			logger.Debug("destroy redis consumer group failed", "error", err, "stream", c.key, "group", c.group)
+		}
+	}
+}
+
+var _ StreamTransport = (*RedisTransport)(nil)
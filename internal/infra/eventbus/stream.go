@@ -0,0 +1,379 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/repo"
+	"goyavision/pkg/apperr"
+	"goyavision/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeadLetterTopic 处理失败的事件转发到的主题，消息为 DeadLetter JSON
+const DeadLetterTopic = "_dead_letter"
+
+// streamForwarderLease 将 outbox 事件转发到消息系统的租约，多副本部署时只有持有者转发
+const streamForwarderLease = "event_stream_forwarder"
+
+// StreamTransport 外部消息系统的最小抽象：按主题发布，按消费组消费
+type StreamTransport interface {
+	// Publish 发布消息，id 为消息唯一标识，支持去重的实现据此丢弃重复发布
+	Publish(ctx context.Context, topic, id string, data []byte) error
+	// Consume 以消费组 group 消费 topic 中此后发布的消息；同组的多个消费者共享消息，每条消息只交给其中一个。
+	// handle 返回 nil 时确认消息，返回 error 或进程退出时消息稍后重新投递。
+	// ephemeral 为 true 时消费组随 Stop 删除，否则保留消费位置供重启后继续。
+	Consume(ctx context.Context, topic, group string, ephemeral bool, handle func(ctx context.Context, data []byte) error) (StreamConsumer, error)
+	// Close 关闭连接
+	Close() error
+}
+
+// StreamConsumer 消费者句柄
+type StreamConsumer interface {
+	// Stop 停止消费并等待正在处理的消息结束
+	Stop()
+}
+
+// DeadLetter 超过最大尝试次数仍处理失败的事件
+type DeadLetter struct {
+	Consumer string          `json:"consumer"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	Envelope json.RawMessage `json:"envelope"`
+	At       int64           `json:"at"`
+}
+
+// StreamConfig 外部事件总线配置，零值字段使用默认值
+type StreamConfig struct {
+	// MaxAttempts handler 失败的默认最大尝试次数，超过后事件转发到 DeadLetterTopic
+	MaxAttempts int
+	// PollInterval 转发 outbox 事件的轮询间隔，本进程发布事件时会立即唤醒转发
+	PollInterval time.Duration
+}
+
+// StreamEventBus 基于外部消息系统的事件总线，多副本共享同一事件流
+//
+// 事件以版本化 JSON 信封（Envelope）按事件类型发布到同名主题。
+// 订阅按消费组消费：port.WithConsumer 指定的消费组在全部副本间共享，每个事件只由其中一个副本处理；
+// 未指定时每个订阅使用本进程独有的临时消费组，各副本都会收到事件（如 SSE 事件流）。
+// handler 失败时在本进程内退避重试，超过最大尝试次数后转发到 DeadLetterTopic 并确认；
+// 处理过程中进程退出的消息由消息系统重新投递，handler 应保证幂等。
+//
+// 指定数据库时 Publish 将事件写入 outbox_events（在 UnitOfWork 事务内随事务提交，回滚则丢弃），
+// 由持有租约的副本按写入顺序转发到消息系统，消息系统确认后才删除，进程崩溃或消息系统不可用时事件不会丢失；
+// 转发后、删除前退出时事件会以相同 ID 重复转发，NATS 据此去重，Redis 由 handler 的幂等保证。
+// 未指定数据库时直接发送，在 UnitOfWork 事务内发布的事件在事务提交后才发送。
+type StreamEventBus struct {
+	transport StreamTransport
+	cfg       StreamConfig
+	holder    string
+	outbox    *repo.OutboxRepo
+	leases    *repo.LeaderLeaseRepo
+
+	mu     sync.Mutex
+	subs   map[int]*streamSubscription
+	nextID int
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	notify    chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	started   bool
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+type streamSubscription struct {
+	id        int
+	eventType string
+	group     string
+	consumer  StreamConsumer
+}
+
+func (s *streamSubscription) EventType() string { return s.eventType }
+
+// NewStreamEventBus 创建基于 transport 的事件总线；db 不为 nil 时事件经 outbox 转发，需调用 Start 启动转发
+func NewStreamEventBus(transport StreamTransport, db *gorm.DB, cfg StreamConfig) *StreamEventBus {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultOutboxPollInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := &StreamEventBus{
+		transport: transport,
+		cfg:       cfg,
+		holder:    newHolderID(),
+		subs:      make(map[int]*streamSubscription),
+		ctx:       ctx,
+		cancel:    cancel,
+		notify:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if db != nil {
+		bus.outbox = repo.NewOutboxRepo(db)
+		bus.leases = repo.NewLeaderLeaseRepo(db)
+	}
+	return bus
+}
+
+// Publish 发布事件；ctx 在 UnitOfWork 事务内时等到提交后发送，回滚则丢弃
+func (bus *StreamEventBus) Publish(ctx context.Context, ev port.Event) error {
+	if bus.outbox != nil {
+		return writeOutbox(ctx, bus.outbox, ev, bus.wake)
+	}
+	if ev == nil {
+		return apperr.InvalidInput("event is required")
+	}
+	if ev.EventType() == "" {
+		return apperr.InvalidInput("event type is required")
+	}
+
+	env, data, err := EncodeEnvelope(ev)
+	if err != nil {
+		return apperr.Wrap(err, apperr.CodeInternal, "failed to encode event")
+	}
+	publish := func(ctx context.Context) error {
+		return bus.transport.Publish(ctx, env.Type, env.ID, data)
+	}
+	if persistence.AfterCommit(ctx, func() {
+		if err := publish(context.WithoutCancel(ctx)); err != nil {
+			logger.Error("publish event after commit failed", "error", err, "event_type", env.Type)
+		}
+	}) {
+		return nil
+	}
+	if err := publish(ctx); err != nil {
+		return apperr.Wrap(err, apperr.CodeServiceUnavailable, "failed to publish event")
+	}
+	return nil
+}
+
+// Subscribe 订阅事件；参数无效或消费组创建失败时返回 nil
+func (bus *StreamEventBus) Subscribe(eventType string, handler port.EventHandler, opts ...port.SubscribeOption) port.Subscription {
+	if eventType == "" {
+		logger.Warn("attempted to subscribe with empty event type")
+		return nil
+	}
+	if handler == nil {
+		logger.Warn("attempted to subscribe with nil handler")
+		return nil
+	}
+
+	o := port.ApplySubscribeOptions(opts)
+	maxAttempts := o.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = bus.cfg.MaxAttempts
+	}
+
+	bus.mu.Lock()
+	bus.nextID++
+	id := bus.nextID
+	bus.mu.Unlock()
+
+	group, ephemeral := o.Consumer, false
+	if group == "" {
+		group, ephemeral = bus.holder+"-"+strconv.Itoa(id), true
+	}
+	sub := &streamSubscription{id: id, eventType: eventType, group: group}
+
+	consumer, err := bus.transport.Consume(bus.ctx, eventType, group, ephemeral, func(ctx context.Context, data []byte) error {
+		return bus.handle(ctx, group, maxAttempts, handler, data)
+	})
+	if err != nil {
+		logger.Error("subscribe event failed", "error", err, "event_type", eventType, "group", group)
+		return nil
+	}
+	sub.consumer = consumer
+
+	bus.mu.Lock()
+	bus.subs[id] = sub
+	bus.mu.Unlock()
+	logger.Debug("subscribed handler to event type", "handler_id", id, "event_type", eventType, "group", group)
+	return sub
+}
+
+// Unsubscribe 取消订阅，等待正在处理的消息结束
+func (bus *StreamEventBus) Unsubscribe(sub port.Subscription) {
+	s, ok := sub.(*streamSubscription)
+	if !ok || s == nil {
+		logger.Warn("attempted to unsubscribe with unknown subscription")
+		return
+	}
+	bus.mu.Lock()
+	_, exists := bus.subs[s.id]
+	delete(bus.subs, s.id)
+	bus.mu.Unlock()
+	if exists {
+		s.consumer.Stop()
+		logger.Debug("unsubscribed handler from event type", "handler_id", s.id, "event_type", s.eventType)
+	}
+}
+
+// Start 启动 outbox 转发；未指定数据库时无需启动
+func (bus *StreamEventBus) Start(ctx context.Context) {
+	if bus.outbox == nil {
+		return
+	}
+	bus.startOnce.Do(func() {
+		bus.mu.Lock()
+		bus.started = true
+		bus.mu.Unlock()
+		go bus.run(ctx)
+	})
+}
+
+// Stop 停止 outbox 转发并等待当前一轮转发结束
+func (bus *StreamEventBus) Stop() {
+	bus.stopOnce.Do(func() { close(bus.stop) })
+	bus.mu.Lock()
+	started := bus.started
+	bus.mu.Unlock()
+	if started {
+		<-bus.done
+	}
+}
+
+func (bus *StreamEventBus) wake() {
+	select {
+	case bus.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (bus *StreamEventBus) run(ctx context.Context) {
+	defer close(bus.done)
+	ticker := time.NewTicker(bus.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		bus.forwardOnce(ctx)
+		select {
+		case <-bus.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-bus.notify:
+		}
+	}
+}
+
+// forwardOnce 持有租约时按写入顺序转发 outbox 事件，消息系统确认后删除；发送失败时停在该事件，下一轮重试
+func (bus *StreamEventBus) forwardOnce(ctx context.Context) {
+	leader, err := bus.leases.TryAcquire(ctx, streamForwarderLease, bus.holder, outboxLeaseTTL)
+	if err != nil {
+		logger.Error("stream forwarder: acquire lease failed", "error", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	for {
+		rows, err := bus.outbox.ListUnsequenced(ctx, outboxBatchSize)
+		if err != nil {
+			logger.Error("stream forwarder: list events failed", "error", err)
+			return
+		}
+		for _, row := range rows {
+			data, err := outboxEnvelope(row)
+			if err != nil {
+				// 无法编码的事件重试也不会成功
+				logger.Error("stream forwarder: event dropped", "error", err, "event_type", row.EventType, "event_id", row.ID)
+			} else if err := bus.transport.Publish(ctx, row.EventType, row.ID.String(), data); err != nil {
+				logger.Warn("stream forwarder: publish failed, will retry", "error", err, "event_type", row.EventType, "event_id", row.ID)
+				return
+			}
+			if err := bus.outbox.Delete(ctx, row.ID); err != nil {
+				logger.Error("stream forwarder: delete forwarded event failed", "error", err, "event_id", row.ID)
+				return
+			}
+		}
+		if len(rows) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// Close 停止 outbox 转发与全部订阅并关闭连接
+func (bus *StreamEventBus) Close() error {
+	bus.Stop()
+	bus.cancel()
+	bus.mu.Lock()
+	subs := bus.subs
+	bus.subs = make(map[int]*streamSubscription)
+	bus.mu.Unlock()
+	for _, s := range subs {
+		s.consumer.Stop()
+	}
+	return bus.transport.Close()
+}
+
+// handle 解码信封并调用 handler，失败时退避重试；返回 error 表示消息应由消息系统重新投递
+func (bus *StreamEventBus) handle(ctx context.Context, group string, maxAttempts int, handler port.EventHandler, data []byte) error {
+	env, ev, err := DecodeEnvelope(data)
+	if err != nil {
+		// 无法解码的消息重试也不会成功
+		return bus.deadLetter(ctx, group, data, env, err, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = callHandler(ctx, handler, ev)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts {
+			return bus.deadLetter(ctx, group, data, env, err, attempt)
+		}
+		logger.Warn("event handler error, will retry",
+			"error", err, "event_type", env.Type, "event_id", env.ID, "group", group, "attempt", attempt)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+func (bus *StreamEventBus) deadLetter(ctx context.Context, group string, data []byte, env *Envelope, cause error, attempts int) error {
+	eventType, eventID := "", ""
+	if env != nil {
+		eventType, eventID = env.Type, env.ID
+	}
+	logger.Error("event dead-lettered",
+		"error", cause, "event_type", eventType, "event_id", eventID, "group", group, "attempts", attempts)
+
+	envelope := json.RawMessage(data)
+	if !json.Valid(data) {
+		envelope, _ = json.Marshal(string(data))
+	}
+	dl, err := json.Marshal(&DeadLetter{
+		Consumer: group,
+		Error:    cause.Error(),
+		Attempts: attempts,
+		Envelope: envelope,
+		At:       time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+	id := uuid.NewString()
+	if eventID != "" {
+		id = group + "-" + eventID
+	}
+	if err := bus.transport.Publish(ctx, DeadLetterTopic, id, dl); err != nil {
+		return fmt.Errorf("publish dead letter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"goyavision/internal/app/event"
+	"goyavision/internal/app/port"
+	"goyavision/internal/infra/persistence"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// waitFor 等待 cond 成立，超时则失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func sorted(ns []int) []int {
+	sort.Ints(ns)
+	return ns
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	ev := event.NewTaskEvent(event.EventTypeTaskStatus, uuid.New(), uuid.New(), uuid.New())
+	ev.Status = "running"
+	ev.Seq = 7
+
+	env, raw, err := EncodeEnvelope(ev)
+	if err != nil {
+		t.Fatalf("EncodeEnvelope() error = %v", err)
+	}
+	if env.Version != EnvelopeVersion || env.Type != event.EventTypeTaskStatus || env.TenantID != ev.TenantID.String() {
+		t.Errorf("envelope = %+v", env)
+	}
+
+	_, decoded, err := DecodeEnvelope(raw)
+	if err != nil {
+		t.Fatalf("DecodeEnvelope() error = %v", err)
+	}
+	got, ok := decoded.(*event.TaskEvent)
+	if !ok {
+		t.Fatalf("decoded type = %T, want *event.TaskEvent", decoded)
+	}
+	if got.TaskID != ev.TaskID || got.Status != "running" || got.Seq != 7 {
+		t.Errorf("decoded = %+v", got)
+	}
+
+	var m map[string]interface{}
+	_ = json.Unmarshal(raw, &m)
+	m["v"] = EnvelopeVersion + 1
+	future, _ := json.Marshal(m)
+	if _, _, err := DecodeEnvelope(future); err == nil {
+		t.Error("DecodeEnvelope() accepted unsupported version")
+	}
+}
+
+func TestStreamEventBusConsumerGroups(t *testing.T) {
+	bus := NewMemoryEventBus(StreamConfig{})
+	defer bus.Close()
+
+	shared := &recorder{}
+	other := &recorder{}
+	ephemeralA, ephemeralB := &recorder{}, &recorder{}
+	bus.Subscribe(testEventType, shared.handle, port.WithConsumer("shared"))
+	bus.Subscribe(testEventType, shared.handle, port.WithConsumer("shared"))
+	bus.Subscribe(testEventType, other.handle, port.WithConsumer("other"))
+	bus.Subscribe(testEventType, ephemeralA.handle)
+	bus.Subscribe(testEventType, ephemeralB.handle)
+
+	publishN(t, bus, 1, 10)
+	all := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	waitFor(t, "deliveries", func() bool {
+		return len(shared.received()) == 10 && len(other.received()) == 10 &&
+			len(ephemeralA.received()) == 10 && len(ephemeralB.received()) == 10
+	})
+	// 同组两个订阅合计每个事件只处理一次
+	time.Sleep(20 * time.Millisecond)
+	assertInts(t, sorted(shared.received()), all)
+	assertInts(t, other.received(), all)
+	assertInts(t, ephemeralA.received(), all)
+	assertInts(t, ephemeralB.received(), all)
+}
+
+func TestStreamEventBusRetryAndDeadLetter(t *testing.T) {
+	transport := NewMemoryTransport()
+	bus := NewStreamEventBus(transport, nil, StreamConfig{})
+	defer bus.Close()
+
+	retried := &recorder{failures: 1}
+	dead := &recorder{failures: 100}
+	bus.Subscribe(testEventType, retried.handle, port.WithConsumer("retried"), port.WithMaxAttempts(2))
+	bus.Subscribe(testEventType, dead.handle, port.WithConsumer("dead"), port.WithMaxAttempts(1))
+
+	publishN(t, bus, 1, 1)
+	waitFor(t, "retry", func() bool { return len(retried.received()) == 1 })
+	waitFor(t, "dead letter", func() bool { return len(transport.Messages(DeadLetterTopic)) == 1 })
+
+	var dl DeadLetter
+	if err := json.Unmarshal(transport.Messages(DeadLetterTopic)[0], &dl); err != nil {
+		t.Fatalf("decode dead letter: %v", err)
+	}
+	if dl.Consumer != "dead" || dl.Attempts != 1 || dl.Error != "boom" {
+		t.Errorf("dead letter = %+v", dl)
+	}
+	if _, ev, err := DecodeEnvelope(dl.Envelope); err != nil || ev.EventType() != testEventType {
+		t.Errorf("dead letter envelope: event = %v, err = %v", ev, err)
+	}
+}
+
+func TestStreamEventBusUnsubscribe(t *testing.T) {
+	bus := NewMemoryEventBus(StreamConfig{})
+	defer bus.Close()
+
+	kept, removed := &recorder{}, &recorder{}
+	bus.Subscribe(testEventType, kept.handle)
+	sub := bus.Subscribe(testEventType, removed.handle)
+	bus.Unsubscribe(sub)
+	bus.Unsubscribe(sub)
+
+	publishN(t, bus, 1, 1)
+	waitFor(t, "delivery", func() bool { return len(kept.received()) == 1 })
+	assertInts(t, removed.received(), nil)
+}
+
+func TestStreamEventBusPublishAfterCommit(t *testing.T) {
+	db := newTestDB(t)
+	uow := persistence.NewUnitOfWork(db)
+	transport := NewMemoryTransport()
+	bus := NewStreamEventBus(transport, db, StreamConfig{})
+	defer bus.Close()
+
+	_ = uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		_ = bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 1}))
+		return errors.New("rollback")
+	})
+	_ = uow.Do(context.Background(), func(ctx context.Context, _ *port.Repositories) error {
+		_ = bus.Publish(ctx, event.NewGenericEvent(testEventType, uuid.New(), map[string]interface{}{"n": 2}))
+		return nil
+	})
+	if n := len(transport.Messages(testEventType)); n != 0 {
+		t.Errorf("published %d messages before forwarding", n)
+	}
+
+	bus.forwardOnce(context.Background())
+	msgs := transport.Messages(testEventType)
+	if len(msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(msgs))
+	}
+	_, ev, err := DecodeEnvelope(msgs[0])
+	if err != nil {
+		t.Fatalf("DecodeEnvelope() error = %v", err)
+	}
+	if n := ev.(*event.GenericEvent).Data["n"]; n != 2.0 {
+		t.Errorf("published n = %v, want 2", n)
+	}
+	if n := countOutbox(t, db); n != 0 {
+		t.Errorf("outbox has %d events after forwarding, want 0", n)
+	}
+}
+
+// failingTransport 在 failures 次发布内返回错误，之后交给 MemoryTransport
+type failingTransport struct {
+	*MemoryTransport
+	mu       sync.Mutex
+	failures int
+}
+
+func (t *failingTransport) Publish(ctx context.Context, topic, id string, data []byte) error {
+	t.mu.Lock()
+	if t.failures > 0 {
+		t.failures--
+		t.mu.Unlock()
+		return errors.New("broker unavailable")
+	}
+	t.mu.Unlock()
+	return t.MemoryTransport.Publish(ctx, topic, id, data)
+}
+
+func TestStreamEventBusForwardKeepsEventsUntilAcked(t *testing.T) {
+	db := newTestDB(t)
+	transport := &failingTransport{MemoryTransport: NewMemoryTransport(), failures: 1}
+	bus := NewStreamEventBus(transport, db, StreamConfig{})
+	defer bus.Close()
+
+	publishN(t, bus, 1, 3)
+	bus.forwardOnce(context.Background())
+	if n := len(transport.Messages(testEventType)); n != 0 {
+		t.Errorf("published %d messages while broker unavailable", n)
+	}
+	if n := countOutbox(t, db); n != 3 {
+		t.Fatalf("outbox has %d events after failed publish, want 3", n)
+	}
+
+	got := &recorder{}
+	bus.Subscribe(testEventType, got.handle, port.WithConsumer("forwarded"))
+	bus.forwardOnce(context.Background())
+	waitFor(t, "deliveries", func() bool { return len(got.received()) == 3 })
+	assertInts(t, got.received(), []int{1, 2, 3})
+	if n := countOutbox(t, db); n != 0 {
+		t.Errorf("outbox has %d events after forwarding, want 0", n)
+	}
+}
+
+func countOutbox(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&model.OutboxEventModel{}).Count(&n).Error; err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	return n
+}
@@ -4,3 +4,4 @@ import "goyavision/internal/app/port"
 
 // Compile-time interface verification
 var _ port.EventBus = (*LocalEventBus)(nil)
+var _ port.EventBus = (*OutboxEventBus)(nil)
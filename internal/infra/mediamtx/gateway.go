@@ -2,6 +2,8 @@ package mediamtx
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"goyavision/internal/adapter/mediamtx"
 	"goyavision/internal/app/port"
@@ -13,18 +15,37 @@ type Gateway struct {
 	recordPath      string
 	recordFormat    string
 	segmentDuration string
+	hookURL         string
+	hookToken       string
 }
 
 // NewGateway 创建 MediaMTX 网关
-func NewGateway(baseURL, username, password, recordPath, recordFormat, segmentDuration string) port.MediaGateway {
+//
+// hookURL 非空时，新建路径注册 runOnReady/runOnNotReady/runOnRecordSegmentComplete 命令，
+// 通过 curl 回调 hookURL 下的 /api/v1/hooks/mediamtx/:event，请求携带 hookToken。
+func NewGateway(baseURL, username, password, recordPath, recordFormat, segmentDuration, hookURL, hookToken string) port.MediaGateway {
 	return &Gateway{
 		client:          mediamtx.NewClient(baseURL, username, password),
 		recordPath:      recordPath,
 		recordFormat:    recordFormat,
 		segmentDuration: segmentDuration,
+		hookURL:         strings.TrimRight(hookURL, "/"),
+		hookToken:       hookToken,
 	}
 }
 
+// hookCommand 构造回调命令。MediaMTX 在执行前替换命令中的 $MTX_* 环境变量，
+// 参数以表单字段提交，由 curl 负责编码。
+func (g *Gateway) hookCommand(event string, fields ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "curl -fsS -m 10 -H \"%s: %s\"", port.MediaHookTokenHeader, g.hookToken)
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, " --data-urlencode \"%s=%s\"", fields[i], fields[i+1])
+	}
+	fmt.Fprintf(&b, " %s/api/v1/hooks/mediamtx/%s", g.hookURL, event)
+	return b.String()
+}
+
 // PatchPath 更新流路径配置
 func (g *Gateway) PatchPath(ctx context.Context, pathName, source string) error {
 	cfg := &mediamtx.PathConfig{
@@ -44,6 +65,15 @@ func (g *Gateway) AddPath(ctx context.Context, pathName, source string) error {
 		RecordFormat:          g.recordFormat,
 		RecordSegmentDuration: g.segmentDuration,
 	}
+	if g.hookURL != "" {
+		cfg.RunOnReady = g.hookCommand(port.MediaHookReady, "path", "$MTX_PATH")
+		cfg.RunOnNotReady = g.hookCommand(port.MediaHookNotReady, "path", "$MTX_PATH")
+		cfg.RunOnRecordSegmentComplete = g.hookCommand(port.MediaHookSegmentComplete,
+			"path", "$MTX_PATH",
+			"segment_path", "$MTX_SEGMENT_PATH",
+			"segment_duration", "$MTX_SEGMENT_DURATION",
+		)
+	}
 	return g.client.AddPath(ctx, pathName, cfg)
 }
 
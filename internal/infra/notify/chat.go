@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+)
+
+// ChatNotifier 将事件以纯文本消息投递到聊天机器人 webhook
+//
+// 消息体按订阅的 ChatFormat 生成：
+//   - generic：{"text": ..., "event": {...}}
+//   - slack：{"text": ...}
+//   - dingtalk、wecom：{"msgtype": "text", "text": {"content": ...}}
+//   - feishu：{"msg_type": "text", "content": {"text": ...}}
+//
+// 钉钉、飞书、企业微信在 HTTP 200 的响应中以非零 errcode/code 表示失败，同样视为投递失败。
+type ChatNotifier struct {
+	client *http.Client
+}
+
+// NewChatNotifier 创建聊天渠道，client 为 nil 时使用 http.DefaultClient
+func NewChatNotifier(client *http.Client) *ChatNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ChatNotifier{client: client}
+}
+
+func (n *ChatNotifier) Send(ctx context.Context, sub *notification.Subscription, msg *notification.Message) (*notification.SendResult, error) {
+	body, err := json.Marshal(chatBody(sub.ChatFormat, msg))
+	if err != nil {
+		return nil, fmt.Errorf("marshal body: %w", err)
+	}
+	result, err := postJSON(ctx, n.client, sub.URL, body, nil)
+	if err != nil {
+		return result, err
+	}
+	if err := chatError(result.Body); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func chatBody(format notification.ChatFormat, msg *notification.Message) map[string]interface{} {
+	text := msg.Text()
+	switch format {
+	case notification.ChatFormatSlack:
+		return map[string]interface{}{"text": text}
+	case notification.ChatFormatDingTalk, notification.ChatFormatWeCom:
+		return map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]interface{}{"content": text},
+		}
+	case notification.ChatFormatFeishu:
+		return map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]interface{}{"text": text},
+		}
+	default:
+		return map[string]interface{}{
+			"text": text,
+			"event": map[string]interface{}{
+				"id":          msg.EventID.String(),
+				"type":        msg.EventType,
+				"tenant_id":   msg.TenantID.String(),
+				"occurred_at": msg.OccurredAt,
+				"data":        msg.Payload,
+			},
+		}
+	}
+}
+
+// chatError 解析响应体中的 errcode（钉钉、企业微信）或 code（飞书），非 JSON 响应视为成功
+func chatError(body string) error {
+	var resp struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return nil
+	}
+	if resp.ErrCode != nil && *resp.ErrCode != 0 {
+		return fmt.Errorf("chat error %d: %s", *resp.ErrCode, resp.ErrMsg)
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		return fmt.Errorf("chat error %d: %s", *resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+var _ port.Notifier = (*ChatNotifier)(nil)
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"goyavision/internal/app/port"
+)
+
+// Fetcher 经 URLGuard 校验目标后下载外部媒体
+type Fetcher struct {
+	guard  *URLGuard
+	client *http.Client
+}
+
+var _ port.MediaFetcher = (*Fetcher)(nil)
+
+// NewFetcher 创建媒体下载器，timeout 为单次下载（含读取响应体）的超时，为 0 时由调用方的 ctx 控制
+func NewFetcher(guard *URLGuard, timeout time.Duration) *Fetcher {
+	return &Fetcher{guard: guard, client: guard.Client(timeout)}
+}
+
+func (f *Fetcher) CheckURL(ctx context.Context, rawURL string) error {
+	return f.guard.CheckURL(ctx, rawURL)
+}
+
+// Fetch 先校验地址再发起 GET 请求；连接与跳转由 guard 再次校验
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*http.Response, error) {
+	if err := f.guard.CheckURL(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return f.client.Do(req)
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrForbiddenTarget 投递目标解析到内网、回环、链路本地等不允许访问的地址
+var ErrForbiddenTarget = errors.New("notification target resolves to a forbidden address")
+
+// URLGuard 限制通知的出站目标，防止订阅地址被用于访问内网服务（SSRF）
+//
+// 主机解析出的任一地址为回环、私有、链路本地、组播或未指定地址时拒绝；
+// allowed 中列出的主机名、IP 或 CIDR 网段不受限制（如内网部署的 IM 机器人网关）。
+// 创建、更新订阅时由 CheckURL 校验；投递时由 Transport 在建立连接前再次校验实际连接的地址，
+// 防止 DNS 解析结果在校验后变化（DNS rebinding）以及跳转到内网地址。
+type URLGuard struct {
+	hosts    map[string]bool
+	nets     []*net.IPNet
+	resolver *net.Resolver
+	dialer   *net.Dialer
+}
+
+// NewURLGuard 创建出站目标校验，allowed 的每一项为主机名、IP 或 CIDR 网段
+func NewURLGuard(allowed []string) *URLGuard {
+	g := &URLGuard{
+		hosts:    make(map[string]bool),
+		resolver: net.DefaultResolver,
+		dialer:   &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			g.nets = append(g.nets, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			g.nets = append(g.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		g.hosts[entry] = true
+	}
+	return g
+}
+
+// CheckURL 解析 URL 的主机并校验其全部地址
+func (g *URLGuard) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("url host is required")
+	}
+	_, err = g.resolve(ctx, host)
+	return err
+}
+
+// Transport 返回在建立连接前校验目标地址的 http.Transport，不使用环境变量中的代理
+func (g *URLGuard) Transport() *http.Transport {
+	return &http.Transport{
+		DialContext:           g.dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// maxRedirects 跟随跳转的最大次数，与 net/http 默认值一致
+const maxRedirects = 10
+
+// CheckRedirect 用作 http.Client.CheckRedirect：跳转目标须通过校验
+func (g *URLGuard) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return g.CheckURL(req.Context(), req.URL.String())
+}
+
+// Client 返回经 Transport 与 CheckRedirect 校验出站目标的 http.Client
+func (g *URLGuard) Client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     g.Transport(),
+		CheckRedirect: g.CheckRedirect,
+	}
+}
+
+// dialContext 连接校验通过的解析地址，而不是重新解析主机名
+func (g *URLGuard) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := g.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := g.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// resolve 解析主机，任一地址不被允许时返回 ErrForbiddenTarget
+func (g *URLGuard) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := g.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("resolve %s: no addresses", host)
+		}
+	}
+	if g.hosts[host] {
+		return ips, nil
+	}
+	for _, ip := range ips {
+		if !g.allowedIP(ip) {
+			return nil, fmt.Errorf("%w: %s (%s)", ErrForbiddenTarget, host, ip)
+		}
+	}
+	return ips, nil
+}
+
+func (g *URLGuard) allowedIP(ip net.IP) bool {
+	for _, n := range g.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
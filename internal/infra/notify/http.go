@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"goyavision/internal/domain/notification"
+)
+
+// postJSON 发送 JSON 请求，返回的 SendResult 在非 2xx 时同样携带响应
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) (*notification.SendResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoyaVision-Notifier")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, notification.ResponseBodyLimit))
+	result := &notification.SendResult{StatusCode: resp.StatusCode, Body: string(respBody)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return result, nil
+}
@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"goyavision/internal/domain/notification"
+	"goyavision/internal/domain/workflow"
+
+	"github.com/google/uuid"
+)
+
+func testMessage() *notification.Message {
+	return &notification.Message{
+		DeliveryID: uuid.New(),
+		EventID:    uuid.New(),
+		EventType:  "task_status",
+		TenantID:   uuid.New(),
+		OccurredAt: 1700000000,
+		Payload:    map[string]interface{}{"task_id": "t1", "status": "failed"},
+	}
+}
+
+func TestWebhookNotifierSignsRequest(t *testing.T) {
+	msg := testMessage()
+	var received webhookBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(notification.HeaderEvent) != "task_status" {
+			t.Errorf("event header = %q", r.Header.Get(notification.HeaderEvent))
+		}
+		if r.Header.Get(notification.HeaderNonce) != msg.DeliveryID.String() {
+			t.Errorf("nonce = %q, want delivery id", r.Header.Get(notification.HeaderNonce))
+		}
+		if !workflow.VerifyWebhookSignature("whsec_test", r.Header.Get(notification.HeaderTimestamp),
+			r.Header.Get(notification.HeaderNonce), body, r.Header.Get(notification.HeaderSignature)) {
+			t.Errorf("signature does not verify")
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sub := &notification.Subscription{Channel: notification.ChannelWebhook, URL: srv.URL, Secret: "whsec_test"}
+	result, err := NewWebhookNotifier(nil).Send(context.Background(), sub, msg)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d", result.StatusCode)
+	}
+	if received.ID != msg.EventID.String() || received.Type != "task_status" || received.Data["status"] != "failed" {
+		t.Fatalf("unexpected body: %+v", received)
+	}
+}
+
+func TestWebhookNotifierReturnsResponseOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("upstream down"))
+	}))
+	defer srv.Close()
+
+	sub := &notification.Subscription{Channel: notification.ChannelWebhook, URL: srv.URL}
+	result, err := NewWebhookNotifier(nil).Send(context.Background(), sub, testMessage())
+	if err == nil {
+		t.Fatal("expected error for 502")
+	}
+	if result == nil || result.StatusCode != http.StatusBadGateway || result.Body != "upstream down" {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestChatNotifierFormats(t *testing.T) {
+	tests := []struct {
+		format notification.ChatFormat
+		reply  string
+		check  func(map[string]interface{}) bool
+		fail   bool
+	}{
+		{notification.ChatFormatGeneric, `ok`, func(b map[string]interface{}) bool { return b["event"] != nil && b["text"] != nil }, false},
+		{notification.ChatFormatSlack, `ok`, func(b map[string]interface{}) bool { return b["text"] != nil }, false},
+		{notification.ChatFormatDingTalk, `{"errcode":0,"errmsg":"ok"}`, func(b map[string]interface{}) bool { return b["msgtype"] == "text" }, false},
+		{notification.ChatFormatWeCom, `{"errcode":93000,"errmsg":"invalid webhook url"}`, func(b map[string]interface{}) bool { return b["msgtype"] == "text" }, true},
+		{notification.ChatFormatFeishu, `{"code":19001,"msg":"param invalid"}`, func(b map[string]interface{}) bool { return b["msg_type"] == "text" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var body map[string]interface{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				_, _ = w.Write([]byte(tt.reply))
+			}))
+			defer srv.Close()
+
+			sub := &notification.Subscription{Channel: notification.ChannelChat, URL: srv.URL, ChatFormat: tt.format}
+			_, err := NewChatNotifier(nil).Send(context.Background(), sub, testMessage())
+			if (err != nil) != tt.fail {
+				t.Fatalf("err = %v, want fail=%v", err, tt.fail)
+			}
+			if !tt.check(body) {
+				t.Fatalf("unexpected body: %v", body)
+			}
+		})
+	}
+}
+
+func TestBuildMail(t *testing.T) {
+	msg := testMessage()
+	raw, err := buildMail("GoyaVision <noreply@example.com>", []string{"ops@example.com"}, msg, time.Unix(msg.OccurredAt, 0))
+	if err != nil {
+		t.Fatalf("build mail: %v", err)
+	}
+	s := string(raw)
+	for _, want := range []string{"To: ops@example.com\r\n", "Subject: [GoyaVision] task_status: failed\r\n", "task_id: t1"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("mail missing %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestURLGuardCheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		url     string
+		wantErr bool
+	}{
+		{"public", nil, "https://93.184.216.34/hook", false},
+		{"loopback", nil, "http://127.0.0.1:8080/hook", true},
+		{"localhost", nil, "http://localhost/hook", true},
+		{"ipv6 loopback", nil, "http://[::1]/hook", true},
+		{"private", nil, "http://10.0.0.5/hook", true},
+		{"metadata", nil, "http://169.254.169.254/latest/meta-data", true},
+		{"unspecified", nil, "http://0.0.0.0/hook", true},
+		{"mapped loopback", nil, "http://[::ffff:127.0.0.1]/hook", true},
+		{"allowed ip", []string{"127.0.0.1"}, "http://127.0.0.1:8080/hook", false},
+		{"allowed cidr", []string{"10.0.0.0/8"}, "http://10.0.0.5/hook", false},
+		{"allowed host", []string{"LocalHost"}, "http://localhost/hook", false},
+		{"cidr does not cover", []string{"10.0.0.0/8"}, "http://192.168.1.1/hook", true},
+		{"no host", nil, "http:///hook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewURLGuard(tt.allowed).CheckURL(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckURL(%s) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestURLGuardTransportBlocksForbiddenTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	sub := &notification.Subscription{Channel: notification.ChannelWebhook, URL: srv.URL}
+
+	client := &http.Client{Transport: NewURLGuard(nil).Transport()}
+	if _, err := NewWebhookNotifier(client).Send(context.Background(), sub, testMessage()); !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("err = %v, want ErrForbiddenTarget", err)
+	}
+
+	client = &http.Client{Transport: NewURLGuard([]string{"127.0.0.1"}).Transport()}
+	if _, err := NewWebhookNotifier(client).Send(context.Background(), sub, testMessage()); err != nil {
+		t.Fatalf("send to allowed host: %v", err)
+	}
+}
+
+func TestFetcherRefusesRedirectToForbiddenTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			// 跳转到未被允许的回环地址
+			http.Redirect(w, r, strings.Replace("http://"+r.Host+"/media", "localhost", "127.0.0.1", 1), http.StatusFound)
+			return
+		}
+		_, _ = io.WriteString(w, "media")
+	}))
+	defer srv.Close()
+	start := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/start"
+
+	fetcher := NewFetcher(NewURLGuard([]string{"localhost"}), time.Second)
+	if err := fetcher.CheckURL(context.Background(), start); err != nil {
+		t.Fatalf("CheckURL(%s): %v", start, err)
+	}
+	if resp, err := fetcher.Fetch(context.Background(), start); !errors.Is(err, ErrForbiddenTarget) {
+		if err == nil {
+			resp.Body.Close()
+		}
+		t.Fatalf("err = %v, want ErrForbiddenTarget", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), srv.URL+"/media"); !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("err = %v, want ErrForbiddenTarget", err)
+	}
+
+	fetcher = NewFetcher(NewURLGuard([]string{"localhost", "127.0.0.1"}), time.Second)
+	resp, err := fetcher.Fetch(context.Background(), start)
+	if err != nil {
+		t.Fatalf("fetch allowed redirect: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "media" {
+		t.Errorf("body = %q, want media", body)
+	}
+}
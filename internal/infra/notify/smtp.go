@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"goyavision/config"
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+)
+
+// SMTPNotifier 通过 SMTP 向订阅的收件人发送纯文本邮件
+//
+// cfg.TLS 为 true 时使用隐式 TLS，否则在服务器支持时升级为 STARTTLS；配置了用户名时使用 PLAIN 认证。
+type SMTPNotifier struct {
+	cfg config.NotificationSMTP
+}
+
+// NewSMTPNotifier 创建 email 渠道
+func NewSMTPNotifier(cfg config.NotificationSMTP) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, sub *notification.Subscription, msg *notification.Message) (*notification.SendResult, error) {
+	if n.cfg.Host == "" {
+		return nil, errors.New("smtp is not configured")
+	}
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from: %w", err)
+	}
+	body, err := buildMail(n.cfg.From, sub.Recipients, msg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return nil, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return nil, fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, rcpt := range sub.Recipients {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q", rcpt)
+		}
+		if err := client.Rcpt(addr.Address); err != nil {
+			return nil, fmt.Errorf("smtp rcpt %s: %w", addr.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return nil, fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("smtp data: %w", err)
+	}
+	if err := client.Quit(); err != nil {
+		return nil, fmt.Errorf("smtp quit: %w", err)
+	}
+	return nil, nil
+}
+
+// dial 建立连接并完成 TLS 协商，连接截止时间取 ctx 的截止时间
+func (n *SMTPNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	port := n.cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if n.cfg.TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake: %w", err)
+	}
+	if !n.cfg.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("smtp starttls: %w", err)
+			}
+		}
+	}
+	return client, nil
+}
+
+// buildMail 生成 UTF-8 纯文本邮件，正文使用 quoted-printable 编码
+func buildMail(from string, to []string, msg *notification.Message, now time.Time) ([]byte, error) {
+	var text bytes.Buffer
+	qp := quotedprintable.NewWriter(&text)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(msg.Text(), "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("encode body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("encode body: %w", err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "%s: %s\r\n", notification.HeaderEvent, msg.EventType)
+	fmt.Fprintf(&b, "%s: %s\r\n", notification.HeaderDelivery, msg.DeliveryID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+	b.Write(text.Bytes())
+	return b.Bytes(), nil
+}
+
+var _ port.Notifier = (*SMTPNotifier)(nil)
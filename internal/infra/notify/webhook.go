@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goyavision/internal/app/port"
+	"goyavision/internal/domain/notification"
+	"goyavision/internal/domain/workflow"
+)
+
+// WebhookNotifier 将事件以签名的 JSON 请求投递到订阅地址
+//
+// 请求体：
+//
+//	{"id": "<event_id>", "delivery_id": "...", "type": "task_status", "tenant_id": "...", "occurred_at": 1700000000, "data": {...}}
+//
+// 签名与入站 webhook 相同，nonce 为 delivery_id，接收方可据此防重放；重放投递的 delivery_id 不同但 id 相同。
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier 创建 webhook 渠道，client 为 nil 时使用 http.DefaultClient（超时由调用方的 ctx 控制）
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookNotifier{client: client}
+}
+
+type webhookBody struct {
+	ID         string                 `json:"id"`
+	DeliveryID string                 `json:"delivery_id"`
+	Type       string                 `json:"type"`
+	TenantID   string                 `json:"tenant_id"`
+	OccurredAt int64                  `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, sub *notification.Subscription, msg *notification.Message) (*notification.SendResult, error) {
+	body, err := json.Marshal(webhookBody{
+		ID:         msg.EventID.String(),
+		DeliveryID: msg.DeliveryID.String(),
+		Type:       msg.EventType,
+		TenantID:   msg.TenantID.String(),
+		OccurredAt: msg.OccurredAt,
+		Data:       msg.Payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal body: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := msg.DeliveryID.String()
+	header := http.Header{}
+	header.Set(notification.HeaderEvent, msg.EventType)
+	header.Set(notification.HeaderDelivery, nonce)
+	header.Set(notification.HeaderTimestamp, timestamp)
+	header.Set(notification.HeaderNonce, nonce)
+	if sub.Secret != "" {
+		header.Set(notification.HeaderSignature, "sha256="+workflow.WebhookSignature(sub.Secret, timestamp, nonce, body))
+	}
+	return postJSON(ctx, n.client, sub.URL, body, header)
+}
+
+var _ port.Notifier = (*WebhookNotifier)(nil)
@@ -0,0 +1,115 @@
+package mapper
+
+import (
+	"encoding/json"
+
+	"goyavision/internal/domain/notification"
+	"goyavision/internal/infra/persistence/model"
+
+	"gorm.io/datatypes"
+)
+
+func NotificationSubscriptionToModel(s *notification.Subscription) *model.NotificationSubscriptionModel {
+	m := &model.NotificationSubscriptionModel{
+		ID:                  s.ID,
+		TenantID:            s.TenantID,
+		Name:                s.Name,
+		Channel:             string(s.Channel),
+		URL:                 s.URL,
+		Secret:              s.Secret,
+		ChatFormat:          string(s.ChatFormat),
+		Enabled:             s.Enabled,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		DisabledReason:      s.DisabledReason,
+		CreatedBy:           s.CreatedBy,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+	if s.EventTypes != nil {
+		data, _ := json.Marshal(s.EventTypes)
+		m.EventTypes = datatypes.JSON(data)
+	}
+	if s.Recipients != nil {
+		data, _ := json.Marshal(s.Recipients)
+		m.Recipients = datatypes.JSON(data)
+	}
+	return m
+}
+
+func NotificationSubscriptionToDomain(m *model.NotificationSubscriptionModel) *notification.Subscription {
+	s := &notification.Subscription{
+		ID:                  m.ID,
+		TenantID:            m.TenantID,
+		Name:                m.Name,
+		Channel:             notification.Channel(m.Channel),
+		URL:                 m.URL,
+		Secret:              m.Secret,
+		ChatFormat:          notification.ChatFormat(m.ChatFormat),
+		Enabled:             m.Enabled,
+		ConsecutiveFailures: m.ConsecutiveFailures,
+		DisabledAt:          m.DisabledAt,
+		DisabledReason:      m.DisabledReason,
+		CreatedBy:           m.CreatedBy,
+		CreatedAt:           m.CreatedAt,
+		UpdatedAt:           m.UpdatedAt,
+	}
+	if m.EventTypes != nil {
+		_ = json.Unmarshal(m.EventTypes, &s.EventTypes)
+	}
+	if m.Recipients != nil {
+		_ = json.Unmarshal(m.Recipients, &s.Recipients)
+	}
+	return s
+}
+
+func NotificationDeliveryToModel(d *notification.Delivery) *model.NotificationDeliveryModel {
+	m := &model.NotificationDeliveryModel{
+		ID:             d.ID,
+		TenantID:       d.TenantID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		OccurredAt:     d.OccurredAt,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		ReplayOf:       d.ReplayOf,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	if d.Payload != nil {
+		data, _ := json.Marshal(d.Payload)
+		m.Payload = datatypes.JSON(data)
+	}
+	return m
+}
+
+func NotificationDeliveryToDomain(m *model.NotificationDeliveryModel) *notification.Delivery {
+	d := &notification.Delivery{
+		ID:             m.ID,
+		TenantID:       m.TenantID,
+		SubscriptionID: m.SubscriptionID,
+		EventID:        m.EventID,
+		EventType:      m.EventType,
+		OccurredAt:     m.OccurredAt,
+		Status:         notification.DeliveryStatus(m.Status),
+		Attempts:       m.Attempts,
+		NextAttemptAt:  m.NextAttemptAt,
+		LastError:      m.LastError,
+		ResponseStatus: m.ResponseStatus,
+		ResponseBody:   m.ResponseBody,
+		ReplayOf:       m.ReplayOf,
+		DeliveredAt:    m.DeliveredAt,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.Payload != nil {
+		_ = json.Unmarshal(m.Payload, &d.Payload)
+	}
+	return d
+}
@@ -0,0 +1,70 @@
+package mapper
+
+import (
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/model"
+)
+
+func WebhookToModel(w *workflow.Webhook) *model.WorkflowWebhookModel {
+	return &model.WorkflowWebhookModel{
+		ID:                w.ID,
+		TenantID:          w.TenantID,
+		WorkflowID:        w.WorkflowID,
+		Secret:            w.Secret,
+		PreviousSecret:    w.PreviousSecret,
+		PreviousExpiresAt: w.PreviousExpiresAt,
+		CreatedBy:         w.CreatedBy,
+		RotatedAt:         w.RotatedAt,
+		CreatedAt:         w.CreatedAt,
+		UpdatedAt:         w.UpdatedAt,
+	}
+}
+
+func WebhookToDomain(m *model.WorkflowWebhookModel) *workflow.Webhook {
+	return &workflow.Webhook{
+		ID:                m.ID,
+		TenantID:          m.TenantID,
+		WorkflowID:        m.WorkflowID,
+		Secret:            m.Secret,
+		PreviousSecret:    m.PreviousSecret,
+		PreviousExpiresAt: m.PreviousExpiresAt,
+		CreatedBy:         m.CreatedBy,
+		RotatedAt:         m.RotatedAt,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+func WebhookDeliveryToModel(d *workflow.WebhookDelivery) *model.WebhookDeliveryModel {
+	return &model.WebhookDeliveryModel{
+		ID:         d.ID,
+		TenantID:   d.TenantID,
+		WebhookID:  d.WebhookID,
+		WorkflowID: d.WorkflowID,
+		Status:     string(d.Status),
+		Error:      d.Error,
+		Nonce:      d.Nonce,
+		RemoteAddr: d.RemoteAddr,
+		Body:       d.Body,
+		TaskID:     d.TaskID,
+		AssetID:    d.AssetID,
+		ReceivedAt: d.ReceivedAt,
+	}
+}
+
+func WebhookDeliveryToDomain(m *model.WebhookDeliveryModel) *workflow.WebhookDelivery {
+	return &workflow.WebhookDelivery{
+		ID:         m.ID,
+		TenantID:   m.TenantID,
+		WebhookID:  m.WebhookID,
+		WorkflowID: m.WorkflowID,
+		Status:     workflow.WebhookDeliveryStatus(m.Status),
+		Error:      m.Error,
+		Nonce:      m.Nonce,
+		RemoteAddr: m.RemoteAddr,
+		Body:       m.Body,
+		TaskID:     m.TaskID,
+		AssetID:    m.AssetID,
+		ReceivedAt: m.ReceivedAt,
+	}
+}
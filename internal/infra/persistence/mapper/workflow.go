@@ -3,6 +3,7 @@ package mapper
 import (
 	"encoding/json"
 
+	"goyavision/internal/domain/operator"
 	"goyavision/internal/domain/workflow"
 	"goyavision/internal/infra/persistence/model"
 
@@ -19,6 +20,8 @@ func WorkflowToModel(w *workflow.Workflow) *model.WorkflowModel {
 		Name:           w.Name,
 		Description: w.Description,
 		Version:     w.Version,
+		Revision:    w.Revision,
+		RevisionID:  w.RevisionID,
 		TriggerType: string(w.TriggerType),
 		Status:      string(w.Status),
 		CreatedAt:   w.CreatedAt,
@@ -32,6 +35,10 @@ func WorkflowToModel(w *workflow.Workflow) *model.WorkflowModel {
 		data, _ := json.Marshal(w.Tags)
 		m.Tags = datatypes.JSON(data)
 	}
+	if w.Timeouts != nil {
+		data, _ := json.Marshal(w.Timeouts)
+		m.Timeouts = datatypes.JSON(data)
+	}
 	if w.VisibleRoleIDs != nil {
 		data, _ := json.Marshal(w.VisibleRoleIDs)
 		m.VisibleRoleIDs = datatypes.JSON(data)
@@ -55,8 +62,12 @@ func WorkflowToDomain(m *model.WorkflowModel) *workflow.Workflow {
 		Name:           m.Name,
 		Description: m.Description,
 		Version:     m.Version,
+		Revision:    m.Revision,
+		RevisionID:  m.RevisionID,
 		TriggerType: workflow.TriggerType(m.TriggerType),
 		Status:      workflow.Status(m.Status),
+		LastRunAt:   m.LastRunAt,
+		NextRunAt:   m.NextRunAt,
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
 	}
@@ -69,6 +80,12 @@ func WorkflowToDomain(m *model.WorkflowModel) *workflow.Workflow {
 	if m.Tags != nil {
 		_ = json.Unmarshal(m.Tags, &w.Tags)
 	}
+	if m.Timeouts != nil {
+		var tc workflow.TimeoutConfig
+		if err := json.Unmarshal(m.Timeouts, &tc); err == nil {
+			w.Timeouts = &tc
+		}
+	}
 	if m.VisibleRoleIDs != nil {
 		_ = json.Unmarshal(m.VisibleRoleIDs, &w.VisibleRoleIDs)
 	}
@@ -167,7 +184,14 @@ func TaskToModel(t *workflow.Task) *model.TaskModel {
 		TenantID:          t.TenantID,
 		TriggeredByUserID: t.TriggeredByUserID,
 		WorkflowID:        t.WorkflowID,
+		RevisionID:        t.RevisionID,
 		AssetID:           t.AssetID,
+		CallerTaskID:      t.CallerTaskID,
+		CallerNodeKey:     t.CallerNodeKey,
+		ParentTaskID:      t.ParentTaskID,
+		Priority:          t.Priority,
+		Deadline:          t.Deadline,
+		SLABreachedAt:     t.SLABreachedAt,
 		Status:      string(t.Status),
 		Progress:    t.Progress,
 		CurrentNode: t.CurrentNode,
@@ -185,6 +209,10 @@ func TaskToModel(t *workflow.Task) *model.TaskModel {
 		data, _ := json.Marshal(t.NodeExecutions)
 		m.NodeExecutions = datatypes.JSON(data)
 	}
+	if t.OperatorVersions != nil {
+		data, _ := json.Marshal(t.OperatorVersions)
+		m.OperatorVersions = datatypes.JSON(data)
+	}
 	return m
 }
 
@@ -194,7 +222,16 @@ func TaskToDomain(m *model.TaskModel) *workflow.Task {
 		TenantID:          m.TenantID,
 		TriggeredByUserID: m.TriggeredByUserID,
 		WorkflowID:        m.WorkflowID,
+		RevisionID:        m.RevisionID,
 		AssetID:           m.AssetID,
+		CallerTaskID:      m.CallerTaskID,
+		CallerNodeKey:     m.CallerNodeKey,
+		ParentTaskID:      m.ParentTaskID,
+		Priority:          m.Priority,
+		LeaseOwner:        m.LeaseOwner,
+		LeaseExpiresAt:    m.LeaseExpiresAt,
+		Deadline:          m.Deadline,
+		SLABreachedAt:     m.SLABreachedAt,
 		Status:      workflow.TaskStatus(m.Status),
 		Progress:    m.Progress,
 		CurrentNode: m.CurrentNode,
@@ -210,6 +247,9 @@ func TaskToDomain(m *model.TaskModel) *workflow.Task {
 	if m.NodeExecutions != nil {
 		_ = json.Unmarshal(m.NodeExecutions, &t.NodeExecutions)
 	}
+	if m.OperatorVersions != nil {
+		_ = json.Unmarshal(m.OperatorVersions, &t.OperatorVersions)
+	}
 	return t
 }
 
@@ -220,6 +260,7 @@ func ArtifactToModel(a *workflow.Artifact) *model.ArtifactModel {
 		TaskID:    a.TaskID,
 		Type:      string(a.Type),
 		AssetID:   a.AssetID,
+		Temporary: a.Temporary,
 		CreatedAt: a.CreatedAt,
 		UpdatedAt: a.UpdatedAt,
 	}
@@ -237,6 +278,7 @@ func ArtifactToDomain(m *model.ArtifactModel) *workflow.Artifact {
 		TaskID:    m.TaskID,
 		Type:      workflow.ArtifactType(m.Type),
 		AssetID:   m.AssetID,
+		Temporary: m.Temporary,
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
 	}
@@ -248,3 +290,123 @@ func ArtifactToDomain(m *model.ArtifactModel) *workflow.Artifact {
 	}
 	return a
 }
+
+func TaskCheckpointToModel(c *workflow.TaskCheckpoint) *model.TaskCheckpointModel {
+	m := &model.TaskCheckpointModel{
+		ID:        c.ID,
+		TenantID:  c.TenantID,
+		TaskID:    c.TaskID,
+		NodeKey:   c.NodeKey,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	if c.Output != nil {
+		data, _ := json.Marshal(c.Output)
+		m.Output = datatypes.JSON(data)
+	}
+	data, _ := json.Marshal(c.Execution)
+	m.Execution = datatypes.JSON(data)
+	return m
+}
+
+func TaskCheckpointToDomain(m *model.TaskCheckpointModel) *workflow.TaskCheckpoint {
+	c := &workflow.TaskCheckpoint{
+		ID:        m.ID,
+		TenantID:  m.TenantID,
+		TaskID:    m.TaskID,
+		NodeKey:   m.NodeKey,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if m.Output != nil {
+		var out operator.Output
+		if err := json.Unmarshal(m.Output, &out); err == nil {
+			c.Output = &out
+		}
+	}
+	if m.Execution != nil {
+		_ = json.Unmarshal(m.Execution, &c.Execution)
+	}
+	return c
+}
+
+func NodeCacheEntryToModel(e *workflow.NodeCacheEntry) *model.NodeCacheEntryModel {
+	m := &model.NodeCacheEntryModel{
+		ID:                e.ID,
+		TenantID:          e.TenantID,
+		OperatorVersionID: e.OperatorVersionID,
+		InputHash:         e.InputHash,
+		SourceTaskID:      e.SourceTaskID,
+		ExpiresAt:         e.ExpiresAt,
+		CreatedAt:         e.CreatedAt,
+	}
+	if e.Output != nil {
+		data, _ := json.Marshal(e.Output)
+		m.Output = datatypes.JSON(data)
+	}
+	if e.ArtifactIDs != nil {
+		data, _ := json.Marshal(e.ArtifactIDs)
+		m.ArtifactIDs = datatypes.JSON(data)
+	}
+	return m
+}
+
+func NodeCacheEntryToDomain(m *model.NodeCacheEntryModel) *workflow.NodeCacheEntry {
+	e := &workflow.NodeCacheEntry{
+		ID:                m.ID,
+		TenantID:          m.TenantID,
+		OperatorVersionID: m.OperatorVersionID,
+		InputHash:         m.InputHash,
+		SourceTaskID:      m.SourceTaskID,
+		ExpiresAt:         m.ExpiresAt,
+		CreatedAt:         m.CreatedAt,
+	}
+	if m.Output != nil {
+		var out operator.Output
+		if err := json.Unmarshal(m.Output, &out); err == nil {
+			e.Output = &out
+		}
+	}
+	if m.ArtifactIDs != nil {
+		_ = json.Unmarshal(m.ArtifactIDs, &e.ArtifactIDs)
+	}
+	return e
+}
+
+func RevisionToModel(r *workflow.Revision) *model.WorkflowRevisionModel {
+	m := &model.WorkflowRevisionModel{
+		ID:         r.ID,
+		TenantID:   r.TenantID,
+		WorkflowID: r.WorkflowID,
+		Revision:   r.Revision,
+		Hash:       r.Hash,
+		Comment:    r.Comment,
+		CreatedBy:  r.CreatedBy,
+		CreatedAt:  r.CreatedAt,
+	}
+	data, _ := json.Marshal(r.Nodes)
+	m.Nodes = datatypes.JSON(data)
+	data, _ = json.Marshal(r.Edges)
+	m.Edges = datatypes.JSON(data)
+	return m
+}
+
+func RevisionToDomain(m *model.WorkflowRevisionModel) *workflow.Revision {
+	r := &workflow.Revision{
+		ID:         m.ID,
+		TenantID:   m.TenantID,
+		WorkflowID: m.WorkflowID,
+		Revision:   m.Revision,
+		Hash:       m.Hash,
+		Comment:    m.Comment,
+		CreatedBy:  m.CreatedBy,
+		CreatedAt:  m.CreatedAt,
+	}
+	if m.Nodes != nil {
+		_ = json.Unmarshal(m.Nodes, &r.Nodes)
+	}
+	if m.Edges != nil {
+		_ = json.Unmarshal(m.Edges, &r.Edges)
+	}
+	return r
+}
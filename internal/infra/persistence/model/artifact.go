@@ -14,6 +14,7 @@ type ArtifactModel struct {
 	Type      string         `gorm:"type:varchar(50);not null;index:idx_artifacts_type"`
 	AssetID   *uuid.UUID     `gorm:"type:uuid;index:idx_artifacts_asset_id"`
 	Data      datatypes.JSON `gorm:"serializer:json"`
+	Temporary bool           `gorm:"not null;default:false"`
 	CreatedAt time.Time      `gorm:"autoCreateTime;index:idx_artifacts_created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// LeaderLeaseModel 多副本领导者租约，每个 Name 同一时刻只有一个 Holder
+type LeaderLeaseModel struct {
+	Name      string    `gorm:"type:varchar(100);primaryKey"`
+	Holder    string    `gorm:"type:varchar(200);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (LeaderLeaseModel) TableName() string { return "leader_leases" }
@@ -0,0 +1,8 @@
+package model
+
+// LockModel 跨进程串行化的命名行锁，事务内以 SELECT ... FOR UPDATE 锁定对应行
+type LockModel struct {
+	Name string `gorm:"type:varchar(200);primaryKey"`
+}
+
+func (LockModel) TableName() string { return "locks" }
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type NodeCacheEntryModel struct {
+	ID                uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	TenantID          uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:uk_node_cache_key"`
+	OperatorVersionID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:uk_node_cache_key;index:idx_node_cache_operator_version"`
+	InputHash         string         `gorm:"type:varchar(64);not null;uniqueIndex:uk_node_cache_key"`
+	Output            datatypes.JSON `gorm:"serializer:json"`
+	ArtifactIDs       datatypes.JSON `gorm:"serializer:json"`
+	SourceTaskID      uuid.UUID      `gorm:"type:uuid"`
+	ExpiresAt         *time.Time     `gorm:"index:idx_node_cache_expires_at"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime"`
+}
+
+func (NodeCacheEntryModel) TableName() string { return "node_cache_entries" }
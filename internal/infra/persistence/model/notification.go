@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// NotificationSubscriptionModel 租户的事件通知订阅
+type NotificationSubscriptionModel struct {
+	ID                  uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	TenantID            uuid.UUID      `gorm:"type:uuid;not null;index:idx_notification_subscriptions_tenant_id"`
+	Name                string         `gorm:"type:varchar(200);not null"`
+	Channel             string         `gorm:"type:varchar(20);not null"`
+	EventTypes          datatypes.JSON `gorm:"serializer:json"`
+	URL                 string         `gorm:"type:text"`
+	Secret              string         `gorm:"type:text"`
+	ChatFormat          string         `gorm:"type:varchar(20)"`
+	Recipients          datatypes.JSON `gorm:"serializer:json"`
+	Enabled             bool           `gorm:"not null;default:true"`
+	ConsecutiveFailures int            `gorm:"not null;default:0"`
+	DisabledAt          *time.Time
+	DisabledReason      string     `gorm:"type:text"`
+	CreatedBy           *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (NotificationSubscriptionModel) TableName() string { return "notification_subscriptions" }
+
+// NotificationDeliveryModel 通知投递记录
+type NotificationDeliveryModel struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	TenantID       uuid.UUID      `gorm:"type:uuid;not null;index:idx_notification_deliveries_tenant_id"`
+	SubscriptionID uuid.UUID      `gorm:"type:uuid;not null;index:idx_notification_deliveries_subscription_id"`
+	EventID        uuid.UUID      `gorm:"type:uuid;not null"`
+	EventType      string         `gorm:"type:varchar(100);not null"`
+	Payload        datatypes.JSON `gorm:"serializer:json"`
+	OccurredAt     int64          `gorm:"not null"`
+	Status         string         `gorm:"type:varchar(20);not null;index:idx_notification_deliveries_due,priority:1"`
+	Attempts       int            `gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time     `gorm:"index:idx_notification_deliveries_due,priority:2"`
+	LastError      string         `gorm:"type:text"`
+	ResponseStatus int
+	ResponseBody   string     `gorm:"type:text"`
+	ReplayOf       *uuid.UUID `gorm:"type:uuid"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime;index:idx_notification_deliveries_created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+func (NotificationDeliveryModel) TableName() string { return "notification_deliveries" }
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OperatorSlotModel 算子并发槽位，每行是一次进行中的算子调用；持有方失联时按 ExpiresAt 过期
+type OperatorSlotModel struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OperatorCode string    `gorm:"type:varchar(100);not null;index"`
+	Holder       string    `gorm:"type:varchar(200);not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (OperatorSlotModel) TableName() string { return "operator_slots" }
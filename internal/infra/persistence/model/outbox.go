@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEventModel 事务性 outbox 事件，随业务事务写入；Seq 由中继在事务提交后按提交顺序分配，消费者按 Seq 递增消费
+type OutboxEventModel struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Seq        *int64     `gorm:"uniqueIndex:uk_outbox_events_seq;index:idx_outbox_events_type_seq,priority:2"`
+	EventType  string     `gorm:"type:varchar(100);not null;index:idx_outbox_events_type_seq,priority:1"`
+	TenantID   *uuid.UUID `gorm:"type:uuid"`
+	Payload    string     `gorm:"type:text;not null"`
+	OccurredAt int64      `gorm:"not null"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index:idx_outbox_events_created_at"`
+}
+
+func (OutboxEventModel) TableName() string { return "outbox_events" }
+
+// EventConsumerOffsetModel 持久消费者按事件类型已确认的最大 Seq
+type EventConsumerOffsetModel struct {
+	Consumer  string    `gorm:"type:varchar(100);primaryKey"`
+	EventType string    `gorm:"type:varchar(100);primaryKey"`
+	Position  int64     `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (EventConsumerOffsetModel) TableName() string { return "event_consumer_offsets" }
+
+// EventDeadLetterModel 超过最大尝试次数仍处理失败的事件
+type EventDeadLetterModel struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Consumer  string    `gorm:"type:varchar(100);not null;index:idx_event_dead_letters_consumer"`
+	EventID   uuid.UUID `gorm:"type:uuid;not null"`
+	EventType string    `gorm:"type:varchar(100);not null"`
+	Seq       int64     `gorm:"not null"`
+	Payload   string    `gorm:"type:text;not null"`
+	Error     string    `gorm:"type:text"`
+	Attempts  int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (EventDeadLetterModel) TableName() string { return "event_dead_letters" }
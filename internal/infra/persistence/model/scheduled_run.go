@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledRunModel 待执行的定时触发（排队或补跑），创建任务后删除
+type ScheduledRunModel struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TenantID    uuid.UUID `gorm:"type:uuid;not null"`
+	WorkflowID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:uk_scheduled_runs_workflow_time"`
+	ScheduledAt time.Time `gorm:"not null;uniqueIndex:uk_scheduled_runs_workflow_time"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+func (ScheduledRunModel) TableName() string { return "scheduled_runs" }
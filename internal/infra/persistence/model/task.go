@@ -12,13 +12,23 @@ type TaskModel struct {
 	TenantID          uuid.UUID      `gorm:"type:uuid;not null;index:idx_tasks_tenant_id"`
 	TriggeredByUserID *uuid.UUID     `gorm:"type:uuid;index:idx_tasks_triggered_by"`
 	WorkflowID        uuid.UUID      `gorm:"type:uuid;not null;index:idx_tasks_workflow_id"`
+	RevisionID        *uuid.UUID     `gorm:"type:uuid;index:idx_tasks_revision_id"`
+	OperatorVersions  datatypes.JSON `gorm:"serializer:json"`
 	AssetID           *uuid.UUID     `gorm:"type:uuid;index:idx_tasks_asset_id"`
+	CallerTaskID      *uuid.UUID     `gorm:"type:uuid;index:idx_tasks_caller_task_id"`
+	CallerNodeKey     string         `gorm:"type:varchar(100)"`
+	ParentTaskID      *uuid.UUID     `gorm:"type:uuid;index:idx_tasks_parent_task_id"`
+	Priority          int            `gorm:"not null;default:0;index:idx_tasks_priority"`
 	Status            string         `gorm:"type:varchar(20);not null;default:'pending';index:idx_tasks_status"`
 	Progress          int            `gorm:"not null;default:0"`
 	CurrentNode       string         `gorm:"type:varchar(100)"`
 	InputParams       datatypes.JSON `gorm:"serializer:json"`
 	Error             string         `gorm:"type:text"`
 	NodeExecutions    datatypes.JSON `gorm:"serializer:json"`
+	LeaseOwner        string         `gorm:"type:varchar(200)"`
+	LeaseExpiresAt    *time.Time     `gorm:"index:idx_tasks_lease_expires_at"`
+	Deadline          *time.Time
+	SLABreachedAt     *time.Time
 	StartedAt         *time.Time
 	CompletedAt       *time.Time
 	CreatedAt         time.Time `gorm:"autoCreateTime;index:idx_tasks_created_at"`
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type TaskCheckpointModel struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	TenantID  uuid.UUID      `gorm:"type:uuid;not null;index:idx_task_checkpoints_tenant_id"`
+	TaskID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:uk_task_checkpoints_task_node"`
+	NodeKey   string         `gorm:"type:varchar(100);not null;uniqueIndex:uk_task_checkpoints_task_node"`
+	Output    datatypes.JSON `gorm:"serializer:json"`
+	Execution datatypes.JSON `gorm:"serializer:json"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+}
+
+func (TaskCheckpointModel) TableName() string { return "task_checkpoints" }
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkflowWebhookModel 工作流入站 webhook 端点，每个工作流最多一个
+type WorkflowWebhookModel struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TenantID          uuid.UUID `gorm:"type:uuid;not null;index:idx_workflow_webhooks_tenant_id"`
+	WorkflowID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:uk_workflow_webhooks_workflow_id"`
+	Secret            string    `gorm:"type:text;not null"`
+	PreviousSecret    string    `gorm:"type:text"`
+	PreviousExpiresAt *time.Time
+	CreatedBy         *uuid.UUID `gorm:"type:uuid"`
+	RotatedAt         time.Time  `gorm:"not null"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (WorkflowWebhookModel) TableName() string { return "workflow_webhooks" }
+
+// WebhookNonceModel 已使用的 nonce，过期后删除
+type WebhookNonceModel struct {
+	WebhookID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Nonce     string    `gorm:"type:varchar(200);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index:idx_webhook_nonces_expires_at"`
+}
+
+func (WebhookNonceModel) TableName() string { return "webhook_nonces" }
+
+// WebhookDeliveryModel webhook 投递记录
+type WebhookDeliveryModel struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	TenantID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_webhook_deliveries_tenant_id"`
+	WebhookID  uuid.UUID  `gorm:"type:uuid;not null;index:idx_webhook_deliveries_webhook_id"`
+	WorkflowID uuid.UUID  `gorm:"type:uuid;not null"`
+	Status     string     `gorm:"type:varchar(20);not null"`
+	Error      string     `gorm:"type:text"`
+	Nonce      string     `gorm:"type:varchar(200)"`
+	RemoteAddr string     `gorm:"type:varchar(100)"`
+	Body       string     `gorm:"type:text"`
+	TaskID     *uuid.UUID `gorm:"type:uuid"`
+	AssetID    *uuid.UUID `gorm:"type:uuid"`
+	ReceivedAt time.Time  `gorm:"not null;index:idx_webhook_deliveries_received_at"`
+}
+
+func (WebhookDeliveryModel) TableName() string { return "webhook_deliveries" }
@@ -17,10 +17,15 @@ type WorkflowModel struct {
 	Name           string         `gorm:"type:varchar(255);not null"`
 	Description string         `gorm:"type:text"`
 	Version     string         `gorm:"type:varchar(50);not null;default:'1.0.0'"`
+	Revision    int            `gorm:"not null;default:0"`
+	RevisionID  *uuid.UUID     `gorm:"type:uuid"`
 	TriggerType string         `gorm:"type:varchar(50);not null;index:idx_workflows_trigger_type"`
 	TriggerConf datatypes.JSON `gorm:"serializer:json"`
 	Status      string         `gorm:"type:varchar(20);not null;default:'draft';index:idx_workflows_status"`
 	Tags        datatypes.JSON `gorm:"serializer:json"`
+	Timeouts    datatypes.JSON `gorm:"serializer:json"`
+	LastRunAt   *time.Time
+	NextRunAt   *time.Time
 	CreatedAt   time.Time      `gorm:"autoCreateTime;index:idx_workflows_created_at"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
 
@@ -57,3 +62,18 @@ type WorkflowEdgeModel struct {
 }
 
 func (WorkflowEdgeModel) TableName() string { return "workflow_edges" }
+
+type WorkflowRevisionModel struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	TenantID   uuid.UUID      `gorm:"type:uuid;not null;index:idx_workflow_revisions_tenant_id"`
+	WorkflowID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:uk_workflow_revisions_number"`
+	Revision   int            `gorm:"not null;uniqueIndex:uk_workflow_revisions_number"`
+	Nodes      datatypes.JSON `gorm:"serializer:json"`
+	Edges      datatypes.JSON `gorm:"serializer:json"`
+	Hash       string         `gorm:"type:varchar(64);not null"`
+	Comment    string         `gorm:"type:varchar(500)"`
+	CreatedBy  *uuid.UUID     `gorm:"type:uuid"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime"`
+}
+
+func (WorkflowRevisionModel) TableName() string { return "workflow_revisions" }
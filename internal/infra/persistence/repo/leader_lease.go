@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"goyavision/internal/infra/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaderLeaseRepo struct {
+	db *gorm.DB
+}
+
+func NewLeaderLeaseRepo(db *gorm.DB) *LeaderLeaseRepo {
+	return &LeaderLeaseRepo{db: db}
+}
+
+// TryAcquire 续约自己持有的租约或接管已过期的租约，租约不存在时创建
+func (r *LeaderLeaseRepo) TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	res := r.db.WithContext(ctx).Model(&model.LeaderLeaseModel{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": expiresAt})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+
+	res = r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.LeaderLeaseModel{Name: name, Holder: holder, ExpiresAt: expiresAt})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"goyavision/internal/infra/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockRow 在事务 tx 内锁定命名行锁，持有到事务结束，使多个进程的同类操作串行执行。
+// SQLite 同一时刻只有一个写事务，不需要行锁
+func lockRow(tx *gorm.DB, name string) error {
+	if tx.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.LockModel{Name: name}).Error; err != nil {
+		return err
+	}
+	var lock model.LockModel
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&lock).Error
+}
@@ -26,9 +26,9 @@ func (r *MediaAssetRepo) Create(ctx context.Context, a *media.Asset) error {
 		a.ID = uuid.New()
 	}
 	tenantID, userID := scope.GetContextInfo(ctx)
+	a.TenantID = tenantID
+	a.OwnerID = userID
 	m := mapper.AssetToModel(a)
-	m.TenantID = tenantID
-	m.OwnerID = userID
 
 	return r.db.WithContext(ctx).Create(m).Error
 }
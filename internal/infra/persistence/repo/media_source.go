@@ -25,9 +25,9 @@ func (r *MediaSourceRepo) Create(ctx context.Context, s *media.Source) error {
 		s.ID = uuid.New()
 	}
 	tenantID, userID := scope.GetContextInfo(ctx)
+	s.TenantID = tenantID
+	s.OwnerID = userID
 	m := mapper.SourceToModel(s)
-	m.TenantID = tenantID
-	m.OwnerID = userID
 
 	return r.db.WithContext(ctx).Create(m).Error
 }
@@ -48,6 +48,14 @@ func (r *MediaSourceRepo) GetByPathName(ctx context.Context, pathName string) (*
 	return mapper.SourceToDomain(&m), nil
 }
 
+func (r *MediaSourceRepo) GetByPathNameUnscoped(ctx context.Context, pathName string) (*media.Source, error) {
+	var m model.MediaSourceModel
+	if err := r.db.WithContext(ctx).Where("path_name = ?", pathName).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.SourceToDomain(&m), nil
+}
+
 func (r *MediaSourceRepo) List(ctx context.Context, filter media.SourceFilter) ([]*media.Source, int64, error) {
 	q := r.db.WithContext(ctx).Model(&model.MediaSourceModel{}).Scopes(scope.ScopeTenant(ctx), scope.ScopeVisibility(ctx))
 	if filter.Type != nil {
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/mapper"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/infra/persistence/scope"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NodeCacheRepo struct {
+	db *gorm.DB
+}
+
+func NewNodeCacheRepo(db *gorm.DB) *NodeCacheRepo {
+	return &NodeCacheRepo{db: db}
+}
+
+// Get 缓存严格按租户隔离，上下文中没有租户时只匹配无租户的缓存项
+func (r *NodeCacheRepo) Get(ctx context.Context, operatorVersionID uuid.UUID, inputHash string) (*workflow.NodeCacheEntry, error) {
+	tenantID, _ := scope.GetContextInfo(ctx)
+	var m model.NodeCacheEntryModel
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND operator_version_id = ? AND input_hash = ?", tenantID, operatorVersionID, inputHash).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.NodeCacheEntryToDomain(&m), nil
+}
+
+func (r *NodeCacheRepo) Save(ctx context.Context, e *workflow.NodeCacheEntry) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.TenantID == uuid.Nil {
+		e.TenantID, _ = scope.GetContextInfo(ctx)
+	}
+	m := mapper.NodeCacheEntryToModel(e)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "operator_version_id"}, {Name: "input_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"output", "artifact_ids", "source_task_id", "expires_at", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *NodeCacheRepo) DeleteByOperatorVersion(ctx context.Context, operatorVersionID uuid.UUID) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("operator_version_id = ?", operatorVersionID).
+		Delete(&model.NodeCacheEntryModel{})
+	return res.RowsAffected, res.Error
+}
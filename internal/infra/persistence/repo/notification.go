@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"goyavision/internal/domain/notification"
+	"goyavision/internal/infra/persistence/mapper"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/infra/persistence/scope"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationSubscriptionRepo 订阅按上下文租户过滤；事件分发与投递没有用户身份，使用 *Unscoped/ByTenant 方法
+type NotificationSubscriptionRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationSubscriptionRepo(db *gorm.DB) *NotificationSubscriptionRepo {
+	return &NotificationSubscriptionRepo{db: db}
+}
+
+func (r *NotificationSubscriptionRepo) Create(ctx context.Context, s *notification.Subscription) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	tenantID, userID := scope.GetContextInfo(ctx)
+	if s.TenantID == uuid.Nil {
+		s.TenantID = tenantID
+	}
+	if userID != uuid.Nil {
+		s.CreatedBy = &userID
+	}
+	m := mapper.NotificationSubscriptionToModel(s)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return err
+	}
+	s.CreatedAt = m.CreatedAt
+	s.UpdatedAt = m.UpdatedAt
+	return nil
+}
+
+func (r *NotificationSubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (*notification.Subscription, error) {
+	var m model.NotificationSubscriptionModel
+	if err := r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.NotificationSubscriptionToDomain(&m), nil
+}
+
+func (r *NotificationSubscriptionRepo) GetUnscoped(ctx context.Context, id uuid.UUID) (*notification.Subscription, error) {
+	var m model.NotificationSubscriptionModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.NotificationSubscriptionToDomain(&m), nil
+}
+
+func (r *NotificationSubscriptionRepo) List(ctx context.Context, limit, offset int) ([]*notification.Subscription, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.NotificationSubscriptionModel{}).Scopes(scope.ScopeTenantOnly(ctx))
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var models []*model.NotificationSubscriptionModel
+	if err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	result := make([]*notification.Subscription, len(models))
+	for i, m := range models {
+		result[i] = mapper.NotificationSubscriptionToDomain(m)
+	}
+	return result, total, nil
+}
+
+func (r *NotificationSubscriptionRepo) ListEnabledByTenant(ctx context.Context, tenantID uuid.UUID) ([]*notification.Subscription, error) {
+	var models []*model.NotificationSubscriptionModel
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND enabled = ?", tenantID, true).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*notification.Subscription, len(models))
+	for i, m := range models {
+		result[i] = mapper.NotificationSubscriptionToDomain(m)
+	}
+	return result, nil
+}
+
+// Update 整体保存订阅（含清零的失败计数与停用信息）
+func (r *NotificationSubscriptionRepo) Update(ctx context.Context, s *notification.Subscription) error {
+	m := mapper.NotificationSubscriptionToModel(s)
+	return r.db.WithContext(ctx).Model(&model.NotificationSubscriptionModel{}).
+		Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", s.ID).
+		Updates(map[string]interface{}{
+			"name":                 m.Name,
+			"channel":              m.Channel,
+			"event_types":          m.EventTypes,
+			"url":                  m.URL,
+			"secret":               m.Secret,
+			"chat_format":          m.ChatFormat,
+			"recipients":           m.Recipients,
+			"enabled":              m.Enabled,
+			"consecutive_failures": m.ConsecutiveFailures,
+			"disabled_at":          m.DisabledAt,
+			"disabled_reason":      m.DisabledReason,
+		}).Error
+}
+
+func (r *NotificationSubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", id).
+		Delete(&model.NotificationSubscriptionModel{}).Error
+}
+
+type NotificationDeliveryRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationDeliveryRepo(db *gorm.DB) *NotificationDeliveryRepo {
+	return &NotificationDeliveryRepo{db: db}
+}
+
+func (r *NotificationDeliveryRepo) Create(ctx context.Context, d *notification.Delivery) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	m := mapper.NotificationDeliveryToModel(d)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return err
+	}
+	d.CreatedAt = m.CreatedAt
+	d.UpdatedAt = m.UpdatedAt
+	return nil
+}
+
+func (r *NotificationDeliveryRepo) Get(ctx context.Context, id uuid.UUID) (*notification.Delivery, error) {
+	var m model.NotificationDeliveryModel
+	if err := r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.NotificationDeliveryToDomain(&m), nil
+}
+
+func (r *NotificationDeliveryRepo) List(ctx context.Context, filter notification.DeliveryFilter) ([]*notification.Delivery, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.NotificationDeliveryModel{}).
+		Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("subscription_id = ?", filter.SubscriptionID)
+	if filter.Status != nil {
+		q = q.Where("status = ?", string(*filter.Status))
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var models []*model.NotificationDeliveryModel
+	if err := q.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	result := make([]*notification.Delivery, len(models))
+	for i, m := range models {
+		result[i] = mapper.NotificationDeliveryToDomain(m)
+	}
+	return result, total, nil
+}
+
+func (r *NotificationDeliveryRepo) Update(ctx context.Context, d *notification.Delivery) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationDeliveryModel{}).Where("id = ?", d.ID).
+		Updates(map[string]interface{}{
+			"status":          string(d.Status),
+			"attempts":        d.Attempts,
+			"next_attempt_at": d.NextAttemptAt,
+			"last_error":      d.LastError,
+			"response_status": d.ResponseStatus,
+			"response_body":   d.ResponseBody,
+			"delivered_at":    d.DeliveredAt,
+		}).Error
+}
+
+func (r *NotificationDeliveryRepo) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*notification.Delivery, error) {
+	var claimed []*notification.Delivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("status = ? AND next_attempt_at <= ?", string(notification.DeliveryPending), now).
+			Order("next_attempt_at ASC").Limit(limit)
+		if tx.Dialector.Name() != "sqlite" {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var models []*model.NotificationDeliveryModel
+		if err := q.Find(&models).Error; err != nil {
+			return err
+		}
+
+		leaseUntil := now.Add(lease)
+		for _, m := range models {
+			res := tx.Model(&model.NotificationDeliveryModel{}).
+				Where("id = ? AND status = ? AND next_attempt_at = ?", m.ID, string(notification.DeliveryPending), m.NextAttemptAt).
+				Update("next_attempt_at", leaseUntil)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			m.NextAttemptAt = &leaseUntil
+			claimed = append(claimed, mapper.NotificationDeliveryToDomain(m))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *NotificationDeliveryRepo) FailPending(ctx context.Context, subscriptionID uuid.UUID, reason string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.NotificationDeliveryModel{}).
+		Where("subscription_id = ? AND status = ?", subscriptionID, string(notification.DeliveryPending)).
+		Updates(map[string]interface{}{
+			"status":          string(notification.DeliveryFailed),
+			"next_attempt_at": nil,
+			"last_error":      reason,
+		})
+	return res.RowsAffected, res.Error
+}
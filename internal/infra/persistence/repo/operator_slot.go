@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OperatorSlotRepo struct {
+	db *gorm.DB
+}
+
+func NewOperatorSlotRepo(db *gorm.DB) *OperatorSlotRepo {
+	return &OperatorSlotRepo{db: db}
+}
+
+// Acquire 锁定算子的行锁后清理过期槽位并计数，未满时插入新槽位。
+// 行锁使各执行方对同一算子的计数与占用串行执行
+func (r *OperatorSlotRepo) Acquire(ctx context.Context, operatorCode string, limit int, holder string, ttl time.Duration) (uuid.UUID, bool, error) {
+	code := strings.ToLower(operatorCode)
+	slot := &model.OperatorSlotModel{ID: uuid.New(), OperatorCode: code, Holder: holder}
+	acquired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, "operator_slot:"+code); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Where("operator_code = ? AND expires_at < ?", code, now).Delete(&model.OperatorSlotModel{}).Error; err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&model.OperatorSlotModel{}).Where("operator_code = ?", code).Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= int64(limit) {
+			return nil
+		}
+		slot.ExpiresAt = now.Add(ttl)
+		if err := tx.Create(slot).Error; err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	if err != nil || !acquired {
+		return uuid.Nil, false, err
+	}
+	return slot.ID, true, nil
+}
+
+func (r *OperatorSlotRepo) Renew(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	return r.db.WithContext(ctx).Model(&model.OperatorSlotModel{}).
+		Where("id = ?", id).
+		Update("expires_at", time.Now().Add(ttl)).Error
+}
+
+func (r *OperatorSlotRepo) Release(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.OperatorSlotModel{}).Error
+}
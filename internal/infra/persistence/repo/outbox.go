@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrOutboxSequenceConflict 其他副本同时为同一批事件分配了序号，本次分配已回滚
+var ErrOutboxSequenceConflict = errors.New("outbox sequence conflict")
+
+type OutboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+func (r *OutboxRepo) Create(ctx context.Context, e *model.OutboxEventModel) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+// Sequence 按写入顺序为最多 limit 条已提交但未分配序号的事件分配递增 Seq，返回分配数量
+//
+// 事件在各自事务提交后才可见，由中继分配序号保证消费者按 Seq 推进时不会越过晚提交的事件。
+func (r *OutboxRepo) Sequence(ctx context.Context, limit int) (int, error) {
+	var n int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []model.OutboxEventModel
+		if err := tx.Select("id").Where("seq IS NULL").
+			Order("created_at ASC, id ASC").Limit(limit).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		var maxSeq int64
+		if err := tx.Model(&model.OutboxEventModel{}).Select("COALESCE(MAX(seq), 0)").Scan(&maxSeq).Error; err != nil {
+			return err
+		}
+		for i, row := range rows {
+			res := tx.Model(&model.OutboxEventModel{}).
+				Where("id = ? AND seq IS NULL", row.ID).
+				Update("seq", maxSeq+int64(i)+1)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrOutboxSequenceConflict
+			}
+		}
+		n = len(rows)
+		return nil
+	})
+	return n, err
+}
+
+// MaxSeq 已分配的最大序号，没有事件时为 0
+func (r *OutboxRepo) MaxSeq(ctx context.Context) (int64, error) {
+	var maxSeq int64
+	err := r.db.WithContext(ctx).Model(&model.OutboxEventModel{}).Select("COALESCE(MAX(seq), 0)").Scan(&maxSeq).Error
+	return maxSeq, err
+}
+
+// ListAfter 按 Seq 升序列出指定类型序号大于 after 的事件
+func (r *OutboxRepo) ListAfter(ctx context.Context, eventType string, after int64, limit int) ([]*model.OutboxEventModel, error) {
+	var rows []*model.OutboxEventModel
+	err := r.db.WithContext(ctx).
+		Where("event_type = ? AND seq > ?", eventType, after).
+		Order("seq ASC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// EnsurePosition 返回持久消费者的消费位置，不存在时以 initial 创建
+func (r *OutboxRepo) EnsurePosition(ctx context.Context, consumer, eventType string, initial int64) (int64, error) {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.EventConsumerOffsetModel{Consumer: consumer, EventType: eventType, Position: initial}).Error; err != nil {
+		return 0, err
+	}
+	var m model.EventConsumerOffsetModel
+	if err := r.db.WithContext(ctx).
+		Where("consumer = ? AND event_type = ?", consumer, eventType).First(&m).Error; err != nil {
+		return 0, err
+	}
+	return m.Position, nil
+}
+
+// CommitPosition 推进消费位置，只前进不后退，重复提交无副作用
+func (r *OutboxRepo) CommitPosition(ctx context.Context, consumer, eventType string, position int64) error {
+	return r.db.WithContext(ctx).Model(&model.EventConsumerOffsetModel{}).
+		Where("consumer = ? AND event_type = ? AND position < ?", consumer, eventType, position).
+		Update("position", position).Error
+}
+
+func (r *OutboxRepo) CreateDeadLetter(ctx context.Context, d *model.EventDeadLetterModel) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+// DeleteSequencedBefore 删除 before 之前写入且已分配序号的事件，返回删除数量
+func (r *OutboxRepo) DeleteSequencedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("seq IS NOT NULL AND created_at < ?", before).
+		Delete(&model.OutboxEventModel{})
+	return res.RowsAffected, res.Error
+}
+
+// ListUnsequenced 按写入顺序返回最多 limit 条已提交但未分配序号的事件
+func (r *OutboxRepo) ListUnsequenced(ctx context.Context, limit int) ([]*model.OutboxEventModel, error) {
+	var rows []*model.OutboxEventModel
+	err := r.db.WithContext(ctx).Where("seq IS NULL").
+		Order("created_at ASC, id ASC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// Delete 删除事件
+func (r *OutboxRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.OutboxEventModel{}, "id = ?", id).Error
+}
@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledRunRepo struct {
+	db *gorm.DB
+}
+
+func NewScheduledRunRepo(db *gorm.DB) *ScheduledRunRepo {
+	return &ScheduledRunRepo{db: db}
+}
+
+func (r *ScheduledRunRepo) Queue(ctx context.Context, run *workflow.ScheduledRun) error {
+	return queueScheduledRuns(r.db.WithContext(ctx), run)
+}
+
+// RecordMissed 以 next_run_at < now 为条件推进下一次触发时间，条件更新成功的副本才记录补跑
+func (r *ScheduledRunRepo) RecordMissed(ctx context.Context, wf *workflow.Workflow, now time.Time, next *time.Time, runs []time.Time) (bool, error) {
+	advanced := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.WorkflowModel{}).
+			Where("id = ? AND next_run_at < ?", wf.ID, now).
+			UpdateColumn("next_run_at", next)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		advanced = true
+		missed := make([]*workflow.ScheduledRun, len(runs))
+		for i, t := range runs {
+			missed[i] = &workflow.ScheduledRun{TenantID: wf.TenantID, WorkflowID: wf.ID, ScheduledAt: t}
+		}
+		return queueScheduledRuns(tx, missed...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return advanced, nil
+}
+
+func (r *ScheduledRunRepo) List(ctx context.Context) ([]*workflow.ScheduledRun, error) {
+	var models []*model.ScheduledRunModel
+	if err := r.db.WithContext(ctx).
+		Order("workflow_id, scheduled_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*workflow.ScheduledRun, len(models))
+	for i, m := range models {
+		result[i] = &workflow.ScheduledRun{
+			ID:          m.ID,
+			TenantID:    m.TenantID,
+			WorkflowID:  m.WorkflowID,
+			ScheduledAt: m.ScheduledAt,
+			CreatedAt:   m.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+func (r *ScheduledRunRepo) Start(ctx context.Context, id uuid.UUID, task *workflow.Task) (bool, error) {
+	started := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ?", id).Delete(&model.ScheduledRunModel{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := NewTaskRepo(tx).Create(ctx, task); err != nil {
+			return err
+		}
+		started = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return started, nil
+}
+
+func (r *ScheduledRunRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.ScheduledRunModel{}).Error
+}
+
+func (r *ScheduledRunRepo) DeleteByWorkflow(ctx context.Context, workflowID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("workflow_id = ?", workflowID).Delete(&model.ScheduledRunModel{}).Error
+}
+
+// queueScheduledRuns 插入触发记录，已记录的触发（同一工作流同一触发时间）忽略
+func queueScheduledRuns(db *gorm.DB, runs ...*workflow.ScheduledRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	models := make([]*model.ScheduledRunModel, len(runs))
+	for i, run := range runs {
+		if run.ID == uuid.Nil {
+			run.ID = uuid.New()
+		}
+		models[i] = &model.ScheduledRunModel{
+			ID:          run.ID,
+			TenantID:    run.TenantID,
+			WorkflowID:  run.WorkflowID,
+			ScheduledAt: run.ScheduledAt,
+		}
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models).Error
+}
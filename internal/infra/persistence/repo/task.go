@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"goyavision/internal/domain/workflow"
 	"goyavision/internal/infra/persistence/mapper"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaskRepo struct {
@@ -24,10 +26,15 @@ func (r *TaskRepo) Create(ctx context.Context, t *workflow.Task) error {
 	if t.ID == uuid.Nil {
 		t.ID = uuid.New()
 	}
+	// 后台触发（定时、事件、子工作流）时上下文中没有租户，保留调用方预先设置的归属
 	tenantID, userID := scope.GetContextInfo(ctx)
+	if tenantID != uuid.Nil || t.TenantID == uuid.Nil {
+		t.TenantID = tenantID
+	}
+	if userID != uuid.Nil || t.TriggeredByUserID == nil {
+		t.TriggeredByUserID = &userID
+	}
 	m := mapper.TaskToModel(t)
-	m.TenantID = tenantID
-	m.TriggeredByUserID = &userID
 
 	return r.db.WithContext(ctx).Create(m).Error
 }
@@ -63,6 +70,12 @@ func (r *TaskRepo) List(ctx context.Context, filter workflow.TaskFilter) ([]*wor
 	if filter.AssetID != nil {
 		q = q.Where("asset_id = ?", *filter.AssetID)
 	}
+	if filter.CallerTaskID != nil {
+		q = q.Where("caller_task_id = ?", *filter.CallerTaskID)
+	}
+	if filter.ParentTaskID != nil {
+		q = q.Where("parent_task_id = ?", *filter.ParentTaskID)
+	}
 	if filter.Status != nil {
 		q = q.Where("status = ?", string(*filter.Status))
 	}
@@ -98,6 +111,27 @@ func (r *TaskRepo) Update(ctx context.Context, t *workflow.Task) error {
 	return r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", t.ID).Updates(m).Error
 }
 
+// Fence 锁定任务行（SQLite 写事务本身串行）并检查状态与租约持有者；任务不存在时返回 gorm.ErrRecordNotFound
+func (r *TaskRepo) Fence(ctx context.Context, id uuid.UUID, owner string) error {
+	q := r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Select("status", "lease_owner").Where("id = ?", id)
+	if r.db.Dialector.Name() != "sqlite" {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var m model.TaskModel
+	if err := q.Take(&m).Error; err != nil {
+		return err
+	}
+	switch workflow.TaskStatus(m.Status) {
+	case workflow.TaskStatusCancelled, workflow.TaskStatusTimedOut:
+		return workflow.ErrTaskStopped
+	}
+	// 租约被回收后任务回到 pending 且持有者清空，或已被其他执行方认领
+	if owner != "" && m.LeaseOwner != owner {
+		return workflow.ErrLeaseLost
+	}
+	return nil
+}
+
 func (r *TaskRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Scopes(scope.ScopeTenantOnly(ctx)).Where("id = ?", id).Delete(&model.TaskModel{}).Error
 }
@@ -115,6 +149,16 @@ func (r *TaskRepo) GetStats(ctx context.Context, workflowID *uuid.UUID) (*workfl
 
 	stats := &workflow.TaskStats{Total: total}
 
+	// 队列深度：等待调度的顶层任务（子任务由父任务直接执行，不经过队列）
+	qq := r.db.WithContext(ctx).Model(&model.TaskModel{}).Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("status = ? AND caller_task_id IS NULL", string(workflow.TaskStatusPending))
+	if workflowID != nil {
+		qq = qq.Where("workflow_id = ?", *workflowID)
+	}
+	if err := qq.Count(&stats.Queued).Error; err != nil {
+		return nil, err
+	}
+
 	type statusCount struct {
 		Status string
 		Count  int64
@@ -136,12 +180,16 @@ func (r *TaskRepo) GetStats(ctx context.Context, workflowID *uuid.UUID) (*workfl
 			stats.Pending = sc.Count
 		case workflow.TaskStatusRunning:
 			stats.Running = sc.Count
+		case workflow.TaskStatusWaiting:
+			stats.Waiting = sc.Count
 		case workflow.TaskStatusSuccess:
 			stats.Success = sc.Count
 		case workflow.TaskStatusFailed:
 			stats.Failed = sc.Count
 		case workflow.TaskStatusCancelled:
 			stats.Cancelled = sc.Count
+		case workflow.TaskStatusTimedOut:
+			stats.TimedOut = sc.Count
 		}
 	}
 
@@ -164,6 +212,258 @@ func (r *TaskRepo) ListRunning(ctx context.Context) ([]*workflow.Task, error) {
 	return result, nil
 }
 
+func (r *TaskRepo) ListWaiting(ctx context.Context) ([]*workflow.Task, error) {
+	var models []*model.TaskModel
+	if err := r.db.WithContext(ctx).
+		Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("status = ?", string(workflow.TaskStatusWaiting)).
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*workflow.Task, len(models))
+	for i, m := range models {
+		result[i] = mapper.TaskToDomain(m)
+	}
+	return result, nil
+}
+
+// ListUnfinished 列出 pending/running/waiting 状态的顶层任务
+func (r *TaskRepo) ListUnfinished(ctx context.Context) ([]*workflow.Task, error) {
+	var models []*model.TaskModel
+	if err := r.db.WithContext(ctx).
+		Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("status IN ? AND caller_task_id IS NULL", []string{
+			string(workflow.TaskStatusPending),
+			string(workflow.TaskStatusRunning),
+			string(workflow.TaskStatusWaiting),
+		}).
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*workflow.Task, len(models))
+	for i, m := range models {
+		result[i] = mapper.TaskToDomain(m)
+	}
+	return result, nil
+}
+
+// ListPending 按优先级（高在前）与创建时间列出等待调度的顶层任务。
+// limits 非 nil 时排除已达到并发上限的租户与工作流的任务，避免其积压的任务占满批次、
+// 使其他租户的任务读不到；全局上限已满时返回空
+func (r *TaskRepo) ListPending(ctx context.Context, limit int, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	db := r.db.WithContext(ctx)
+	q := db.Scopes(scope.ScopeTenantOnly(ctx)).
+		Where("status = ? AND caller_task_id IS NULL", string(workflow.TaskStatusPending))
+	if limits != nil {
+		counts, err := countRunning(db)
+		if err != nil {
+			return nil, err
+		}
+		if limits.MaxConcurrent > 0 && counts.Total >= limits.MaxConcurrent {
+			return nil, nil
+		}
+		var tenants []uuid.UUID
+		for id, n := range counts.ByTenant {
+			if l := limits.TenantLimit(id); l > 0 && n >= l {
+				tenants = append(tenants, id)
+			}
+		}
+		ids := make([]uuid.UUID, 0, len(counts.ByWorkflow))
+		for id := range counts.ByWorkflow {
+			ids = append(ids, id)
+		}
+		codes, err := workflowCodes(db, ids)
+		if err != nil {
+			return nil, err
+		}
+		var workflows []uuid.UUID
+		for id, n := range counts.ByWorkflow {
+			if l := limits.WorkflowLimit(codes[id]); l > 0 && n >= l {
+				workflows = append(workflows, id)
+			}
+		}
+		if len(tenants) > 0 {
+			q = q.Where("tenant_id NOT IN ?", tenants)
+		}
+		if len(workflows) > 0 {
+			q = q.Where("workflow_id NOT IN ?", workflows)
+		}
+	}
+
+	var models []*model.TaskModel
+	if err := q.Order("priority DESC, created_at ASC").
+		Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*workflow.Task, len(models))
+	for i, m := range models {
+		result[i] = mapper.TaskToDomain(m)
+	}
+	return result, nil
+}
+
+// taskClaimLock 认领任务时的行锁，使并发上限的计数与认领在所有执行方之间串行
+const taskClaimLock = "task_claim"
+
+// Claim 认领 pending 任务。Postgres/MySQL 使用 FOR UPDATE SKIP LOCKED 跳过其他 worker 正在认领的行，
+// 并逐行按 status 条件更新，保证同一任务只会被一个 worker 认领（SQLite 依赖条件更新）。
+// limits 非 nil 时先锁定认领行锁，在同一事务中统计所有执行方运行中的任务，
+// 按优先级认领上限内的任务，超出上限的任务留在队列中
+func (r *TaskRepo) Claim(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration, limits *workflow.ClaimLimits) ([]*workflow.Task, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var claimed []*workflow.Task
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var counts *workflow.RunningTaskCounts
+		if limits != nil {
+			if err := lockRow(tx, taskClaimLock); err != nil {
+				return err
+			}
+			var err error
+			if counts, err = countRunning(tx); err != nil {
+				return err
+			}
+		}
+
+		q := tx.Where("id IN ? AND status = ?", ids, string(workflow.TaskStatusPending)).
+			Order("priority DESC, created_at ASC")
+		if tx.Dialector.Name() != "sqlite" {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var models []*model.TaskModel
+		if err := q.Find(&models).Error; err != nil {
+			return err
+		}
+		workflowIDs := make([]uuid.UUID, 0, len(models))
+		for _, m := range models {
+			workflowIDs = append(workflowIDs, m.WorkflowID)
+		}
+		codes, err := workflowCodes(tx, workflowIDs)
+		if err != nil {
+			return err
+		}
+
+		expiresAt := time.Now().Add(ttl)
+		for _, m := range models {
+			if limits != nil && limits.Max > 0 && len(claimed) >= limits.Max {
+				break
+			}
+			task := mapper.TaskToDomain(m)
+			if !limits.Admit(counts, task, codes[m.WorkflowID]) {
+				continue
+			}
+			res := tx.Model(&model.TaskModel{}).
+				Where("id = ? AND status = ?", m.ID, string(workflow.TaskStatusPending)).
+				Updates(map[string]interface{}{
+					"status":           string(workflow.TaskStatusRunning),
+					"lease_owner":      owner,
+					"lease_expires_at": expiresAt,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			task.Status = workflow.TaskStatusRunning
+			task.LeaseOwner = owner
+			task.LeaseExpiresAt = &expiresAt
+			claimed = append(claimed, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// CountRunning 统计所有执行方中运行中的顶层任务，按租户与工作流分组
+func (r *TaskRepo) CountRunning(ctx context.Context) (*workflow.RunningTaskCounts, error) {
+	return countRunning(r.db.WithContext(ctx))
+}
+
+func countRunning(db *gorm.DB) (*workflow.RunningTaskCounts, error) {
+	var rows []struct {
+		TenantID   uuid.UUID
+		WorkflowID uuid.UUID
+		N          int
+	}
+	if err := db.Model(&model.TaskModel{}).
+		Select("tenant_id, workflow_id, COUNT(*) AS n").
+		Where("status = ? AND caller_task_id IS NULL", string(workflow.TaskStatusRunning)).
+		Group("tenant_id, workflow_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := workflow.NewRunningTaskCounts()
+	for _, row := range rows {
+		counts.Total += row.N
+		counts.ByTenant[row.TenantID] += row.N
+		counts.ByWorkflow[row.WorkflowID] += row.N
+	}
+	return counts, nil
+}
+
+// workflowCodes 读取工作流的编码，用于按编码查找工作流上限
+func workflowCodes(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	codes := make(map[uuid.UUID]string)
+	if len(ids) == 0 {
+		return codes, nil
+	}
+	var rows []struct {
+		ID   uuid.UUID
+		Code string
+	}
+	if err := db.Model(&model.WorkflowModel{}).Select("id, code").Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		codes[row.ID] = row.Code
+	}
+	return codes, nil
+}
+
+// RenewLeases 为 owner 持有的运行中任务续约，在同一事务中返回续约成功的任务 ID
+func (r *TaskRepo) RenewLeases(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var renewed []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		held := func() *gorm.DB {
+			return tx.Model(&model.TaskModel{}).
+				Where("id IN ? AND lease_owner = ? AND status = ?", ids, owner, string(workflow.TaskStatusRunning))
+		}
+		if err := held().Update("lease_expires_at", time.Now().Add(ttl)).Error; err != nil {
+			return err
+		}
+		return held().Pluck("id", &renewed).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renewed, nil
+}
+
+// ReclaimExpired 回收 worker 失联后租约过期的顶层任务，重新派发时从检查点继续
+func (r *TaskRepo) ReclaimExpired(ctx context.Context) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.TaskModel{}).
+		Where("status = ? AND caller_task_id IS NULL AND lease_expires_at < ?", string(workflow.TaskStatusRunning), time.Now()).
+		Updates(map[string]interface{}{
+			"status":           string(workflow.TaskStatusPending),
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+		})
+	return res.RowsAffected, res.Error
+}
+
 type ArtifactRepo struct {
 	db *gorm.DB
 }
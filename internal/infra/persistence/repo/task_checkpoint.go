@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/mapper"
+	"goyavision/internal/infra/persistence/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskCheckpointRepo struct {
+	db *gorm.DB
+}
+
+func NewTaskCheckpointRepo(db *gorm.DB) *TaskCheckpointRepo {
+	return &TaskCheckpointRepo{db: db}
+}
+
+// Save 按 (task_id, node_key) 写入或覆盖检查点
+func (r *TaskCheckpointRepo) Save(ctx context.Context, c *workflow.TaskCheckpoint) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	m := mapper.TaskCheckpointToModel(c)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}, {Name: "node_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"output", "execution", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *TaskCheckpointRepo) ListByTask(ctx context.Context, taskID uuid.UUID) ([]*workflow.TaskCheckpoint, error) {
+	var models []*model.TaskCheckpointModel
+	if err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*workflow.TaskCheckpoint, len(models))
+	for i, m := range models {
+		result[i] = mapper.TaskCheckpointToDomain(m)
+	}
+	return result, nil
+}
+
+func (r *TaskCheckpointRepo) DeleteByTask(ctx context.Context, taskID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("task_id = ?", taskID).Delete(&model.TaskCheckpointModel{}).Error
+}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/mapper"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/infra/persistence/scope"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepo 按 ID 查询端点时不按租户过滤：入站请求没有用户身份，以签名鉴权；
+// 按工作流查询时调用方需先通过工作流仓储校验访问权限
+type WebhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, w *workflow.Webhook) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	tenantID, userID := scope.GetContextInfo(ctx)
+	if w.TenantID == uuid.Nil {
+		w.TenantID = tenantID
+	}
+	if userID != uuid.Nil {
+		w.CreatedBy = &userID
+	}
+	m := mapper.WebhookToModel(w)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return err
+	}
+	w.CreatedAt = m.CreatedAt
+	w.UpdatedAt = m.UpdatedAt
+	return nil
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Webhook, error) {
+	var m model.WorkflowWebhookModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.WebhookToDomain(&m), nil
+}
+
+func (r *WebhookRepo) GetByWorkflow(ctx context.Context, workflowID uuid.UUID) (*workflow.Webhook, error) {
+	var m model.WorkflowWebhookModel
+	if err := r.db.WithContext(ctx).Where("workflow_id = ?", workflowID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.WebhookToDomain(&m), nil
+}
+
+// Update 整体保存端点（含清空的旧密钥），CreatedBy 记为当前用户
+func (r *WebhookRepo) Update(ctx context.Context, w *workflow.Webhook) error {
+	if _, userID := scope.GetContextInfo(ctx); userID != uuid.Nil {
+		w.CreatedBy = &userID
+	}
+	m := mapper.WebhookToModel(w)
+	return r.db.WithContext(ctx).Model(&model.WorkflowWebhookModel{}).Where("id = ?", w.ID).
+		Updates(map[string]interface{}{
+			"secret":              m.Secret,
+			"previous_secret":     m.PreviousSecret,
+			"previous_expires_at": m.PreviousExpiresAt,
+			"created_by":          m.CreatedBy,
+			"rotated_at":          m.RotatedAt,
+		}).Error
+}
+
+func (r *WebhookRepo) UseNonce(ctx context.Context, webhookID uuid.UUID, nonce string, expiresAt time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Where("webhook_id = ? AND expires_at < ?", webhookID, time.Now()).
+		Delete(&model.WebhookNonceModel{}).Error; err != nil {
+		return err
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.WebhookNonceModel{WebhookID: webhookID, Nonce: nonce, ExpiresAt: expiresAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return workflow.ErrWebhookReplay
+	}
+	return nil
+}
+
+func (r *WebhookRepo) CreateDelivery(ctx context.Context, d *workflow.WebhookDelivery) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(mapper.WebhookDeliveryToModel(d)).Error
+}
+
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, d *workflow.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookDeliveryModel{}).Where("id = ?", d.ID).
+		Updates(map[string]interface{}{
+			"status":  string(d.Status),
+			"error":   d.Error,
+			"task_id": d.TaskID,
+		}).Error
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]*workflow.WebhookDelivery, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.WebhookDeliveryModel{}).
+		Where("webhook_id = ?", webhookID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var models []*model.WebhookDeliveryModel
+	if err := q.Order("received_at DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	result := make([]*workflow.WebhookDelivery, len(models))
+	for i, m := range models {
+		result[i] = mapper.WebhookDeliveryToDomain(m)
+	}
+	return result, total, nil
+}
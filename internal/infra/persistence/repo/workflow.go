@@ -3,6 +3,7 @@ package repo
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"goyavision/internal/domain/workflow"
 	"goyavision/internal/infra/persistence/mapper"
@@ -102,14 +103,25 @@ func (r *WorkflowRepo) Update(ctx context.Context, w *workflow.Workflow) error {
 	return r.db.WithContext(ctx).Scopes(scope.ScopeTenant(ctx)).Where("id = ?", w.ID).Updates(m).Error
 }
 
+// UpdateRunTimes 由调度器在后台调用，不按租户过滤
+func (r *WorkflowRepo) UpdateRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error {
+	columns := map[string]interface{}{"next_run_at": nextRunAt}
+	if lastRunAt != nil {
+		columns["last_run_at"] = lastRunAt
+	}
+	return r.db.WithContext(ctx).Model(&model.WorkflowModel{}).Where("id = ?", id).UpdateColumns(columns).Error
+}
+
 func (r *WorkflowRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Scopes(scope.ScopeTenant(ctx)).Where("id = ?", id).Delete(&model.WorkflowModel{}).Error
 }
 
+// ListEnabled 列出启用的工作流，供调度器与事件触发使用：不按可见性过滤，
+// 上下文无租户时返回全部租户的工作流
 func (r *WorkflowRepo) ListEnabled(ctx context.Context) ([]*workflow.Workflow, error) {
 	var models []*model.WorkflowModel
 	if err := r.db.WithContext(ctx).
-		Scopes(scope.ScopeTenant(ctx), scope.ScopeVisibility(ctx)).
+		Scopes(scope.ScopeTenantOnly(ctx)).
 		Preload("Nodes.Operator").
 		Preload("Edges").
 		Where("status = ?", "enabled").
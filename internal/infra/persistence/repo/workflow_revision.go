@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+
+	"goyavision/internal/domain/workflow"
+	"goyavision/internal/infra/persistence/mapper"
+	"goyavision/internal/infra/persistence/model"
+	"goyavision/internal/infra/persistence/scope"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkflowRevisionRepo 修订的可见性由所属工作流决定（含其他租户公开的工作流），
+// 因此查询不按租户过滤，调用方需先通过工作流仓储校验访问权限
+type WorkflowRevisionRepo struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRevisionRepo(db *gorm.DB) *WorkflowRevisionRepo {
+	return &WorkflowRevisionRepo{db: db}
+}
+
+func (r *WorkflowRevisionRepo) Create(ctx context.Context, rev *workflow.Revision) error {
+	if rev.ID == uuid.Nil {
+		rev.ID = uuid.New()
+	}
+	tenantID, userID := scope.GetContextInfo(ctx)
+	if rev.TenantID == uuid.Nil {
+		rev.TenantID = tenantID
+	}
+	if rev.CreatedBy == nil && userID != uuid.Nil {
+		rev.CreatedBy = &userID
+	}
+	m := mapper.RevisionToModel(rev)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return err
+	}
+	rev.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *WorkflowRevisionRepo) Get(ctx context.Context, id uuid.UUID) (*workflow.Revision, error) {
+	var m model.WorkflowRevisionModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.RevisionToDomain(&m), nil
+}
+
+func (r *WorkflowRevisionRepo) GetByNumber(ctx context.Context, workflowID uuid.UUID, revision int) (*workflow.Revision, error) {
+	var m model.WorkflowRevisionModel
+	if err := r.db.WithContext(ctx).
+		Where("workflow_id = ? AND revision = ?", workflowID, revision).
+		First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.RevisionToDomain(&m), nil
+}
+
+func (r *WorkflowRevisionRepo) GetLatest(ctx context.Context, workflowID uuid.UUID) (*workflow.Revision, error) {
+	var m model.WorkflowRevisionModel
+	if err := r.db.WithContext(ctx).
+		Where("workflow_id = ?", workflowID).
+		Order("revision DESC").
+		First(&m).Error; err != nil {
+		return nil, err
+	}
+	return mapper.RevisionToDomain(&m), nil
+}
+
+func (r *WorkflowRevisionRepo) List(ctx context.Context, workflowID uuid.UUID, limit, offset int) ([]*workflow.Revision, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.WorkflowRevisionModel{}).
+		Where("workflow_id = ?", workflowID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var models []*model.WorkflowRevisionModel
+	if err := q.Order("revision DESC").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	result := make([]*workflow.Revision, len(models))
+	for i, m := range models {
+		result[i] = mapper.RevisionToDomain(m)
+	}
+	return result, total, nil
+}
@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"context"
+	"sync"
 
 	appport "goyavision/internal/app/port"
 	"goyavision/internal/infra/persistence/repo"
@@ -18,10 +19,51 @@ func NewUnitOfWork(db *gorm.DB) appport.UnitOfWork {
 }
 
 func (u *gormUoW) Do(ctx context.Context, fn func(ctx context.Context, repos *appport.Repositories) error) error {
-	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	state := &txState{}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
 		repos := newRepositories(tx)
-		return fn(ctx, repos)
+		return fn(context.WithValue(ctx, txKey{}, state), repos)
 	})
+	if err != nil {
+		return err
+	}
+	state.mu.Lock()
+	hooks := state.afterCommit
+	state.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+type txKey struct{}
+
+// txState UnitOfWork.Do 回调 ctx 携带的事务，供事件总线等组件在同一事务内写入
+type txState struct {
+	tx          *gorm.DB
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// TxFromContext 返回 ctx 所在 UnitOfWork 事务，不在事务内时返回 nil
+func TxFromContext(ctx context.Context) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return nil
+}
+
+// AfterCommit 在 ctx 所在事务提交后执行 fn（回滚时不执行），不在事务内时返回 false 且不执行 fn
+func AfterCommit(ctx context.Context, fn func()) bool {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return false
+	}
+	state.mu.Lock()
+	state.afterCommit = append(state.afterCommit, fn)
+	state.mu.Unlock()
+	return true
 }
 
 func newRepositories(db *gorm.DB) *appport.Repositories {
@@ -32,9 +74,14 @@ func newRepositories(db *gorm.DB) *appport.Repositories {
 		OperatorVersions:     repo.NewOperatorVersionRepo(db),
 		OperatorTemplates:    repo.NewOperatorTemplateRepo(db),
 		OperatorDependencies: repo.NewOperatorDependencyRepo(db),
+		OperatorSlots:        repo.NewOperatorSlotRepo(db),
 		Workflows:   repo.NewWorkflowRepo(db),
+		WorkflowRevisions: repo.NewWorkflowRevisionRepo(db),
+		WorkflowWebhooks:  repo.NewWebhookRepo(db),
 		Tasks:       repo.NewTaskRepo(db),
 		Artifacts:   repo.NewArtifactRepo(db),
+		TaskCheckpoints: repo.NewTaskCheckpointRepo(db),
+		NodeCache:       repo.NewNodeCacheRepo(db),
 		Users:       repo.NewUserRepo(db),
 		Roles:       repo.NewRoleRepo(db),
 		Permissions: repo.NewPermissionRepo(db),
@@ -43,6 +90,8 @@ func newRepositories(db *gorm.DB) *appport.Repositories {
 		AIModels:       repo.NewAIModelRepo(db),
 		UserIdentities: repo.NewUserIdentityRepo(db),
 		UserAssets:     repo.NewUserAssetRepo(db),
+		NotificationSubscriptions: repo.NewNotificationSubscriptionRepo(db),
+		NotificationDeliveries:    repo.NewNotificationDeliveryRepo(db),
 	}
 }
 
@@ -32,9 +32,18 @@ type WorkflowEngine interface {
 	// Execute 执行工作流
 	Execute(ctx context.Context, workflow *workflow.Workflow, task *workflow.Task) error
 
+	// Resume 从检查点恢复被中断的工作流执行
+	Resume(ctx context.Context, workflow *workflow.Workflow, task *workflow.Task) error
+
 	// Cancel 取消工作流执行
 	Cancel(ctx context.Context, taskID uuid.UUID) error
 
+	// Abandon 放弃本进程中租约已丢失的工作流执行，不写回任务状态、不执行清理
+	Abandon(ctx context.Context, taskID uuid.UUID) error
+
+	// Cleanup 为未在执行中被取消或超时的任务（如等待审批时被取消）执行补偿与收尾节点
+	Cleanup(ctx context.Context, workflow *workflow.Workflow, task *workflow.Task) error
+
 	// GetProgress 获取工作流执行进度
 	GetProgress(ctx context.Context, taskID uuid.UUID) (int, error)
 }
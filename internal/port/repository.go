@@ -101,6 +101,7 @@ type Repository interface {
 	UpdateWorkflow(ctx context.Context, w *workflow.Workflow) error
 	DeleteWorkflow(ctx context.Context, id uuid.UUID) error
 	ListEnabledWorkflows(ctx context.Context) ([]*workflow.Workflow, error)
+	UpdateWorkflowRunTimes(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt *time.Time) error
 
 	// WorkflowNode
 	CreateWorkflowNode(ctx context.Context, n *workflow.Node) error
@@ -121,6 +122,30 @@ type Repository interface {
 	DeleteTask(ctx context.Context, id uuid.UUID) error
 	GetTaskStats(ctx context.Context, workflowID *uuid.UUID) (*workflow.TaskStats, error)
 	ListRunningTasks(ctx context.Context) ([]*workflow.Task, error)
+	ListWaitingTasks(ctx context.Context) ([]*workflow.Task, error)
+	// ListPendingTasks 列出等待调度的顶层任务，limits 非 nil 时排除已达到并发上限的租户与工作流
+	ListPendingTasks(ctx context.Context, limit int, limits *workflow.ClaimLimits) ([]*workflow.Task, error)
+	ListUnfinishedTasks(ctx context.Context) ([]*workflow.Task, error)
+	// ClaimTasks 认领 pending 任务，limits 非 nil 时按所有执行方合计的并发上限认领
+	ClaimTasks(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration, limits *workflow.ClaimLimits) ([]*workflow.Task, error)
+	// CountRunningTasks 统计所有执行方中运行中的顶层任务
+	CountRunningTasks(ctx context.Context) (*workflow.RunningTaskCounts, error)
+	RenewTaskLeases(ctx context.Context, ids []uuid.UUID, owner string, ttl time.Duration) ([]uuid.UUID, error)
+	// UpdateTaskFenced 在任务未被取消、未判定超时且（owner 非空时）仍由 owner 持有租约时更新任务，
+	// 否则返回 workflow.ErrTaskStopped 或 workflow.ErrLeaseLost
+	UpdateTaskFenced(ctx context.Context, t *workflow.Task, owner string) error
+	ReclaimExpiredTasks(ctx context.Context) (int64, error)
+
+	// LeaderLease 多副本间的领导者租约（如定时调度），返回 holder 是否持有租约
+	TryAcquireLeaderLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// ScheduledRun 待执行的定时触发（overlap 为 queue 时排队的触发与需要补跑的触发）
+	QueueScheduledRun(ctx context.Context, run *workflow.ScheduledRun) error
+	RecordMissedRuns(ctx context.Context, wf *workflow.Workflow, now time.Time, next *time.Time, runs []time.Time) (bool, error)
+	ListScheduledRuns(ctx context.Context) ([]*workflow.ScheduledRun, error)
+	StartScheduledRun(ctx context.Context, id uuid.UUID, task *workflow.Task) (bool, error)
+	DeleteScheduledRun(ctx context.Context, id uuid.UUID) error
+	DeleteScheduledRunsByWorkflow(ctx context.Context, workflowID uuid.UUID) error
 
 	// Artifact
 	CreateArtifact(ctx context.Context, a *workflow.Artifact) error
@@ -0,0 +1,477 @@
+// Package expr 实现一个用于工作流条件分支的沙箱表达式语言。
+//
+// 表达式只能读取传入的变量环境，不能调用任意函数、访问文件或网络，
+// 也不存在循环结构，因此求值时间与表达式长度成正比。
+//
+// 支持的语法：
+//   - 字面量：数字、'字符串'/"字符串"、true、false、null
+//   - 变量与取值：results[0].confidence、diagnostics["fps"]
+//   - 运算符：|| && ! == != < <= > >= + - * / %
+//   - 内置函数：len(x)、contains(x, y)
+//
+// 访问不存在的字段或越界下标返回 null，null 参与大小比较时结果为 false，
+// 便于编写 `results[0].confidence > 0.8` 这类在结果为空时自然不成立的条件。
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Program 编译后的表达式，可并发安全地重复求值
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile 解析并校验表达式
+func Compile(source string) (*Program, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	root, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("compile expression %q: %w", source, err)
+	}
+	return &Program{source: source, root: root}, nil
+}
+
+// Source 返回原始表达式
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Eval 在给定变量环境下求值
+func (p *Program) Eval(env map[string]interface{}) (interface{}, error) {
+	v, err := eval(p.root, env)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate expression %q: %w", p.source, err)
+	}
+	return v, nil
+}
+
+// EvalBool 求值并要求结果为布尔值，null 视为 false
+func (p *Program) EvalBool(env map[string]interface{}) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, err := truthy(v)
+	if err != nil {
+		return false, fmt.Errorf("evaluate expression %q: %w", p.source, err)
+	}
+	return b, nil
+}
+
+// Variables 返回表达式引用的顶层变量名（按首次出现顺序去重）
+func (p *Program) Variables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *identNode:
+			if !seen[n.name] {
+				seen[n.name] = true
+				names = append(names, n.name)
+			}
+		case *memberNode:
+			walk(n.object)
+		case *indexNode:
+			walk(n.object)
+			walk(n.index)
+		case *callNode:
+			for _, a := range n.args {
+				walk(a)
+			}
+		case *unaryNode:
+			walk(n.operand)
+		case *binaryNode:
+			walk(n.left)
+			walk(n.right)
+		}
+	}
+	walk(p.root)
+	return names
+}
+
+// PathSegment 变量路径中的一段：字段名或列表下标
+type PathSegment struct {
+	Field   string
+	Index   int
+	IsIndex bool
+}
+
+// Path 当表达式是纯变量路径（如 detect.results[0].data.bbox）时，返回根变量与后续路径；
+// 下标只支持数字与字符串字面量
+func (p *Program) Path() (root string, segments []PathSegment, ok bool) {
+	n := p.root
+	for {
+		switch cur := n.(type) {
+		case *identNode:
+			for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+				segments[i], segments[j] = segments[j], segments[i]
+			}
+			return cur.name, segments, true
+		case *memberNode:
+			segments = append(segments, PathSegment{Field: cur.name})
+			n = cur.object
+		case *indexNode:
+			lit, isLit := cur.index.(*literalNode)
+			if !isLit {
+				return "", nil, false
+			}
+			switch v := lit.value.(type) {
+			case float64:
+				segments = append(segments, PathSegment{Index: int(v), IsIndex: true})
+			case string:
+				segments = append(segments, PathSegment{Field: v})
+			default:
+				return "", nil, false
+			}
+			n = cur.object
+		default:
+			return "", nil, false
+		}
+	}
+}
+
+type builtin struct {
+	arity int
+	fn    func(args []interface{}) (interface{}, error)
+}
+
+var builtins = map[string]builtin{
+	"len":      {arity: 1, fn: builtinLen},
+	"contains": {arity: 2, fn: builtinContains},
+}
+
+func eval(n node, env map[string]interface{}) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *identNode:
+		return normalize(env[n.name]), nil
+	case *memberNode:
+		obj, err := eval(n.object, env)
+		if err != nil {
+			return nil, err
+		}
+		return member(obj, n.name)
+	case *indexNode:
+		obj, err := eval(n.object, env)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := eval(n.index, env)
+		if err != nil {
+			return nil, err
+		}
+		return index(obj, idx)
+	case *callNode:
+		args := make([]interface{}, len(n.args))
+		for i, a := range n.args {
+			v, err := eval(a, env)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return builtins[n.name].fn(args)
+	case *unaryNode:
+		v, err := eval(n.operand, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, err := truthy(v)
+			if err != nil {
+				return nil, err
+			}
+			return !b, nil
+		}
+		f, ok := toNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("operator - expects number, got %s", typeName(v))
+		}
+		return -f, nil
+	case *binaryNode:
+		return evalBinary(n, env)
+	}
+	return nil, fmt.Errorf("unsupported expression node %T", n)
+}
+
+func evalBinary(n *binaryNode, env map[string]interface{}) (interface{}, error) {
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	if n.op == "&&" || n.op == "||" {
+		lb, err := truthy(left)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "&&" && !lb {
+			return false, nil
+		}
+		if n.op == "||" && lb {
+			return true, nil
+		}
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right)
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "+":
+		if ls, ok := left.(string); ok {
+			if rs, ok := right.(string); ok {
+				return ls + rs, nil
+			}
+		}
+	}
+
+	lf, lok := toNumber(left)
+	rf, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s expects numbers, got %s and %s", n.op, typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", n.op)
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %s", typeName(right))
+		}
+		c := strings.Compare(ls, rs)
+		return compareResult(op, float64(c), 0), nil
+	}
+	lf, lok := toNumber(left)
+	rf, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+	}
+	return compareResult(op, lf, rf), nil
+}
+
+func compareResult(op string, l, r float64) bool {
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
+
+func equal(left, right interface{}) bool {
+	if lf, ok := toNumber(left); ok {
+		if rf, ok := toNumber(right); ok {
+			return lf == rf
+		}
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func member(obj interface{}, name string) (interface{}, error) {
+	switch o := obj.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return normalize(o[name]), nil
+	}
+	return nil, fmt.Errorf("cannot access field %q on %s", name, typeName(obj))
+}
+
+func index(obj, idx interface{}) (interface{}, error) {
+	switch o := obj.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		key, ok := idx.(string)
+		if !ok {
+			return nil, fmt.Errorf("map index must be string, got %s", typeName(idx))
+		}
+		return normalize(o[key]), nil
+	case []interface{}:
+		f, ok := toNumber(idx)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("list index must be integer, got %s", typeName(idx))
+		}
+		i := int(f)
+		if i < 0 || i >= len(o) {
+			return nil, nil
+		}
+		return normalize(o[i]), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(obj))
+}
+
+func builtinLen(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(len([]rune(v))), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("len expects string, list or map, got %s", typeName(args[0]))
+}
+
+func builtinContains(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return false, nil
+	case string:
+		s, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains on string expects string, got %s", typeName(args[1]))
+		}
+		return strings.Contains(v, s), nil
+	case []interface{}:
+		for _, item := range v {
+			if equal(normalize(item), args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains on map expects string key, got %s", typeName(args[1]))
+		}
+		_, exists := v[key]
+		return exists, nil
+	}
+	return nil, fmt.Errorf("contains expects string, list or map, got %s", typeName(args[0]))
+}
+
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("expected boolean, got %s", typeName(v))
+}
+
+// normalize 将 Go 原生数值、切片与映射统一为 float64、[]interface{} 与 map[string]interface{}
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, string, float64, []interface{}, map[string]interface{}:
+		return v
+	case []map[string]interface{}:
+		out := make([]interface{}, len(x))
+		for i := range x {
+			out[i] = x[i]
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(x))
+		for i := range x {
+			out[i] = x[i]
+		}
+		return out
+	}
+	if f, ok := toNumber(v); ok {
+		return f
+	}
+	return v
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,141 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func testEnv() map[string]interface{} {
+	return map[string]interface{}{
+		"results": []interface{}{
+			map[string]interface{}{"type": "detection", "confidence": 0.92, "data": map[string]interface{}{"label": "person"}},
+			map[string]interface{}{"type": "detection", "confidence": 0.41},
+		},
+		"timeline":    []interface{}{},
+		"diagnostics": map[string]interface{}{"fps": 25, "codec": "h264"},
+		"tags":        []string{"night", "outdoor"},
+		"value":       "ok",
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"results[0].confidence > 0.8", true},
+		{"results[1].confidence > 0.8", false},
+		{"results[5].confidence > 0.8", false},
+		{"len(timeline) > 0", false},
+		{"len(results) == 2", true},
+		{"diagnostics.fps >= 25 && diagnostics[\"codec\"] == 'h264'", true},
+		{"contains(tags, 'night')", true},
+		{"contains(results[0].data.label, 'per')", true},
+		{"!contains(diagnostics, 'bitrate')", true},
+		{"missing == null", true},
+		{"missing.field == nil", true},
+		{"value == 'ok' || results[0].bogus > 1", true},
+		{"(1 + 2) * 3 == 9 && 7 % 4 == 3 && -1 < 0", true},
+		{"'a' + 'b' == 'ab'", true},
+		{"'abc' < 'abd'", true},
+		{"missing", false},
+	}
+	env := testEnv()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			got, err := p.EvalBool(env)
+			if err != nil {
+				t.Fatalf("eval: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", "   "},
+		{"unknown function", "exec('rm -rf /')"},
+		{"wrong arity", "len(results, timeline)"},
+		{"trailing token", "a b"},
+		{"unterminated string", "value == 'ok"},
+		{"unexpected character", "a = 1"},
+		{"unclosed bracket", "results[0"},
+		{"too long", strings.Repeat("a", MaxLength+1)},
+		{"too deep", strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.expr); err == nil {
+				t.Errorf("expected compile error for %q", tt.expr)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []string{
+		"results > 1",
+		"value + 1 > 0",
+		"1 / 0 > 0",
+		"value && true",
+		"diagnostics.fps",
+		"value.field == 1",
+		"results['a'] == 1",
+	}
+	env := testEnv()
+	for _, src := range tests {
+		t.Run(src, func(t *testing.T) {
+			p, err := Compile(src)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if _, err := p.EvalBool(env); err == nil {
+				t.Errorf("expected eval error for %q", src)
+			}
+		})
+	}
+}
+
+func TestVariablesAndPath(t *testing.T) {
+	p, err := Compile(`detect.results[0].data["bbox"]`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if vars := p.Variables(); len(vars) != 1 || vars[0] != "detect" {
+		t.Errorf("variables: got %v", vars)
+	}
+	root, segs, ok := p.Path()
+	if !ok || root != "detect" {
+		t.Fatalf("path: got root %q ok %v", root, ok)
+	}
+	want := []PathSegment{{Field: "results"}, {Index: 0, IsIndex: true}, {Field: "data"}, {Field: "bbox"}}
+	if len(segs) != len(want) {
+		t.Fatalf("segments: got %v, want %v", segs, want)
+	}
+	for i := range want {
+		if segs[i] != want[i] {
+			t.Errorf("segment %d: got %v, want %v", i, segs[i], want[i])
+		}
+	}
+
+	p, err = Compile("len(a.items) > b")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if vars := p.Variables(); len(vars) != 2 || vars[0] != "a" || vars[1] != "b" {
+		t.Errorf("variables: got %v", vars)
+	}
+	if _, _, ok := p.Path(); ok {
+		t.Error("expected non-path expression")
+	}
+}